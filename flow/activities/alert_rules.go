@@ -0,0 +1,186 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/alerting"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+type scopedAlertRule struct {
+	mirrorName string
+	peerName   string
+	rule       alerting.AlertRule
+	window     time.Duration
+}
+
+func (r *scopedAlertRule) appliesTo(config *protos.FlowConnectionConfigs) bool {
+	return (r.mirrorName == "" || r.mirrorName == config.FlowJobName) &&
+		(r.peerName == "" || r.peerName == config.SourceName || r.peerName == config.DestinationName)
+}
+
+// EvaluateAlertRules checks metrics of CDC mirrors against the enabled alert rules scoped to them
+func (a *FlowableActivity) EvaluateAlertRules(ctx context.Context) error {
+	rows, err := a.CatalogPool.Query(ctx,
+		`SELECT id,name,coalesce(mirror_name,''),coalesce(peer_name,''),metric,threshold,window_minutes
+		FROM peerdb_stats.alert_rules WHERE enabled`)
+	if err != nil {
+		return fmt.Errorf("failed to read alert rules from catalog: %w", err)
+	}
+	rules, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*scopedAlertRule, error) {
+		var rule scopedAlertRule
+		var windowMinutes int32
+		if err := row.Scan(&rule.rule.ID, &rule.rule.Name, &rule.mirrorName, &rule.peerName, &rule.rule.Metric,
+			&rule.rule.Threshold, &windowMinutes); err != nil {
+			return nil, err
+		}
+		rule.window = time.Duration(max(windowMinutes, 1)) * time.Minute
+		return &rule, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read alert rules from catalog: %w", err)
+	} else if len(rules) == 0 {
+		return nil
+	}
+
+	rows, err = a.CatalogPool.Query(ctx, "SELECT DISTINCT ON (name) name, config_proto, workflow_id FROM flows WHERE query_string IS NULL")
+	if err != nil {
+		return err
+	}
+	infos, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*flowInformation, error) {
+		var flowName string
+		var configProto []byte
+		var workflowID string
+		if err := row.Scan(&flowName, &configProto, &workflowID); err != nil {
+			return nil, err
+		}
+
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configProto, &config); err != nil {
+			return nil, err
+		}
+
+		return &flowInformation{
+			config:     &config,
+			workflowID: workflowID,
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger := internal.LoggerFromCtx(ctx)
+	for _, info := range infos {
+		activity.RecordHeartbeat(ctx, "evaluating alert rules for "+info.config.FlowJobName)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		status, err := internal.GetWorkflowStatus(ctx, a.CatalogPool, a.TemporalClient, info.workflowID)
+		if err != nil {
+			logger.Warn("failed to get workflow status", slog.String("flowName", info.config.FlowJobName), slog.Any("error", err))
+			continue
+		}
+		info.status = status
+		if _, info.isActive = activeFlowStatuses[status]; !info.isActive && status != protos.FlowStatus_STATUS_PAUSED {
+			continue
+		}
+
+		for _, rule := range rules {
+			if !rule.appliesTo(info.config) {
+				continue
+			}
+			value, ok, err := a.alertRuleMetric(ctx, info, rule)
+			if err != nil {
+				logger.Warn("failed to get metric for alert rule", slog.String("flowName", info.config.FlowJobName),
+					slog.String("rule", rule.rule.Name), slog.Any("error", err))
+				continue
+			} else if !ok {
+				continue
+			}
+			a.Alerter.AlertIfRuleBreached(ctx, &alerting.AlertKeys{
+				FlowName: info.config.FlowJobName,
+				PeerName: info.config.SourceName,
+			}, rule.rule, value)
+		}
+	}
+	return nil
+}
+
+// alertRuleMetric returns false when the metric does not apply to the mirror in its current state
+func (a *FlowableActivity) alertRuleMetric(ctx context.Context, info *flowInformation, rule *scopedAlertRule) (float64, bool, error) {
+	switch rule.rule.Metric {
+	case alerting.AlertRuleMetricSlotLagMB:
+		slotName := "peerflow_slot_" + info.config.FlowJobName
+		if info.config.ReplicationSlotName != "" {
+			slotName = info.config.ReplicationSlotName
+		}
+		var slotSize int64
+		if err := a.CatalogPool.QueryRow(ctx,
+			`SELECT slot_size FROM peerdb_stats.peer_slot_size WHERE slot_name=$1 AND peer_name=$2 ORDER BY id DESC LIMIT 1`,
+			slotName, info.config.SourceName,
+		).Scan(&slotSize); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return 0, false, nil
+			}
+			return 0, false, err
+		}
+		return float64(slotSize) / 1024 / 1024, true, nil
+	case alerting.AlertRuleMetricLagSeconds:
+		// lag is only reported for batches applied while running
+		if info.status != protos.FlowStatus_STATUS_RUNNING {
+			return 0, false, nil
+		}
+		res, err := a.TemporalClient.QueryWorkflow(ctx, info.workflowID, "", shared.CDCTableLagQuery)
+		if err != nil {
+			return 0, false, err
+		}
+		var tableLags map[string]*protos.TableReplicationLag
+		if err := res.Get(&tableLags); err != nil {
+			return 0, false, err
+		} else if len(tableLags) == 0 {
+			return 0, false, nil
+		}
+		var lagSeconds float64
+		for _, tableLag := range tableLags {
+			lagSeconds = max(lagSeconds, tableLag.LagSeconds)
+		}
+		return lagSeconds, true, nil
+	case alerting.AlertRuleMetricErrorRate:
+		var errorCount int64
+		if err := a.CatalogPool.QueryRow(ctx,
+			`SELECT count(*) FROM peerdb_stats.flow_errors
+			WHERE flow_name=$1 AND error_type='error' AND error_timestamp > now() - make_interval(secs => $2)`,
+			info.config.FlowJobName, rule.window.Seconds(),
+		).Scan(&errorCount); err != nil {
+			return 0, false, err
+		}
+		return float64(errorCount) / rule.window.Minutes(), true, nil
+	case alerting.AlertRuleMetricRowsPerSecond:
+		// paused mirrors trivially sync nothing
+		if info.status != protos.FlowStatus_STATUS_RUNNING {
+			return 0, false, nil
+		}
+		var rowCount int64
+		if err := a.CatalogPool.QueryRow(ctx,
+			`SELECT coalesce(sum(rows_in_batch),0) FROM peerdb_stats.cdc_batches
+			WHERE flow_name=$1 AND end_time > now() - make_interval(secs => $2)`,
+			info.config.FlowJobName, rule.window.Seconds(),
+		).Scan(&rowCount); err != nil {
+			return 0, false, err
+		}
+		return float64(rowCount) / rule.window.Seconds(), true, nil
+	default:
+		return 0, false, fmt.Errorf("unknown alert rule metric %s", rule.rule.Metric)
+	}
+}
@@ -0,0 +1,48 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// waitWhileBackfillPaused blocks between partitions while the backfill of the mirror is paused,
+// pausing is checked per partition so a partition in progress always completes
+func (a *FlowableActivity) waitWhileBackfillPaused(ctx context.Context, config *protos.QRepConfig) error {
+	mirrorName := qrepMirrorName(config)
+
+	logged := false
+	for {
+		var paused bool
+		if err := a.CatalogPool.QueryRow(ctx,
+			"SELECT coalesce(bool_or(backfill_paused), false) FROM flows WHERE name = $1", mirrorName,
+		).Scan(&paused); err != nil {
+			return fmt.Errorf("failed to check if backfill of %s is paused: %w", mirrorName, err)
+		} else if !paused {
+			if logged {
+				a.Alerter.LogFlowInfo(ctx, config.FlowJobName, "backfill resumed for table "+config.DestinationTableIdentifier)
+			}
+			return nil
+		}
+
+		if !logged {
+			a.Alerter.LogFlowInfo(ctx, config.FlowJobName, "backfill paused for table "+config.DestinationTableIdentifier)
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(30 * time.Second):
+		}
+	}
+}
+
+// qrepMirrorName is the mirror a QRep flow belongs to, snapshot clones belong to their CDC mirror
+func qrepMirrorName(config *protos.QRepConfig) string {
+	if config.ParentMirrorName != "" {
+		return config.ParentMirrorName
+	}
+	return config.FlowJobName
+}
@@ -0,0 +1,146 @@
+package activities
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// CreateCutoverReport records row counts, checksums, the final source position and sequence values of a mirror,
+// signed with the current encryption key so the report can be handed over as proof of a completed migration
+func (a *FlowableActivity) CreateCutoverReport(
+	ctx context.Context,
+	input *protos.CutoverReportInput,
+) (*protos.CutoverReportOutput, error) {
+	cfg := input.FlowConnectionConfigs
+	ctx = context.WithValue(ctx, shared.FlowNameKey, input.FlowJobName)
+	logger := log.With(internal.LoggerFromCtx(ctx), slog.String(string(shared.FlowNameKey), input.FlowJobName))
+
+	report := &protos.CutoverReport{
+		FlowJobName:     input.FlowJobName,
+		SourcePeer:      cfg.SourceName,
+		DestinationPeer: cfg.DestinationName,
+		StartedAt:       timestamppb.Now(),
+	}
+	var tablesDone atomic.Int32
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return fmt.Sprintf("creating cutover report, %d of %d tables done", tablesDone.Load(), len(cfg.TableMappings))
+	})
+	defer shutdown()
+
+	if err := a.CatalogPool.QueryRow(ctx,
+		"SELECT last_offset, last_text, sync_batch_id, coalesce(normalize_batch_id, 0) FROM metadata_last_sync_state WHERE job_name = $1",
+		input.FlowJobName,
+	).Scan(&report.FinalOffset, &report.FinalOffsetText, &report.SyncBatchId, &report.NormalizeBatchId); err != nil &&
+		!errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get last sync state: %w", err)
+	}
+
+	srcConn, err := connectors.GetByNameAs[connectors.CutoverReportConnector](ctx, cfg.Env, a.CatalogPool, cfg.SourceName)
+	if errors.Is(err, errors.ErrUnsupported) {
+		logger.Info("source does not support cutover reports, leaving out source counts")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get source connector: %w", err)
+	} else {
+		defer connectors.CloseConnector(ctx, srcConn)
+	}
+	dstConn, err := connectors.GetByNameAs[connectors.CutoverReportConnector](ctx, cfg.Env, a.CatalogPool, cfg.DestinationName)
+	if errors.Is(err, errors.ErrUnsupported) {
+		logger.Info("destination does not support cutover reports, leaving out destination counts")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get destination connector: %w", err)
+	} else {
+		defer connectors.CloseConnector(ctx, dstConn)
+	}
+
+	sourceTables := make([]string, 0, len(cfg.TableMappings))
+	for _, tm := range cfg.TableMappings {
+		schema, err := internal.LoadTableSchemaFromCatalog(ctx, a.CatalogPool, input.FlowJobName, tm.DestinationTableIdentifier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schema of %s: %w", tm.DestinationTableIdentifier, err)
+		}
+		sourceColumns := make([]string, 0, len(schema.Columns))
+		destinationColumns := make([]string, 0, len(schema.Columns))
+		for _, column := range schema.Columns {
+			sourceColumns = append(sourceColumns, column.Name)
+			destinationColumn := column.Name
+			for _, col := range tm.Columns {
+				if col.SourceName == column.Name && col.DestinationName != "" {
+					destinationColumn = col.DestinationName
+				}
+			}
+			destinationColumns = append(destinationColumns, destinationColumn)
+		}
+
+		tableReport := &protos.CutoverTableReport{
+			SourceTableIdentifier:      tm.SourceTableIdentifier,
+			DestinationTableIdentifier: tm.DestinationTableIdentifier,
+		}
+		if srcConn != nil {
+			if tableReport.SourceRowCount, tableReport.SourceChecksum, err = srcConn.TableRowCountAndChecksum(
+				ctx, tm.SourceTableIdentifier, sourceColumns, "",
+			); err != nil {
+				return nil, err
+			}
+		}
+		if dstConn != nil {
+			if tableReport.DestinationRowCount, tableReport.DestinationChecksum, err = dstConn.TableRowCountAndChecksum(
+				ctx, tm.DestinationTableIdentifier, destinationColumns, cfg.SoftDeleteColName,
+			); err != nil {
+				return nil, err
+			}
+		}
+		if tableReport.SourceRowCount != tableReport.DestinationRowCount {
+			logger.Warn("row counts differ at cutover", slog.String("table", tm.DestinationTableIdentifier),
+				slog.Int64("source", tableReport.SourceRowCount), slog.Int64("destination", tableReport.DestinationRowCount))
+		}
+		report.Tables = append(report.Tables, tableReport)
+		sourceTables = append(sourceTables, tm.SourceTableIdentifier)
+		tablesDone.Add(1)
+	}
+
+	if pgConn, ok := srcConn.(*connpostgres.PostgresConnector); ok {
+		if report.Sequences, err = pgConn.GetOwnedSequences(ctx, sourceTables); err != nil {
+			return nil, err
+		}
+	}
+	report.FinishedAt = timestamppb.Now()
+
+	reportJSON, err := protojson.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize cutover report: %w", err)
+	}
+	encKey, err := internal.PeerDBCurrentEncKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key: %w", err)
+	}
+	signature, err := encKey.Sign(reportJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign cutover report: %w", err)
+	}
+
+	var reportID int64
+	if err := a.CatalogPool.QueryRow(ctx,
+		"INSERT INTO cutover_reports (flow_name, report_json, signature, key_id) VALUES ($1, $2, $3, $4) RETURNING id",
+		input.FlowJobName, string(reportJSON), hex.EncodeToString(signature), encKey.ID,
+	).Scan(&reportID); err != nil {
+		return nil, fmt.Errorf("failed to store cutover report: %w", err)
+	}
+
+	a.Alerter.LogFlowInfo(ctx, input.FlowJobName, fmt.Sprintf("created cutover report %d for %d tables", reportID, len(report.Tables)))
+	return &protos.CutoverReportOutput{ReportId: reportID}, nil
+}
@@ -34,6 +34,8 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/pua"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+	"github.com/PeerDB-io/peerdb/flow/wasm"
 )
 
 type CheckMetadataTablesResult struct {
@@ -41,8 +43,10 @@ type CheckMetadataTablesResult struct {
 }
 
 type NormalizeBatchRequest struct {
-	Done    chan struct{}
-	BatchID int64
+	Done chan struct{}
+	// destination table -> source commit time (UnixNano) of its oldest change up to BatchID
+	TableCommitTimes map[string]int64
+	BatchID          int64
 }
 
 type FlowableActivity struct {
@@ -181,7 +185,11 @@ func (a *FlowableActivity) SetupTableSchema(
 	if err != nil {
 		return a.Alerter.LogFlowError(ctx, config.FlowName, fmt.Errorf("failed to get GetTableSchemaConnector: %w", err))
 	}
-	processed := internal.BuildProcessedSchemaMapping(config.TableMappings, tableNameSchemaMapping, logger)
+	zones, err := internal.TimestampZonesFromEnv(ctx, config.Env)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowName, err)
+	}
+	processed := internal.BuildProcessedSchemaMapping(config.TableMappings, tableNameSchemaMapping, zones, logger)
 
 	tx, err := a.CatalogPool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
@@ -297,7 +305,7 @@ func (a *FlowableActivity) SyncFlow(
 	var syncingBatchID atomic.Int64
 	var syncState atomic.Pointer[string]
 	syncState.Store(shared.Ptr("setup"))
-	shutdown := heartbeatRoutine(ctx, func() string {
+	heartbeatMessage := func() string {
 		// Must load Waiting after BatchID to avoid race saying we're waiting on currently processing batch
 		sBatchID := syncingBatchID.Load()
 		nBatchID := normalizingBatchID.Load()
@@ -310,7 +318,8 @@ func (a *FlowableActivity) SyncFlow(
 			currentSyncFlowNum.Load(), totalRecordsSynced.Load(),
 			sBatchID, *syncState.Load(), nBatchID, nWaiting,
 		)
-	})
+	}
+	shutdown := heartbeatRoutine(ctx, heartbeatMessage)
 	defer shutdown()
 
 	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
@@ -318,6 +327,12 @@ func (a *FlowableActivity) SyncFlow(
 	ctx = internal.WithOperationContext(ctx, protos.FlowOperation_FLOW_OPERATION_SYNC)
 	logger := internal.LoggerFromCtx(ctx)
 
+	releaseSourceConnection, err := a.acquireSourceConnection(ctx, config.FlowJobName, config.MaxSourceConnections)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	}
+	defer releaseSourceConnection()
+
 	srcConn, err := connectors.GetByNameAs[connectors.CDCPullConnectorCore](ctx, config.Env, a.CatalogPool, config.SourceName)
 	if err != nil {
 		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
@@ -340,6 +355,18 @@ func (a *FlowableActivity) SyncFlow(
 		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 	}
 
+	backpressurePending, err := internal.PeerDBCDCBackpressurePendingNormalize(ctx, config.Env)
+	if err != nil {
+		connectors.CloseConnector(ctx, srcConn)
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	}
+	// batches queued for normalize plus the one being normalized, sync blocks on the channel past that
+	if maxPending := normalizeBufferSize + 1; backpressurePending > maxPending {
+		logger.Warn("PEERDB_CDC_BACKPRESSURE_PENDING_NORMALIZE above what can wait on normalize, lowering it",
+			slog.Int("backpressurePending", backpressurePending), slog.Int("maxPending", maxPending))
+		backpressurePending = maxPending
+	}
+
 	// syncDone will be closed by SyncFlow,
 	// whereas normalizeDone will be closed by normalizing goroutine
 	// Wait on normalizeDone at end to not interrupt final normalize
@@ -361,17 +388,41 @@ func (a *FlowableActivity) SyncFlow(
 		return nil
 	})
 
+	pendingNormalize := func() int {
+		// Must load Waiting after len to not miss a batch being dequeued
+		pending := len(normRequests)
+		if !normalizeWaiting.Load() {
+			pending++
+		}
+		return pending
+	}
+
+	var draining bool
 	for groupCtx.Err() == nil {
+		if backpressurePending > 0 {
+			waitForNormalize(groupCtx, logger, backpressurePending, pendingNormalize, &syncState)
+		}
+		batchOptions, quotaErr := a.applyCDCQuota(groupCtx, config.FlowJobName, options, &syncState)
+		if quotaErr != nil {
+			if groupCtx.Err() != nil {
+				break
+			}
+			// syncing without the quota could go past its limits, fail so the activity is retried
+			logger.Error("failed to check mirror quota", slog.Any("error", quotaErr))
+			syncState.Store(shared.Ptr("cleanup"))
+			close(syncDone)
+			return errors.Join(a.Alerter.LogFlowError(ctx, config.FlowJobName, quotaErr), group.Wait())
+		}
 		syncNum := currentSyncFlowNum.Add(1)
 		logger.Info("executing sync flow", slog.Int64("count", int64(syncNum)))
 
 		var syncResponse *model.SyncResponse
 		var syncErr error
 		if config.System == protos.TypeSystem_Q {
-			syncResponse, syncErr = a.syncRecords(groupCtx, config, options, srcConn.(connectors.CDCPullConnector),
+			syncResponse, syncErr = a.syncRecords(groupCtx, config, batchOptions, srcConn.(connectors.CDCPullConnector),
 				normRequests, &syncingBatchID, &syncState)
 		} else {
-			syncResponse, syncErr = a.syncPg(groupCtx, config, options, srcConn.(connectors.CDCPullPgConnector),
+			syncResponse, syncErr = a.syncPg(groupCtx, config, batchOptions, srcConn.(connectors.CDCPullPgConnector),
 				normRequests, &syncingBatchID, &syncState)
 		}
 
@@ -384,7 +435,11 @@ func (a *FlowableActivity) SyncFlow(
 			syncState.Store(shared.Ptr("cleanup"))
 			close(syncDone)
 			return errors.Join(syncErr, group.Wait())
-		} else if syncResponse != nil {
+		}
+		if err := monitoring.UpdateLastSyncForCDCFlow(ctx, a.CatalogPool, config.FlowJobName); err != nil {
+			logger.Warn("failed to record last sync", slog.Any("error", err))
+		}
+		if syncResponse != nil {
 			totalRecordsSynced.Add(syncResponse.NumRecordsSynced)
 			logger.Info("synced records", slog.Int64("numRecordsSynced", syncResponse.NumRecordsSynced),
 				slog.Int64("totalRecordsSynced", totalRecordsSynced.Load()))
@@ -396,6 +451,12 @@ func (a *FlowableActivity) SyncFlow(
 		if (options.NumberOfSyncs > 0 && syncNum >= options.NumberOfSyncs) || (reconnectAfterBatches > 0 && syncNum >= reconnectAfterBatches) {
 			break
 		}
+		if workerStopping(ctx) {
+			// stop between batches so the staged batch gets normalized below, the workflow picks up on another worker
+			logger.Info("worker stopping, draining sync", slog.Int64("syncingBatchID", syncingBatchID.Load()))
+			draining = true
+			break
+		}
 	}
 
 	syncState.Store(shared.Ptr("cleanup"))
@@ -408,6 +469,11 @@ func (a *FlowableActivity) SyncFlow(
 		logger.Error("sync failed", slog.Any("error", waitErr))
 		return waitErr
 	}
+	if draining {
+		syncState.Store(shared.Ptr("drained"))
+		activity.RecordHeartbeat(ctx, "final heartbeat: "+heartbeatMessage())
+		logger.Info("sync drained", slog.Int64("normalizingBatchID", normalizingBatchID.Load()))
+	}
 	return nil
 }
 
@@ -420,12 +486,48 @@ func (a *FlowableActivity) syncRecords(
 	syncingBatchID *atomic.Int64,
 	syncWaiting *atomic.Pointer[string],
 ) (*model.SyncResponse, error) {
+	// json path columns and timestamp zones are only part of destination schemas with the q type system
+	var jsonPathExtractors map[string]*model.JSONPathExtractor
+	var zones *types.TimestampZones
+	if config.System == protos.TypeSystem_Q {
+		var err error
+		if jsonPathExtractors, err = utils.NewJSONPathExtractors(options.TableMappings); err != nil {
+			return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+		}
+		if zones, err = internal.TimestampZonesFromEnv(ctx, config.Env); err != nil {
+			return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+		}
+	}
+	script := config.Script
+	isWasm, err := wasm.IsWasmScript(ctx, a.CatalogPool, script)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	} else if isWasm {
+		// destinations only run Lua scripts, WASM scripts transform records before they reach them
+		config = proto.CloneOf(config)
+		config.Script = ""
+	}
 	var adaptStream func(stream *model.CDCStream[model.RecordItems]) (*model.CDCStream[model.RecordItems], error)
-	if config.Script != "" {
+	if script != "" || len(jsonPathExtractors) != 0 || zones != nil {
 		var onErr context.CancelCauseFunc
 		ctx, onErr = context.WithCancelCause(ctx)
 		adaptStream = func(stream *model.CDCStream[model.RecordItems]) (*model.CDCStream[model.RecordItems], error) {
-			ls, err := utils.LoadScript(ctx, config.Script, utils.LuaPrintFn(func(s string) {
+			if len(jsonPathExtractors) != 0 {
+				stream = utils.AttachJSONPathsToCdcStream(ctx, jsonPathExtractors, stream, onErr)
+			}
+			if zones != nil {
+				stream = utils.AttachTimestampZonesToCdcStream(ctx, zones, stream, onErr)
+			}
+			if script == "" {
+				return stream, nil
+			} else if isWasm {
+				module, err := wasm.LoadFromCatalog(ctx, a.CatalogPool, config.Env, script)
+				if err != nil {
+					return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+				}
+				return wasm.AttachToCdcStream(ctx, module, stream, onErr), nil
+			}
+			ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
 				a.Alerter.LogFlowInfo(ctx, config.FlowJobName, s)
 			}))
 			if err != nil {
@@ -435,12 +537,17 @@ func (a *FlowableActivity) syncRecords(
 				return pua.AttachToCdcStream(ctx, ls, fn, stream, onErr), nil
 			} else if fn, ok := ls.Env.RawGetString("transformRow").(*lua.LFunction); ok {
 				return pua.AttachToCdcStream(ctx, ls, ls.NewFunction(func(ls *lua.LState) int {
+					// records are dropped when transformRow returns false for either of their rows
 					ud, _ := pua.LuaRecord.Check(ls, 1)
 					for _, key := range []string{"old", "new"} {
 						if row := ls.GetField(ud, key); row != lua.LNil {
 							ls.Push(fn)
 							ls.Push(row)
-							ls.Call(1, 0)
+							ls.Call(1, 1)
+							if ls.Get(-1) == lua.LFalse {
+								return 1
+							}
+							ls.Pop(1)
 						}
 					}
 					return 0
@@ -551,26 +658,68 @@ func (a *FlowableActivity) ReplicateQRepPartitions(ctx context.Context,
 		return fmt.Errorf("failed to update start time for qrep run: %w", err)
 	}
 
+	script := config.Script
+	isWasm, err := wasm.IsWasmScript(ctx, a.CatalogPool, script)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	} else if isWasm {
+		// destinations only run Lua scripts, WASM scripts transform rows before they reach them
+		config = proto.CloneOf(config)
+		config.Script = ""
+	}
+
 	numPartitions := len(partitions.Partitions)
 	logger.Info("replicating partitions for batch",
 		slog.Int64("batchID", int64(partitions.BatchId)), slog.Int("partitions", numPartitions))
 
-	for _, p := range partitions.Partitions {
+	for idx, p := range partitions.Partitions {
+		if workerStopping(ctx) {
+			// synced partitions are skipped on retry, so the rest of the batch resumes on another worker
+			logger.Info("worker stopping, leaving remaining partitions for retry",
+				slog.Int64("batchID", int64(partitions.BatchId)), slog.Int("replicated", idx), slog.Int("partitions", numPartitions))
+			return errors.New("worker stopping before all partitions were replicated")
+		}
+		if err := a.waitWhileBackfillPaused(ctx, config); err != nil {
+			return err
+		}
 		logger.Info(fmt.Sprintf("batch-%d - replicating partition - %s", partitions.BatchId, p.PartitionId))
-		var err error
+		releaseSourceConnection, err := a.acquireSourceConnection(ctx, qrepMirrorName(config), config.MaxSourceConnections)
+		if err != nil {
+			return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+		}
 		switch config.System {
 		case protos.TypeSystem_Q:
 			stream := model.NewQRecordStream(shared.FetchAndChannelSize)
-			outstream := stream
-			if config.Script != "" {
-				ls, err := utils.LoadScript(ctx, config.Script, utils.LuaPrintFn(func(s string) {
+			outstream := utils.SampleQRepStream(config, stream)
+			if jsonPathExtractor, err := model.NewJSONPathExtractor(config.JsonPathColumns); err != nil {
+				releaseSourceConnection()
+				return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+			} else if jsonPathExtractor != nil {
+				outstream = utils.AttachJSONPathsToQRepStream(jsonPathExtractor, outstream)
+			}
+			if zones, err := internal.TimestampZonesFromEnv(ctx, config.Env); err != nil {
+				releaseSourceConnection()
+				return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+			} else if zones != nil {
+				outstream = utils.AttachTimestampZonesToQRepStream(zones, outstream)
+			}
+			if isWasm {
+				module, err := wasm.LoadFromCatalog(ctx, a.CatalogPool, config.Env, script)
+				if err != nil {
+					releaseSourceConnection()
+					return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+				}
+				outstream = wasm.AttachToStream(ctx, module, outstream)
+			} else if script != "" {
+				ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
 					a.Alerter.LogFlowInfo(ctx, config.FlowJobName, s)
 				}))
 				if err != nil {
+					releaseSourceConnection()
 					return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 				}
 				if fn, ok := ls.Env.RawGetString("transformRow").(*lua.LFunction); ok {
-					outstream = pua.AttachToStream(ls, fn, stream)
+					outstream = pua.AttachToStream(ls, fn, outstream)
 				}
 			}
 			err = replicateQRepPartition(ctx, a, config, p, runUUID, stream, outstream,
@@ -586,9 +735,13 @@ func (a *FlowableActivity) ReplicateQRepPartitions(ctx context.Context,
 		default:
 			err = fmt.Errorf("unknown type system %d", config.System)
 		}
+		releaseSourceConnection()
 
 		if err != nil {
 			logger.Error("failed to replicate partition", slog.Any("error", err))
+			if err := monitoring.UpdateErrorForPartition(ctx, a.CatalogPool, runUUID, p, err); err != nil {
+				logger.Error("failed to record partition error", slog.Any("error", err))
+			}
 			return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 		}
 	}
@@ -631,7 +784,10 @@ func (a *FlowableActivity) CleanupQRepFlow(ctx context.Context, config *protos.Q
 	}
 	defer connectors.CloseConnector(ctx, dst)
 
-	return dst.CleanupQRepFlow(ctx, config)
+	if err := dst.CleanupQRepFlow(ctx, config); err != nil {
+		return err
+	}
+	return a.retainStage(ctx, config)
 }
 
 func (a *FlowableActivity) DropFlowSource(ctx context.Context, req *protos.DropFlowActivityInput) error {
@@ -1064,11 +1220,17 @@ func (a *FlowableActivity) ReplicateXminPartition(ctx context.Context,
 	})
 	defer shutdown()
 
+	releaseSourceConnection, err := a.acquireSourceConnection(ctx, qrepMirrorName(config), config.MaxSourceConnections)
+	if err != nil {
+		return 0, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	}
+	defer releaseSourceConnection()
+
 	switch config.System {
 	case protos.TypeSystem_Q:
 		stream := model.NewQRecordStream(shared.FetchAndChannelSize)
 		return replicateXminPartition(ctx, a, config, partition, runUUID,
-			stream, stream,
+			stream, utils.SampleQRepStream(config, stream),
 			(*connpostgres.PostgresConnector).PullXminRecordStream,
 			connectors.QRepSyncConnector.SyncQRepRecords)
 	case protos.TypeSystem_PG:
@@ -1178,6 +1340,96 @@ func (a *FlowableActivity) RemoveTablesFromRawTable(
 	return nil
 }
 
+func (a *FlowableActivity) ReplayRecords(
+	ctx context.Context,
+	input *protos.ReplayRecordsInput,
+) (*protos.ReplayRecordsOutput, error) {
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return fmt.Sprintf("replaying batches %d to %d", input.StartBatchId, input.EndBatchId)
+	})
+	defer shutdown()
+
+	cfg := input.FlowConnectionConfigs
+	ctx = context.WithValue(ctx, shared.FlowNameKey, input.FlowJobName)
+	logger := log.With(internal.LoggerFromCtx(ctx), slog.String(string(shared.FlowNameKey), input.FlowJobName))
+
+	dstConn, err := connectors.GetByNameAs[connectors.StageReplayConnector](ctx, cfg.Env, a.CatalogPool, cfg.DestinationName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, input.FlowJobName,
+			fmt.Errorf("[ReplayRecords] failed to get destination connector: %w", err))
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	tableNameSchemaMapping, err := a.getTableNameSchemaMapping(ctx, input.FlowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table name schema mapping: %w", err)
+	}
+
+	logger.Info("replaying batches",
+		slog.Int64("startBatchID", input.StartBatchId), slog.Int64("endBatchID", input.EndBatchId))
+	numRecords, err := dstConn.ReplayStagedBatches(ctx, &model.ReplayStagedBatchesRequest{
+		Env:                    cfg.Env,
+		TableNameSchemaMapping: tableNameSchemaMapping,
+		FlowJobName:            input.FlowJobName,
+		TableMappings:          cfg.TableMappings,
+		StartBatchID:           input.StartBatchId,
+		EndBatchID:             input.EndBatchId,
+		Version:                cfg.Version,
+	})
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, input.FlowJobName, fmt.Errorf("failed to replay batches: %w", err))
+	}
+
+	a.Alerter.LogFlowInfo(ctx, input.FlowJobName, fmt.Sprintf("replayed %d records from batches %d to %d",
+		numRecords, input.StartBatchId, input.EndBatchId))
+	return &protos.ReplayRecordsOutput{NumRecords: numRecords}, nil
+}
+
+func (a *FlowableActivity) DeduplicateDestination(
+	ctx context.Context,
+	input *protos.DeduplicateDestinationInput,
+) (*protos.DeduplicateDestinationOutput, error) {
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return "deduplicating destination tables"
+	})
+	defer shutdown()
+
+	cfg := input.FlowConnectionConfigs
+	ctx = context.WithValue(ctx, shared.FlowNameKey, input.FlowJobName)
+	logger := log.With(internal.LoggerFromCtx(ctx), slog.String(string(shared.FlowNameKey), input.FlowJobName))
+
+	dstConn, err := connectors.GetByNameAs[connectors.DeduplicationConnector](ctx, cfg.Env, a.CatalogPool, cfg.DestinationName)
+	if errors.Is(err, errors.ErrUnsupported) {
+		logger.Info("destination does not need deduplication, skipping")
+		return &protos.DeduplicateDestinationOutput{}, nil
+	} else if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, input.FlowJobName,
+			fmt.Errorf("[DeduplicateDestination] failed to get destination connector: %w", err))
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	tableNameSchemaMapping, err := a.getTableNameSchemaMapping(ctx, input.FlowJobName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, input.FlowJobName, fmt.Errorf("failed to get table name schema mapping: %w", err))
+	}
+	numRemoved, err := dstConn.DeduplicateBatches(ctx, &model.DeduplicateBatchesRequest{
+		Env:                    cfg.Env,
+		TableNameSchemaMapping: tableNameSchemaMapping,
+		FlowJobName:            input.FlowJobName,
+		TableMappings:          cfg.TableMappings,
+		StartBatchID:           input.StartBatchId,
+		EndBatchID:             input.EndBatchId,
+	})
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, input.FlowJobName, fmt.Errorf("failed to deduplicate destination: %w", err))
+	}
+
+	if numRemoved > 0 {
+		a.Alerter.LogFlowInfo(ctx, input.FlowJobName, fmt.Sprintf("removed %d duplicate rows from destination", numRemoved))
+	}
+	return &protos.DeduplicateDestinationOutput{NumRowsRemoved: numRemoved}, nil
+}
+
 func (a *FlowableActivity) RemoveTablesFromCatalog(
 	ctx context.Context,
 	cfg *protos.FlowConnectionConfigs,
@@ -19,7 +19,9 @@ import (
 	"go.temporal.io/sdk/temporal"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/PeerDB-io/peerdb/flow/alerting"
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	connmysql "github.com/PeerDB-io/peerdb/flow/connectors/mysql"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
@@ -54,6 +56,17 @@ func heartbeatRoutine(
 	)
 }
 
+// workerStopping reports whether the worker running the activity is shutting down,
+// long running activities check it between units of work to finish early with their progress saved
+func workerStopping(ctx context.Context) bool {
+	select {
+	case <-activity.GetWorkerStopChannel(ctx):
+		return true
+	default:
+		return false
+	}
+}
+
 func (a *FlowableActivity) getTableNameSchemaMapping(ctx context.Context, flowName string) (map[string]*protos.TableSchema, error) {
 	rows, err := a.CatalogPool.Query(ctx, "select table_name, table_schema from table_schema_mapping where flow_name = $1", flowName)
 	if err != nil {
@@ -126,7 +139,16 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 
 	tblNameMapping := make(map[string]model.NameAndExclude, len(options.TableMappings))
 	for _, v := range options.TableMappings {
-		tblNameMapping[v.SourceTableIdentifier] = model.NewNameAndExclude(v.DestinationTableIdentifier, v.Exclude)
+		nameAndExclude := model.NewNameAndExclude(v.DestinationTableIdentifier, v.Exclude)
+		nameAndExclude.GeneratedColumns = v.GeneratedColumns
+		nameAndExclude.IdentityColumns = v.IdentityColumns
+		if len(v.JsonPathColumns) != 0 {
+			nameAndExclude.Derived = make(map[string]struct{}, len(v.JsonPathColumns))
+			for _, col := range v.JsonPathColumns {
+				nameAndExclude.Derived[col.DestinationName] = struct{}{}
+			}
+		}
+		tblNameMapping[v.SourceTableIdentifier] = nameAndExclude
 	}
 
 	if err := srcConn.ConnectionActive(ctx); err != nil {
@@ -135,7 +157,7 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 
 	batchSize := options.BatchSize
 	if batchSize == 0 {
-		batchSize = 250_000
+		batchSize = shared.DefaultCDCBatchSize
 	}
 
 	lastOffset, err := func() (model.CdcCheckpoint, error) {
@@ -177,6 +199,19 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 		return nil, err
 	}
 
+	var messageDestination string
+	dstType, err := connectors.LoadPeerType(ctx, a.CatalogPool, config.DestinationName)
+	if err != nil {
+		return nil, err
+	}
+	isQueue := dstType == protos.DBType_KAFKA || dstType == protos.DBType_PUBSUB || dstType == protos.DBType_EVENTHUBS ||
+		dstType == protos.DBType_KINESIS
+	if isQueue {
+		if messageDestination, err = internal.PeerDBQueueLogicalMessageTopic(ctx, config.Env); err != nil {
+			return nil, fmt.Errorf("failed to get logical message topic: %w", err)
+		}
+	}
+
 	startTime := time.Now()
 	syncState.Store(shared.Ptr("syncing"))
 	errGroup, errCtx := errgroup.WithContext(ctx)
@@ -188,6 +223,7 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 			LastOffset:            lastOffset,
 			ConsumedOffset:        &consumedOffset,
 			MaxBatchSize:          batchSize,
+			MaxBatchBytes:         options.MaxBatchBytes,
 			IdleTimeout: internal.PeerDBCDCIdleTimeoutSeconds(
 				int(options.IdleTimeoutSeconds),
 			),
@@ -197,6 +233,8 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 			RecordStream:                recordBatchPull,
 			Env:                         config.Env,
 			InternalVersion:             config.Version,
+			MessageDestination:          messageDestination,
+			PropagateDroppedColumns:     isQueue,
 		})
 	})
 
@@ -265,6 +303,7 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 			TableMappings:          options.TableMappings,
 			StagingPath:            config.CdcStagingPath,
 			Script:                 config.Script,
+			S3Partition:            config.S3Partition,
 			TableNameSchemaMapping: tableNameSchemaMapping,
 			Env:                    config.Env,
 			Version:                config.Version,
@@ -340,7 +379,11 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 		}
 		syncState.Store(shared.Ptr("normalizing"))
 		select {
-		case normRequests <- NormalizeBatchRequest{BatchID: res.CurrentSyncBatchID, Done: done}:
+		case normRequests <- NormalizeBatchRequest{
+			BatchID:          res.CurrentSyncBatchID,
+			Done:             done,
+			TableCommitTimes: tableCommitTimes(res.TableNameRowsMapping),
+		}:
 		case <-ctx.Done():
 			return res, nil
 		}
@@ -669,6 +712,14 @@ func (a *FlowableActivity) startNormalize(
 		}
 	}
 
+	if res.ConflictCount > 0 {
+		if err := monitoring.AddNormalizeConflicts(ctx, a.CatalogPool, config.FlowJobName, res.EndBatchID, res.Conflicts); err != nil {
+			logger.Warn("failed to log normalize conflicts", slog.Any("error", err))
+		}
+		a.Alerter.LogFlowInfo(ctx, config.FlowJobName,
+			fmt.Sprintf("resolved %d conflicts with destination rows modified out-of-band", res.ConflictCount))
+	}
+
 	logger.Info("normalized batches", slog.Int64("StartBatchID", res.StartBatchID), slog.Int64("EndBatchID", res.EndBatchID))
 
 	return nil
@@ -686,6 +737,7 @@ func (a *FlowableActivity) normalizeLoop(
 ) {
 	defer normalizeWaiting.Store(false)
 
+	lagTracker := tableLagTracker{pending: make(map[string]*protos.TableReplicationLag)}
 	for {
 		normalizeWaiting.Store(true)
 		select {
@@ -700,7 +752,17 @@ func (a *FlowableActivity) normalizeLoop(
 					for {
 						// update req to latest normalize request & retry
 						select {
-						case req = <-normalizeRequests:
+						case nextReq := <-normalizeRequests:
+							// keep lag of the batches being skipped over, they get normalized together with the next one
+							for table, commitTime := range req.TableCommitTimes {
+								if nextCommitTime, ok := nextReq.TableCommitTimes[table]; !ok || commitTime < nextCommitTime {
+									if nextReq.TableCommitTimes == nil {
+										nextReq.TableCommitTimes = make(map[string]int64, len(req.TableCommitTimes))
+									}
+									nextReq.TableCommitTimes[table] = commitTime
+								}
+							}
+							req = nextReq
 						case <-syncDone:
 							logger.Info("[normalize-loop] syncDone closed before retry")
 							return
@@ -719,6 +781,7 @@ func (a *FlowableActivity) normalizeLoop(
 				a.OtelManager.Metrics.LastNormalizedBatchIdGauge.Record(ctx, req.BatchID, metric.WithAttributeSet(attribute.NewSet(
 					attribute.String(otel_metrics.FlowNameKey, config.FlowJobName),
 				)))
+				a.recordTableLag(ctx, logger, config, req, &lagTracker)
 				break
 			}
 		case <-syncDone:
@@ -730,3 +793,115 @@ func (a *FlowableActivity) normalizeLoop(
 		}
 	}
 }
+
+// waitForNormalize holds off pulling the next batch while limit synced batches wait on normalize,
+// the slot keeps changes instead of memory and staging storage while the destination catches up
+func waitForNormalize(
+	ctx context.Context,
+	logger log.Logger,
+	limit int,
+	pendingNormalize func() int,
+	syncState *atomic.Pointer[string],
+) {
+	pending := pendingNormalize()
+	if pending < limit {
+		return
+	}
+	logger.Info("destination behind on normalize, waiting before pulling next batch",
+		slog.Int("pendingNormalize", pending), slog.Int("limit", limit))
+	syncState.Store(shared.Ptr("backpressure"))
+	start := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for pendingNormalize() >= limit {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+	logger.Info("normalize caught up, resuming pull", slog.Duration("waited", time.Since(start)))
+}
+
+// interval at which table lag is reported to CDCFlowWorkflow, to keep its history small
+const tableLagSignalInterval = time.Minute
+
+type tableLagTracker struct {
+	lastSignal time.Time
+	// lag not yet reported to the workflow
+	pending map[string]*protos.TableReplicationLag
+}
+
+func tableCommitTimes(tableNameRowsMapping map[string]*model.RecordTypeCounts) map[string]int64 {
+	commitTimes := make(map[string]int64, len(tableNameRowsMapping))
+	for table, counts := range tableNameRowsMapping {
+		if commitTime := counts.OldestCommitTimeNano.Load(); commitTime != 0 {
+			commitTimes[table] = commitTime
+		}
+	}
+	return commitTimes
+}
+
+// recordTableLag emits lag between source commit and destination apply for tables of a normalized batch,
+// alerts on tables lagging beyond PEERDB_TABLE_LAG_ALERT_THRESHOLD_MINUTES and on breaches of the freshness SLO of the mirror, and periodically signals it to CDCFlowWorkflow where it is exposed through the CDCTableLagQuery
+func (a *FlowableActivity) recordTableLag(
+	ctx context.Context,
+	logger log.Logger,
+	config *protos.FlowConnectionConfigs,
+	req NormalizeBatchRequest,
+	tracker *tableLagTracker,
+) {
+	flowName := config.FlowJobName
+	alertThreshold, err := internal.PeerDBTableLagAlertThresholdMinutes(ctx, config.Env)
+	if err != nil {
+		logger.Warn("failed to get table lag alert threshold", slog.Any("error", err))
+	}
+	tableGroups := make(map[string]string, len(config.TableMappings))
+	for _, tm := range config.TableMappings {
+		tableGroups[tm.DestinationTableIdentifier] = tm.TableGroup
+	}
+
+	appliedAt := time.Now()
+	var staleness time.Duration
+	tableLags := make([]alerting.TableLag, 0, len(req.TableCommitTimes))
+	for table, commitTimeNano := range req.TableCommitTimes {
+		commitTime := time.Unix(0, commitTimeNano)
+		lag := appliedAt.Sub(commitTime)
+		a.OtelManager.Metrics.TableReplicationLagGauge.Record(ctx, lag.Seconds(), metric.WithAttributeSet(attribute.NewSet(
+			attribute.String(otel_metrics.FlowNameKey, flowName),
+			attribute.String(otel_metrics.DestinationTableKey, table),
+		)))
+		tracker.pending[table] = &protos.TableReplicationLag{
+			DestinationTableName: table,
+			BatchId:              req.BatchID,
+			OldestCommitTime:     timestamppb.New(commitTime),
+			AppliedAt:            timestamppb.New(appliedAt),
+			LagSeconds:           lag.Seconds(),
+		}
+		tableLags = append(tableLags, alerting.TableLag{TableName: table, TableGroup: tableGroups[table], Lag: lag})
+		staleness = max(staleness, lag)
+	}
+	if alertThreshold > 0 {
+		a.Alerter.AlertIfTableLag(ctx, &alerting.AlertKeys{FlowName: flowName}, tableLags, alertThreshold)
+	}
+	if maxStaleness := config.FreshnessSlo.GetMaxStalenessSeconds(); maxStaleness > 0 && len(tableLags) > 0 {
+		a.Alerter.AlertIfFreshnessSloBreach(ctx, &alerting.AlertKeys{FlowName: flowName}, staleness,
+			time.Duration(maxStaleness)*time.Second)
+	}
+
+	if len(tracker.pending) == 0 || appliedAt.Sub(tracker.lastSignal) < tableLagSignalInterval {
+		return
+	}
+	lags := make([]*protos.TableReplicationLag, 0, len(tracker.pending))
+	for _, lag := range tracker.pending {
+		lags = append(lags, lag)
+	}
+	if err := model.TableLagSignal.SignalClientWorkflow(
+		ctx, a.TemporalClient, activity.GetInfo(ctx).WorkflowExecution.ID, "", lags,
+	); err != nil {
+		logger.Warn("failed to signal table lag to workflow", slog.Any("error", err))
+		return
+	}
+	tracker.lastSignal = appliedAt
+	clear(tracker.pending)
+}
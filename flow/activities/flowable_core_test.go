@@ -0,0 +1,50 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestWaitForNormalize(t *testing.T) {
+	logger := log.NewStructuredLogger(slog.Default())
+
+	t.Run("below limit", func(t *testing.T) {
+		var syncState atomic.Pointer[string]
+		syncState.Store(shared.Ptr("syncing"))
+		waitForNormalize(t.Context(), logger, 2, func() int { return 1 }, &syncState)
+		require.Equal(t, "syncing", *syncState.Load())
+	})
+
+	t.Run("waits until normalize catches up", func(t *testing.T) {
+		var syncState atomic.Pointer[string]
+		syncState.Store(shared.Ptr("syncing"))
+		var pending atomic.Int64
+		pending.Store(3)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			pending.Store(1)
+		}()
+		start := time.Now()
+		waitForNormalize(t.Context(), logger, 2, func() int { return int(pending.Load()) }, &syncState)
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		require.Equal(t, int64(1), pending.Load())
+		require.Equal(t, "backpressure", *syncState.Load())
+	})
+
+	t.Run("returns on cancel", func(t *testing.T) {
+		var syncState atomic.Pointer[string]
+		syncState.Store(shared.Ptr("syncing"))
+		ctx, cancel := context.WithCancel(t.Context())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		waitForNormalize(ctx, logger, 2, func() int { return 2 }, &syncState)
+		require.Error(t, ctx.Err())
+	})
+}
@@ -0,0 +1,168 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func migrationSlotName(cfg *protos.FlowConnectionConfigs) string {
+	if cfg.ReplicationSlotName != "" {
+		return cfg.ReplicationSlotName
+	}
+	return "peerflow_slot_" + cfg.FlowJobName
+}
+
+// WaitForMigrationSnapshot waits for the CDC mirror of a migration to finish setup and its initial snapshot
+func (a *FlowableActivity) WaitForMigrationSnapshot(ctx context.Context, flowJobName string) (protos.FlowStatus, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowJobName)
+	var workflowID string
+	if err := a.CatalogPool.QueryRow(ctx, "SELECT workflow_id FROM flows WHERE name = $1", flowJobName).Scan(&workflowID); err != nil {
+		return protos.FlowStatus_STATUS_UNKNOWN, fmt.Errorf("failed to get workflow of mirror %s: %w", flowJobName, err)
+	}
+
+	status, err := RunEveryIntervalUntilFinish(ctx, func() (bool, protos.FlowStatus, error) {
+		activity.RecordHeartbeat(ctx, "waiting for snapshot of "+flowJobName)
+		status, err := internal.GetWorkflowStatus(ctx, a.CatalogPool, a.TemporalClient, workflowID)
+		if err != nil {
+			return false, status, err
+		}
+		switch status {
+		case protos.FlowStatus_STATUS_UNKNOWN, protos.FlowStatus_STATUS_SETUP,
+			protos.FlowStatus_STATUS_SNAPSHOT, protos.FlowStatus_STATUS_RESYNC:
+			return false, status, nil
+		default:
+			return true, status, nil
+		}
+	}, 10*time.Second, "waiting for snapshot of "+flowJobName, 5*time.Minute, true)
+	if err != nil {
+		return status, err
+	}
+	if status != protos.FlowStatus_STATUS_RUNNING && status != protos.FlowStatus_STATUS_PAUSED {
+		return status, fmt.Errorf("mirror %s ended up %s instead of replicating", flowJobName, status)
+	}
+	return status, nil
+}
+
+// GetMigrationLag returns how far the mirror of a migration trails untilLSN, or the current source position
+// when untilLSN is empty, along with the batches it has yet to normalize
+func (a *FlowableActivity) GetMigrationLag(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	untilLSN string,
+) (*protos.MigrationLag, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, cfg.FlowJobName)
+	srcConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, cfg.Env, a.CatalogPool, cfg.SourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	var lag protos.MigrationLag
+	if lag.LagBytes, err = srcConn.GetSlotLagBytes(ctx, migrationSlotName(cfg), untilLSN); err != nil {
+		return nil, err
+	}
+	if err := a.CatalogPool.QueryRow(ctx,
+		"SELECT greatest(sync_batch_id - coalesce(normalize_batch_id, 0), 0) FROM metadata_last_sync_state WHERE job_name = $1",
+		cfg.FlowJobName,
+	).Scan(&lag.PendingBatches); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get last sync state: %w", err)
+	}
+	return &lag, nil
+}
+
+// VerifyWriteFreeze checks that no rows of the source tables of a migration are written over window,
+// returning the source position once the window has passed so the mirror can be drained up to it
+func (a *FlowableActivity) VerifyWriteFreeze(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	window time.Duration,
+) (*protos.WriteFreezeVerification, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, cfg.FlowJobName)
+	logger := internal.LoggerFromCtx(ctx)
+	srcConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, cfg.Env, a.CatalogPool, cfg.SourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	tables := make([]string, 0, len(cfg.TableMappings))
+	for _, tm := range cfg.TableMappings {
+		tables = append(tables, tm.SourceTableIdentifier)
+	}
+	before, err := srcConn.GetTableWriteCounts(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return "verifying write freeze of " + cfg.FlowJobName
+	})
+	defer shutdown()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(window):
+	}
+
+	after, err := srcConn.GetTableWriteCounts(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+	var verification protos.WriteFreezeVerification
+	for _, table := range slices.Sorted(maps.Keys(after)) {
+		if count := after[table]; count < 0 || count != before[table] {
+			verification.WrittenTables = append(verification.WrittenTables, table)
+		}
+	}
+	if len(verification.WrittenTables) > 0 {
+		logger.Warn("source tables written to during write freeze", slog.Any("tables", verification.WrittenTables))
+		return &verification, nil
+	}
+	if verification.Lsn, err = srcConn.GetCurrentLSN(ctx); err != nil {
+		return nil, err
+	}
+	verification.Frozen = true
+	return &verification, nil
+}
+
+// SyncMigrationSequences sets the destination sequences of a migration to the values of their source sequences,
+// doing nothing for destinations other than Postgres
+func (a *FlowableActivity) SyncMigrationSequences(ctx context.Context, cfg *protos.FlowConnectionConfigs) (int32, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, cfg.FlowJobName)
+	logger := internal.LoggerFromCtx(ctx)
+	srcConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, cfg.Env, a.CatalogPool, cfg.SourceName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	dstConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, cfg.Env, a.CatalogPool, cfg.DestinationName)
+	if errors.Is(err, errors.ErrUnsupported) {
+		logger.Info("destination has no sequences, skipping sequence sync")
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to get destination connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	synced, err := dstConn.SyncOwnedSequences(ctx, srcConn, cfg.TableMappings)
+	if err != nil {
+		return synced, a.Alerter.LogFlowError(ctx, cfg.FlowJobName, fmt.Errorf("failed to sync sequences: %w", err))
+	}
+	a.Alerter.LogFlowInfo(ctx, cfg.FlowJobName, fmt.Sprintf("synced %d sequences to destination", synced))
+	return synced, nil
+}
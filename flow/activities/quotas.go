@@ -0,0 +1,100 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/alerting"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// interval at which a mirror over its rows per day quota checks whether it may sync again
+const quotaWaitInterval = time.Minute
+
+const quotaRowsPerDay = "rows per day"
+
+// checkRowsPerDay reports whether the mirror has synced fewer rows over the last day than its quota allows
+func (a *FlowableActivity) checkRowsPerDay(ctx context.Context, flowName string, quota internal.MirrorQuota) (bool, error) {
+	if quota.MaxRowsPerDay == 0 {
+		return true, nil
+	}
+	numRows, err := internal.MirrorRowsLastDay(ctx, a.CatalogPool, flowName)
+	if err != nil {
+		return false, err
+	}
+	a.Alerter.AlertIfQuotaReached(ctx, &alerting.AlertKeys{FlowName: flowName}, quotaRowsPerDay, numRows, quota.MaxRowsPerDay)
+	return numRows < quota.MaxRowsPerDay, nil
+}
+
+// QRepPartitionQuota is how many partition workflows a QRep run may spawn within the quota of its mirror,
+// 0 while the mirror is over its rows per day quota and the run should wait
+func (a *FlowableActivity) QRepPartitionQuota(ctx context.Context, config *protos.QRepConfig, maxParallelWorkers int) (int, error) {
+	flowName := config.FlowJobName
+	if config.ParentMirrorName != "" {
+		flowName = config.ParentMirrorName
+	}
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowName)
+	quota, err := internal.LoadMirrorQuota(ctx, a.CatalogPool, flowName)
+	if err != nil {
+		return 0, err
+	}
+
+	if ok, err := a.checkRowsPerDay(ctx, flowName, quota); err != nil {
+		return 0, err
+	} else if !ok {
+		internal.LoggerFromCtx(ctx).Info("mirror reached its rows per day quota, waiting before replicating partitions")
+		return 0, nil
+	}
+
+	if limit := int(quota.MaxConcurrentPartitionWorkflows); limit > 0 {
+		if maxParallelWorkers > limit {
+			internal.LoggerFromCtx(ctx).Info("limiting partition workflows to mirror quota",
+				slog.Int("maxParallelWorkers", maxParallelWorkers), slog.Int("quota", limit))
+			return limit, nil
+		}
+	}
+	return maxParallelWorkers, nil
+}
+
+// applyCDCQuota waits while the mirror is over its rows per day quota,
+// then returns options with the batch size in bytes capped by its staged bytes quota,
+// a batch can exceed the cap by the last record pulled
+func (a *FlowableActivity) applyCDCQuota(
+	ctx context.Context,
+	flowName string,
+	options *protos.SyncFlowOptions,
+	syncState *atomic.Pointer[string],
+) (*protos.SyncFlowOptions, error) {
+	for {
+		quota, err := internal.LoadMirrorQuota(ctx, a.CatalogPool, flowName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load quota of mirror: %w", err)
+		}
+		if ok, err := a.checkRowsPerDay(ctx, flowName, quota); err != nil {
+			return nil, fmt.Errorf("failed to check rows per day quota of mirror: %w", err)
+		} else if ok {
+			maxBatchBytes := quota.CapBatchBytes(options.MaxBatchBytes)
+			if maxBatchBytes == options.MaxBatchBytes {
+				return options, nil
+			}
+			capped := proto.CloneOf(options)
+			capped.MaxBatchBytes = maxBatchBytes
+			return capped, nil
+		}
+
+		internal.LoggerFromCtx(ctx).Info("mirror reached its rows per day quota, waiting before pulling next batch")
+		syncState.Store(shared.Ptr("quota"))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(quotaWaitInterval):
+		}
+	}
+}
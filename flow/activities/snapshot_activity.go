@@ -2,6 +2,7 @@ package activities
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/alerting"
 	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/shared"
@@ -162,6 +164,25 @@ func (a *SnapshotActivity) WaitForExportSnapshot(ctx context.Context, sessionID
 	}
 }
 
+// GetLeafPartitions lists the partitions of partitioned source tables, so they can be cloned one partition at a time
+func (a *SnapshotActivity) GetLeafPartitions(
+	ctx context.Context,
+	peerName string,
+	env map[string]string,
+	tables []string,
+) (map[string][]string, error) {
+	conn, err := connectors.GetByNameAs[connectors.PartitionedTablesConnector](ctx, env, a.CatalogPool, peerName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	return conn.GetLeafPartitions(ctx, tables)
+}
+
 func (a *SnapshotActivity) LoadTableSchema(
 	ctx context.Context,
 	flowName string,
@@ -169,3 +190,31 @@ func (a *SnapshotActivity) LoadTableSchema(
 ) (*protos.TableSchema, error) {
 	return internal.LoadTableSchemaFromCatalog(ctx, a.CatalogPool, flowName, tableName)
 }
+
+// MigrateConstraints recreates the unique indexes, check constraints and foreign keys of source tables on the destination
+func (a *SnapshotActivity) MigrateConstraints(ctx context.Context, config *protos.FlowConnectionConfigs) error {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	logger := internal.LoggerFromCtx(ctx)
+
+	srcConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, config.Env, a.CatalogPool, config.SourceName)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get source connector: %w", err))
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	dstConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, config.Env, a.CatalogPool, config.DestinationName)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get destination connector: %w", err))
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	constraints, err := srcConn.GetTableConstraints(ctx, config.TableMappings)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get source constraints: %w", err))
+	}
+	logger.Info("migrating constraints", slog.Int("constraints", len(constraints)))
+	if err := dstConn.CreateTableConstraints(ctx, constraints, config.TableMappings); err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to create constraints: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// leases not refreshed for this long belong to crashed workers and no longer count against the budget
+const sourceConnectionLeaseTimeout = 5 * time.Minute
+
+// acquireSourceConnection blocks until the mirror has a source connection left in its budget,
+// release has to be called once the connection is closed. A limit of 0 never blocks
+func (a *FlowableActivity) acquireSourceConnection(ctx context.Context, mirrorName string, limit uint32) (func(), error) {
+	if limit == 0 {
+		return func() {}, nil
+	}
+
+	logger := internal.LoggerFromCtx(ctx)
+	leaseID := uuid.NewString()
+	logged := false
+	for {
+		acquired, err := a.tryAcquireSourceConnection(ctx, mirrorName, leaseID, limit)
+		if err != nil {
+			return nil, err
+		} else if acquired {
+			break
+		}
+
+		if !logged {
+			logger.Info("waiting for a source connection of the mirror to be released",
+				slog.String(string(shared.FlowNameKey), mirrorName), slog.Uint64("limit", uint64(limit)))
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(sourceConnectionLeaseTimeout / 5)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-refreshCtx.Done():
+				return
+			case <-ticker.C:
+				if _, err := a.CatalogPool.Exec(refreshCtx,
+					"UPDATE source_connection_leases SET heartbeat_at = now() WHERE flow_name = $1 AND lease_id = $2",
+					mirrorName, leaseID,
+				); err != nil {
+					logger.Warn("failed to refresh source connection lease", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		stopRefresh()
+		releaseCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if _, err := a.CatalogPool.Exec(releaseCtx,
+			"DELETE FROM source_connection_leases WHERE flow_name = $1 AND lease_id = $2", mirrorName, leaseID,
+		); err != nil {
+			logger.Warn("failed to release source connection lease, it expires on its own", slog.Any("error", err))
+		}
+	}, nil
+}
+
+func (a *FlowableActivity) tryAcquireSourceConnection(ctx context.Context, mirrorName string, leaseID string, limit uint32) (bool, error) {
+	tx, err := a.CatalogPool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction for source connection lease: %w", err)
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+
+	// serializes acquiring leases of a mirror so that concurrent workers cannot both take the last connection
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('peerdb_source_connections_' || $1))", mirrorName); err != nil {
+		return false, fmt.Errorf("failed to lock source connection leases: %w", err)
+	}
+	var inUse uint32
+	if err := tx.QueryRow(ctx,
+		"SELECT count(*) FROM source_connection_leases WHERE flow_name = $1 AND heartbeat_at > now() - $2::interval",
+		mirrorName, sourceConnectionLeaseTimeout,
+	).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("failed to count source connection leases: %w", err)
+	} else if inUse >= limit {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx,
+		"DELETE FROM source_connection_leases WHERE flow_name = $1 AND heartbeat_at <= now() - $2::interval",
+		mirrorName, sourceConnectionLeaseTimeout,
+	); err != nil {
+		return false, fmt.Errorf("failed to expire source connection leases: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO source_connection_leases (flow_name, lease_id) VALUES ($1, $2)", mirrorName, leaseID,
+	); err != nil {
+		return false, fmt.Errorf("failed to insert source connection lease: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit source connection lease: %w", err)
+	}
+	return true, nil
+}
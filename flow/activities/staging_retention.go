@@ -0,0 +1,100 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// retainStage records a stage whose recent files were kept by PEERDB_STAGING_RETENTION_HOURS,
+// CleanupExpiredStages drops the rest of it once retention passes
+func (a *FlowableActivity) retainStage(ctx context.Context, config *protos.QRepConfig) error {
+	if config.StagingPath == "" {
+		return nil
+	}
+	retentionHours, err := internal.PeerDBStagingRetentionHours(ctx, config.Env)
+	if err != nil || retentionHours == 0 {
+		return err
+	}
+	if _, err := a.CatalogPool.Exec(ctx,
+		`INSERT INTO staging_retention (peer_name, flow_name, staging_path, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(hours => $4))
+		ON CONFLICT (peer_name, flow_name, staging_path) DO UPDATE SET expires_at = EXCLUDED.expires_at`,
+		config.DestinationName, config.FlowJobName, config.StagingPath, int32(retentionHours),
+	); err != nil {
+		return fmt.Errorf("failed to record retained stage: %w", err)
+	}
+	return nil
+}
+
+type retainedStage struct {
+	peerName    string
+	flowName    string
+	stagingPath string
+}
+
+// CleanupExpiredStages drops stages whose retention passed, including those of dropped mirrors
+func (a *FlowableActivity) CleanupExpiredStages(ctx context.Context) error {
+	rows, err := a.CatalogPool.Query(ctx,
+		"SELECT peer_name, flow_name, staging_path FROM staging_retention WHERE expires_at <= now()")
+	if err != nil {
+		return fmt.Errorf("failed to read expired stages from catalog: %w", err)
+	}
+	stages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (retainedStage, error) {
+		var stage retainedStage
+		err := row.Scan(&stage.peerName, &stage.flowName, &stage.stagingPath)
+		return stage, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read expired stages from catalog: %w", err)
+	}
+
+	logger := internal.LoggerFromCtx(ctx)
+	for _, stage := range stages {
+		activity.RecordHeartbeat(ctx, "dropping expired stage of "+stage.flowName)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := a.dropExpiredStage(ctx, stage); err != nil {
+			logger.Warn("failed to drop expired stage", slog.String("flowName", stage.flowName),
+				slog.String("stagingPath", stage.stagingPath), slog.Any("error", err))
+			continue
+		}
+		if _, err := a.CatalogPool.Exec(ctx,
+			`DELETE FROM staging_retention WHERE peer_name = $1 AND flow_name = $2 AND staging_path = $3 AND expires_at <= now()`,
+			stage.peerName, stage.flowName, stage.stagingPath,
+		); err != nil {
+			return fmt.Errorf("failed to remove expired stage from catalog: %w", err)
+		}
+		logger.Info("dropped expired stage", slog.String("flowName", stage.flowName), slog.String("stagingPath", stage.stagingPath))
+	}
+	return nil
+}
+
+func (a *FlowableActivity) dropExpiredStage(ctx context.Context, stage retainedStage) error {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, stage.flowName)
+	dst, err := connectors.GetByNameAs[connectors.QRepConsolidateConnector](ctx, nil, a.CatalogPool, stage.peerName)
+	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, errors.ErrUnsupported) {
+		// the peer is gone, nothing is left to clean up with
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer connectors.CloseConnector(ctx, dst)
+
+	// files staged within retention are only those of later runs, which track their own expiry
+	return dst.CleanupQRepFlow(ctx, &protos.QRepConfig{
+		FlowJobName:     stage.flowName,
+		DestinationName: stage.peerName,
+		StagingPath:     stage.stagingPath,
+	})
+}
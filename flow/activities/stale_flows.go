@@ -0,0 +1,125 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peerdb/flow/alerting"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// CheckStaleFlows alerts on running CDC mirrors that have not completed a sync within the configured threshold,
+// a sync completes even without records once the idle timeout passes, so only stuck mirrors go without one
+func (a *FlowableActivity) CheckStaleFlows(ctx context.Context) error {
+	thresholdMinutes, err := internal.PeerDBStaleFlowAlertThresholdMinutes(ctx, nil)
+	if err != nil {
+		return err
+	} else if thresholdMinutes == 0 {
+		return nil
+	}
+	threshold := time.Duration(thresholdMinutes) * time.Minute
+	autoReset, err := internal.PeerDBStaleFlowAutoReset(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	rows, err := a.CatalogPool.Query(ctx, `SELECT f.name, f.workflow_id, extract(epoch from now() - greatest(
+			c.last_sync_at,
+			(SELECT max(end_time) FROM peerdb_stats.cdc_batches b WHERE b.flow_name = f.name)
+		))::float8
+		FROM (SELECT DISTINCT ON (name) name, workflow_id FROM flows WHERE query_string IS NULL) f
+		JOIN peerdb_stats.cdc_flows c ON c.flow_name = f.name`)
+	if err != nil {
+		return fmt.Errorf("failed to read last syncs from catalog: %w", err)
+	}
+	type flowProgress struct {
+		flowName      string
+		workflowID    string
+		sinceProgress *float64
+	}
+	flows, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (flowProgress, error) {
+		var progress flowProgress
+		err := row.Scan(&progress.flowName, &progress.workflowID, &progress.sinceProgress)
+		return progress, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read last syncs from catalog: %w", err)
+	}
+
+	logger := internal.LoggerFromCtx(ctx)
+	for _, flow := range flows {
+		activity.RecordHeartbeat(ctx, "checking staleness of "+flow.flowName)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// mirrors that never synced are still setting up or snapshotting
+		if flow.sinceProgress == nil {
+			continue
+		}
+
+		status, err := internal.GetWorkflowStatus(ctx, a.CatalogPool, a.TemporalClient, flow.workflowID)
+		if err != nil {
+			logger.Warn("failed to get workflow status", slog.String("flowName", flow.flowName), slog.Any("error", err))
+			continue
+		} else if status != protos.FlowStatus_STATUS_RUNNING {
+			continue
+		}
+
+		sinceProgress := time.Duration(*flow.sinceProgress * float64(time.Second))
+		reset := false
+		if autoReset && sinceProgress > threshold {
+			if reset, err = a.resetStaleFlow(ctx, flow.workflowID, threshold); err != nil {
+				logger.Error("failed to reset stale mirror", slog.String("flowName", flow.flowName), slog.Any("error", err))
+			} else if reset {
+				logger.Warn("reset stale mirror", slog.String("flowName", flow.flowName), slog.Duration("sinceProgress", sinceProgress))
+			}
+		}
+		a.Alerter.AlertIfFlowStale(ctx, &alerting.AlertKeys{FlowName: flow.flowName}, sinceProgress, threshold, reset)
+	}
+	return nil
+}
+
+// resetStaleFlow resets the workflow to its last completed workflow task, which reschedules its sync activity.
+// Workflows with a workflow task completed within the threshold are left alone, so a reset isn't repeated before it could take effect
+func (a *FlowableActivity) resetStaleFlow(ctx context.Context, workflowID string, threshold time.Duration) (bool, error) {
+	var lastTaskCompletedID int64
+	var lastTaskCompletedAt time.Time
+	iter := a.TemporalClient.GetWorkflowHistory(ctx, workflowID, "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return false, fmt.Errorf("failed to read workflow history: %w", err)
+		}
+		if event.EventType == enums.EVENT_TYPE_WORKFLOW_TASK_COMPLETED {
+			lastTaskCompletedID = event.EventId
+			lastTaskCompletedAt = event.EventTime.AsTime()
+		}
+	}
+	if lastTaskCompletedID == 0 {
+		return false, errors.New("workflow has no completed workflow task to reset to")
+	} else if time.Since(lastTaskCompletedAt) < threshold {
+		return false, nil
+	}
+
+	if _, err := a.TemporalClient.ResetWorkflowExecution(ctx, &workflowservice.ResetWorkflowExecutionRequest{
+		Namespace:                 activity.GetInfo(ctx).WorkflowNamespace,
+		WorkflowExecution:         &commonpb.WorkflowExecution{WorkflowId: workflowID},
+		Reason:                    "mirror stale for longer than PEERDB_STALE_FLOW_ALERT_THRESHOLD_MINUTES",
+		WorkflowTaskFinishEventId: lastTaskCompletedID,
+		RequestId:                 uuid.NewString(),
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
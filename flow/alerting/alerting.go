@@ -9,7 +9,9 @@ import (
 	"log/slog"
 	"net"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -34,12 +36,15 @@ type Alerter struct {
 	snsTelemetrySender        telemetry.Sender
 	incidentIoTelemetrySender telemetry.Sender
 	otelManager               *otel_metrics.OtelManager
+	// dedup key of quota incidents to whether the quota was reached when last checked
+	quotaReached sync.Map
 }
 
 type AlertSenderConfig struct {
-	Sender          AlertSender
-	AlertForMirrors []string
-	Id              int64
+	Sender              AlertSender
+	AlertForMirrors     []string
+	AlertForTableGroups []string
+	Id                  int64
 }
 
 type AlertKeys struct {
@@ -91,7 +96,7 @@ func NewAlerter(ctx context.Context, catalogPool shared.CatalogPool, otelManager
 
 func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderConfig, error) {
 	rows, err := a.CatalogPool.Query(ctx,
-		`SELECT id, service_type, service_config, enc_key_id, alert_for_mirrors
+		`SELECT id, service_type, service_config, enc_key_id, alert_for_mirrors, alert_for_table_groups
 		FROM peerdb_stats.alerting_config`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read alerter config from catalog: %w", err)
@@ -104,7 +109,7 @@ func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderCon
 		var serviceConfigEnc []byte
 		var encKeyId string
 		if err := row.Scan(&alertSenderConfig.Id, &serviceType, &serviceConfigEnc, &encKeyId,
-			&alertSenderConfig.AlertForMirrors); err != nil {
+			&alertSenderConfig.AlertForMirrors, &alertSenderConfig.AlertForTableGroups); err != nil {
 			return alertSenderConfig, err
 		}
 
@@ -154,6 +159,42 @@ func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderCon
 			}
 			alertSenderConfig.Sender = alertSender
 
+			return alertSenderConfig, nil
+		case PAGERDUTY:
+			var pagerDutyServiceConfig pagerDutyAlertConfig
+			if err := json.Unmarshal(serviceConfig, &pagerDutyServiceConfig); err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to unmarshal %s service config: %w", serviceType, err)
+			}
+			if pagerDutyServiceConfig.RoutingKey == "" {
+				return alertSenderConfig, errors.New("missing routing_key for PagerDuty alerting service")
+			}
+
+			alertSenderConfig.Sender = newPagerDutyAlertSender(&pagerDutyServiceConfig)
+			return alertSenderConfig, nil
+		case OPSGENIE:
+			var opsgenieServiceConfig opsgenieAlertConfig
+			if err := json.Unmarshal(serviceConfig, &opsgenieServiceConfig); err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to unmarshal %s service config: %w", serviceType, err)
+			}
+			if opsgenieServiceConfig.APIKey == "" {
+				return alertSenderConfig, errors.New("missing api_key for Opsgenie alerting service")
+			}
+
+			alertSenderConfig.Sender = newOpsgenieAlertSender(&opsgenieServiceConfig)
+			return alertSenderConfig, nil
+		case WEBHOOK:
+			var webhookServiceConfig webhookAlertConfig
+			if err := json.Unmarshal(serviceConfig, &webhookServiceConfig); err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to unmarshal %s service config: %w", serviceType, err)
+			}
+			if webhookServiceConfig.URL == "" {
+				return alertSenderConfig, errors.New("missing url for webhook alerting service")
+			}
+
+			alertSenderConfig.Sender, err = newWebhookAlertSender(&webhookServiceConfig)
+			if err != nil {
+				return alertSenderConfig, err
+			}
 			return alertSenderConfig, nil
 		default:
 			return alertSenderConfig, fmt.Errorf("unknown service type: %s", serviceType)
@@ -199,26 +240,42 @@ func (a *Alerter) AlertIfSlotLag(ctx context.Context, alertKeys *AlertKeys, slot
 	badWalStatusAlertMessage := fmt.Sprintf("%sSlot `%s` on peer `%s` has bad WAL status: `%s`",
 		deploymentUIDPrefix, slotInfo.SlotName, alertKeys.PeerName, slotInfo.WalStatus)
 
+	slotLagIncident := Incident{
+		DedupKey:  incidentDedupKey(alertKeys.FlowName, AlertTypeSlotLag),
+		Title:     thresholdAlertKey,
+		FlowName:  alertKeys.FlowName,
+		AlertType: AlertTypeSlotLag,
+	}
+	badWalStatusIncident := Incident{
+		DedupKey:  incidentDedupKey(alertKeys.FlowName, AlertTypeBadWALStatus),
+		Title:     badWalStatusAlertKey,
+		Message:   badWalStatusAlertMessage,
+		FlowName:  alertKeys.FlowName,
+		AlertType: AlertTypeBadWALStatus,
+	}
+	badWalStatus := slotInfo.WalStatus == "lost" || slotInfo.WalStatus == "unreserved"
+
 	for _, alertSenderConfig := range alertSendersForMirrors {
+		slotLagMBAlertThreshold := defaultSlotLagMBAlertThreshold
+		if alertSenderConfig.Sender.getSlotLagMBAlertThreshold() > 0 {
+			slotLagMBAlertThreshold = alertSenderConfig.Sender.getSlotLagMBAlertThreshold()
+		}
+		if slotInfo.LagInMb <= float32(slotLagMBAlertThreshold) {
+			a.resolveIncident(ctx, alertSenderConfig, slotLagIncident)
+		}
 		if a.checkAndAddAlertToCatalog(ctx,
 			alertSenderConfig.Id, thresholdAlertKey,
 			fmt.Sprintf(thresholdAlertMessageTemplate, lowestSlotLagMBAlertThreshold)) {
-			if alertSenderConfig.Sender.getSlotLagMBAlertThreshold() > 0 {
-				if slotInfo.LagInMb > float32(alertSenderConfig.Sender.getSlotLagMBAlertThreshold()) {
-					a.alertToProvider(ctx, alertSenderConfig, thresholdAlertKey,
-						fmt.Sprintf(thresholdAlertMessageTemplate, alertSenderConfig.Sender.getSlotLagMBAlertThreshold()))
-				}
-			} else {
-				if slotInfo.LagInMb > float32(defaultSlotLagMBAlertThreshold) {
-					a.alertToProvider(ctx, alertSenderConfig, thresholdAlertKey,
-						fmt.Sprintf(thresholdAlertMessageTemplate, defaultSlotLagMBAlertThreshold))
-				}
+			if slotInfo.LagInMb > float32(slotLagMBAlertThreshold) {
+				slotLagIncident.Message = fmt.Sprintf(thresholdAlertMessageTemplate, slotLagMBAlertThreshold)
+				a.alertToProvider(ctx, alertSenderConfig, slotLagIncident)
 			}
 		}
 
-		if (slotInfo.WalStatus == "lost" || slotInfo.WalStatus == "unreserved") &&
-			a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, badWalStatusAlertKey, badWalStatusAlertMessage) {
-			a.alertToProvider(ctx, alertSenderConfig, badWalStatusAlertKey, badWalStatusAlertMessage)
+		if !badWalStatus {
+			a.resolveIncident(ctx, alertSenderConfig, badWalStatusIncident)
+		} else if a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, badWalStatusAlertKey, badWalStatusAlertMessage) {
+			a.alertToProvider(ctx, alertSenderConfig, badWalStatusIncident)
 		}
 	}
 }
@@ -256,26 +313,27 @@ func (a *Alerter) AlertIfOpenConnections(ctx context.Context, alertKeys *AlertKe
 		` has exceeded threshold size of %%d connections, currently at %d connections!`,
 		deploymentUIDPrefix, openConnections.UserName, alertKeys.PeerName, openConnections.CurrentOpenConnections)
 
-	if openConnections.CurrentOpenConnections > int64(lowestOpenConnectionsThreshold) {
-		for _, alertSenderConfig := range alertSenderConfigs {
-			if len(alertSenderConfig.AlertForMirrors) > 0 &&
-				!slices.Contains(alertSenderConfig.AlertForMirrors, alertKeys.FlowName) {
-				continue
-			}
-			if a.checkAndAddAlertToCatalog(ctx,
-				alertSenderConfig.Id, alertKey, fmt.Sprintf(alertMessageTemplate, lowestOpenConnectionsThreshold)) {
-				if alertSenderConfig.Sender.getOpenConnectionsAlertThreshold() > 0 {
-					if openConnections.CurrentOpenConnections > int64(alertSenderConfig.Sender.getOpenConnectionsAlertThreshold()) {
-						a.alertToProvider(ctx, alertSenderConfig, alertKey,
-							fmt.Sprintf(alertMessageTemplate, alertSenderConfig.Sender.getOpenConnectionsAlertThreshold()))
-					}
-				} else {
-					if openConnections.CurrentOpenConnections > int64(defaultOpenConnectionsThreshold) {
-						a.alertToProvider(ctx, alertSenderConfig, alertKey,
-							fmt.Sprintf(alertMessageTemplate, defaultOpenConnectionsThreshold))
-					}
-				}
-			}
+	incident := Incident{
+		DedupKey:  incidentDedupKey(alertKeys.FlowName, AlertTypeOpenConnections),
+		Title:     alertKey,
+		FlowName:  alertKeys.FlowName,
+		AlertType: AlertTypeOpenConnections,
+	}
+	for _, alertSenderConfig := range alertSenderConfigs {
+		if len(alertSenderConfig.AlertForMirrors) > 0 &&
+			!slices.Contains(alertSenderConfig.AlertForMirrors, alertKeys.FlowName) {
+			continue
+		}
+		openConnectionsThreshold := defaultOpenConnectionsThreshold
+		if alertSenderConfig.Sender.getOpenConnectionsAlertThreshold() > 0 {
+			openConnectionsThreshold = alertSenderConfig.Sender.getOpenConnectionsAlertThreshold()
+		}
+		if openConnections.CurrentOpenConnections <= int64(openConnectionsThreshold) {
+			a.resolveIncident(ctx, alertSenderConfig, incident)
+		} else if a.checkAndAddAlertToCatalog(ctx,
+			alertSenderConfig.Id, alertKey, fmt.Sprintf(alertMessageTemplate, lowestOpenConnectionsThreshold)) {
+			incident.Message = fmt.Sprintf(alertMessageTemplate, openConnectionsThreshold)
+			a.alertToProvider(ctx, alertSenderConfig, incident)
 		}
 	}
 }
@@ -302,29 +360,266 @@ func (a *Alerter) AlertIfTooLongSinceLastNormalize(ctx context.Context, alertKey
 		deploymentUIDPrefix = fmt.Sprintf("[%s] - ", internal.PeerDBDeploymentUID())
 	}
 
-	if intervalSinceLastNormalize > time.Duration(intervalSinceLastNormalizeThreshold)*time.Minute {
-		alertKey := fmt.Sprintf("%s Too long since last data normalize for PeerDB mirror %s",
-			deploymentUIDPrefix, alertKeys.FlowName)
-		alertMessage := fmt.Sprintf("%sData hasn't been synced to the target for mirror `%s` since the last `%s`."+
-			` This could indicate an issue with the pipeline — please check the UI and logs to confirm.`+
-			` Alternatively, it might be that the source database is idle and not receiving new updates.`, deploymentUIDPrefix,
-			alertKeys.FlowName, intervalSinceLastNormalize)
+	alertKey := fmt.Sprintf("%s Too long since last data normalize for PeerDB mirror %s",
+		deploymentUIDPrefix, alertKeys.FlowName)
+	alertMessage := fmt.Sprintf("%sData hasn't been synced to the target for mirror `%s` since the last `%s`."+
+		` This could indicate an issue with the pipeline — please check the UI and logs to confirm.`+
+		` Alternatively, it might be that the source database is idle and not receiving new updates.`, deploymentUIDPrefix,
+		alertKeys.FlowName, intervalSinceLastNormalize)
+	incident := Incident{
+		DedupKey:  incidentDedupKey(alertKeys.FlowName, AlertTypeNormalizeGap),
+		Title:     alertKey,
+		Message:   alertMessage,
+		FlowName:  alertKeys.FlowName,
+		AlertType: AlertTypeNormalizeGap,
+	}
+	exceeded := intervalSinceLastNormalize > time.Duration(intervalSinceLastNormalizeThreshold)*time.Minute
+
+	for _, alertSenderConfig := range alertSenderConfigs {
+		if len(alertSenderConfig.AlertForMirrors) == 0 ||
+			slices.Contains(alertSenderConfig.AlertForMirrors, alertKeys.FlowName) {
+			if !exceeded {
+				a.resolveIncident(ctx, alertSenderConfig, incident)
+			} else if a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKey, alertMessage) {
+				a.alertToProvider(ctx, alertSenderConfig, incident)
+			}
+		}
+	}
+}
+
+type TableLag struct {
+	TableName  string
+	TableGroup string
+	Lag        time.Duration
+}
+
+// AlertIfTableLag alerts senders configured for the mirror and the table group of tables lagging behind their source
+// by more than thresholdMinutes, and resolves incidents of tables that have caught up
+func (a *Alerter) AlertIfTableLag(ctx context.Context, alertKeys *AlertKeys, tableLags []TableLag, thresholdMinutes uint32) {
+	prefix := deploymentUIDPrefix()
+	checks := make([]incidentCheck, 0, len(tableLags))
+	for _, tableLag := range tableLags {
+		groupInfo := ""
+		if tableLag.TableGroup != "" {
+			groupInfo = fmt.Sprintf(" (table group `%s`)", tableLag.TableGroup)
+		}
+		checks = append(checks, incidentCheck{
+			Incident: Incident{
+				DedupKey: incidentDedupKey(alertKeys.FlowName, AlertTypeTableLag, tableLag.TableName),
+				Title:    fmt.Sprintf("%s Table %s of PeerDB mirror %s is lagging", prefix, tableLag.TableName, alertKeys.FlowName),
+				Message: fmt.Sprintf("%sChanges to table `%s`%s of mirror `%s` took %s from commit at source to reach the target,"+
+					" above the configured threshold of %d minutes.", prefix, tableLag.TableName, groupInfo, alertKeys.FlowName,
+					tableLag.Lag.Round(time.Second), thresholdMinutes),
+				FlowName:  alertKeys.FlowName,
+				AlertType: AlertTypeTableLag,
+			},
+			tableGroup: tableLag.TableGroup,
+			breached:   tableLag.Lag > time.Duration(thresholdMinutes)*time.Minute,
+		})
+	}
+	a.alertOrResolveIncidents(ctx, checks...)
+}
+
+// AlertIfFreshnessSloBreach alerts senders configured for the mirror when its staleness exceeds its freshness SLO,
+// and resolves the incident once the mirror is back within it
+func (a *Alerter) AlertIfFreshnessSloBreach(ctx context.Context, alertKeys *AlertKeys, staleness time.Duration, maxStaleness time.Duration) {
+	prefix := deploymentUIDPrefix()
+	a.alertOrResolveIncidents(ctx, incidentCheck{
+		Incident: Incident{
+			DedupKey: incidentDedupKey(alertKeys.FlowName, AlertTypeFreshnessSlo),
+			Title:    fmt.Sprintf("%s PeerDB mirror %s breached its freshness SLO", prefix, alertKeys.FlowName),
+			Message: fmt.Sprintf("%sData of mirror `%s` is %s behind its source, above its freshness SLO of %s.",
+				prefix, alertKeys.FlowName, staleness.Round(time.Second), maxStaleness),
+			FlowName:  alertKeys.FlowName,
+			AlertType: AlertTypeFreshnessSlo,
+		},
+		breached: staleness > maxStaleness,
+	})
+}
+
+// AlertIfRuleBreached alerts senders configured for the mirror when a metric breaches an alert rule,
+// and resolves the incident of the rule once the metric is back within it
+func (a *Alerter) AlertIfRuleBreached(ctx context.Context, alertKeys *AlertKeys, rule AlertRule, value float64) {
+	prefix := deploymentUIDPrefix()
+	comparison := "above"
+	if rule.Metric == AlertRuleMetricRowsPerSecond {
+		comparison = "below"
+	}
+	a.alertOrResolveIncidents(ctx, incidentCheck{
+		Incident: Incident{
+			DedupKey: incidentDedupKey(alertKeys.FlowName, AlertTypeAlertRule, strconv.FormatInt(int64(rule.ID), 10)),
+			Title:    fmt.Sprintf("%s PeerDB mirror %s breached alert rule %s", prefix, alertKeys.FlowName, rule.Name),
+			Message: fmt.Sprintf("%s%s of mirror `%s` is %.2f, %s the threshold of %.2f set by alert rule `%s`.",
+				prefix, rule.Metric, alertKeys.FlowName, value, comparison, rule.Threshold, rule.Name),
+			FlowName:  alertKeys.FlowName,
+			AlertType: AlertTypeAlertRule,
+		},
+		breached: rule.Breached(value),
+	})
+}
+
+// AlertIfFlowStale alerts when a running mirror has gone longer than the threshold without showing progress,
+// and resolves the incident once it syncs again
+func (a *Alerter) AlertIfFlowStale(ctx context.Context, alertKeys *AlertKeys, sinceProgress time.Duration,
+	threshold time.Duration, reset bool,
+) {
+	prefix := deploymentUIDPrefix()
+	message := fmt.Sprintf("%sMirror `%s` is running but has not synced for %s, above the threshold of %s.",
+		prefix, alertKeys.FlowName, sinceProgress.Round(time.Second), threshold)
+	if reset {
+		message += " Its workflow has been reset to restart sync."
+	}
+	a.alertOrResolveIncidents(ctx, incidentCheck{
+		Incident: Incident{
+			DedupKey:  incidentDedupKey(alertKeys.FlowName, AlertTypeStaleFlow),
+			Title:     fmt.Sprintf("%s PeerDB mirror %s is stale", prefix, alertKeys.FlowName),
+			Message:   message,
+			FlowName:  alertKeys.FlowName,
+			AlertType: AlertTypeStaleFlow,
+		},
+		breached: sinceProgress > threshold,
+	})
+}
+
+// AlertIfQuotaReached alerts senders configured for the mirror when usage reaches a limit of its quota,
+// and resolves the incident of that limit once usage is back under it.
+// Quotas are checked before every batch, so senders are only notified when usage crosses the limit
+func (a *Alerter) AlertIfQuotaReached(ctx context.Context, alertKeys *AlertKeys, quota string, usage int64, limit int64) {
+	dedupKey := incidentDedupKey(alertKeys.FlowName, AlertTypeQuota, quota)
+	reached := usage >= limit
+	if !a.quotaChanged(dedupKey, reached) {
+		return
+	}
+
+	prefix := deploymentUIDPrefix()
+	if !a.alertOrResolveIncidents(ctx, incidentCheck{
+		Incident: Incident{
+			DedupKey: dedupKey,
+			Title:    fmt.Sprintf("%s PeerDB mirror %s reached its %s quota", prefix, alertKeys.FlowName, quota),
+			Message: fmt.Sprintf("%sMirror `%s` is at %d %s, reaching its quota of %d. It waits until usage drops or the quota is raised.",
+				prefix, alertKeys.FlowName, usage, quota, limit),
+			FlowName:  alertKeys.FlowName,
+			AlertType: AlertTypeQuota,
+		},
+		breached: reached,
+	}) {
+		// check again next time
+		a.quotaReached.Delete(dedupKey)
+	}
+}
+
+// incidentCheck is an incident along with whether the condition it reports currently holds
+type incidentCheck struct {
+	Incident
+	// senders limited to table groups are only notified of table incidents in one of them
+	tableGroup string
+	breached   bool
+}
+
+// alertOrResolveIncidents triggers incidents whose condition holds and resolves those that cleared,
+// with the senders configured for their mirror. Returns false when senders could not be loaded
+func (a *Alerter) alertOrResolveIncidents(ctx context.Context, checks ...incidentCheck) bool {
+	alertSenderConfigs, err := a.registerSendersFromPool(ctx)
+	if err != nil {
+		internal.LoggerFromCtx(ctx).Warn("failed to set alert senders", slog.Any("error", err))
+		return false
+	}
 
+	for _, check := range checks {
 		for _, alertSenderConfig := range alertSenderConfigs {
-			if len(alertSenderConfig.AlertForMirrors) == 0 ||
-				slices.Contains(alertSenderConfig.AlertForMirrors, alertKeys.FlowName) {
-				if a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKey, alertMessage) {
-					a.alertToProvider(ctx, alertSenderConfig, alertKey, alertMessage)
-				}
+			if len(alertSenderConfig.AlertForMirrors) > 0 && !slices.Contains(alertSenderConfig.AlertForMirrors, check.FlowName) {
+				continue
+			}
+			if check.AlertType == AlertTypeTableLag && len(alertSenderConfig.AlertForTableGroups) > 0 &&
+				!slices.Contains(alertSenderConfig.AlertForTableGroups, check.tableGroup) {
+				continue
+			}
+			if !check.breached {
+				a.resolveIncident(ctx, alertSenderConfig, check.Incident)
+			} else if a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, check.Title, check.Message) {
+				a.alertToProvider(ctx, alertSenderConfig, check.Incident)
 			}
 		}
 	}
+	return true
+}
+
+func deploymentUIDPrefix() string {
+	if deploymentUID := internal.PeerDBDeploymentUID(); deploymentUID != "" {
+		return fmt.Sprintf("[%s] - ", deploymentUID)
+	}
+	return ""
+}
+
+// quotaChanged records whether a quota is reached, reporting if that differs from when it was last checked,
+// the first check after start counts as a change so incidents left open by an earlier run get resolved
+func (a *Alerter) quotaChanged(dedupKey string, reached bool) bool {
+	previous, loaded := a.quotaReached.Swap(dedupKey, reached)
+	return !loaded || previous.(bool) != reached
 }
 
-func (a *Alerter) alertToProvider(ctx context.Context, alertSenderConfig AlertSenderConfig, alertKey string, alertMessage string) {
-	if err := alertSenderConfig.Sender.sendAlert(ctx, alertKey, alertMessage); err != nil {
-		internal.LoggerFromCtx(ctx).Warn("failed to send alert", slog.Any("error", err))
+// incidentDedupKey ties incidents to the mirror and type of alert, qualifiers tell apart e.g. tables of the same mirror
+func incidentDedupKey(flowName string, alertType AlertType, qualifiers ...string) string {
+	parts := []string{"peerdb"}
+	if deploymentUID := internal.PeerDBDeploymentUID(); deploymentUID != "" {
+		parts = append(parts, deploymentUID)
+	}
+	parts = append(parts, flowName, string(alertType))
+	return strings.Join(append(parts, qualifiers...), ":")
+}
+
+func (a *Alerter) alertToProvider(ctx context.Context, alertSenderConfig AlertSenderConfig, incident Incident) {
+	incidentSender, ok := alertSenderConfig.Sender.(IncidentAlertSender)
+	if !ok {
+		if err := alertSenderConfig.Sender.sendAlert(ctx, incident.Title, incident.Message); err != nil {
+			internal.LoggerFromCtx(ctx).Warn("failed to send alert", slog.Any("error", err))
+		}
+		return
+	}
+
+	if err := incidentSender.triggerIncident(ctx, incident); err != nil {
+		internal.LoggerFromCtx(ctx).Warn("failed to trigger incident", slog.Any("error", err))
+		return
+	}
+	if _, err := a.CatalogPool.Exec(ctx,
+		"INSERT INTO peerdb_stats.alert_incidents(alert_config_id,dedup_key) VALUES($1,$2) ON CONFLICT DO NOTHING",
+		alertSenderConfig.Id, incident.DedupKey,
+	); err != nil {
+		internal.LoggerFromCtx(ctx).Warn("failed to record open incident", slog.Any("error", err))
+	}
+}
+
+// resolveIncident resolves an incident triggered earlier for a condition that has since cleared,
+// only senders that track incidents are notified, and only for incidents still open
+func (a *Alerter) resolveIncident(ctx context.Context, alertSenderConfig AlertSenderConfig, incident Incident) {
+	incidentSender, ok := alertSenderConfig.Sender.(IncidentAlertSender)
+	if !ok {
+		return
+	}
+
+	logger := internal.LoggerFromCtx(ctx)
+	tag, err := a.CatalogPool.Exec(ctx,
+		"DELETE FROM peerdb_stats.alert_incidents WHERE alert_config_id=$1 AND dedup_key=$2",
+		alertSenderConfig.Id, incident.DedupKey)
+	if err != nil {
+		logger.Warn("failed to check for open incident", slog.Any("error", err))
+		return
+	} else if tag.RowsAffected() == 0 {
+		return
+	}
+
+	if err := incidentSender.resolveIncident(ctx, incident); err != nil {
+		logger.Warn("failed to resolve incident", slog.String("dedupKey", incident.DedupKey), slog.Any("error", err))
+		// keep incident open so resolving is retried on next check
+		if _, err := a.CatalogPool.Exec(ctx,
+			"INSERT INTO peerdb_stats.alert_incidents(alert_config_id,dedup_key) VALUES($1,$2) ON CONFLICT DO NOTHING",
+			alertSenderConfig.Id, incident.DedupKey,
+		); err != nil {
+			logger.Warn("failed to record open incident", slog.Any("error", err))
+		}
+		return
 	}
+	logger.Info("resolved incident", slog.String("dedupKey", incident.DedupKey))
 }
 
 // Only raises an alert if another alert with the same key hasn't been raised
@@ -481,6 +776,9 @@ func (a *Alerter) logFlowErrorInternal(ctx context.Context, flowName, errorType
 	errorClass, errInfo := GetErrorClass(ctx, inErr)
 	tags = append(tags, "errorClass:"+errorClass.String(), "errorAction:"+errorClass.ErrorAction().String())
 
+	if errorClass.ErrorAction() == NotifyUser {
+		a.alertFlowErrorToWebhooks(ctx, flowName, errorClass, inErr)
+	}
 	if !internal.PeerDBTelemetryErrorActionBasedAlertingEnabled() || errorClass.ErrorAction() == NotifyTelemetry {
 		// Warnings alert us just like errors until there's a customer warning system
 		a.sendTelemetryMessage(ctx, logger, flowName, inErrWithStack, telemetry.ERROR, tags...)
@@ -500,6 +798,46 @@ func (a *Alerter) logFlowErrorInternal(ctx context.Context, flowName, errorType
 	a.otelManager.Metrics.ErrorEmittedGauge.Record(ctx, 1, errorAttributeSet)
 }
 
+// alertFlowErrorToWebhooks sends errors users can act on to webhooks opting into flow errors,
+// other senders only get alerts on thresholds
+func (a *Alerter) alertFlowErrorToWebhooks(ctx context.Context, flowName string, errorClass ErrorClass, inErr error) {
+	alertSenderConfigs, err := a.registerSendersFromPool(ctx)
+	if err != nil {
+		internal.LoggerFromCtx(ctx).Warn("failed to set alert senders", slog.Any("error", err))
+		return
+	}
+
+	deploymentUIDPrefix := ""
+	if internal.PeerDBDeploymentUID() != "" {
+		deploymentUIDPrefix = fmt.Sprintf("[%s] - ", internal.PeerDBDeploymentUID())
+	}
+
+	alertKey := fmt.Sprintf("%s PeerDB mirror %s failed with %s", deploymentUIDPrefix, flowName, errorClass)
+	alertMessage := fmt.Sprintf("%sMirror `%s` failed with error class %s: %s", deploymentUIDPrefix, flowName, errorClass, inErr)
+	incident := Incident{
+		DedupKey:  incidentDedupKey(flowName, AlertTypeFlowError, errorClass.String()),
+		Title:     alertKey,
+		Message:   alertMessage,
+		FlowName:  flowName,
+		AlertType: AlertTypeFlowError,
+	}
+	for _, alertSenderConfig := range alertSenderConfigs {
+		webhookSender, ok := alertSenderConfig.Sender.(*WebhookAlertSender)
+		if !ok || !webhookSender.sendFlowErrors {
+			continue
+		}
+		if len(alertSenderConfig.AlertForMirrors) > 0 && !slices.Contains(alertSenderConfig.AlertForMirrors, flowName) {
+			continue
+		}
+		// errors don't clear like thresholds, so no incident is kept open for them
+		if a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKey, alertMessage) {
+			if err := webhookSender.triggerIncident(ctx, incident); err != nil {
+				internal.LoggerFromCtx(ctx).Warn("failed to send flow error to webhook", slog.Any("error", err))
+			}
+		}
+	}
+}
+
 func (a *Alerter) LogFlowError(ctx context.Context, flowName string, inErr error) error {
 	logger := internal.LoggerFromCtx(ctx)
 	a.logFlowErrorInternal(ctx, flowName, "error", inErr, logger.Error)
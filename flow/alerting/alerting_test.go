@@ -0,0 +1,29 @@
+package alerting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaChanged(t *testing.T) {
+	var a Alerter
+	rowsKey := incidentDedupKey("mirror", AlertTypeQuota, "rows per day")
+	otherKey := incidentDedupKey("other", AlertTypeQuota, "rows per day")
+
+	// first check after start resolves incidents left open by an earlier run
+	require.True(t, a.quotaChanged(rowsKey, false))
+	require.False(t, a.quotaChanged(rowsKey, false))
+
+	// reaching the quota alerts once, however often it is checked
+	require.True(t, a.quotaChanged(rowsKey, true))
+	require.False(t, a.quotaChanged(rowsKey, true))
+	require.False(t, a.quotaChanged(rowsKey, true))
+
+	// quotas of other mirrors are tracked apart
+	require.True(t, a.quotaChanged(otherKey, true))
+	require.False(t, a.quotaChanged(otherKey, true))
+
+	require.True(t, a.quotaChanged(rowsKey, false))
+	require.False(t, a.quotaChanged(rowsKey, false))
+}
@@ -54,6 +54,7 @@ const (
 	ErrorSourcePostgresCatalog ErrorSource = "postgres_catalog"
 	ErrorSourceSSH             ErrorSource = "ssh_tunnel"
 	ErrorSourceNet             ErrorSource = "net"
+	ErrorSourceSchemaRegistry  ErrorSource = "schema_registry"
 	ErrorSourceOther           ErrorSource = "other"
 )
 
@@ -112,6 +113,9 @@ var (
 	ErrorNotifyTerminate = ErrorClass{
 		Class: "NOTIFY_TERMINATE", action: NotifyUser,
 	}
+	ErrorNotifySchemaRegistry = ErrorClass{
+		Class: "NOTIFY_SCHEMA_REGISTRY", action: NotifyUser,
+	}
 	ErrorInternal = ErrorClass{
 		Class: "INTERNAL", action: NotifyTelemetry,
 	}
@@ -502,6 +506,14 @@ func GetErrorClass(ctx context.Context, err error) (ErrorClass, ErrorInfo) {
 		}
 	}
 
+	var schemaRegistryError *exceptions.SchemaRegistryError
+	if errors.As(err, &schemaRegistryError) {
+		return ErrorNotifySchemaRegistry, ErrorInfo{
+			Source: ErrorSourceSchemaRegistry,
+			Code:   schemaRegistryError.Subject,
+		}
+	}
+
 	var numericOutOfRangeError *exceptions.NumericOutOfRangeError
 	if errors.As(err, &numericOutOfRangeError) {
 		return ErrorLossyConversion, ErrorInfo{
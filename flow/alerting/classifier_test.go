@@ -314,6 +314,16 @@ func TestPeerCreateTimeoutErrorShouldBeConnectivity(t *testing.T) {
 	}, errInfo, "Unexpected error info")
 }
 
+func TestSchemaRegistryErrorShouldNotifyUser(t *testing.T) {
+	err := exceptions.NewSchemaRegistryError(errors.New("schema being registered is incompatible with an earlier schema"), "orders-value")
+	errorClass, errInfo := GetErrorClass(t.Context(), fmt.Errorf("failed to encode record: %w", err))
+	assert.Equal(t, ErrorNotifySchemaRegistry, errorClass, "Unexpected error class")
+	assert.Equal(t, ErrorInfo{
+		Source: ErrorSourceSchemaRegistry,
+		Code:   "orders-value",
+	}, errInfo, "Unexpected error info")
+}
+
 func TestPostgresCouldNotFindRecordWalErrorShouldBeRecoverable(t *testing.T) {
 	// Simulate a "could not find record while sending logically-decoded data" error
 	err := &exceptions.PostgresWalError{
@@ -7,3 +7,20 @@ type AlertSender interface {
 	getSlotLagMBAlertThreshold() uint32
 	getOpenConnectionsAlertThreshold() uint32
 }
+
+type Incident struct {
+	// identifies the condition, so repeated alerts update one incident and resolving closes it
+	DedupKey  string
+	Title     string
+	Message   string
+	FlowName  string
+	AlertType AlertType
+}
+
+// IncidentAlertSender is implemented by incident management services,
+// incidents get resolved once the condition that triggered them clears
+type IncidentAlertSender interface {
+	AlertSender
+	triggerIncident(ctx context.Context, incident Incident) error
+	resolveIncident(ctx context.Context, incident Incident) error
+}
@@ -0,0 +1,146 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	opsgenieDefaultAPIURL = "https://api.opsgenie.com"
+	// Opsgenie rejects alert messages longer than this
+	opsgenieMaxMessageLength = 130
+)
+
+type opsgenieAlertConfig struct {
+	APIKey string `json:"api_key"`
+	// https://api.eu.opsgenie.com for EU accounts
+	APIURL                        string                 `json:"api_url"`
+	SeverityMapping               map[AlertType]Severity `json:"severity_mapping"`
+	SlotLagMBAlertThreshold       uint32                 `json:"slot_lag_mb_alert_threshold"`
+	OpenConnectionsAlertThreshold uint32                 `json:"open_connections_alert_threshold"`
+}
+
+type OpsgenieAlertSender struct {
+	http                          *http.Client
+	apiKey                        string
+	apiURL                        string
+	severityMapping               map[AlertType]Severity
+	slotLagMBAlertThreshold       uint32
+	openConnectionsAlertThreshold uint32
+}
+
+type opsgenieAlert struct {
+	Details     map[string]string `json:"details,omitempty"`
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Tags        []string          `json:"tags,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+func newOpsgenieAlertSender(config *opsgenieAlertConfig) *OpsgenieAlertSender {
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = opsgenieDefaultAPIURL
+	}
+	return &OpsgenieAlertSender{
+		http:                          &http.Client{Timeout: 10 * time.Second},
+		apiKey:                        config.APIKey,
+		apiURL:                        apiURL,
+		severityMapping:               config.SeverityMapping,
+		slotLagMBAlertThreshold:       config.SlotLagMBAlertThreshold,
+		openConnectionsAlertThreshold: config.OpenConnectionsAlertThreshold,
+	}
+}
+
+func (o *OpsgenieAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return o.slotLagMBAlertThreshold
+}
+
+func (o *OpsgenieAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return o.openConnectionsAlertThreshold
+}
+
+func opsgeniePriority(severity Severity) string {
+	switch severity {
+	case SeverityCritical:
+		return "P1"
+	case SeverityError:
+		return "P2"
+	case SeverityWarning:
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+func (o *OpsgenieAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	return o.triggerIncident(ctx, Incident{DedupKey: alertTitle, Title: alertTitle, Message: alertMessage})
+}
+
+func (o *OpsgenieAlertSender) triggerIncident(ctx context.Context, incident Incident) error {
+	message := incident.Title
+	if len(message) > opsgenieMaxMessageLength {
+		message = message[:opsgenieMaxMessageLength]
+	}
+	alert := opsgenieAlert{
+		Message:     message,
+		Alias:       incident.DedupKey,
+		Description: incident.Message,
+		Priority:    opsgeniePriority(alertSeverity(o.severityMapping, incident.AlertType)),
+		Source:      "PeerDB",
+	}
+	if incident.AlertType != "" {
+		alert.Tags = []string{string(incident.AlertType)}
+	}
+	if incident.FlowName != "" {
+		alert.Details = map[string]string{"flow_name": incident.FlowName}
+	}
+	return o.post(ctx, "/v2/alerts", alert)
+}
+
+func (o *OpsgenieAlertSender) resolveIncident(ctx context.Context, incident Incident) error {
+	return o.post(ctx, "/v2/alerts/"+url.PathEscape(incident.DedupKey)+"/close?identifierType=alias", opsgenieClose{
+		Source: "PeerDB",
+		Note:   "condition cleared",
+	})
+}
+
+func (o *OpsgenieAlertSender) post(ctx context.Context, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to serialize Opsgenie request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.apiKey)
+
+	resp, err := o.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("opsgenie request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// requests are processed asynchronously
+	if resp.StatusCode != http.StatusAccepted {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response from Opsgenie. status: %d. body: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package alerting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpsgenieAlertSenderRequests(t *testing.T) {
+	var body []byte
+	var requestURI, authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		requestURI = r.RequestURI
+		authHeader = r.Header.Get("Authorization")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := newOpsgenieAlertSender(&opsgenieAlertConfig{
+		APIKey:          "key",
+		APIURL:          server.URL,
+		SeverityMapping: map[AlertType]Severity{AlertTypeSlotLag: SeverityCritical},
+	})
+
+	incident := Incident{DedupKey: "flow/slot lag", Title: "slot lag", Message: "lag is 5GB", FlowName: "flow", AlertType: AlertTypeSlotLag}
+	require.NoError(t, sender.triggerIncident(t.Context(), incident))
+	require.Equal(t, "/v2/alerts", requestURI)
+	require.Equal(t, "GenieKey key", authHeader)
+	require.JSONEq(t, `{
+		"message": "slot lag",
+		"alias": "flow/slot lag",
+		"description": "lag is 5GB",
+		"priority": "P1",
+		"source": "PeerDB",
+		"tags": ["slot_lag"],
+		"details": {"flow_name": "flow"}
+	}`, string(body))
+
+	require.NoError(t, sender.resolveIncident(t.Context(), incident))
+	require.Equal(t, "/v2/alerts/flow%2Fslot%20lag/close?identifierType=alias", requestURI)
+	require.JSONEq(t, `{"source": "PeerDB", "note": "condition cleared"}`, string(body))
+
+	require.NoError(t, sender.triggerIncident(t.Context(), Incident{DedupKey: "long", Title: strings.Repeat("a", 200)}))
+	require.Contains(t, string(body), `"message":"`+strings.Repeat("a", opsgenieMaxMessageLength)+`"`)
+	require.NotContains(t, string(body), `"tags"`)
+}
+
+func TestOpsgenieAlertSenderPriority(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := newOpsgenieAlertSender(&opsgenieAlertConfig{
+		APIKey: "key",
+		APIURL: server.URL,
+		SeverityMapping: map[AlertType]Severity{
+			AlertTypeNormalizeGap: SeverityInfo,
+			AlertTypeTableLag:     SeverityError,
+		},
+	})
+
+	for _, tc := range []struct {
+		alertType AlertType
+		priority  string
+	}{
+		{AlertTypeBadWALStatus, "P1"},
+		{AlertTypeTableLag, "P2"},
+		{AlertTypeOpenConnections, "P3"},
+		{AlertTypeNormalizeGap, "P5"},
+	} {
+		t.Run(string(tc.alertType), func(t *testing.T) {
+			require.NoError(t, sender.triggerIncident(t.Context(), Incident{DedupKey: "key", Title: "title", AlertType: tc.alertType}))
+			require.Contains(t, string(body), `"priority":"`+tc.priority+`"`)
+		})
+	}
+}
+
+func TestOpsgenieAlertSenderUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Key format is not valid!"}`))
+	}))
+	defer server.Close()
+
+	sender := newOpsgenieAlertSender(&opsgenieAlertConfig{APIKey: "bad", APIURL: server.URL})
+	err := sender.resolveIncident(t.Context(), Incident{DedupKey: "key"})
+	require.ErrorContains(t, err, "status: 401")
+	require.ErrorContains(t, err, "Key format is not valid!")
+
+	require.Equal(t, opsgenieDefaultAPIURL, newOpsgenieAlertSender(&opsgenieAlertConfig{APIKey: "key"}).apiURL)
+}
@@ -0,0 +1,119 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+type pagerDutyAlertConfig struct {
+	RoutingKey                    string                 `json:"routing_key"`
+	SeverityMapping               map[AlertType]Severity `json:"severity_mapping"`
+	SlotLagMBAlertThreshold       uint32                 `json:"slot_lag_mb_alert_threshold"`
+	OpenConnectionsAlertThreshold uint32                 `json:"open_connections_alert_threshold"`
+}
+
+type PagerDutyAlertSender struct {
+	http                          *http.Client
+	eventsURL                     string
+	routingKey                    string
+	severityMapping               map[AlertType]Severity
+	slotLagMBAlertThreshold       uint32
+	openConnectionsAlertThreshold uint32
+}
+
+type pagerDutyPayload struct {
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      Severity          `json:"severity"`
+	Class         string            `json:"class,omitempty"`
+}
+
+type pagerDutyEvent struct {
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+}
+
+func newPagerDutyAlertSender(config *pagerDutyAlertConfig) *PagerDutyAlertSender {
+	return &PagerDutyAlertSender{
+		http:                          &http.Client{Timeout: 10 * time.Second},
+		eventsURL:                     pagerDutyEventsURL,
+		routingKey:                    config.RoutingKey,
+		severityMapping:               config.SeverityMapping,
+		slotLagMBAlertThreshold:       config.SlotLagMBAlertThreshold,
+		openConnectionsAlertThreshold: config.OpenConnectionsAlertThreshold,
+	}
+}
+
+func (p *PagerDutyAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return p.slotLagMBAlertThreshold
+}
+
+func (p *PagerDutyAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return p.openConnectionsAlertThreshold
+}
+
+func (p *PagerDutyAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	return p.triggerIncident(ctx, Incident{DedupKey: alertTitle, Title: alertTitle, Message: alertMessage})
+}
+
+func (p *PagerDutyAlertSender) triggerIncident(ctx context.Context, incident Incident) error {
+	details := map[string]string{"message": incident.Message}
+	if incident.FlowName != "" {
+		details["flow_name"] = incident.FlowName
+	}
+	return p.sendEvent(ctx, &pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    incident.DedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:       incident.Title,
+			Source:        "PeerDB",
+			Severity:      alertSeverity(p.severityMapping, incident.AlertType),
+			Class:         string(incident.AlertType),
+			CustomDetails: details,
+		},
+	})
+}
+
+func (p *PagerDutyAlertSender) resolveIncident(ctx context.Context, incident Incident) error {
+	return p.sendEvent(ctx, &pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "resolve",
+		DedupKey:    incident.DedupKey,
+	})
+}
+
+func (p *PagerDutyAlertSender) sendEvent(ctx context.Context, event *pagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize PagerDuty event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.eventsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("pagerduty request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response from PagerDuty. status: %d. body: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
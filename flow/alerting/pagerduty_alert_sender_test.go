@@ -0,0 +1,101 @@
+package alerting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerDutyAlertSenderEvents(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := newPagerDutyAlertSender(&pagerDutyAlertConfig{
+		RoutingKey:      "routing",
+		SeverityMapping: map[AlertType]Severity{AlertTypeSlotLag: SeverityCritical},
+	})
+	sender.eventsURL = server.URL
+
+	incident := Incident{DedupKey: "flow:slot_lag", Title: "slot lag", Message: "lag is 5GB", FlowName: "flow", AlertType: AlertTypeSlotLag}
+	require.NoError(t, sender.triggerIncident(t.Context(), incident))
+	require.JSONEq(t, `{
+		"routing_key": "routing",
+		"event_action": "trigger",
+		"dedup_key": "flow:slot_lag",
+		"payload": {
+			"summary": "slot lag",
+			"source": "PeerDB",
+			"severity": "critical",
+			"class": "slot_lag",
+			"custom_details": {"message": "lag is 5GB", "flow_name": "flow"}
+		}
+	}`, string(body))
+
+	require.NoError(t, sender.resolveIncident(t.Context(), incident))
+	require.JSONEq(t, `{"routing_key": "routing", "event_action": "resolve", "dedup_key": "flow:slot_lag"}`, string(body))
+
+	require.NoError(t, sender.sendAlert(t.Context(), "title", "message"))
+	require.JSONEq(t, `{
+		"routing_key": "routing",
+		"event_action": "trigger",
+		"dedup_key": "title",
+		"payload": {"summary": "title", "source": "PeerDB", "severity": "error", "custom_details": {"message": "message"}}
+	}`, string(body))
+}
+
+func TestPagerDutyAlertSenderSeverity(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := newPagerDutyAlertSender(&pagerDutyAlertConfig{
+		RoutingKey: "routing",
+		SeverityMapping: map[AlertType]Severity{
+			AlertTypeNormalizeGap: SeverityInfo,
+			AlertTypeTableLag:     "urgent",
+		},
+	})
+	sender.eventsURL = server.URL
+
+	for _, tc := range []struct {
+		alertType AlertType
+		severity  Severity
+	}{
+		{AlertTypeNormalizeGap, SeverityInfo},
+		{AlertTypeTableLag, SeverityWarning},
+		{AlertTypeBadWALStatus, SeverityCritical},
+		{"", SeverityError},
+	} {
+		t.Run(string(tc.alertType), func(t *testing.T) {
+			require.NoError(t, sender.triggerIncident(t.Context(), Incident{DedupKey: "key", Title: "title", AlertType: tc.alertType}))
+			require.Contains(t, string(body), `"severity":"`+string(tc.severity)+`"`)
+		})
+	}
+}
+
+func TestPagerDutyAlertSenderUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"invalid event"}`))
+	}))
+	defer server.Close()
+
+	sender := newPagerDutyAlertSender(&pagerDutyAlertConfig{RoutingKey: "routing"})
+	sender.eventsURL = server.URL
+
+	err := sender.triggerIncident(t.Context(), Incident{DedupKey: "key", Title: "title"})
+	require.ErrorContains(t, err, "status: 400")
+	require.ErrorContains(t, err, "invalid event")
+}
@@ -3,6 +3,88 @@ package alerting
 type ServiceType string
 
 const (
-	SLACK ServiceType = "slack"
-	EMAIL ServiceType = "email"
+	SLACK     ServiceType = "slack"
+	EMAIL     ServiceType = "email"
+	PAGERDUTY ServiceType = "pagerduty"
+	OPSGENIE  ServiceType = "opsgenie"
+	WEBHOOK   ServiceType = "webhook"
 )
+
+type AlertType string
+
+const (
+	AlertTypeSlotLag         AlertType = "slot_lag"
+	AlertTypeBadWALStatus    AlertType = "bad_wal_status"
+	AlertTypeOpenConnections AlertType = "open_connections"
+	AlertTypeNormalizeGap    AlertType = "normalize_gap"
+	AlertTypeTableLag        AlertType = "table_lag"
+	AlertTypeFreshnessSlo    AlertType = "freshness_slo"
+	AlertTypeFlowError       AlertType = "flow_error"
+	AlertTypeAlertRule       AlertType = "alert_rule"
+	AlertTypeStaleFlow       AlertType = "stale_flow"
+	AlertTypeQuota           AlertType = "quota"
+)
+
+// Severity follows PagerDuty event severities, Opsgenie priorities are derived from it
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityError    Severity = "error"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+var defaultAlertSeverities = map[AlertType]Severity{
+	AlertTypeSlotLag:         SeverityWarning,
+	AlertTypeBadWALStatus:    SeverityCritical,
+	AlertTypeOpenConnections: SeverityWarning,
+	AlertTypeNormalizeGap:    SeverityError,
+	AlertTypeTableLag:        SeverityWarning,
+	AlertTypeFreshnessSlo:    SeverityError,
+	AlertTypeFlowError:       SeverityError,
+	AlertTypeAlertRule:       SeverityWarning,
+	AlertTypeStaleFlow:       SeverityCritical,
+	AlertTypeQuota:           SeverityWarning,
+}
+
+// alertSeverity looks up the severity configured for an alert type, falling back to defaults
+func alertSeverity(severityMapping map[AlertType]Severity, alertType AlertType) Severity {
+	if severity, ok := severityMapping[alertType]; ok {
+		switch severity {
+		case SeverityCritical, SeverityError, SeverityWarning, SeverityInfo:
+			return severity
+		}
+	}
+	if severity, ok := defaultAlertSeverities[alertType]; ok {
+		return severity
+	}
+	return SeverityError
+}
+
+// metrics alert rules are defined on, matching the peerdb_stats.alert_rules check constraint
+const (
+	AlertRuleMetricSlotLagMB     = "slot_lag_mb"
+	AlertRuleMetricLagSeconds    = "lag_seconds"
+	AlertRuleMetricErrorRate     = "error_rate"
+	AlertRuleMetricRowsPerSecond = "rows_per_second"
+)
+
+var AlertRuleMetrics = []string{
+	AlertRuleMetricSlotLagMB, AlertRuleMetricLagSeconds, AlertRuleMetricErrorRate, AlertRuleMetricRowsPerSecond,
+}
+
+type AlertRule struct {
+	Name      string
+	Metric    string
+	Threshold float64
+	ID        int32
+}
+
+// Breached checks a value against the rule, rows_per_second is a floor while other metrics are ceilings
+func (r AlertRule) Breached(value float64) bool {
+	if r.Metric == AlertRuleMetricRowsPerSecond {
+		return value < r.Threshold
+	}
+	return value > r.Threshold
+}
@@ -0,0 +1,143 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const (
+	webhookEventTrigger = "trigger"
+	webhookEventResolve = "resolve"
+)
+
+// used when no payload_template is configured, renders every field of webhookPayload as JSON
+const defaultWebhookPayloadTemplate = `{{ json . }}`
+
+type webhookAlertConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// Go text/template rendered with webhookPayload, `json` function quotes values
+	PayloadTemplate               string                 `json:"payload_template"`
+	SeverityMapping               map[AlertType]Severity `json:"severity_mapping"`
+	SendFlowErrors                bool                   `json:"send_flow_errors"`
+	SlotLagMBAlertThreshold       uint32                 `json:"slot_lag_mb_alert_threshold"`
+	OpenConnectionsAlertThreshold uint32                 `json:"open_connections_alert_threshold"`
+}
+
+type WebhookAlertSender struct {
+	http                          *http.Client
+	payloadTemplate               *template.Template
+	headers                       map[string]string
+	severityMapping               map[AlertType]Severity
+	url                           string
+	sendFlowErrors                bool
+	slotLagMBAlertThreshold       uint32
+	openConnectionsAlertThreshold uint32
+}
+
+type webhookPayload struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Event         string    `json:"event"`
+	DedupKey      string    `json:"dedup_key"`
+	Title         string    `json:"title"`
+	Message       string    `json:"message"`
+	FlowName      string    `json:"flow_name"`
+	AlertType     AlertType `json:"alert_type"`
+	Severity      Severity  `json:"severity"`
+	DeploymentUID string    `json:"deployment_uid"`
+}
+
+func parseWebhookPayloadTemplate(payloadTemplate string) (*template.Template, error) {
+	if payloadTemplate == "" {
+		payloadTemplate = defaultWebhookPayloadTemplate
+	}
+	return template.New("payload").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Option("missingkey=error").Parse(payloadTemplate)
+}
+
+func newWebhookAlertSender(config *webhookAlertConfig) (*WebhookAlertSender, error) {
+	payloadTemplate, err := parseWebhookPayloadTemplate(config.PayloadTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook payload template: %w", err)
+	}
+	return &WebhookAlertSender{
+		http:                          &http.Client{Timeout: 10 * time.Second},
+		payloadTemplate:               payloadTemplate,
+		headers:                       config.Headers,
+		severityMapping:               config.SeverityMapping,
+		url:                           config.URL,
+		sendFlowErrors:                config.SendFlowErrors,
+		slotLagMBAlertThreshold:       config.SlotLagMBAlertThreshold,
+		openConnectionsAlertThreshold: config.OpenConnectionsAlertThreshold,
+	}, nil
+}
+
+func (w *WebhookAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return w.slotLagMBAlertThreshold
+}
+
+func (w *WebhookAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return w.openConnectionsAlertThreshold
+}
+
+func (w *WebhookAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	return w.triggerIncident(ctx, Incident{DedupKey: alertTitle, Title: alertTitle, Message: alertMessage})
+}
+
+func (w *WebhookAlertSender) triggerIncident(ctx context.Context, incident Incident) error {
+	return w.post(ctx, webhookEventTrigger, incident)
+}
+
+func (w *WebhookAlertSender) resolveIncident(ctx context.Context, incident Incident) error {
+	return w.post(ctx, webhookEventResolve, incident)
+}
+
+func (w *WebhookAlertSender) post(ctx context.Context, event string, incident Incident) error {
+	var body bytes.Buffer
+	if err := w.payloadTemplate.Execute(&body, webhookPayload{
+		Timestamp:     time.Now().UTC(),
+		Event:         event,
+		DedupKey:      incident.DedupKey,
+		Title:         incident.Title,
+		Message:       incident.Message,
+		FlowName:      incident.FlowName,
+		AlertType:     incident.AlertType,
+		Severity:      alertSeverity(w.severityMapping, incident.AlertType),
+		DeploymentUID: internal.PeerDBDeploymentUID(),
+	}); err != nil {
+		return fmt.Errorf("failed to render webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response from webhook. status: %d. body: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package alerting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookAlertSenderTemplatedPayload(t *testing.T) {
+	var body []byte
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		authHeader = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender, err := newWebhookAlertSender(&webhookAlertConfig{
+		URL:             server.URL,
+		Headers:         map[string]string{"Authorization": "Bearer token"},
+		PayloadTemplate: `{"text": {{ json .Message }}, "state": "{{ .Event }}", "severity": "{{ .Severity }}"}`,
+		SeverityMapping: map[AlertType]Severity{AlertTypeSlotLag: SeverityCritical},
+	})
+	require.NoError(t, err)
+
+	incident := Incident{DedupKey: "key", Title: "title", Message: `lag of "slot"`, FlowName: "flow", AlertType: AlertTypeSlotLag}
+	require.NoError(t, sender.triggerIncident(t.Context(), incident))
+	require.JSONEq(t, `{"text": "lag of \"slot\"", "state": "trigger", "severity": "critical"}`, string(body))
+	require.Equal(t, "Bearer token", authHeader)
+
+	require.NoError(t, sender.resolveIncident(t.Context(), incident))
+	require.JSONEq(t, `{"text": "lag of \"slot\"", "state": "resolve", "severity": "critical"}`, string(body))
+
+	_, err = newWebhookAlertSender(&webhookAlertConfig{URL: server.URL, PayloadTemplate: "{{ .Message"})
+	require.Error(t, err)
+}
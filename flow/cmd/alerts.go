@@ -2,16 +2,21 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/jackc/pgx/v5"
 
+	"github.com/PeerDB-io/peerdb/flow/alerting"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
 func (h *FlowRequestHandler) GetAlertConfigs(ctx context.Context, req *protos.GetAlertConfigsRequest) (*protos.GetAlertConfigsResponse, error) {
-	rows, err := h.pool.Query(ctx, "SELECT id,service_type,service_config,enc_key_id,alert_for_mirrors from peerdb_stats.alerting_config")
+	rows, err := h.pool.Query(ctx, `SELECT id,service_type,service_config,enc_key_id,alert_for_mirrors,alert_for_table_groups
+		from peerdb_stats.alerting_config`)
 	if err != nil {
 		return nil, err
 	}
@@ -20,7 +25,8 @@ func (h *FlowRequestHandler) GetAlertConfigs(ctx context.Context, req *protos.Ge
 		var serviceConfigPayload []byte
 		var encKeyID string
 		config := &protos.AlertConfig{}
-		if err := row.Scan(&config.Id, &config.ServiceType, &serviceConfigPayload, &encKeyID, &config.AlertForMirrors); err != nil {
+		if err := row.Scan(&config.Id, &config.ServiceType, &serviceConfigPayload, &encKeyID, &config.AlertForMirrors,
+			&config.AlertForTableGroups); err != nil {
 			return nil, err
 		}
 		serviceConfig, err := internal.Decrypt(ctx, encKeyID, serviceConfigPayload)
@@ -55,28 +61,33 @@ func (h *FlowRequestHandler) PostAlertConfig(ctx context.Context, req *protos.Po
 				service_type,
 				service_config,
 				enc_key_id,
-				alert_for_mirrors
+				alert_for_mirrors,
+				alert_for_table_groups
 			) VALUES (
 				$1,
 				$2,
 				$3,
-				$4
+				$4,
+				$5
 			) RETURNING id`,
 			req.Config.ServiceType,
 			serviceConfig,
 			key.ID,
 			req.Config.AlertForMirrors,
+			req.Config.AlertForTableGroups,
 		).Scan(&id); err != nil {
 			return nil, err
 		}
 		return &protos.PostAlertConfigResponse{Id: id}, nil
 	} else if _, err := h.pool.Exec(
 		ctx,
-		"update peerdb_stats.alerting_config set service_type = $1, service_config = $2, enc_key_id = $3, alert_for_mirrors = $4 where id = $5",
+		`update peerdb_stats.alerting_config set service_type = $1, service_config = $2, enc_key_id = $3, alert_for_mirrors = $4,
+		alert_for_table_groups = $5 where id = $6`,
 		req.Config.ServiceType,
 		serviceConfig,
 		key.ID,
 		req.Config.AlertForMirrors,
+		req.Config.AlertForTableGroups,
 		req.Config.Id,
 	); err != nil {
 		return nil, err
@@ -93,3 +104,99 @@ func (h *FlowRequestHandler) DeleteAlertConfig(
 	}
 	return &protos.DeleteAlertConfigResponse{}, nil
 }
+
+func (h *FlowRequestHandler) GetAlertRules(ctx context.Context, req *protos.GetAlertRulesRequest) (*protos.GetAlertRulesResponse, error) {
+	rows, err := h.pool.Query(ctx, `SELECT id,name,coalesce(mirror_name,''),coalesce(peer_name,''),metric,threshold,window_minutes,enabled
+		from peerdb_stats.alert_rules order by id`)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.AlertRule, error) {
+		rule := &protos.AlertRule{}
+		var windowMinutes int32
+		if err := row.Scan(&rule.Id, &rule.Name, &rule.MirrorName, &rule.PeerName, &rule.Metric, &rule.Threshold,
+			&windowMinutes, &rule.Enabled); err != nil {
+			return nil, err
+		}
+		rule.WindowMinutes = uint32(windowMinutes)
+		return rule, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &protos.GetAlertRulesResponse{Rules: rules}, nil
+}
+
+func (h *FlowRequestHandler) PostAlertRule(ctx context.Context, req *protos.PostAlertRuleRequest) (*protos.PostAlertRuleResponse, error) {
+	if req.Rule == nil {
+		return nil, errors.New("alert rule is required")
+	}
+	if !slices.Contains(alerting.AlertRuleMetrics, req.Rule.Metric) {
+		return nil, fmt.Errorf("unknown alert rule metric %s, expected one of %v", req.Rule.Metric, alerting.AlertRuleMetrics)
+	}
+	windowMinutes := req.Rule.WindowMinutes
+	if windowMinutes == 0 {
+		windowMinutes = 15
+	}
+
+	if req.Rule.Id == -1 {
+		var id int32
+		if err := h.pool.QueryRow(
+			ctx,
+			`INSERT INTO peerdb_stats.alert_rules (
+				name,
+				mirror_name,
+				peer_name,
+				metric,
+				threshold,
+				window_minutes,
+				enabled
+			) VALUES (
+				$1,
+				NULLIF($2, ''),
+				NULLIF($3, ''),
+				$4,
+				$5,
+				$6,
+				$7
+			) RETURNING id`,
+			req.Rule.Name,
+			req.Rule.MirrorName,
+			req.Rule.PeerName,
+			req.Rule.Metric,
+			req.Rule.Threshold,
+			windowMinutes,
+			req.Rule.Enabled,
+		).Scan(&id); err != nil {
+			return nil, err
+		}
+		return &protos.PostAlertRuleResponse{Id: id}, nil
+	} else if _, err := h.pool.Exec(
+		ctx,
+		`update peerdb_stats.alert_rules set name = $1, mirror_name = NULLIF($2, ''), peer_name = NULLIF($3, ''), metric = $4,
+		threshold = $5, window_minutes = $6, enabled = $7 where id = $8`,
+		req.Rule.Name,
+		req.Rule.MirrorName,
+		req.Rule.PeerName,
+		req.Rule.Metric,
+		req.Rule.Threshold,
+		windowMinutes,
+		req.Rule.Enabled,
+		req.Rule.Id,
+	); err != nil {
+		return nil, err
+	}
+	return &protos.PostAlertRuleResponse{Id: req.Rule.Id}, nil
+}
+
+func (h *FlowRequestHandler) DeleteAlertRule(
+	ctx context.Context,
+	req *protos.DeleteAlertRuleRequest,
+) (*protos.DeleteAlertRuleResponse, error) {
+	if _, err := h.pool.Exec(ctx, "delete from peerdb_stats.alert_rules where id = $1", req.Id); err != nil {
+		return nil, err
+	}
+	return &protos.DeleteAlertRuleResponse{}, nil
+}
@@ -204,7 +204,12 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 		return fmt.Errorf("unable to create Temporal client: %w", err)
 	}
 
-	authGrpcMiddleware, err := middleware.AuthGrpcMiddleware([]string{
+	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get catalog connection pool: %w", err)
+	}
+
+	authGrpcMiddleware, err := middleware.AuthGrpcMiddleware(catalogPool, []string{
 		grpc_health_v1.Health_Check_FullMethodName,
 		grpc_health_v1.Health_Watch_FullMethodName,
 	})
@@ -214,10 +219,14 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 
 	requestLoggingMiddleware := middleware.RequestLoggingMiddleWare()
 
+	taskQueue := internal.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
+	flowHandler := NewFlowRequestHandler(ctx, tc, catalogPool, taskQueue, args.TemporalNamespace)
+
 	serverOptions := []grpc.ServerOption{
 		// Interceptors are executed in the order they are passed to, so unauthorized requests are not logged
 		grpc.ChainUnaryInterceptor(
 			authGrpcMiddleware,
+			flowHandler.ScopeGrpcMiddleware(),
 			requestLoggingMiddleware,
 		),
 	}
@@ -234,13 +243,15 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 
 	grpcServer := grpc.NewServer(serverOptions...)
 
-	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	autoscalingMetricsProvider, err := otel_metrics.SetupPeerDBMetricsProvider(
+		ctx, otel_metrics.FlowApiServiceName, args.EnableOtelMetrics,
+	)
 	if err != nil {
-		return fmt.Errorf("unable to get catalog connection pool: %w", err)
+		return fmt.Errorf("unable to setup metrics provider for autoscaling hints: %w", err)
+	}
+	if err := flowHandler.registerAutoscalingMetrics(ctx, autoscalingMetricsProvider.Meter("io.peerdb.autoscaling")); err != nil {
+		return fmt.Errorf("unable to register autoscaling metrics: %w", err)
 	}
-
-	taskQueue := internal.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
-	flowHandler := NewFlowRequestHandler(ctx, tc, catalogPool, taskQueue)
 
 	if err := killExistingScheduleFlows(ctx, tc, args.TemporalNamespace, taskQueue); err != nil {
 		return fmt.Errorf("unable to kill existing scheduler flows: %w", err)
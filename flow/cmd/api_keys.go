@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
+)
+
+// CreateApiKey only stores a hash of the key, so the response is the one chance to read it
+func (h *FlowRequestHandler) CreateApiKey(
+	ctx context.Context,
+	req *protos.CreateApiKeyRequest,
+) (*protos.CreateApiKeyResponse, error) {
+	if req.Name == "" {
+		return nil, errors.New("API key name is required")
+	}
+	if _, ok := protos.ApiKeyRole_name[int32(req.Role)]; !ok {
+		return nil, fmt.Errorf("unknown API key role %d", req.Role)
+	}
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		expiry := req.ExpiresAt.AsTime()
+		if !expiry.After(time.Now()) {
+			return nil, errors.New("API key expiry must be in the future")
+		}
+		expiresAt = &expiry
+	}
+
+	key, prefix, err := internal.GenerateApiKey()
+	if err != nil {
+		return nil, err
+	}
+	peers := internal.NormalizeApiKeyScope(req.Peers)
+	mirrors := internal.NormalizeApiKeyScope(req.Mirrors)
+	// scopes left empty by normalizing would grant every peer or mirror
+	if len(peers) == 0 && len(req.Peers) > 0 {
+		return nil, errors.New("API key peers must not be empty")
+	}
+	if len(mirrors) == 0 && len(req.Mirrors) > 0 {
+		return nil, errors.New("API key mirrors must not be empty")
+	}
+	var createdAt time.Time
+	if err := h.pool.QueryRow(ctx, `INSERT INTO api_keys (name, key_prefix, key_hash, role, peers, mirrors, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`,
+		req.Name, prefix, internal.HashApiKey(key), middleware.Role(req.Role).String(), peers, mirrors, expiresAt,
+	).Scan(&createdAt); err != nil {
+		return nil, fmt.Errorf("unable to create API key %s: %w", req.Name, err)
+	}
+
+	return &protos.CreateApiKeyResponse{
+		ApiKey: &protos.ApiKey{
+			Name:      req.Name,
+			Role:      req.Role,
+			Peers:     peers,
+			Mirrors:   mirrors,
+			Prefix:    prefix,
+			CreatedAt: timestamppb.New(createdAt),
+			ExpiresAt: req.ExpiresAt,
+		},
+		Key: key,
+	}, nil
+}
+
+func (h *FlowRequestHandler) ListApiKeys(
+	ctx context.Context,
+	req *protos.ListApiKeysRequest,
+) (*protos.ListApiKeysResponse, error) {
+	rows, err := h.pool.Query(ctx,
+		"SELECT name, role, peers, mirrors, key_prefix, created_at, expires_at, last_used_at FROM api_keys ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query API keys: %w", err)
+	}
+	apiKeys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.ApiKey, error) {
+		var apiKey protos.ApiKey
+		var roleName string
+		var createdAt time.Time
+		var expiresAt, lastUsedAt pgtype.Timestamptz
+		if err := row.Scan(&apiKey.Name, &roleName, &apiKey.Peers, &apiKey.Mirrors, &apiKey.Prefix,
+			&createdAt, &expiresAt, &lastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		role, err := middleware.ParseRole(roleName)
+		if err != nil {
+			return nil, fmt.Errorf("API key %s: %w", apiKey.Name, err)
+		}
+		apiKey.Role = protos.ApiKeyRole(role)
+		apiKey.CreatedAt = timestamppb.New(createdAt)
+		if expiresAt.Valid {
+			apiKey.ExpiresAt = timestamppb.New(expiresAt.Time)
+		}
+		if lastUsedAt.Valid {
+			apiKey.LastUsedAt = timestamppb.New(lastUsedAt.Time)
+		}
+		return &apiKey, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query API keys: %w", err)
+	}
+
+	return &protos.ListApiKeysResponse{ApiKeys: apiKeys}, nil
+}
+
+// RevokeApiKey deletes the key, requests using it fail from then on
+func (h *FlowRequestHandler) RevokeApiKey(
+	ctx context.Context,
+	req *protos.RevokeApiKeyRequest,
+) (*protos.RevokeApiKeyResponse, error) {
+	tag, err := h.pool.Exec(ctx, "DELETE FROM api_keys WHERE name = $1", req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to revoke API key %s: %w", req.Name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("API key %s not found", req.Name)
+	}
+
+	return &protos.RevokeApiKeyResponse{}, nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
+)
+
+// ScopeGrpcMiddleware checks the caller is scoped to the mirrors & peers a request acts on,
+// it runs after authentication so the identity of the request is known
+func (h *FlowRequestHandler) ScopeGrpcMiddleware() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorizeRequest(ctx, info.FullMethod, req, h.mirrorPeers); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// mirrorPeersFunc looks up the source & destination peer of a mirror, exists is false without a catalog entry
+type mirrorPeersFunc func(ctx context.Context, flowJobName string) (sourceName string, destinationName string, exists bool, err error)
+
+// authorizeRequest checks the scope of requests that change a mirror or peer.
+// Read-only methods are not scoped, other requests not listed here may only be made by identities without a scope.
+// Creating mirrors is also done by other handlers on behalf of their caller,
+// so CreateCDCFlow & CreateQRepFlow authorize the mirror they create themselves
+func authorizeRequest(ctx context.Context, fullMethod string, req any, mirrorPeers mirrorPeersFunc) error {
+	identity := middleware.IdentityFromContext(ctx)
+	if identity == nil || middleware.MethodRole(fullMethod) == middleware.RoleReadOnly {
+		return nil
+	}
+
+	switch req := req.(type) {
+	case *protos.CreateCDCFlowRequest, *protos.CreateQRepFlowRequest, *protos.DeclarativeSpecRequest:
+		return nil
+	case *protos.CreatePeerRequest:
+		return authorizePeers(ctx, req.Peer.GetName())
+	case *protos.DropPeerRequest:
+		return authorizePeers(ctx, req.PeerName)
+	case *protos.ProbePeerRequest:
+		return authorizePeers(ctx, req.PeerName)
+	case *protos.FlowStateChangeRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.CreateCustomSyncRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.RollbackMirrorConfigRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.UpdateMirrorEnvRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.MoveMirrorToWorkerPoolRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.RetryQRepPartitionsRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.ReplayRecordsRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.DeduplicateMirrorRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.CreateCutoverReportRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.ConfirmMigrationWriteFreezeRequest:
+		return authorizeMirror(ctx, req.FlowJobName, mirrorPeers)
+	case *protos.CreateOrReplaceFlowTagsRequest:
+		return authorizeMirror(ctx, req.FlowName, mirrorPeers)
+	case *protos.CloneMirrorRequest:
+		// the clone itself is authorized when it is created
+		return authorizeMirror(ctx, req.SourceFlowJobName, mirrorPeers)
+	case *protos.CreateMigrationRequest:
+		cfg := req.ConnectionConfigs
+		return authorizeNewMirror(ctx, cfg.GetFlowJobName(), cfg.GetSourceName(), cfg.GetDestinationName())
+	case *protos.CreateSubsetFlowRequest:
+		// every mirror of the subset is authorized when it is created, dry runs still read the source
+		return authorizePeers(ctx, req.SourceName, req.DestinationName)
+	case *protos.ImportMirrorStateRequest:
+		for _, mirror := range req.Mirrors {
+			if err := authorizeNewMirror(ctx, mirror.FlowName, mirror.SourcePeerName, mirror.DestinationPeerName); err != nil {
+				return err
+			}
+			if req.Overwrite {
+				if err := authorizeExistingMirror(ctx, mirror.FlowName, mirrorPeers); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case *protos.PostMirrorQuotaRequest:
+		if flowName := req.Quota.GetFlowName(); flowName != "" {
+			return authorizeMirror(ctx, flowName, mirrorPeers)
+		}
+	case *protos.DeleteMirrorQuotaRequest:
+		if req.FlowName != "" {
+			return authorizeMirror(ctx, req.FlowName, mirrorPeers)
+		}
+	}
+	return authorizeUnscoped(ctx, fullMethod)
+}
+
+// authorizeUnscoped checks the caller is not scoped to peers or mirrors, for changes that affect every mirror
+func authorizeUnscoped(ctx context.Context, fullMethod string) error {
+	if identity := middleware.IdentityFromContext(ctx); identity != nil && (len(identity.Peers) > 0 || len(identity.Mirrors) > 0) {
+		return status.Errorf(codes.PermissionDenied, "%s is scoped to peers or mirrors and may not call %s", identity.Subject, fullMethod)
+	}
+	return nil
+}
+
+// authorizeRole checks the caller has at least role, for handlers applying changes that need more than the method they were called by
+func authorizeRole(ctx context.Context, role middleware.Role) error {
+	if identity := middleware.IdentityFromContext(ctx); identity != nil && identity.Role < role {
+		return status.Errorf(codes.PermissionDenied, "%s has the %s role, %s is required", identity.Subject, identity.Role, role)
+	}
+	return nil
+}
+
+// authorizePeers checks the caller is scoped to manage every one of peers
+func authorizePeers(ctx context.Context, peers ...string) error {
+	identity := middleware.IdentityFromContext(ctx)
+	if identity == nil {
+		return nil
+	}
+	for _, peer := range peers {
+		if !identity.CanManagePeer(peer) {
+			return status.Errorf(codes.PermissionDenied, "%s is not permitted to manage peer %s", identity.Subject, peer)
+		}
+	}
+	return nil
+}
+
+// authorizeNewMirror checks the caller is scoped to manage a mirror between sourceName & destinationName
+func authorizeNewMirror(ctx context.Context, flowJobName string, sourceName string, destinationName string) error {
+	if identity := middleware.IdentityFromContext(ctx); identity != nil && !identity.CanManageMirror(flowJobName) {
+		return status.Errorf(codes.PermissionDenied, "%s is not permitted to manage mirror %s", identity.Subject, flowJobName)
+	}
+	return authorizePeers(ctx, sourceName, destinationName)
+}
+
+// authorizeMirror checks the caller is scoped to manage an existing mirror and both its peers,
+// mirrors without a catalog entry may only be managed by callers not scoped to peers
+func authorizeMirror(ctx context.Context, flowJobName string, mirrorPeers mirrorPeersFunc) error {
+	identity := middleware.IdentityFromContext(ctx)
+	if identity == nil {
+		return nil
+	}
+	if !identity.CanManageMirror(flowJobName) {
+		return status.Errorf(codes.PermissionDenied, "%s is not permitted to manage mirror %s", identity.Subject, flowJobName)
+	}
+	if len(identity.Peers) == 0 {
+		return nil
+	}
+	sourceName, destinationName, exists, err := mirrorPeers(ctx, flowJobName)
+	if err != nil {
+		return err
+	} else if !exists {
+		return status.Errorf(codes.PermissionDenied, "%s is not permitted to manage mirror %s", identity.Subject, flowJobName)
+	}
+	return authorizePeers(ctx, sourceName, destinationName)
+}
+
+// authorizeExistingMirror is authorizeMirror for a mirror that may not have been created yet
+func authorizeExistingMirror(ctx context.Context, flowJobName string, mirrorPeers mirrorPeersFunc) error {
+	identity := middleware.IdentityFromContext(ctx)
+	if identity == nil || len(identity.Peers) == 0 {
+		return nil
+	}
+	sourceName, destinationName, exists, err := mirrorPeers(ctx, flowJobName)
+	if err != nil || !exists {
+		return err
+	}
+	return authorizePeers(ctx, sourceName, destinationName)
+}
+
+func (h *FlowRequestHandler) mirrorPeers(ctx context.Context, flowJobName string) (string, string, bool, error) {
+	var sourceName, destinationName string
+	if err := h.pool.QueryRow(ctx, `SELECT sp.name, dp.name
+		FROM flows f JOIN peers sp ON f.source_peer = sp.id JOIN peers dp ON f.destination_peer = dp.id
+		WHERE f.name = $1`, flowJobName,
+	).Scan(&sourceName, &destinationName); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", false, nil
+		}
+		return "", "", false, fmt.Errorf("unable to query peers of mirror %s: %w", flowJobName, err)
+	}
+	return sourceName, destinationName, true, nil
+}
+
+func (h *FlowRequestHandler) authorizeMirror(ctx context.Context, flowJobName string) error {
+	return authorizeMirror(ctx, flowJobName, h.mirrorPeers)
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func contextWithTestIdentity(identity *middleware.Identity) context.Context {
+	return context.WithValue(context.Background(), shared.RequestIdentityKey, identity)
+}
+
+// testMirrorPeers knows mirror between pg & ch and other between pg & sf, recording the mirrors looked up
+func testMirrorPeers(lookups *[]string) mirrorPeersFunc {
+	return func(ctx context.Context, flowJobName string) (string, string, bool, error) {
+		*lookups = append(*lookups, flowJobName)
+		switch flowJobName {
+		case "mirror":
+			return "pg", "ch", true, nil
+		case "other":
+			return "pg", "sf", true, nil
+		case "broken":
+			return "", "", false, errors.New("catalog unavailable")
+		default:
+			return "", "", false, nil
+		}
+	}
+}
+
+func requirePermissionDenied(t *testing.T, err error) {
+	t.Helper()
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "%v", err)
+}
+
+func TestAuthorizePeersAndNewMirror(t *testing.T) {
+	require.NoError(t, authorizePeers(context.Background(), "pg", "ch"))
+	require.NoError(t, authorizeNewMirror(context.Background(), "mirror", "pg", "ch"))
+
+	ctx := contextWithTestIdentity(&middleware.Identity{Subject: "sub", Role: middleware.RoleOperator, Peers: []string{"pg", "ch"}})
+	require.NoError(t, authorizePeers(ctx))
+	require.NoError(t, authorizePeers(ctx, "pg", "ch"))
+	requirePermissionDenied(t, authorizePeers(ctx, "pg", "sf"))
+	require.NoError(t, authorizeNewMirror(ctx, "mirror", "pg", "ch"))
+	requirePermissionDenied(t, authorizeNewMirror(ctx, "mirror", "sf", "ch"))
+
+	ctx = contextWithTestIdentity(&middleware.Identity{Subject: "sub", Role: middleware.RoleOperator, Mirrors: []string{"mirror"}})
+	require.NoError(t, authorizeNewMirror(ctx, "mirror", "pg", "sf"))
+	requirePermissionDenied(t, authorizeNewMirror(ctx, "other", "pg", "sf"))
+}
+
+func TestAuthorizeMirror(t *testing.T) {
+	tests := []struct {
+		identity *middleware.Identity
+		name     string
+		mirror   string
+		lookups  []string
+		denied   bool
+		wantErr  bool
+	}{
+		{name: "no identity", mirror: "mirror"},
+		{name: "unscoped", identity: &middleware.Identity{Subject: "sub"}, mirror: "missing"},
+		{
+			name:     "scoped to mirror",
+			identity: &middleware.Identity{Subject: "sub", Mirrors: []string{"mirror"}},
+			mirror:   "mirror",
+		},
+		{
+			name:     "not scoped to mirror",
+			identity: &middleware.Identity{Subject: "sub", Mirrors: []string{"mirror"}},
+			mirror:   "other",
+			denied:   true,
+		},
+		{
+			name:     "scoped to both peers",
+			identity: &middleware.Identity{Subject: "sub", Peers: []string{"pg", "ch"}},
+			mirror:   "mirror",
+			lookups:  []string{"mirror"},
+		},
+		{
+			name:     "scoped to one peer",
+			identity: &middleware.Identity{Subject: "sub", Peers: []string{"pg", "ch"}},
+			mirror:   "other",
+			lookups:  []string{"other"},
+			denied:   true,
+		},
+		{
+			name:     "scoped to peers of missing mirror",
+			identity: &middleware.Identity{Subject: "sub", Peers: []string{"pg", "ch"}},
+			mirror:   "missing",
+			lookups:  []string{"missing"},
+			denied:   true,
+		},
+		{
+			name:     "lookup failure",
+			identity: &middleware.Identity{Subject: "sub", Peers: []string{"pg", "ch"}},
+			mirror:   "broken",
+			lookups:  []string{"broken"},
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.identity != nil {
+				ctx = contextWithTestIdentity(tc.identity)
+			}
+			var lookups []string
+			err := authorizeMirror(ctx, tc.mirror, testMirrorPeers(&lookups))
+			switch {
+			case tc.denied:
+				requirePermissionDenied(t, err)
+			case tc.wantErr:
+				require.Error(t, err)
+				require.NotEqual(t, codes.PermissionDenied, status.Code(err))
+			default:
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.lookups, lookups)
+		})
+	}
+}
+
+func TestAuthorizeRequest(t *testing.T) {
+	peerScoped := &middleware.Identity{Subject: "sub", Role: middleware.RoleOperator, Peers: []string{"pg", "ch"}}
+	tests := []struct {
+		req      any
+		identity *middleware.Identity
+		name     string
+		method   string
+		denied   bool
+	}{
+		{
+			name:     "read-only methods are not scoped",
+			method:   protos.FlowService_MirrorStatus_FullMethodName,
+			req:      &protos.MirrorStatusRequest{FlowJobName: "other"},
+			identity: peerScoped,
+		},
+		{
+			name:     "replay of mirror in scope",
+			method:   protos.FlowService_ReplayRecords_FullMethodName,
+			req:      &protos.ReplayRecordsRequest{FlowJobName: "mirror"},
+			identity: peerScoped,
+		},
+		{
+			name:     "replay of mirror out of scope",
+			method:   protos.FlowService_ReplayRecords_FullMethodName,
+			req:      &protos.ReplayRecordsRequest{FlowJobName: "other"},
+			identity: peerScoped,
+			denied:   true,
+		},
+		{
+			name:     "clone of mirror out of scope",
+			method:   protos.FlowService_CloneMirror_FullMethodName,
+			req:      &protos.CloneMirrorRequest{SourceFlowJobName: "other", FlowJobName: "clone"},
+			identity: peerScoped,
+			denied:   true,
+		},
+		{
+			name:     "probe of peer out of scope",
+			method:   protos.FlowService_ProbePeer_FullMethodName,
+			req:      &protos.ProbePeerRequest{PeerName: "sf"},
+			identity: peerScoped,
+			denied:   true,
+		},
+		{
+			name:   "migration between peers in scope",
+			method: protos.FlowService_CreateMigration_FullMethodName,
+			req: &protos.CreateMigrationRequest{
+				ConnectionConfigs: &protos.FlowConnectionConfigs{FlowJobName: "new", SourceName: "pg", DestinationName: "ch"},
+			},
+			identity: peerScoped,
+		},
+		{
+			name:     "subset into peer out of scope",
+			method:   protos.FlowService_CreateSubsetFlow_FullMethodName,
+			req:      &protos.CreateSubsetFlowRequest{FlowJobName: "subset", SourceName: "pg", DestinationName: "sf"},
+			identity: peerScoped,
+			denied:   true,
+		},
+		{
+			name:   "import of new mirror in scope",
+			method: protos.FlowService_ImportMirrorState_FullMethodName,
+			req: &protos.ImportMirrorStateRequest{
+				Mirrors: []*protos.MirrorState{{FlowName: "new", SourcePeerName: "pg", DestinationPeerName: "ch"}},
+			},
+			identity: peerScoped,
+		},
+		{
+			name:   "import overwriting mirror out of scope",
+			method: protos.FlowService_ImportMirrorState_FullMethodName,
+			req: &protos.ImportMirrorStateRequest{
+				Mirrors:   []*protos.MirrorState{{FlowName: "other", SourcePeerName: "pg", DestinationPeerName: "ch"}},
+				Overwrite: true,
+			},
+			identity: peerScoped,
+			denied:   true,
+		},
+		{
+			name:     "mirror templates need an unscoped identity",
+			method:   protos.FlowService_CreateMirrorTemplate_FullMethodName,
+			req:      &protos.CreateMirrorTemplateRequest{Template: &protos.MirrorTemplate{Name: "template"}},
+			identity: peerScoped,
+			denied:   true,
+		},
+		{
+			name:     "mirror templates by unscoped identity",
+			method:   protos.FlowService_CreateMirrorTemplate_FullMethodName,
+			req:      &protos.CreateMirrorTemplateRequest{Template: &protos.MirrorTemplate{Name: "template"}},
+			identity: &middleware.Identity{Subject: "sub", Role: middleware.RoleOperator},
+		},
+		{
+			name:   "API keys need an unscoped identity",
+			method: protos.FlowService_CreateApiKey_FullMethodName,
+			req:    &protos.CreateApiKeyRequest{Name: "key", Role: protos.ApiKeyRole(middleware.RoleAdmin)},
+			// an API key scoped to peers may not mint one without that scope
+			identity: &middleware.Identity{Subject: "api-key:scoped", Role: middleware.RoleAdmin, Peers: []string{"pg"}},
+			denied:   true,
+		},
+		{
+			name:     "global quota needs an unscoped identity",
+			method:   protos.FlowService_PostMirrorQuota_FullMethodName,
+			req:      &protos.PostMirrorQuotaRequest{Quota: &protos.MirrorQuota{}},
+			identity: &middleware.Identity{Subject: "sub", Role: middleware.RoleAdmin, Mirrors: []string{"mirror"}},
+			denied:   true,
+		},
+		{
+			name:     "quota of mirror in scope",
+			method:   protos.FlowService_PostMirrorQuota_FullMethodName,
+			req:      &protos.PostMirrorQuotaRequest{Quota: &protos.MirrorQuota{FlowName: "mirror"}},
+			identity: &middleware.Identity{Subject: "sub", Role: middleware.RoleAdmin, Mirrors: []string{"mirror"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var lookups []string
+			err := authorizeRequest(contextWithTestIdentity(tc.identity), tc.method, tc.req, testMirrorPeers(&lookups))
+			if tc.denied {
+				requirePermissionDenied(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestAuthorizeRequestCoversMirrorMethods checks every method that changes a mirror named in its request
+// is scoped to that mirror, rather than falling back to requiring an unscoped identity
+func TestAuthorizeRequestCoversMirrorMethods(t *testing.T) {
+	service, err := protoregistry.GlobalFiles.FindDescriptorByName("peerdb_route.FlowService")
+	require.NoError(t, err)
+	methods := service.(protoreflect.ServiceDescriptor).Methods()
+	ctx := contextWithTestIdentity(&middleware.Identity{Subject: "sub", Role: middleware.RoleAdmin, Mirrors: []string{"mirror"}})
+	for i := range methods.Len() {
+		method := methods.Get(i)
+		fullMethod := "/peerdb_route.FlowService/" + string(method.Name())
+		if middleware.MethodRole(fullMethod) == middleware.RoleReadOnly {
+			continue
+		}
+		input := method.Input()
+		var fields []protoreflect.FieldDescriptor
+		for _, name := range []protoreflect.Name{"flow_job_name", "flow_name", "source_flow_job_name"} {
+			if field := input.Fields().ByName(name); field != nil && field.Kind() == protoreflect.StringKind {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		t.Run(string(method.Name()), func(t *testing.T) {
+			messageType, err := protoregistry.GlobalTypes.FindMessageByName(input.FullName())
+			require.NoError(t, err)
+			req := messageType.New()
+			for _, field := range fields {
+				req.Set(field, protoreflect.ValueOfString("mirror"))
+			}
+			var lookups []string
+			require.NoError(t, authorizeRequest(ctx, fullMethod, req.Interface(), testMirrorPeers(&lookups)))
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (h *FlowRequestHandler) GetAutoscalingHints(
+	ctx context.Context,
+	req *protos.GetAutoscalingHintsRequest,
+) (*protos.GetAutoscalingHintsResponse, error) {
+	for _, workerPool := range req.WorkerPools {
+		if err := internal.ValidateWorkerPool(workerPool); err != nil {
+			return nil, err
+		}
+	}
+
+	syncFlowsByPool, err := h.pendingSyncFlowsByWorkerPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	workerPools := []string{""}
+	for workerPool := range syncFlowsByPool {
+		workerPools = append(workerPools, workerPool)
+	}
+	workerPools = append(workerPools, req.WorkerPools...)
+	slices.Sort(workerPools)
+	workerPools = slices.Compact(workerPools)
+
+	res := &protos.GetAutoscalingHintsResponse{}
+	for _, workerPool := range workerPools {
+		backlog, err := h.describeTaskQueueBacklog(ctx, internal.WorkerPoolTaskQueueName(workerPool))
+		if err != nil {
+			return nil, err
+		}
+		backlog.WorkerPool = workerPool
+		backlog.PendingSyncFlows = syncFlowsByPool[workerPool]
+		res.PendingSyncFlows += backlog.PendingSyncFlows
+		res.TaskQueues = append(res.TaskQueues, backlog)
+	}
+	snapshotBacklog, err := h.describeTaskQueueBacklog(ctx, internal.PeerFlowTaskQueueName(shared.SnapshotFlowTaskQueue))
+	if err != nil {
+		return nil, err
+	}
+	res.TaskQueues = append(res.TaskQueues, snapshotBacklog)
+
+	// partitions of runs that never finished linger, only count those of mirrors still in the catalog
+	if err := h.pool.QueryRow(ctx, `SELECT
+		count(*) FILTER (WHERE qp.start_time IS NULL), count(*) FILTER (WHERE qp.start_time IS NOT NULL)
+		FROM peerdb_stats.qrep_partitions qp
+		JOIN peerdb_stats.qrep_runs qr ON qr.run_uuid = qp.run_uuid
+		JOIN flows f ON f.name = coalesce(qp.parent_mirror_name, qp.flow_name)
+		WHERE qp.end_time IS NULL AND qr.end_time IS NULL`,
+	).Scan(&res.QueuedPartitions, &res.RunningPartitions); err != nil {
+		return nil, fmt.Errorf("unable to count queued partitions: %w", err)
+	}
+
+	return res, nil
+}
+
+// pendingSyncFlowsByWorkerPool counts running CDC mirrors by the worker pool they are assigned to,
+// pools of CDC mirrors in other states are included with no sync flows
+func (h *FlowRequestHandler) pendingSyncFlowsByWorkerPool(ctx context.Context) (map[string]int64, error) {
+	rows, err := h.pool.Query(ctx,
+		"SELECT config_proto, status FROM flows WHERE coalesce(query_string, '') = '' AND config_proto IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query CDC mirrors: %w", err)
+	}
+	syncFlows := make(map[string]int64)
+	var configBytes []byte
+	var status int32
+	if _, err := pgx.ForEachRow(rows, []any{&configBytes, &status}, func() error {
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configBytes, &config); err != nil {
+			return fmt.Errorf("unable to unmarshal flow config: %w", err)
+		}
+		if protos.FlowStatus(status) == protos.FlowStatus_STATUS_RUNNING {
+			syncFlows[config.WorkerPool]++
+		} else if _, ok := syncFlows[config.WorkerPool]; !ok {
+			syncFlows[config.WorkerPool] = 0
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to read CDC mirrors: %w", err)
+	}
+	return syncFlows, nil
+}
+
+// describeTaskQueueBacklog sums the backlog of workflow & activity tasks on taskQueue
+func (h *FlowRequestHandler) describeTaskQueueBacklog(ctx context.Context, taskQueue string) (*protos.TaskQueueBacklog, error) {
+	backlog := &protos.TaskQueueBacklog{TaskQueue: taskQueue}
+	pollers := make(map[string]struct{})
+	for _, taskQueueType := range []enums.TaskQueueType{
+		enums.TASK_QUEUE_TYPE_WORKFLOW, enums.TASK_QUEUE_TYPE_ACTIVITY,
+	} {
+		res, err := h.temporalClient.WorkflowService().DescribeTaskQueue(ctx, &workflowservice.DescribeTaskQueueRequest{
+			Namespace:     h.temporalNamespace,
+			TaskQueue:     &taskqueue.TaskQueue{Name: taskQueue, Kind: enums.TASK_QUEUE_KIND_NORMAL},
+			TaskQueueType: taskQueueType,
+			ReportStats:   true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe task queue %s: %w", taskQueue, err)
+		}
+		for _, poller := range res.Pollers {
+			pollers[poller.Identity] = struct{}{}
+		}
+		if stats := res.Stats; stats != nil {
+			backlog.ApproximateBacklogCount += stats.ApproximateBacklogCount
+			backlog.ApproximateBacklogAgeSeconds = max(backlog.ApproximateBacklogAgeSeconds,
+				stats.GetApproximateBacklogAge().AsDuration().Seconds())
+		}
+	}
+	backlog.Pollers = int32(len(pollers))
+	return backlog, nil
+}
+
+// autoscalingHintsRefreshInterval bounds how often catalog & Temporal are queried for the exported gauges,
+// independent of how often the metrics are collected
+const autoscalingHintsRefreshInterval = 15 * time.Second
+
+// refreshAutoscalingHints stores the hints from fetch in latest every interval until ctx is done,
+// keeping the last hints on error so gauges aren't dropped over a transient failure
+func refreshAutoscalingHints(
+	ctx context.Context,
+	interval time.Duration,
+	latest *atomic.Pointer[protos.GetAutoscalingHintsResponse],
+	fetch func(context.Context) (*protos.GetAutoscalingHintsResponse, error),
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if hints, err := fetch(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("unable to get autoscaling hints", slog.Any("error", err))
+		} else {
+			latest.Store(hints)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// registerAutoscalingMetrics exports the autoscaling hints as gauges, for HPA through a metrics adapter,
+// hints are refreshed in the background until ctx is done and the callback only observes the latest
+func (h *FlowRequestHandler) registerAutoscalingMetrics(ctx context.Context, meter metric.Meter) error {
+	backlogGauge, err := meter.Int64ObservableGauge(otel_metrics.BuildMetricName(otel_metrics.TaskQueueBacklogGaugeName),
+		metric.WithDescription("Approximate number of tasks waiting for a flow worker on the task queue"))
+	if err != nil {
+		return err
+	}
+	backlogAgeGauge, err := meter.Float64ObservableGauge(otel_metrics.BuildMetricName(otel_metrics.TaskQueueBacklogAgeGaugeName),
+		metric.WithDescription("Age of the oldest task waiting for a flow worker on the task queue"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	pollersGauge, err := meter.Int64ObservableGauge(otel_metrics.BuildMetricName(otel_metrics.TaskQueuePollersGaugeName),
+		metric.WithDescription("Number of flow workers polling the task queue"))
+	if err != nil {
+		return err
+	}
+	syncFlowsGauge, err := meter.Int64ObservableGauge(otel_metrics.BuildMetricName(otel_metrics.PendingSyncFlowsGaugeName),
+		metric.WithDescription("Number of running CDC mirrors, each holding a flow worker slot for its sync flow"))
+	if err != nil {
+		return err
+	}
+	queuedPartitionsGauge, err := meter.Int64ObservableGauge(otel_metrics.BuildMetricName(otel_metrics.QueuedPartitionsGaugeName),
+		metric.WithDescription("Number of partitions of QRep and initial load runs waiting to be replicated"))
+	if err != nil {
+		return err
+	}
+	runningPartitionsGauge, err := meter.Int64ObservableGauge(otel_metrics.BuildMetricName(otel_metrics.RunningPartitionsGaugeName),
+		metric.WithDescription("Number of partitions of QRep and initial load runs being replicated"))
+	if err != nil {
+		return err
+	}
+
+	var latest atomic.Pointer[protos.GetAutoscalingHintsResponse]
+	if _, err := meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		hints := latest.Load()
+		if hints == nil {
+			return nil
+		}
+		for _, taskQueue := range hints.TaskQueues {
+			attrs := metric.WithAttributeSet(attribute.NewSet(
+				attribute.String(otel_metrics.TaskQueueKey, taskQueue.TaskQueue),
+				attribute.String(otel_metrics.WorkerPoolKey, taskQueue.WorkerPool),
+			))
+			observer.ObserveInt64(backlogGauge, taskQueue.ApproximateBacklogCount, attrs)
+			observer.ObserveFloat64(backlogAgeGauge, taskQueue.ApproximateBacklogAgeSeconds, attrs)
+			observer.ObserveInt64(pollersGauge, int64(taskQueue.Pollers), attrs)
+			observer.ObserveInt64(syncFlowsGauge, taskQueue.PendingSyncFlows, attrs)
+		}
+		observer.ObserveInt64(queuedPartitionsGauge, hints.QueuedPartitions)
+		observer.ObserveInt64(runningPartitionsGauge, hints.RunningPartitions)
+		return nil
+	}, backlogGauge, backlogAgeGauge, pollersGauge, syncFlowsGauge, queuedPartitionsGauge, runningPartitionsGauge); err != nil {
+		return err
+	}
+
+	go refreshAutoscalingHints(ctx, autoscalingHintsRefreshInterval, &latest,
+		func(ctx context.Context) (*protos.GetAutoscalingHintsResponse, error) {
+			return h.GetAutoscalingHints(ctx, &protos.GetAutoscalingHintsRequest{})
+		})
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestRefreshAutoscalingHints(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var latest atomic.Pointer[protos.GetAutoscalingHintsResponse]
+	var calls atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		refreshAutoscalingHints(ctx, time.Millisecond, &latest, func(context.Context) (*protos.GetAutoscalingHintsResponse, error) {
+			switch calls.Add(1) {
+			case 1:
+				return &protos.GetAutoscalingHintsResponse{QueuedPartitions: 1}, nil
+			case 2:
+				return nil, errors.New("catalog unavailable")
+			default:
+				return &protos.GetAutoscalingHintsResponse{QueuedPartitions: 3}, nil
+			}
+		})
+	}()
+
+	require.Eventually(t, func() bool {
+		return latest.Load().GetQueuedPartitions() == 3
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	require.GreaterOrEqual(t, calls.Load(), int64(3))
+}
+
+func TestRefreshAutoscalingHintsKeepsLastOnError(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var latest atomic.Pointer[protos.GetAutoscalingHintsResponse]
+	latest.Store(&protos.GetAutoscalingHintsResponse{RunningPartitions: 2})
+	var calls atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		refreshAutoscalingHints(ctx, time.Millisecond, &latest, func(context.Context) (*protos.GetAutoscalingHintsResponse, error) {
+			calls.Add(1)
+			return nil, errors.New("temporal unavailable")
+		})
+	}()
+
+	require.Eventually(t, func() bool { return calls.Load() >= 2 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	require.Equal(t, int64(2), latest.Load().GetRunningPartitions())
+}
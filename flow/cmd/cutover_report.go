@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+func (h *FlowRequestHandler) CreateCutoverReport(
+	ctx context.Context, req *protos.CreateCutoverReportRequest,
+) (*protos.CreateCutoverReportResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+
+	isCDC, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		slog.Error("unable to check if mirror is cdc", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to determine if mirror %s is cdc: %w", req.FlowJobName, err)
+	}
+	if !isCDC {
+		return nil, fmt.Errorf("cutover reports are only supported for CDC mirrors, %s is not one", req.FlowJobName)
+	}
+
+	cfg, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowID := fmt.Sprintf("%s-cutover-report-%s", req.FlowJobName, uuid.New())
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(cfg.WorkerPool),
+		TypedSearchAttributes: shared.NewSearchAttributes(req.FlowJobName),
+	}
+	if _, err := h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, peerflow.CutoverReportWorkflow,
+		&protos.CutoverReportInput{
+			FlowJobName:           req.FlowJobName,
+			FlowConnectionConfigs: cfg,
+		},
+	); err != nil {
+		slog.Error("unable to start CutoverReport workflow", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to start CutoverReport workflow: %w", err)
+	}
+
+	slog.Info("cutover report started for mirror", slog.String("mirror", req.FlowJobName))
+	return &protos.CreateCutoverReportResponse{WorkflowId: workflowID}, nil
+}
+
+// GetCutoverReport returns the latest cutover report of a mirror along with the exact json that was signed
+func (h *FlowRequestHandler) GetCutoverReport(
+	ctx context.Context, req *protos.GetCutoverReportRequest,
+) (*protos.GetCutoverReportResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+
+	var res protos.GetCutoverReportResponse
+	var createdAt time.Time
+	if err := h.pool.QueryRow(ctx,
+		`SELECT id, report_json, signature, key_id, created_at FROM cutover_reports
+		WHERE flow_name = $1 ORDER BY created_at DESC, id DESC LIMIT 1`, req.FlowJobName,
+	).Scan(&res.ReportId, &res.ReportJson, &res.Signature, &res.KeyId, &createdAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("no cutover report found for mirror %s", req.FlowJobName)
+		}
+		return nil, fmt.Errorf("unable to query cutover report: %w", err)
+	}
+	res.CreatedAt = timestamppb.New(createdAt)
+
+	var report protos.CutoverReport
+	if err := protojson.Unmarshal([]byte(res.ReportJson), &report); err != nil {
+		return nil, fmt.Errorf("unable to parse cutover report: %w", err)
+	}
+	res.Report = &report
+	return &res, nil
+}
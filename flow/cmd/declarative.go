@@ -0,0 +1,487 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const (
+	declarativeKindPeer       = "peer"
+	declarativeKindCDCMirror  = "cdc_mirror"
+	declarativeKindQRepMirror = "qrep_mirror"
+)
+
+// declarativeChange is a change of the diff along with how it is applied
+type declarativeChange struct {
+	change *protos.DeclarativeChange
+	// changes are applied by calling handlers directly, so the caller's scope is checked for each of them
+	authorize func(ctx context.Context) error
+	apply     func(ctx context.Context) error
+}
+
+func (h *FlowRequestHandler) DiffDeclarativeSpec(
+	ctx context.Context,
+	req *protos.DeclarativeSpecRequest,
+) (*protos.DeclarativeSpecResponse, error) {
+	changes, err := h.diffDeclarativeSpec(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := &protos.DeclarativeSpecResponse{Changes: make([]*protos.DeclarativeChange, 0, len(changes))}
+	for _, change := range changes {
+		res.Changes = append(res.Changes, change.change)
+	}
+	return res, nil
+}
+
+// ApplyDeclarativeSpec brings peers and mirrors to the state of the spec.
+// Changes are applied in order, peers before mirrors and mirror drops before peer drops,
+// a failed change is reported and does not stop the others, so applying again picks up where it failed
+func (h *FlowRequestHandler) ApplyDeclarativeSpec(
+	ctx context.Context,
+	req *protos.DeclarativeSpecRequest,
+) (*protos.DeclarativeSpecResponse, error) {
+	changes, err := h.diffDeclarativeSpec(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := &protos.DeclarativeSpecResponse{Changes: make([]*protos.DeclarativeChange, 0, len(changes))}
+	for _, change := range changes {
+		if change.change.Error == "" {
+			if err := change.apply(ctx); err != nil {
+				slog.Error("unable to apply declarative change", slog.String("kind", change.change.Kind),
+					slog.String("name", change.change.Name), slog.Any("error", err))
+				change.change.Error = err.Error()
+			}
+		}
+		res.Changes = append(res.Changes, change.change)
+	}
+	return res, nil
+}
+
+func parseDeclarativeSpec(spec string) (*protos.DeclarativeSpec, error) {
+	// JSON is valid YAML, so both go through the same conversion
+	specJSON, err := yaml.YAMLToJSON([]byte(spec))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+	var parsed protos.DeclarativeSpec
+	if err := protojson.Unmarshal(specJSON, &parsed); err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+
+	names := make(map[string]struct{}, len(parsed.CdcMirrors)+len(parsed.QrepMirrors))
+	for _, cfg := range parsed.CdcMirrors {
+		if _, ok := names[cfg.FlowJobName]; ok || cfg.FlowJobName == "" {
+			return nil, fmt.Errorf("mirror name %q is empty or duplicated in spec", cfg.FlowJobName)
+		}
+		names[cfg.FlowJobName] = struct{}{}
+	}
+	for _, cfg := range parsed.QrepMirrors {
+		if _, ok := names[cfg.FlowJobName]; ok || cfg.FlowJobName == "" {
+			return nil, fmt.Errorf("mirror name %q is empty or duplicated in spec", cfg.FlowJobName)
+		}
+		names[cfg.FlowJobName] = struct{}{}
+	}
+	peerNames := make(map[string]struct{}, len(parsed.Peers))
+	for _, peer := range parsed.Peers {
+		if _, ok := peerNames[peer.Name]; ok || peer.Name == "" {
+			return nil, fmt.Errorf("peer name %q is empty or duplicated in spec", peer.Name)
+		}
+		peerNames[peer.Name] = struct{}{}
+	}
+	return &parsed, nil
+}
+
+func (h *FlowRequestHandler) diffDeclarativeSpec(
+	ctx context.Context,
+	req *protos.DeclarativeSpecRequest,
+) ([]declarativeChange, error) {
+	spec, err := parseDeclarativeSpec(req.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	peerRows, err := h.pool.Query(ctx, "SELECT name FROM peers ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query peers: %w", err)
+	}
+	existingPeers, err := pgx.CollectRows(peerRows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("unable to query peers: %w", err)
+	}
+	mirrorRows, err := h.pool.Query(ctx, "SELECT name, coalesce(query_string, '') = '' FROM flows")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirrors: %w", err)
+	}
+	existingMirrors := make(map[string]bool)
+	var mirrorName string
+	var isCDC bool
+	if _, err := pgx.ForEachRow(mirrorRows, []any{&mirrorName, &isCDC}, func() error {
+		existingMirrors[mirrorName] = isCDC
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to query mirrors: %w", err)
+	}
+
+	var changes []declarativeChange
+	for _, peer := range spec.Peers {
+		change, err := h.diffDeclarativePeer(ctx, peer, slices.Contains(existingPeers, peer.Name))
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	for _, cfg := range spec.CdcMirrors {
+		change, err := h.diffDeclarativeCDCMirror(ctx, cfg, existingMirrors)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	for _, cfg := range spec.QrepMirrors {
+		change, err := h.diffDeclarativeQRepMirror(ctx, cfg, existingMirrors)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	if req.Prune {
+		for _, name := range slices.Sorted(maps.Keys(existingMirrors)) {
+			if slices.ContainsFunc(spec.CdcMirrors, func(cfg *protos.FlowConnectionConfigs) bool { return cfg.FlowJobName == name }) ||
+				slices.ContainsFunc(spec.QrepMirrors, func(cfg *protos.QRepConfig) bool { return cfg.FlowJobName == name }) {
+				continue
+			}
+			kind := declarativeKindQRepMirror
+			if existingMirrors[name] {
+				kind = declarativeKindCDCMirror
+			}
+			changes = append(changes, declarativeChange{
+				change: &protos.DeclarativeChange{Kind: kind, Name: name, Action: protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_DROP},
+				authorize: func(ctx context.Context) error {
+					return h.authorizeMirror(ctx, name)
+				},
+				apply: func(ctx context.Context) error {
+					_, err := h.FlowStateChange(ctx, &protos.FlowStateChangeRequest{
+						FlowJobName:        name,
+						RequestedFlowState: protos.FlowStatus_STATUS_TERMINATED,
+					})
+					return err
+				},
+			})
+		}
+		// mirrors are dropped asynchronously, so peers they use can only be dropped by a later apply
+		for _, name := range existingPeers {
+			if slices.ContainsFunc(spec.Peers, func(peer *protos.Peer) bool { return peer.Name == name }) {
+				continue
+			}
+			changes = append(changes, declarativeChange{
+				change: &protos.DeclarativeChange{
+					Kind: declarativeKindPeer, Name: name, Action: protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_DROP,
+				},
+				authorize: func(ctx context.Context) error {
+					return authorizeDeclarativePeer(ctx, name)
+				},
+				apply: func(ctx context.Context) error {
+					_, err := h.DropPeer(ctx, &protos.DropPeerRequest{PeerName: name})
+					return err
+				},
+			})
+		}
+	}
+
+	for _, change := range changes {
+		if change.change.Error == "" {
+			if err := change.authorize(ctx); err != nil {
+				change.change.Error = err.Error()
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func (h *FlowRequestHandler) diffDeclarativePeer(
+	ctx context.Context,
+	peer *protos.Peer,
+	exists bool,
+) (*declarativeChange, error) {
+	change := &protos.DeclarativeChange{Kind: declarativeKindPeer, Name: peer.Name}
+	if !exists {
+		change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_CREATE
+	} else {
+		current, err := connectors.LoadPeer(ctx, h.pool, peer.Name)
+		if err != nil {
+			return nil, err
+		}
+		if proto.Equal(current, peer) {
+			return nil, nil
+		}
+		change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_UPDATE
+		change.Fields = declarativeDiffFields(current, peer)
+	}
+
+	return &declarativeChange{
+		change: change,
+		authorize: func(ctx context.Context) error {
+			return authorizeDeclarativePeer(ctx, peer.Name)
+		},
+		apply: func(ctx context.Context) error {
+			res, err := h.CreatePeer(ctx, &protos.CreatePeerRequest{Peer: peer, AllowUpdate: true})
+			if err != nil {
+				return err
+			} else if res.Status != protos.CreatePeerStatus_CREATED {
+				return errors.New(res.Message)
+			}
+			return nil
+		},
+	}, nil
+}
+
+func (h *FlowRequestHandler) diffDeclarativeCDCMirror(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	existingMirrors map[string]bool,
+) (*declarativeChange, error) {
+	change := &protos.DeclarativeChange{Kind: declarativeKindCDCMirror, Name: cfg.FlowJobName}
+	isCDC, exists := existingMirrors[cfg.FlowJobName]
+	if !exists {
+		change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_CREATE
+		return &declarativeChange{
+			change: change,
+			authorize: func(ctx context.Context) error {
+				return authorizeNewMirror(ctx, cfg.FlowJobName, cfg.SourceName, cfg.DestinationName)
+			},
+			apply: func(ctx context.Context) error {
+				_, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: cfg})
+				return err
+			},
+		}, nil
+	} else if !isCDC {
+		change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_UPDATE
+		change.Error = "mirror exists as a QRep mirror"
+		return &declarativeChange{change: change}, nil
+	}
+
+	current, err := h.getFlowConfigFromCatalog(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	target := declarativeCDCTarget(current, cfg)
+	if proto.Equal(current, target) {
+		return nil, nil
+	}
+	change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_UPDATE
+	change.Fields = declarativeDiffFields(current, target)
+	if _, staticFields := cdcConfigRollbackUpdate(current, target); len(staticFields) > 0 {
+		change.Error = fmt.Sprintf("changing %s requires resyncing the mirror", strings.Join(staticFields, ", "))
+	}
+
+	return &declarativeChange{
+		change: change,
+		authorize: func(ctx context.Context) error {
+			return h.authorizeMirror(ctx, cfg.FlowJobName)
+		},
+		apply: func(ctx context.Context) error {
+			workflowID, err := h.getWorkflowID(ctx, cfg.FlowJobName)
+			if err != nil {
+				return err
+			}
+			currState, err := h.getWorkflowStatus(ctx, workflowID)
+			if err != nil {
+				return err
+			}
+			_, err = h.applyCDCConfig(ctx, cfg.FlowJobName, false, currState, target)
+			return err
+		},
+	}, nil
+}
+
+// declarativeCDCTarget fills in what the spec does not manage from the current config:
+// env set outside the spec, paused tables, and the options only used at creation
+func declarativeCDCTarget(current *protos.FlowConnectionConfigs, cfg *protos.FlowConnectionConfigs) *protos.FlowConnectionConfigs {
+	target := proto.CloneOf(cfg)
+	target.Version = current.Version
+	target.Resync = current.Resync
+	target.DoInitialSnapshot = current.DoInitialSnapshot
+	for key, value := range current.Env {
+		if _, ok := target.Env[key]; !ok {
+			if target.Env == nil {
+				target.Env = make(map[string]string)
+			}
+			target.Env[key] = value
+		}
+	}
+	target.PausedTableMappings = current.PausedTableMappings
+	target.TableMappings = slices.DeleteFunc(target.TableMappings, func(mapping *protos.TableMapping) bool {
+		return slices.ContainsFunc(current.PausedTableMappings, func(paused *protos.TableMapping) bool {
+			return paused.SourceTableIdentifier == mapping.SourceTableIdentifier
+		})
+	})
+	return target
+}
+
+func (h *FlowRequestHandler) diffDeclarativeQRepMirror(
+	ctx context.Context,
+	cfg *protos.QRepConfig,
+	existingMirrors map[string]bool,
+) (*declarativeChange, error) {
+	change := &protos.DeclarativeChange{Kind: declarativeKindQRepMirror, Name: cfg.FlowJobName}
+	isCDC, exists := existingMirrors[cfg.FlowJobName]
+	if !exists {
+		change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_CREATE
+		return &declarativeChange{
+			change: change,
+			authorize: func(ctx context.Context) error {
+				return authorizeNewMirror(ctx, cfg.FlowJobName, cfg.SourceName, cfg.DestinationName)
+			},
+			apply: func(ctx context.Context) error {
+				_, err := h.CreateQRepFlow(ctx, &protos.CreateQRepFlowRequest{QrepConfig: cfg, CreateCatalogEntry: true})
+				return err
+			},
+		}, nil
+	} else if isCDC {
+		change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_UPDATE
+		change.Error = "mirror exists as a CDC mirror"
+		return &declarativeChange{change: change}, nil
+	}
+
+	currentConfig, err := h.getMirrorConfig(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	current, ok := currentConfig.(*protos.QRepConfig)
+	if !ok {
+		return nil, fmt.Errorf("mirror %s is not a QRep mirror", cfg.FlowJobName)
+	}
+	// match the defaults filled in by CreateQRepFlow
+	target := proto.CloneOf(cfg)
+	target.Version = current.Version
+	target.ParentMirrorName = target.FlowJobName
+	if target.SyncedAtColName == "" {
+		target.SyncedAtColName = shared.DefaultSyncedAtColName
+	}
+	if proto.Equal(current, target) {
+		return nil, nil
+	}
+	change.Action = protos.DeclarativeChangeAction_DECLARATIVE_CHANGE_ACTION_UPDATE
+	change.Fields = declarativeDiffFields(current, target)
+
+	return &declarativeChange{
+		change: change,
+		authorize: func(ctx context.Context) error {
+			return h.authorizeMirror(ctx, cfg.FlowJobName)
+		},
+		apply: func(ctx context.Context) error {
+			workflowID, err := h.getWorkflowID(ctx, cfg.FlowJobName)
+			if err != nil {
+				return err
+			}
+			currState, err := h.getWorkflowStatus(ctx, workflowID)
+			if err != nil {
+				return err
+			}
+			_, err = h.applyQRepConfig(ctx, workflowID, currState, target)
+			return err
+		},
+	}, nil
+}
+
+// declarativeDiffFields returns the names of top level fields that differ between two messages of the same type
+func declarativeDiffFields(current proto.Message, target proto.Message) []string {
+	currentReflect := current.ProtoReflect()
+	targetReflect := target.ProtoReflect()
+	var fields []string
+	descriptors := currentReflect.Descriptor().Fields()
+	for i := range descriptors.Len() {
+		field := descriptors.Get(i)
+		if !currentReflect.Get(field).Equal(targetReflect.Get(field)) {
+			fields = append(fields, string(field.Name()))
+		}
+	}
+	return fields
+}
+
+type DeclarativeCLIParams struct {
+	SpecPath        string
+	FlowGrpcAddress string
+	FlowTlsEnabled  bool
+	DryRun          bool
+	Prune           bool
+}
+
+// DeclarativeApplyMain is the entry point for the apply command, it sends a spec file to the flow API
+// and prints the resulting changes
+func DeclarativeApplyMain(ctx context.Context, args *DeclarativeCLIParams) error {
+	spec, err := os.ReadFile(args.SpecPath)
+	if err != nil {
+		return fmt.Errorf("unable to read spec: %w", err)
+	}
+	client, err := constructFlowClient(args.FlowGrpcAddress, args.FlowTlsEnabled)
+	if err != nil {
+		return err
+	}
+
+	req := &protos.DeclarativeSpecRequest{Spec: string(spec), Prune: args.Prune}
+	var res *protos.DeclarativeSpecResponse
+	if args.DryRun {
+		res, err = client.DiffDeclarativeSpec(ctx, req)
+	} else {
+		res, err = client.ApplyDeclarativeSpec(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, change := range res.Changes {
+		action := strings.ToLower(strings.TrimPrefix(change.Action.String(), "DECLARATIVE_CHANGE_ACTION_"))
+		line := fmt.Sprintf("%s %s %s", action, change.Kind, change.Name)
+		if len(change.Fields) > 0 {
+			line += " (" + strings.Join(change.Fields, ", ") + ")"
+		}
+		if change.Error != "" {
+			failed += 1
+			line += ": " + change.Error
+		}
+		fmt.Println(line)
+	}
+	if len(res.Changes) == 0 {
+		fmt.Println("no changes")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d changes could not be applied", failed)
+	}
+	return nil
+}
+
+// authorizeDeclarativePeer checks the caller may change peer, peers are otherwise only changed by admins
+func authorizeDeclarativePeer(ctx context.Context, peer string) error {
+	if err := authorizeRole(ctx, middleware.RoleAdmin); err != nil {
+		return err
+	}
+	return authorizePeers(ctx, peer)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/client"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+func (h *FlowRequestHandler) DeduplicateMirror(
+	ctx context.Context, req *protos.DeduplicateMirrorRequest,
+) (*protos.DeduplicateMirrorResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+	if req.StartBatchId < 0 || req.EndBatchId < 0 || (req.EndBatchId != 0 && req.EndBatchId < req.StartBatchId) {
+		return nil, fmt.Errorf("invalid batch range [%d, %d]", req.StartBatchId, req.EndBatchId)
+	}
+
+	isCDC, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		slog.Error("unable to check if mirror is cdc", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to determine if mirror %s is cdc: %w", req.FlowJobName, err)
+	}
+	if !isCDC {
+		return nil, fmt.Errorf("deduplication is only supported for CDC mirrors, %s is not one", req.FlowJobName)
+	}
+
+	cfg, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowID := fmt.Sprintf("%s-dedup-%s", req.FlowJobName, uuid.New())
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(cfg.WorkerPool),
+		TypedSearchAttributes: shared.NewSearchAttributes(req.FlowJobName),
+	}
+	if _, err := h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, peerflow.DeduplicateDestinationWorkflow,
+		&protos.DeduplicateDestinationInput{
+			FlowJobName:           req.FlowJobName,
+			FlowConnectionConfigs: cfg,
+			StartBatchId:          req.StartBatchId,
+			EndBatchId:            req.EndBatchId,
+		},
+	); err != nil {
+		slog.Error("unable to start DeduplicateDestination workflow", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to start DeduplicateDestination workflow: %w", err)
+	}
+
+	slog.Info("deduplication started for mirror", slog.String("mirror", req.FlowJobName),
+		slog.Int64("startBatchId", req.StartBatchId), slog.Int64("endBatchId", req.EndBatchId))
+	return &protos.DeduplicateMirrorResponse{WorkflowId: workflowID}, nil
+}
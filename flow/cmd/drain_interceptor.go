@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// DrainWorkerInterceptor tracks running activities so shutdown can report what it is still waiting on
+type DrainWorkerInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	running map[string]int
+	mutex   sync.Mutex
+}
+
+func NewDrainWorkerInterceptor() *DrainWorkerInterceptor {
+	return &DrainWorkerInterceptor{
+		WorkerInterceptorBase: interceptor.WorkerInterceptorBase{},
+		running:               make(map[string]int),
+	}
+}
+
+func (d *DrainWorkerInterceptor) InterceptActivity(
+	ctx context.Context,
+	next interceptor.ActivityInboundInterceptor,
+) interceptor.ActivityInboundInterceptor {
+	return &drainActivityInboundInterceptor{
+		ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next},
+		drain:                          d,
+	}
+}
+
+// Running returns the number of in-flight activities by activity type
+func (d *DrainWorkerInterceptor) Running() map[string]int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return maps.Clone(d.running)
+}
+
+func (d *DrainWorkerInterceptor) track(activityType string, delta int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.running[activityType] += delta
+	if d.running[activityType] <= 0 {
+		delete(d.running, activityType)
+	}
+}
+
+type drainActivityInboundInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	drain *DrainWorkerInterceptor
+}
+
+func (c *drainActivityInboundInterceptor) ExecuteActivity(
+	ctx context.Context,
+	in *interceptor.ExecuteActivityInput,
+) (any, error) {
+	activityType := activity.GetInfo(ctx).ActivityType.Name
+	c.drain.track(activityType, 1)
+	defer c.drain.track(activityType, -1)
+	return c.Next.ExecuteActivity(ctx, in)
+}
@@ -31,13 +31,17 @@ type FlowRequestHandler struct {
 	pool                shared.CatalogPool
 	alerter             *alerting.Alerter
 	peerflowTaskQueueID string
+	temporalNamespace   string
 }
 
-func NewFlowRequestHandler(ctx context.Context, temporalClient client.Client, pool shared.CatalogPool, taskQueue string) *FlowRequestHandler {
+func NewFlowRequestHandler(
+	ctx context.Context, temporalClient client.Client, pool shared.CatalogPool, taskQueue string, temporalNamespace string,
+) *FlowRequestHandler {
 	return &FlowRequestHandler{
 		temporalClient:      temporalClient,
 		pool:                pool,
 		peerflowTaskQueueID: taskQueue,
+		temporalNamespace:   temporalNamespace,
 		alerter:             alerting.NewAlerter(ctx, pool, nil),
 	}
 }
@@ -127,7 +131,22 @@ func (h *FlowRequestHandler) createQRepJobEntry(ctx context.Context,
 func (h *FlowRequestHandler) CreateCDCFlow(
 	ctx context.Context, req *protos.CreateCDCFlowRequest,
 ) (*protos.CreateCDCFlowResponse, error) {
+	req, err := h.resolveMirrorTemplate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 	cfg := req.ConnectionConfigs
+	if err := authorizeNewMirror(ctx, cfg.FlowJobName, cfg.SourceName, cfg.DestinationName); err != nil {
+		return nil, err
+	}
+	if err := internal.ValidateWorkerPool(cfg.WorkerPool); err != nil {
+		return nil, err
+	}
+	if req.AllowUpdate && !cfg.Resync {
+		if res, err := h.updateExistingCDCFlow(ctx, cfg); err != nil || res != nil {
+			return res, err
+		}
+	}
 	cfg.Version = shared.InternalVersion_Latest
 
 	// For resync, we validate the mirror before dropping it and getting to this step.
@@ -142,7 +161,7 @@ func (h *FlowRequestHandler) CreateCDCFlow(
 	workflowID := fmt.Sprintf("%s-peerflow-%s", cfg.FlowJobName, uuid.New())
 	workflowOptions := client.StartWorkflowOptions{
 		ID:                    workflowID,
-		TaskQueue:             h.peerflowTaskQueueID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(cfg.WorkerPool),
 		TypedSearchAttributes: shared.NewSearchAttributes(cfg.FlowJobName),
 	}
 
@@ -160,9 +179,18 @@ func (h *FlowRequestHandler) CreateCDCFlow(
 		slog.Error("unable to start PeerFlow workflow", slog.Any("error", err))
 		return nil, fmt.Errorf("unable to start PeerFlow workflow: %w", err)
 	}
+	// resync recreates the mirror, it is recorded as a single resync by FlowStateChange
+	if !cfg.Resync {
+		h.recordMirrorAuditEvent(ctx, cfg.FlowJobName, mirrorAuditCreate, nil, cfg)
+	}
+	flowID, _, err := h.getFlowID(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
 
 	return &protos.CreateCDCFlowResponse{
 		WorkflowId: workflowID,
+		FlowId:     flowID,
 	}, nil
 }
 
@@ -177,12 +205,33 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 	ctx context.Context, req *protos.CreateQRepFlowRequest,
 ) (*protos.CreateQRepFlowResponse, error) {
 	cfg := req.QrepConfig
+	if err := authorizeNewMirror(ctx, cfg.FlowJobName, cfg.SourceName, cfg.DestinationName); err != nil {
+		return nil, err
+	}
+	if err := internal.ValidateWorkerPool(cfg.WorkerPool); err != nil {
+		return nil, err
+	}
+	if cfg.SamplePercent < 0 || cfg.SamplePercent > 100 {
+		return nil, fmt.Errorf("sample percent must be between 0 and 100, got %v", cfg.SamplePercent)
+	} else if cfg.SamplePercent > 0 && cfg.System == protos.TypeSystem_PG {
+		return nil, errors.New("sampling is not supported with the PG type system")
+	} else if cfg.SamplePercent > 0 && cfg.WatermarkColumn == "xmin" && cfg.SampleColumn == "" {
+		return nil, errors.New("sample column is required to sample xmin mirrors")
+	}
+	if cfg.Script != "" && cfg.System == protos.TypeSystem_PG {
+		return nil, errors.New("scripts are not supported with the PG type system")
+	}
+	if req.AllowUpdate && req.CreateCatalogEntry {
+		if res, err := h.updateExistingQRepFlow(ctx, cfg); err != nil || res != nil {
+			return res, err
+		}
+	}
 	cfg.Version = shared.InternalVersion_Latest
 
 	workflowID := fmt.Sprintf("%s-qrepflow-%s", cfg.FlowJobName, uuid.New())
 	workflowOptions := client.StartWorkflowOptions{
 		ID:                    workflowID,
-		TaskQueue:             h.peerflowTaskQueueID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(cfg.WorkerPool),
 		TypedSearchAttributes: shared.NewSearchAttributes(cfg.FlowJobName),
 	}
 	if req.CreateCatalogEntry {
@@ -204,7 +253,7 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 	}
 
 	if req.QrepConfig.SyncedAtColName == "" {
-		cfg.SyncedAtColName = "_PEERDB_SYNCED_AT"
+		cfg.SyncedAtColName = shared.DefaultSyncedAtColName
 	}
 
 	cfg.ParentMirrorName = cfg.FlowJobName
@@ -220,9 +269,17 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 			slog.Any("error", err), slog.String("flowName", cfg.FlowJobName))
 		return nil, fmt.Errorf("unable to update qrep config in catalog: %w", err)
 	}
+	var flowID int64
+	if req.CreateCatalogEntry {
+		h.recordMirrorAuditEvent(ctx, cfg.FlowJobName, mirrorAuditCreate, nil, cfg)
+		if flowID, _, err = h.getFlowID(ctx, cfg.FlowJobName); err != nil {
+			return nil, err
+		}
+	}
 
 	return &protos.CreateQRepFlowResponse{
 		WorkflowId: workflowID,
+		FlowId:     flowID,
 	}, nil
 }
 
@@ -230,7 +287,7 @@ func (h *FlowRequestHandler) updateQRepConfigInCatalog(
 	ctx context.Context,
 	cfg *protos.QRepConfig,
 ) error {
-	cfgBytes, err := proto.Marshal(cfg)
+	cfgBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("unable to marshal qrep config: %w", err)
 	}
@@ -242,7 +299,7 @@ func (h *FlowRequestHandler) updateQRepConfigInCatalog(
 		return fmt.Errorf("unable to update qrep config in catalog: %w", err)
 	}
 
-	return nil
+	return internal.RecordConfigVersion(ctx, h.pool, cfg.FlowJobName, cfgBytes)
 }
 
 func (h *FlowRequestHandler) shutdownFlow(
@@ -266,23 +323,24 @@ func (h *FlowRequestHandler) shutdownFlow(
 		return fmt.Errorf("unable to wait for PeerFlow workflow to close: %w", err)
 	}
 
-	isCdc, err := h.isCDCFlow(ctx, flowJobName)
+	mirrorConfig, err := h.getMirrorConfig(ctx, flowJobName)
 	if err != nil {
-		slog.Error("unable to check if workflow is cdc", logs, slog.Any("error", err))
-		return fmt.Errorf("unable to determine if workflow is cdc: %w", err)
+		slog.Error("unable to get mirror config from catalog", logs, slog.Any("error", err))
+		return fmt.Errorf("unable to get mirror config from catalog: %w", err)
 	}
 	var cdcConfig *protos.FlowConnectionConfigs
-	if isCdc {
-		cdcConfig, err = h.getFlowConfigFromCatalog(ctx, flowJobName)
-		if err != nil {
-			slog.Error("unable to get cdc config from catalog", logs, slog.Any("error", err))
-			return fmt.Errorf("unable to get cdc config from catalog: %w", err)
-		}
+	var workerPool string
+	switch config := mirrorConfig.(type) {
+	case *protos.FlowConnectionConfigs:
+		cdcConfig = config
+		workerPool = config.WorkerPool
+	case *protos.QRepConfig:
+		workerPool = config.WorkerPool
 	}
 	dropFlowWorkflowID := fmt.Sprintf("%s-dropflow-%s", flowJobName, uuid.New())
 	workflowOptions := client.StartWorkflowOptions{
 		ID:                    dropFlowWorkflowID,
-		TaskQueue:             h.peerflowTaskQueueID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(workerPool),
 		TypedSearchAttributes: shared.NewSearchAttributes(flowJobName),
 	}
 
@@ -345,6 +403,8 @@ func (h *FlowRequestHandler) FlowStateChange(
 		slog.Error("[flow-state-change] unable to get workflow status", logs, slog.Any("error", err))
 		return nil, err
 	}
+	// read before any change since drop and resync delete the catalog entry
+	previousConfig := h.mirrorAuditConfig(ctx, req.FlowJobName)
 
 	if req.FlowConfigUpdate != nil && req.FlowConfigUpdate.GetCdcFlowConfigUpdate() != nil {
 		if err := model.CDCDynamicPropertiesSignal.SignalClientWorkflow(
@@ -357,22 +417,51 @@ func (h *FlowRequestHandler) FlowStateChange(
 			slog.Error("unable to signal workflow", logs, slog.Any("error", err))
 			return nil, fmt.Errorf("unable to signal workflow: %w", err)
 		}
+		h.recordMirrorAuditEvent(ctx, req.FlowJobName, mirrorAuditEdit, previousConfig, req.FlowConfigUpdate.GetCdcFlowConfigUpdate())
 	}
 
 	slog.Info("[flow-state-change] received request", logs,
-		slog.Any("requestedFlowState", req.RequestedFlowState), slog.Any("currState", currState))
+		slog.Any("requestedFlowState", req.RequestedFlowState), slog.Any("currState", currState),
+		slog.Any("scope", req.Scope))
+	switch req.Scope {
+	case protos.FlowStateChangeScope_FLOW_STATE_CHANGE_SCOPE_BACKFILL:
+		if err := h.backfillStateChange(ctx, req, previousConfig); err != nil {
+			slog.Error("unable to change backfill state", logs, slog.Any("error", err))
+			return nil, err
+		}
+		return &protos.FlowStateChangeResponse{}, nil
+	case protos.FlowStateChangeScope_FLOW_STATE_CHANGE_SCOPE_CDC:
+		// outside of snapshots change capture is all that runs, so it is paused and resumed like the whole mirror
+		if currState == protos.FlowStatus_STATUS_SETUP || currState == protos.FlowStatus_STATUS_SNAPSHOT {
+			operation, err := pauseOrResumeOperation(req.RequestedFlowState)
+			if err != nil {
+				return nil, err
+			}
+			if err := model.FlowSignalStateChange.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", req); err != nil {
+				slog.Error("unable to signal workflow", logs, slog.Any("error", err))
+				return nil, fmt.Errorf("unable to signal workflow: %w", err)
+			}
+			h.recordMirrorAuditEvent(ctx, req.FlowJobName, operation, previousConfig, nil)
+			return &protos.FlowStateChangeResponse{}, nil
+		}
+	}
+
 	if req.RequestedFlowState != currState {
 		var changeErr error
+		var operation string
 		switch req.RequestedFlowState {
 		case protos.FlowStatus_STATUS_PAUSED:
 			if currState == protos.FlowStatus_STATUS_RUNNING {
 				changeErr = model.FlowSignal.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", model.PauseSignal)
+				operation = mirrorAuditPause
 			}
 		case protos.FlowStatus_STATUS_RUNNING:
 			if currState == protos.FlowStatus_STATUS_PAUSED {
 				changeErr = model.FlowSignal.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", model.NoopSignal)
+				operation = mirrorAuditResume
 			}
 		case protos.FlowStatus_STATUS_RESYNC:
+			operation = mirrorAuditResync
 			if currState == protos.FlowStatus_STATUS_COMPLETED {
 				changeErr = h.resyncMirror(ctx, req.FlowJobName, req.DropMirrorStats)
 			} else if isCDC, err := h.isCDCFlow(ctx, req.FlowJobName); err != nil {
@@ -399,6 +488,7 @@ func (h *FlowRequestHandler) FlowStateChange(
 			}
 		case protos.FlowStatus_STATUS_TERMINATING, protos.FlowStatus_STATUS_TERMINATED:
 			if currState != protos.FlowStatus_STATUS_TERMINATED && currState != protos.FlowStatus_STATUS_TERMINATING {
+				operation = mirrorAuditDrop
 				if currState == protos.FlowStatus_STATUS_COMPLETED {
 					changeErr = h.shutdownFlow(ctx, req.FlowJobName, req.DropMirrorStats, req.SkipDestinationDrop)
 				} else {
@@ -415,11 +505,46 @@ func (h *FlowRequestHandler) FlowStateChange(
 			slog.Error("unable to signal workflow", logs, slog.Any("error", changeErr))
 			return nil, fmt.Errorf("unable to signal workflow: %w", changeErr)
 		}
+		if operation != "" {
+			h.recordMirrorAuditEvent(ctx, req.FlowJobName, operation, previousConfig, nil)
+		}
 	}
 
 	return &protos.FlowStateChangeResponse{}, nil
 }
 
+// backfillStateChange pauses or resumes replicating partitions of initial load, table additions and QRep,
+// the flag is read between partitions so it also applies to partitions already scheduled
+func (h *FlowRequestHandler) backfillStateChange(
+	ctx context.Context,
+	req *protos.FlowStateChangeRequest,
+	previousConfig proto.Message,
+) error {
+	operation, err := pauseOrResumeOperation(req.RequestedFlowState)
+	if err != nil {
+		return err
+	}
+	if _, err := h.pool.Exec(ctx,
+		"UPDATE flows SET backfill_paused=$2,updated_at=now() WHERE name=$1",
+		req.FlowJobName, req.RequestedFlowState == protos.FlowStatus_STATUS_PAUSED,
+	); err != nil {
+		return fmt.Errorf("unable to update backfill state in catalog: %w", err)
+	}
+	h.recordMirrorAuditEvent(ctx, req.FlowJobName, operation, previousConfig, nil)
+	return nil
+}
+
+func pauseOrResumeOperation(requestedFlowState protos.FlowStatus) (string, error) {
+	switch requestedFlowState {
+	case protos.FlowStatus_STATUS_PAUSED:
+		return mirrorAuditPause, nil
+	case protos.FlowStatus_STATUS_RUNNING:
+		return mirrorAuditResume, nil
+	default:
+		return "", fmt.Errorf("only pause and resume can be scoped, requested state is: %v", requestedFlowState)
+	}
+}
+
 func (h *FlowRequestHandler) handleCancelWorkflow(ctx context.Context, workflowID, runID string) error {
 	errChan := make(chan error, 1)
 
@@ -466,7 +591,14 @@ func (h *FlowRequestHandler) CreatePeer(
 		}, nil
 	}
 
-	return utils.CreatePeerNoValidate(ctx, h.pool, req.Peer, req.AllowUpdate)
+	res, err := utils.CreatePeerNoValidate(ctx, h.pool, req.Peer, req.AllowUpdate)
+	if err != nil || res.Status != protos.CreatePeerStatus_CREATED {
+		return res, err
+	}
+	if res.PeerId, _, err = h.getPeerID(ctx, req.Peer.Name); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 func (h *FlowRequestHandler) DropPeer(
@@ -149,7 +149,7 @@ func skipStartMaintenanceIfNeeded(ctx context.Context, args *MaintenanceCLIParam
 
 	if args.SkipOnApiVersionMatch || args.SkipOnNoMirrors || args.SkipOnDeploymentVersionMatch {
 		slog.Info("Checking if API version matches")
-		peerFlowClient, err := constructFlowClient(args)
+		peerFlowClient, err := constructFlowClient(args.FlowGrpcAddress, args.FlowTlsEnabled)
 		if err != nil {
 			return false, err
 		}
@@ -213,16 +213,16 @@ func skipStartMaintenanceIfNeeded(ctx context.Context, args *MaintenanceCLIParam
 	return false, nil
 }
 
-func constructFlowClient(args *MaintenanceCLIParams) (protos.FlowServiceClient, error) {
-	if args.FlowGrpcAddress == "" {
+func constructFlowClient(flowGrpcAddress string, flowTlsEnabled bool) (protos.FlowServiceClient, error) {
+	if flowGrpcAddress == "" {
 		return nil, errors.New("flow address is required")
 	}
 	slog.Info("Constructing flow client")
 	transportCredentials := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS13})
-	if !args.FlowTlsEnabled {
+	if !flowTlsEnabled {
 		transportCredentials = insecure.NewCredentials()
 	}
-	conn, err := grpc.NewClient(args.FlowGrpcAddress,
+	conn, err := grpc.NewClient(flowGrpcAddress,
 		grpc.WithTransportCredentials(transportCredentials),
 	)
 	if err != nil {
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.temporal.io/sdk/client"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+const (
+	defaultMigrationMaxCatchUpLagBytes       = 16 * 1024 * 1024
+	defaultMigrationWriteFreezeWindowSeconds = 30
+)
+
+func migrationWorkflowID(flowJobName string) string {
+	return flowJobName + "-migration"
+}
+
+// CreateMigration creates a CDC mirror and starts a migration that takes it through to cutover
+func (h *FlowRequestHandler) CreateMigration(
+	ctx context.Context, req *protos.CreateMigrationRequest,
+) (*protos.CreateMigrationResponse, error) {
+	if req.ConnectionConfigs == nil || req.ConnectionConfigs.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+	flowJobName := req.ConnectionConfigs.FlowJobName
+	if _, sourceType, err := h.getPeerID(ctx, req.ConnectionConfigs.SourceName); err != nil {
+		return nil, err
+	} else if protos.DBType(sourceType) != protos.DBType_POSTGRES {
+		return nil, fmt.Errorf("migrations are only supported from Postgres sources, %s is %s",
+			req.ConnectionConfigs.SourceName, protos.DBType(sourceType))
+	}
+	if req.MaxCatchUpLagBytes < 0 {
+		return nil, errors.New("max catch-up lag cannot be negative")
+	}
+	maxCatchUpLagBytes := req.MaxCatchUpLagBytes
+	if maxCatchUpLagBytes == 0 {
+		maxCatchUpLagBytes = defaultMigrationMaxCatchUpLagBytes
+	}
+	writeFreezeWindowSeconds := req.WriteFreezeWindowSeconds
+	if writeFreezeWindowSeconds == 0 {
+		writeFreezeWindowSeconds = defaultMigrationWriteFreezeWindowSeconds
+	}
+
+	res, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: req.ConnectionConfigs})
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := h.getFlowConfigFromCatalog(ctx, flowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowID := migrationWorkflowID(flowJobName)
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(cfg.WorkerPool),
+		TypedSearchAttributes: shared.NewSearchAttributes(flowJobName),
+	}
+	if _, err := h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, peerflow.MigrationFlowWorkflow,
+		&protos.MigrationFlowInput{
+			FlowConnectionConfigs:    cfg,
+			MaxCatchUpLagBytes:       maxCatchUpLagBytes,
+			WriteFreezeWindowSeconds: writeFreezeWindowSeconds,
+		},
+	); err != nil {
+		slog.Error("unable to start Migration workflow", slog.String("mirror", flowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to start Migration workflow: %w", err)
+	}
+
+	slog.Info("migration started for mirror", slog.String("mirror", flowJobName))
+	return &protos.CreateMigrationResponse{WorkflowId: workflowID, FlowId: res.FlowId}, nil
+}
+
+// GetMigrationState returns the phase a migration is in along with its progress
+func (h *FlowRequestHandler) GetMigrationState(
+	ctx context.Context, req *protos.GetMigrationStateRequest,
+) (*protos.GetMigrationStateResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+
+	workflowID := migrationWorkflowID(req.FlowJobName)
+	res, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", shared.MigrationStateQuery)
+	if err != nil {
+		slog.Error("failed to query migration state", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get state of migration %s: %w", req.FlowJobName, err)
+	}
+	var state *protos.MigrationState
+	if err := res.Get(&state); err != nil {
+		return nil, fmt.Errorf("failed to get state of migration %s: %w", req.FlowJobName, err)
+	}
+	return &protos.GetMigrationStateResponse{State: state}, nil
+}
+
+// ConfirmMigrationWriteFreeze tells a migration that writes to its source tables have been frozen,
+// the migration verifies this before draining the mirror and cutting over
+func (h *FlowRequestHandler) ConfirmMigrationWriteFreeze(
+	ctx context.Context, req *protos.ConfirmMigrationWriteFreezeRequest,
+) (*protos.ConfirmMigrationWriteFreezeResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+
+	if err := model.MigrationWriteFreezeSignal.SignalClientWorkflow(
+		ctx, h.temporalClient, migrationWorkflowID(req.FlowJobName), "", struct{}{},
+	); err != nil {
+		slog.Error("unable to signal write freeze", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to signal write freeze to migration %s: %w", req.FlowJobName, err)
+	}
+	return &protos.ConfirmMigrationWriteFreezeResponse{}, nil
+}
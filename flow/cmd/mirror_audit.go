@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const (
+	mirrorAuditCreate = "create"
+	mirrorAuditPause  = "pause"
+	mirrorAuditResume = "resume"
+	mirrorAuditResync = "resync"
+	mirrorAuditEdit   = "edit"
+	mirrorAuditDrop   = "drop"
+)
+
+// mirrorAuditConfig loads the current config of a mirror to be recorded as previous config of an audit event,
+// failures are logged since auditing should not fail the operation
+func (h *FlowRequestHandler) mirrorAuditConfig(ctx context.Context, flowJobName string) proto.Message {
+	config, err := h.getMirrorConfig(ctx, flowJobName)
+	if err != nil {
+		slog.Warn("unable to load flow config for audit", slog.String("flowName", flowJobName), slog.Any("error", err))
+		return nil
+	}
+	return config
+}
+
+// recordMirrorAuditEvent appends a lifecycle operation to the audit log, the actor is the subject of the request token.
+// Failures are logged rather than returned since the operation itself already took place
+func (h *FlowRequestHandler) recordMirrorAuditEvent(
+	ctx context.Context,
+	flowJobName string,
+	operation string,
+	previous proto.Message,
+	next proto.Message,
+) {
+	actor, _ := ctx.Value(shared.RequestActorKey).(string)
+	previousJSON, err := marshalMirrorAuditConfig(previous)
+	if err != nil {
+		slog.Warn("unable to marshal previous config for audit", slog.String("flowName", flowJobName), slog.Any("error", err))
+	}
+	nextJSON, err := marshalMirrorAuditConfig(next)
+	if err != nil {
+		slog.Warn("unable to marshal new config for audit", slog.String("flowName", flowJobName), slog.Any("error", err))
+	}
+
+	if _, err := h.pool.Exec(ctx,
+		`INSERT INTO peerdb_stats.mirror_audit_events(flow_name, operation, actor, previous_config, new_config)
+		VALUES ($1, $2, $3, $4, $5)`,
+		flowJobName, operation, actor, previousJSON, nextJSON,
+	); err != nil {
+		slog.Error("unable to record mirror audit event",
+			slog.String("flowName", flowJobName), slog.String("operation", operation), slog.Any("error", err))
+	}
+}
+
+func marshalMirrorAuditConfig(config proto.Message) ([]byte, error) {
+	if config == nil || !config.ProtoReflect().IsValid() {
+		return nil, nil
+	}
+	return protojson.Marshal(config)
+}
+
+func (h *FlowRequestHandler) ListMirrorAuditEvents(
+	ctx context.Context,
+	req *protos.ListMirrorAuditEventsRequest,
+) (*protos.ListMirrorAuditEventsResponse, error) {
+	numPerPage := req.NumPerPage
+	if numPerPage <= 0 {
+		numPerPage = 100
+	}
+
+	rows, err := h.pool.Query(ctx, `SELECT id, flow_name, operation, actor,
+		coalesce(previous_config::text, ''), coalesce(new_config::text, ''), created_at
+		FROM peerdb_stats.mirror_audit_events
+		WHERE ($1 = '' OR flow_name = $1) AND ($2 = '' OR operation = $2) AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4`, req.FlowJobName, req.Operation, req.BeforeId, numPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirror audit events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.MirrorAuditEvent, error) {
+		var event protos.MirrorAuditEvent
+		var createdAt time.Time
+		if err := row.Scan(
+			&event.Id, &event.FlowJobName, &event.Operation, &event.Actor,
+			&event.PreviousConfig, &event.NewConfig, &createdAt,
+		); err != nil {
+			return nil, err
+		}
+		event.CreatedAt = float64(createdAt.UnixMilli())
+		return &event, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirror audit events: %w", err)
+	}
+
+	return &protos.ListMirrorAuditEventsResponse{Events: events}, nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// CloneMirror creates a mirror with the config of an existing one, tuned settings like parallelism,
+// batch sizes and env are kept while state tied to the source mirror is reset
+func (h *FlowRequestHandler) CloneMirror(
+	ctx context.Context,
+	req *protos.CloneMirrorRequest,
+) (*protos.CloneMirrorResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("name of the cloned mirror is required")
+	}
+
+	sourceConfig, err := h.getMirrorConfig(ctx, req.SourceFlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	switch config := sourceConfig.(type) {
+	case *protos.FlowConnectionConfigs:
+		cfg := proto.CloneOf(config)
+		cfg.FlowJobName = req.FlowJobName
+		cfg.Version = 0
+		cfg.Resync = false
+		cfg.PausedTableMappings = nil
+		// slots and publications belong to a single mirror, the clone gets its own
+		cfg.ReplicationSlotName = ""
+		cfg.PublicationName = ""
+		if req.DestinationName != "" {
+			cfg.DestinationName = req.DestinationName
+		}
+		if len(req.TableMappings) > 0 {
+			cfg.TableMappings = req.TableMappings
+		}
+		if req.DestinationTableIdentifier != "" {
+			return nil, errors.New("destination_table_identifier only applies to QRep mirrors, use table_mappings instead")
+		}
+		if err := validateCDCCloneDestination(config, cfg); err != nil {
+			return nil, err
+		}
+
+		res, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: cfg})
+		if err != nil {
+			return nil, fmt.Errorf("unable to clone mirror %s: %w", req.SourceFlowJobName, err)
+		}
+		return &protos.CloneMirrorResponse{WorkflowId: res.WorkflowId, FlowId: res.FlowId}, nil
+	case *protos.QRepConfig:
+		cfg := proto.CloneOf(config)
+		cfg.FlowJobName = req.FlowJobName
+		cfg.ParentMirrorName = ""
+		cfg.Version = 0
+		if req.DestinationName != "" {
+			cfg.DestinationName = req.DestinationName
+		}
+		if req.DestinationTableIdentifier != "" {
+			cfg.DestinationTableIdentifier = req.DestinationTableIdentifier
+		}
+		if len(req.TableMappings) > 0 {
+			return nil, errors.New("table_mappings only apply to CDC mirrors, use destination_table_identifier instead")
+		}
+		if err := validateQRepCloneDestination(config, cfg); err != nil {
+			return nil, err
+		}
+
+		res, err := h.CreateQRepFlow(ctx, &protos.CreateQRepFlowRequest{QrepConfig: cfg, CreateCatalogEntry: true})
+		if err != nil {
+			return nil, fmt.Errorf("unable to clone mirror %s: %w", req.SourceFlowJobName, err)
+		}
+		return &protos.CloneMirrorResponse{WorkflowId: res.WorkflowId, FlowId: res.FlowId}, nil
+	default:
+		return nil, fmt.Errorf("unable to load config of mirror %s", req.SourceFlowJobName)
+	}
+}
+
+// validateCDCCloneDestination rejects a clone replicating into tables of the source mirror,
+// its snapshot and normalize would run alongside those of the source mirror on the same tables
+func validateCDCCloneDestination(source *protos.FlowConnectionConfigs, clone *protos.FlowConnectionConfigs) error {
+	if clone.DestinationName != source.DestinationName {
+		return nil
+	}
+	sourceTables := make(map[string]struct{}, len(source.TableMappings))
+	for _, tableMapping := range source.TableMappings {
+		sourceTables[tableMapping.DestinationTableIdentifier] = struct{}{}
+	}
+	for _, tableMapping := range clone.TableMappings {
+		if _, ok := sourceTables[tableMapping.DestinationTableIdentifier]; ok {
+			return fmt.Errorf("destination table %s is already replicated to by mirror %s on peer %s, "+
+				"clone into another peer or set table_mappings",
+				tableMapping.DestinationTableIdentifier, source.FlowJobName, source.DestinationName)
+		}
+	}
+	return nil
+}
+
+// validateQRepCloneDestination rejects a clone writing into the table of the source mirror,
+// in overwrite mode it would truncate the table of the source mirror
+func validateQRepCloneDestination(source *protos.QRepConfig, clone *protos.QRepConfig) error {
+	if clone.DestinationName == source.DestinationName &&
+		clone.DestinationTableIdentifier == source.DestinationTableIdentifier {
+		return fmt.Errorf("destination table %s is already replicated to by mirror %s on peer %s, "+
+			"clone into another peer or set destination_table_identifier",
+			source.DestinationTableIdentifier, source.FlowJobName, source.DestinationName)
+	}
+	return nil
+}
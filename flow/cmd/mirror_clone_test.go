@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestValidateCDCCloneDestination(t *testing.T) {
+	source := &protos.FlowConnectionConfigs{
+		FlowJobName:     "source",
+		DestinationName: "ch",
+		TableMappings: []*protos.TableMapping{
+			{SourceTableIdentifier: "public.a", DestinationTableIdentifier: "a"},
+			{SourceTableIdentifier: "public.b", DestinationTableIdentifier: "b"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		clone *protos.FlowConnectionConfigs
+		valid bool
+	}{
+		{"same peer and tables", &protos.FlowConnectionConfigs{DestinationName: "ch", TableMappings: source.TableMappings}, false},
+		{"same peer, one overlapping table", &protos.FlowConnectionConfigs{DestinationName: "ch", TableMappings: []*protos.TableMapping{
+			{SourceTableIdentifier: "public.a", DestinationTableIdentifier: "a_clone"},
+			{SourceTableIdentifier: "public.b", DestinationTableIdentifier: "b"},
+		}}, false},
+		{"same peer, other tables", &protos.FlowConnectionConfigs{DestinationName: "ch", TableMappings: []*protos.TableMapping{
+			{SourceTableIdentifier: "public.a", DestinationTableIdentifier: "a_clone"},
+		}}, true},
+		{"other peer", &protos.FlowConnectionConfigs{DestinationName: "ch2", TableMappings: source.TableMappings}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCDCCloneDestination(source, tc.clone)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateQRepCloneDestination(t *testing.T) {
+	source := &protos.QRepConfig{FlowJobName: "source", DestinationName: "sf", DestinationTableIdentifier: "public.t"}
+
+	require.Error(t, validateQRepCloneDestination(source,
+		&protos.QRepConfig{DestinationName: "sf", DestinationTableIdentifier: "public.t"}))
+	require.NoError(t, validateQRepCloneDestination(source,
+		&protos.QRepConfig{DestinationName: "sf", DestinationTableIdentifier: "public.t_clone"}))
+	require.NoError(t, validateQRepCloneDestination(source,
+		&protos.QRepConfig{DestinationName: "sf2", DestinationTableIdentifier: "public.t"}))
+}
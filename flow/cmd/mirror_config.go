@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// getFlowID returns the id of the first catalog entry of a mirror, CDC mirrors have an entry per table
+func (h *FlowRequestHandler) getFlowID(ctx context.Context, flowJobName string) (int64, bool, error) {
+	var flowID pgtype.Int8
+	if err := h.pool.QueryRow(ctx, "SELECT min(id) FROM flows WHERE name = $1", flowJobName).Scan(&flowID); err != nil {
+		return 0, false, fmt.Errorf("unable to query id of mirror %s: %w", flowJobName, err)
+	}
+	return flowID.Int64, flowID.Valid, nil
+}
+
+// updateExistingCDCFlow brings an existing mirror to cfg for upserts, it returns nil when there is no mirror to update
+func (h *FlowRequestHandler) updateExistingCDCFlow(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+) (*protos.CreateCDCFlowResponse, error) {
+	flowID, exists, err := h.getFlowID(ctx, cfg.FlowJobName)
+	if err != nil || !exists {
+		return nil, err
+	}
+	isCDC, err := h.isCDCFlow(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := h.diffDeclarativeCDCMirror(ctx, cfg, map[string]bool{cfg.FlowJobName: isCDC})
+	if err != nil {
+		return nil, err
+	}
+	if change != nil {
+		if change.change.Error != "" {
+			return nil, fmt.Errorf("unable to update mirror %s: %s", cfg.FlowJobName, change.change.Error)
+		}
+		if err := change.apply(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	workflowID, err := h.getWorkflowID(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.CreateCDCFlowResponse{WorkflowId: workflowID, FlowId: flowID}, nil
+}
+
+// updateExistingQRepFlow brings an existing mirror to cfg for upserts, it returns nil when there is no mirror to update
+func (h *FlowRequestHandler) updateExistingQRepFlow(
+	ctx context.Context,
+	cfg *protos.QRepConfig,
+) (*protos.CreateQRepFlowResponse, error) {
+	flowID, exists, err := h.getFlowID(ctx, cfg.FlowJobName)
+	if err != nil || !exists {
+		return nil, err
+	}
+	isCDC, err := h.isCDCFlow(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := h.diffDeclarativeQRepMirror(ctx, cfg, map[string]bool{cfg.FlowJobName: isCDC})
+	if err != nil {
+		return nil, err
+	}
+	if change != nil {
+		if change.change.Error != "" {
+			return nil, fmt.Errorf("unable to update mirror %s: %s", cfg.FlowJobName, change.change.Error)
+		}
+		if err := change.apply(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// restarting QRep mirrors changes their workflow
+	workflowID, err := h.getWorkflowID(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.CreateQRepFlowResponse{WorkflowId: workflowID, FlowId: flowID}, nil
+}
+
+func (h *FlowRequestHandler) GetMirrorConfig(
+	ctx context.Context,
+	req *protos.GetMirrorConfigRequest,
+) (*protos.GetMirrorConfigResponse, error) {
+	flowID, exists, err := h.getFlowID(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, fmt.Errorf("mirror %s not found", req.FlowJobName)
+	}
+	workflowID, err := h.getWorkflowID(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &protos.GetMirrorConfigResponse{FlowId: flowID, WorkflowId: workflowID}
+	mirrorConfig, err := h.getMirrorConfig(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	switch config := mirrorConfig.(type) {
+	case *protos.FlowConnectionConfigs:
+		effective, err := h.effectiveCDCConfig(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		res.Config = &protos.GetMirrorConfigResponse_CdcConfig{CdcConfig: config}
+		res.EffectiveConfig = &protos.GetMirrorConfigResponse_EffectiveCdcConfig{EffectiveCdcConfig: effective}
+	case *protos.QRepConfig:
+		res.Config = &protos.GetMirrorConfigResponse_QrepConfig{QrepConfig: config}
+		res.EffectiveConfig = &protos.GetMirrorConfigResponse_EffectiveQrepConfig{EffectiveQrepConfig: effectiveQRepConfig(config)}
+	default:
+		return nil, errors.New("unable to load mirror config")
+	}
+	return res, nil
+}
+
+// effectiveCDCConfig fills in the defaults used for settings left unset
+func (h *FlowRequestHandler) effectiveCDCConfig(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+) (*protos.FlowConnectionConfigs, error) {
+	effective := proto.CloneOf(config)
+	if effective.MaxBatchSize == 0 {
+		effective.MaxBatchSize = shared.DefaultCDCBatchSize
+	}
+	if effective.IdleTimeoutSeconds == 0 {
+		effective.IdleTimeoutSeconds = shared.DefaultCDCIdleTimeoutSeconds
+	}
+	if effective.SnapshotMaxParallelWorkers == 0 {
+		effective.SnapshotMaxParallelWorkers = shared.DefaultSnapshotMaxParallelWorkers
+	}
+	if effective.SnapshotNumRowsPerPartition == 0 {
+		effective.SnapshotNumRowsPerPartition = shared.DefaultSnapshotNumRowsPerPartition
+	}
+	if effective.SnapshotNumTablesInParallel == 0 {
+		effective.SnapshotNumTablesInParallel = shared.DefaultSnapshotNumTablesInParallel
+	}
+
+	sourceType, err := connectors.LoadPeerType(ctx, h.pool, effective.SourceName)
+	if err != nil {
+		return nil, err
+	}
+	if sourceType == protos.DBType_POSTGRES {
+		if effective.ReplicationSlotName == "" {
+			effective.ReplicationSlotName = "peerflow_slot_" + effective.FlowJobName
+		}
+		if effective.PublicationName == "" {
+			effective.PublicationName = "peerflow_pub_" + effective.FlowJobName
+		}
+	}
+	return effective, nil
+}
+
+// effectiveQRepConfig fills in the defaults used for settings left unset
+func effectiveQRepConfig(config *protos.QRepConfig) *protos.QRepConfig {
+	effective := proto.CloneOf(config)
+	if effective.MaxParallelWorkers == 0 {
+		effective.MaxParallelWorkers = shared.DefaultQRepMaxParallelWorkers
+	}
+	if effective.WaitBetweenBatchesSeconds == 0 {
+		effective.WaitBetweenBatchesSeconds = shared.DefaultQRepWaitBetweenBatchesSeconds
+	}
+	if effective.SyncedAtColName == "" {
+		effective.SyncedAtColName = shared.DefaultSyncedAtColName
+	}
+	return effective
+}
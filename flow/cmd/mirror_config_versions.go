@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+const (
+	configRollbackByUpdate  = "update"
+	configRollbackByResync  = "resync"
+	configRollbackByRestart = "restart"
+	configRollbackByCatalog = "catalog"
+)
+
+func (h *FlowRequestHandler) ListMirrorConfigVersions(
+	ctx context.Context,
+	req *protos.ListMirrorConfigVersionsRequest,
+) (*protos.ListMirrorConfigVersionsResponse, error) {
+	isCDC, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.pool.Query(ctx,
+		"SELECT version, config_proto, created_at FROM flow_config_versions WHERE flow_name=$1 ORDER BY version DESC",
+		req.FlowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query config versions: %w", err)
+	}
+	versions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.MirrorConfigVersion, error) {
+		var version int32
+		var configBytes []byte
+		var createdAt time.Time
+		if err := row.Scan(&version, &configBytes, &createdAt); err != nil {
+			return nil, err
+		}
+		return newMirrorConfigVersion(isCDC, version, configBytes, createdAt)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query config versions: %w", err)
+	}
+
+	return &protos.ListMirrorConfigVersionsResponse{Versions: versions}, nil
+}
+
+// newMirrorConfigVersion decodes a row of flow_config_versions
+func newMirrorConfigVersion(isCDC bool, version int32, configBytes []byte, createdAt time.Time) (*protos.MirrorConfigVersion, error) {
+	configVersion := &protos.MirrorConfigVersion{Version: version, CreatedAt: float64(createdAt.UnixMilli())}
+	if isCDC {
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configBytes, &config); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal config version %d: %w", version, err)
+		}
+		configVersion.Config = &protos.MirrorConfigVersion_CdcConfig{CdcConfig: &config}
+	} else {
+		var config protos.QRepConfig
+		if err := proto.Unmarshal(configBytes, &config); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal config version %d: %w", version, err)
+		}
+		configVersion.Config = &protos.MirrorConfigVersion_QrepConfig{QrepConfig: &config}
+	}
+	return configVersion, nil
+}
+
+// RollbackMirrorConfig applies a previous config version to a mirror.
+// CDC mirrors are updated in place when only dynamic settings and tables differ, otherwise they need to be resynced.
+// QRep mirrors are restarted with the previous config, continuing from their last partition
+func (h *FlowRequestHandler) RollbackMirrorConfig(
+	ctx context.Context,
+	req *protos.RollbackMirrorConfigRequest,
+) (*protos.RollbackMirrorConfigResponse, error) {
+	var configBytes []byte
+	if err := h.pool.QueryRow(ctx,
+		"SELECT config_proto FROM flow_config_versions WHERE flow_name=$1 AND version=$2", req.FlowJobName, req.Version,
+	).Scan(&configBytes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("config version %d of mirror %s not found", req.Version, req.FlowJobName)
+		}
+		return nil, fmt.Errorf("unable to query config version: %w", err)
+	}
+
+	workflowID, err := h.getWorkflowID(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	currState, err := h.getWorkflowStatus(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	isCDC, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	var appliedBy string
+	if isCDC {
+		var target protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configBytes, &target); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal config version %d: %w", req.Version, err)
+		}
+		appliedBy, err = h.applyCDCConfig(ctx, req.FlowJobName, req.AllowResync, currState, &target)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var target protos.QRepConfig
+		if err := proto.Unmarshal(configBytes, &target); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal config version %d: %w", req.Version, err)
+		}
+		appliedBy, err = h.applyQRepConfig(ctx, workflowID, currState, &target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Info("rolled back mirror config", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.Int("version", int(req.Version)), slog.String("appliedBy", appliedBy))
+	return &protos.RollbackMirrorConfigResponse{AppliedBy: appliedBy}, nil
+}
+
+// applyCDCConfig changes the config of a CDC mirror to target, returning how it was applied
+func (h *FlowRequestHandler) applyCDCConfig(
+	ctx context.Context,
+	flowJobName string,
+	allowResync bool,
+	currState protos.FlowStatus,
+	target *protos.FlowConnectionConfigs,
+) (string, error) {
+	current, err := h.getFlowConfigFromCatalog(ctx, flowJobName)
+	if err != nil {
+		return "", err
+	}
+
+	switch currState {
+	case protos.FlowStatus_STATUS_COMPLETED:
+		// nothing runs anymore, so only the config in catalog changes
+		if err := h.updateFlowConfigInCatalog(ctx, target); err != nil {
+			return "", fmt.Errorf("unable to update flow config in catalog: %w", err)
+		}
+		h.recordMirrorAuditEvent(ctx, flowJobName, mirrorAuditEdit, current, target)
+		return configRollbackByCatalog, nil
+	case protos.FlowStatus_STATUS_RUNNING, protos.FlowStatus_STATUS_PAUSED:
+	default:
+		return "", fmt.Errorf("mirror config can only be rolled back while running or paused, current state is: %v", currState)
+	}
+
+	update, staticFields := cdcConfigRollbackUpdate(current, target)
+	if len(staticFields) == 0 {
+		if _, err := h.FlowStateChange(ctx, &protos.FlowStateChangeRequest{
+			FlowJobName:        flowJobName,
+			RequestedFlowState: currState,
+			FlowConfigUpdate: &protos.FlowConfigUpdate{
+				Update: &protos.FlowConfigUpdate_CdcFlowConfigUpdate{CdcFlowConfigUpdate: update},
+			},
+		}); err != nil {
+			return "", err
+		}
+		return configRollbackByUpdate, nil
+	} else if !allowResync {
+		return "", fmt.Errorf("rolling back %s requires resyncing the mirror, set allow_resync to proceed",
+			strings.Join(staticFields, ", "))
+	}
+
+	target.Resync = true
+	target.DoInitialSnapshot = true
+	if _, err := h.ValidateCDCMirror(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: target}); err != nil {
+		return "", err
+	}
+	if err := h.shutdownFlow(ctx, flowJobName, false, false); err != nil {
+		return "", err
+	}
+	if _, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: target}); err != nil {
+		return "", err
+	}
+	h.recordMirrorAuditEvent(ctx, flowJobName, mirrorAuditResync, current, target)
+	return configRollbackByResync, nil
+}
+
+// cdcConfigRollbackUpdate returns the update turning the current config into the target config,
+// along with the fields that differ but cannot be updated in place
+func cdcConfigRollbackUpdate(
+	current *protos.FlowConnectionConfigs,
+	target *protos.FlowConnectionConfigs,
+) (*protos.CDCFlowConfigUpdate, []string) {
+	update := &protos.CDCFlowConfigUpdate{}
+	var staticFields []string
+
+	// zero values mean unchanged in updates, so they can only be rolled back to non-zero values
+	if target.MaxBatchSize != current.MaxBatchSize {
+		if target.MaxBatchSize == 0 {
+			staticFields = append(staticFields, "max_batch_size")
+		}
+		update.BatchSize = target.MaxBatchSize
+	}
+	if target.IdleTimeoutSeconds != current.IdleTimeoutSeconds {
+		if target.IdleTimeoutSeconds == 0 {
+			staticFields = append(staticFields, "idle_timeout_seconds")
+		}
+		update.IdleTimeout = target.IdleTimeoutSeconds
+	}
+	if target.MaxBatchBytes != current.MaxBatchBytes {
+		update.MaxBatchBytes = &target.MaxBatchBytes
+	}
+	if !proto.Equal(target.FreshnessSlo, current.FreshnessSlo) {
+		update.FreshnessSlo = target.FreshnessSlo
+		if update.FreshnessSlo == nil {
+			update.FreshnessSlo = &protos.FreshnessSlo{}
+		}
+	}
+
+	// env keys can be set but not removed
+	for key, value := range target.Env {
+		if currentValue, ok := current.Env[key]; !ok || currentValue != value {
+			if update.UpdatedEnv == nil {
+				update.UpdatedEnv = make(map[string]string)
+			}
+			update.UpdatedEnv[key] = value
+		}
+	}
+	for key := range current.Env {
+		if _, ok := target.Env[key]; !ok {
+			staticFields = append(staticFields, "env."+key)
+		}
+	}
+
+	// changed table mappings are removed and added again
+	targetTables := make(map[string]*protos.TableMapping, len(target.TableMappings))
+	for _, mapping := range target.TableMappings {
+		targetTables[mapping.SourceTableIdentifier] = mapping
+	}
+	currentTables := make(map[string]*protos.TableMapping, len(current.TableMappings))
+	for _, mapping := range current.TableMappings {
+		currentTables[mapping.SourceTableIdentifier] = mapping
+		if targetMapping, ok := targetTables[mapping.SourceTableIdentifier]; !ok || !proto.Equal(mapping, targetMapping) {
+			update.RemovedTables = append(update.RemovedTables, mapping)
+		}
+	}
+	for _, mapping := range target.TableMappings {
+		if currentMapping, ok := currentTables[mapping.SourceTableIdentifier]; !ok || !proto.Equal(mapping, currentMapping) {
+			update.AdditionalTables = append(update.AdditionalTables, mapping)
+		}
+	}
+
+	// everything else, paused table groups included, can only change by resyncing
+	currentRest := proto.CloneOf(current)
+	targetRest := proto.CloneOf(target)
+	for _, cfg := range []*protos.FlowConnectionConfigs{currentRest, targetRest} {
+		cfg.MaxBatchSize = 0
+		cfg.IdleTimeoutSeconds = 0
+		cfg.MaxBatchBytes = 0
+		cfg.FreshnessSlo = nil
+		cfg.Env = nil
+		cfg.TableMappings = nil
+		cfg.Resync = false
+		cfg.DoInitialSnapshot = false
+		cfg.Version = 0
+	}
+	currentReflect := currentRest.ProtoReflect()
+	targetReflect := targetRest.ProtoReflect()
+	fields := currentReflect.Descriptor().Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if !currentReflect.Get(field).Equal(targetReflect.Get(field)) {
+			staticFields = append(staticFields, string(field.Name()))
+		}
+	}
+
+	return update, staticFields
+}
+
+// applyQRepConfig changes the config of a QRep mirror to target, returning how it was applied
+func (h *FlowRequestHandler) applyQRepConfig(
+	ctx context.Context,
+	workflowID string,
+	currState protos.FlowStatus,
+	target *protos.QRepConfig,
+) (string, error) {
+	current := h.mirrorAuditConfig(ctx, target.FlowJobName)
+	if currState == protos.FlowStatus_STATUS_COMPLETED {
+		if err := h.updateQRepConfigInCatalog(ctx, target); err != nil {
+			return "", err
+		}
+		h.recordMirrorAuditEvent(ctx, target.FlowJobName, mirrorAuditEdit, current, target)
+		return configRollbackByCatalog, nil
+	}
+
+	// QRep workflows take their config as input, so they continue from their current state in a new workflow
+	stateRes, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", shared.QRepFlowStateQuery)
+	if err != nil {
+		return "", fmt.Errorf("unable to query state of qrep workflow: %w", err)
+	}
+	var state *protos.QRepFlowState
+	if err := stateRes.Get(&state); err != nil {
+		return "", fmt.Errorf("unable to query state of qrep workflow: %w", err)
+	}
+
+	if err := h.handleCancelWorkflow(ctx, workflowID, ""); err != nil {
+		return "", fmt.Errorf("unable to cancel qrep workflow: %w", err)
+	}
+
+	dbtype, err := connectors.LoadPeerType(ctx, h.pool, target.SourceName)
+	if err != nil {
+		return "", err
+	}
+	var workflowFn any
+	if dbtype == protos.DBType_POSTGRES && target.WatermarkColumn == "xmin" {
+		workflowFn = peerflow.XminFlowWorkflow
+	} else {
+		workflowFn = peerflow.QRepFlowWorkflow
+	}
+	newWorkflowID := fmt.Sprintf("%s-qrepflow-%s", target.FlowJobName, uuid.New())
+	if _, err := h.temporalClient.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:                    newWorkflowID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(target.WorkerPool),
+		TypedSearchAttributes: shared.NewSearchAttributes(target.FlowJobName),
+	}, workflowFn, target, state); err != nil {
+		return "", fmt.Errorf("unable to start QRepFlow workflow: %w", err)
+	}
+
+	if _, err := h.pool.Exec(ctx,
+		"UPDATE flows SET workflow_id=$1,updated_at=now() WHERE name=$2", newWorkflowID, target.FlowJobName,
+	); err != nil {
+		return "", fmt.Errorf("unable to update workflow id in catalog: %w", err)
+	}
+	if err := h.updateQRepConfigInCatalog(ctx, target); err != nil {
+		return "", err
+	}
+	h.recordMirrorAuditEvent(ctx, target.FlowJobName, mirrorAuditEdit, current, target)
+	return configRollbackByRestart, nil
+}
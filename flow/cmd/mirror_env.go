@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// UpdateMirrorEnv changes the dynamic setting overrides of a CDC mirror, a running mirror applies them
+// by restarting its sync without being paused
+func (h *FlowRequestHandler) UpdateMirrorEnv(
+	ctx context.Context, req *protos.UpdateMirrorEnvRequest,
+) (*protos.UpdateMirrorEnvResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+	if len(req.Env) == 0 && len(req.RemovedKeys) == 0 {
+		return nil, errors.New("no env changes requested")
+	}
+	if err := internal.ValidateMirrorEnv(req.Env); err != nil {
+		return nil, err
+	}
+	for _, key := range req.RemovedKeys {
+		if _, ok := req.Env[key]; ok {
+			return nil, fmt.Errorf("setting %s cannot be both set and removed", key)
+		}
+	}
+
+	workflowID, err := h.getWorkflowID(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	previousConfig := h.mirrorAuditConfig(ctx, req.FlowJobName)
+	update := &protos.MirrorEnvUpdate{Env: req.Env, RemovedKeys: req.RemovedKeys}
+	if err := model.MirrorEnvUpdateSignal.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", update); err != nil {
+		slog.Error("unable to signal env update", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to signal env update to mirror %s: %w", req.FlowJobName, err)
+	}
+	h.recordMirrorAuditEvent(ctx, req.FlowJobName, mirrorAuditEdit, previousConfig, update)
+	return &protos.UpdateMirrorEnvResponse{}, nil
+}
@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// ExportMirrorState dumps everything needed to rebuild the catalog entries of mirrors,
+// read in a single snapshot so that configs and checkpoints are consistent with each other.
+// Peers are not part of the export, they are expected to be recreated before import.
+func (h *FlowRequestHandler) ExportMirrorState(
+	ctx context.Context,
+	req *protos.ExportMirrorStateRequest,
+) (*protos.ExportMirrorStateResponse, error) {
+	tx, err := h.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		slog.Error("unable to begin transaction for mirror state export", slog.Any("error", err))
+		return nil, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer shared.RollbackTx(tx, slog.Default())
+
+	var flowNames []string
+	if len(req.FlowNames) > 0 {
+		flowNames = req.FlowNames
+	}
+	rows, err := tx.Query(ctx, `SELECT f.name, coalesce(f.workflow_id, ''), sp.name, dp.name, coalesce(f.description, ''),
+		coalesce(f.query_string, ''), f.config_proto, f.status, f.tags, f.backfill_paused,
+		coalesce(f.source_table_identifier, ''), coalesce(f.destination_table_identifier, '')
+		FROM flows f JOIN peers sp ON f.source_peer = sp.id JOIN peers dp ON f.destination_peer = dp.id
+		WHERE $1::text[] IS NULL OR f.name = ANY($1)
+		ORDER BY f.name, f.id`, flowNames)
+	if err != nil {
+		slog.Error("unable to query flows for mirror state export", slog.Any("error", err))
+		return nil, fmt.Errorf("unable to query flows: %w", err)
+	}
+
+	var mirrors []*protos.MirrorState
+	var mirror *protos.MirrorState
+	for rows.Next() {
+		var name, workflowID, srcName, dstName, description, queryString, srcTable, dstTable string
+		var configBytes []byte
+		var status int32
+		var tags map[string]string
+		var backfillPaused bool
+		if err := rows.Scan(&name, &workflowID, &srcName, &dstName, &description,
+			&queryString, &configBytes, &status, &tags, &backfillPaused, &srcTable, &dstTable,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("unable to scan flow: %w", err)
+		}
+
+		// CDC mirrors have a row in flows per table mapping
+		if mirror == nil || mirror.FlowName != name {
+			mirror = &protos.MirrorState{
+				FlowName:            name,
+				WorkflowId:          workflowID,
+				SourcePeerName:      srcName,
+				DestinationPeerName: dstName,
+				Description:         description,
+				QueryString:         queryString,
+				Status:              protos.FlowStatus(status),
+				TableSchemas:        make(map[string]*protos.TableSchema),
+				BackfillPaused:      backfillPaused,
+			}
+			for key, value := range tags {
+				mirror.Tags = append(mirror.Tags, &protos.FlowTag{Key: key, Value: value})
+			}
+			if configBytes != nil {
+				if queryString == "" {
+					var cfg protos.FlowConnectionConfigs
+					if err := proto.Unmarshal(configBytes, &cfg); err != nil {
+						rows.Close()
+						return nil, fmt.Errorf("unable to unmarshal config of mirror %s: %w", name, err)
+					}
+					mirror.Config = &protos.MirrorState_CdcConfig{CdcConfig: &cfg}
+				} else {
+					var cfg protos.QRepConfig
+					if err := proto.Unmarshal(configBytes, &cfg); err != nil {
+						rows.Close()
+						return nil, fmt.Errorf("unable to unmarshal config of mirror %s: %w", name, err)
+					}
+					mirror.Config = &protos.MirrorState_QrepConfig{QrepConfig: &cfg}
+				}
+			}
+			mirrors = append(mirrors, mirror)
+		}
+		mirror.Tables = append(mirror.Tables, &protos.MirrorStateTable{
+			SourceTableIdentifier:      srcTable,
+			DestinationTableIdentifier: dstTable,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read flows: %w", err)
+	}
+
+	for _, mirror := range mirrors {
+		if err := exportMirrorSyncState(ctx, tx, mirror); err != nil {
+			slog.Error("unable to export mirror state", slog.String("flowName", mirror.FlowName), slog.Any("error", err))
+			return nil, err
+		}
+	}
+
+	return &protos.ExportMirrorStateResponse{
+		Mirrors:    mirrors,
+		ExportedAt: timestamppb.Now(),
+	}, nil
+}
+
+func exportMirrorSyncState(ctx context.Context, tx pgx.Tx, mirror *protos.MirrorState) error {
+	var syncState protos.MirrorSyncState
+	var normalizeBatchID, latestBatchIDInRawTable pgtype.Int8
+	var tableBatchIDs []byte
+	if err := tx.QueryRow(ctx, `SELECT last_offset, last_text, sync_batch_id, normalize_batch_id,
+		latest_batch_id_in_raw_table, table_batch_id_data
+		FROM metadata_last_sync_state WHERE job_name = $1`, mirror.FlowName,
+	).Scan(&syncState.LastOffset, &syncState.LastText, &syncState.SyncBatchId, &normalizeBatchID,
+		&latestBatchIDInRawTable, &tableBatchIDs,
+	); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("unable to query last sync state: %w", err)
+		}
+	} else {
+		syncState.NormalizeBatchId = normalizeBatchID.Int64
+		if latestBatchIDInRawTable.Valid {
+			syncState.LatestBatchIdInRawTable = &latestBatchIDInRawTable.Int64
+		}
+		if len(tableBatchIDs) > 0 {
+			if err := json.Unmarshal(tableBatchIDs, &syncState.TableBatchIds); err != nil {
+				return fmt.Errorf("unable to unmarshal table batch ids: %w", err)
+			}
+		}
+		mirror.SyncState = &syncState
+	}
+
+	schemaRows, err := tx.Query(ctx,
+		"SELECT table_name, table_schema FROM table_schema_mapping WHERE flow_name = $1", mirror.FlowName)
+	if err != nil {
+		return fmt.Errorf("unable to query table schemas: %w", err)
+	}
+	var tableName string
+	var tableSchemaBytes []byte
+	if _, err := pgx.ForEachRow(schemaRows, []any{&tableName, &tableSchemaBytes}, func() error {
+		tableSchema := &protos.TableSchema{}
+		if err := proto.Unmarshal(tableSchemaBytes, tableSchema); err != nil {
+			return fmt.Errorf("unable to unmarshal schema of table %s: %w", tableName, err)
+		}
+		mirror.TableSchemas[tableName] = tableSchema
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	partitionRows, err := tx.Query(ctx, `SELECT sync_partition::text, sync_start_time, sync_finish_time
+		FROM metadata_qrep_partitions WHERE job_name = $1 ORDER BY sync_finish_time`, mirror.FlowName)
+	if err != nil {
+		return fmt.Errorf("unable to query synced partitions: %w", err)
+	}
+	var partitionJSON string
+	var syncStartTime, syncFinishTime time.Time
+	if _, err := pgx.ForEachRow(partitionRows, []any{&partitionJSON, &syncStartTime, &syncFinishTime}, func() error {
+		partition := &protos.QRepPartition{}
+		if err := protojson.Unmarshal([]byte(partitionJSON), partition); err != nil {
+			return fmt.Errorf("unable to unmarshal synced partition: %w", err)
+		}
+		mirror.SyncedPartitionStates = append(mirror.SyncedPartitionStates, &protos.MirrorSyncedPartition{
+			Partition:      partition,
+			SyncStartTime:  timestamppb.New(syncStartTime),
+			SyncFinishTime: timestamppb.New(syncFinishTime),
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	versionRows, err := tx.Query(ctx,
+		"SELECT version, config_proto, created_at FROM flow_config_versions WHERE flow_name = $1 ORDER BY version",
+		mirror.FlowName)
+	if err != nil {
+		return fmt.Errorf("unable to query config versions: %w", err)
+	}
+	var version int32
+	var configBytes []byte
+	var createdAt time.Time
+	if _, err := pgx.ForEachRow(versionRows, []any{&version, &configBytes, &createdAt}, func() error {
+		configVersion, err := newMirrorConfigVersion(mirror.QueryString == "", version, configBytes, createdAt)
+		if err != nil {
+			return err
+		}
+		mirror.ConfigVersions = append(mirror.ConfigVersions, configVersion)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ImportMirrorState restores mirrors exported by ExportMirrorState into this catalog.
+// Workflows are not started, the mirrors' workflows are expected to still be known to Temporal.
+func (h *FlowRequestHandler) ImportMirrorState(
+	ctx context.Context,
+	req *protos.ImportMirrorStateRequest,
+) (*protos.ImportMirrorStateResponse, error) {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		slog.Error("unable to begin transaction for mirror state import", slog.Any("error", err))
+		return nil, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer shared.RollbackTx(tx, slog.Default())
+
+	flowNames := make([]string, 0, len(req.Mirrors))
+	for _, mirror := range req.Mirrors {
+		if err := importMirrorState(ctx, tx, mirror, req.Overwrite); err != nil {
+			slog.Error("unable to import mirror state", slog.String("flowName", mirror.FlowName), slog.Any("error", err))
+			return nil, fmt.Errorf("unable to import mirror %s: %w", mirror.FlowName, err)
+		}
+		flowNames = append(flowNames, mirror.FlowName)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("unable to commit mirror state import: %w", err)
+	}
+
+	slog.Info("imported mirror state", slog.Any("flowNames", flowNames))
+	return &protos.ImportMirrorStateResponse{FlowNames: flowNames}, nil
+}
+
+func importMirrorState(ctx context.Context, tx pgx.Tx, mirror *protos.MirrorState, overwrite bool) error {
+	if mirror.FlowName == "" {
+		return errors.New("mirror name cannot be empty")
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM flows WHERE name = $1)", mirror.FlowName).Scan(&exists); err != nil {
+		return fmt.Errorf("unable to check if mirror exists: %w", err)
+	}
+	if exists {
+		if !overwrite {
+			return errors.New("mirror already exists")
+		}
+		for _, query := range []string{
+			"DELETE FROM flows WHERE name = $1",
+			"DELETE FROM table_schema_mapping WHERE flow_name = $1",
+			"DELETE FROM metadata_last_sync_state WHERE job_name = $1",
+			"DELETE FROM metadata_qrep_partitions WHERE job_name = $1",
+			"DELETE FROM metadata_write_streams WHERE job_name = $1",
+			"DELETE FROM flow_config_versions WHERE flow_name = $1",
+		} {
+			if _, err := tx.Exec(ctx, query, mirror.FlowName); err != nil {
+				return fmt.Errorf("unable to clear existing state: %w", err)
+			}
+		}
+	}
+
+	var sourcePeerID, destinationPeerID int32
+	if err := tx.QueryRow(ctx, "SELECT id FROM peers WHERE name = $1", mirror.SourcePeerName).Scan(&sourcePeerID); err != nil {
+		return fmt.Errorf("unable to get id of source peer %s: %w", mirror.SourcePeerName, err)
+	}
+	if err := tx.QueryRow(ctx, "SELECT id FROM peers WHERE name = $1", mirror.DestinationPeerName).Scan(&destinationPeerID); err != nil {
+		return fmt.Errorf("unable to get id of destination peer %s: %w", mirror.DestinationPeerName, err)
+	}
+
+	var configBytes []byte
+	var err error
+	switch config := mirror.Config.(type) {
+	case *protos.MirrorState_CdcConfig:
+		configBytes, err = proto.Marshal(config.CdcConfig)
+	case *protos.MirrorState_QrepConfig:
+		configBytes, err = proto.Marshal(config.QrepConfig)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to marshal config: %w", err)
+	}
+
+	tags := make(map[string]string, len(mirror.Tags))
+	for _, tag := range mirror.Tags {
+		tags[tag.Key] = tag.Value
+	}
+
+	var queryString *string
+	if mirror.QueryString != "" {
+		queryString = &mirror.QueryString
+	}
+	tables := mirror.Tables
+	if len(tables) == 0 {
+		tables = []*protos.MirrorStateTable{{}}
+	}
+	for _, table := range tables {
+		if _, err := tx.Exec(ctx, `INSERT INTO flows (workflow_id, name, source_peer, destination_peer, description,
+			source_table_identifier, destination_table_identifier, query_string, config_proto, status, tags, backfill_paused)
+			VALUES ($1, $2, $3, $4, $5, nullif($6, ''), nullif($7, ''), $8, $9, $10, $11, $12)`,
+			mirror.WorkflowId, mirror.FlowName, sourcePeerID, destinationPeerID, mirror.Description,
+			table.SourceTableIdentifier, table.DestinationTableIdentifier, queryString, configBytes,
+			int32(mirror.Status), tags, mirror.BackfillPaused,
+		); err != nil {
+			return fmt.Errorf("unable to insert into flows: %w", err)
+		}
+	}
+
+	if syncState := mirror.SyncState; syncState != nil {
+		tableBatchIDs, err := json.Marshal(syncState.TableBatchIds)
+		if err != nil {
+			return fmt.Errorf("unable to marshal table batch ids: %w", err)
+		}
+		if syncState.TableBatchIds == nil {
+			tableBatchIDs = []byte("{}")
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO metadata_last_sync_state (job_name, last_offset, last_text, sync_batch_id,
+			normalize_batch_id, latest_batch_id_in_raw_table, table_batch_id_data)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			mirror.FlowName, syncState.LastOffset, syncState.LastText, syncState.SyncBatchId,
+			syncState.NormalizeBatchId, syncState.LatestBatchIdInRawTable, tableBatchIDs,
+		); err != nil {
+			return fmt.Errorf("unable to insert last sync state: %w", err)
+		}
+	}
+
+	for tableName, tableSchema := range mirror.TableSchemas {
+		tableSchemaBytes, err := proto.Marshal(tableSchema)
+		if err != nil {
+			return fmt.Errorf("unable to marshal schema of table %s: %w", tableName, err)
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO table_schema_mapping (flow_name, table_name, table_schema) VALUES ($1, $2, $3)",
+			mirror.FlowName, tableName, tableSchemaBytes,
+		); err != nil {
+			return fmt.Errorf("unable to insert schema of table %s: %w", tableName, err)
+		}
+	}
+
+	for _, partition := range mirror.SyncedPartitionStates {
+		partitionJSON, err := protojson.Marshal(partition.Partition)
+		if err != nil {
+			return fmt.Errorf("unable to marshal partition %s: %w", partition.Partition.GetPartitionId(), err)
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO metadata_qrep_partitions (job_name, partition_id, sync_partition,
+			sync_start_time, sync_finish_time) VALUES ($1, $2, $3, $4, $5)`,
+			mirror.FlowName, partition.Partition.GetPartitionId(), string(partitionJSON),
+			partition.SyncStartTime.AsTime(), partition.SyncFinishTime.AsTime(),
+		); err != nil {
+			return fmt.Errorf("unable to insert synced partition %s: %w", partition.Partition.GetPartitionId(), err)
+		}
+	}
+
+	for _, configVersion := range mirror.ConfigVersions {
+		configBytes, err := mirrorConfigVersionBytes(configVersion)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO flow_config_versions (flow_name, version, config_proto, created_at)
+			VALUES ($1, $2, $3, $4)`,
+			mirror.FlowName, configVersion.Version, configBytes, time.UnixMilli(int64(configVersion.CreatedAt)),
+		); err != nil {
+			return fmt.Errorf("unable to insert config version %d: %w", configVersion.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// mirrorConfigVersionBytes encodes a config version as stored in flow_config_versions
+func mirrorConfigVersionBytes(configVersion *protos.MirrorConfigVersion) ([]byte, error) {
+	var configBytes []byte
+	var err error
+	switch config := configVersion.Config.(type) {
+	case *protos.MirrorConfigVersion_CdcConfig:
+		configBytes, err = proto.Marshal(config.CdcConfig)
+	case *protos.MirrorConfigVersion_QrepConfig:
+		configBytes, err = proto.Marshal(config.QrepConfig)
+	default:
+		return nil, fmt.Errorf("config version %d has no config", configVersion.Version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal config version %d: %w", configVersion.Version, err)
+	}
+	return configBytes, nil
+}
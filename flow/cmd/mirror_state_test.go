@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestMirrorConfigVersionRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name   string
+		config proto.Message
+		isCDC  bool
+	}{
+		{"cdc", &protos.FlowConnectionConfigs{FlowJobName: "cdc", MaxBatchSize: 1000}, true},
+		{"qrep", &protos.QRepConfig{FlowJobName: "qrep", MaxParallelWorkers: 4}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			configBytes, err := proto.Marshal(tc.config)
+			require.NoError(t, err)
+
+			configVersion, err := newMirrorConfigVersion(tc.isCDC, 3, configBytes, createdAt)
+			require.NoError(t, err)
+			require.Equal(t, int32(3), configVersion.Version)
+			require.Equal(t, createdAt, time.UnixMilli(int64(configVersion.CreatedAt)).UTC())
+
+			imported, err := mirrorConfigVersionBytes(configVersion)
+			require.NoError(t, err)
+			require.Equal(t, configBytes, imported)
+		})
+	}
+
+	_, err := mirrorConfigVersionBytes(&protos.MirrorConfigVersion{Version: 1})
+	require.Error(t, err)
+}
@@ -3,8 +3,10 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"slices"
 	"strings"
 	"time"
@@ -83,6 +85,19 @@ func (h *FlowRequestHandler) MirrorStatus(
 		return nil, fmt.Errorf("unable to get the creation time of mirror %s: %w", req.FlowJobName, err)
 	}
 
+	backfillPaused, err := h.getMirrorBackfillPaused(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the backfill state of mirror %s: %w", req.FlowJobName, err)
+	}
+	var cdcPausePending bool
+	if currState == protos.FlowStatus_STATUS_SETUP || currState == protos.FlowStatus_STATUS_SNAPSHOT {
+		if state, err := h.getCDCWorkflowState(ctx, workflowID); err != nil {
+			slog.Warn("unable to get the workflow state of mirror", slog.Any("error", err))
+		} else {
+			cdcPausePending = state.PauseAfterSnapshot
+		}
+	}
+
 	if req.IncludeFlowInfo {
 		if cdcFlow, err := h.isCDCFlow(ctx, req.FlowJobName); err != nil {
 			slog.Error("unable to determine if mirror is cdc", slog.Any("error", err))
@@ -101,6 +116,8 @@ func (h *FlowRequestHandler) MirrorStatus(
 				},
 				CurrentFlowState: currState,
 				CreatedAt:        timestamppb.New(*createdAt),
+				BackfillPaused:   backfillPaused,
+				CdcPausePending:  cdcPausePending,
 			}, nil
 		} else {
 			qrepStatus, err := h.qrepFlowStatus(ctx, req)
@@ -116,6 +133,8 @@ func (h *FlowRequestHandler) MirrorStatus(
 				},
 				CurrentFlowState: currState,
 				CreatedAt:        timestamppb.New(*createdAt),
+				BackfillPaused:   backfillPaused,
+				CdcPausePending:  cdcPausePending,
 			}, nil
 		}
 	}
@@ -124,6 +143,8 @@ func (h *FlowRequestHandler) MirrorStatus(
 		FlowJobName:      req.FlowJobName,
 		CurrentFlowState: currState,
 		CreatedAt:        timestamppb.New(*createdAt),
+		BackfillPaused:   backfillPaused,
+		CdcPausePending:  cdcPausePending,
 	}, nil
 }
 
@@ -152,7 +173,9 @@ func (h *FlowRequestHandler) cdcFlowStatus(
 	if state.SyncFlowOptions != nil {
 		config.IdleTimeoutSeconds = state.SyncFlowOptions.IdleTimeoutSeconds
 		config.MaxBatchSize = state.SyncFlowOptions.BatchSize
+		config.MaxBatchBytes = state.SyncFlowOptions.MaxBatchBytes
 		config.TableMappings = state.SyncFlowOptions.TableMappings
+		config.PausedTableMappings = state.PausedTableMappings
 	}
 
 	srcType, err := connectors.LoadPeerType(ctx, h.pool, config.SourceName)
@@ -433,6 +456,32 @@ func (h *FlowRequestHandler) getFlowConfigFromCatalog(
 	return &config, nil
 }
 
+// getMirrorConfig loads the config of a mirror from the catalog,
+// a *protos.FlowConnectionConfigs for CDC mirrors or a *protos.QRepConfig for QRep mirrors
+func (h *FlowRequestHandler) getMirrorConfig(ctx context.Context, flowJobName string) (proto.Message, error) {
+	var configBytes sql.RawBytes
+	var queryString sql.NullString
+	if err := h.pool.QueryRow(ctx,
+		"SELECT config_proto, query_string FROM flows WHERE name = $1", flowJobName,
+	).Scan(&configBytes, &queryString); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("mirror %s not found", flowJobName)
+		}
+		return nil, fmt.Errorf("unable to query config of mirror %s: %w", flowJobName, err)
+	}
+
+	var config proto.Message
+	if queryString.String == "" {
+		config = &protos.FlowConnectionConfigs{}
+	} else {
+		config = &protos.QRepConfig{}
+	}
+	if err := proto.Unmarshal(configBytes, config); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal config of mirror %s: %w", flowJobName, err)
+	}
+	return config, nil
+}
+
 func (h *FlowRequestHandler) isCDCFlow(ctx context.Context, flowJobName string) (bool, error) {
 	var isCdc bool
 	if err := h.pool.QueryRow(
@@ -480,6 +529,17 @@ func (h *FlowRequestHandler) getMirrorCreatedAt(ctx context.Context, flowJobName
 	return &createdAt.Time, nil
 }
 
+func (h *FlowRequestHandler) getMirrorBackfillPaused(ctx context.Context, flowJobName string) (bool, error) {
+	var backfillPaused bool
+	if err := h.pool.QueryRow(ctx,
+		"SELECT coalesce(bool_or(backfill_paused), false) FROM flows WHERE name=$1", flowJobName,
+	).Scan(&backfillPaused); err != nil {
+		slog.Error("unable to query flow", slog.Any("error", err))
+		return false, fmt.Errorf("unable to query flow: %w", err)
+	}
+	return backfillPaused, nil
+}
+
 func (h *FlowRequestHandler) GetCDCBatches(ctx context.Context, req *protos.GetCDCBatchesRequest) (*protos.GetCDCBatchesResponse, error) {
 	return h.CDCBatches(ctx, req)
 }
@@ -506,7 +566,7 @@ func (h *FlowRequestHandler) CDCBatches(ctx context.Context, req *protos.GetCDCB
 	}
 
 	q := fmt.Sprintf(`SELECT DISTINCT ON(batch_id)
-			batch_id,start_time,end_time,rows_in_batch,batch_start_lsn,batch_end_lsn
+			batch_id,start_time,end_time,rows_in_batch,batch_start_lsn,batch_end_lsn,oversized_rows
 		FROM peerdb_stats.cdc_batches
 		WHERE flow_name=$1 AND start_time IS NOT NULL%s
 		ORDER BY batch_id %s%s`, whereExpr, sortOrderBy, limitClause)
@@ -523,7 +583,8 @@ func (h *FlowRequestHandler) CDCBatches(ctx context.Context, req *protos.GetCDCB
 		var numRows pgtype.Int8
 		var startLSN pgtype.Numeric
 		var endLSN pgtype.Numeric
-		if err := rows.Scan(&batchID, &startTime, &endTime, &numRows, &startLSN, &endLSN); err != nil {
+		var oversizedRows pgtype.Int8
+		if err := rows.Scan(&batchID, &startTime, &endTime, &numRows, &startLSN, &endLSN, &oversizedRows); err != nil {
 			slog.Error(fmt.Sprintf("unable to scan cdc batches - %s: %s", req.FlowJobName, err.Error()))
 			return nil, fmt.Errorf("unable to scan cdc batches - %s: %w", req.FlowJobName, err)
 		}
@@ -548,6 +609,9 @@ func (h *FlowRequestHandler) CDCBatches(ctx context.Context, req *protos.GetCDCB
 		if endLSN.Valid {
 			batch.EndLsn = endLSN.Int.Int64()
 		}
+		if oversizedRows.Valid {
+			batch.OversizedRows = oversizedRows.Int64
+		}
 
 		return &batch, nil
 	})
@@ -656,6 +720,54 @@ func (h *FlowRequestHandler) CDCTableTotalCounts(
 	return response, nil
 }
 
+func (h *FlowRequestHandler) CDCTableGroupStats(
+	ctx context.Context,
+	req *protos.CDCTableGroupStatsRequest,
+) (*protos.CDCTableGroupStatsResponse, error) {
+	config, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	groupStats := make(map[string]*protos.CDCTableGroupStats)
+	tableGroups := make(map[string]*protos.CDCTableGroupStats)
+	addTables := func(tableMappings []*protos.TableMapping, paused bool) {
+		for _, tm := range tableMappings {
+			if tm.TableGroup == "" {
+				continue
+			}
+			stats, ok := groupStats[tm.TableGroup]
+			if !ok {
+				stats = &protos.CDCTableGroupStats{TableGroup: tm.TableGroup, Counts: &protos.CDCRowCounts{}}
+				groupStats[tm.TableGroup] = stats
+			}
+			stats.DestinationTableNames = append(stats.DestinationTableNames, tm.DestinationTableIdentifier)
+			stats.Paused = stats.Paused || paused
+			tableGroups[tm.DestinationTableIdentifier] = stats
+		}
+	}
+	addTables(config.TableMappings, false)
+	addTables(config.PausedTableMappings, true)
+
+	tableCounts, err := h.CDCTableTotalCounts(ctx, &protos.CDCTableTotalCountsRequest{FlowJobName: req.FlowJobName})
+	if err != nil {
+		return nil, err
+	}
+	for _, tableCount := range tableCounts.TablesData {
+		if stats, ok := tableGroups[tableCount.TableName]; ok {
+			stats.Counts.TotalCount += tableCount.Counts.TotalCount
+			stats.Counts.InsertsCount += tableCount.Counts.InsertsCount
+			stats.Counts.UpdatesCount += tableCount.Counts.UpdatesCount
+			stats.Counts.DeletesCount += tableCount.Counts.DeletesCount
+		}
+	}
+
+	groups := slices.SortedFunc(maps.Values(groupStats), func(a, b *protos.CDCTableGroupStats) int {
+		return strings.Compare(a.TableGroup, b.TableGroup)
+	})
+	return &protos.CDCTableGroupStatsResponse{Groups: groups}, nil
+}
+
 func (h *FlowRequestHandler) ListMirrorNames(
 	ctx context.Context,
 	req *protos.ListMirrorNamesRequest,
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func (h *FlowRequestHandler) CreateMirrorTemplate(
+	ctx context.Context,
+	req *protos.CreateMirrorTemplateRequest,
+) (*protos.CreateMirrorTemplateResponse, error) {
+	template := req.Template
+	if template == nil || template.Name == "" {
+		return nil, errors.New("template name is required")
+	}
+	config := template.Config
+	if config == nil {
+		config = &protos.FlowConnectionConfigs{}
+	}
+	if config.FlowJobName != "" || len(config.TableMappings) > 0 || len(config.PausedTableMappings) > 0 ||
+		config.Resync || config.Version != 0 {
+		return nil, errors.New("templates cannot set flow_job_name, table_mappings, paused_table_mappings, resync or version")
+	}
+
+	configBytes, err := proto.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal template config: %w", err)
+	}
+	query := "INSERT INTO mirror_templates (name, config_proto) VALUES ($1, $2)"
+	if req.AllowUpdate {
+		query += " ON CONFLICT (name) DO UPDATE SET config_proto = $2, updated_at = now()"
+	}
+	if _, err := h.pool.Exec(ctx, query, template.Name, configBytes); err != nil {
+		return nil, fmt.Errorf("unable to create mirror template %s: %w", template.Name, err)
+	}
+
+	return &protos.CreateMirrorTemplateResponse{}, nil
+}
+
+func (h *FlowRequestHandler) ListMirrorTemplates(
+	ctx context.Context,
+	req *protos.ListMirrorTemplatesRequest,
+) (*protos.ListMirrorTemplatesResponse, error) {
+	rows, err := h.pool.Query(ctx, "SELECT name, config_proto, created_at, updated_at FROM mirror_templates ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirror templates: %w", err)
+	}
+	templates, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.MirrorTemplate, error) {
+		var template protos.MirrorTemplate
+		var configBytes []byte
+		var createdAt, updatedAt time.Time
+		if err := row.Scan(&template.Name, &configBytes, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		template.Config = &protos.FlowConnectionConfigs{}
+		if err := proto.Unmarshal(configBytes, template.Config); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal config of mirror template %s: %w", template.Name, err)
+		}
+		template.CreatedAt = float64(createdAt.UnixMilli())
+		template.UpdatedAt = float64(updatedAt.UnixMilli())
+		return &template, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirror templates: %w", err)
+	}
+
+	return &protos.ListMirrorTemplatesResponse{Templates: templates}, nil
+}
+
+// DropMirrorTemplate only removes the template, mirrors created from it keep their config
+func (h *FlowRequestHandler) DropMirrorTemplate(
+	ctx context.Context,
+	req *protos.DropMirrorTemplateRequest,
+) (*protos.DropMirrorTemplateResponse, error) {
+	tag, err := h.pool.Exec(ctx, "DELETE FROM mirror_templates WHERE name = $1", req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to drop mirror template %s: %w", req.Name, err)
+	} else if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("mirror template %s not found", req.Name)
+	}
+
+	return &protos.DropMirrorTemplateResponse{}, nil
+}
+
+// resolveMirrorTemplate returns the request with the referenced template filled in under the mirror config,
+// fields set in the mirror config override the template while map entries of both are kept
+func (h *FlowRequestHandler) resolveMirrorTemplate(
+	ctx context.Context,
+	req *protos.CreateCDCFlowRequest,
+) (*protos.CreateCDCFlowRequest, error) {
+	if req.TemplateName == "" || req.ConnectionConfigs == nil {
+		return req, nil
+	}
+
+	var configBytes []byte
+	if err := h.pool.QueryRow(ctx,
+		"SELECT config_proto FROM mirror_templates WHERE name = $1", req.TemplateName,
+	).Scan(&configBytes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("mirror template %s not found", req.TemplateName)
+		}
+		return nil, fmt.Errorf("unable to query mirror template: %w", err)
+	}
+	var config protos.FlowConnectionConfigs
+	if err := proto.Unmarshal(configBytes, &config); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal config of mirror template %s: %w", req.TemplateName, err)
+	}
+
+	proto.Merge(&config, req.ConnectionConfigs)
+	resolved := proto.CloneOf(req)
+	resolved.ConnectionConfigs = &config
+	resolved.TemplateName = ""
+	return resolved, nil
+}
@@ -66,9 +66,15 @@ func (h *FlowRequestHandler) GetPeerInfo(
 		return true
 	})
 
+	peerID, _, err := h.getPeerID(ctx, req.PeerName)
+	if err != nil {
+		return nil, err
+	}
+
 	return &protos.PeerInfoResponse{
 		Peer:    peer,
 		Version: version,
+		PeerId:  peerID,
 	}, nil
 }
 
@@ -114,10 +120,11 @@ func (h *FlowRequestHandler) ListPeers(
 	sourceItems := make([]*protos.PeerListItem, 0, len(peers))
 	destinationItems := make([]*protos.PeerListItem, 0, len(peers))
 	for _, peer := range peers {
-		if peer.Type == protos.DBType_POSTGRES || peer.Type == protos.DBType_MYSQL {
+		if peer.Type == protos.DBType_POSTGRES || peer.Type == protos.DBType_MYSQL || peer.Type == protos.DBType_SQLSERVER {
 			sourceItems = append(sourceItems, peer)
 		}
-		if peer.Type != protos.DBType_MYSQL && (!internal.PeerDBOnlyClickHouseAllowed() || peer.Type == protos.DBType_CLICKHOUSE) {
+		if peer.Type != protos.DBType_MYSQL && peer.Type != protos.DBType_SQLSERVER &&
+			(!internal.PeerDBOnlyClickHouseAllowed() || peer.Type == protos.DBType_CLICKHOUSE) {
 			destinationItems = append(destinationItems, peer)
 		}
 	}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+const (
+	peerProbePings      = 5
+	peerProbeSampleSize = 1 << 20
+)
+
+// ProbePeer measures connection setup, round trip latency and when supported read/write throughput of a peer,
+// results are kept in the catalog including failed probes
+func (h *FlowRequestHandler) ProbePeer(
+	ctx context.Context,
+	req *protos.ProbePeerRequest,
+) (*protos.ProbePeerResponse, error) {
+	probe := &protos.PeerProbe{PeerName: req.PeerName, ProbedAt: float64(time.Now().UnixMilli())}
+	if err := h.probePeer(ctx, probe); err != nil {
+		probe.Error = err.Error()
+	}
+
+	if _, err := h.pool.Exec(ctx,
+		`INSERT INTO peerdb_stats.peer_probes (peer_name, probed_at, connect_ms, latency_min_ms, latency_avg_ms, latency_max_ms,
+		read_bytes_per_second, write_bytes_per_second, error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		probe.PeerName, time.UnixMilli(int64(probe.ProbedAt)), probe.ConnectMs, probe.LatencyMinMs, probe.LatencyAvgMs, probe.LatencyMaxMs,
+		probe.ReadBytesPerSecond, probe.WriteBytesPerSecond, pgtype.Text{String: probe.Error, Valid: probe.Error != ""},
+	); err != nil {
+		return nil, fmt.Errorf("unable to store probe of peer %s: %w", req.PeerName, err)
+	}
+
+	return &protos.ProbePeerResponse{Probe: probe}, nil
+}
+
+func (h *FlowRequestHandler) probePeer(ctx context.Context, probe *protos.PeerProbe) error {
+	start := time.Now()
+	conn, err := connectors.GetByNameAs[connectors.Connector](ctx, nil, h.pool, probe.PeerName)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, conn)
+	probe.ConnectMs = durationMs(time.Since(start))
+
+	var total time.Duration
+	for i := range peerProbePings {
+		start := time.Now()
+		if err := conn.ConnectionActive(ctx); err != nil {
+			return fmt.Errorf("failed to ping: %w", err)
+		}
+		latency := time.Since(start)
+		total += latency
+		if i == 0 || durationMs(latency) < probe.LatencyMinMs {
+			probe.LatencyMinMs = durationMs(latency)
+		}
+		probe.LatencyMaxMs = max(probe.LatencyMaxMs, durationMs(latency))
+	}
+	probe.LatencyAvgMs = durationMs(total / peerProbePings)
+
+	if probeConn, ok := conn.(connectors.PeerProbeConnector); ok {
+		readDuration, writeDuration, err := probeConn.ProbeThroughput(ctx, peerProbeSampleSize)
+		if readDuration > 0 {
+			probe.ReadBytesPerSecond = peerProbeSampleSize / readDuration.Seconds()
+		}
+		if writeDuration > 0 {
+			probe.WriteBytesPerSecond = peerProbeSampleSize / writeDuration.Seconds()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to probe throughput: %w", err)
+		}
+	}
+	return nil
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func (h *FlowRequestHandler) ListPeerProbes(
+	ctx context.Context,
+	req *protos.ListPeerProbesRequest,
+) (*protos.ListPeerProbesResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := h.pool.Query(ctx,
+		`SELECT peer_name, probed_at, coalesce(connect_ms, 0), coalesce(latency_min_ms, 0), coalesce(latency_avg_ms, 0),
+		coalesce(latency_max_ms, 0), coalesce(read_bytes_per_second, 0), coalesce(write_bytes_per_second, 0), coalesce(error, '')
+		FROM peerdb_stats.peer_probes WHERE peer_name = $1 ORDER BY probed_at DESC LIMIT $2`,
+		req.PeerName, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query probes of peer %s: %w", req.PeerName, err)
+	}
+	probes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.PeerProbe, error) {
+		var probe protos.PeerProbe
+		var probedAt time.Time
+		if err := row.Scan(&probe.PeerName, &probedAt, &probe.ConnectMs, &probe.LatencyMinMs, &probe.LatencyAvgMs,
+			&probe.LatencyMaxMs, &probe.ReadBytesPerSecond, &probe.WriteBytesPerSecond, &probe.Error,
+		); err != nil {
+			return nil, err
+		}
+		probe.ProbedAt = float64(probedAt.UnixMilli())
+		return &probe, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query probes of peer %s: %w", req.PeerName, err)
+	}
+
+	return &protos.ListPeerProbesResponse{Probes: probes}, nil
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+// latestQRepRunUUID returns the most recent run of a QRep mirror, or of the initial snapshot of a CDC mirror
+func (h *FlowRequestHandler) latestQRepRunUUID(ctx context.Context, flowJobName string) (string, error) {
+	var runUUID string
+	if err := h.pool.QueryRow(ctx,
+		"SELECT run_uuid FROM peerdb_stats.qrep_runs WHERE flow_name = $1 OR parent_mirror_name = $1 ORDER BY id DESC LIMIT 1",
+		flowJobName,
+	).Scan(&runUUID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("no runs found for mirror %s", flowJobName)
+		}
+		return "", fmt.Errorf("unable to query latest run of mirror %s: %w", flowJobName, err)
+	}
+	return runUUID, nil
+}
+
+func (h *FlowRequestHandler) ListQRepPartitions(
+	ctx context.Context,
+	req *protos.ListQRepPartitionsRequest,
+) (*protos.ListQRepPartitionsResponse, error) {
+	runUUID := req.RunUuid
+	if runUUID == "" {
+		var err error
+		if runUUID, err = h.latestQRepRunUUID(ctx, req.FlowJobName); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := h.pool.Query(ctx,
+		`SELECT partition_uuid, flow_name, start_time, end_time, rows_in_partition, rows_synced, restart_count,
+		last_error, last_error_time
+		FROM peerdb_stats.qrep_partitions
+		WHERE run_uuid = $1 AND (flow_name = $2 OR parent_mirror_name = $2)
+		ORDER BY id`,
+		runUUID, req.FlowJobName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query partitions of mirror %s: %w", req.FlowJobName, err)
+	}
+	partitions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.QRepPartitionInfo, error) {
+		var partition protos.QRepPartitionInfo
+		var startTime, endTime, lastErrorTime pgtype.Timestamp
+		var rowsInPartition, rowsSynced pgtype.Int8
+		var lastError pgtype.Text
+		if err := row.Scan(&partition.PartitionId, &partition.FlowName, &startTime, &endTime, &rowsInPartition,
+			&rowsSynced, &partition.RestartCount, &lastError, &lastErrorTime,
+		); err != nil {
+			return nil, err
+		}
+		partition.RowsInPartition = rowsInPartition.Int64
+		partition.RowsSynced = rowsSynced.Int64
+		partition.LastError = lastError.String
+		if startTime.Valid {
+			partition.StartTime = float64(startTime.Time.UnixMilli())
+		}
+		if endTime.Valid {
+			partition.EndTime = float64(endTime.Time.UnixMilli())
+		}
+		if lastErrorTime.Valid {
+			partition.LastErrorTime = float64(lastErrorTime.Time.UnixMilli())
+		}
+
+		switch {
+		case endTime.Valid:
+			partition.State = protos.QRepPartitionState_QREP_PARTITION_STATE_COMPLETED
+		case lastError.Valid:
+			partition.State = protos.QRepPartitionState_QREP_PARTITION_STATE_FAILED
+		case startTime.Valid:
+			partition.State = protos.QRepPartitionState_QREP_PARTITION_STATE_RUNNING
+		default:
+			partition.State = protos.QRepPartitionState_QREP_PARTITION_STATE_PENDING
+		}
+		return &partition, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query partitions of mirror %s: %w", req.FlowJobName, err)
+	}
+
+	if req.State != protos.QRepPartitionState_QREP_PARTITION_STATE_ALL {
+		partitions = slices.DeleteFunc(partitions, func(partition *protos.QRepPartitionInfo) bool {
+			return partition.State != req.State
+		})
+	}
+	return &protos.ListQRepPartitionsResponse{RunUuid: runUUID, Partitions: partitions}, nil
+}
+
+// RetryQRepPartitions replicates failed partitions of a QRep mirror again in a separate workflow,
+// partitions of CDC initial snapshots are not retryable this way since their config is not kept in the catalog
+func (h *FlowRequestHandler) RetryQRepPartitions(
+	ctx context.Context,
+	req *protos.RetryQRepPartitionsRequest,
+) (*protos.RetryQRepPartitionsResponse, error) {
+	if len(req.PartitionIds) == 0 {
+		return nil, errors.New("no partitions to retry")
+	}
+	runUUID := req.RunUuid
+	if runUUID == "" {
+		var err error
+		if runUUID, err = h.latestQRepRunUUID(ctx, req.FlowJobName); err != nil {
+			return nil, err
+		}
+	}
+
+	mirrorConfig, err := h.getMirrorConfig(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := mirrorConfig.(*protos.QRepConfig)
+	if !ok {
+		return nil, fmt.Errorf("mirror %s is not a QRep mirror", req.FlowJobName)
+	}
+	if config.WatermarkColumn == "xmin" {
+		return nil, errors.New("partitions of xmin mirrors cannot be retried")
+	}
+	if config.ParentMirrorName == "" {
+		config.ParentMirrorName = config.FlowJobName
+	}
+
+	rows, err := h.pool.Query(ctx,
+		`SELECT partition_uuid, partition_proto, end_time IS NULL AND last_error IS NOT NULL
+		FROM peerdb_stats.qrep_partitions
+		WHERE run_uuid = $1 AND flow_name = $2 AND partition_uuid = ANY($3)`,
+		runUUID, req.FlowJobName, req.PartitionIds,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query partitions of mirror %s: %w", req.FlowJobName, err)
+	}
+	partitions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.QRepPartition, error) {
+		var partitionID string
+		var partitionBytes []byte
+		var failed bool
+		if err := row.Scan(&partitionID, &partitionBytes, &failed); err != nil {
+			return nil, err
+		}
+		if !failed {
+			return nil, fmt.Errorf("partition %s has not failed", partitionID)
+		} else if partitionBytes == nil {
+			return nil, fmt.Errorf("partition %s was created before partitions were kept for retries", partitionID)
+		}
+		var partition protos.QRepPartition
+		if err := proto.Unmarshal(partitionBytes, &partition); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal partition %s: %w", partitionID, err)
+		}
+		return &partition, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load partitions of mirror %s: %w", req.FlowJobName, err)
+	}
+	if len(partitions) != len(req.PartitionIds) {
+		return nil, fmt.Errorf("only found %d of %d partitions in run %s", len(partitions), len(req.PartitionIds), runUUID)
+	}
+
+	workflowID := fmt.Sprintf("%s-qrep-retry-%s", req.FlowJobName, uuid.New())
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(config.WorkerPool),
+		TypedSearchAttributes: shared.NewSearchAttributes(req.FlowJobName),
+	}
+	if _, err := h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, peerflow.QRepPartitionWorkflow,
+		config, &protos.QRepPartitionBatch{Partitions: partitions}, runUUID,
+	); err != nil {
+		slog.Error("unable to start partition retry workflow",
+			slog.String(string(shared.FlowNameKey), req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to start partition retry workflow: %w", err)
+	}
+
+	return &protos.RetryQRepPartitionsResponse{WorkflowId: workflowID}, nil
+}
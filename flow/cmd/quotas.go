@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+func (h *FlowRequestHandler) GetMirrorQuotas(
+	ctx context.Context,
+	req *protos.GetMirrorQuotasRequest,
+) (*protos.GetMirrorQuotasResponse, error) {
+	rows, err := h.pool.Query(ctx, `SELECT flow_name, max_concurrent_partition_workflows, max_staged_bytes, max_rows_per_day
+		FROM mirror_quotas ORDER BY flow_name`)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirror quotas: %w", err)
+	}
+	quotas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.MirrorQuota, error) {
+		var quota protos.MirrorQuota
+		err := row.Scan(&quota.FlowName, &quota.MaxConcurrentPartitionWorkflows, &quota.MaxStagedBytes, &quota.MaxRowsPerDay)
+		return &quota, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirror quotas: %w", err)
+	}
+	for _, quota := range quotas {
+		if quota.FlowName == "" {
+			continue
+		}
+		if quota.RowsLastDay, err = internal.MirrorRowsLastDay(ctx, h.pool, quota.FlowName); err != nil {
+			return nil, err
+		}
+	}
+
+	return &protos.GetMirrorQuotasResponse{Quotas: quotas}, nil
+}
+
+// PostMirrorQuota sets the quota of a mirror, or the default quota when flow_name is empty,
+// running mirrors pick it up before their next batch or partitions
+func (h *FlowRequestHandler) PostMirrorQuota(
+	ctx context.Context,
+	req *protos.PostMirrorQuotaRequest,
+) (*protos.PostMirrorQuotaResponse, error) {
+	quota := req.Quota
+	if quota == nil {
+		return nil, errors.New("quota is required")
+	}
+	if quota.MaxConcurrentPartitionWorkflows < 0 || quota.MaxStagedBytes < 0 || quota.MaxRowsPerDay < 0 {
+		return nil, errors.New("quota limits cannot be negative")
+	}
+	if _, err := h.pool.Exec(ctx, `INSERT INTO mirror_quotas
+		(flow_name, max_concurrent_partition_workflows, max_staged_bytes, max_rows_per_day) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (flow_name) DO UPDATE SET max_concurrent_partition_workflows = $2, max_staged_bytes = $3,
+		max_rows_per_day = $4, updated_at = now()`,
+		quota.FlowName, quota.MaxConcurrentPartitionWorkflows, quota.MaxStagedBytes, quota.MaxRowsPerDay,
+	); err != nil {
+		return nil, fmt.Errorf("unable to set quota of mirror %s: %w", quota.FlowName, err)
+	}
+
+	return &protos.PostMirrorQuotaResponse{}, nil
+}
+
+func (h *FlowRequestHandler) DeleteMirrorQuota(
+	ctx context.Context,
+	req *protos.DeleteMirrorQuotaRequest,
+) (*protos.DeleteMirrorQuotaResponse, error) {
+	if _, err := h.pool.Exec(ctx, "DELETE FROM mirror_quotas WHERE flow_name = $1", req.FlowName); err != nil {
+		return nil, fmt.Errorf("unable to delete quota of mirror %s: %w", req.FlowName, err)
+	}
+
+	return &protos.DeleteMirrorQuotaResponse{}, nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/client"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+func (h *FlowRequestHandler) ReplayRecords(
+	ctx context.Context, req *protos.ReplayRecordsRequest,
+) (*protos.ReplayRecordsResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+	if err := validateReplayRange(req.StartBatchId, req.EndBatchId); err != nil {
+		return nil, err
+	}
+
+	isCDC, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		slog.Error("unable to check if mirror is cdc", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to determine if mirror %s is cdc: %w", req.FlowJobName, err)
+	}
+	if !isCDC {
+		return nil, fmt.Errorf("replay is only supported for CDC mirrors, %s is not one", req.FlowJobName)
+	}
+
+	cfg, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	dstType, err := connectors.LoadPeerType(ctx, h.pool, cfg.DestinationName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load destination peer type: %w", err)
+	}
+	if err := validateReplayDestination(dstType); err != nil {
+		return nil, err
+	}
+
+	workflowID := fmt.Sprintf("%s-replay-%s", req.FlowJobName, uuid.New())
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             internal.WorkerPoolTaskQueueName(cfg.WorkerPool),
+		TypedSearchAttributes: shared.NewSearchAttributes(req.FlowJobName),
+	}
+	if _, err := h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, peerflow.ReplayRecordsWorkflow, &protos.ReplayRecordsInput{
+		FlowJobName:           req.FlowJobName,
+		FlowConnectionConfigs: cfg,
+		StartBatchId:          req.StartBatchId,
+		EndBatchId:            req.EndBatchId,
+	}); err != nil {
+		slog.Error("unable to start ReplayRecords workflow", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to start ReplayRecords workflow: %w", err)
+	}
+
+	slog.Info("replay started for mirror", slog.String("mirror", req.FlowJobName),
+		slog.Int64("startBatchId", req.StartBatchId), slog.Int64("endBatchId", req.EndBatchId))
+	return &protos.ReplayRecordsResponse{WorkflowId: workflowID}, nil
+}
+
+func validateReplayRange(startBatchID int64, endBatchID int64) error {
+	if startBatchID <= 0 || endBatchID < startBatchID {
+		return fmt.Errorf("invalid batch range [%d, %d]", startBatchID, endBatchID)
+	}
+	return nil
+}
+
+// replay re-applies batches the destination itself retained, from its raw table or the Avro stage kept alongside it,
+// there is no separate store of failed records to replay from, so only destinations retaining batches can replay
+func validateReplayDestination(dstType protos.DBType) error {
+	if dstType != protos.DBType_CLICKHOUSE {
+		return fmt.Errorf("replay is only supported for mirrors into ClickHouse, destination is %s", dstType)
+	}
+	return nil
+}
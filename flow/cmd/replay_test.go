@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestValidateReplayRange(t *testing.T) {
+	require.NoError(t, validateReplayRange(1, 1))
+	require.NoError(t, validateReplayRange(3, 7))
+	require.Error(t, validateReplayRange(0, 5))
+	require.Error(t, validateReplayRange(-1, 5))
+	require.Error(t, validateReplayRange(5, 4))
+}
+
+func TestValidateReplayDestination(t *testing.T) {
+	require.NoError(t, validateReplayDestination(protos.DBType_CLICKHOUSE))
+	for _, dstType := range []protos.DBType{
+		protos.DBType_POSTGRES, protos.DBType_SNOWFLAKE, protos.DBType_BIGQUERY, protos.DBType_S3, protos.DBType_KAFKA,
+	} {
+		require.Error(t, validateReplayDestination(dstType), dstType.String())
+	}
+}
@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/wasm"
 )
 
 func (h *FlowRequestHandler) GetScripts(ctx context.Context, req *protos.GetScriptsRequest) (*protos.GetScriptsResponse, error) {
@@ -24,7 +26,11 @@ func (h *FlowRequestHandler) GetScripts(ctx context.Context, req *protos.GetScri
 		var sourceBytes []byte
 		err := row.Scan(&script.Id, &script.Lang, &script.Name, &sourceBytes)
 		if err == nil {
-			script.Source = string(sourceBytes)
+			if script.Lang == "wasm" {
+				script.Source = base64.StdEncoding.EncodeToString(sourceBytes)
+			} else {
+				script.Source = string(sourceBytes)
+			}
 		}
 		return script, err
 	})
@@ -36,6 +42,16 @@ func (h *FlowRequestHandler) GetScripts(ctx context.Context, req *protos.GetScri
 }
 
 func (h *FlowRequestHandler) PostScript(ctx context.Context, req *protos.PostScriptRequest) (*protos.PostScriptResponse, error) {
+	source := []byte(req.Script.Source)
+	if req.Script.Lang == "wasm" {
+		var err error
+		if source, err = wasm.DecodeSource(req.Script.Source); err != nil {
+			return nil, err
+		}
+		if err := wasm.Validate(ctx, source); err != nil {
+			return nil, fmt.Errorf("invalid WASM script %s: %w", req.Script.Name, err)
+		}
+	}
 	if req.Script.Id == -1 {
 		var id int32
 		if err := h.pool.QueryRow(
@@ -43,7 +59,7 @@ func (h *FlowRequestHandler) PostScript(ctx context.Context, req *protos.PostScr
 			"INSERT INTO scripts(lang,name,source) VALUES($1,$2,$3) RETURNING id",
 			req.Script.Lang,
 			req.Script.Name,
-			[]byte(req.Script.Source),
+			source,
 		).Scan(&id); err != nil {
 			return nil, err
 		}
@@ -53,7 +69,7 @@ func (h *FlowRequestHandler) PostScript(ctx context.Context, req *protos.PostScr
 		"UPDATE scripts SET lang=$1,name=$2,source=$3 where id=$4",
 		req.Script.Lang,
 		req.Script.Name,
-		[]byte(req.Script.Source),
+		source,
 		req.Script.Id,
 	); err != nil {
 		return nil, err
@@ -6,6 +6,7 @@ import (
 	"slices"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -16,19 +17,33 @@ func (h *FlowRequestHandler) GetDynamicSettings(
 	ctx context.Context,
 	req *protos.GetDynamicSettingsRequest,
 ) (*protos.GetDynamicSettingsResponse, error) {
-	rows, err := h.pool.Query(ctx, "select config_name,config_value from dynamic_settings")
+	rows, err := h.pool.Query(ctx, "select config_name,config_value,scope,scope_name from dynamic_settings")
 	if err != nil {
 		slog.Error("[GetDynamicConfigs] failed to query settings", slog.Any("error", err))
 		return nil, err
 	}
 	settings := slices.Clone(internal.DynamicSettings[:])
 	var name string
-	var value string
-	if _, err := pgx.ForEachRow(rows, []any{&name, &value}, func() error {
-		if idx, ok := internal.DynamicIndex[name]; ok {
+	var value pgtype.Text
+	var scope string
+	var scopeName string
+	if _, err := pgx.ForEachRow(rows, []any{&name, &value, &scope, &scopeName}, func() error {
+		idx, ok := internal.DynamicIndex[name]
+		if !ok || !value.Valid {
+			return nil
+		}
+		if settings[idx] == internal.DynamicSettings[idx] {
 			settings[idx] = proto.CloneOf(settings[idx])
-			newValue := value // create a new string reference as value can be overwritten by the next iteration.
+		}
+		if scope == "global" {
+			newValue := value.String // create a new string reference as value can be overwritten by the next iteration.
 			settings[idx].Value = &newValue
+		} else {
+			settings[idx].Overrides = append(settings[idx].Overrides, &protos.DynamicSettingOverride{
+				Scope:     internal.DynamicSettingScopeFromColumn(scope),
+				ScopeName: scopeName,
+				Value:     value.String,
+			})
 		}
 		return nil
 	}); err != nil {
@@ -54,7 +69,12 @@ func (h *FlowRequestHandler) PostDynamicSetting(
 	ctx context.Context,
 	req *protos.PostDynamicSettingRequest,
 ) (*protos.PostDynamicSettingResponse, error) {
-	err := internal.UpdateDynamicSetting(ctx, h.pool, req.Name, req.Value)
+	if idx, ok := internal.DynamicIndex[req.Name]; ok && req.Value != nil {
+		if err := internal.ValidateDynamicSettingValue(internal.DynamicSettings[idx], *req.Value); err != nil {
+			return nil, err
+		}
+	}
+	err := internal.UpdateScopedDynamicSetting(ctx, h.pool, req.Name, req.Scope, req.ScopeName, req.Value)
 	if err != nil {
 		slog.Error("[PostDynamicConfig] failed to execute update setting", slog.Any("error", err))
 		return nil, err
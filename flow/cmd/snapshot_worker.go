@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"go.temporal.io/sdk/client"
 	temporalotel "go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/interceptor"
 	"go.temporal.io/sdk/worker"
 	"go.temporal.io/sdk/workflow"
 
@@ -24,6 +26,7 @@ type SnapshotWorkerOptions struct {
 	TemporalHostPort  string
 	TemporalNamespace string
 	EnableOtelMetrics bool
+	WorkerStopTimeout time.Duration
 }
 
 func SnapshotWorkerMain(ctx context.Context, opts *SnapshotWorkerOptions) (*WorkerSetupResponse, error) {
@@ -56,8 +59,11 @@ func SnapshotWorkerMain(ctx context.Context, opts *SnapshotWorkerOptions) (*Work
 	}
 
 	taskQueue := internal.PeerFlowTaskQueueName(shared.SnapshotFlowTaskQueue)
+	drain := NewDrainWorkerInterceptor()
 	w := worker.New(c, taskQueue, worker.Options{
 		EnableSessionWorker: true,
+		WorkerStopTimeout:   opts.WorkerStopTimeout,
+		Interceptors:        []interceptor.WorkerInterceptor{drain},
 		OnFatalError: func(err error) {
 			slog.Error("Snapshot Worker failed", slog.Any("error", err))
 		},
@@ -81,5 +87,7 @@ func SnapshotWorkerMain(ctx context.Context, opts *SnapshotWorkerOptions) (*Work
 		Client:      c,
 		Worker:      w,
 		OtelManager: otelManager,
+		Drain:       drain,
+		StopTimeout: opts.WorkerStopTimeout,
 	}, nil
 }
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// CreateSubsetFlow copies a subset of the source keeping relationships between its tables intact,
+// every table is copied by an initial load only QRep mirror named after the table
+func (h *FlowRequestHandler) CreateSubsetFlow(
+	ctx context.Context,
+	req *protos.CreateSubsetFlowRequest,
+) (*protos.CreateSubsetFlowResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("subset flow name is required")
+	}
+	sourceType, err := connectors.LoadPeerType(ctx, h.pool, req.SourceName)
+	if err != nil {
+		return nil, err
+	} else if sourceType != protos.DBType_POSTGRES {
+		return nil, fmt.Errorf("subsets are only supported from postgres sources, not %s", sourceType)
+	}
+
+	tables, warnings, err := utils.PlanSubset(req.Roots, req.Relationships)
+	if err != nil {
+		return nil, fmt.Errorf("unable to plan subset: %w", err)
+	}
+	destinationTables := make(map[string]string, len(req.TableMappings))
+	for _, mapping := range req.TableMappings {
+		destinationTables[mapping.SourceTableIdentifier] = mapping.DestinationTableIdentifier
+	}
+
+	res := &protos.CreateSubsetFlowResponse{
+		Tables:   make([]*protos.SubsetTablePlan, 0, len(tables)),
+		Warnings: warnings,
+	}
+	for _, table := range tables {
+		destinationTable := table.Name
+		if name, ok := destinationTables[table.Name]; ok {
+			destinationTable = name
+		}
+		res.Tables = append(res.Tables, &protos.SubsetTablePlan{
+			SourceTable:      table.Name,
+			DestinationTable: destinationTable,
+			Query:            table.Query,
+			FlowJobName:      shared.ReplaceIllegalCharactersWithUnderscores(req.FlowJobName + "_" + table.Name),
+		})
+	}
+	if req.DryRun {
+		return res, nil
+	}
+
+	for _, table := range res.Tables {
+		qrepRes, err := h.CreateQRepFlow(ctx, &protos.CreateQRepFlowRequest{
+			QrepConfig: &protos.QRepConfig{
+				FlowJobName:                      table.FlowJobName,
+				SourceName:                       req.SourceName,
+				DestinationName:                  req.DestinationName,
+				DestinationTableIdentifier:       table.DestinationTable,
+				Query:                            table.Query,
+				WatermarkTable:                   table.SourceTable,
+				InitialCopyOnly:                  true,
+				SetupWatermarkTableOnDestination: true,
+				System:                           protos.TypeSystem_Q,
+			},
+			CreateCatalogEntry: true,
+		})
+		if err != nil {
+			slog.Error("unable to create subset mirror", slog.String(string(shared.FlowNameKey), table.FlowJobName), slog.Any("error", err))
+			return nil, fmt.Errorf("unable to create mirror for table %s of subset: %w", table.SourceTable, err)
+		}
+		table.WorkflowId = qrepRes.WorkflowId
+	}
+	return res, nil
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 	"github.com/PeerDB-io/peerdb/flow/shared/telemetry"
 )
@@ -32,6 +34,11 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 		return nil, exceptions.ErrUnderMaintenance
 	}
 
+	req, err = h.resolveMirrorTemplate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	if !req.ConnectionConfigs.Resync {
 		mirrorExists, existCheckErr := h.CheckIfMirrorNameExists(ctx, req.ConnectionConfigs.FlowJobName)
 		if existCheckErr != nil {
@@ -57,6 +64,18 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 				return nil, fmt.Errorf("invalid custom column type %s", col.DestinationType)
 			}
 		}
+		if _, err := model.NewJSONPathExtractor(tm.JsonPathColumns); err != nil {
+			return nil, err
+		}
+	}
+	if err := internal.ValidateMirrorEnv(req.ConnectionConfigs.Env); err != nil {
+		return nil, err
+	}
+	if req.ConnectionConfigs.Script != "" && req.ConnectionConfigs.System == protos.TypeSystem_PG {
+		return nil, errors.New("scripts are not supported with the PG type system")
+	}
+	if err := h.validatePostgresOnlyOptions(ctx, req.ConnectionConfigs); err != nil {
+		return nil, err
 	}
 
 	srcConn, err := connectors.GetByNameAs[connectors.MirrorSourceValidationConnector](
@@ -112,6 +131,138 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 	return &protos.ValidateCDCMirrorResponse{}, nil
 }
 
+// PreflightCDCMirror runs the checks of ValidateCDCMirror and actively tests creating tables, inserting into them
+// and accessing the stage on the destination, reporting every check rather than failing at the first one
+func (h *FlowRequestHandler) PreflightCDCMirror(
+	ctx context.Context, req *protos.CreateCDCFlowRequest,
+) (*protos.PreflightCDCMirrorResponse, error) {
+	req, err := h.resolveMirrorTemplate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	cfg := req.ConnectionConfigs
+	if cfg == nil {
+		return nil, errors.New("connection configs is nil")
+	}
+
+	var checks []*protos.PreflightCheck
+	addChecks := func(target string, targetChecks ...*protos.PreflightCheck) {
+		for _, check := range targetChecks {
+			check.Target = target
+		}
+		checks = append(checks, targetChecks...)
+	}
+
+	if !cfg.Resync {
+		mirrorExists, err := h.CheckIfMirrorNameExists(ctx, cfg.FlowJobName)
+		if err == nil && mirrorExists {
+			err = fmt.Errorf("mirror with name %s already exists", cfg.FlowJobName)
+		}
+		addChecks("mirror", utils.PreflightCheckResult("mirror_name", err))
+	}
+	var invalidColumnType error
+	for _, tm := range cfg.TableMappings {
+		for _, col := range tm.Columns {
+			if !CustomColumnTypeRegex.MatchString(col.DestinationType) {
+				invalidColumnType = fmt.Errorf("invalid custom column type %s", col.DestinationType)
+			}
+		}
+		if _, err := model.NewJSONPathExtractor(tm.JsonPathColumns); err != nil {
+			invalidColumnType = err
+		}
+	}
+	addChecks("mirror", utils.PreflightCheckResult("column_types", invalidColumnType))
+	addChecks("mirror", utils.PreflightCheckResult("postgres_options", h.validatePostgresOnlyOptions(ctx, cfg)))
+
+	var schemas map[string]*protos.TableSchema
+	if srcConn, err := connectors.GetByNameAs[connectors.MirrorSourceValidationConnector](
+		ctx, cfg.Env, h.pool, cfg.SourceName,
+	); err != nil {
+		addChecks("source", utils.PreflightCheckResult("connection", err))
+	} else {
+		defer connectors.CloseConnector(ctx, srcConn)
+		if preflightConn, ok := srcConn.(connectors.MirrorSourcePreflightConnector); ok {
+			addChecks("source", preflightConn.PreflightMirrorSource(ctx, cfg)...)
+		} else {
+			addChecks("source", utils.PreflightCheckResult("mirror_source", srcConn.ValidateMirrorSource(ctx, cfg)))
+		}
+
+		schemas, err = srcConn.GetTableSchema(ctx, cfg.Env, cfg.Version, cfg.System, cfg.TableMappings)
+		addChecks("source", utils.PreflightCheckResult("table_schemas", err))
+	}
+
+	if dstConn, err := connectors.GetByNameAs[connectors.Connector](ctx, cfg.Env, h.pool, cfg.DestinationName); err != nil {
+		addChecks("destination", utils.PreflightCheckResult("connection", err))
+	} else {
+		defer connectors.CloseConnector(ctx, dstConn)
+		if preflightConn, ok := dstConn.(connectors.MirrorDestinationPreflightConnector); ok {
+			addChecks("destination", preflightConn.PreflightMirrorDestination(ctx, cfg)...)
+		} else if validationConn, ok := dstConn.(connectors.ValidationConnector); ok {
+			addChecks("destination", utils.PreflightCheckResult("permissions", validationConn.ValidateCheck(ctx)))
+		} else {
+			addChecks("destination", &protos.PreflightCheck{
+				Name:    "permissions",
+				Status:  protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_WARN,
+				Message: "permissions cannot be tested for this destination type",
+			})
+		}
+		if mirrorConn, ok := dstConn.(connectors.MirrorDestinationValidationConnector); ok && schemas != nil {
+			addChecks("destination", utils.PreflightCheckResult("mirror_destination",
+				mirrorConn.ValidateMirrorDestination(ctx, cfg, schemas)))
+		}
+	}
+
+	res := &protos.PreflightCDCMirrorResponse{Checks: checks, Ok: true}
+	for _, check := range checks {
+		if check.Status == protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_FAIL {
+			res.Ok = false
+		}
+	}
+	return res, nil
+}
+
+// validatePostgresOnlyOptions checks generated column policies, conflict policies and constraint migration
+// are only set for peers supporting them
+func (h *FlowRequestHandler) validatePostgresOnlyOptions(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	var hasPolicy, regenerates, detectsConflicts bool
+	for _, tm := range cfg.TableMappings {
+		for _, policy := range []protos.GeneratedColumnPolicy{tm.GeneratedColumns, tm.IdentityColumns} {
+			hasPolicy = hasPolicy || policy != protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REPLICATE
+			regenerates = regenerates || policy == protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REGENERATE
+		}
+		if tm.ConflictPolicy != protos.ConflictPolicy_CONFLICT_POLICY_SOURCE_WINS && tm.ConflictTimestampColumn == "" {
+			return fmt.Errorf("conflict policy %s of table %s needs a conflict timestamp column",
+				tm.ConflictPolicy, tm.DestinationTableIdentifier)
+		}
+		if tm.ConflictPolicy == protos.ConflictPolicy_CONFLICT_POLICY_DESTINATION_WINS && cfg.SyncedAtColName == "" {
+			return fmt.Errorf("conflict policy %s of table %s needs a synced at column",
+				tm.ConflictPolicy, tm.DestinationTableIdentifier)
+		}
+		detectsConflicts = detectsConflicts || tm.ConflictTimestampColumn != ""
+	}
+	if !hasPolicy && !detectsConflicts && !cfg.MigrateConstraints {
+		return nil
+	}
+	peerTypes, err := connectors.LoadPeerTypes(ctx, h.pool, []string{cfg.SourceName, cfg.DestinationName})
+	if err != nil {
+		return fmt.Errorf("failed to load peer types: %w", err)
+	}
+	if cfg.MigrateConstraints &&
+		(peerTypes[cfg.SourceName] != protos.DBType_POSTGRES || peerTypes[cfg.DestinationName] != protos.DBType_POSTGRES) {
+		return errors.New("constraints can only be migrated from Postgres sources to Postgres destinations")
+	}
+	if hasPolicy && peerTypes[cfg.SourceName] != protos.DBType_POSTGRES {
+		return errors.New("generated and identity column policies are only supported with Postgres sources")
+	}
+	if regenerates && peerTypes[cfg.DestinationName] != protos.DBType_POSTGRES {
+		return errors.New("generated and identity columns can only be regenerated by Postgres destinations")
+	}
+	if detectsConflicts && peerTypes[cfg.DestinationName] != protos.DBType_POSTGRES {
+		return errors.New("conflict policies are only supported with Postgres destinations")
+	}
+	return nil
+}
+
 func (h *FlowRequestHandler) CheckIfMirrorNameExists(ctx context.Context, mirrorName string) (bool, error) {
 	var nameExists pgtype.Bool
 	err := h.pool.QueryRow(ctx, "SELECT EXISTS(SELECT * FROM flows WHERE name = $1)", mirrorName).Scan(&nameExists)
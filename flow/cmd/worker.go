@@ -10,10 +10,12 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"go.temporal.io/sdk/client"
 	temporalotel "go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/interceptor"
 	"go.temporal.io/sdk/worker"
 	"go.temporal.io/sdk/workflow"
 
@@ -29,18 +31,63 @@ import (
 type WorkerSetupOptions struct {
 	TemporalHostPort                   string
 	TemporalNamespace                  string
+	WorkerPool                         string // Worker pool whose mirrors the worker runs, empty for the default pool
 	TemporalMaxConcurrentActivities    int
 	TemporalMaxConcurrentWorkflowTasks int
 	EnableProfiling                    bool
 	EnableOtelMetrics                  bool
 	UseMaintenanceTaskQueue            bool
-	PprofPort                          int // Port for pprof HTTP server
+	PprofPort                          int           // Port for pprof HTTP server
+	WorkerStopTimeout                  time.Duration // How long in-flight activities get to drain on shutdown
 }
 
 type WorkerSetupResponse struct {
 	Client      client.Client
 	Worker      worker.Worker
 	OtelManager *otel_metrics.OtelManager
+	Drain       *DrainWorkerInterceptor
+	StopTimeout time.Duration
+}
+
+// Run runs the worker until interrupted. On interrupt the worker stops polling and in-flight activities
+// get up to StopTimeout to flush their progress before being cancelled, with drain status logged throughout
+func (w *WorkerSetupResponse) Run() error {
+	interruptCh := make(chan any)
+	stopped := make(chan struct{})
+	var draining atomic.Bool
+	go func() {
+		select {
+		case sig := <-worker.InterruptCh():
+			draining.Store(true)
+			slog.Info("Draining worker",
+				slog.Any("signal", sig), slog.Duration("stopTimeout", w.StopTimeout), slog.Any("running", w.Drain.Running()))
+			close(interruptCh)
+		case <-stopped:
+			return
+		}
+
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopped:
+				return
+			case <-ticker.C:
+				slog.Info("Worker still draining", slog.Any("running", w.Drain.Running()))
+			}
+		}
+	}()
+
+	err := w.Worker.Run(interruptCh)
+	close(stopped)
+	if draining.Load() {
+		if running := w.Drain.Running(); len(running) > 0 {
+			slog.Warn("Worker stopped before activities drained", slog.Any("running", running))
+		} else {
+			slog.Info("Worker drained")
+		}
+	}
+	return err
 }
 
 func (w *WorkerSetupResponse) Close(ctx context.Context) {
@@ -109,11 +156,13 @@ func WorkerSetup(ctx context.Context, opts *WorkerSetupOptions) (*WorkerSetupRes
 		return nil, fmt.Errorf("unable to create Temporal client: %w", err)
 	}
 	slog.Info("Created temporal client")
-	queueId := shared.PeerFlowTaskQueue
+	if err := internal.ValidateWorkerPool(opts.WorkerPool); err != nil {
+		return nil, err
+	}
+	taskQueue := internal.WorkerPoolTaskQueueName(opts.WorkerPool)
 	if opts.UseMaintenanceTaskQueue {
-		queueId = shared.MaintenanceFlowTaskQueue
+		taskQueue = internal.PeerFlowTaskQueueName(shared.MaintenanceFlowTaskQueue)
 	}
-	taskQueue := internal.PeerFlowTaskQueueName(queueId)
 	slog.Info(
 		fmt.Sprintf("Creating temporal worker for queue %v: %v workflow workers %v activity workers",
 			taskQueue,
@@ -121,10 +170,13 @@ func WorkerSetup(ctx context.Context, opts *WorkerSetupOptions) (*WorkerSetupRes
 			opts.TemporalMaxConcurrentActivities,
 		),
 	)
+	drain := NewDrainWorkerInterceptor()
 	w := worker.New(c, taskQueue, worker.Options{
 		EnableSessionWorker:                    true,
 		MaxConcurrentActivityExecutionSize:     opts.TemporalMaxConcurrentActivities,
 		MaxConcurrentWorkflowTaskExecutionSize: opts.TemporalMaxConcurrentWorkflowTasks,
+		WorkerStopTimeout:                      opts.WorkerStopTimeout,
+		Interceptors:                           []interceptor.WorkerInterceptor{drain},
 		OnFatalError: func(err error) {
 			slog.Error("Peerflow Worker failed", slog.Any("error", err))
 		},
@@ -154,5 +206,7 @@ func WorkerSetup(ctx context.Context, opts *WorkerSetupOptions) (*WorkerSetupRes
 		Client:      c,
 		Worker:      w,
 		OtelManager: otelManager,
+		Drain:       drain,
+		StopTimeout: opts.WorkerStopTimeout,
 	}, nil
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// MoveMirrorToWorkerPool reassigns a mirror to the task queue of another worker pool,
+// its workflow moves when it next continues as new
+func (h *FlowRequestHandler) MoveMirrorToWorkerPool(
+	ctx context.Context, req *protos.MoveMirrorToWorkerPoolRequest,
+) (*protos.MoveMirrorToWorkerPoolResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+	if err := internal.ValidateWorkerPool(req.WorkerPool); err != nil {
+		return nil, err
+	}
+
+	workflowID, err := h.getWorkflowID(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	previousConfig, err := h.getMirrorConfig(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if err := model.WorkerPoolSignal.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", req.WorkerPool); err != nil {
+		slog.Error("unable to signal worker pool move", slog.String("mirror", req.FlowJobName), slog.Any("error", err))
+		return nil, fmt.Errorf("unable to signal worker pool move to mirror %s: %w", req.FlowJobName, err)
+	}
+	// CDC mirrors persist the move from their workflow, QRep mirrors only carry it until they continue as new
+	if qrepConfig, ok := previousConfig.(*protos.QRepConfig); ok && qrepConfig.WorkerPool != req.WorkerPool {
+		qrepConfig = proto.CloneOf(qrepConfig)
+		qrepConfig.WorkerPool = req.WorkerPool
+		if err := h.updateQRepConfigInCatalog(ctx, qrepConfig); err != nil {
+			return nil, err
+		}
+	}
+	h.recordMirrorAuditEvent(ctx, req.FlowJobName, mirrorAuditEdit, previousConfig, req)
+	return &protos.MoveMirrorToWorkerPoolResponse{}, nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+type WorkflowReplayCLIParams struct {
+	TemporalHostPort  string
+	TemporalNamespace string
+	WorkflowTypes     []string
+	Since             time.Duration
+	Limit             int
+}
+
+// DefaultReplayWorkflowTypes are the long running workflows whose histories outlive deployments
+var DefaultReplayWorkflowTypes = []string{
+	"CDCFlowWorkflow",
+	"SetupFlowWorkflow",
+	"SnapshotFlowWorkflow",
+	"QRepFlowWorkflow",
+	"QRepPartitionWorkflow",
+	"XminFlowWorkflow",
+	"DropFlowWorkflow",
+}
+
+// WorkflowReplayMain replays recent workflow histories against the workflows of this binary,
+// failing when any of them no longer replays deterministically so that it can gate deployments
+func WorkflowReplayMain(ctx context.Context, args *WorkflowReplayCLIParams) error {
+	tc, err := setupTemporalClient(ctx, client.Options{
+		HostPort:  args.TemporalHostPort,
+		Namespace: args.TemporalNamespace,
+		Logger:    slog.New(shared.NewSlogHandler(slog.NewJSONHandler(os.Stdout, nil))),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create Temporal client: %w", err)
+	}
+	defer tc.Close()
+
+	replayer, err := worker.NewWorkflowReplayerWithOptions(worker.WorkflowReplayerOptions{
+		ContextPropagators: []workflow.ContextPropagator{
+			internal.NewContextPropagator[*protos.FlowContextMetadata](internal.FlowMetadataKey),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create workflow replayer: %w", err)
+	}
+	peerflow.RegisterFlowWorkerWorkflows(replayer)
+	replayer.RegisterWorkflow(peerflow.SnapshotFlowWorkflow)
+
+	workflowTypes := args.WorkflowTypes
+	if len(workflowTypes) == 0 {
+		workflowTypes = DefaultReplayWorkflowTypes
+	}
+	since := time.Now().Add(-args.Since).UTC().Format(time.RFC3339)
+
+	var replayed, failed int
+	for _, workflowType := range workflowTypes {
+		executions, err := listReplayExecutions(ctx, tc, args.TemporalNamespace,
+			fmt.Sprintf("WorkflowType = '%s' AND (StartTime > '%s' OR ExecutionStatus = 'Running')", workflowType, since),
+			args.Limit,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, execution := range executions {
+			logger := slog.With(slog.String("workflowType", workflowType),
+				slog.String("workflowID", execution.WorkflowId), slog.String("runID", execution.RunId))
+			hist, err := fetchReplayHistory(ctx, tc, execution.WorkflowId, execution.RunId)
+			if err != nil {
+				return err
+			}
+			replayed++
+			if err := replayer.ReplayWorkflowHistory(nil, hist); err != nil {
+				failed++
+				logger.Error("workflow history failed to replay", slog.Any("error", err))
+			}
+		}
+	}
+
+	slog.Info("replayed workflow histories", slog.Int("replayed", replayed), slog.Int("failed", failed))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d workflow histories failed to replay, workflow changes are not deterministic", failed, replayed)
+	}
+	return nil
+}
+
+func listReplayExecutions(
+	ctx context.Context,
+	tc client.Client,
+	namespace string,
+	query string,
+	limit int,
+) ([]*common.WorkflowExecution, error) {
+	var executions []*common.WorkflowExecution
+	var pageToken []byte
+	for {
+		res, err := tc.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     namespace,
+			Query:         query,
+			NextPageToken: pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list workflows: %w", err)
+		}
+		for _, info := range res.Executions {
+			if limit > 0 && len(executions) >= limit {
+				return executions, nil
+			}
+			executions = append(executions, info.Execution)
+		}
+		pageToken = res.NextPageToken
+		if len(pageToken) == 0 {
+			return executions, nil
+		}
+	}
+}
+
+func fetchReplayHistory(ctx context.Context, tc client.Client, workflowID string, runID string) (*history.History, error) {
+	var hist history.History
+	iter := tc.GetWorkflowHistory(ctx, workflowID, runID, false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch history of workflow %s: %w", workflowID, err)
+		}
+		hist.Events = append(hist.Events, event)
+	}
+	if len(hist.Events) == 0 {
+		return nil, errors.New("empty history for workflow " + workflowID)
+	}
+	return &hist, nil
+}
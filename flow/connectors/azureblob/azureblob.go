@@ -0,0 +1,120 @@
+package connazureblob
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+type AzureBlobConnector struct {
+	*metadataStore.PostgresMetadata
+	logger     log.Logger
+	client     *azblob.Client
+	path       *utils.AzureBlobPath
+	codec      protos.AvroCodec
+	fileFormat protos.S3FileFormat
+}
+
+func NewAzureBlobConnector(
+	ctx context.Context,
+	config *protos.AzureBlobConfig,
+) (*AzureBlobConnector, error) {
+	logger := internal.LoggerFromCtx(ctx)
+
+	if config.FileFormat != protos.S3FileFormat_S3_AVRO && config.FileFormat != protos.S3FileFormat_S3_PARQUET {
+		return nil, fmt.Errorf("unsupported file format %s for Azure Blob Storage", config.FileFormat)
+	}
+	path, err := utils.NewAzureBlobPath(config.Url)
+	if err != nil {
+		return nil, err
+	}
+	client, err := utils.CreateAzureBlobClient(path, utils.NewPeerAzureCredentials(config))
+	if err != nil {
+		return nil, err
+	}
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		logger.Error("failed to create postgres metadata store", "error", err)
+		return nil, err
+	}
+	return &AzureBlobConnector{
+		PostgresMetadata: pgMetadata,
+		logger:           logger,
+		client:           client,
+		path:             path,
+		codec:            config.Codec,
+		fileFormat:       config.FileFormat,
+	}, nil
+}
+
+func (c *AzureBlobConnector) CreateRawTable(_ context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	c.logger.Info("CreateRawTable for Azure Blob Storage is a no-op")
+	return nil, nil
+}
+
+func (c *AzureBlobConnector) Close() error {
+	return nil
+}
+
+func (c *AzureBlobConnector) ValidateCheck(ctx context.Context) error {
+	return utils.PutAndRemoveAzureBlob(ctx, c.client, c.path)
+}
+
+func (c *AzureBlobConnector) ConnectionActive(ctx context.Context) error {
+	return nil
+}
+
+func (c *AzureBlobConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	streamReq := model.NewRecordsToStreamRequest(
+		req.Records.GetRecords(), tableNameRowsMapping, req.SyncBatchID, false, protos.DBType_AZURE_BLOB,
+	)
+	recordStream, err := utils.RecordsToRawTableStream(ctx, req.Env, req.FlowJobName, streamReq, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+	}
+	qrepConfig := &protos.QRepConfig{
+		FlowJobName:                req.FlowJobName,
+		DestinationTableIdentifier: "raw_table_" + req.FlowJobName,
+		Env:                        req.Env,
+		Version:                    req.Version,
+	}
+	partition := &protos.QRepPartition{
+		PartitionId: strconv.FormatInt(req.SyncBatchID, 10),
+	}
+	numRecords, _, err := c.SyncQRepRecords(ctx, qrepConfig, partition, recordStream)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Info(fmt.Sprintf("Synced %d records", numRecords))
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		c.logger.Error("failed to increment id", "error", err)
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   req.SyncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *AzureBlobConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	c.logger.Info("ReplayTableSchemaDeltas for Azure Blob Storage is a no-op")
+	return nil
+}
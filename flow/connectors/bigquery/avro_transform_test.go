@@ -28,7 +28,7 @@ func TestAvroTransform(t *testing.T) {
 	}
 
 	expectedTransformCols := []string{
-		"ST_GEOGFROMTEXT(`col1`) AS `col1`",
+		"ST_GEOGFROMTEXT(REGEXP_REPLACE(`col1`, r'^SRID=[0-9]+;', '')) AS `col1`",
 		"PARSE_JSON(`col2`,wide_number_mode=>'round') AS `col2`",
 		"`camelCol4`",
 		"CURRENT_TIMESTAMP AS `sync_col`",
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"path"
 	"reflect"
 	"slices"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
 	"cloud.google.com/go/storage"
 	"go.temporal.io/sdk/log"
 	"google.golang.org/api/iterator"
@@ -27,6 +29,17 @@ const (
 	SyncRecordsBatchSize = 1024
 )
 
+var rawTableSchema = bigquery.Schema{
+	{Name: "_peerdb_uid", Type: bigquery.StringFieldType},
+	{Name: "_peerdb_timestamp", Type: bigquery.IntegerFieldType},
+	{Name: "_peerdb_destination_table_name", Type: bigquery.StringFieldType},
+	{Name: "_peerdb_data", Type: bigquery.StringFieldType},
+	{Name: "_peerdb_record_type", Type: bigquery.IntegerFieldType},
+	{Name: "_peerdb_match_data", Type: bigquery.StringFieldType},
+	{Name: "_peerdb_batch_id", Type: bigquery.IntegerFieldType},
+	{Name: "_peerdb_unchanged_toast_columns", Type: bigquery.StringFieldType},
+}
+
 func NewBigQueryServiceAccount(bqConfig *protos.BigqueryConfig) (*utils.GcpServiceAccount, error) {
 	var serviceAccount utils.GcpServiceAccount
 	serviceAccount.Type = bqConfig.AuthType
@@ -52,13 +65,16 @@ type BigQueryConnector struct {
 	logger        log.Logger
 	bqConfig      *protos.BigqueryConfig
 	client        *bigquery.Client
+	writeClient   *managedwriter.Client
 	storageClient *storage.Client
 	catalogPool   shared.CatalogPool
-	datasetID     string
-	projectID     string
+	// nil without max_load_jobs_per_day
+	loadJobLimit *utils.RateLimiter
+	datasetID    string
+	projectID    string
 }
 
-func NewBigQueryConnector(ctx context.Context, config *protos.BigqueryConfig) (*BigQueryConnector, error) {
+func NewBigQueryConnector(ctx context.Context, peerName string, config *protos.BigqueryConfig) (*BigQueryConnector, error) {
 	logger := internal.LoggerFromCtx(ctx)
 
 	bqsa, err := NewBigQueryServiceAccount(config)
@@ -94,19 +110,35 @@ func NewBigQueryConnector(ctx context.Context, config *protos.BigqueryConfig) (*
 		return nil, fmt.Errorf("failed to create Storage client: %v", err)
 	}
 
+	writeClient, err := bqsa.CreateBigQueryWriteClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
 	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create catalog connection pool: %v", err)
 	}
 
+	var loadJobLimit *utils.RateLimiter
+	if config.MaxLoadJobsPerDay > 0 {
+		if loadJobLimit, err = utils.NewRateLimiter(
+			catalogPool, peerName, "load_jobs", float64(config.MaxLoadJobsPerDay), 24*time.Hour,
+		); err != nil {
+			return nil, err
+		}
+	}
+
 	return &BigQueryConnector{
 		bqConfig:         config,
 		client:           client,
+		writeClient:      writeClient,
 		datasetID:        datasetID,
 		projectID:        projectID,
 		PostgresMetadata: metadataStore.NewPostgresMetadataFromCatalog(logger, catalogPool),
 		storageClient:    storageClient,
 		catalogPool:      catalogPool,
+		loadJobLimit:     loadJobLimit,
 		logger:           logger,
 	}, nil
 }
@@ -116,6 +148,56 @@ func NewBigQueryConnector(ctx context.Context, config *protos.BigqueryConfig) (*
 // 2. Inserts one row into the table
 // 3. Deletes the table
 func (c *BigQueryConnector) ValidateCheck(ctx context.Context) error {
+	newTable, err := c.createValidationTable(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if err := c.insertValidationRow(ctx, newTable); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Drop the table
+	deleteErr := newTable.Delete(ctx)
+	if deleteErr != nil {
+		errs = append(errs, fmt.Errorf("unable to delete table :%w. ", deleteErr))
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// PreflightMirrorDestination runs the checks of ValidateCheck, reporting creating a table and inserting into it separately,
+// along with access to the bucket an initial snapshot is staged in
+func (c *BigQueryConnector) PreflightMirrorDestination(
+	ctx context.Context, cfg *protos.FlowConnectionConfigs,
+) []*protos.PreflightCheck {
+	var newTable *bigquery.Table
+	checks := utils.PreflightTableChecks(func() error {
+		var err error
+		newTable, err = c.createValidationTable(ctx)
+		return err
+	}, func() error {
+		return c.insertValidationRow(ctx, newTable)
+	})
+	if newTable != nil {
+		if err := newTable.Delete(ctx); err != nil {
+			c.logger.Error("preflight failed to delete table", slog.String("table", newTable.TableID), slog.Any("error", err))
+		}
+	}
+
+	// batches are appended through the Storage Write API, only snapshots are staged
+	if cfg.DoInitialSnapshot && cfg.SnapshotStagingPath != "" {
+		checks = append(checks, utils.PreflightCheckResult("snapshot_stage", c.validateStage(ctx, cfg.SnapshotStagingPath)))
+	}
+	return checks
+}
+
+func (c *BigQueryConnector) createValidationTable(ctx context.Context) (*bigquery.Table, error) {
 	dummyTable := "peerdb_validate_dummy_" + shared.RandomString(4)
 
 	newTable := c.client.DatasetInProject(c.projectID, c.datasetID).Table(dummyTable)
@@ -130,36 +212,45 @@ func (c *BigQueryConnector) ValidateCheck(ctx context.Context) error {
 		},
 	})
 	if createErr != nil {
-		return fmt.Errorf("unable to validate table creation within dataset: %w. "+
+		return nil, fmt.Errorf("unable to validate table creation within dataset: %w. "+
 			"Please check if bigquery.tables.create permission has been granted", createErr)
 	}
+	return newTable, nil
+}
 
-	var errs []error
-	insertQuery := c.client.Query(fmt.Sprintf("INSERT INTO %s VALUES(true)", dummyTable))
+func (c *BigQueryConnector) insertValidationRow(ctx context.Context, newTable *bigquery.Table) error {
+	insertQuery := c.client.Query(fmt.Sprintf("INSERT INTO %s VALUES(true)", newTable.TableID))
 	insertQuery.DefaultDatasetID = c.datasetID
 	insertQuery.DefaultProjectID = c.projectID
-	_, insertErr := insertQuery.Run(ctx)
-	if insertErr != nil {
-		errs = append(errs, fmt.Errorf("unable to validate insertion into table: %w. ", insertErr))
+	if _, insertErr := insertQuery.Run(ctx); insertErr != nil {
+		return fmt.Errorf("unable to validate insertion into table: %w. ", insertErr)
 	}
+	return nil
+}
 
-	// Drop the table
-	deleteErr := newTable.Delete(ctx)
-	if deleteErr != nil {
-		errs = append(errs, fmt.Errorf("unable to delete table :%w. ", deleteErr))
+// validateStage writes an object to the bucket of stagingPath and deletes it,
+// stagingPath being a bare bucket name or gs://bucket/prefix like for QRepAvroSyncMethod
+func (c *BigQueryConnector) validateStage(ctx context.Context, stagingPath string) error {
+	gcsBucket, gcsPrefix, _ := strings.Cut(strings.TrimPrefix(stagingPath, "gs://"), "/")
+	obj := c.storageClient.Bucket(gcsBucket).Object(
+		strings.TrimPrefix(path.Join(gcsPrefix, "peerdb_check"+shared.RandomString(8)), "/"))
+	w := obj.NewWriter(ctx)
+	if _, err := w.Write([]byte(time.Now().Format(time.RFC3339))); err != nil {
+		return fmt.Errorf("failed to write to bucket %s: %w", gcsBucket, err)
 	}
-
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write to bucket %s: %w", gcsBucket, err)
+	}
+	if err := obj.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete from bucket %s: %w", gcsBucket, err)
 	}
-
 	return nil
 }
 
 // Close closes the BigQuery driver.
 func (c *BigQueryConnector) Close() error {
 	if c != nil {
-		return c.client.Close()
+		return errors.Join(c.writeClient.Close(), c.client.Close())
 	}
 	return nil
 }
@@ -248,6 +339,36 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 		}
 	}
 
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil || len(schemaDelta.WidenedColumns) == 0 {
+			continue
+		}
+		dstDatasetTable, err := c.convertToDatasetTable(schemaDelta.DstTableName)
+		if err != nil {
+			return err
+		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			// integers share INT64 and floats share FLOAT64, so mostly numeric precision changes here
+			previousBigQueryType := qValueKindToBigQueryTypeString(widenedColumn.Previous, schemaDelta.NullableEnabled, false)
+			widenedBigQueryType := qValueKindToBigQueryTypeString(widenedColumn.Current, schemaDelta.NullableEnabled, false)
+			if widenedBigQueryType == previousBigQueryType {
+				continue
+			}
+			query := c.queryWithLogging(fmt.Sprintf(
+				"ALTER TABLE `%s` ALTER COLUMN `%s` SET DATA TYPE %s",
+				dstDatasetTable.table, widenedColumn.Current.Name, widenedBigQueryType))
+			query.DefaultProjectID = c.projectID
+			query.DefaultDatasetID = dstDatasetTable.dataset
+			if _, err := query.Read(ctx); err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Current.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s from data type %s to %s in table %s",
+				widenedColumn.Current.Name, previousBigQueryType, widenedBigQueryType, schemaDelta.DstTableName))
+		}
+	}
+
 	return nil
 }
 
@@ -349,7 +470,7 @@ func (c *BigQueryConnector) SyncRecords(ctx context.Context, req *model.SyncReco
 
 	c.logger.Info(fmt.Sprintf("pushing records to %s.%s...", c.datasetID, rawTableName))
 
-	res, err := c.syncRecordsViaAvro(ctx, req, rawTableName, req.SyncBatchID)
+	res, err := c.syncRecordsViaStorageWrite(ctx, req, rawTableName, req.SyncBatchID)
 	if err != nil {
 		return nil, err
 	}
@@ -358,36 +479,6 @@ func (c *BigQueryConnector) SyncRecords(ctx context.Context, req *model.SyncReco
 	return res, nil
 }
 
-func (c *BigQueryConnector) syncRecordsViaAvro(
-	ctx context.Context,
-	req *model.SyncRecordsRequest[model.RecordItems],
-	rawTableName string,
-	syncBatchID int64,
-) (*model.SyncResponse, error) {
-	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
-	streamReq := model.NewRecordsToStreamRequest(
-		req.Records.GetRecords(), tableNameRowsMapping, syncBatchID, false, protos.DBType_BIGQUERY,
-	)
-	stream, err := utils.RecordsToRawTableStream(streamReq, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
-	}
-
-	avroSync := NewQRepAvroSyncMethod(c, req.StagingPath, req.FlowJobName)
-	rawTableMetadata, err := c.client.DatasetInProject(c.projectID, c.datasetID).Table(rawTableName).Metadata(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get metadata of destination table: %w", err)
-	}
-
-	res, err := avroSync.SyncRecords(ctx, req, rawTableName,
-		rawTableMetadata, syncBatchID, stream, streamReq.TableMapping)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sync records via avro: %w", err)
-	}
-
-	return res, nil
-}
-
 // NormalizeRecords normalizes raw table to destination table,
 // one batch at a time from the previous normalized batch to the currently synced batch.
 func (c *BigQueryConnector) NormalizeRecords(ctx context.Context, req *model.NormalizeRecordsRequest) (model.NormalizeResponse, error) {
@@ -528,16 +619,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 func (c *BigQueryConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
 	rawTableName := c.getRawTableName(req.FlowJobName)
 
-	schema := bigquery.Schema{
-		{Name: "_peerdb_uid", Type: bigquery.StringFieldType},
-		{Name: "_peerdb_timestamp", Type: bigquery.IntegerFieldType},
-		{Name: "_peerdb_destination_table_name", Type: bigquery.StringFieldType},
-		{Name: "_peerdb_data", Type: bigquery.StringFieldType},
-		{Name: "_peerdb_record_type", Type: bigquery.IntegerFieldType},
-		{Name: "_peerdb_match_data", Type: bigquery.StringFieldType},
-		{Name: "_peerdb_batch_id", Type: bigquery.IntegerFieldType},
-		{Name: "_peerdb_unchanged_toast_columns", Type: bigquery.StringFieldType},
-	}
+	schema := rawTableSchema
 
 	// create the table
 	table := c.client.DatasetInProject(c.projectID, c.datasetID).Table(rawTableName)
@@ -693,27 +775,32 @@ func (c *BigQueryConnector) SetupNormalizedTable(
 	// create the table using the columns
 	schema := bigquery.Schema(columns)
 
-	supportedPkeyCols := obtainClusteringColumns(tableSchema)
 	// cluster by the supported primary keys if < 4 columns.
-	numSupportedPkeyCols := len(supportedPkeyCols)
-	var clustering *bigquery.Clustering
-	if numSupportedPkeyCols > 0 && numSupportedPkeyCols < 4 {
-		clustering = &bigquery.Clustering{
-			Fields: supportedPkeyCols,
-		}
+	supportedPkeyCols := obtainClusteringColumns(tableSchema)
+	if len(supportedPkeyCols) >= 4 {
+		supportedPkeyCols = nil
 	}
 
 	timePartitionEnabled, err := internal.PeerDBBigQueryEnableSyncedAtPartitioning(ctx, config.Env)
 	if err != nil {
 		return false, fmt.Errorf("failed to get dynamic setting for BigQuery time partitioning: %w", err)
 	}
-	var timePartitioning *bigquery.TimePartitioning
-	if timePartitionEnabled && config.SyncedAtColName != "" {
-		timePartitioning = &bigquery.TimePartitioning{
-			Type:  bigquery.DayPartitioningType,
-			Field: config.SyncedAtColName,
+	var syncedAtPartitionColumn string
+	if timePartitionEnabled {
+		syncedAtPartitionColumn = config.SyncedAtColName
+	}
+
+	var tableMapping *protos.TableMapping
+	for _, tm := range config.TableMappings {
+		if tm.DestinationTableIdentifier == tableIdentifier {
+			tableMapping = tm
+			break
 		}
 	}
+	timePartitioning, clustering, err := tableLayout(tableMapping, schema, syncedAtPartitionColumn, supportedPkeyCols)
+	if err != nil {
+		return false, fmt.Errorf("invalid partitioning or clustering for table %s: %w", tableIdentifier, err)
+	}
 
 	metadata := &bigquery.TableMetadata{
 		Schema:           schema,
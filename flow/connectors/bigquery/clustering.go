@@ -1,6 +1,10 @@
 package connbigquery
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"cloud.google.com/go/bigquery"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -43,3 +47,80 @@ func obtainClusteringColumns(tableSchema *protos.TableSchema) []string {
 	}
 	return supportedPkeyColsForClustering
 }
+
+// Columns in BigQuery which can be used for time-unit column partitioning
+// Reference: https://cloud.google.com/bigquery/docs/partitioned-tables#date_timestamp_partitioned_tables
+var supportedTimePartitioningTypes = map[bigquery.FieldType]struct{}{
+	bigquery.DateFieldType:      {},
+	bigquery.DateTimeFieldType:  {},
+	bigquery.TimestampFieldType: {},
+}
+
+// tableLayout returns the partitioning and clustering of a destination table,
+// options of the table mapping take precedence over partitioning on the synced at column and clustering on the primary key
+func tableLayout(
+	tableMapping *protos.TableMapping,
+	schema bigquery.Schema,
+	defaultPartitionColumn string,
+	defaultClusteringColumns []string,
+) (*bigquery.TimePartitioning, *bigquery.Clustering, error) {
+	partitionColumn := defaultPartitionColumn
+	clusteringColumns := defaultClusteringColumns
+	var partitionExpirationDays *uint32
+	if tableMapping != nil {
+		if tableMapping.TimePartitioningColumn != "" {
+			partitionColumn = tableMapping.TimePartitioningColumn
+		}
+		if len(tableMapping.ClusteringColumns) > 0 {
+			clusteringColumns = tableMapping.ClusteringColumns
+		}
+		partitionExpirationDays = tableMapping.PartitionExpirationDays
+	}
+
+	fieldTypes := make(map[string]bigquery.FieldType, len(schema))
+	for _, field := range schema {
+		fieldTypes[field.Name] = field.Type
+	}
+
+	var timePartitioning *bigquery.TimePartitioning
+	if partitionColumn != "" {
+		fieldType, ok := fieldTypes[partitionColumn]
+		if !ok {
+			return nil, nil, fmt.Errorf("partitioning column %s is not in the table", partitionColumn)
+		}
+		if _, ok := supportedTimePartitioningTypes[fieldType]; !ok {
+			return nil, nil, fmt.Errorf("partitioning column %s has type %s, expected DATE, DATETIME or TIMESTAMP",
+				partitionColumn, fieldType)
+		}
+		timePartitioning = &bigquery.TimePartitioning{
+			Type:  bigquery.DayPartitioningType,
+			Field: partitionColumn,
+		}
+		if partitionExpirationDays != nil {
+			timePartitioning.Expiration = time.Duration(*partitionExpirationDays) * 24 * time.Hour
+		}
+	} else if partitionExpirationDays != nil {
+		return nil, nil, errors.New("partition expiration needs a partitioning column")
+	}
+
+	if len(clusteringColumns) > 4 {
+		return nil, nil, fmt.Errorf("tables can be clustered on at most 4 columns, got %d", len(clusteringColumns))
+	}
+	var clustering *bigquery.Clustering
+	if len(clusteringColumns) > 0 {
+		for _, column := range clusteringColumns {
+			fieldType, ok := fieldTypes[column]
+			if !ok {
+				return nil, nil, fmt.Errorf("clustering column %s is not in the table", column)
+			}
+			if !isSupportedClusteringType(fieldType) {
+				return nil, nil, fmt.Errorf("clustering column %s has type %s, which cannot be clustered on", column, fieldType)
+			}
+		}
+		clustering = &bigquery.Clustering{
+			Fields: clusteringColumns,
+		}
+	}
+
+	return timePartitioning, clustering, nil
+}
@@ -0,0 +1,53 @@
+package connbigquery
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestTableLayout(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType},
+		{Name: "region", Type: bigquery.StringFieldType},
+		{Name: "payload", Type: bigquery.JSONFieldType},
+		{Name: "created_at", Type: bigquery.TimestampFieldType},
+		{Name: "_PEERDB_SYNCED_AT", Type: bigquery.TimestampFieldType},
+	}
+
+	partitioning, clustering, err := tableLayout(nil, schema, "_PEERDB_SYNCED_AT", []string{"id"})
+	require.NoError(t, err)
+	require.Equal(t, &bigquery.TimePartitioning{Type: bigquery.DayPartitioningType, Field: "_PEERDB_SYNCED_AT"}, partitioning)
+	require.Equal(t, []string{"id"}, clustering.Fields)
+
+	expirationDays := uint32(30)
+	partitioning, clustering, err = tableLayout(&protos.TableMapping{
+		TimePartitioningColumn:  "created_at",
+		PartitionExpirationDays: &expirationDays,
+		ClusteringColumns:       []string{"region", "id"},
+	}, schema, "", []string{"id"})
+	require.NoError(t, err)
+	require.Equal(t, "created_at", partitioning.Field)
+	require.Equal(t, 30*24*time.Hour, partitioning.Expiration)
+	require.Equal(t, []string{"region", "id"}, clustering.Fields)
+
+	partitioning, clustering, err = tableLayout(&protos.TableMapping{}, schema, "", nil)
+	require.NoError(t, err)
+	require.Nil(t, partitioning)
+	require.Nil(t, clustering)
+
+	for _, tm := range []*protos.TableMapping{
+		{TimePartitioningColumn: "region"},
+		{TimePartitioningColumn: "missing"},
+		{PartitionExpirationDays: &expirationDays},
+		{ClusteringColumns: []string{"payload"}},
+		{ClusteringColumns: []string{"id", "region", "created_at", "_PEERDB_SYNCED_AT", "id"}},
+	} {
+		_, _, err := tableLayout(tm, schema, "", nil)
+		require.Error(t, err)
+	}
+}
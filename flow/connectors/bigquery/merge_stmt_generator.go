@@ -51,7 +51,8 @@ func (m *mergeStmtGenerator) generateFlattenedCTE(dstTable string, normalizedTab
 				"UNNEST(CAST(JSON_VALUE_ARRAY(_peerdb_data, '$.%s') AS ARRAY<STRING>)) AS element WHERE element IS NOT null) AS `%s`",
 				bqTypeString, column.Name, shortCol)
 		case types.QValueKindGeography, types.QValueKindGeometry, types.QValueKindPoint:
-			castStmt = fmt.Sprintf("CAST(ST_GEOGFROMTEXT(JSON_VALUE(_peerdb_data, '$.%s')) AS %s) AS `%s`",
+			// values are EWKT, which BigQuery doesn't parse with the SRID prefix
+			castStmt = fmt.Sprintf("CAST(ST_GEOGFROMTEXT(REGEXP_REPLACE(JSON_VALUE(_peerdb_data, '$.%s'), r'^SRID=[0-9]+;', '')) AS %s) AS `%s`",
 				column.Name, bqTypeString, shortCol)
 		default:
 			castStmt = fmt.Sprintf("CAST(JSON_VALUE(_peerdb_data, '$.%s') AS %s) AS `%s`",
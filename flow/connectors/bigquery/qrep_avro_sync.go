@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -24,93 +23,24 @@ import (
 type QRepAvroSyncMethod struct {
 	connector   *BigQueryConnector
 	gcsBucket   string
+	gcsPrefix   string
 	flowJobName string
 }
 
-func NewQRepAvroSyncMethod(connector *BigQueryConnector, gcsBucket string,
+// NewQRepAvroSyncMethod stages Avro files at stagingPath, either a bare bucket name or gs://bucket/prefix,
+// or loads them from local files when it is empty
+func NewQRepAvroSyncMethod(connector *BigQueryConnector, stagingPath string,
 	flowJobName string,
 ) *QRepAvroSyncMethod {
+	gcsBucket, gcsPrefix, _ := strings.Cut(strings.TrimPrefix(stagingPath, "gs://"), "/")
 	return &QRepAvroSyncMethod{
 		connector:   connector,
 		gcsBucket:   gcsBucket,
+		gcsPrefix:   strings.Trim(gcsPrefix, "/"),
 		flowJobName: flowJobName,
 	}
 }
 
-func (s *QRepAvroSyncMethod) SyncRecords(
-	ctx context.Context,
-	req *model.SyncRecordsRequest[model.RecordItems],
-	rawTableName string,
-	dstTableMetadata *bigquery.TableMetadata,
-	syncBatchID int64,
-	stream *model.QRecordStream,
-	tableNameRowsMapping map[string]*model.RecordTypeCounts,
-) (*model.SyncResponse, error) {
-	s.connector.logger.Info(
-		fmt.Sprintf("Obtaining Avro schema for destination table %s and sync batch ID %d",
-			rawTableName, syncBatchID))
-
-	// You will need to define your Avro schema as a string
-	avroSchema, err := DefineAvroSchema(rawTableName, dstTableMetadata, "", "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to define Avro schema: %w", err)
-	}
-
-	stagingTable := fmt.Sprintf("%s_%s_staging", rawTableName, strconv.FormatInt(syncBatchID, 10))
-	numRecords, err := s.writeToStage(ctx, req.Env, strconv.FormatInt(syncBatchID, 10), rawTableName, avroSchema,
-		&datasetTable{
-			project: s.connector.projectID,
-			dataset: s.connector.datasetID,
-			table:   stagingTable,
-		}, stream, req.FlowJobName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to push to avro stage: %w", err)
-	}
-
-	bqClient := s.connector.client
-	datasetID := s.connector.datasetID
-	insertStmt := fmt.Sprintf("INSERT INTO `%s` SELECT * FROM `%s`;",
-		rawTableName, stagingTable)
-
-	query := bqClient.Query(insertStmt)
-	query.DefaultDatasetID = s.connector.datasetID
-	query.DefaultProjectID = s.connector.projectID
-	_, err = query.Read(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute statements in a transaction: %w", err)
-	}
-
-	lastCP := req.Records.GetLastCheckpoint()
-	if err := s.connector.FinishBatch(ctx, req.FlowJobName, syncBatchID, lastCP); err != nil {
-		return nil, fmt.Errorf("failed to update metadata: %w", err)
-	}
-
-	// drop the staging table
-	if err := bqClient.DatasetInProject(s.connector.projectID, datasetID).Table(stagingTable).Delete(ctx); err != nil {
-		// just log the error this isn't fatal.
-		s.connector.logger.Warn("failed to delete staging table "+stagingTable,
-			slog.Any("error", err),
-			slog.Int64("syncBatchID", syncBatchID),
-			slog.String("destinationTable", rawTableName))
-	}
-
-	s.connector.logger.Info(fmt.Sprintf("loaded stage into %s.%s", datasetID, rawTableName),
-		slog.String(string(shared.FlowNameKey), req.FlowJobName),
-		slog.String("dstTableName", rawTableName))
-
-	if err := s.connector.ReplayTableSchemaDeltas(ctx, req.Env, req.FlowJobName, req.Records.SchemaDeltas); err != nil {
-		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
-	}
-
-	return &model.SyncResponse{
-		LastSyncedCheckpoint: lastCP,
-		NumRecordsSynced:     numRecords,
-		CurrentSyncBatchID:   syncBatchID,
-		TableNameRowsMapping: tableNameRowsMapping,
-		TableSchemaDeltas:    req.Records.SchemaDeltas,
-	}, nil
-}
-
 func getTransformedColumns(dstSchema *bigquery.Schema, syncedAtCol string, softDeleteCol string) []string {
 	transformedColumns := make([]string, 0, len(*dstSchema))
 	for _, col := range *dstSchema {
@@ -126,7 +56,7 @@ func getTransformedColumns(dstSchema *bigquery.Schema, syncedAtCol string, softD
 		switch col.Type {
 		case bigquery.GeographyFieldType:
 			transformedColumns = append(transformedColumns,
-				fmt.Sprintf("ST_GEOGFROMTEXT(`%s`) AS `%s`", col.Name, col.Name))
+				fmt.Sprintf("ST_GEOGFROMTEXT(REGEXP_REPLACE(`%s`, r'^SRID=[0-9]+;', '')) AS `%s`", col.Name, col.Name))
 		case bigquery.JSONFieldType:
 			transformedColumns = append(transformedColumns,
 				fmt.Sprintf("PARSE_JSON(`%s`,wide_number_mode=>'round') AS `%s`", col.Name, col.Name))
@@ -367,6 +297,9 @@ func (s *QRepAvroSyncMethod) writeToStage(
 	if s.gcsBucket != "" {
 		bucket := s.connector.storageClient.Bucket(s.gcsBucket)
 		avroFilePath := fmt.Sprintf("%s/%s.avro", objectFolder, syncID)
+		if s.gcsPrefix != "" {
+			avroFilePath = s.gcsPrefix + "/" + avroFilePath
+		}
 		obj := bucket.Object(avroFilePath)
 		w := obj.NewWriter(ctx)
 
@@ -425,6 +358,11 @@ func (s *QRepAvroSyncMethod) writeToStage(
 	loader.UseAvroLogicalTypes = true
 	loader.DecimalTargetTypes = []bigquery.DecimalTargetType{bigquery.BigNumericTargetType}
 	loader.WriteDisposition = bigquery.WriteTruncate
+	if limit := s.connector.loadJobLimit; limit != nil {
+		if err := limit.Wait(ctx, 1); err != nil {
+			return 0, err
+		}
+	}
 	job, err := loader.Run(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("failed to run BigQuery load job: %w", err)
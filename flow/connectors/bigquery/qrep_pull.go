@@ -0,0 +1,218 @@
+package connbigquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const fullTablePartitionID = "bigquery-full-table-partition-id"
+
+func (c *BigQueryConnector) GetQRepPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkColumn == "" {
+		// if no watermark column is specified, return a single partition
+		return []*protos.QRepPartition{
+			{
+				PartitionId:        fullTablePartitionID,
+				Range:              nil,
+				FullTablePartition: true,
+			},
+		}, nil
+	}
+
+	if config.NumRowsPerPartition <= 0 {
+		return nil, errors.New("num rows per partition must be greater than 0")
+	}
+
+	watermarkTable, err := c.convertToDatasetTable(config.WatermarkTable)
+	if err != nil {
+		return nil, err
+	}
+	quotedWatermarkColumn := "`" + config.WatermarkColumn + "`"
+
+	whereClause := ""
+	var params []bigquery.QueryParameter
+	if last != nil && last.Range != nil {
+		whereClause = fmt.Sprintf("WHERE %s > @last", quotedWatermarkColumn)
+		switch lastRange := last.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			params = append(params, bigquery.QueryParameter{Name: "last", Value: lastRange.IntRange.End})
+		case *protos.PartitionRange_UintRange:
+			params = append(params, bigquery.QueryParameter{Name: "last", Value: int64(lastRange.UintRange.End)})
+		case *protos.PartitionRange_TimestampRange:
+			params = append(params, bigquery.QueryParameter{Name: "last", Value: lastRange.TimestampRange.End.AsTime()})
+		default:
+			return nil, fmt.Errorf("unsupported partition range type %T", lastRange)
+		}
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM `%s` %s", watermarkTable.string(), whereClause)
+	countRows, err := c.readQuery(ctx, countQuery, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	var countRow []bigquery.Value
+	if err := countRows.Next(&countRow); err != nil {
+		return nil, fmt.Errorf("failed to read total rows: %w", err)
+	}
+	totalRows, ok := countRow[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("unexpected row count type %T", countRow[0])
+	}
+	if totalRows == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return make([]*protos.QRepPartition, 0), nil
+	}
+
+	numRowsPerPartition := int64(config.NumRowsPerPartition)
+	numPartitions := totalRows / numRowsPerPartition
+	if totalRows%numRowsPerPartition != 0 {
+		numPartitions++
+	}
+	c.logger.Info(fmt.Sprintf("total rows: %d, num partitions: %d, num rows per partition: %d",
+		totalRows, numPartitions, numRowsPerPartition))
+
+	partitionsQuery := fmt.Sprintf("SELECT MIN(w), MAX(w) FROM ("+
+		"SELECT %[1]s AS w, NTILE(%[2]d) OVER (ORDER BY %[1]s) AS bucket FROM `%[3]s` %[4]s"+
+		") GROUP BY bucket ORDER BY MIN(w)",
+		quotedWatermarkColumn, numPartitions, watermarkTable.string(), whereClause)
+	c.logger.Info("partitions query", slog.String("query", partitionsQuery))
+	rows, err := c.readQuery(ctx, partitionsQuery, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for partitions: %w", err)
+	}
+
+	partitionHelper := utils.NewPartitionHelper(c.logger)
+	for {
+		var row []bigquery.Value
+		if err := rows.Next(&row); err == iterator.Done {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read partitions: %w", err)
+		}
+		start, end := row[0], row[1]
+		if start, err = watermarkValue(start); err != nil {
+			return nil, err
+		}
+		if end, err = watermarkValue(end); err != nil {
+			return nil, err
+		}
+		if err := partitionHelper.AddPartition(start, end); err != nil {
+			return nil, fmt.Errorf("failed to add partition: %w", err)
+		}
+	}
+
+	return partitionHelper.GetPartitions(), nil
+}
+
+// watermarkValue converts a watermark read from BigQuery into a type partitions can be made of
+func watermarkValue(v bigquery.Value) (any, error) {
+	switch v.(type) {
+	case nil, int64, time.Time:
+		return v, nil
+	default:
+		t, err := bigQueryTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("watermark column must be an integer, timestamp, datetime or date: %w", err)
+		}
+		return t, nil
+	}
+}
+
+func (c *BigQueryConnector) readQuery(
+	ctx context.Context,
+	query string,
+	params []bigquery.QueryParameter,
+) (*bigquery.RowIterator, error) {
+	q := c.client.Query(query)
+	q.DefaultProjectID = c.projectID
+	q.DefaultDatasetID = c.datasetID
+	q.Parameters = params
+	return q.Read(ctx)
+}
+
+func (c *BigQueryConnector) PullQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	query := config.Query
+	var params []bigquery.QueryParameter
+	if !partition.FullTablePartition {
+		var rangeStart, rangeEnd any
+		switch x := partition.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			rangeStart, rangeEnd = x.IntRange.Start, x.IntRange.End
+		case *protos.PartitionRange_UintRange:
+			rangeStart, rangeEnd = int64(x.UintRange.Start), int64(x.UintRange.End)
+		case *protos.PartitionRange_TimestampRange:
+			rangeStart, rangeEnd = x.TimestampRange.Start.AsTime(), x.TimestampRange.End.AsTime()
+		default:
+			return 0, 0, fmt.Errorf("unknown range type: %v", x)
+		}
+		query = strings.NewReplacer("{{.start}}", "@start", "{{.end}}", "@end").Replace(query)
+		params = append(params,
+			bigquery.QueryParameter{Name: "start", Value: rangeStart},
+			bigquery.QueryParameter{Name: "end", Value: rangeEnd},
+		)
+	}
+
+	c.logger.Info("[bigquery] pulling partition", slog.String("query", query), slog.String("partition", partition.PartitionId))
+	rows, err := c.readQuery(ctx, query, params)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query partition: %w", err)
+	}
+
+	start := time.Now()
+	var totalRecords int64
+	var schema types.QRecordSchema
+	for {
+		var row []bigquery.Value
+		err := rows.Next(&row)
+		// schema is only known once the first page is read
+		if schema.Fields == nil {
+			fields := make([]types.QField, 0, len(rows.Schema))
+			for _, field := range rows.Schema {
+				fields = append(fields, BigQueryFieldToQField(field))
+			}
+			schema = types.NewQRecordSchema(fields)
+			stream.SetSchema(schema)
+		}
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return 0, 0, fmt.Errorf("failed to read partition: %w", err)
+		}
+
+		record := make([]types.QValue, 0, len(row))
+		for idx, val := range row {
+			qv, err := qvalueFromBigQuery(schema.Fields[idx].Type, val)
+			if err != nil {
+				return 0, 0, fmt.Errorf("could not convert bigquery value for %s: %w", schema.Fields[idx].Name, err)
+			}
+			record = append(record, qv)
+		}
+		stream.Records <- record
+		totalRecords += 1
+	}
+
+	c.logger.Info("[bigquery] pulled partition", slog.Int64("records", totalRecords), slog.Duration("duration", time.Since(start)))
+	close(stream.Records)
+	return totalRecords, 0, nil
+}
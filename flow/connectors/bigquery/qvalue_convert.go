@@ -2,8 +2,12 @@ package connbigquery
 
 import (
 	"fmt"
+	"math/big"
+	"time"
 
 	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
@@ -117,6 +121,11 @@ func BigQueryTypeToQValueKind(fieldSchema *bigquery.FieldSchema) types.QValueKin
 			return types.QValueKindArrayTimestamp
 		}
 		return types.QValueKindTimestamp
+	case bigquery.DateTimeFieldType:
+		if fieldSchema.Repeated {
+			return types.QValueKindArrayTimestamp
+		}
+		return types.QValueKindTimestamp
 	case bigquery.DateFieldType:
 		if fieldSchema.Repeated {
 			return types.QValueKindArrayDate
@@ -169,3 +178,150 @@ func BigQueryFieldToQField(bqField *bigquery.FieldSchema) types.QField {
 		Nullable:  !bqField.Required,
 	}
 }
+
+// numericScale is the most digits after the decimal point BIGNUMERIC values have
+const numericScale = 38
+
+func civilTimeToDuration(t civil.Time) time.Duration {
+	return time.Duration(t.Hour)*time.Hour +
+		time.Duration(t.Minute)*time.Minute +
+		time.Duration(t.Second)*time.Second +
+		time.Duration(t.Nanosecond)
+}
+
+// bigQueryTime is the time of a TIMESTAMP, DATETIME or DATE value, the latter two in UTC
+func bigQueryTime(val bigquery.Value) (time.Time, error) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, nil
+	case civil.DateTime:
+		return v.In(time.UTC), nil
+	case civil.Date:
+		return v.In(time.UTC), nil
+	default:
+		return time.Time{}, fmt.Errorf("unexpected time type %T", val)
+	}
+}
+
+func bigQueryArray[T any](val bigquery.Value, convert func(bigquery.Value) (T, error)) ([]T, error) {
+	values, ok := val.([]bigquery.Value)
+	if !ok {
+		return nil, fmt.Errorf("unexpected array type %T", val)
+	}
+	arr := make([]T, 0, len(values))
+	for _, v := range values {
+		elem, err := convert(v)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, elem)
+	}
+	return arr, nil
+}
+
+func bigQueryScalar[T any](val bigquery.Value) (T, error) {
+	v, ok := val.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("expected %T, got %T", zero, val)
+	}
+	return v, nil
+}
+
+func bigQueryNumeric(val bigquery.Value) (decimal.Decimal, error) {
+	v, ok := val.(*big.Rat)
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("expected *big.Rat, got %T", val)
+	}
+	return decimal.NewFromBigRat(v, numericScale), nil
+}
+
+// qvalueFromBigQuery converts a value read from BigQuery into a qvalue of kind
+func qvalueFromBigQuery(kind types.QValueKind, val bigquery.Value) (types.QValue, error) {
+	if val == nil {
+		return types.QValueNull(kind), nil
+	}
+
+	var err error
+	var qv types.QValue
+	switch kind {
+	case types.QValueKindBoolean:
+		var v bool
+		v, err = bigQueryScalar[bool](val)
+		qv = types.QValueBoolean{Val: v}
+	case types.QValueKindInt64:
+		var v int64
+		v, err = bigQueryScalar[int64](val)
+		qv = types.QValueInt64{Val: v}
+	case types.QValueKindFloat64:
+		var v float64
+		v, err = bigQueryScalar[float64](val)
+		qv = types.QValueFloat64{Val: v}
+	case types.QValueKindNumeric:
+		var v decimal.Decimal
+		v, err = bigQueryNumeric(val)
+		qv = types.QValueNumeric{Val: v}
+	case types.QValueKindString:
+		var v string
+		v, err = bigQueryScalar[string](val)
+		qv = types.QValueString{Val: v}
+	case types.QValueKindJSON:
+		var v string
+		v, err = bigQueryScalar[string](val)
+		qv = types.QValueJSON{Val: v}
+	case types.QValueKindGeography:
+		var v string
+		v, err = bigQueryScalar[string](val)
+		qv = types.QValueGeography{Val: v}
+	case types.QValueKindBytes:
+		var v []byte
+		v, err = bigQueryScalar[[]byte](val)
+		qv = types.QValueBytes{Val: v}
+	case types.QValueKindTimestamp:
+		var v time.Time
+		v, err = bigQueryTime(val)
+		qv = types.QValueTimestamp{Val: v}
+	case types.QValueKindDate:
+		var v time.Time
+		v, err = bigQueryTime(val)
+		qv = types.QValueDate{Val: v}
+	case types.QValueKindTime:
+		var v civil.Time
+		v, err = bigQueryScalar[civil.Time](val)
+		qv = types.QValueTime{Val: civilTimeToDuration(v)}
+	case types.QValueKindArrayBoolean:
+		var v []bool
+		v, err = bigQueryArray(val, bigQueryScalar[bool])
+		qv = types.QValueArrayBoolean{Val: v}
+	case types.QValueKindArrayInt64:
+		var v []int64
+		v, err = bigQueryArray(val, bigQueryScalar[int64])
+		qv = types.QValueArrayInt64{Val: v}
+	case types.QValueKindArrayFloat64:
+		var v []float64
+		v, err = bigQueryArray(val, bigQueryScalar[float64])
+		qv = types.QValueArrayFloat64{Val: v}
+	case types.QValueKindArrayNumeric:
+		var v []decimal.Decimal
+		v, err = bigQueryArray(val, bigQueryNumeric)
+		qv = types.QValueArrayNumeric{Val: v}
+	case types.QValueKindArrayString:
+		var v []string
+		v, err = bigQueryArray(val, bigQueryScalar[string])
+		qv = types.QValueArrayString{Val: v}
+	case types.QValueKindArrayTimestamp:
+		var v []time.Time
+		v, err = bigQueryArray(val, bigQueryTime)
+		qv = types.QValueArrayTimestamp{Val: v}
+	case types.QValueKindArrayDate:
+		var v []time.Time
+		v, err = bigQueryArray(val, bigQueryTime)
+		qv = types.QValueArrayDate{Val: v}
+	default:
+		return nil, fmt.Errorf("unsupported BigQuery value kind %s", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return qv, nil
+}
@@ -0,0 +1,50 @@
+package connbigquery
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestQValueFromBigQuery(t *testing.T) {
+	qv, err := qvalueFromBigQuery(types.QValueKindInt64, int64(7))
+	require.NoError(t, err)
+	require.Equal(t, types.QValueInt64{Val: 7}, qv)
+
+	qv, err = qvalueFromBigQuery(types.QValueKindTimestamp, civil.DateTime{
+		Date: civil.Date{Year: 2024, Month: time.March, Day: 1},
+		Time: civil.Time{Hour: 12},
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.QValueTimestamp{Val: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}, qv)
+
+	qv, err = qvalueFromBigQuery(types.QValueKindNumeric, big.NewRat(5, 4))
+	require.NoError(t, err)
+	require.Equal(t, "1.25", qv.(types.QValueNumeric).Val.String())
+
+	qv, err = qvalueFromBigQuery(types.QValueKindArrayString, []bigquery.Value{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, types.QValueArrayString{Val: []string{"a", "b"}}, qv)
+
+	qv, err = qvalueFromBigQuery(types.QValueKindString, nil)
+	require.NoError(t, err)
+	require.Equal(t, types.QValueNull(types.QValueKindString), qv)
+
+	_, err = qvalueFromBigQuery(types.QValueKindInt64, "7")
+	require.Error(t, err)
+}
+
+func TestWatermarkValue(t *testing.T) {
+	v, err := watermarkValue(civil.Date{Year: 2024, Month: time.March, Day: 1})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), v)
+
+	_, err = watermarkValue("abc")
+	require.Error(t, err)
+}
@@ -0,0 +1,292 @@
+package connbigquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// rows are appended in requests of about this size
+	storageWriteMaxAppendBytes = 4 << 20
+	// requests to AppendRows are limited to 10MB, a row has to fit in one with room for the rest of the request
+	storageWriteMaxRowBytes = 10<<20 - 64<<10
+)
+
+type rawRowDescriptor struct {
+	message    protoreflect.MessageDescriptor
+	normalized *descriptorpb.DescriptorProto
+}
+
+var getRawRowDescriptor = sync.OnceValues(func() (rawRowDescriptor, error) {
+	storageSchema, err := adapt.BQSchemaToStorageTableSchema(rawTableSchema)
+	if err != nil {
+		return rawRowDescriptor{}, fmt.Errorf("failed to convert raw table schema: %w", err)
+	}
+	descriptor, err := adapt.StorageSchemaToProto2Descriptor(storageSchema, "root")
+	if err != nil {
+		return rawRowDescriptor{}, fmt.Errorf("failed to build raw row descriptor: %w", err)
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return rawRowDescriptor{}, errors.New("raw row descriptor is not a message descriptor")
+	}
+	normalized, err := adapt.NormalizeDescriptor(message)
+	if err != nil {
+		return rawRowDescriptor{}, fmt.Errorf("failed to normalize raw row descriptor: %w", err)
+	}
+	return rawRowDescriptor{message: message, normalized: normalized}, nil
+})
+
+// encodeRawRow serializes a raw table record as the proto message the Storage Write API expects, nulls are left unset
+func encodeRawRow(descriptor protoreflect.MessageDescriptor, fields []types.QField, record []types.QValue) ([]byte, error) {
+	message := dynamicpb.NewMessage(descriptor)
+	for idx, value := range record {
+		field := descriptor.Fields().ByName(protoreflect.Name(fields[idx].Name))
+		if field == nil {
+			return nil, fmt.Errorf("raw table has no column %s", fields[idx].Name)
+		}
+		switch v := value.Value().(type) {
+		case nil:
+		case string:
+			message.Set(field, protoreflect.ValueOfString(v))
+		case int64:
+			message.Set(field, protoreflect.ValueOfInt64(v))
+		default:
+			return nil, fmt.Errorf("unexpected %T for raw table column %s", v, fields[idx].Name)
+		}
+	}
+	return proto.Marshal(message)
+}
+
+// rawRowSizeError rejects rows too large for an AppendRows request, which would fail the whole batch once sent
+func rawRowSizeError(fields []types.QField, record []types.QValue, size int) error {
+	if size <= storageWriteMaxRowBytes {
+		return nil
+	}
+	var table string
+	for idx, field := range fields {
+		if field.Name == "_peerdb_destination_table_name" && idx < len(record) {
+			table, _ = record[idx].Value().(string)
+		}
+	}
+	return fmt.Errorf("row for table %s is %d bytes, over the %d bytes a BigQuery Storage Write API request can hold",
+		table, size, storageWriteMaxRowBytes)
+}
+
+// syncRecordsViaStorageWrite writes a batch to the raw table through a pending write stream of its own,
+// appends carry their row offset so retried appends are not applied twice and the stream is committed at once,
+// so a batch is either fully in the raw table or not at all. The stream is recorded in the catalog before
+// it is committed, a batch whose stream was committed by an earlier attempt that failed before finishing
+// the batch is not written again. Only CDC batches go through the Storage Write API,
+// QRep partitions and initial loads are still staged as Avro files in the GCS bucket of their staging path.
+func (c *BigQueryConnector) syncRecordsViaStorageWrite(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	rawTableName string,
+	syncBatchID int64,
+) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	streamReq := model.NewRecordsToStreamRequest(
+		req.Records.GetRecords(), tableNameRowsMapping, syncBatchID, false, protos.DBType_BIGQUERY,
+	)
+	stream, err := utils.RecordsToRawTableStream(ctx, req.Env, req.FlowJobName, streamReq, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+	}
+	schema, err := stream.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	committed, err := c.isBatchCommitted(ctx, req.FlowJobName, rawTableName, syncBatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	var numRecords int64
+	if committed {
+		for range stream.Records {
+			numRecords += 1
+		}
+		if err := stream.Err(); err != nil {
+			return nil, err
+		}
+		c.logger.Info("batch already in raw table, skipping write", slog.Int64("syncBatchID", syncBatchID))
+	} else if numRecords, err = c.writeRawRows(ctx, req.FlowJobName, rawTableName, syncBatchID, schema, stream); err != nil {
+		return nil, err
+	}
+
+	lastCP := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, syncBatchID, lastCP); err != nil {
+		return nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.Env, req.FlowJobName, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCP,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   syncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+// isBatchCommitted checks whether an earlier attempt committed the write stream it recorded for the batch,
+// only scanning the raw table when BigQuery no longer knows that stream
+func (c *BigQueryConnector) isBatchCommitted(ctx context.Context, flowJobName string, rawTableName string, syncBatchID int64) (bool, error) {
+	streamName, err := c.GetWriteStream(ctx, flowJobName, syncBatchID)
+	if err != nil {
+		return false, err
+	} else if streamName == "" {
+		// no attempt got as far as committing the batch
+		return false, nil
+	}
+	writeStream, err := c.writeClient.GetWriteStream(ctx, &storagepb.GetWriteStreamRequest{
+		Name: streamName,
+		View: storagepb.WriteStreamView_BASIC,
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			c.logger.Warn("write stream of batch not found, checking raw table",
+				slog.Int64("syncBatchID", syncBatchID), slog.String("stream", streamName))
+			return c.isBatchInRawTable(ctx, rawTableName, syncBatchID)
+		}
+		return false, fmt.Errorf("failed to get write stream of batch %d: %w", syncBatchID, err)
+	}
+	return writeStream.GetCommitTime() != nil, nil
+}
+
+func (c *BigQueryConnector) isBatchInRawTable(ctx context.Context, rawTableName string, syncBatchID int64) (bool, error) {
+	query := c.queryWithLogging(fmt.Sprintf(
+		"SELECT EXISTS(SELECT 1 FROM `%s` WHERE _peerdb_batch_id = %d)", rawTableName, syncBatchID))
+	query.DefaultDatasetID = c.datasetID
+	query.DefaultProjectID = c.projectID
+	it, err := query.Read(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if batch %d is in raw table: %w", syncBatchID, err)
+	}
+	var row []bigquery.Value
+	if err := it.Next(&row); err != nil {
+		return false, fmt.Errorf("failed to check if batch %d is in raw table: %w", syncBatchID, err)
+	}
+	exists, ok := row[0].(bool)
+	if !ok {
+		return false, fmt.Errorf("unexpected %T checking if batch %d is in raw table", row[0], syncBatchID)
+	}
+	return exists, nil
+}
+
+func (c *BigQueryConnector) writeRawRows(
+	ctx context.Context,
+	flowJobName string,
+	rawTableName string,
+	syncBatchID int64,
+	schema types.QRecordSchema,
+	stream *model.QRecordStream,
+) (int64, error) {
+	descriptor, err := getRawRowDescriptor()
+	if err != nil {
+		return 0, err
+	}
+	tableParent := managedwriter.TableParentFromParts(c.projectID, c.datasetID, rawTableName)
+	writeStream, err := c.writeClient.NewManagedStream(ctx,
+		managedwriter.WithDestinationTable(tableParent),
+		managedwriter.WithType(managedwriter.PendingStream),
+		managedwriter.WithSchemaDescriptor(descriptor.normalized),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open write stream on %s: %w", rawTableName, err)
+	}
+	defer writeStream.Close()
+
+	var results []*managedwriter.AppendResult
+	var offset int64
+	var rows [][]byte
+	var rowsBytes int
+	appendRows := func() error {
+		result, err := writeStream.AppendRows(ctx, rows, managedwriter.WithOffset(offset))
+		if err != nil {
+			return fmt.Errorf("failed to append rows at offset %d: %w", offset, err)
+		}
+		results = append(results, result)
+		offset += int64(len(rows))
+		rows = nil
+		rowsBytes = 0
+		return nil
+	}
+
+	for record := range stream.Records {
+		row, err := encodeRawRow(descriptor.message, schema.Fields, record)
+		if err != nil {
+			return 0, err
+		}
+		if err := rawRowSizeError(schema.Fields, record, len(row)); err != nil {
+			return 0, err
+		}
+		if len(rows) > 0 && rowsBytes+len(row) > storageWriteMaxAppendBytes {
+			if err := appendRows(); err != nil {
+				return 0, err
+			}
+		}
+		rows = append(rows, row)
+		rowsBytes += len(row)
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if len(rows) > 0 {
+		if err := appendRows(); err != nil {
+			return 0, err
+		}
+	}
+	if offset == 0 {
+		return 0, nil
+	}
+
+	for _, result := range results {
+		if _, err := result.GetResult(ctx); err != nil {
+			return 0, fmt.Errorf("failed to append rows to %s: %w", rawTableName, err)
+		}
+	}
+	if _, err := writeStream.Finalize(ctx); err != nil {
+		return 0, fmt.Errorf("failed to finalize write stream on %s: %w", rawTableName, err)
+	}
+	if err := c.SetWriteStream(ctx, flowJobName, syncBatchID, writeStream.StreamName()); err != nil {
+		return 0, err
+	}
+	resp, err := c.writeClient.BatchCommitWriteStreams(ctx, &storagepb.BatchCommitWriteStreamsRequest{
+		Parent:       tableParent,
+		WriteStreams: []string{writeStream.StreamName()},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to commit write stream on %s: %w", rawTableName, err)
+	}
+	if streamErrors := resp.GetStreamErrors(); len(streamErrors) > 0 {
+		return 0, fmt.Errorf("failed to commit write stream on %s: %s", rawTableName, streamErrors[0].GetErrorMessage())
+	}
+
+	c.logger.Info(fmt.Sprintf("committed %d rows to %s.%s", offset, c.datasetID, rawTableName))
+	return offset, nil
+}
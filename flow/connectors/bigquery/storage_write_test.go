@@ -0,0 +1,48 @@
+package connbigquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestEncodeRawRow(t *testing.T) {
+	descriptor, err := getRawRowDescriptor()
+	require.NoError(t, err)
+
+	fields := []types.QField{
+		{Name: "_peerdb_uid"},
+		{Name: "_peerdb_batch_id"},
+		{Name: "_peerdb_match_data"},
+	}
+	encoded, err := encodeRawRow(descriptor.message, fields, []types.QValue{
+		types.QValueString{Val: "uid"},
+		types.QValueInt64{Val: 42},
+		types.QValueNull(types.QValueKindString),
+	})
+	require.NoError(t, err)
+
+	message := dynamicpb.NewMessage(descriptor.message)
+	require.NoError(t, proto.Unmarshal(encoded, message))
+	columns := descriptor.message.Fields()
+	require.Equal(t, "uid", message.Get(columns.ByName("_peerdb_uid")).String())
+	require.Equal(t, int64(42), message.Get(columns.ByName("_peerdb_batch_id")).Int())
+	require.False(t, message.Has(columns.ByName("_peerdb_match_data")))
+
+	_, err = encodeRawRow(descriptor.message, []types.QField{{Name: "not_a_column"}}, []types.QValue{types.QValueInt64{Val: 1}})
+	require.Error(t, err)
+}
+
+func TestRawRowSizeError(t *testing.T) {
+	fields := []types.QField{{Name: "_peerdb_uid"}, {Name: "_peerdb_destination_table_name"}}
+	record := []types.QValue{types.QValueString{Val: "uid"}, types.QValueString{Val: "dataset.wide"}}
+
+	require.NoError(t, rawRowSizeError(fields, record, 1<<10))
+	require.NoError(t, rawRowSizeError(fields, record, storageWriteMaxRowBytes))
+	err := rawRowSizeError(fields, record, storageWriteMaxRowBytes+1)
+	require.ErrorContains(t, err, "dataset.wide")
+}
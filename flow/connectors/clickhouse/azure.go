@@ -0,0 +1,45 @@
+package connclickhouse
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+)
+
+// azureStage stages avro files on Azure Blob Storage, ClickHouse reads them back with the azureBlobStorage table function
+type azureStage struct {
+	path   *utils.AzureBlobPath
+	creds  utils.PeerAzureCredentials
+	client *azblob.Client
+	url    string
+}
+
+func newAzureStage(config *protos.AzureBlobConfig) (*azureStage, error) {
+	path, err := utils.NewAzureBlobPath(config.Url)
+	if err != nil {
+		return nil, err
+	}
+	creds := utils.NewPeerAzureCredentials(config)
+	if creds.SasToken == "" {
+		// ClickHouse reads staged files itself, so the identity of workers can't be passed on
+		return nil, errors.New("azure stage for ClickHouse requires a SAS token")
+	}
+	client, err := utils.CreateAzureBlobClient(path, creds)
+	if err != nil {
+		return nil, err
+	}
+	return &azureStage{path: path, creds: creds, client: client, url: config.Url}, nil
+}
+
+func (s *azureStage) tableFunction(blobName string) string {
+	connectionString := fmt.Sprintf("BlobEndpoint=%s;SharedAccessSignature=%s", s.path.ServiceURL(), s.creds.SasToken)
+	return fmt.Sprintf("azureBlobStorage(%s,%s,%s,'Avro')",
+		peerdb_clickhouse.QuoteLiteral(connectionString),
+		peerdb_clickhouse.QuoteLiteral(s.path.Container),
+		peerdb_clickhouse.QuoteLiteral(blobName))
+}
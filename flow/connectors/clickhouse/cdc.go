@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	_ "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
@@ -23,6 +24,13 @@ const (
 	dropTableIfExistsSQL  = "DROP TABLE IF EXISTS %s"
 )
 
+// raw tables known to have the checkpoint id column, connectors only live for a batch so this is kept per process
+var rawTablesWithCheckpointID sync.Map
+
+func (c *ClickHouseConnector) rawTableKey(rawTableName string) string {
+	return c.config.Host + "/" + c.config.Database + "/" + rawTableName
+}
+
 // GetRawTableName returns the raw table name for the given table identifier.
 func (c *ClickHouseConnector) GetRawTableName(flowJobName string) string {
 	return "_peerdb_raw_" + shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)
@@ -42,7 +50,7 @@ func (c *ClickHouseConnector) checkIfTableExists(ctx context.Context, databaseNa
 func (c *ClickHouseConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
 	rawTableName := c.GetRawTableName(req.FlowJobName)
 
-	createRawTableSQL := `CREATE TABLE IF NOT EXISTS %s (
+	createRawTableSQL := `CREATE TABLE IF NOT EXISTS %s%s (
 		_peerdb_uid UUID,
 		_peerdb_timestamp Int64,
 		_peerdb_destination_table_name String,
@@ -50,22 +58,36 @@ func (c *ClickHouseConnector) CreateRawTable(ctx context.Context, req *protos.Cr
 		_peerdb_record_type Int,
 		_peerdb_match_data String,
 		_peerdb_batch_id Int64,
-		_peerdb_unchanged_toast_columns String
+		_peerdb_unchanged_toast_columns String,
+		_peerdb_checkpoint_id Int64
 	) ENGINE = MergeTree() ORDER BY (_peerdb_batch_id, _peerdb_destination_table_name);`
 
 	err := c.execWithLogging(ctx,
-		fmt.Sprintf(createRawTableSQL, rawTableName))
+		fmt.Sprintf(createRawTableSQL, c.localTable(rawTableName), c.onCluster()))
 	if err != nil {
 		return nil, fmt.Errorf("unable to create raw table: %w", err)
 	}
+	if c.distributed() {
+		if err := c.createDistributedTable(ctx, rawTableName); err != nil {
+			return nil, fmt.Errorf("unable to create raw table: %w", err)
+		}
+	}
+	rawTablesWithCheckpointID.Store(c.rawTableKey(rawTableName), struct{}{})
 	return &protos.CreateRawTableOutput{
 		TableIdentifier: rawTableName,
 	}, nil
 }
 
+func (c *ClickHouseConnector) stagingPath() string {
+	if c.azureStage != nil {
+		return c.azureStage.url
+	}
+	return c.credsProvider.BucketPath
+}
+
 func (c *ClickHouseConnector) avroSyncMethod(flowJobName string, env map[string]string, version uint32) *ClickHouseAvroSyncMethod {
 	qrepConfig := &protos.QRepConfig{
-		StagingPath:                c.credsProvider.BucketPath,
+		StagingPath:                c.stagingPath(),
 		FlowJobName:                flowJobName,
 		DestinationTableIdentifier: c.GetRawTableName(flowJobName),
 		Env:                        env,
@@ -89,13 +111,18 @@ func (c *ClickHouseConnector) syncRecordsViaAvro(
 		protos.DBType_CLICKHOUSE,
 	)
 	numericTruncator := model.NewStreamNumericTruncator(req.TableMappings, peerdb_clickhouse.NumericDestinationTypes)
-	stream, err := utils.RecordsToRawTableStream(streamReq, numericTruncator)
+	stream, err := utils.RecordsToRawTableStream(ctx, req.Env, req.FlowJobName, streamReq, numericTruncator)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
 	}
 
-	avroSyncer := c.avroSyncMethod(req.FlowJobName, req.Env, req.Version)
-	numRecords, err := avroSyncer.SyncRecords(ctx, req.Env, stream, req.FlowJobName, syncBatchID)
+	var numRecords int64
+	if c.config.NativeInsert {
+		numRecords, err = c.syncRecordsNative(ctx, stream, req.FlowJobName, syncBatchID)
+	} else {
+		avroSyncer := c.avroSyncMethod(req.FlowJobName, req.Env, req.Version)
+		numRecords, err = avroSyncer.SyncRecords(ctx, req.Env, stream, req.FlowJobName, syncBatchID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +142,40 @@ func (c *ClickHouseConnector) syncRecordsViaAvro(
 	}, nil
 }
 
+// syncRecordsNative inserts the batch straight into the raw table, the stage left behind for normalize
+// has no file so that normalize only has to advance past the batch
+func (c *ClickHouseConnector) syncRecordsNative(
+	ctx context.Context,
+	stream *model.QRecordStream,
+	flowJobName string,
+	syncBatchID int64,
+) (int64, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	numRecords, err := c.nativeInsert(ctx, c.GetRawTableName(flowJobName), schema.GetColumnNames(), stream, nil)
+	if err != nil {
+		return 0, err
+	}
+	c.logger.Info("[SyncRecords] inserted records into raw table",
+		slog.Int64("numRecords", numRecords),
+		slog.Int64("syncBatchID", syncBatchID))
+
+	if err := SetAvroStage(ctx, flowJobName, syncBatchID, utils.AvroFile{NumRecords: numRecords}); err != nil {
+		return 0, fmt.Errorf("failed to set avro stage: %w", err)
+	}
+	return numRecords, nil
+}
+
 func (c *ClickHouseConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	if err := c.removePartialBatch(ctx, req.FlowJobName, req.SyncBatchID); err != nil {
+		return nil, err
+	}
+	if err := c.addRawCheckpointIDColumn(ctx, req.FlowJobName); err != nil {
+		return nil, err
+	}
+
 	res, err := c.syncRecordsViaAvro(ctx, req, req.SyncBatchID)
 	if err != nil {
 		return nil, err
@@ -129,6 +189,32 @@ func (c *ClickHouseConnector) SyncRecords(ctx context.Context, req *model.SyncRe
 	return res, nil
 }
 
+// addRawCheckpointIDColumn adds the checkpoint id to raw tables created before it was synced,
+// their earlier rows keep 0 and are left alone by DeduplicateBatches. Checked once per raw table
+func (c *ClickHouseConnector) addRawCheckpointIDColumn(ctx context.Context, flowJobName string) error {
+	rawTableName := c.GetRawTableName(flowJobName)
+	key := c.rawTableKey(rawTableName)
+	if _, ok := rawTablesWithCheckpointID.Load(key); ok {
+		return nil
+	}
+	var exists uint8
+	if err := c.queryRow(ctx, fmt.Sprintf(
+		"SELECT count() > 0 FROM system.columns WHERE database = currentDatabase() AND table = %s AND name = %s",
+		peerdb_clickhouse.QuoteLiteral(rawTableName), peerdb_clickhouse.QuoteLiteral(utils.RawTableCheckpointIDColumn)),
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check raw table for %s: %w", utils.RawTableCheckpointIDColumn, err)
+	}
+	if exists == 0 {
+		if err := c.alterTable(ctx, rawTableName, fmt.Sprintf("ADD COLUMN IF NOT EXISTS %s Int64",
+			peerdb_clickhouse.QuoteIdentifier(utils.RawTableCheckpointIDColumn)),
+		); err != nil {
+			return fmt.Errorf("failed to add %s to raw table: %w", utils.RawTableCheckpointIDColumn, err)
+		}
+	}
+	rawTablesWithCheckpointID.Store(key, struct{}{})
+	return nil
+}
+
 func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 	ctx context.Context,
 	env map[string]string,
@@ -139,8 +225,13 @@ func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 		return nil
 	}
 
+	preserveColumnOrder, err := internal.PeerDBPreserveColumnOrder(ctx, env)
+	if err != nil {
+		return err
+	}
+
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil || (len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.WidenedColumns) == 0) {
 			continue
 		}
 
@@ -152,10 +243,18 @@ func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 			if err != nil {
 				return fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", addedColumn.Type, err)
 			}
-			if err := c.execWithLogging(ctx,
-				fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s",
-					peerdb_clickhouse.QuoteIdentifier(schemaDelta.DstTableName),
-					peerdb_clickhouse.QuoteIdentifier(addedColumn.Name), clickHouseColType),
+			// otherwise columns go after PeerDB columns
+			var position string
+			if after, ok := schemaDelta.AddedColumnsAfter[addedColumn.Name]; ok && preserveColumnOrder {
+				if after == "" {
+					position = " FIRST"
+				} else {
+					position = " AFTER " + peerdb_clickhouse.QuoteIdentifier(after)
+				}
+			}
+			if err := c.alterTable(ctx, schemaDelta.DstTableName,
+				fmt.Sprintf("ADD COLUMN IF NOT EXISTS %s %s%s",
+					peerdb_clickhouse.QuoteIdentifier(addedColumn.Name), clickHouseColType, position),
 			); err != nil {
 				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name, schemaDelta.DstTableName, err)
 			}
@@ -164,6 +263,37 @@ func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 				"destination table name", schemaDelta.DstTableName,
 				"source table name", schemaDelta.SrcTableName)
 		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			previousColType, err := qvalue.ToDWHColumnType(
+				ctx, types.QValueKind(widenedColumn.Previous.Type), env, protos.DBType_CLICKHOUSE,
+				widenedColumn.Previous, schemaDelta.NullableEnabled,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", widenedColumn.Previous.Type, err)
+			}
+			clickHouseColType, err := qvalue.ToDWHColumnType(
+				ctx, types.QValueKind(widenedColumn.Current.Type), env, protos.DBType_CLICKHOUSE,
+				widenedColumn.Current, schemaDelta.NullableEnabled,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", widenedColumn.Current.Type, err)
+			}
+			if clickHouseColType == previousColType {
+				continue
+			}
+			if err := c.alterTable(ctx, schemaDelta.DstTableName,
+				fmt.Sprintf("MODIFY COLUMN %s %s",
+					peerdb_clickhouse.QuoteIdentifier(widenedColumn.Current.Name), clickHouseColType),
+			); err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Current.Name, schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(
+				fmt.Sprintf("[schema delta replay] widened column %s from data type %s to %s",
+					widenedColumn.Current.Name, previousColType, clickHouseColType),
+				"destination table name", schemaDelta.DstTableName,
+				"source table name", schemaDelta.SrcTableName)
+		}
 	}
 
 	return nil
@@ -201,13 +331,13 @@ func (c *ClickHouseConnector) RenameTables(
 			// which supports a special query to exchange two tables, allowing dependent (materialized) views and dictionaries on these tables
 			c.logger.Info("attempting atomic exchange",
 				slog.String("OldName", renameRequest.CurrentName), slog.String("NewName", renameRequest.NewName))
+			// distributed tables keep pointing at the local tables by name, so only the local tables are swapped
 			if err = c.execWithLogging(ctx,
-				fmt.Sprintf("EXCHANGE TABLES %s and %s",
-					peerdb_clickhouse.QuoteIdentifier(renameRequest.NewName), peerdb_clickhouse.QuoteIdentifier(renameRequest.CurrentName)),
+				fmt.Sprintf("EXCHANGE TABLES %s and %s%s",
+					peerdb_clickhouse.QuoteIdentifier(c.localTable(renameRequest.NewName)),
+					peerdb_clickhouse.QuoteIdentifier(c.localTable(renameRequest.CurrentName)), c.onCluster()),
 			); err == nil {
-				if err := c.execWithLogging(ctx,
-					fmt.Sprintf(dropTableIfExistsSQL, peerdb_clickhouse.QuoteIdentifier(renameRequest.CurrentName)),
-				); err != nil {
+				if err := c.dropTable(ctx, renameRequest.CurrentName); err != nil {
 					return nil, fmt.Errorf("unable to drop exchanged table %s: %w", renameRequest.CurrentName, err)
 				}
 			} else if ex, ok := err.(*clickhouse.Exception); !ok || ex.Code != 48 {
@@ -220,18 +350,24 @@ func (c *ClickHouseConnector) RenameTables(
 		// either original table doesn't exist, in which case it is safe to just run rename,
 		// or err is set (in which case err comes from EXCHANGE TABLES)
 		if !originalTableExists || err != nil {
-			if err := c.execWithLogging(ctx,
-				fmt.Sprintf(dropTableIfExistsSQL, peerdb_clickhouse.QuoteIdentifier(renameRequest.NewName)),
-			); err != nil {
+			if err := c.dropTable(ctx, renameRequest.NewName); err != nil {
 				return nil, fmt.Errorf("unable to drop table %s: %w", renameRequest.NewName, err)
 			}
 
-			if err := c.execWithLogging(ctx, fmt.Sprintf("RENAME TABLE %s TO %s",
-				peerdb_clickhouse.QuoteIdentifier(renameRequest.CurrentName),
-				peerdb_clickhouse.QuoteIdentifier(renameRequest.NewName),
+			if err := c.execWithLogging(ctx, fmt.Sprintf("RENAME TABLE %s TO %s%s",
+				peerdb_clickhouse.QuoteIdentifier(c.localTable(renameRequest.CurrentName)),
+				peerdb_clickhouse.QuoteIdentifier(c.localTable(renameRequest.NewName)), c.onCluster(),
 			)); err != nil {
 				return nil, fmt.Errorf("unable to rename table %s to %s: %w", renameRequest.CurrentName, renameRequest.NewName, err)
 			}
+			if c.distributed() {
+				if err := c.createDistributedTable(ctx, renameRequest.NewName); err != nil {
+					return nil, err
+				}
+				if err := c.dropTable(ctx, renameRequest.CurrentName); err != nil {
+					return nil, fmt.Errorf("unable to drop table %s: %w", renameRequest.CurrentName, err)
+				}
+			}
 		}
 
 		c.logger.Info("successfully renamed table",
@@ -246,9 +382,10 @@ func (c *ClickHouseConnector) RenameTables(
 func (c *ClickHouseConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
 	// delete raw table if exists
 	rawTableIdentifier := c.GetRawTableName(jobName)
-	if err := c.execWithLogging(ctx, fmt.Sprintf(dropTableIfExistsSQL, peerdb_clickhouse.QuoteIdentifier(rawTableIdentifier))); err != nil {
+	if err := c.dropTable(ctx, rawTableIdentifier); err != nil {
 		return fmt.Errorf("[clickhouse] unable to drop raw table: %w", err)
 	}
+	rawTablesWithCheckpointID.Delete(c.rawTableKey(rawTableIdentifier))
 	c.logger.Info("successfully dropped raw table " + rawTableIdentifier)
 
 	return nil
@@ -262,9 +399,9 @@ func (c *ClickHouseConnector) RemoveTableEntriesFromRawTable(
 		// Better to use lightweight deletes here as the main goal is to
 		// not have the rows in the table be visible by the NormalizeRecords'
 		// INSERT INTO SELECT queries
-		if err := c.execWithLogging(ctx, fmt.Sprintf("DELETE FROM `%s` WHERE _peerdb_destination_table_name = %s"+
-			" AND _peerdb_batch_id > %d AND _peerdb_batch_id <= %d",
-			c.GetRawTableName(req.FlowJobName), peerdb_clickhouse.QuoteLiteral(tableName), req.NormalizeBatchId, req.SyncBatchId),
+		if err := c.deleteFrom(ctx, c.GetRawTableName(req.FlowJobName), fmt.Sprintf(
+			"_peerdb_destination_table_name = %s AND _peerdb_batch_id > %d AND _peerdb_batch_id <= %d",
+			peerdb_clickhouse.QuoteLiteral(tableName), req.NormalizeBatchId, req.SyncBatchId),
 		); err != nil {
 			return fmt.Errorf("unable to remove table %s from raw table: %w", tableName, err)
 		}
@@ -3,10 +3,10 @@ package connclickhouse
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/url"
 	"slices"
 	"strings"
@@ -17,6 +17,7 @@ import (
 	chproto "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 
 	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -24,7 +25,6 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	chvalidate "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
-	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 type ClickHouseConnector struct {
@@ -33,6 +33,7 @@ type ClickHouseConnector struct {
 	logger        log.Logger
 	config        *protos.ClickhouseConfig
 	credsProvider *utils.ClickHouseS3Credentials
+	azureStage    *azureStage
 }
 
 func NewClickHouseConnector(
@@ -52,6 +53,25 @@ func NewClickHouseConnector(
 		return nil, err
 	}
 
+	connector := &ClickHouseConnector{
+		database:         database,
+		PostgresMetadata: pgMetadata,
+		config:           config,
+		logger:           logger,
+	}
+	// native inserts don't stage anything, an S3 stage is only set up when one is configured explicitly
+	if config.NativeInsert && config.S3 == nil && config.S3Path == "" && config.AzureBlob == nil {
+		return connector, nil
+	}
+	if config.AzureBlob != nil {
+		stage, err := newAzureStage(config.AzureBlob)
+		if err != nil {
+			return nil, err
+		}
+		connector.azureStage = stage
+		return connector, nil
+	}
+
 	var awsConfig utils.PeerAWSCredentials
 	var awsBucketPath string
 	if config.S3 != nil {
@@ -73,6 +93,10 @@ func NewClickHouseConnector(
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := credentialsProvider.(*utils.GcsCredentialsProvider); ok {
+		// ClickHouse reads staged files itself through its s3 table function, which signs requests with keys
+		return nil, errors.New("GCS stage for ClickHouse requires HMAC keys")
+	}
 
 	if awsBucketPath == "" {
 		deploymentUID := internal.PeerDBDeploymentUID()
@@ -95,15 +119,9 @@ func NewClickHouseConnector(
 		return nil, err
 	}
 
-	connector := &ClickHouseConnector{
-		database:         database,
-		PostgresMetadata: pgMetadata,
-		config:           config,
-		logger:           logger,
-		credsProvider: &utils.ClickHouseS3Credentials{
-			Provider:   credentialsProvider,
-			BucketPath: awsBucketPath,
-		},
+	connector.credsProvider = &utils.ClickHouseS3Credentials{
+		Provider:   credentialsProvider,
+		BucketPath: awsBucketPath,
 	}
 
 	if credentials.AWS.SessionToken != "" {
@@ -159,82 +177,124 @@ func ValidateClickHouseHost(ctx context.Context, chHost string, allowedDomainStr
 
 // Performs some checks on the ClickHouse peer to ensure it will work for mirrors
 func (c *ClickHouseConnector) ValidateCheck(ctx context.Context) error {
+	if err := c.validateHost(ctx); err != nil {
+		return err
+	}
+
+	validateDummyTableName, err := c.createValidationTable(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.dropValidationTable(validateDummyTableName)
+
+	if err := c.insertValidationRow(ctx, validateDummyTableName); err != nil {
+		return err
+	}
+
+	// drop the table
+	if err := c.exec(ctx, "DROP TABLE IF EXISTS "+validateDummyTableName+c.onCluster()); err != nil {
+		return fmt.Errorf("failed to drop validation table %s: %w", validateDummyTableName, err)
+	}
+
+	return c.validateStage(ctx)
+}
+
+func (c *ClickHouseConnector) validateHost(ctx context.Context) error {
 	// validate clickhouse host
 	allowedDomains := internal.PeerDBClickHouseAllowedDomains()
 	if err := ValidateClickHouseHost(ctx, c.config.Host, allowedDomains); err != nil {
 		return err
 	}
+	if c.config.Distributed && c.config.Cluster == "" {
+		return errors.New("distributed tables require a cluster")
+	}
+	if c.config.Cluster != "" {
+		var numHosts uint64
+		if err := c.queryRow(ctx,
+			"SELECT count() FROM system.clusters WHERE cluster = "+chvalidate.QuoteLiteral(c.config.Cluster),
+		).Scan(&numHosts); err != nil {
+			return fmt.Errorf("failed to look up cluster %s: %w", c.config.Cluster, err)
+		} else if numHosts == 0 {
+			return fmt.Errorf("cluster %s not found in system.clusters", c.config.Cluster)
+		}
+	}
+	return nil
+}
+
+// createValidationTable creates, alters and renames a table the way mirrors do, returning the name it ends up with
+func (c *ClickHouseConnector) createValidationTable(ctx context.Context) (string, error) {
 	validateDummyTableName := "peerdb_validation_" + shared.RandomString(4)
 	// create a table
 	if err := c.exec(ctx,
-		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id UInt64) ENGINE = ReplacingMergeTree ORDER BY id;`, validateDummyTableName),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s (id UInt64) ENGINE = ReplacingMergeTree ORDER BY id;`,
+			validateDummyTableName, c.onCluster()),
 	); err != nil {
-		return fmt.Errorf("failed to create validation table %s: %w", validateDummyTableName, err)
+		return "", fmt.Errorf("failed to create validation table %s: %w", validateDummyTableName, err)
 	}
-	defer func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		defer cancel()
-		if err := c.exec(ctx, "DROP TABLE IF EXISTS "+validateDummyTableName); err != nil {
-			c.logger.Error("validation failed to drop table", slog.String("table", validateDummyTableName), slog.Any("error", err))
-		}
-	}()
 
 	// add a column
 	if err := c.exec(ctx,
-		fmt.Sprintf("ALTER TABLE %s ADD COLUMN updated_at DateTime64(9) DEFAULT now64()", validateDummyTableName),
+		fmt.Sprintf("ALTER TABLE %s%s ADD COLUMN updated_at DateTime64(9) DEFAULT now64()", validateDummyTableName, c.onCluster()),
 	); err != nil {
-		return fmt.Errorf("failed to add column to validation table %s: %w", validateDummyTableName, err)
+		c.dropValidationTable(validateDummyTableName)
+		return "", fmt.Errorf("failed to add column to validation table %s: %w", validateDummyTableName, err)
 	}
 
 	// rename the table
 	if err := c.exec(ctx,
-		fmt.Sprintf("RENAME TABLE %s TO %s", validateDummyTableName, validateDummyTableName+"_renamed"),
+		fmt.Sprintf("RENAME TABLE %s TO %s%s", validateDummyTableName, validateDummyTableName+"_renamed", c.onCluster()),
 	); err != nil {
-		return fmt.Errorf("failed to rename validation table %s: %w", validateDummyTableName, err)
+		c.dropValidationTable(validateDummyTableName)
+		return "", fmt.Errorf("failed to rename validation table %s: %w", validateDummyTableName, err)
 	}
-	validateDummyTableName += "_renamed"
+	return validateDummyTableName + "_renamed", nil
+}
 
-	// insert a row
+func (c *ClickHouseConnector) insertValidationRow(ctx context.Context, validateDummyTableName string) error {
 	if err := c.exec(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1, now64())", validateDummyTableName)); err != nil {
 		return fmt.Errorf("failed to insert into validation table %s: %w", validateDummyTableName, err)
 	}
+	return nil
+}
 
-	// drop the table
-	if err := c.exec(ctx, "DROP TABLE IF EXISTS "+validateDummyTableName); err != nil {
-		return fmt.Errorf("failed to drop validation table %s: %w", validateDummyTableName, err)
+func (c *ClickHouseConnector) dropValidationTable(validateDummyTableName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := c.exec(ctx, "DROP TABLE IF EXISTS "+validateDummyTableName+c.onCluster()); err != nil {
+		c.logger.Error("validation failed to drop table", slog.String("table", validateDummyTableName), slog.Any("error", err))
 	}
+}
 
-	// validate s3 stage
-	if err := ValidateS3(ctx, c.credsProvider); err != nil {
-		return fmt.Errorf("failed to validate S3 bucket: %w", err)
+func (c *ClickHouseConnector) validateStage(ctx context.Context) error {
+	if c.azureStage != nil {
+		if err := utils.PutAndRemoveAzureBlob(ctx, c.azureStage.client, c.azureStage.path); err != nil {
+			return fmt.Errorf("failed to validate Azure container: %w", err)
+		}
+	}
+	if c.credsProvider != nil {
+		if err := ValidateS3(ctx, c.credsProvider); err != nil {
+			return fmt.Errorf("failed to validate S3 bucket: %w", err)
+		}
 	}
-
 	return nil
 }
 
 func Connect(ctx context.Context, env map[string]string, config *protos.ClickhouseConfig) (clickhouse.Conn, error) {
 	var tlsSetting *tls.Config
 	if !config.DisableTls {
-		tlsSetting = &tls.Config{MinVersion: tls.VersionTLS13}
-		if config.Certificate != nil || config.PrivateKey != nil {
-			if config.Certificate == nil || config.PrivateKey == nil {
-				return nil, errors.New("both certificate and private key must be provided if using certificate-based authentication")
-			}
-			cert, err := tls.X509KeyPair([]byte(*config.Certificate), []byte(*config.PrivateKey))
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse provided certificate: %w", err)
-			}
-			tlsSetting.Certificates = []tls.Certificate{cert}
+		// certificates of the peer predate its tls block, which overrides them
+		peerTls := &protos.TlsConfig{
+			Certificate: config.Certificate,
+			PrivateKey:  config.PrivateKey,
+			RootCa:      config.RootCa,
+			ServerName:  config.TlsHost,
 		}
-		if config.RootCa != nil {
-			caPool := x509.NewCertPool()
-			if !caPool.AppendCertsFromPEM([]byte(*config.RootCa)) {
-				return nil, errors.New("failed to parse provided root CA")
-			}
-			tlsSetting.RootCAs = caPool
+		if config.Tls != nil {
+			proto.Merge(peerTls, config.Tls)
 		}
-		if config.TlsHost != "" {
-			tlsSetting.ServerName = config.TlsHost
+		var err error
+		if tlsSetting, err = utils.PeerTlsConfig(peerTls, tls.VersionTLS13, nil, config.Host, "", false); err != nil {
+			return nil, err
 		}
 	}
 
@@ -246,14 +306,35 @@ func Connect(ctx context.Context, env map[string]string, config *protos.Clickhou
 		// avoid "there is no metadata of table ..."
 		"alter_sync": uint64(1),
 	}
+	if config.Cluster != "" && config.Distributed {
+		// rows have to reach the shards before a batch counts as synced or normalized
+		settings["insert_distributed_sync"] = uint64(1)
+	}
 	if maxInsertThreads, err := internal.PeerDBClickHouseMaxInsertThreads(ctx, env); err != nil {
 		return nil, fmt.Errorf("failed to load max_insert_threads config: %w", err)
 	} else if maxInsertThreads != 0 {
 		settings["max_insert_threads"] = maxInsertThreads
 	}
 
+	tunnel, err := utils.NewSSHTunnel(ctx, config.SshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ssh tunnel: %w", err)
+	}
+	var dialContext func(ctx context.Context, addr string) (net.Conn, error)
+	if tunnel.Client != nil {
+		// the driver only applies TLS to connections it dials itself
+		dialContext = func(ctx context.Context, addr string) (net.Conn, error) {
+			conn, err := tunnel.DialContext(ctx, "tcp", addr)
+			if err != nil || tlsSetting == nil {
+				return conn, err
+			}
+			return tls.Client(conn, tlsSetting), nil
+		}
+	}
+
 	conn, err := clickhouse.Open(&clickhouse.Options{
-		Addr: []string{shared.JoinHostPort(config.Host, config.Port)},
+		Addr:        []string{shared.JoinHostPort(config.Host, config.Port)},
+		DialContext: dialContext,
 		Auth: clickhouse.Auth{
 			Database: config.Database,
 			Username: config.User,
@@ -274,8 +355,12 @@ func Connect(ctx context.Context, env map[string]string, config *protos.Clickhou
 		ReadTimeout: 3600 * time.Second,
 	})
 	if err != nil {
+		tunnel.Close()
 		return nil, fmt.Errorf("failed to connect to ClickHouse peer: %w", err)
 	}
+	if tunnel.Client != nil {
+		conn = &tunneledConn{Conn: conn, tunnel: tunnel}
+	}
 
 	if err := conn.Ping(ctx); err != nil {
 		conn.Close()
@@ -285,6 +370,16 @@ func Connect(ctx context.Context, env map[string]string, config *protos.Clickhou
 	return conn, nil
 }
 
+// tunneledConn closes the SSH tunnel of its connections along with them
+type tunneledConn struct {
+	clickhouse.Conn
+	tunnel utils.SSHTunnel
+}
+
+func (c *tunneledConn) Close() error {
+	return errors.Join(c.Conn.Close(), c.tunnel.Close())
+}
+
 func (c *ClickHouseConnector) exec(ctx context.Context, query string) error {
 	return chvalidate.Exec(ctx, c.logger, c.database, query)
 }
@@ -376,60 +471,9 @@ func GetTableSchemaForTable(tm *protos.TableMapping, columns []driver.ColumnType
 			continue
 		}
 
-		var qkind types.QValueKind
-		switch column.DatabaseTypeName() {
-		case "String", "Nullable(String)", "LowCardinality(String)", "LowCardinality(Nullable(String))":
-			qkind = types.QValueKindString
-		case "Bool", "Nullable(Bool)":
-			qkind = types.QValueKindBoolean
-		case "Int8", "Nullable(Int8)":
-			qkind = types.QValueKindInt8
-		case "Int16", "Nullable(Int16)":
-			qkind = types.QValueKindInt16
-		case "Int32", "Nullable(Int32)":
-			qkind = types.QValueKindInt32
-		case "Int64", "Nullable(Int64)":
-			qkind = types.QValueKindInt64
-		case "UInt8", "Nullable(UInt8)":
-			qkind = types.QValueKindUInt8
-		case "UInt16", "Nullable(UInt16)":
-			qkind = types.QValueKindUInt16
-		case "UInt32", "Nullable(UInt32)":
-			qkind = types.QValueKindUInt32
-		case "UInt64", "Nullable(UInt64)":
-			qkind = types.QValueKindUInt64
-		case "UUID", "Nullable(UUID)":
-			qkind = types.QValueKindUUID
-		case "DateTime64(6)", "Nullable(DateTime64(6))", "DateTime64(9)", "Nullable(DateTime64(9))":
-			qkind = types.QValueKindTimestamp
-		case "Date32", "Nullable(Date32)":
-			qkind = types.QValueKindDate
-		case "Float32", "Nullable(Float32)":
-			qkind = types.QValueKindFloat32
-		case "Float64", "Nullable(Float64)":
-			qkind = types.QValueKindFloat64
-		case "Array(Int32)":
-			qkind = types.QValueKindArrayInt32
-		case "Array(Float32)":
-			qkind = types.QValueKindArrayFloat32
-		case "Array(Float64)":
-			qkind = types.QValueKindArrayFloat64
-		case "Array(String)", "Array(LowCardinality(String))":
-			qkind = types.QValueKindArrayString
-		case "Array(UUID)":
-			qkind = types.QValueKindArrayUUID
-		case "Array(DateTime64(6))":
-			qkind = types.QValueKindArrayTimestamp
-		default:
-			if strings.Contains(column.DatabaseTypeName(), "Decimal") {
-				if strings.HasPrefix(column.DatabaseTypeName(), "Array(") {
-					qkind = types.QValueKindArrayNumeric
-				} else {
-					qkind = types.QValueKindNumeric
-				}
-			} else {
-				return nil, fmt.Errorf("failed to resolve QValueKind for %s", column.DatabaseTypeName())
-			}
+		qkind, err := qkindFromClickHouseType(column.DatabaseTypeName())
+		if err != nil {
+			return nil, err
 		}
 
 		colFields = append(colFields, &protos.FieldDescription{
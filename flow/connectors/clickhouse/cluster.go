@@ -0,0 +1,89 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+)
+
+const localTableSuffix = "_local"
+
+// onCluster is the clause making DDL run on every node of the configured cluster
+func (c *ClickHouseConnector) onCluster() string {
+	if c.config.Cluster == "" {
+		return ""
+	}
+	return " ON CLUSTER " + peerdb_clickhouse.QuoteIdentifier(c.config.Cluster)
+}
+
+func (c *ClickHouseConnector) distributed() bool {
+	return c.config.Cluster != "" && c.config.Distributed
+}
+
+// localTable is the table storing the rows of table, with distributed tables table itself only routes to it
+func (c *ClickHouseConnector) localTable(table string) string {
+	if c.distributed() {
+		return table + localTableSuffix
+	}
+	return table
+}
+
+// createDistributedTable (re)creates table as a Distributed table over its local tables.
+// Rows are sharded by the sorting key of the local table, so rows that the engine merges end up on the same shard
+func (c *ClickHouseConnector) createDistributedTable(ctx context.Context, table string) error {
+	localTable := c.localTable(table)
+	var sortingKey string
+	if err := c.queryRow(ctx, fmt.Sprintf(
+		"SELECT sorting_key FROM system.tables WHERE database = currentDatabase() AND name = %s",
+		peerdb_clickhouse.QuoteLiteral(localTable)),
+	).Scan(&sortingKey); err != nil {
+		return fmt.Errorf("failed to get sorting key of %s: %w", localTable, err)
+	}
+	shardingKey := "rand()"
+	if sortingKey != "" {
+		shardingKey = "cityHash64(" + sortingKey + ")"
+	}
+
+	if err := c.execWithLogging(ctx, fmt.Sprintf("CREATE OR REPLACE TABLE %s%s AS %s ENGINE = Distributed(%s, %s, %s, %s)",
+		peerdb_clickhouse.QuoteIdentifier(table), c.onCluster(), peerdb_clickhouse.QuoteIdentifier(localTable),
+		peerdb_clickhouse.QuoteLiteral(c.config.Cluster), peerdb_clickhouse.QuoteLiteral(c.config.Database),
+		peerdb_clickhouse.QuoteLiteral(localTable), shardingKey),
+	); err != nil {
+		return fmt.Errorf("failed to create distributed table %s: %w", table, err)
+	}
+	return nil
+}
+
+// dropTable drops table everywhere on the cluster, along with its local tables
+func (c *ClickHouseConnector) dropTable(ctx context.Context, table string) error {
+	if err := c.execWithLogging(ctx,
+		fmt.Sprintf(dropTableIfExistsSQL, peerdb_clickhouse.QuoteIdentifier(table))+c.onCluster(),
+	); err != nil {
+		return err
+	}
+	if c.distributed() {
+		return c.execWithLogging(ctx,
+			fmt.Sprintf(dropTableIfExistsSQL, peerdb_clickhouse.QuoteIdentifier(c.localTable(table)))+c.onCluster())
+	}
+	return nil
+}
+
+// alterTable applies alteration to table, and to its local tables when it is distributed
+func (c *ClickHouseConnector) alterTable(ctx context.Context, table string, alteration string) error {
+	if c.distributed() {
+		if err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s%s %s",
+			peerdb_clickhouse.QuoteIdentifier(c.localTable(table)), c.onCluster(), alteration),
+		); err != nil {
+			return err
+		}
+	}
+	return c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s%s %s",
+		peerdb_clickhouse.QuoteIdentifier(table), c.onCluster(), alteration))
+}
+
+// deleteFrom runs a lightweight delete, which has to target local tables as Distributed tables don't support it
+func (c *ClickHouseConnector) deleteFrom(ctx context.Context, table string, condition string) error {
+	return c.execWithLogging(ctx, fmt.Sprintf("DELETE FROM %s%s WHERE %s",
+		peerdb_clickhouse.QuoteIdentifier(c.localTable(table)), c.onCluster(), condition))
+}
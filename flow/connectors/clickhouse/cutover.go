@@ -0,0 +1,50 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+)
+
+// TableRowCountAndChecksum xors a 64-bit hash of every row, so the checksum does not depend on part order.
+// ReplacingMergeTree and CollapsingMergeTree tables are read with FINAL, replacing tables also leave out deleted rows,
+// which ClickHouse always marks in _peerdb_is_deleted whatever softDeleteColName is
+func (c *ClickHouseConnector) TableRowCountAndChecksum(
+	ctx context.Context,
+	table string,
+	columns []string,
+	softDeleteColName string,
+) (int64, string, error) {
+	localTable := c.localTable(table)
+	var engine string
+	if err := c.queryRow(ctx, fmt.Sprintf(
+		"SELECT engine FROM system.tables WHERE database = currentDatabase() AND name = %s",
+		peerdb_clickhouse.QuoteLiteral(localTable)),
+	).Scan(&engine); err != nil {
+		return 0, "", fmt.Errorf("failed to get engine of %s: %w", localTable, err)
+	}
+
+	checksumExpr := "''"
+	if len(columns) > 0 {
+		quotedColumns := make([]string, 0, len(columns))
+		for _, column := range columns {
+			quotedColumns = append(quotedColumns, peerdb_clickhouse.QuoteIdentifier(column))
+		}
+		checksumExpr = fmt.Sprintf("toString(groupBitXor(cityHash64(%s)))", strings.Join(quotedColumns, ","))
+	}
+	query := fmt.Sprintf("SELECT toInt64(count()), %s FROM %s", checksumExpr, peerdb_clickhouse.QuoteIdentifier(table))
+	if strings.HasSuffix(engine, "ReplacingMergeTree") {
+		query += fmt.Sprintf(" FINAL WHERE %s = 0", peerdb_clickhouse.QuoteIdentifier(signColName))
+	} else if strings.HasSuffix(engine, "CollapsingMergeTree") {
+		query += " FINAL"
+	}
+
+	var rowCount int64
+	var checksum string
+	if err := c.queryRow(ctx, query).Scan(&rowCount, &checksum); err != nil {
+		return 0, "", fmt.Errorf("failed to count rows of %s: %w", table, err)
+	}
+	return rowCount, checksum, nil
+}
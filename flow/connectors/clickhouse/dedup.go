@@ -0,0 +1,131 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+)
+
+func isAppendOnlyEngine(engine protos.TableEngine) bool {
+	return engine == protos.TableEngine_CH_ENGINE_MERGE_TREE || engine == protos.TableEngine_CH_ENGINE_REPLICATED_MERGE_TREE ||
+		engine == protos.TableEngine_CH_ENGINE_SHARED_MERGE_TREE
+}
+
+// DeduplicateBatches removes rows that were normalized more than once into MergeTree tables.
+// A change pulled again after an interrupted sync, in the same or a later batch, shows up in the raw table
+// as rows with the same primary key and source position (_peerdb_checkpoint_id) but different _peerdb_timestamp,
+// while the same row changed again has another position, even with an identical payload.
+// Normalization carries _peerdb_timestamp over as _peerdb_version, so for every such group
+// all but the earliest version are deleted from the destination table and from the raw table.
+// Rows without a source position, from sources other than Postgres or synced before it was recorded, are left alone.
+// ReplacingMergeTree tables collapse these on their own and are left untouched.
+func (c *ClickHouseConnector) DeduplicateBatches(
+	ctx context.Context,
+	req *model.DeduplicateBatchesRequest,
+) (int64, error) {
+	normBatchID, err := c.GetLastNormalizeBatchID(ctx, req.FlowJobName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last normalize batch id: %w", err)
+	}
+	endBatchID := req.EndBatchID
+	if endBatchID == 0 {
+		endBatchID = normBatchID
+	}
+	startBatchID := req.StartBatchID
+	if startBatchID == 0 {
+		startBatchID = endBatchID
+	}
+	if startBatchID <= 0 || endBatchID < startBatchID {
+		return 0, fmt.Errorf("invalid batch range [%d, %d]", startBatchID, endBatchID)
+	}
+	if endBatchID > normBatchID {
+		return 0, fmt.Errorf("cannot deduplicate batch %d, last normalized batch is %d", endBatchID, normBatchID)
+	}
+
+	rawTbl := peerdb_clickhouse.QuoteIdentifier(c.GetRawTableName(req.FlowJobName))
+	var numRemoved int64
+	for _, tm := range req.TableMappings {
+		if !isAppendOnlyEngine(tm.Engine) {
+			continue
+		}
+
+		dstTbl := tm.DestinationTableIdentifier
+		duplicateVersions := fmt.Sprintf(
+			"SELECT arrayJoin(arrayPopFront(arraySort(groupArray(_peerdb_timestamp)))) FROM %s"+
+				" WHERE _peerdb_batch_id >= %d AND _peerdb_batch_id <= %d AND _peerdb_destination_table_name = %s"+
+				" AND _peerdb_checkpoint_id != 0 GROUP BY %s HAVING count() > 1",
+			rawTbl, startBatchID, endBatchID, peerdb_clickhouse.QuoteLiteral(dstTbl),
+			duplicateKey(req.TableNameSchemaMapping[dstTbl]))
+		// updates changing the primary key also emit a delete of the old row at _peerdb_timestamp - 1
+		dstFilter := fmt.Sprintf("%[1]s IN (%[3]s) OR (%[2]s = 1 AND %[1]s + 1 IN (%[3]s))",
+			peerdb_clickhouse.QuoteIdentifier(versionColName), peerdb_clickhouse.QuoteIdentifier(signColName), duplicateVersions)
+
+		var dstCount uint64
+		if err := c.queryRow(ctx, fmt.Sprintf("SELECT count() FROM %s WHERE %s",
+			peerdb_clickhouse.QuoteIdentifier(dstTbl), dstFilter),
+		).Scan(&dstCount); err != nil {
+			return 0, fmt.Errorf("failed to count duplicate rows in %s: %w", dstTbl, err)
+		}
+		if dstCount == 0 {
+			continue
+		}
+
+		c.logger.Info("[clickhouse] removing duplicate rows",
+			slog.String("table", dstTbl), slog.Uint64("rows", dstCount),
+			slog.Int64("startBatchID", startBatchID), slog.Int64("endBatchID", endBatchID))
+		if err := c.deleteFrom(ctx, dstTbl, dstFilter); err != nil {
+			return 0, fmt.Errorf("failed to remove duplicate rows from %s: %w", dstTbl, err)
+		}
+		// drop the duplicates from the raw table too, so that replaying these batches doesn't bring them back
+		if err := c.deleteFrom(ctx, c.GetRawTableName(req.FlowJobName), fmt.Sprintf(
+			"_peerdb_batch_id >= %[1]d AND _peerdb_batch_id <= %[2]d"+
+				" AND _peerdb_destination_table_name = %[3]s AND _peerdb_timestamp IN (%[4]s)",
+			startBatchID, endBatchID, peerdb_clickhouse.QuoteLiteral(dstTbl), duplicateVersions),
+		); err != nil {
+			return 0, fmt.Errorf("failed to remove duplicate rows of %s from raw table: %w", dstTbl, err)
+		}
+		numRemoved += int64(dstCount)
+	}
+
+	return numRemoved, nil
+}
+
+// duplicateKey groups the raw rows of a change by its source position and the primary key of the row,
+// tables without a primary key are keyed by the whole row
+func duplicateKey(schema *protos.TableSchema) string {
+	key := []string{"_peerdb_checkpoint_id"}
+	for _, column := range schema.GetPrimaryKeyColumns() {
+		key = append(key, "JSONExtractRaw(_peerdb_data, "+peerdb_clickhouse.QuoteLiteral(column)+")")
+	}
+	if len(key) == 1 {
+		key = append(key, "_peerdb_data")
+	}
+	return strings.Join(key, ", ")
+}
+
+// removePartialBatch clears raw table rows left behind by an earlier attempt at syncing this batch.
+// Such an attempt did not get to FinishBatch, so none of its rows have been normalized yet.
+func (c *ClickHouseConnector) removePartialBatch(ctx context.Context, flowJobName string, syncBatchID int64) error {
+	rawTbl := peerdb_clickhouse.QuoteIdentifier(c.GetRawTableName(flowJobName))
+	var rawCount uint64
+	if err := c.queryRow(ctx,
+		fmt.Sprintf("SELECT count() FROM %s WHERE _peerdb_batch_id = %d", rawTbl, syncBatchID),
+	).Scan(&rawCount); err != nil {
+		return fmt.Errorf("failed to check raw table for batch %d: %w", syncBatchID, err)
+	}
+	if rawCount == 0 {
+		return nil
+	}
+
+	c.logger.Warn("[clickhouse] raw table has rows from an interrupted sync of this batch, removing them",
+		slog.Int64("batchID", syncBatchID), slog.Uint64("rows", rawCount))
+	if err := c.deleteFrom(ctx, c.GetRawTableName(flowJobName), fmt.Sprintf("_peerdb_batch_id = %d", syncBatchID)); err != nil {
+		return fmt.Errorf("failed to remove rows of interrupted batch %d from raw table: %w", syncBatchID, err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	require.Equal(t, "_peerdb_checkpoint_id, JSONExtractRaw(_peerdb_data, 'id'), JSONExtractRaw(_peerdb_data, 'it\\'s')",
+		duplicateKey(&protos.TableSchema{PrimaryKeyColumns: []string{"id", "it's"}}))
+	require.Equal(t, "_peerdb_checkpoint_id, _peerdb_data", duplicateKey(&protos.TableSchema{}))
+	// schema of a table that is no longer part of the mirror
+	require.Equal(t, "_peerdb_checkpoint_id, _peerdb_data", duplicateKey(nil))
+}
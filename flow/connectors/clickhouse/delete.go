@@ -0,0 +1,82 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+)
+
+const (
+	// deletes only set _peerdb_is_deleted, readers filter them out
+	deleteModeSoft = "soft"
+	// replacing tables are created with _peerdb_is_deleted as their is_deleted column,
+	// so FINAL skips deleted rows and cleanup merges drop them
+	deleteModeIsDeleted = "is_deleted"
+	// deleted rows are removed with a lightweight DELETE after every normalize
+	deleteModeLightweight = "lightweight"
+)
+
+func getDeleteMode(ctx context.Context, env map[string]string) (string, error) {
+	mode, err := internal.PeerDBClickHouseDeleteMode(ctx, env)
+	if err != nil {
+		return "", err
+	}
+	switch mode {
+	case "", deleteModeSoft:
+		return deleteModeSoft, nil
+	case deleteModeIsDeleted, deleteModeLightweight:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown ClickHouse delete mode %q, expected %s, %s or %s",
+			mode, deleteModeSoft, deleteModeIsDeleted, deleteModeLightweight)
+	}
+}
+
+func isReplacingEngine(engine protos.TableEngine) bool {
+	return engine == protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE ||
+		engine == protos.TableEngine_CH_ENGINE_REPLICATED_REPLACING_MERGE_TREE ||
+		engine == protos.TableEngine_CH_ENGINE_SHARED_REPLACING_MERGE_TREE
+}
+
+// applyLightweightDeletes removes every version of the rows in table whose latest version is a delete.
+// Only ReplacingMergeTree tables are touched: append only tables keep deletes as history
+// and CollapsingMergeTree tables cancel deleted rows on their own.
+// Rows are matched on the sorting key, which is what the engine deduplicates by
+func (c *ClickHouseConnector) applyLightweightDeletes(ctx context.Context, table string) error {
+	localTable := c.localTable(table)
+	var engine, sortingKey string
+	if err := c.queryRow(ctx, fmt.Sprintf(
+		"SELECT engine, sorting_key FROM system.tables WHERE database = currentDatabase() AND name = %s",
+		peerdb_clickhouse.QuoteLiteral(localTable)),
+	).Scan(&engine, &sortingKey); err != nil {
+		return fmt.Errorf("failed to get engine of %s: %w", localTable, err)
+	}
+	if !strings.HasSuffix(engine, "ReplacingMergeTree") || sortingKey == "" {
+		return nil
+	}
+
+	quotedTable := peerdb_clickhouse.QuoteIdentifier(localTable)
+	quotedSign := peerdb_clickhouse.QuoteIdentifier(signColName)
+	var numDeleted uint64
+	if err := c.queryRow(ctx,
+		fmt.Sprintf("SELECT count() FROM %s WHERE %s = 1", quotedTable, quotedSign),
+	).Scan(&numDeleted); err != nil {
+		return fmt.Errorf("failed to count deleted rows in %s: %w", table, err)
+	}
+	if numDeleted == 0 {
+		return nil
+	}
+
+	// a key deleted and inserted again has a newer live version, which must survive
+	deletedKeys := fmt.Sprintf(
+		"SELECT %[1]s FROM %[2]s WHERE (%[1]s) IN (SELECT %[1]s FROM %[2]s WHERE %[3]s = 1)"+
+			" GROUP BY %[1]s HAVING argMax(%[3]s, %[4]s) = 1",
+		sortingKey, quotedTable, quotedSign, peerdb_clickhouse.QuoteIdentifier(versionColName))
+	c.logger.Info("[clickhouse] removing deleted rows", slog.String("table", table), slog.Uint64("deletes", numDeleted))
+	return c.deleteFrom(ctx, table, fmt.Sprintf("(%s) IN (%s)", sortingKey, deletedKeys))
+}
@@ -0,0 +1,60 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// geoColumnExpr parses the (E)WKT text expr evaluates to into geo type chType, nulls and empty text become empty geometries
+func geoColumnExpr(expr string, chType string) string {
+	return fmt.Sprintf("if(ifNull(%[1]s, '') = '', defaultValueOfTypeName(%[2]s), %[3]s(replaceRegexpOne(%[1]s, '^SRID=[0-9]+;', '')))",
+		expr, peerdb_clickhouse.QuoteLiteral(chType), peerdb_clickhouse.GeoWKTReader(chType))
+}
+
+// destinationColumnTypes returns the ClickHouse type of every column of table
+func (c *ClickHouseConnector) destinationColumnTypes(ctx context.Context, table string) (map[string]string, error) {
+	rows, err := c.database.Query(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT 0", peerdb_clickhouse.QuoteIdentifier(table)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types of %s: %w", table, err)
+	}
+	defer rows.Close()
+	columnTypes := make(map[string]string)
+	for _, column := range rows.ColumnTypes() {
+		columnTypes[column.Name()] = column.DatabaseTypeName()
+	}
+	return columnTypes, nil
+}
+
+func isGeoField(field types.QField) bool {
+	return field.Type == types.QValueKindGeometry || field.Type == types.QValueKindGeography || field.Type == types.QValueKindPoint
+}
+
+var geoScanTypes = map[reflect.Type]struct{}{
+	reflect.TypeFor[orb.Point]():           {},
+	reflect.TypeFor[orb.LineString]():      {},
+	reflect.TypeFor[orb.Polygon]():         {},
+	reflect.TypeFor[orb.MultiLineString](): {},
+	reflect.TypeFor[orb.MultiPolygon]():    {},
+}
+
+// nativeGeoValue parses the (E)WKT of value for geo columns of the native protocol, nulls and unparsable
+// or mismatched geometries become empty values as geo types cannot be Nullable
+func nativeGeoValue(scanType reflect.Type, value any) (any, bool) {
+	if _, ok := geoScanTypes[scanType]; !ok {
+		return nil, false
+	}
+	if text, ok := value.(string); ok && text != "" {
+		if geometry, err := wkt.Unmarshal(datatypes.StripSRID(text)); err == nil && reflect.TypeOf(geometry) == scanType {
+			return geometry, true
+		}
+	}
+	return reflect.Zero(scanType).Interface(), true
+}
@@ -0,0 +1,37 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+)
+
+// jsonColumnExpr parses the json text expr evaluates to into chType, or returns expr as is when json stays a String
+func jsonColumnExpr(expr string, chType string) string {
+	switch chType {
+	case peerdb_clickhouse.JSONType:
+		// JSON columns only hold objects, nulls and other values become empty objects
+		return fmt.Sprintf("CAST(if(isValidJSON(ifNull(%[1]s, '')) AND JSONType(ifNull(%[1]s, '')) = 'Object', %[1]s, '{}'), 'JSON')",
+			expr)
+	case peerdb_clickhouse.MapStringStringType:
+		// top level string values are unquoted, nested objects and other values keep their json text
+		return fmt.Sprintf("CAST(arrayMap(x -> (x.1, if(JSONType(x.2) = 'String', JSONExtractString(x.2), x.2)),"+
+			" JSONExtractKeysAndValuesRaw(ifNull(%s, ''))), %s)",
+			expr, peerdb_clickhouse.QuoteLiteral(peerdb_clickhouse.MapStringStringType))
+	default:
+		return expr
+	}
+}
+
+// jsonbDestinationType is the ClickHouse type of jsonb column colName, the column setting overriding the mirror wide type
+func jsonbDestinationType(ctx context.Context, env map[string]string, columns []*protos.ColumnSetting, colName string) (string, error) {
+	for _, col := range columns {
+		if col.SourceName == colName && col.DestinationType != "" {
+			return col.DestinationType, nil
+		}
+	}
+	return internal.PeerDBClickHouseJSONBType(ctx, env)
+}
@@ -0,0 +1,151 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// rows buffered by the driver before they are flushed to the server as one block of the insert
+	nativeInsertBlockRows = 1 << 16
+	// rows collected column by column before they are appended to the block,
+	// columns whose values share the type of their destination column are appended as a whole
+	nativeInsertChunkRows = 1 << 12
+)
+
+// nativeInsert appends the rows of the stream to table with a batch insert over the native protocol,
+// columns lists the destination column of every stream field. extra values are appended to every row
+func (c *ClickHouseConnector) nativeInsert(
+	ctx context.Context,
+	table string,
+	columns []string,
+	stream *model.QRecordStream,
+	typeConversions map[string]types.TypeConversion,
+	extra ...any,
+) (int64, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	quotedColumns := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, peerdb_clickhouse.QuoteIdentifier(column))
+	}
+
+	batch, err := c.database.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s(%s)",
+		peerdb_clickhouse.QuoteIdentifier(table), strings.Join(quotedColumns, ",")))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare native insert into %s: %w", table, err)
+	}
+	defer func() {
+		if !batch.IsSent() {
+			if err := batch.Abort(); err != nil {
+				c.logger.Warn("failed to abort native insert", slog.String("table", table), slog.Any("error", err))
+			}
+		}
+	}()
+
+	scanTypes := make([]reflect.Type, 0, len(columns))
+	for _, column := range batch.Columns() {
+		scanTypes = append(scanTypes, column.ScanType())
+	}
+	if len(scanTypes) != len(schema.Fields)+len(extra) {
+		return 0, fmt.Errorf("native insert into %s expects %d columns, got %d",
+			table, len(scanTypes), len(schema.Fields)+len(extra))
+	}
+
+	var numRecords int64
+	chunk := model.NewColumnarBatch(schema, nativeInsertChunkRows)
+	appendChunk := func() error {
+		for idx, field := range schema.Fields {
+			conversion, hasConversion := typeConversions[field.Name]
+			column := batch.Column(idx)
+			if values, ok := chunk.Column(idx); ok && !hasConversion && reflect.TypeOf(values).Elem() == scanTypes[idx] {
+				if err := column.Append(values); err != nil {
+					return fmt.Errorf("failed to append column %s to native insert into %s: %w", field.Name, table, err)
+				}
+				continue
+			}
+			for row := range chunk.Len() {
+				value := chunk.Value(row, idx)
+				if hasConversion {
+					value = conversion.ValueConversion(value)
+				}
+				if err := column.AppendRow(nativeValue(scanTypes[idx], value.Value())); err != nil {
+					return fmt.Errorf("failed to append column %s to native insert into %s: %w", field.Name, table, err)
+				}
+			}
+		}
+		for idx, value := range extra {
+			column := batch.Column(len(schema.Fields) + idx)
+			for range chunk.Len() {
+				if err := column.AppendRow(value); err != nil {
+					return fmt.Errorf("failed to append row to native insert into %s: %w", table, err)
+				}
+			}
+		}
+		chunk.Reset()
+		return nil
+	}
+	for record := range stream.Records {
+		chunk.AppendRow(record)
+		numRecords += 1
+		if chunk.Len() == nativeInsertChunkRows {
+			if err := appendChunk(); err != nil {
+				return 0, err
+			}
+		}
+		if numRecords%nativeInsertBlockRows == 0 {
+			if err := batch.Flush(); err != nil {
+				return 0, fmt.Errorf("failed to flush native insert into %s: %w", table, err)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+
+	if err := appendChunk(); err != nil {
+		return 0, err
+	}
+	if err := batch.Send(); err != nil {
+		return 0, fmt.Errorf("failed to send native insert into %s: %w", table, err)
+	}
+	return numRecords, nil
+}
+
+// nativeValue converts integers and floats to the width of their column, which the driver refuses to do itself
+func nativeValue(scanType reflect.Type, value any) any {
+	if geometry, ok := nativeGeoValue(scanType, value); ok {
+		return geometry
+	}
+	if value == nil {
+		return nil
+	}
+	if scanType.Kind() == reflect.Pointer {
+		scanType = scanType.Elem()
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Type() == scanType || !isNumericKind(rv.Kind()) || !isNumericKind(scanType.Kind()) {
+		return value
+	}
+	return rv.Convert(scanType).Interface()
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
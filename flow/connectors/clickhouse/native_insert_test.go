@@ -0,0 +1,28 @@
+package connclickhouse
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NativeValue(t *testing.T) {
+	var nullableInt32 *int32
+	now := time.Now()
+
+	require.Equal(t, int32(3), nativeValue(reflect.TypeFor[int32](), int64(3)))
+	require.Equal(t, int32(3), nativeValue(reflect.TypeOf(nullableInt32), int64(3)))
+	require.Equal(t, float32(1.5), nativeValue(reflect.TypeFor[float32](), float64(1.5)))
+	require.Equal(t, "3", nativeValue(reflect.TypeFor[int64](), "3"))
+	require.Equal(t, now, nativeValue(reflect.TypeFor[time.Time](), now))
+	require.Nil(t, nativeValue(reflect.TypeFor[int32](), nil))
+
+	require.Equal(t, orb.Point{1.5, 2}, nativeValue(reflect.TypeFor[orb.Point](), "SRID=4326;POINT(1.5 2)"))
+	require.Equal(t, orb.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		nativeValue(reflect.TypeFor[orb.Polygon](), "POLYGON((0 0,1 0,1 1,0 0))"))
+	require.Equal(t, orb.Point{}, nativeValue(reflect.TypeFor[orb.Point](), nil))
+	require.Equal(t, orb.LineString(nil), nativeValue(reflect.TypeFor[orb.LineString](), "POINT(1 2)"))
+}
@@ -26,6 +26,7 @@ const (
 	signColType         = "Int8"
 	versionColName      = "_peerdb_version"
 	versionColType      = "Int64"
+	collapseColName     = "_peerdb_sign"
 	sourceSchemaColName = "_peerdb_source_schema"
 	sourceSchemaColType = "LowCardinality(String)"
 )
@@ -61,6 +62,8 @@ func (c *ClickHouseConnector) SetupNormalizedTable(
 		ctx,
 		config,
 		destinationTableIdentifier,
+		c.localTable(destinationTableIdentifier),
+		c.onCluster(),
 		sourceTableSchema,
 	)
 	if err != nil {
@@ -70,6 +73,11 @@ func (c *ClickHouseConnector) SetupNormalizedTable(
 	if err := c.execWithLogging(ctx, normalizedTableCreateSQL); err != nil {
 		return false, fmt.Errorf("[ch] error while creating destination ClickHouse table: %w", err)
 	}
+	if c.distributed() {
+		if err := c.createDistributedTable(ctx, destinationTableIdentifier); err != nil {
+			return false, fmt.Errorf("[ch] error while creating destination ClickHouse table: %w", err)
+		}
+	}
 	return false, nil
 }
 
@@ -84,6 +92,9 @@ func generateCreateTableSQLForNormalizedTable(
 	ctx context.Context,
 	config *protos.SetupNormalizedTableBatchInput,
 	tableIdentifier string,
+	// table actually created, differs from tableIdentifier when it gets wrapped by a distributed table
+	localTableIdentifier string,
+	onCluster string,
 	tableSchema *protos.TableSchema,
 ) (string, error) {
 	var tableMapping *protos.TableMapping
@@ -103,7 +114,7 @@ func generateCreateTableSQLForNormalizedTable(
 	if !config.IsResync {
 		stmtBuilder.WriteString("IF NOT EXISTS ")
 	}
-	fmt.Fprintf(&stmtBuilder, "%s (", peerdb_clickhouse.QuoteIdentifier(tableIdentifier))
+	fmt.Fprintf(&stmtBuilder, "%s%s (", peerdb_clickhouse.QuoteIdentifier(localTableIdentifier), onCluster)
 
 	colNameMap := make(map[string]string)
 	for _, column := range tableSchema.Columns {
@@ -161,16 +172,30 @@ func generateCreateTableSQLForNormalizedTable(
 	if tableMapping != nil {
 		tmEngine = tableMapping.Engine
 	}
+
+	// replacing engines take the version column, and with is_deleted deletes also get the deleted flag,
+	// which ClickHouse requires to be UInt8
+	replacingParams := peerdb_clickhouse.QuoteIdentifier(versionColName)
+	tableSignColType := signColType
+	deleteMode, err := getDeleteMode(ctx, config.Env)
+	if err != nil {
+		return "", err
+	}
+	if deleteMode == deleteModeIsDeleted && isReplacingEngine(tmEngine) {
+		replacingParams += "," + peerdb_clickhouse.QuoteIdentifier(signColName)
+		tableSignColType = "UInt8"
+	}
+
 	switch tmEngine {
 	case protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE:
-		engine = fmt.Sprintf("ReplacingMergeTree(%s)", peerdb_clickhouse.QuoteIdentifier(versionColName))
+		engine = fmt.Sprintf("ReplacingMergeTree(%s)", replacingParams)
 	case protos.TableEngine_CH_ENGINE_MERGE_TREE:
 		engine = "MergeTree()"
 	case protos.TableEngine_CH_ENGINE_REPLICATED_REPLACING_MERGE_TREE:
 		engine = fmt.Sprintf(
 			"ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/{database}/%s','{replica}',%s)",
 			peerdb_clickhouse.EscapeStr(tableIdentifier),
-			peerdb_clickhouse.QuoteIdentifier(versionColName),
+			replacingParams,
 		)
 	case protos.TableEngine_CH_ENGINE_REPLICATED_MERGE_TREE:
 		engine = fmt.Sprintf(
@@ -179,25 +204,48 @@ func generateCreateTableSQLForNormalizedTable(
 		)
 	case protos.TableEngine_CH_ENGINE_NULL:
 		engine = "Null"
+	case protos.TableEngine_CH_ENGINE_COLLAPSING_MERGE_TREE:
+		engine = fmt.Sprintf("CollapsingMergeTree(%s)", peerdb_clickhouse.QuoteIdentifier(collapseColName))
+	case protos.TableEngine_CH_ENGINE_SHARED_MERGE_TREE:
+		engine = "SharedMergeTree()"
+	case protos.TableEngine_CH_ENGINE_SHARED_REPLACING_MERGE_TREE:
+		engine = fmt.Sprintf("SharedReplacingMergeTree(%s)", replacingParams)
 	}
 
 	// add sign and version columns
-	fmt.Fprintf(&stmtBuilder, "%s %s, %s %s) ENGINE = %s",
-		peerdb_clickhouse.QuoteIdentifier(signColName), signColType, peerdb_clickhouse.QuoteIdentifier(versionColName), versionColType, engine)
-
-	orderByColumns := getOrderedOrderByColumns(tableMapping, tableSchema.PrimaryKeyColumns, colNameMap)
-
-	if sourceSchemaAsDestinationColumn {
-		orderByColumns = append([]string{sourceSchemaColName}, orderByColumns...)
+	fmt.Fprintf(&stmtBuilder, "%s %s, %s %s",
+		peerdb_clickhouse.QuoteIdentifier(signColName), tableSignColType, peerdb_clickhouse.QuoteIdentifier(versionColName), versionColType)
+	if tmEngine == protos.TableEngine_CH_ENGINE_COLLAPSING_MERGE_TREE {
+		// collapsing needs a sign of 1 or -1, derived from the deleted flag so that normalization inserts stay the same
+		fmt.Fprintf(&stmtBuilder, ", %s %s MATERIALIZED 1 - 2 * %s",
+			peerdb_clickhouse.QuoteIdentifier(collapseColName), signColType, peerdb_clickhouse.QuoteIdentifier(signColName))
 	}
+	fmt.Fprintf(&stmtBuilder, ") ENGINE = %s", engine)
 
 	if tmEngine != protos.TableEngine_CH_ENGINE_NULL {
-		if len(orderByColumns) > 0 {
-			orderByStr := strings.Join(orderByColumns, ",")
+		if tableMapping != nil && tableMapping.PartitionBy != "" {
+			fmt.Fprintf(&stmtBuilder, " PARTITION BY %s", tableMapping.PartitionBy)
+		}
 
-			fmt.Fprintf(&stmtBuilder, " PRIMARY KEY (%[1]s) ORDER BY (%[1]s)", orderByStr)
+		if tableMapping != nil && tableMapping.OrderBy != "" {
+			fmt.Fprintf(&stmtBuilder, " ORDER BY (%s)", tableMapping.OrderBy)
 		} else {
-			stmtBuilder.WriteString(" ORDER BY tuple()")
+			orderByColumns := getOrderedOrderByColumns(tableMapping, tableSchema.PrimaryKeyColumns, colNameMap)
+			if sourceSchemaAsDestinationColumn {
+				orderByColumns = append([]string{sourceSchemaColName}, orderByColumns...)
+			}
+
+			if len(orderByColumns) > 0 {
+				orderByStr := strings.Join(orderByColumns, ",")
+
+				fmt.Fprintf(&stmtBuilder, " PRIMARY KEY (%[1]s) ORDER BY (%[1]s)", orderByStr)
+			} else {
+				stmtBuilder.WriteString(" ORDER BY tuple()")
+			}
+		}
+
+		if tableMapping != nil && tableMapping.Ttl != "" {
+			fmt.Fprintf(&stmtBuilder, " TTL %s", tableMapping.Ttl)
 		}
 
 		if nullable, err := internal.PeerDBNullable(ctx, config.Env); err != nil {
@@ -298,6 +346,11 @@ func (c *ClickHouseConnector) NormalizeRecords(
 		return model.NormalizeResponse{}, err
 	}
 
+	deleteMode, err := getDeleteMode(ctx, req.Env)
+	if err != nil {
+		return model.NormalizeResponse{}, err
+	}
+
 	parallelNormalize, err := internal.PeerDBClickHouseParallelNormalize(ctx, req.Env)
 	if err != nil {
 		return model.NormalizeResponse{}, err
@@ -437,6 +490,14 @@ func (c *ClickHouseConnector) NormalizeRecords(
 		return model.NormalizeResponse{}, err
 	}
 
+	if deleteMode == deleteModeLightweight {
+		for _, tbl := range destinationTableNames {
+			if err := c.applyLightweightDeletes(ctx, tbl); err != nil {
+				return model.NormalizeResponse{}, fmt.Errorf("error while deleting rows from %s: %w", tbl, err)
+			}
+		}
+	}
+
 	if err := c.UpdateNormalizeBatchID(ctx, req.FlowJobName, req.SyncBatchID); err != nil {
 		c.logger.Error("[clickhouse] error while updating normalize batch id", slog.Int64("BatchID", req.SyncBatchID), slog.Any("error", err))
 		return model.NormalizeResponse{}, err
@@ -494,13 +555,19 @@ func (c *ClickHouseConnector) copyAvroStageToDestination(
 	env map[string]string,
 	version uint32,
 ) error {
-	avroSyncMethod := c.avroSyncMethod(flowJobName, env, version)
 	avroFile, err := GetAvroStage(ctx, flowJobName, syncBatchID)
 	if err != nil {
 		return fmt.Errorf("failed to get avro stage: %w", err)
 	}
+	if avroFile.FilePath == "" {
+		// batch was inserted into the raw table during sync
+		return nil
+	} else if c.credsProvider == nil && c.azureStage == nil {
+		return fmt.Errorf("batch %d was staged but the peer has no stage configured", syncBatchID)
+	}
 	defer avroFile.Cleanup()
 
+	avroSyncMethod := c.avroSyncMethod(flowJobName, env, version)
 	if err := avroSyncMethod.CopyStageToDestination(ctx, avroFile); err != nil {
 		return fmt.Errorf("failed to copy stage to destination: %w", err)
 	}
@@ -161,6 +161,28 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 					)
 				}
 			}
+		case peerdb_clickhouse.JSONType, peerdb_clickhouse.MapStringStringType:
+			fmt.Fprintf(&projection, "%s AS %s,",
+				jsonColumnExpr(fmt.Sprintf("JSONExtractString(_peerdb_data, %s)", peerdb_clickhouse.QuoteLiteral(colName)), clickHouseType),
+				peerdb_clickhouse.QuoteIdentifier(dstColName),
+			)
+			if t.enablePrimaryUpdate {
+				fmt.Fprintf(&projectionUpdate, "%s AS %s,",
+					jsonColumnExpr(fmt.Sprintf("JSONExtractString(_peerdb_match_data, %s)", peerdb_clickhouse.QuoteLiteral(colName)), clickHouseType),
+					peerdb_clickhouse.QuoteIdentifier(dstColName),
+				)
+			}
+		case "Point", "LineString", "Polygon", "MultiLineString", "MultiPolygon":
+			fmt.Fprintf(&projection, "%s AS %s,",
+				geoColumnExpr(fmt.Sprintf("JSONExtractString(_peerdb_data, %s)", peerdb_clickhouse.QuoteLiteral(colName)), clickHouseType),
+				peerdb_clickhouse.QuoteIdentifier(dstColName),
+			)
+			if t.enablePrimaryUpdate {
+				fmt.Fprintf(&projectionUpdate, "%s AS %s,",
+					geoColumnExpr(fmt.Sprintf("JSONExtractString(_peerdb_match_data, %s)", peerdb_clickhouse.QuoteLiteral(colName)), clickHouseType),
+					peerdb_clickhouse.QuoteIdentifier(dstColName),
+				)
+			}
 		case "Array(DateTime64(6))", "Nullable(Array(DateTime64(6)))":
 			fmt.Fprintf(&projection,
 				`arrayMap(x -> parseDateTime64BestEffortOrNull(trimBoth(x, '"'), 6), JSONExtractArrayRaw(_peerdb_data, %s)) AS %s,`,
@@ -1,11 +1,13 @@
 package connclickhouse
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
@@ -348,3 +350,113 @@ func TestBuildQuery_WithNumParts(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, query, "cityHash64(_peerdb_uid) % 4 = 2")
 }
+
+func TestBuildQuery_WithJSONB(t *testing.T) {
+	tableName := "my_table"
+	tableSchema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "doc", Type: string(types.QValueKindJSONB), Nullable: true},
+			{Name: "attrs", Type: string(types.QValueKindJSONB), Nullable: true},
+		},
+		NullableEnabled: true,
+	}
+	tableMappings := []*protos.TableMapping{
+		{
+			SourceTableIdentifier:      "public.my_table",
+			DestinationTableIdentifier: tableName,
+			Columns: []*protos.ColumnSetting{
+				{SourceName: "attrs", DestinationType: "Map(String, String)"},
+			},
+		},
+	}
+	env := map[string]string{"PEERDB_CLICKHOUSE_JSONB_TYPE": "JSON"}
+
+	g := NewNormalizeQueryGenerator(tableName, 0, map[string]*protos.TableSchema{tableName: tableSchema},
+		tableMappings, 10, 5, 1, false, false, env, "raw_my_table")
+	query, err := g.BuildQuery(t.Context())
+	require.NoError(t, err)
+	require.Contains(t, query, "CAST(if(isValidJSON(ifNull(JSONExtractString(_peerdb_data, 'doc'), ''))")
+	require.Contains(t, query, "'{}'), 'JSON') AS `doc`")
+	require.Contains(t, query, "JSONExtractKeysAndValuesRaw(ifNull(JSONExtractString(_peerdb_data, 'attrs'), ''))),"+
+		" 'Map(String, String)') AS `attrs`")
+
+	colType, err := qvalue.ToDWHColumnType(t.Context(), types.QValueKindJSONB, env, protos.DBType_CLICKHOUSE,
+		tableSchema.Columns[1], true)
+	require.NoError(t, err)
+	require.Equal(t, "JSON", colType)
+	env["PEERDB_CLICKHOUSE_JSONB_TYPE"] = "String"
+	colType, err = qvalue.ToDWHColumnType(t.Context(), types.QValueKindJSONB, env, protos.DBType_CLICKHOUSE,
+		tableSchema.Columns[1], true)
+	require.NoError(t, err)
+	require.Equal(t, "Nullable(String)", colType)
+}
+
+func TestGenerateCreateTableSQL_EngineAndClauses(t *testing.T) {
+	env := map[string]string{
+		"PEERDB_SOURCE_SCHEMA_AS_DESTINATION_COLUMN": "false",
+		"PEERDB_NULLABLE":               "false",
+		"PEERDB_CLICKHOUSE_DELETE_MODE": "soft",
+	}
+	tableSchema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "created_at", Type: string(types.QValueKindTimestamp)},
+		},
+		PrimaryKeyColumns: []string{"id"},
+	}
+	tableMapping := &protos.TableMapping{
+		SourceTableIdentifier:      "public.events",
+		DestinationTableIdentifier: "events",
+		Engine:                     protos.TableEngine_CH_ENGINE_COLLAPSING_MERGE_TREE,
+		OrderBy:                    "toDate(created_at), id",
+		PartitionBy:                "toYYYYMM(created_at)",
+		Ttl:                        "toDateTime(created_at) + INTERVAL 1 YEAR",
+	}
+
+	query, err := generateCreateTableSQLForNormalizedTable(t.Context(), &protos.SetupNormalizedTableBatchInput{
+		TableMappings: []*protos.TableMapping{tableMapping},
+		Env:           env,
+	}, "events", "events", "", tableSchema)
+	require.NoError(t, err)
+	require.Contains(t, query, "`_peerdb_sign` Int8 MATERIALIZED 1 - 2 * `_peerdb_is_deleted`")
+	require.Contains(t, query, "ENGINE = CollapsingMergeTree(`_peerdb_sign`)"+
+		" PARTITION BY toYYYYMM(created_at) ORDER BY (toDate(created_at), id) TTL toDateTime(created_at) + INTERVAL 1 YEAR")
+	require.NotContains(t, query, "PRIMARY KEY")
+
+	tableMapping.Engine = protos.TableEngine_CH_ENGINE_SHARED_REPLACING_MERGE_TREE
+	tableMapping.OrderBy, tableMapping.PartitionBy, tableMapping.Ttl = "", "", ""
+	query, err = generateCreateTableSQLForNormalizedTable(t.Context(), &protos.SetupNormalizedTableBatchInput{
+		TableMappings: []*protos.TableMapping{tableMapping},
+		Env:           env,
+	}, "events", "events", "", tableSchema)
+	require.NoError(t, err)
+	require.Contains(t, query, "ENGINE = SharedReplacingMergeTree(`_peerdb_version`) PRIMARY KEY (`id`) ORDER BY (`id`)")
+	require.NotContains(t, query, "_peerdb_sign")
+
+	tableMapping.Engine = protos.TableEngine_CH_ENGINE_REPLICATED_REPLACING_MERGE_TREE
+	query, err = generateCreateTableSQLForNormalizedTable(t.Context(), &protos.SetupNormalizedTableBatchInput{
+		TableMappings: []*protos.TableMapping{tableMapping},
+		Env:           env,
+	}, "events", "events_local", " ON CLUSTER `main`", tableSchema)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS `events_local` ON CLUSTER `main` ("), query)
+
+	env["PEERDB_CLICKHOUSE_DELETE_MODE"] = "is_deleted"
+	tableMapping.Engine = protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE
+	query, err = generateCreateTableSQLForNormalizedTable(t.Context(), &protos.SetupNormalizedTableBatchInput{
+		TableMappings: []*protos.TableMapping{tableMapping},
+		Env:           env,
+	}, "events", "events", "", tableSchema)
+	require.NoError(t, err)
+	require.Contains(t, query, "`_peerdb_is_deleted` UInt8, `_peerdb_version` Int64")
+	require.Contains(t, query, "ENGINE = ReplacingMergeTree(`_peerdb_version`,`_peerdb_is_deleted`)")
+
+	tableMapping.Engine = protos.TableEngine_CH_ENGINE_MERGE_TREE
+	query, err = generateCreateTableSQLForNormalizedTable(t.Context(), &protos.SetupNormalizedTableBatchInput{
+		TableMappings: []*protos.TableMapping{tableMapping},
+		Env:           env,
+	}, "events", "events", "", tableSchema)
+	require.NoError(t, err)
+	require.Contains(t, query, "`_peerdb_is_deleted` Int8")
+}
@@ -0,0 +1,37 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	chvalidate "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+)
+
+// ProbeThroughput times reading and writing size bytes, writes go to a Memory table dropped right after
+func (c *ClickHouseConnector) ProbeThroughput(ctx context.Context, size int) (time.Duration, time.Duration, error) {
+	start := time.Now()
+	var data string
+	if err := chvalidate.QueryRow(ctx, c.logger, c.database, "SELECT repeat('x', ?)", size).Scan(&data); err != nil {
+		return 0, 0, fmt.Errorf("failed to read probe data: %w", err)
+	}
+	readDuration := time.Since(start)
+
+	table := "peerdb_probe_" + strings.ToLower(shared.RandomString(4))
+	if err := c.exec(ctx, "CREATE TABLE "+table+" (data String) ENGINE = Memory"); err != nil {
+		return readDuration, 0, fmt.Errorf("failed to create probe table: %w", err)
+	}
+	defer func() {
+		if err := c.exec(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			c.logger.Warn("failed to drop probe table", slog.String("table", table), slog.Any("error", err))
+		}
+	}()
+	start = time.Now()
+	if err := chvalidate.Exec(ctx, c.logger, c.database, "INSERT INTO "+table+" VALUES (?)", data); err != nil {
+		return readDuration, 0, fmt.Errorf("failed to write probe data: %w", err)
+	}
+	return readDuration, time.Since(start), nil
+}
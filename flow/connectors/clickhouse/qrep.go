@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 )
 
 func (*ClickHouseConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
@@ -34,11 +37,58 @@ func (c *ClickHouseConnector) SyncQRepRecords(
 
 	c.logger.Info("Called QRep sync function", flowLog)
 
+	if c.config.NativeInsert {
+		return c.syncQRepRecordsNative(ctx, config, partition, stream)
+	}
+
 	avroSync := NewClickHouseAvroSyncMethod(config, c)
 
 	return avroSync.SyncQRepRecords(ctx, config, partition, stream)
 }
 
+func (c *ClickHouseConnector) syncQRepRecordsNative(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+	destTypeConversions := findTypeConversions(schema, config.Columns)
+
+	sourceSchemaAsDestinationColumn, err := internal.PeerDBSourceSchemaAsDestinationColumn(ctx, config.Env)
+	if err != nil {
+		return 0, nil, err
+	}
+	columns := schema.GetColumnNames()
+	var extra []any
+	if sourceSchemaAsDestinationColumn {
+		schemaTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+		if err != nil {
+			return 0, nil, err
+		}
+		columns = append(columns, sourceSchemaColName)
+		extra = append(extra, schemaTable.Schema)
+	}
+
+	numRecords, err := c.nativeInsert(ctx, config.DestinationTableIdentifier, columns, stream, destTypeConversions, extra...)
+	if err != nil {
+		c.logger.Error("failed to insert partition into ClickHouse",
+			slog.String("dstTable", config.DestinationTableIdentifier),
+			slog.Any("error", err))
+		return 0, nil, exceptions.NewQRepSyncError(err, config.DestinationTableIdentifier, c.config.Database)
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		c.logger.Error("Failed to finish QRep partition", slog.Any("error", err))
+		return 0, nil, err
+	}
+	return numRecords, nil, nil
+}
+
 func (c *ClickHouseConnector) ConsolidateQRepPartitions(_ context.Context, config *protos.QRepConfig) error {
 	c.logger.Info("Consolidating partitions noop")
 	return nil
@@ -47,13 +97,17 @@ func (c *ClickHouseConnector) ConsolidateQRepPartitions(_ context.Context, confi
 // CleanupQRepFlow function for clickhouse connector
 func (c *ClickHouseConnector) CleanupQRepFlow(ctx context.Context, config *protos.QRepConfig) error {
 	c.logger.Info("Cleaning up flow job")
-	return c.dropStage(ctx, config.StagingPath, config.FlowJobName)
+	cutoff, err := utils.StagingRetentionCutoff(ctx, config.Env)
+	if err != nil {
+		return err
+	}
+	return c.dropStage(ctx, config.StagingPath, config.FlowJobName, cutoff)
 }
 
-// dropStage drops the stage for the given job.
-func (c *ClickHouseConnector) dropStage(ctx context.Context, stagingPath string, job string) error {
-	// if s3 we need to delete the contents of the bucket
-	if strings.HasPrefix(stagingPath, "s3://") {
+// dropStage drops the stage for the given job, keeping staged files modified after a non-zero cutoff.
+func (c *ClickHouseConnector) dropStage(ctx context.Context, stagingPath string, job string, cutoff time.Time) error {
+	// if s3 or gcs we need to delete the contents of the bucket
+	if (strings.HasPrefix(stagingPath, "s3://") || strings.HasPrefix(stagingPath, "gs://")) && c.credsProvider != nil {
 		s3o, err := utils.NewS3BucketAndPrefix(stagingPath)
 		if err != nil {
 			c.logger.Error("failed to create S3 bucket and prefix", slog.Any("error", err))
@@ -83,6 +137,9 @@ func (c *ClickHouseConnector) dropStage(ctx context.Context, stagingPath string,
 			}
 
 			for _, object := range page.Contents {
+				if !utils.StagedBefore(object.LastModified, cutoff) {
+					continue
+				}
 				_, err = s3svc.DeleteObject(ctx, &s3.DeleteObjectInput{
 					Bucket: aws.String(s3o.Bucket),
 					Key:    object.Key,
@@ -97,6 +154,15 @@ func (c *ClickHouseConnector) dropStage(ctx context.Context, stagingPath string,
 		c.logger.Info("Deleted contents of bucket", slog.String("bucket", s3o.Bucket), slog.String("prefix", prefix))
 	}
 
+	if utils.IsAzureBlobURL(stagingPath) && c.azureStage != nil {
+		prefix := c.azureStage.path.BlobName(job) + "/"
+		if err := utils.DeleteAzureBlobPrefix(ctx, c.azureStage.client, c.azureStage.path.Container, prefix, cutoff); err != nil {
+			c.logger.Error("failed to delete blobs from container", slog.Any("error", err))
+			return fmt.Errorf("failed to delete blobs of stage: %w", err)
+		}
+		c.logger.Info("Deleted blobs of container", slog.String("container", c.azureStage.path.Container), slog.String("prefix", prefix))
+	}
+
 	c.logger.Info("Dropped stage", slog.String("path", stagingPath))
 	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -36,23 +38,31 @@ func NewClickHouseAvroSyncMethod(
 }
 
 func (s *ClickHouseAvroSyncMethod) s3TableFunctionBuilder(ctx context.Context, avroFilePath string) (string, error) {
-	stagingPath := s.credsProvider.BucketPath
+	if s.azureStage != nil {
+		return s.azureStage.tableFunction(avroFilePath), nil
+	}
+	return s.s3TableFunction(ctx, avroFilePath, "Avro")
+}
+
+// s3TableFunction is the s3 table function reading or writing filePath of the S3 stage in format
+func (c *ClickHouseConnector) s3TableFunction(ctx context.Context, filePath string, format string) (string, error) {
+	stagingPath := c.credsProvider.BucketPath
 	s3o, err := utils.NewS3BucketAndPrefix(stagingPath)
 	if err != nil {
 		return "", err
 	}
 
-	endpoint := s.credsProvider.Provider.GetEndpointURL()
-	region := s.credsProvider.Provider.GetRegion()
-	avroFileUrl := utils.FileURLForS3Service(endpoint, region, s3o.Bucket, avroFilePath)
-	creds, err := s.credsProvider.Provider.Retrieve(ctx)
+	endpoint := c.credsProvider.Provider.GetEndpointURL()
+	region := utils.S3BucketRegion(ctx, c.credsProvider.Provider, s3o.Bucket)
+	fileUrl := utils.FileURLForS3Service(endpoint, region, s3o.Bucket, filePath)
+	creds, err := c.credsProvider.Provider.Retrieve(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	var expr strings.Builder
 	expr.WriteString("s3(")
-	expr.WriteString(peerdb_clickhouse.QuoteLiteral(avroFileUrl))
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(fileUrl))
 	expr.WriteByte(',')
 	expr.WriteString(peerdb_clickhouse.QuoteLiteral(creds.AWS.AccessKeyID))
 	expr.WriteByte(',')
@@ -61,10 +71,31 @@ func (s *ClickHouseAvroSyncMethod) s3TableFunctionBuilder(ctx context.Context, a
 		expr.WriteByte(',')
 		expr.WriteString(peerdb_clickhouse.QuoteLiteral(creds.AWS.SessionToken))
 	}
-	expr.WriteString(",'Avro')")
+	expr.WriteString(",")
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(format))
+	if headers := creds.Encryption.S3Headers(); len(headers) > 0 {
+		// ClickHouse needs SSE-C keys to read and write staged objects itself
+		expr.WriteString(",headers(")
+		for i, name := range slices.Sorted(maps.Keys(headers)) {
+			if i > 0 {
+				expr.WriteByte(',')
+			}
+			expr.WriteString(peerdb_clickhouse.QuoteLiteral(name))
+			expr.WriteByte('=')
+			expr.WriteString(peerdb_clickhouse.QuoteLiteral(headers[name]))
+		}
+		expr.WriteByte(')')
+	}
+	expr.WriteString(")")
 	return expr.String(), nil
 }
 
+// legacyRawTableColumns are the columns of raw table files staged before their columns were recorded
+var legacyRawTableColumns = []string{
+	"_peerdb_uid", "_peerdb_timestamp", "_peerdb_destination_table_name", "_peerdb_data",
+	"_peerdb_record_type", "_peerdb_match_data", "_peerdb_batch_id", "_peerdb_unchanged_toast_columns",
+}
+
 func (s *ClickHouseAvroSyncMethod) CopyStageToDestination(ctx context.Context, avroFile utils.AvroFile) error {
 	s3TableFunction, err := s.s3TableFunctionBuilder(ctx, avroFile.FilePath)
 	if err != nil {
@@ -74,8 +105,18 @@ func (s *ClickHouseAvroSyncMethod) CopyStageToDestination(ctx context.Context, a
 		return fmt.Errorf("failed to build S3 table function: %w", err)
 	}
 
-	query := fmt.Sprintf("INSERT INTO %s SELECT * FROM %s",
-		peerdb_clickhouse.QuoteIdentifier(s.config.DestinationTableIdentifier), s3TableFunction)
+	// raw tables gain columns over time, so files are inserted by name rather than by position
+	columns := avroFile.Columns
+	if len(columns) == 0 {
+		columns = legacyRawTableColumns
+	}
+	quotedColumns := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, peerdb_clickhouse.QuoteIdentifier(column))
+	}
+	columnList := strings.Join(quotedColumns, ",")
+	query := fmt.Sprintf("INSERT INTO %s(%s) SELECT %s FROM %s",
+		peerdb_clickhouse.QuoteIdentifier(s.config.DestinationTableIdentifier), columnList, columnList, s3TableFunction)
 	return s.exec(ctx, query)
 }
 
@@ -112,6 +153,7 @@ func (s *ClickHouseAvroSyncMethod) SyncRecords(
 		slog.Int64("numRecords", avroFile.NumRecords),
 		slog.Int64("syncBatchID", syncBatchID))
 
+	avroFile.Columns = schema.GetColumnNames()
 	if err := SetAvroStage(ctx, flowJobName, syncBatchID, avroFile); err != nil {
 		return 0, fmt.Errorf("failed to set avro stage: %w", err)
 	}
@@ -261,9 +303,18 @@ func (s *ClickHouseAvroSyncMethod) pushS3DataToClickHouse(
 		return err
 	}
 
+	// geo columns are staged as WKT, which destination columns of geo types need parsed
+	var destinationTypes map[string]string
+	if slices.ContainsFunc(schema.Fields, isGeoField) {
+		if destinationTypes, err = s.destinationColumnTypes(ctx, config.DestinationTableIdentifier); err != nil {
+			return err
+		}
+	}
+
 	selectedColumnNames := make([]string, 0, len(schema.Fields))
 	insertedColumnNames := make([]string, 0, len(schema.Fields))
-	for _, colName := range schema.GetColumnNames() {
+	for _, field := range schema.Fields {
+		colName := field.Name
 		for _, excludedColumn := range config.Exclude {
 			if colName == excludedColumn {
 				continue
@@ -276,7 +327,18 @@ func (s *ClickHouseAvroSyncMethod) pushS3DataToClickHouse(
 				slog.String("avroFieldName", avroColName))
 			return fmt.Errorf("destination column %s not found in avro schema", colName)
 		}
-		selectedColumnNames = append(selectedColumnNames, peerdb_clickhouse.QuoteIdentifier(avroColName))
+		selector := peerdb_clickhouse.QuoteIdentifier(avroColName)
+		if field.Type == types.QValueKindJSONB {
+			// jsonb is staged as json text, which JSON and Map columns need parsed
+			dstType, err := jsonbDestinationType(ctx, config.Env, config.Columns, colName)
+			if err != nil {
+				return err
+			}
+			selector = jsonColumnExpr(selector, dstType)
+		} else if dstType := destinationTypes[colName]; isGeoField(field) && peerdb_clickhouse.IsGeoDestinationType(dstType) {
+			selector = geoColumnExpr(selector, dstType)
+		}
+		selectedColumnNames = append(selectedColumnNames, selector)
 		insertedColumnNames = append(insertedColumnNames, peerdb_clickhouse.QuoteIdentifier(colName))
 	}
 	if sourceSchemaAsDestinationColumn {
@@ -373,8 +435,19 @@ func (s *ClickHouseAvroSyncMethod) writeToAvroFile(
 	typeConversions map[string]types.TypeConversion,
 	numericTruncator *model.SnapshotTableNumericTruncator,
 ) (utils.AvroFile, error) {
-	stagingPath := s.credsProvider.BucketPath
 	ocfWriter := utils.NewPeerDBOCFWriter(stream, avroSchema, ocf.ZStandard, protos.DBType_CLICKHOUSE)
+	if s.azureStage != nil {
+		blobName := s.azureStage.path.BlobName(flowJobName, identifierForFile+".avro")
+		avroFile, err := ocfWriter.WriteRecordsToAzureBlob(
+			ctx, env, s.azureStage.client, s.azureStage.path.Container, blobName, avroSize, typeConversions, numericTruncator,
+		)
+		if err != nil {
+			return utils.AvroFile{}, fmt.Errorf("failed to write records to Azure Blob Storage: %w", err)
+		}
+		return avroFile, nil
+	}
+
+	stagingPath := s.credsProvider.BucketPath
 	s3o, err := utils.NewS3BucketAndPrefix(stagingPath)
 	if err != nil {
 		return utils.AvroFile{}, fmt.Errorf("failed to parse staging path: %w", err)
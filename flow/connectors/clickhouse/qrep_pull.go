@@ -0,0 +1,263 @@
+package connclickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const fullTablePartitionID = "clickhouse-full-table-partition-id"
+
+func (c *ClickHouseConnector) GetQRepPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkColumn == "" {
+		// if no watermark column is specified, return a single partition
+		return []*protos.QRepPartition{
+			{
+				PartitionId:        fullTablePartitionID,
+				Range:              nil,
+				FullTablePartition: true,
+			},
+		}, nil
+	}
+
+	if config.NumRowsPerPartition <= 0 {
+		return nil, errors.New("num rows per partition must be greater than 0")
+	}
+
+	watermarkTable := peerdb_clickhouse.QuoteIdentifier(config.WatermarkTable)
+	if parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable); err == nil {
+		watermarkTable = peerdb_clickhouse.QuoteIdentifier(parsedWatermarkTable.Schema) + "." +
+			peerdb_clickhouse.QuoteIdentifier(parsedWatermarkTable.Table)
+	}
+	quotedWatermarkColumn := peerdb_clickhouse.QuoteIdentifier(config.WatermarkColumn)
+
+	whereClause := ""
+	var args []any
+	if last != nil && last.Range != nil {
+		whereClause = fmt.Sprintf("WHERE %s > @last", quotedWatermarkColumn)
+		switch lastRange := last.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = append(args, clickhouse.Named("last", lastRange.IntRange.End))
+		case *protos.PartitionRange_UintRange:
+			args = append(args, clickhouse.Named("last", lastRange.UintRange.End))
+		case *protos.PartitionRange_TimestampRange:
+			args = append(args, clickhouse.DateNamed("last", lastRange.TimestampRange.End.AsTime(), clickhouse.NanoSeconds))
+		default:
+			return nil, fmt.Errorf("unsupported partition range type %T", lastRange)
+		}
+	}
+
+	var totalRows uint64
+	countQuery := fmt.Sprintf("SELECT count() FROM %s %s", watermarkTable, whereClause)
+	if err := c.database.QueryRow(ctx, countQuery, args...).Scan(&totalRows); err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	if totalRows == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return make([]*protos.QRepPartition, 0), nil
+	}
+
+	numRowsPerPartition := uint64(config.NumRowsPerPartition)
+	numPartitions := totalRows / numRowsPerPartition
+	if totalRows%numRowsPerPartition != 0 {
+		numPartitions++
+	}
+	c.logger.Info(fmt.Sprintf("total rows: %d, num partitions: %d, num rows per partition: %d",
+		totalRows, numPartitions, numRowsPerPartition))
+
+	partitionsQuery := fmt.Sprintf(`SELECT min(w), max(w) FROM (
+		SELECT %[1]s AS w, ntile(%[2]d) OVER (ORDER BY %[1]s) AS bucket FROM %[3]s %[4]s
+	) GROUP BY bucket ORDER BY min(w)`,
+		quotedWatermarkColumn, numPartitions, watermarkTable, whereClause)
+	c.logger.Info("partitions query", slog.String("query", partitionsQuery))
+	rows, err := c.database.Query(ctx, partitionsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for partitions: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes := rows.ColumnTypes()
+	partitionHelper := utils.NewPartitionHelper(c.logger)
+	for rows.Next() {
+		start := reflect.New(columnTypes[0].ScanType())
+		end := reflect.New(columnTypes[1].ScanType())
+		if err := rows.Scan(start.Interface(), end.Interface()); err != nil {
+			return nil, err
+		}
+		if err := partitionHelper.AddPartition(watermarkValue(start.Elem()), watermarkValue(end.Elem())); err != nil {
+			return nil, fmt.Errorf("failed to add partition: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	return partitionHelper.GetPartitions(), nil
+}
+
+// watermarkValue dereferences watermarks of nullable columns, leaving nil for NULL
+func watermarkValue(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func (c *ClickHouseConnector) PullQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	query := config.Query
+	var args []any
+	if !partition.FullTablePartition {
+		switch x := partition.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = append(args, clickhouse.Named("start", x.IntRange.Start), clickhouse.Named("end", x.IntRange.End))
+		case *protos.PartitionRange_UintRange:
+			args = append(args, clickhouse.Named("start", x.UintRange.Start), clickhouse.Named("end", x.UintRange.End))
+		case *protos.PartitionRange_TimestampRange:
+			args = append(args,
+				clickhouse.DateNamed("start", x.TimestampRange.Start.AsTime(), clickhouse.NanoSeconds),
+				clickhouse.DateNamed("end", x.TimestampRange.End.AsTime(), clickhouse.NanoSeconds),
+			)
+		default:
+			return 0, 0, fmt.Errorf("unknown range type: %v", x)
+		}
+		query = strings.NewReplacer("{{.start}}", "@start", "{{.end}}", "@end").Replace(query)
+	}
+
+	exportViaS3, err := internal.PeerDBClickHouseQRepSourceS3Export(ctx, config.Env)
+	if err != nil {
+		return 0, 0, err
+	}
+	if exportViaS3 && c.credsProvider != nil {
+		return c.pullQRepRecordsViaS3(ctx, config, partition, query, args, stream)
+	}
+
+	c.logger.Info("[clickhouse] pulling partition", slog.String("query", query), slog.String("partition", partition.PartitionId))
+	rows, err := c.database.Query(ctx, query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query partition: %w", err)
+	}
+	return c.streamRows(rows, stream)
+}
+
+// pullQRepRecordsViaS3 has ClickHouse export the partition to the S3 stage and streams the export back,
+// so long partitions aren't bound to the pace of the destination while their query runs
+func (c *ClickHouseConnector) pullQRepRecordsViaS3(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	query string,
+	args []any,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	s3o, err := utils.NewS3BucketAndPrefix(c.credsProvider.BucketPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	exportKey := fmt.Sprintf("%s/%s/%s.native", s3o.Prefix, config.FlowJobName, partition.PartitionId)
+	exportFunction, err := c.s3TableFunction(ctx, exportKey, "Native")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build S3 table function: %w", err)
+	}
+
+	c.logger.Info("[clickhouse] exporting partition to S3",
+		slog.String("query", query), slog.String("partition", partition.PartitionId), slog.String("key", exportKey))
+	if err := c.database.Exec(ctx, fmt.Sprintf("INSERT INTO FUNCTION %s %s SETTINGS s3_truncate_on_insert = 1",
+		exportFunction, query), args...); err != nil {
+		return 0, 0, fmt.Errorf("failed to export partition to S3: %w", err)
+	}
+	defer func() {
+		s3svc, err := utils.CreateS3Client(ctx, c.credsProvider.Provider)
+		if err == nil {
+			_, err = s3svc.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(s3o.Bucket),
+				Key:    aws.String(exportKey),
+			})
+		}
+		if err != nil {
+			c.logger.Warn("failed to delete partition export", slog.String("key", exportKey), slog.Any("error", err))
+		}
+	}()
+
+	rows, err := c.query(ctx, "SELECT * FROM "+exportFunction)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read partition export: %w", err)
+	}
+	return c.streamRows(rows, stream)
+}
+
+func (c *ClickHouseConnector) streamRows(rows driver.Rows, stream *model.QRecordStream) (int64, int64, error) {
+	defer rows.Close()
+
+	columnTypes := rows.ColumnTypes()
+	fields := make([]types.QField, 0, len(columnTypes))
+	for _, column := range columnTypes {
+		qkind, err := qkindFromClickHouseType(column.DatabaseTypeName())
+		if err != nil {
+			return 0, 0, err
+		}
+		fields = append(fields, types.QField{
+			Name:     column.Name(),
+			Type:     qkind,
+			Nullable: column.Nullable(),
+		})
+	}
+	schema := types.NewQRecordSchema(fields)
+	stream.SetSchema(schema)
+
+	start := time.Now()
+	var totalRecords int64
+	values := make([]any, len(columnTypes))
+	for rows.Next() {
+		for idx, column := range columnTypes {
+			values[idx] = reflect.New(column.ScanType()).Interface()
+		}
+		if err := rows.Scan(values...); err != nil {
+			return 0, 0, err
+		}
+		record := make([]types.QValue, 0, len(values))
+		for idx, val := range values {
+			qv, err := qvalueFromClickHouse(schema.Fields[idx].Type, reflect.ValueOf(val).Elem().Interface())
+			if err != nil {
+				return 0, 0, fmt.Errorf("could not convert clickhouse value for %s: %w", schema.Fields[idx].Name, err)
+			}
+			record = append(record, qv)
+		}
+		stream.Records <- record
+		totalRecords += 1
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	c.logger.Info("[clickhouse] pulled partition", slog.Int64("records", totalRecords), slog.Duration("duration", time.Since(start)))
+	close(stream.Records)
+	return totalRecords, 0, nil
+}
@@ -0,0 +1,168 @@
+package connclickhouse
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// unwrapClickHouseType strips Nullable & LowCardinality wrappers off typeName
+func unwrapClickHouseType(typeName string) string {
+	for {
+		if inner, ok := strings.CutPrefix(typeName, "Nullable("); ok {
+			typeName = strings.TrimSuffix(inner, ")")
+		} else if inner, ok := strings.CutPrefix(typeName, "LowCardinality("); ok {
+			typeName = strings.TrimSuffix(inner, ")")
+		} else {
+			return typeName
+		}
+	}
+}
+
+func qkindFromClickHouseType(typeName string) (types.QValueKind, error) {
+	baseType := unwrapClickHouseType(typeName)
+	if elemType, ok := strings.CutPrefix(baseType, "Array("); ok {
+		elemType = unwrapClickHouseType(strings.TrimSuffix(elemType, ")"))
+		switch {
+		case elemType == "Int16":
+			return types.QValueKindArrayInt16, nil
+		case elemType == "Int32":
+			return types.QValueKindArrayInt32, nil
+		case elemType == "Int64":
+			return types.QValueKindArrayInt64, nil
+		case elemType == "Float32":
+			return types.QValueKindArrayFloat32, nil
+		case elemType == "Float64":
+			return types.QValueKindArrayFloat64, nil
+		case elemType == "Bool":
+			return types.QValueKindArrayBoolean, nil
+		case elemType == "String":
+			return types.QValueKindArrayString, nil
+		case elemType == "UUID":
+			return types.QValueKindArrayUUID, nil
+		case elemType == "Date", elemType == "Date32":
+			return types.QValueKindArrayDate, nil
+		case strings.HasPrefix(elemType, "DateTime"):
+			return types.QValueKindArrayTimestamp, nil
+		case strings.HasPrefix(elemType, "Decimal"):
+			return types.QValueKindArrayNumeric, nil
+		}
+		return "", fmt.Errorf("failed to resolve QValueKind for %s", typeName)
+	}
+
+	switch {
+	case baseType == "String", strings.HasPrefix(baseType, "FixedString("):
+		return types.QValueKindString, nil
+	case baseType == "Bool":
+		return types.QValueKindBoolean, nil
+	case baseType == "Int8":
+		return types.QValueKindInt8, nil
+	case baseType == "Int16":
+		return types.QValueKindInt16, nil
+	case baseType == "Int32":
+		return types.QValueKindInt32, nil
+	case baseType == "Int64":
+		return types.QValueKindInt64, nil
+	case baseType == "UInt8":
+		return types.QValueKindUInt8, nil
+	case baseType == "UInt16":
+		return types.QValueKindUInt16, nil
+	case baseType == "UInt32":
+		return types.QValueKindUInt32, nil
+	case baseType == "UInt64":
+		return types.QValueKindUInt64, nil
+	case baseType == "UUID":
+		return types.QValueKindUUID, nil
+	case baseType == "Date", baseType == "Date32":
+		return types.QValueKindDate, nil
+	case strings.HasPrefix(baseType, "DateTime"):
+		return types.QValueKindTimestamp, nil
+	case baseType == "Float32":
+		return types.QValueKindFloat32, nil
+	case baseType == "Float64":
+		return types.QValueKindFloat64, nil
+	case strings.HasPrefix(baseType, "Decimal"):
+		return types.QValueKindNumeric, nil
+	}
+	return "", fmt.Errorf("failed to resolve QValueKind for %s", typeName)
+}
+
+// qvalueFromClickHouse converts a value scanned into the scan type of its column into a qvalue of kind
+func qvalueFromClickHouse(kind types.QValueKind, value any) (types.QValue, error) {
+	// nullable columns scan into pointers
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return types.QValueNull(kind), nil
+		}
+		value = rv.Elem().Interface()
+	}
+	if value == nil {
+		return types.QValueNull(kind), nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return types.QValueString{Val: v}, nil
+	case bool:
+		return types.QValueBoolean{Val: v}, nil
+	case int8:
+		return types.QValueInt8{Val: v}, nil
+	case int16:
+		return types.QValueInt16{Val: v}, nil
+	case int32:
+		return types.QValueInt32{Val: v}, nil
+	case int64:
+		return types.QValueInt64{Val: v}, nil
+	case uint8:
+		return types.QValueUInt8{Val: v}, nil
+	case uint16:
+		return types.QValueUInt16{Val: v}, nil
+	case uint32:
+		return types.QValueUInt32{Val: v}, nil
+	case uint64:
+		return types.QValueUInt64{Val: v}, nil
+	case float32:
+		return types.QValueFloat32{Val: v}, nil
+	case float64:
+		return types.QValueFloat64{Val: v}, nil
+	case uuid.UUID:
+		return types.QValueUUID{Val: v}, nil
+	case decimal.Decimal:
+		return types.QValueNumeric{Val: v}, nil
+	case time.Time:
+		if kind == types.QValueKindDate {
+			return types.QValueDate{Val: v}, nil
+		}
+		return types.QValueTimestamp{Val: v}, nil
+	case []int16:
+		return types.QValueArrayInt16{Val: v}, nil
+	case []int32:
+		return types.QValueArrayInt32{Val: v}, nil
+	case []int64:
+		return types.QValueArrayInt64{Val: v}, nil
+	case []float32:
+		return types.QValueArrayFloat32{Val: v}, nil
+	case []float64:
+		return types.QValueArrayFloat64{Val: v}, nil
+	case []bool:
+		return types.QValueArrayBoolean{Val: v}, nil
+	case []string:
+		return types.QValueArrayString{Val: v}, nil
+	case []uuid.UUID:
+		return types.QValueArrayUUID{Val: v}, nil
+	case []decimal.Decimal:
+		return types.QValueArrayNumeric{Val: v}, nil
+	case []time.Time:
+		if kind == types.QValueKindArrayDate {
+			return types.QValueArrayDate{Val: v}, nil
+		}
+		return types.QValueArrayTimestamp{Val: v}, nil
+	}
+	return nil, fmt.Errorf("unsupported ClickHouse value %T for %s", value, kind)
+}
@@ -0,0 +1,49 @@
+package connclickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestQKindFromClickHouseType(t *testing.T) {
+	for typeName, expected := range map[string]types.QValueKind{
+		"LowCardinality(Nullable(String))": types.QValueKindString,
+		"FixedString(16)":                  types.QValueKindString,
+		"Nullable(UInt64)":                 types.QValueKindUInt64,
+		"DateTime64(3, 'UTC')":             types.QValueKindTimestamp,
+		"Date":                             types.QValueKindDate,
+		"Decimal(38, 6)":                   types.QValueKindNumeric,
+		"Array(Nullable(Int64))":           types.QValueKindArrayInt64,
+		"Array(LowCardinality(String))":    types.QValueKindArrayString,
+	} {
+		qkind, err := qkindFromClickHouseType(typeName)
+		require.NoError(t, err, typeName)
+		require.Equal(t, expected, qkind, typeName)
+	}
+
+	_, err := qkindFromClickHouseType("Map(String, UInt64)")
+	require.Error(t, err)
+}
+
+func TestQValueFromClickHouse(t *testing.T) {
+	qv, err := qvalueFromClickHouse(types.QValueKindUInt32, uint32(7))
+	require.NoError(t, err)
+	require.Equal(t, types.QValueUInt32{Val: 7}, qv)
+
+	var null *string
+	qv, err = qvalueFromClickHouse(types.QValueKindString, null)
+	require.NoError(t, err)
+	require.Equal(t, types.QValueNull(types.QValueKindString), qv)
+
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	qv, err = qvalueFromClickHouse(types.QValueKindDate, &day)
+	require.NoError(t, err)
+	require.Equal(t, types.QValueDate{Val: day}, qv)
+
+	_, err = qvalueFromClickHouse(types.QValueKindJSON, map[string]any{})
+	require.Error(t, err)
+}
@@ -7,11 +7,30 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	chvalidate "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
 )
 
+// PreflightMirrorDestination runs the checks of ValidateCheck, reporting creating a table, inserting into it
+// and accessing the stage separately instead of stopping at the first failure
+func (c *ClickHouseConnector) PreflightMirrorDestination(ctx context.Context, _ *protos.FlowConnectionConfigs) []*protos.PreflightCheck {
+	checks := []*protos.PreflightCheck{utils.PreflightCheckResult("host", c.validateHost(ctx))}
+	var validateDummyTableName string
+	checks = append(checks, utils.PreflightTableChecks(func() error {
+		var err error
+		validateDummyTableName, err = c.createValidationTable(ctx)
+		return err
+	}, func() error {
+		return c.insertValidationRow(ctx, validateDummyTableName)
+	})...)
+	if validateDummyTableName != "" {
+		c.dropValidationTable(validateDummyTableName)
+	}
+	return append(checks, utils.PreflightCheckResult("stage", c.validateStage(ctx)))
+}
+
 func (c *ClickHouseConnector) ValidateMirrorDestination(
 	ctx context.Context,
 	cfg *protos.FlowConnectionConfigs,
@@ -29,7 +48,7 @@ func (c *ClickHouseConnector) ValidateMirrorDestination(
 		peerDBColumns = append(peerDBColumns, strings.ToLower(cfg.SyncedAtColName))
 	}
 	// this is for handling column exclusion, processed schema does that in a step
-	processedMapping := internal.BuildProcessedSchemaMapping(cfg.TableMappings, tableNameSchemaMapping, c.logger)
+	processedMapping := internal.BuildProcessedSchemaMapping(cfg.TableMappings, tableNameSchemaMapping, nil, c.logger)
 	dstTableNames := slices.Collect(maps.Keys(processedMapping))
 
 	// In the case of resync, we don't need to check the content or structure of the original tables;
@@ -5,20 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/alerting"
+	connazureblob "github.com/PeerDB-io/peerdb/flow/connectors/azureblob"
 	connbigquery "github.com/PeerDB-io/peerdb/flow/connectors/bigquery"
 	connclickhouse "github.com/PeerDB-io/peerdb/flow/connectors/clickhouse"
 	connelasticsearch "github.com/PeerDB-io/peerdb/flow/connectors/elasticsearch"
 	conneventhub "github.com/PeerDB-io/peerdb/flow/connectors/eventhub"
 	connkafka "github.com/PeerDB-io/peerdb/flow/connectors/kafka"
+	connkinesis "github.com/PeerDB-io/peerdb/flow/connectors/kinesis"
 	connmysql "github.com/PeerDB-io/peerdb/flow/connectors/mysql"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	connpubsub "github.com/PeerDB-io/peerdb/flow/connectors/pubsub"
 	conns3 "github.com/PeerDB-io/peerdb/flow/connectors/s3"
 	connsnowflake "github.com/PeerDB-io/peerdb/flow/connectors/snowflake"
+	connsqlserver "github.com/PeerDB-io/peerdb/flow/connectors/sqlserver"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -46,12 +50,34 @@ type MirrorSourceValidationConnector interface {
 	ValidateMirrorSource(context.Context, *protos.FlowConnectionConfigs) error
 }
 
+type MirrorSourcePreflightConnector interface {
+	MirrorSourceValidationConnector
+
+	// PreflightMirrorSource runs every source check of a mirror, reporting each of them instead of stopping at the first failure
+	PreflightMirrorSource(context.Context, *protos.FlowConnectionConfigs) []*protos.PreflightCheck
+}
+
 type MirrorDestinationValidationConnector interface {
 	Connector
 
 	ValidateMirrorDestination(context.Context, *protos.FlowConnectionConfigs, map[string]*protos.TableSchema) error
 }
 
+type MirrorDestinationPreflightConnector interface {
+	ValidationConnector
+
+	// PreflightMirrorDestination tests creating a table, inserting into it and accessing the stage a mirror uses,
+	// reporting each of them instead of stopping at the first failure
+	PreflightMirrorDestination(context.Context, *protos.FlowConnectionConfigs) []*protos.PreflightCheck
+}
+
+type PeerProbeConnector interface {
+	Connector
+
+	// ProbeThroughput returns how long reading and writing size bytes took
+	ProbeThroughput(ctx context.Context, size int) (time.Duration, time.Duration, error)
+}
+
 type GetTableSchemaConnector interface {
 	Connector
 
@@ -298,6 +324,38 @@ type RawTableConnector interface {
 	RemoveTableEntriesFromRawTable(context.Context, *protos.RemoveTablesFromRawTableInput) error
 }
 
+type StageReplayConnector interface {
+	Connector
+
+	// ReplayStagedBatches re-applies previously synced batches to the destination tables,
+	// restoring them from retained stages where the raw table no longer has them.
+	ReplayStagedBatches(context.Context, *model.ReplayStagedBatchesRequest) (int64, error)
+}
+
+type DeduplicationConnector interface {
+	Connector
+
+	// DeduplicateBatches removes rows applied more than once to append-only destination tables
+	// for the given batch range, returning the number of rows removed.
+	DeduplicateBatches(context.Context, *model.DeduplicateBatchesRequest) (int64, error)
+}
+
+type CutoverReportConnector interface {
+	Connector
+
+	// TableRowCountAndChecksum counts the live rows of table and fingerprints the given columns independent of row order,
+	// rows marked deleted through softDeleteColName are left out when it is set.
+	TableRowCountAndChecksum(ctx context.Context, table string, columns []string, softDeleteColName string) (int64, string, error)
+}
+
+type PartitionedTablesConnector interface {
+	Connector
+
+	// GetLeafPartitions returns the partitions holding the rows of each of tables that is partitioned,
+	// tables that are not partitioned are left out.
+	GetLeafPartitions(ctx context.Context, tables []string) (map[string][]string, error)
+}
+
 type RenameTablesConnector interface {
 	Connector
 
@@ -389,6 +447,12 @@ func LoadPeer(ctx context.Context, catalogPool shared.CatalogPool, peerName stri
 			return nil, fmt.Errorf("failed to unmarshal S3 config: %w", err)
 		}
 		peer.Config = &protos.Peer_S3Config{S3Config: &config}
+	case protos.DBType_AZURE_BLOB:
+		var config protos.AzureBlobConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Azure Blob Storage config: %w", err)
+		}
+		peer.Config = &protos.Peer_AzureBlobConfig{AzureBlobConfig: &config}
 	case protos.DBType_SQLSERVER:
 		var config protos.SqlServerConfig
 		if err := proto.Unmarshal(peerOptions, &config); err != nil {
@@ -419,6 +483,12 @@ func LoadPeer(ctx context.Context, catalogPool shared.CatalogPool, peerName stri
 			return nil, fmt.Errorf("failed to unmarshal Pub/Sub config: %w", err)
 		}
 		peer.Config = &protos.Peer_PubsubConfig{PubsubConfig: &config}
+	case protos.DBType_KINESIS:
+		var config protos.KinesisConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Kinesis config: %w", err)
+		}
+		peer.Config = &protos.Peer_KinesisConfig{KinesisConfig: &config}
 	case protos.DBType_EVENTHUBS:
 		var config protos.EventHubGroupConfig
 		if err := proto.Unmarshal(peerOptions, &config); err != nil {
@@ -443,23 +513,29 @@ func GetConnector(ctx context.Context, env map[string]string, config *protos.Pee
 	case *protos.Peer_PostgresConfig:
 		return connpostgres.NewPostgresConnector(ctx, env, inner.PostgresConfig)
 	case *protos.Peer_BigqueryConfig:
-		return connbigquery.NewBigQueryConnector(ctx, inner.BigqueryConfig)
+		return connbigquery.NewBigQueryConnector(ctx, config.Name, inner.BigqueryConfig)
 	case *protos.Peer_SnowflakeConfig:
 		return connsnowflake.NewSnowflakeConnector(ctx, inner.SnowflakeConfig)
 	case *protos.Peer_EventhubGroupConfig:
 		return conneventhub.NewEventHubConnector(ctx, inner.EventhubGroupConfig)
 	case *protos.Peer_S3Config:
 		return conns3.NewS3Connector(ctx, inner.S3Config)
+	case *protos.Peer_AzureBlobConfig:
+		return connazureblob.NewAzureBlobConnector(ctx, inner.AzureBlobConfig)
 	case *protos.Peer_MysqlConfig:
 		return connmysql.NewMySqlConnector(ctx, inner.MysqlConfig)
+	case *protos.Peer_SqlserverConfig:
+		return connsqlserver.NewSqlServerConnector(ctx, inner.SqlserverConfig)
 	case *protos.Peer_ClickhouseConfig:
 		return connclickhouse.NewClickHouseConnector(ctx, env, inner.ClickhouseConfig)
 	case *protos.Peer_KafkaConfig:
 		return connkafka.NewKafkaConnector(ctx, env, inner.KafkaConfig)
 	case *protos.Peer_PubsubConfig:
 		return connpubsub.NewPubSubConnector(ctx, env, inner.PubsubConfig)
+	case *protos.Peer_KinesisConfig:
+		return connkinesis.NewKinesisConnector(ctx, env, inner.KinesisConfig)
 	case *protos.Peer_ElasticsearchConfig:
-		return connelasticsearch.NewElasticsearchConnector(ctx, inner.ElasticsearchConfig)
+		return connelasticsearch.NewElasticsearchConnector(ctx, config.Name, inner.ElasticsearchConfig)
 	default:
 		return nil, errors.ErrUnsupported
 	}
@@ -499,6 +575,7 @@ func CloseConnector(ctx context.Context, conn Connector) {
 var (
 	_ CDCPullConnector = &connpostgres.PostgresConnector{}
 	_ CDCPullConnector = &connmysql.MySqlConnector{}
+	_ CDCPullConnector = &connsqlserver.SqlServerConnector{}
 
 	_ CDCPullPgConnector = &connpostgres.PostgresConnector{}
 
@@ -508,7 +585,9 @@ var (
 	_ CDCSyncConnector = &conneventhub.EventHubConnector{}
 	_ CDCSyncConnector = &connkafka.KafkaConnector{}
 	_ CDCSyncConnector = &connpubsub.PubSubConnector{}
+	_ CDCSyncConnector = &connkinesis.KinesisConnector{}
 	_ CDCSyncConnector = &conns3.S3Connector{}
+	_ CDCSyncConnector = &connazureblob.AzureBlobConnector{}
 	_ CDCSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ CDCSyncConnector = &connelasticsearch.ElasticsearchConnector{}
 
@@ -521,22 +600,30 @@ var (
 
 	_ GetTableSchemaConnector = &connpostgres.PostgresConnector{}
 	_ GetTableSchemaConnector = &connmysql.MySqlConnector{}
+	_ GetTableSchemaConnector = &connsqlserver.SqlServerConnector{}
 	_ GetTableSchemaConnector = &connsnowflake.SnowflakeConnector{}
 	_ GetTableSchemaConnector = &connclickhouse.ClickHouseConnector{}
 
 	_ GetSchemaConnector = &connpostgres.PostgresConnector{}
 	_ GetSchemaConnector = &connmysql.MySqlConnector{}
+	_ GetSchemaConnector = &connsqlserver.SqlServerConnector{}
 
 	_ NormalizedTablesConnector = &connpostgres.PostgresConnector{}
 	_ NormalizedTablesConnector = &connbigquery.BigQueryConnector{}
 	_ NormalizedTablesConnector = &connsnowflake.SnowflakeConnector{}
 	_ NormalizedTablesConnector = &connclickhouse.ClickHouseConnector{}
+	_ NormalizedTablesConnector = &conns3.S3Connector{}
+	_ NormalizedTablesConnector = &connkafka.KafkaConnector{}
 
 	_ CreateTablesFromExistingConnector = &connbigquery.BigQueryConnector{}
 	_ CreateTablesFromExistingConnector = &connsnowflake.SnowflakeConnector{}
 
 	_ QRepPullConnector = &connpostgres.PostgresConnector{}
 	_ QRepPullConnector = &connmysql.MySqlConnector{}
+	_ QRepPullConnector = &connsqlserver.SqlServerConnector{}
+	_ QRepPullConnector = &connsnowflake.SnowflakeConnector{}
+	_ QRepPullConnector = &connbigquery.BigQueryConnector{}
+	_ QRepPullConnector = &connclickhouse.ClickHouseConnector{}
 
 	_ QRepPullPgConnector = &connpostgres.PostgresConnector{}
 
@@ -544,7 +631,11 @@ var (
 	_ QRepSyncConnector = &connbigquery.BigQueryConnector{}
 	_ QRepSyncConnector = &connsnowflake.SnowflakeConnector{}
 	_ QRepSyncConnector = &connkafka.KafkaConnector{}
+	_ QRepSyncConnector = &connpubsub.PubSubConnector{}
+	_ QRepSyncConnector = &connkinesis.KinesisConnector{}
+	_ QRepSyncConnector = &conneventhub.EventHubConnector{}
 	_ QRepSyncConnector = &conns3.S3Connector{}
+	_ QRepSyncConnector = &connazureblob.AzureBlobConnector{}
 	_ QRepSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ QRepSyncConnector = &connelasticsearch.ElasticsearchConnector{}
 
@@ -552,12 +643,25 @@ var (
 
 	_ QRepConsolidateConnector = &connsnowflake.SnowflakeConnector{}
 	_ QRepConsolidateConnector = &connclickhouse.ClickHouseConnector{}
+	_ QRepConsolidateConnector = &connkafka.KafkaConnector{}
+	_ QRepConsolidateConnector = &connpubsub.PubSubConnector{}
+	_ QRepConsolidateConnector = &connkinesis.KinesisConnector{}
+	_ QRepConsolidateConnector = &conneventhub.EventHubConnector{}
 
 	_ RenameTablesConnector = &connsnowflake.SnowflakeConnector{}
 	_ RenameTablesConnector = &connbigquery.BigQueryConnector{}
 	_ RenameTablesConnector = &connpostgres.PostgresConnector{}
 	_ RenameTablesConnector = &connclickhouse.ClickHouseConnector{}
 
+	_ StageReplayConnector = &connclickhouse.ClickHouseConnector{}
+
+	_ DeduplicationConnector = &connclickhouse.ClickHouseConnector{}
+
+	_ CutoverReportConnector = &connpostgres.PostgresConnector{}
+	_ CutoverReportConnector = &connclickhouse.ClickHouseConnector{}
+
+	_ PartitionedTablesConnector = &connpostgres.PostgresConnector{}
+
 	_ RawTableConnector = &connclickhouse.ClickHouseConnector{}
 	_ RawTableConnector = &connbigquery.BigQueryConnector{}
 	_ RawTableConnector = &connsnowflake.SnowflakeConnector{}
@@ -568,14 +672,29 @@ var (
 	_ ValidationConnector = &connclickhouse.ClickHouseConnector{}
 	_ ValidationConnector = &connbigquery.BigQueryConnector{}
 	_ ValidationConnector = &conns3.S3Connector{}
+	_ ValidationConnector = &connazureblob.AzureBlobConnector{}
 	_ ValidationConnector = &connmysql.MySqlConnector{}
+	_ ValidationConnector = &connsqlserver.SqlServerConnector{}
 
 	_ MirrorSourceValidationConnector = &connpostgres.PostgresConnector{}
 	_ MirrorSourceValidationConnector = &connmysql.MySqlConnector{}
+	_ MirrorSourceValidationConnector = &connsqlserver.SqlServerConnector{}
+
+	_ MirrorSourcePreflightConnector = &connpostgres.PostgresConnector{}
+	_ MirrorSourcePreflightConnector = &connmysql.MySqlConnector{}
 
 	_ MirrorDestinationValidationConnector = &connclickhouse.ClickHouseConnector{}
 
+	_ MirrorDestinationPreflightConnector = &connpostgres.PostgresConnector{}
+	_ MirrorDestinationPreflightConnector = &connsnowflake.SnowflakeConnector{}
+	_ MirrorDestinationPreflightConnector = &connclickhouse.ClickHouseConnector{}
+	_ MirrorDestinationPreflightConnector = &connbigquery.BigQueryConnector{}
+
+	_ PeerProbeConnector = &connpostgres.PostgresConnector{}
+	_ PeerProbeConnector = &connclickhouse.ClickHouseConnector{}
+
 	_ GetVersionConnector = &connclickhouse.ClickHouseConnector{}
 	_ GetVersionConnector = &connpostgres.PostgresConnector{}
 	_ GetVersionConnector = &connmysql.MySqlConnector{}
+	_ GetVersionConnector = &connsqlserver.SqlServerConnector{}
 )
@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"maps"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,18 +38,48 @@ type ElasticsearchConnector struct {
 	*metadataStore.PostgresMetadata
 	client *elasticsearch.Client
 	logger log.Logger
+	// set when indices are managed through templates & aliases, tables are written to indices named after them otherwise
+	indices *indexManager
+	// OpenSearch, including serverless collections which don't expose node discovery
+	opensearch bool
 }
 
-func NewElasticsearchConnector(ctx context.Context,
+func NewElasticsearchConnector(ctx context.Context, peerName string,
 	config *protos.ElasticsearchConfig,
 ) (*ElasticsearchConnector, error) {
+	// server name is left to the transport to set per address unless overridden
+	tlsConfig, err := utils.PeerTlsConfig(config.Tls, tls.VersionTLS13, nil, "", "", false)
+	if err != nil {
+		return nil, err
+	}
 	esCfg := &elasticsearch.Config{
 		Addresses: config.Addresses,
 		Transport: &http.Transport{
 			MaxIdleConnsPerHost: 4,
-			TLSClientConfig:     &tls.Config{MinVersion: tls.VersionTLS13},
+			TLSClientConfig:     tlsConfig,
 		},
 	}
+	opensearch := config.Opensearch || config.AuthType == protos.ElasticsearchAuthType_AWS_SIGV4
+	if opensearch {
+		transport, err := newOpenSearchTransport(ctx, esCfg.Transport, config)
+		if err != nil {
+			return nil, err
+		}
+		esCfg.Transport = transport
+		esCfg.DisableMetaHeader = true
+	}
+	if config.MaxBulkRequestsPerSecond > 0 {
+		catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create catalog connection pool: %w", err)
+		}
+		limit, err := utils.NewRateLimiter(catalogPool, peerName, "bulk_requests",
+			float64(config.MaxBulkRequestsPerSecond), time.Second)
+		if err != nil {
+			return nil, err
+		}
+		esCfg.Transport = &bulkRateLimitTransport{next: esCfg.Transport, limit: limit}
+	}
 	if config.AuthType == protos.ElasticsearchAuthType_BASIC {
 		esCfg.Username = *config.Username
 		esCfg.Password = *config.Password
@@ -65,14 +96,33 @@ func NewElasticsearchConnector(ctx context.Context,
 		return nil, err
 	}
 
+	var indices *indexManager
+	if config.ManageIndices {
+		indices = newIndexManager(esClient, config)
+	}
+
 	return &ElasticsearchConnector{
 		PostgresMetadata: pgMetadata,
 		client:           esClient,
 		logger:           internal.LoggerFromCtx(ctx),
+		indices:          indices,
+		opensearch:       opensearch,
 	}, nil
 }
 
 func (esc *ElasticsearchConnector) ConnectionActive(ctx context.Context) error {
+	if esc.opensearch {
+		// a missing index still proves the cluster is reachable & accepts our credentials
+		res, err := esc.client.Indices.Exists([]string{"peerdb_connection_check"}, esc.client.Indices.Exists.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to check if opensearch peer is active: %w", err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to check if opensearch peer is active: %s", res.Status())
+		}
+		return nil
+	}
 	err := esc.client.DiscoverNodes()
 	if err != nil {
 		return fmt.Errorf("failed to check if elasticsearch peer is active: %w", err)
@@ -92,10 +142,24 @@ func (esc *ElasticsearchConnector) CreateRawTable(ctx context.Context,
 	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
 }
 
-// we handle schema changes by not handling them since no mapping is being enforced right now
+// without managed indices schema changes are left to dynamic mapping,
+// otherwise added columns are mapped on every index behind the alias of their table
 func (esc *ElasticsearchConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
 	flowJobName string, schemaDeltas []*protos.TableSchemaDelta,
 ) error {
+	if esc.indices == nil {
+		return nil
+	}
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+			continue
+		}
+		if err := esc.indices.putMapping(ctx, schemaDelta.DstTableName,
+			fieldsFromTableSchema(&protos.TableSchema{Columns: schemaDelta.AddedColumns}),
+		); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -201,6 +265,14 @@ func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
 
 		bulkIndexer, ok := esBulkIndexerCache[record.GetDestinationTableName()]
 		if !ok {
+			if esc.indices != nil {
+				if err := esc.indices.prepare(ctx, record.GetDestinationTableName(),
+					fieldsFromTableSchema(req.TableNameSchemaMapping[record.GetDestinationTableName()]),
+				); err != nil {
+					esc.logger.Error("[es] failed to prepare index", slog.Any("error", err))
+					return nil, err
+				}
+			}
 			bulkIndexer, err = esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
 				Index:  record.GetDestinationTableName(),
 				Client: esc.client,
@@ -301,3 +373,18 @@ func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
 		TableSchemaDeltas:    req.Records.SchemaDeltas,
 	}, nil
 }
+
+// bulkRateLimitTransport holds off bulk requests, of both CDC and QRep, until the peer's rate limit allows them
+type bulkRateLimitTransport struct {
+	next  http.RoundTripper
+	limit *utils.RateLimiter
+}
+
+func (t *bulkRateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/_bulk") {
+		if err := t.limit.Wait(req.Context(), 1); err != nil {
+			return nil, err
+		}
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,250 @@
+package connelasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// indexManager writes every table through an alias named after it, backed by indices named
+// <alias>-peerdb-<suffix> which get their mappings & settings from an index template
+type indexManager struct {
+	client *elasticsearch.Client
+	config *protos.ElasticsearchConfig
+	// write index every alias was last pointed at
+	writeIndices map[string]string
+	mu           sync.Mutex
+}
+
+func newIndexManager(client *elasticsearch.Client, config *protos.ElasticsearchConfig) *indexManager {
+	return &indexManager{
+		client:       client,
+		config:       config,
+		writeIndices: make(map[string]string),
+	}
+}
+
+func (m *indexManager) indexName(alias string, now time.Time) string {
+	switch m.config.Rollover {
+	case protos.ElasticsearchRollover_ES_ROLLOVER_DAY:
+		return alias + "-peerdb-" + now.UTC().Format("2006.01.02")
+	case protos.ElasticsearchRollover_ES_ROLLOVER_MONTH:
+		return alias + "-peerdb-" + now.UTC().Format("2006.01")
+	default:
+		return alias + "-peerdb-000001"
+	}
+}
+
+// prepare refreshes the template of alias from fields and points alias at the current write index
+func (m *indexManager) prepare(ctx context.Context, alias string, fields []types.QField) error {
+	if err := m.putTemplate(ctx, alias, fields); err != nil {
+		return err
+	}
+	return m.ensureWriteIndex(ctx, alias)
+}
+
+func (m *indexManager) putTemplate(ctx context.Context, alias string, fields []types.QField) error {
+	settings := make(map[string]any, 2)
+	if m.config.NumberOfShards != nil {
+		settings["number_of_shards"] = m.config.GetNumberOfShards()
+	}
+	if m.config.NumberOfReplicas != nil {
+		settings["number_of_replicas"] = m.config.GetNumberOfReplicas()
+	}
+	body, err := json.Marshal(map[string]any{
+		"index_patterns": []string{alias + "-peerdb-*"},
+		"template": map[string]any{
+			"settings": settings,
+			"mappings": map[string]any{"properties": esProperties(fields)},
+		},
+		"_meta": map[string]any{"managed_by": "peerdb", "alias": alias},
+	})
+	if err != nil {
+		return fmt.Errorf("[es] failed to serialize index template of %s: %w", alias, err)
+	}
+	res, err := m.client.Indices.PutIndexTemplate("peerdb-"+alias, bytes.NewReader(body),
+		m.client.Indices.PutIndexTemplate.WithContext(ctx))
+	return esResponseError(res, err, "put index template of "+alias)
+}
+
+// ensureWriteIndex creates the index alias should currently write to when missing,
+// making it the write index of alias while earlier indices stay readable through it
+func (m *indexManager) ensureWriteIndex(ctx context.Context, alias string) error {
+	index := m.indexName(alias, time.Now())
+	m.mu.Lock()
+	current := m.writeIndices[alias]
+	m.mu.Unlock()
+	if current == index {
+		return nil
+	}
+
+	res, err := m.client.Indices.GetAlias(m.client.Indices.GetAlias.WithName(alias), m.client.Indices.GetAlias.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("[es] failed to get alias %s: %w", alias, err)
+	}
+	defer res.Body.Close()
+	aliasIndices := make(map[string]struct {
+		Aliases map[string]struct {
+			IsWriteIndex *bool `json:"is_write_index"`
+		} `json:"aliases"`
+	})
+	// missing aliases are created along with their first index
+	if res.StatusCode != http.StatusNotFound {
+		if res.IsError() {
+			body, _ := io.ReadAll(res.Body)
+			return fmt.Errorf("[es] failed to get alias %s: %s %s", alias, res.Status(), body)
+		}
+		if err := json.NewDecoder(res.Body).Decode(&aliasIndices); err != nil {
+			return fmt.Errorf("[es] failed to parse alias %s: %w", alias, err)
+		}
+	}
+
+	if existing, ok := aliasIndices[index]; ok {
+		if isWriteIndex := existing.Aliases[alias].IsWriteIndex; (isWriteIndex != nil && *isWriteIndex) ||
+			(isWriteIndex == nil && len(aliasIndices) == 1) {
+			m.mu.Lock()
+			m.writeIndices[alias] = index
+			m.mu.Unlock()
+			return nil
+		}
+	} else {
+		res, err := m.client.Indices.Create(index, m.client.Indices.Create.WithContext(ctx))
+		if err := esResponseError(res, err, "create index "+index); err != nil &&
+			!strings.Contains(err.Error(), "resource_already_exists_exception") {
+			return err
+		}
+	}
+
+	actions := make([]map[string]any, 0, len(aliasIndices)+1)
+	for other := range aliasIndices {
+		if other != index {
+			actions = append(actions, map[string]any{"add": map[string]any{"index": other, "alias": alias, "is_write_index": false}})
+		}
+	}
+	actions = append(actions, map[string]any{"add": map[string]any{"index": index, "alias": alias, "is_write_index": true}})
+	body, err := json.Marshal(map[string]any{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("[es] failed to serialize alias actions of %s: %w", alias, err)
+	}
+	res, err = m.client.Indices.UpdateAliases(bytes.NewReader(body), m.client.Indices.UpdateAliases.WithContext(ctx))
+	if err := esResponseError(res, err, "point alias "+alias+" at "+index); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.writeIndices[alias] = index
+	m.mu.Unlock()
+	return nil
+}
+
+// putMapping adds mappings of new columns to every index behind alias, the template is refreshed by the next prepare
+func (m *indexManager) putMapping(ctx context.Context, alias string, fields []types.QField) error {
+	body, err := json.Marshal(map[string]any{"properties": esProperties(fields)})
+	if err != nil {
+		return fmt.Errorf("[es] failed to serialize mapping of %s: %w", alias, err)
+	}
+	res, err := m.client.Indices.PutMapping([]string{alias}, bytes.NewReader(body), m.client.Indices.PutMapping.WithContext(ctx))
+	return esResponseError(res, err, "put mapping of "+alias)
+}
+
+// esResponseError closes the body of res, returning err or the error response of Elasticsearch
+func esResponseError(res *esapi.Response, err error, action string) error {
+	if err != nil {
+		return fmt.Errorf("[es] failed to %s: %w", action, err)
+	}
+	defer res.Body.Close()
+	if !res.IsError() {
+		return nil
+	}
+	body, _ := io.ReadAll(res.Body)
+	return fmt.Errorf("[es] failed to %s: %s %s", action, res.Status(), body)
+}
+
+// longer vectors are indexed as plain float arrays
+const esMaxDenseVectorDims = 4096
+
+// esProperties maps columns to Elasticsearch field types, columns without an obvious type are left to dynamic mapping
+func esProperties(fields []types.QField) map[string]any {
+	properties := make(map[string]any, len(fields))
+	for _, field := range fields {
+		kind := field.Type
+		if kind == types.QValueKindArrayFloat32 && field.Dimensions > 0 && field.Dimensions <= esMaxDenseVectorDims {
+			// pgvector columns become searchable by similarity
+			properties[field.Name] = map[string]any{"type": "dense_vector", "dims": field.Dimensions}
+			continue
+		}
+		if kind.IsArray() {
+			// every field can hold multiple values
+			kind = types.QValueKind(strings.TrimPrefix(string(kind), "array_"))
+		}
+		if mapping := esFieldMapping(kind); mapping != nil {
+			properties[field.Name] = mapping
+		}
+	}
+	return properties
+}
+
+func esFieldMapping(kind types.QValueKind) map[string]any {
+	var esType string
+	switch kind {
+	case types.QValueKindBoolean:
+		esType = "boolean"
+	case types.QValueKindInt8:
+		esType = "byte"
+	case types.QValueKindInt16, types.QValueKindUInt8:
+		esType = "short"
+	case types.QValueKindInt32, types.QValueKindUInt16:
+		esType = "integer"
+	case types.QValueKindInt64, types.QValueKindUInt32:
+		esType = "long"
+	case types.QValueKindUInt64:
+		esType = "unsigned_long"
+	case types.QValueKindFloat32:
+		esType = "float"
+	case types.QValueKindFloat64, types.QValueKindNumeric:
+		esType = "double"
+	case types.QValueKindTimestamp, types.QValueKindTimestampTZ, types.QValueKindDate:
+		esType = "date"
+	case types.QValueKindBytes:
+		esType = "binary"
+	case types.QValueKindUUID, types.QValueKindCIDR, types.QValueKindINET, types.QValueKindMacaddr, types.QValueKindInterval:
+		esType = "keyword"
+	case types.QValueKindString, types.QValueKindQChar, types.QValueKindEnum:
+		// same as dynamic mapping of strings, searchable as text and aggregatable through the keyword subfield
+		return map[string]any{
+			"type":   "text",
+			"fields": map[string]any{"keyword": map[string]any{"type": "keyword", "ignore_above": 256}},
+		}
+	default:
+		return nil
+	}
+	return map[string]any{"type": esType}
+}
+
+func fieldsFromTableSchema(tableSchema *protos.TableSchema) []types.QField {
+	if tableSchema == nil {
+		return nil
+	}
+	fields := make([]types.QField, 0, len(tableSchema.Columns))
+	for _, column := range tableSchema.Columns {
+		field := types.QField{Name: column.Name, Type: types.QValueKind(column.Type), Nullable: column.Nullable}
+		if field.Type == types.QValueKindArrayFloat32 {
+			field.Dimensions = datatypes.ParseVectorTypmod(column.TypeModifier)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,81 @@
+package connelasticsearch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestIndexManagerRollover(t *testing.T) {
+	index := "orders-peerdb-" + time.Now().UTC().Format("2006.01.02")
+	var mu sync.Mutex
+	requests := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path] = string(body)
+		mu.Unlock()
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /_alias/orders":
+			_, _ = w.Write([]byte(`{"orders-peerdb-2020.01.01":{"aliases":{"orders":{"is_write_index":true}}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"acknowledged":true}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	require.NoError(t, err)
+	m := newIndexManager(client, &protos.ElasticsearchConfig{
+		ManageIndices: true,
+		Rollover:      protos.ElasticsearchRollover_ES_ROLLOVER_DAY,
+	})
+	require.NoError(t, m.prepare(t.Context(), "orders", []types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "tags", Type: types.QValueKindArrayString},
+		{Name: "payload", Type: types.QValueKindJSONB},
+	}))
+
+	var template map[string]any
+	require.NoError(t, json.Unmarshal([]byte(requests["PUT /_index_template/peerdb-orders"]), &template))
+	require.Equal(t, []any{"orders-peerdb-*"}, template["index_patterns"])
+	properties := template["template"].(map[string]any)["mappings"].(map[string]any)["properties"].(map[string]any)
+	require.Equal(t, map[string]any{"type": "long"}, properties["id"])
+	require.Equal(t, "text", properties["tags"].(map[string]any)["type"])
+	require.NotContains(t, properties, "payload")
+
+	require.Contains(t, requests, "PUT /"+index)
+	require.JSONEq(t, `{"actions":[
+		{"add":{"index":"orders-peerdb-2020.01.01","alias":"orders","is_write_index":false}},
+		{"add":{"index":"`+index+`","alias":"orders","is_write_index":true}}
+	]}`, requests["POST /_aliases"])
+
+	// write index is only looked up again once it rolls over
+	clear(requests)
+	require.NoError(t, m.ensureWriteIndex(t.Context(), "orders"))
+	require.Empty(t, requests)
+}
+
+func TestESPropertiesVectors(t *testing.T) {
+	properties := esProperties(fieldsFromTableSchema(&protos.TableSchema{Columns: []*protos.FieldDescription{
+		{Name: "embedding", Type: string(types.QValueKindArrayFloat32), TypeModifier: 3},
+		{Name: "scores", Type: string(types.QValueKindArrayFloat32), TypeModifier: -1},
+	}}))
+	require.Equal(t, map[string]any{"type": "dense_vector", "dims": int32(3)}, properties["embedding"])
+	require.Equal(t, map[string]any{"type": "float"}, properties["scores"])
+
+	properties = esProperties([]types.QField{{Name: "embedding", Type: types.QValueKindArrayFloat32, Dimensions: 8192}})
+	require.Equal(t, map[string]any{"type": "float"}, properties["embedding"])
+}
@@ -0,0 +1,120 @@
+package connelasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// openSearchTransport adapts requests of the Elasticsearch client to OpenSearch,
+// signing them with SigV4 for Amazon OpenSearch Service when a credentials provider is set
+type openSearchTransport struct {
+	base     http.RoundTripper
+	provider utils.AWSCredentialsProvider
+	signer   *v4.Signer
+	region   string
+	// es for domains, aoss for serverless collections
+	service string
+}
+
+func newOpenSearchTransport(
+	ctx context.Context,
+	base http.RoundTripper,
+	config *protos.ElasticsearchConfig,
+) (*openSearchTransport, error) {
+	t := &openSearchTransport{base: base}
+	if config.AuthType != protos.ElasticsearchAuthType_AWS_SIGV4 {
+		return t, nil
+	}
+
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "opensearch", utils.PeerAWSCredentials{
+		Credentials: aws.Credentials{
+			AccessKeyID:     config.GetAccessKeyId(),
+			SecretAccessKey: config.GetSecretAccessKey(),
+		},
+		RoleArn: config.RoleArn,
+		Region:  config.GetRegion(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials for OpenSearch: %w", err)
+	}
+	t.region = config.GetRegion()
+	if t.region == "" {
+		t.region = provider.GetRegion()
+	}
+	if t.region == "" {
+		return nil, errors.New("region is required for SigV4 auth")
+	}
+	t.provider = provider
+	t.signer = v4.NewSigner()
+	t.service = "es"
+	if config.Serverless {
+		t.service = "aoss"
+	}
+	return t, nil
+}
+
+func (t *openSearchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	// OpenSearch doesn't accept the versioned media types of Elasticsearch
+	for _, header := range []string{"Accept", "Content-Type"} {
+		if value := req.Header.Get(header); strings.HasPrefix(value, "application/vnd.elasticsearch+") {
+			mediaType, _, _ := strings.Cut(strings.TrimPrefix(value, "application/vnd.elasticsearch+"), ";")
+			req.Header.Set(header, "application/"+strings.TrimSpace(mediaType))
+		}
+	}
+
+	if t.provider != nil {
+		if err := t.sign(req); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// the client refuses servers that don't identify as Elasticsearch
+	res.Header.Set("X-Elastic-Product", "Elasticsearch")
+	return res, nil
+}
+
+func (t *openSearchTransport) sign(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read OpenSearch request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	payloadHash := sha256.Sum256(body)
+	hash := hex.EncodeToString(payloadHash[:])
+	// serverless collections require the payload hash as a header
+	req.Header.Set("X-Amz-Content-Sha256", hash)
+
+	credentials, err := t.provider.Retrieve(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials for OpenSearch: %w", err)
+	}
+	if err := t.signer.SignHTTP(req.Context(), credentials.AWS, req, hash, t.service, t.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign OpenSearch request: %w", err)
+	}
+	return nil
+}
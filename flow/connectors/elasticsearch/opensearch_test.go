@@ -0,0 +1,49 @@
+package connelasticsearch
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestOpenSearchTransportSigV4(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		require.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKID/"), authorization)
+		require.Contains(t, authorization, "/us-east-2/aoss/aws4_request")
+		require.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+		body, _ := io.ReadAll(r.Body)
+		require.JSONEq(t, `{"properties":{}}`, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	}))
+	defer server.Close()
+
+	config := &protos.ElasticsearchConfig{
+		AuthType:        protos.ElasticsearchAuthType_AWS_SIGV4,
+		Region:          proto.String("us-east-2"),
+		AccessKeyId:     proto.String("AKID"),
+		SecretAccessKey: proto.String("secret"),
+		Serverless:      true,
+	}
+	transport, err := newOpenSearchTransport(t.Context(), http.DefaultTransport, config)
+	require.NoError(t, err)
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:         []string{server.URL},
+		Transport:         transport,
+		DisableMetaHeader: true,
+	})
+	require.NoError(t, err)
+
+	// responses of OpenSearch lack the product header Elasticsearch clients insist on
+	res, err := client.Indices.PutMapping([]string{"orders"}, strings.NewReader(`{"properties":{}}`))
+	require.NoError(t, esResponseError(res, err, "put mapping"))
+}
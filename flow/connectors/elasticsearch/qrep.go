@@ -69,6 +69,13 @@ func (esc *ElasticsearchConnector) SyncQRepRecords(ctx context.Context, config *
 		}
 	}
 
+	if esc.indices != nil {
+		if err := esc.indices.prepare(ctx, config.DestinationTableIdentifier, schema.Fields); err != nil {
+			esc.logger.Error("[es] failed to prepare index", slog.Any("error", err))
+			return 0, nil, err
+		}
+	}
+
 	esBulkIndexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
 		Index:  config.DestinationTableIdentifier,
 		Client: esc.client,
@@ -0,0 +1,36 @@
+package connelasticsearch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestBulkRateLimitTransportOnlyLimitsBulk(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	// a limit below one request fails every bulk wait before it reaches the catalog
+	limit, err := utils.NewRateLimiter(shared.CatalogPool{}, "peer", "bulk_requests", 0.5, time.Second)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &bulkRateLimitTransport{next: http.DefaultTransport, limit: limit}}
+
+	res, err := client.Get(server.URL + "/orders/_mapping")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	for _, path := range []string{"/_bulk", "/orders/_bulk"} {
+		_, err = client.Post(server.URL+path, "application/x-ndjson", nil)
+		require.ErrorContains(t, err, "at most")
+	}
+	require.Equal(t, []string{"/orders/_mapping"}, paths)
+}
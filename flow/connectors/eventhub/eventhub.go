@@ -2,6 +2,7 @@ package conneventhub
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"sync/atomic"
@@ -174,6 +175,15 @@ func (c *EventHubConnector) processBatch(
 ) (uint32, error) {
 	batchPerTopic := NewHubBatches(c.hubManager)
 	toJSONOpts := model.NewToJSONOptions(c.config.UnnestColumns, false)
+	var err error
+	if toJSONOpts.GeoJSON, err = internal.PeerDBQueueGeoJSON(ctx, req.Env); err != nil {
+		return 0, err
+	}
+	schemaVersions := utils.NewSchemaVersions(req.TableNameSchemaMapping)
+	diffUpdate, err := utils.QueueUpdateDiffer(ctx, req.Env, req.TableNameSchemaMapping)
+	if err != nil {
+		return 0, err
+	}
 
 	flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
 	if err != nil {
@@ -210,6 +220,9 @@ func (c *EventHubConnector) processBatch(
 		select {
 		case record, ok := <-req.Records.GetRecords():
 			if !ok {
+				if err := c.addSchemaChanges(ctx, req, schemaVersions, batchPerTopic); err != nil {
+					return 0, err
+				}
 				c.logger.Info("flushing batches because no more records")
 				err := batchPerTopic.flushAllBatches(ctx, req.FlowJobName)
 				if err != nil {
@@ -227,75 +240,8 @@ func (c *EventHubConnector) processBatch(
 				lastSeenLSN = recordLSN
 			}
 
-			var events []ScopedEventhubData
-			destinationString := record.GetDestinationTableName()
-			if fn != nil {
-				ls.Push(fn)
-				ls.Push(pua.LuaRecord.New(ls, record))
-				err := ls.PCall(1, -1, nil)
-				if err != nil {
-					return 0, fmt.Errorf("script failed: %w", err)
-				}
-
-				args := ls.GetTop()
-				for i := range args {
-					scoped, err := lvalueToEventData(ls, ls.Get(i-args))
-					if err != nil {
-						return 0, err
-					}
-
-					if scoped.Data != nil {
-						if scoped.Hub.NamespaceName == "" {
-							scoped.Hub, err = NewScopedEventhub(destinationString)
-							if err != nil {
-								c.logger.Error("failed to get topic name", slog.Any("error", err))
-								return 0, err
-							}
-						}
-						events = append(events, scoped)
-					}
-				}
-				ls.SetTop(0)
-			} else {
-				json, err := record.GetItems().ToJSONWithOptions(toJSONOpts)
-				if err != nil {
-					c.logger.Info("failed to convert record to json", slog.Any("error", err))
-					return 0, err
-				}
-				scopedHub, err := NewScopedEventhub(destinationString)
-				if err != nil {
-					c.logger.Error("failed to get topic name", slog.Any("error", err))
-					return 0, err
-				}
-				events = []ScopedEventhubData{{Hub: scopedHub, Data: &azeventhubs.EventData{Body: []byte(json)}}}
-			}
-
-			for _, event := range events {
-				ehConfig, ok := c.hubManager.namespaceToEventhubMap.Get(event.Hub.NamespaceName)
-				if !ok {
-					c.logger.Error("failed to get eventhub config", slog.String("namespace", event.Hub.NamespaceName))
-					return 0, fmt.Errorf("failed to get eventhub config %s", event.Hub.NamespaceName)
-				}
-
-				// Scoped eventhub is of the form peer_name.eventhub_name.partition_column
-				// partition_column is the column in the table that is used to determine
-				// the partition key for the eventhub.
-				partitionKey := event.Hub.PartitionKeyValue
-				if partitionKey == "" {
-					partitionColumn := event.Hub.PartitionKeyColumn
-					partitionValue := record.GetItems().GetColumnValue(partitionColumn).Value()
-					if partitionValue != nil {
-						partitionKey = fmt.Sprint(partitionValue)
-					}
-
-					partitionKey = HashedPartitionKey(partitionKey, ehConfig.PartitionCount)
-					event.Hub.PartitionKeyValue = partitionKey
-				}
-				err := batchPerTopic.AddEvent(ctx, event.Hub, event.Data, false)
-				if err != nil {
-					c.logger.Error("failed to add event to batch", slog.Any("error", err))
-					return 0, err
-				}
+			if err := c.addRecordEvents(ctx, batchPerTopic, ls, fn, diffUpdate(record), toJSONOpts, schemaVersions); err != nil {
+				return 0, err
 			}
 
 			curNumRecords := numRecords.Add(1)
@@ -322,6 +268,159 @@ func (c *EventHubConnector) processBatch(
 	}
 }
 
+// addRecordEvents runs the record through the script, or serializes it as JSON without one,
+// and queues the resulting events to their eventhubs
+func (c *EventHubConnector) addRecordEvents(
+	ctx context.Context,
+	batchPerTopic *HubBatches,
+	ls *lua.LState,
+	fn *lua.LFunction,
+	record model.Record[model.RecordItems],
+	toJSONOpts model.ToJSONOptions,
+	schemaVersions *utils.SchemaVersions,
+) error {
+	var events []ScopedEventhubData
+	destinationString := record.GetDestinationTableName()
+	if fn != nil {
+		ls.Push(fn)
+		ls.Push(pua.LuaRecord.New(ls, record))
+		err := ls.PCall(1, -1, nil)
+		if err != nil {
+			return fmt.Errorf("script failed: %w", err)
+		}
+
+		args := ls.GetTop()
+		for i := range args {
+			scoped, err := lvalueToEventData(ls, ls.Get(i-args))
+			if err != nil {
+				return err
+			}
+
+			if scoped.Data != nil {
+				if scoped.Hub.NamespaceName == "" {
+					scoped.Hub, err = NewScopedEventhub(destinationString)
+					if err != nil {
+						c.logger.Error("failed to get topic name", slog.Any("error", err))
+						return err
+					}
+				}
+				events = append(events, scoped)
+			}
+		}
+		ls.SetTop(0)
+	} else if message, ok := record.(*model.MessageRecord[model.RecordItems]); ok {
+		// only forwarded messages have a destination, others pass through for acknowledgement
+		if destinationString == "" {
+			return nil
+		}
+		body, err := json.Marshal(struct {
+			Prefix  string `json:"prefix"`
+			Content string `json:"content"`
+		}{Prefix: message.Prefix, Content: message.Content})
+		if err != nil {
+			return fmt.Errorf("failed to serialize logical message: %w", err)
+		}
+		scopedHub, err := NewScopedEventhub(destinationString)
+		if err != nil {
+			c.logger.Error("failed to get topic name", slog.Any("error", err))
+			return err
+		}
+		events = []ScopedEventhubData{{Hub: scopedHub, Data: &azeventhubs.EventData{Body: body}}}
+	} else {
+		json, err := record.GetItems().ToJSONWithOptions(ctx, toJSONOpts)
+		if err != nil {
+			c.logger.Info("failed to convert record to json", slog.Any("error", err))
+			return err
+		}
+		scopedHub, err := NewScopedEventhub(destinationString)
+		if err != nil {
+			c.logger.Error("failed to get topic name", slog.Any("error", err))
+			return err
+		}
+		events = []ScopedEventhubData{{Hub: scopedHub, Data: &azeventhubs.EventData{Body: []byte(json)}}}
+	}
+
+	for _, event := range events {
+		ehConfig, ok := c.hubManager.namespaceToEventhubMap.Get(event.Hub.NamespaceName)
+		if !ok {
+			c.logger.Error("failed to get eventhub config", slog.String("namespace", event.Hub.NamespaceName))
+			return fmt.Errorf("failed to get eventhub config %s", event.Hub.NamespaceName)
+		}
+
+		// Scoped eventhub is of the form peer_name.eventhub_name.partition_column
+		// partition_column is the column in the table that is used to determine
+		// the partition key for the eventhub.
+		partitionKey := event.Hub.PartitionKeyValue
+		if partitionKey == "" {
+			// keep messages of the same prefix in order, they have no columns to partition by
+			if message, ok := record.(*model.MessageRecord[model.RecordItems]); ok {
+				partitionKey = message.Prefix
+			} else if partitionValue := record.GetItems().GetColumnValue(event.Hub.PartitionKeyColumn).Value(); partitionValue != nil {
+				partitionKey = fmt.Sprint(partitionValue)
+			}
+
+			partitionKey = HashedPartitionKey(partitionKey, ehConfig.PartitionCount)
+			event.Hub.PartitionKeyValue = partitionKey
+		}
+		if version, ok := schemaVersions.Get(record.GetDestinationTableName()); ok {
+			if event.Data.Properties == nil {
+				event.Data.Properties = make(map[string]any, 1)
+			}
+			if _, ok := event.Data.Properties[utils.SchemaVersionHeader]; !ok {
+				event.Data.Properties[utils.SchemaVersionHeader] = version
+			}
+		}
+		err := batchPerTopic.AddEvent(ctx, event.Hub, event.Data, false)
+		if err != nil {
+			c.logger.Error("failed to add event to batch", slog.Any("error", err))
+			return err
+		}
+	}
+	return nil
+}
+
+// addSchemaChanges queues schema change events of the batch to the configured schema change eventhub
+func (c *EventHubConnector) addSchemaChanges(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	schemaVersions *utils.SchemaVersions,
+	batchPerTopic *HubBatches,
+) error {
+	if len(req.Records.SchemaDeltas) == 0 {
+		return nil
+	}
+	destination, err := internal.PeerDBQueueSchemaChangeTopic(ctx, req.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get schema change topic: %w", err)
+	} else if destination == "" {
+		return nil
+	}
+	hub, err := NewScopedEventhub(destination)
+	if err != nil {
+		return fmt.Errorf("invalid schema change eventhub: %w", err)
+	}
+	ehConfig, ok := c.hubManager.namespaceToEventhubMap.Get(hub.NamespaceName)
+	if !ok {
+		return fmt.Errorf("failed to get eventhub config %s", hub.NamespaceName)
+	}
+
+	for _, event := range schemaVersions.ChangeEvents(req.FlowJobName, req.SyncBatchID, req.Records.SchemaDeltas) {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to serialize schema change: %w", err)
+		}
+		// keep changes of a table in order by sending them to the same partition
+		hub.PartitionKeyValue = HashedPartitionKey(event.DestinationTable, ehConfig.PartitionCount)
+		if err := batchPerTopic.AddEvent(ctx, hub, &azeventhubs.EventData{
+			Body:       body,
+			Properties: map[string]any{utils.SchemaVersionHeader: event.SchemaVersion},
+		}, false); err != nil {
+			return fmt.Errorf("failed to add schema change of %s to batch: %w", event.DestinationTable, err)
+		}
+	}
+	return nil
+}
+
 func (c *EventHubConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
 	numRecords, err := c.processBatch(ctx, req)
 	if err != nil {
@@ -0,0 +1,155 @@
+package conneventhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/v2"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*EventHubConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+// SyncQRepRecords sends snapshot rows as insert records, the same way SyncRecords sends them
+func (c *EventHubConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	batchPerTopic := NewHubBatches(c.hubManager)
+	toJSONOpts := model.NewToJSONOptions(c.config.UnnestColumns, false)
+	if toJSONOpts.GeoJSON, err = internal.PeerDBQueueGeoJSON(ctx, config.Env); err != nil {
+		return 0, nil, err
+	}
+	schemaVersions := utils.NewSchemaVersions(nil)
+
+	flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, config.Env)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get flush timeout: %w", err)
+	}
+	ticker := time.NewTicker(flushTimeout)
+	defer ticker.Stop()
+
+	var ls *lua.LState
+	var fn *lua.LFunction
+	if config.Script != "" {
+		ls, err = utils.LoadScript(ctx, config.Script, utils.LuaPrintFn(func(s string) {
+			_ = c.LogFlowInfo(ctx, config.FlowJobName, s)
+		}))
+		if err != nil {
+			return 0, nil, err
+		}
+		defer ls.Close()
+
+		lfn := ls.Env.RawGetString("onRecord")
+		var ok bool
+		fn, ok = lfn.(*lua.LFunction)
+		if !ok {
+			return 0, nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+		}
+	}
+
+	var numRecords int64
+Loop:
+	for {
+		select {
+		case qrecord, ok := <-stream.Records:
+			if !ok {
+				break Loop
+			}
+
+			items := model.NewRecordItems(len(qrecord))
+			for i, val := range qrecord {
+				items.AddColumn(schema.Fields[i].Name, val)
+			}
+			record := &model.InsertRecord[model.RecordItems]{
+				BaseRecord:           model.BaseRecord{},
+				Items:                items,
+				SourceTableName:      config.WatermarkTable,
+				DestinationTableName: config.DestinationTableIdentifier,
+				CommitID:             0,
+			}
+			if err := c.addRecordEvents(ctx, batchPerTopic, ls, fn, record, toJSONOpts, schemaVersions); err != nil {
+				return 0, nil, err
+			}
+
+			numRecords += 1
+			if numRecords%10000 == 0 {
+				c.logger.Info("SyncQRepRecords", slog.Int64("number of records processed for sending", numRecords))
+			}
+
+		case <-ctx.Done():
+			return 0, nil, fmt.Errorf("[eventhub] context cancelled %w", ctx.Err())
+
+		case <-ticker.C:
+			if err := batchPerTopic.flushAllBatches(ctx, config.FlowJobName); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return 0, nil, err
+	}
+	c.logger.Info("flushing batches because no more records")
+	if err := batchPerTopic.flushAllBatches(ctx, config.FlowJobName); err != nil {
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords, nil, nil
+}
+
+// ConsolidateQRepPartitions sends the snapshot completion marker to the destination eventhub of an initial copy
+func (c *EventHubConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
+	event := utils.NewSnapshotCompletedEvent(config)
+	if event == nil {
+		return nil
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot completion: %w", err)
+	}
+	hub, err := NewScopedEventhub(config.DestinationTableIdentifier)
+	if err != nil {
+		return err
+	}
+	ehConfig, ok := c.hubManager.namespaceToEventhubMap.Get(hub.NamespaceName)
+	if !ok {
+		return fmt.Errorf("failed to get eventhub config %s", hub.NamespaceName)
+	}
+	hub.PartitionKeyValue = HashedPartitionKey(event.DestinationTable, ehConfig.PartitionCount)
+
+	batchPerTopic := NewHubBatches(c.hubManager)
+	if err := batchPerTopic.AddEvent(ctx, hub, &azeventhubs.EventData{
+		Body:       body,
+		Properties: map[string]any{utils.SnapshotEventHeader: event.Event},
+	}, false); err != nil {
+		return fmt.Errorf("failed to add snapshot completion of %s to batch: %w", event.DestinationTable, err)
+	}
+	return batchPerTopic.flushAllBatches(ctx, config.FlowJobName)
+}
+
+func (*EventHubConnector) CleanupQRepFlow(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
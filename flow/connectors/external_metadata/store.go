@@ -25,6 +25,7 @@ import (
 const (
 	lastSyncStateTableName = "metadata_last_sync_state"
 	qrepTableName          = "metadata_qrep_partitions"
+	writeStreamsTableName  = "metadata_write_streams"
 )
 
 type PostgresMetadata struct {
@@ -288,6 +289,32 @@ func (p *PostgresMetadata) IsQRepPartitionSynced(ctx context.Context, req *proto
 	return exists, nil
 }
 
+// GetWriteStream returns the write stream batchID was committed through, or was about to be, empty if none
+func (p *PostgresMetadata) GetWriteStream(ctx context.Context, jobName string, batchID int64) (string, error) {
+	var streamName string
+	if err := p.pool.QueryRow(ctx,
+		`SELECT stream_name FROM `+writeStreamsTableName+` WHERE job_name = $1 AND batch_id = $2`,
+		jobName, batchID,
+	).Scan(&streamName); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to get write stream of batch %d: %w", batchID, err)
+	}
+	return streamName, nil
+}
+
+// SetWriteStream records the write stream batchID is about to be committed through,
+// forgetting those of earlier batches
+func (p *PostgresMetadata) SetWriteStream(ctx context.Context, jobName string, batchID int64, streamName string) error {
+	if _, err := p.pool.Exec(ctx,
+		`WITH earlier AS (DELETE FROM `+writeStreamsTableName+` WHERE job_name = $1 AND batch_id < $2)
+		INSERT INTO `+writeStreamsTableName+`(job_name, batch_id, stream_name) VALUES ($1, $2, $3)
+		ON CONFLICT (job_name, batch_id) DO UPDATE SET stream_name = $3, created_at = NOW()`,
+		jobName, batchID, streamName,
+	); err != nil {
+		return fmt.Errorf("failed to record write stream of batch %d: %w", batchID, err)
+	}
+	return nil
+}
+
 func (p *PostgresMetadata) SyncFlowCleanup(ctx context.Context, jobName string) error {
 	tx, err := p.pool.Begin(ctx)
 	if err != nil {
@@ -315,5 +342,9 @@ func SyncFlowCleanupInTx(ctx context.Context, tx pgx.Tx, jobName string) error {
 		return err
 	}
 
+	if _, err := tx.Exec(ctx, `DELETE FROM `+writeStreamsTableName+` WHERE job_name = $1`, jobName); err != nil {
+		return err
+	}
+
 	return nil
 }
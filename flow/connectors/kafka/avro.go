@@ -0,0 +1,213 @@
+package connkafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils/schemaregistry"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// avroEncoder serializes records as avro change envelopes prefixed by the wire format header of a schema registry.
+// Every destination table is a topic, its envelope schema is registered under the subject <topic>-value
+type avroEncoder struct {
+	registry schemaregistry.Registry
+	env      map[string]string
+	logger   log.Logger
+	fields   map[string][]types.QField
+	tables   map[string]*avroTable
+	mu       sync.Mutex
+	// inserts of snapshots are marked as reads, like Debezium does
+	snapshot bool
+}
+
+type avroTable struct {
+	schema    avro.Schema
+	fields    []types.QField
+	avroNames []string
+	header    []byte
+}
+
+func newAvroEncoder(
+	registry schemaregistry.Registry,
+	env map[string]string,
+	logger log.Logger,
+	fields map[string][]types.QField,
+	snapshot bool,
+) *avroEncoder {
+	return &avroEncoder{
+		registry: registry,
+		env:      env,
+		logger:   logger,
+		fields:   fields,
+		tables:   make(map[string]*avroTable, len(fields)),
+		snapshot: snapshot,
+	}
+}
+
+func avroFieldsFromTableSchemas(tableNameSchemaMapping map[string]*protos.TableSchema) map[string][]types.QField {
+	tableFields := make(map[string][]types.QField, len(tableNameSchemaMapping))
+	for table, tableSchema := range tableNameSchemaMapping {
+		fields := make([]types.QField, 0, len(tableSchema.Columns))
+		for _, column := range tableSchema.Columns {
+			field := types.QField{Name: column.Name, Type: types.QValueKind(column.Type), Nullable: true}
+			if field.Type == types.QValueKindNumeric {
+				field.Precision, field.Scale = datatypes.ParseNumericTypmod(column.TypeModifier)
+			}
+			fields = append(fields, field)
+		}
+		tableFields[table] = fields
+	}
+	return tableFields
+}
+
+func (e *avroEncoder) table(ctx context.Context, name string) (*avroTable, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if table, ok := e.tables[name]; ok {
+		return table, nil
+	}
+
+	qfields, ok := e.fields[name]
+	if !ok {
+		return nil, fmt.Errorf("no schema for table %s to encode avro", name)
+	}
+	// columns missing from a change, like unchanged toast columns, are encoded as null
+	fields := make([]types.QField, len(qfields))
+	avroNames := make([]string, len(qfields))
+	avroNameMap := make(map[string]string, len(qfields))
+	for i, field := range qfields {
+		field.Nullable = true
+		fields[i] = field
+		avroNames[i] = qvalue.ConvertToAvroCompatibleName(field.Name)
+		avroNameMap[field.Name] = avroNames[i]
+	}
+	recordName := qvalue.ConvertToAvroCompatibleName(name)
+	definition, err := model.GetAvroSchemaDefinition(ctx, e.env, recordName, types.QRecordSchema{Fields: fields},
+		protos.DBType_KAFKA, avroNameMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to define avro schema of %s: %w", name, err)
+	}
+
+	before, err := avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), definition.Schema})
+	if err != nil {
+		return nil, err
+	}
+	after, err := avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), avro.NewRefSchema(definition.Schema)})
+	if err != nil {
+		return nil, err
+	}
+	envelopeFields := make([]*avro.Field, 0, 3)
+	for _, field := range []struct {
+		schema avro.Schema
+		name   string
+	}{
+		{name: "op", schema: avro.NewPrimitiveSchema(avro.String, nil)},
+		{name: "before", schema: before},
+		{name: "after", schema: after},
+	} {
+		avroField, err := avro.NewField(field.name, field.schema)
+		if err != nil {
+			return nil, err
+		}
+		envelopeFields = append(envelopeFields, avroField)
+	}
+	envelope, err := avro.NewRecordSchema(recordName+"_envelope", "", envelopeFields)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := e.registry.Header(ctx, name+"-value", envelope.String())
+	if err != nil {
+		return nil, err
+	}
+	table := &avroTable{
+		schema:    envelope,
+		fields:    fields,
+		avroNames: avroNames,
+		header:    header,
+	}
+	e.tables[name] = table
+	return table, nil
+}
+
+// encode returns nothing for records without row changes, like relation and message records
+func (e *avroEncoder) encode(ctx context.Context, record model.Record[model.RecordItems]) ([]*kgo.Record, error) {
+	var op string
+	var before, after model.RecordItems
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		op = "c"
+		if e.snapshot {
+			op = "r"
+		}
+		after = r.Items
+	case *model.UpdateRecord[model.RecordItems]:
+		op = "u"
+		before = r.OldItems
+		after = r.NewItems
+	case *model.DeleteRecord[model.RecordItems]:
+		op = "d"
+		before = r.Items
+	default:
+		return nil, nil
+	}
+
+	topic := record.GetDestinationTableName()
+	table, err := e.table(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	beforeValue, err := e.row(ctx, table, before)
+	if err != nil {
+		return nil, err
+	}
+	afterValue, err := e.row(ctx, table, after)
+	if err != nil {
+		return nil, err
+	}
+	data, err := avro.Marshal(table.schema, map[string]any{
+		"op":     op,
+		"before": beforeValue,
+		"after":  afterValue,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode avro record of %s: %w", topic, err)
+	}
+
+	value := make([]byte, 0, len(table.header)+len(data))
+	value = append(value, table.header...)
+	value = append(value, data...)
+	return []*kgo.Record{{Topic: topic, Value: value}}, nil
+}
+
+func (e *avroEncoder) row(ctx context.Context, table *avroTable, items model.RecordItems) (any, error) {
+	if items.Len() == 0 {
+		return nil, nil
+	}
+	row := make(map[string]any, len(table.fields))
+	for i := range table.fields {
+		value := items.GetColumnValue(table.fields[i].Name)
+		if value == nil {
+			row[table.avroNames[i]] = nil
+			continue
+		}
+		avroValue, err := qvalue.QValueToAvro(ctx, e.env, value, &table.fields[i], protos.DBType_KAFKA, e.logger, false, qvalue.NumericOverflowNull, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s to avro: %w", table.fields[i].Name, err)
+		}
+		row[table.avroNames[i]] = avroValue
+	}
+	// nullable unions take pointers to their value
+	var value any = row
+	return &value, nil
+}
@@ -0,0 +1,62 @@
+package connkafka
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+type testRegistry struct {
+	subjects map[string]string
+}
+
+func (r *testRegistry) Header(_ context.Context, subject string, schema string) ([]byte, error) {
+	r.subjects[subject] = schema
+	return []byte{0, 0, 0, 0, 7}, nil
+}
+
+func TestAvroEncoder(t *testing.T) {
+	registry := &testRegistry{subjects: make(map[string]string)}
+	encoder := newAvroEncoder(registry, nil, slog.Default(), avroFieldsFromTableSchemas(map[string]*protos.TableSchema{
+		"public.orders": {Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "note", Type: string(types.QValueKindString)},
+		}},
+	}), false)
+
+	oldItems := model.NewRecordItems(1)
+	oldItems.AddColumn("id", types.QValueInt64{Val: 1})
+	newItems := model.NewRecordItems(2)
+	newItems.AddColumn("id", types.QValueInt64{Val: 1})
+	newItems.AddColumn("note", types.QValueString{Val: "shipped"})
+	krs, err := encoder.encode(t.Context(), &model.UpdateRecord[model.RecordItems]{
+		OldItems:             oldItems,
+		NewItems:             newItems,
+		DestinationTableName: "public.orders",
+	})
+	require.NoError(t, err)
+	require.Len(t, krs, 1)
+	kr := krs[0]
+	require.Equal(t, "public.orders", kr.Topic)
+	require.Equal(t, []byte{0, 0, 0, 0, 7}, kr.Value[:5])
+
+	schema, err := avro.Parse(registry.subjects["public.orders-value"])
+	require.NoError(t, err)
+	var envelope map[string]any
+	require.NoError(t, avro.Unmarshal(schema, kr.Value[5:], &envelope))
+	require.Equal(t, "u", envelope["op"])
+	// generic decoding keys records within unions by their name
+	require.Equal(t, map[string]any{"public_orders": map[string]any{"id": int64(1), "note": nil}}, envelope["before"])
+	require.Equal(t, map[string]any{"public_orders": map[string]any{"id": int64(1), "note": "shipped"}}, envelope["after"])
+
+	krs, err = encoder.encode(t.Context(), &model.RelationRecord[model.RecordItems]{})
+	require.NoError(t, err)
+	require.Empty(t, krs)
+}
@@ -0,0 +1,151 @@
+package connkafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// debeziumEncoder serializes records like the json converter of Debezium without schemas,
+// keyed by their primary key and followed by a tombstone when deleted so compacted topics drop the row
+type debeziumEncoder struct {
+	flowJobName string
+	// primary key columns per destination table, records of tables without one are produced without keys
+	primaryKeys map[string][]string
+	jsonOpts    model.ToJSONOptions
+	snapshot    bool
+}
+
+type debeziumSource struct {
+	Version   string `json:"version"`
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	TsMs      int64  `json:"ts_ms"`
+	Snapshot  string `json:"snapshot"`
+	Schema    string `json:"schema,omitempty"`
+	Table     string `json:"table"`
+	Lsn       int64  `json:"lsn,omitempty"`
+}
+
+type debeziumEnvelope struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+	Op     string          `json:"op"`
+	Source debeziumSource  `json:"source"`
+	TsMs   int64           `json:"ts_ms"`
+}
+
+func newDebeziumEncoder(
+	flowJobName string,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+	snapshot bool,
+	geoJSON bool,
+) *debeziumEncoder {
+	primaryKeys := make(map[string][]string, len(tableNameSchemaMapping))
+	for table, tableSchema := range tableNameSchemaMapping {
+		primaryKeys[table] = tableSchema.PrimaryKeyColumns
+	}
+	return &debeziumEncoder{
+		flowJobName: flowJobName,
+		primaryKeys: primaryKeys,
+		jsonOpts:    model.ToJSONOptions{HStoreAsJSON: true, GeoJSON: geoJSON},
+		snapshot:    snapshot,
+	}
+}
+
+// encode returns nothing for records without row changes, like relation and message records
+func (e *debeziumEncoder) encode(ctx context.Context, record model.Record[model.RecordItems]) ([]*kgo.Record, error) {
+	envelope := debeziumEnvelope{
+		Before: json.RawMessage("null"),
+		After:  json.RawMessage("null"),
+		TsMs:   time.Now().UnixMilli(),
+	}
+	var keyItems model.RecordItems
+	var err error
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		envelope.Op = "c"
+		if e.snapshot {
+			envelope.Op = "r"
+		}
+		envelope.After, err = r.Items.MarshalJSONWithOptions(ctx, e.jsonOpts)
+		keyItems = r.Items
+	case *model.UpdateRecord[model.RecordItems]:
+		envelope.Op = "u"
+		if r.OldItems.Len() > 0 {
+			envelope.Before, err = r.OldItems.MarshalJSONWithOptions(ctx, e.jsonOpts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize before image: %w", err)
+			}
+		}
+		envelope.After, err = r.NewItems.MarshalJSONWithOptions(ctx, e.jsonOpts)
+		keyItems = r.NewItems
+	case *model.DeleteRecord[model.RecordItems]:
+		envelope.Op = "d"
+		envelope.Before, err = r.Items.MarshalJSONWithOptions(ctx, e.jsonOpts)
+		keyItems = r.Items
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize row image: %w", err)
+	}
+
+	envelope.Source = debeziumSource{
+		Version:   internal.PeerDBVersionShaShort(),
+		Connector: "peerdb",
+		Name:      e.flowJobName,
+		TsMs:      record.GetCommitTime().UnixMilli(),
+		Snapshot:  strconv.FormatBool(e.snapshot),
+		Table:     record.GetSourceTableName(),
+		Lsn:       record.GetCheckpointID(),
+	}
+	if schemaTable, err := utils.ParseSchemaTable(record.GetSourceTableName()); err == nil {
+		envelope.Source.Schema = schemaTable.Schema
+		envelope.Source.Table = schemaTable.Table
+	}
+	if e.snapshot {
+		// snapshot records have no commit
+		envelope.Source.TsMs = envelope.TsMs
+	}
+
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize Debezium envelope: %w", err)
+	}
+	topic := record.GetDestinationTableName()
+	key, err := e.key(topic, keyItems)
+	if err != nil {
+		return nil, err
+	}
+	results := []*kgo.Record{{Topic: topic, Key: key, Value: value}}
+	if envelope.Op == "d" && key != nil {
+		results = append(results, &kgo.Record{Topic: topic, Key: key})
+	}
+	return results, nil
+}
+
+func (e *debeziumEncoder) key(table string, items model.RecordItems) ([]byte, error) {
+	primaryKey := e.primaryKeys[table]
+	if len(primaryKey) == 0 {
+		return nil, nil
+	}
+	keyItems := model.NewRecordItems(len(primaryKey))
+	for _, column := range primaryKey {
+		keyItems.AddColumn(column, items.GetColumnValue(column))
+	}
+	key, err := keyItems.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize key of %s: %w", table, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,48 @@
+package connkafka
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestDebeziumEncoder(t *testing.T) {
+	encoder := newDebeziumEncoder("orders_mirror", map[string]*protos.TableSchema{
+		"orders": {PrimaryKeyColumns: []string{"id"}},
+	}, false, false)
+
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	items.AddColumn("note", types.QValueString{Val: "shipped"})
+	krs, err := encoder.encode(t.Context(), &model.DeleteRecord[model.RecordItems]{
+		BaseRecord:           model.BaseRecord{CheckpointID: 42},
+		Items:                items,
+		SourceTableName:      "public.orders",
+		DestinationTableName: "orders",
+	})
+	require.NoError(t, err)
+	require.Len(t, krs, 2)
+	require.Equal(t, "orders", krs[0].Topic)
+	require.JSONEq(t, `{"id":1}`, string(krs[0].Key))
+
+	var envelope map[string]any
+	require.NoError(t, json.Unmarshal(krs[0].Value, &envelope))
+	require.Equal(t, "d", envelope["op"])
+	require.Equal(t, map[string]any{"id": float64(1), "note": "shipped"}, envelope["before"])
+	require.Nil(t, envelope["after"])
+	source := envelope["source"].(map[string]any)
+	require.Equal(t, "orders_mirror", source["name"])
+	require.Equal(t, "public", source["schema"])
+	require.Equal(t, "orders", source["table"])
+	require.Equal(t, float64(42), source["lsn"])
+	require.Equal(t, "false", source["snapshot"])
+
+	// tombstone lets compacted topics drop the row
+	require.Equal(t, krs[0].Key, krs[1].Key)
+	require.Nil(t, krs[1].Value)
+}
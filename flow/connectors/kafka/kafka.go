@@ -3,9 +3,11 @@ package connkafka
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 
 	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils/schemaregistry"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -31,6 +34,9 @@ type KafkaConnector struct {
 	*metadataStore.PostgresMetadata
 	client *kgo.Client
 	logger log.Logger
+	// registry of avro schemas, only set when producing avro values
+	registry    schemaregistry.Registry
+	valueFormat protos.KafkaValueFormat
 }
 
 type kgoTemporalLogger struct {
@@ -75,7 +81,12 @@ func NewKafkaConnector(
 		kgo.WithLogger(kgoLogger(logger)),
 	)
 	if !config.DisableTls {
-		optionalOpts = append(optionalOpts, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS13}))
+		// server name is left to the client to set per broker unless overridden
+		tlsConfig, err := utils.PeerTlsConfig(config.Tls, tls.VersionTLS13, nil, "", "", false)
+		if err != nil {
+			return nil, err
+		}
+		optionalOpts = append(optionalOpts, kgo.DialTLSConfig(tlsConfig))
 	}
 	switch config.Partitioner {
 	case "LeastBackup":
@@ -109,6 +120,17 @@ func NewKafkaConnector(
 		optionalOpts = append(optionalOpts, kgo.UnknownTopicRetries(0))
 	}
 
+	var registry schemaregistry.Registry
+	if config.ValueFormat == protos.KafkaValueFormat_KAFKA_VALUE_AVRO {
+		if config.SchemaRegistry == nil {
+			return nil, errors.New("schema registry is required to produce avro values")
+		}
+		registry, err = schemaregistry.New(ctx, config.SchemaRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema registry client: %w", err)
+		}
+	}
+
 	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
 	if err != nil {
 		return nil, err
@@ -123,6 +145,8 @@ func NewKafkaConnector(
 		PostgresMetadata: pgMetadata,
 		client:           client,
 		logger:           logger,
+		registry:         registry,
+		valueFormat:      config.ValueFormat,
 	}, nil
 }
 
@@ -195,6 +219,65 @@ func lvalueToKafkaRecord(ls *lua.LState, value lua.LValue) (*kgo.Record, error)
 	return kr, nil
 }
 
+// scriptRecords calls onRecord of the script loaded in ls, returning the kafka records it produced for record
+func scriptRecords(ls *lua.LState, record model.Record[model.RecordItems]) ([]*kgo.Record, error) {
+	lfn := ls.Env.RawGetString("onRecord")
+	fn, ok := lfn.(*lua.LFunction)
+	if !ok {
+		return nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+	}
+
+	ls.Push(fn)
+	ls.Push(pua.LuaRecord.New(ls, record))
+	if err := ls.PCall(1, -1, nil); err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	args := ls.GetTop()
+	results := make([]*kgo.Record, 0, args)
+	for i := range args {
+		kr, err := lvalueToKafkaRecord(ls, ls.Get(i-args))
+		if err != nil {
+			return nil, err
+		}
+		if kr != nil {
+			results = append(results, kr)
+		}
+	}
+	ls.SetTop(0)
+	return results, nil
+}
+
+// recordEncoder produces records of a value format in place of a script
+type recordEncoder interface {
+	encode(context.Context, model.Record[model.RecordItems]) ([]*kgo.Record, error)
+}
+
+// kafkaRecords publishes rows of outbox tables as they are, encoding other records
+// with encoder when set, or else with the script loaded in ls
+func kafkaRecords(
+	ctx context.Context,
+	ls *lua.LState,
+	outbox utils.OutboxRouter,
+	encoder recordEncoder,
+	record model.Record[model.RecordItems],
+) ([]*kgo.Record, error) {
+	if msg, ok, err := outbox.Route(record); err != nil || ok {
+		if msg == nil {
+			return nil, err
+		}
+		kr := &kgo.Record{Topic: msg.Topic, Key: msg.Key, Value: msg.Value}
+		for _, header := range msg.Headers {
+			kr.Headers = append(kr.Headers, kgo.RecordHeader{Key: header.Name, Value: header.Value})
+		}
+		return []*kgo.Record{kr}, nil
+	}
+	if encoder == nil {
+		return scriptRecords(ls, record)
+	}
+	return encoder.encode(ctx, record)
+}
+
 type poolResult struct {
 	records []*kgo.Record
 	lsn     int64
@@ -273,6 +356,21 @@ func (c *KafkaConnector) SyncRecords(ctx context.Context, req *model.SyncRecords
 	numRecords := atomic.Int64{}
 	lastSeenLSN := atomic.Int64{}
 
+	var encoder recordEncoder
+	if c.valueFormat != protos.KafkaValueFormat_KAFKA_VALUE_SCRIPT && req.Script != "" {
+		return nil, fmt.Errorf("scripts are not supported when producing %s values", c.valueFormat)
+	}
+	switch c.valueFormat {
+	case protos.KafkaValueFormat_KAFKA_VALUE_AVRO:
+		encoder = newAvroEncoder(c.registry, req.Env, c.logger, avroFieldsFromTableSchemas(req.TableNameSchemaMapping), false)
+	case protos.KafkaValueFormat_KAFKA_VALUE_DEBEZIUM:
+		geoJSON, err := internal.PeerDBQueueGeoJSON(ctx, req.Env)
+		if err != nil {
+			return nil, err
+		}
+		encoder = newDebeziumEncoder(req.FlowJobName, req.TableNameSchemaMapping, false, geoJSON)
+	}
+
 	queueCtx, queueErr := context.WithCancelCause(ctx)
 
 	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, &lastSeenLSN, queueErr)
@@ -282,7 +380,13 @@ func (c *KafkaConnector) SyncRecords(ctx context.Context, req *model.SyncRecords
 	defer pool.Close()
 
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	outbox := utils.NewOutboxRouter(req.TableMappings)
+	schemaVersions := utils.NewSchemaVersions(req.TableNameSchemaMapping)
 	flushLoopDone := make(chan struct{})
+	diffUpdate, err := utils.QueueUpdateDiffer(ctx, req.Env, req.TableNameSchemaMapping)
+	if err != nil {
+		return nil, err
+	}
 	go func() {
 		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
 		if err != nil {
@@ -326,38 +430,27 @@ Loop:
 			}
 
 			pool.Run(func(ls *lua.LState) poolResult {
-				lfn := ls.Env.RawGetString("onRecord")
-				fn, ok := lfn.(*lua.LFunction)
-				if !ok {
-					queueErr(fmt.Errorf("script should define `onRecord` as function, not %s", lfn))
-					return poolResult{}
-				}
-
-				ls.Push(fn)
-				ls.Push(pua.LuaRecord.New(ls, record))
-				err := ls.PCall(1, -1, nil)
+				results, err := kafkaRecords(queueCtx, ls, outbox, encoder, diffUpdate(record))
 				if err != nil {
-					queueErr(fmt.Errorf("script failed: %w", err))
+					queueErr(err)
 					return poolResult{}
 				}
 
-				args := ls.GetTop()
-				results := make([]*kgo.Record, 0, args)
-				for i := range args {
-					kr, err := lvalueToKafkaRecord(ls, ls.Get(i-args))
-					if err != nil {
-						queueErr(err)
-						return poolResult{}
+				for _, kr := range results {
+					if kr.Topic == "" {
+						kr.Topic = record.GetDestinationTableName()
 					}
-					if kr != nil {
-						if kr.Topic == "" {
-							kr.Topic = record.GetDestinationTableName()
-						}
-						results = append(results, kr)
-						record.PopulateCountMap(tableNameRowsMapping)
+					if version, ok := schemaVersions.Get(record.GetDestinationTableName()); ok &&
+						!slices.ContainsFunc(kr.Headers, func(header kgo.RecordHeader) bool {
+							return header.Key == utils.SchemaVersionHeader
+						}) {
+						kr.Headers = append(kr.Headers, kgo.RecordHeader{
+							Key:   utils.SchemaVersionHeader,
+							Value: shared.UnsafeFastStringToReadOnlyBytes(version),
+						})
 					}
+					record.PopulateCountMap(tableNameRowsMapping)
 				}
-				ls.SetTop(0)
 				numRecords.Add(1)
 				return poolResult{
 					records: results,
@@ -374,6 +467,9 @@ Loop:
 	if err := pool.Wait(queueCtx); err != nil {
 		return nil, err
 	}
+	if err := c.publishSchemaChanges(queueCtx, req, schemaVersions); err != nil {
+		return nil, err
+	}
 	if err := c.client.Flush(queueCtx); err != nil {
 		return nil, fmt.Errorf("[kafka] final flush error: %w", err)
 	}
@@ -391,3 +487,38 @@ Loop:
 		TableSchemaDeltas:    req.Records.SchemaDeltas,
 	}, nil
 }
+
+func (c *KafkaConnector) publishSchemaChanges(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	schemaVersions *utils.SchemaVersions,
+) error {
+	if len(req.Records.SchemaDeltas) == 0 {
+		return nil
+	}
+	topic, err := internal.PeerDBQueueSchemaChangeTopic(ctx, req.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get schema change topic: %w", err)
+	} else if topic == "" {
+		return nil
+	}
+
+	for _, event := range schemaVersions.ChangeEvents(req.FlowJobName, req.SyncBatchID, req.Records.SchemaDeltas) {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to serialize schema change: %w", err)
+		}
+		if err := c.client.ProduceSync(ctx, &kgo.Record{
+			Key:   []byte(event.DestinationTable),
+			Value: value,
+			Topic: topic,
+			Headers: []kgo.RecordHeader{{
+				Key:   utils.SchemaVersionHeader,
+				Value: []byte(event.SchemaVersion),
+			}},
+		}).FirstErr(); err != nil {
+			return fmt.Errorf("[kafka] failed to publish schema change of %s: %w", event.DestinationTable, err)
+		}
+	}
+	return nil
+}
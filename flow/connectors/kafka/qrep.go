@@ -2,6 +2,7 @@ package connkafka
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -9,10 +10,12 @@ import (
 	"github.com/twmb/franz-go/pkg/kgo"
 	lua "github.com/yuin/gopher-lua"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
-	"github.com/PeerDB-io/peerdb/flow/pua"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 func (*KafkaConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
@@ -32,6 +35,26 @@ func (c *KafkaConnector) SyncQRepRecords(
 		return 0, nil, err
 	}
 
+	var encoder recordEncoder
+	if c.valueFormat != protos.KafkaValueFormat_KAFKA_VALUE_SCRIPT && config.Script != "" {
+		return 0, nil, fmt.Errorf("scripts are not supported when producing %s values", c.valueFormat)
+	}
+	switch c.valueFormat {
+	case protos.KafkaValueFormat_KAFKA_VALUE_AVRO:
+		encoder = newAvroEncoder(c.registry, config.Env, c.logger,
+			map[string][]types.QField{config.DestinationTableIdentifier: schema.Fields}, true)
+	case protos.KafkaValueFormat_KAFKA_VALUE_DEBEZIUM:
+		tableNameSchemaMapping, err := c.snapshotTableSchema(ctx, config)
+		if err != nil {
+			return 0, nil, err
+		}
+		geoJSON, err := internal.PeerDBQueueGeoJSON(ctx, config.Env)
+		if err != nil {
+			return 0, nil, err
+		}
+		encoder = newDebeziumEncoder(config.FlowJobName, tableNameSchemaMapping, true, geoJSON)
+	}
+
 	queueCtx, queueErr := context.WithCancelCause(ctx)
 	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, nil, queueErr)
 	if err != nil {
@@ -66,37 +89,16 @@ Loop:
 					CommitID:             0,
 				}
 
-				lfn := ls.Env.RawGetString("onRecord")
-				fn, ok := lfn.(*lua.LFunction)
-				if !ok {
-					queueErr(fmt.Errorf("script should define `onRecord` as function, not %s", lfn))
-					return poolResult{}
-				}
-
-				ls.Push(fn)
-				ls.Push(pua.LuaRecord.New(ls, record))
-				err := ls.PCall(1, -1, nil)
+				results, err := kafkaRecords(queueCtx, ls, nil, encoder, record)
 				if err != nil {
-					queueErr(fmt.Errorf("script failed: %w", err))
+					queueErr(err)
 					return poolResult{}
 				}
-
-				args := ls.GetTop()
-				results := make([]*kgo.Record, 0, args)
-				for i := range args {
-					kr, err := lvalueToKafkaRecord(ls, ls.Get(i-args))
-					if err != nil {
-						queueErr(err)
-						return poolResult{}
-					}
-					if kr != nil {
-						if kr.Topic == "" {
-							kr.Topic = record.GetDestinationTableName()
-						}
-						results = append(results, kr)
+				for _, kr := range results {
+					if kr.Topic == "" {
+						kr.Topic = record.GetDestinationTableName()
 					}
 				}
-				ls.SetTop(0)
 				numRecords.Add(1)
 				return poolResult{records: results}
 			})
@@ -118,3 +120,48 @@ Loop:
 	}
 	return numRecords.Load(), nil, nil
 }
+
+// snapshotTableSchema loads the schema of the destination table of an initial load from its CDC mirror,
+// standalone query replications have no primary key to key records on
+func (c *KafkaConnector) snapshotTableSchema(ctx context.Context, config *protos.QRepConfig) (map[string]*protos.TableSchema, error) {
+	if config.ParentMirrorName == "" {
+		return nil, nil
+	}
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tableSchema, err := internal.LoadTableSchemaFromCatalog(ctx, pool, config.ParentMirrorName, config.DestinationTableIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema of %s: %w", config.DestinationTableIdentifier, err)
+	}
+	return map[string]*protos.TableSchema{config.DestinationTableIdentifier: tableSchema}, nil
+}
+
+// ConsolidateQRepPartitions publishes the snapshot completion marker to the destination topic of an initial copy
+func (c *KafkaConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
+	event := utils.NewSnapshotCompletedEvent(config)
+	if event == nil {
+		return nil
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot completion: %w", err)
+	}
+	if err := c.client.ProduceSync(ctx, &kgo.Record{
+		Key:   []byte(event.DestinationTable),
+		Value: value,
+		Topic: config.DestinationTableIdentifier,
+		Headers: []kgo.RecordHeader{{
+			Key:   utils.SnapshotEventHeader,
+			Value: []byte(event.Event),
+		}},
+	}).FirstErr(); err != nil {
+		return fmt.Errorf("[kafka] failed to publish snapshot completion of %s: %w", event.DestinationTable, err)
+	}
+	return nil
+}
+
+func (*KafkaConnector) CleanupQRepFlow(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package connkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func (c *KafkaConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *KafkaConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *KafkaConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+// SetupNormalizedTable creates the destination topic of a table mapping with topic options,
+// other topics are left to be auto-created by the cluster on first produce
+func (c *KafkaConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	_ *protos.TableSchema,
+) (bool, error) {
+	var tableMapping *protos.TableMapping
+	for _, tm := range config.TableMappings {
+		if tm.DestinationTableIdentifier == destinationTableIdentifier {
+			tableMapping = tm
+			break
+		}
+	}
+	if !hasTopicOptions(tableMapping) {
+		return false, nil
+	}
+
+	partitions, replicationFactor, configs, err := topicOptions(tableMapping)
+	if err != nil {
+		return false, err
+	}
+	if _, err := kadm.NewClient(c.client).CreateTopic(
+		ctx, partitions, replicationFactor, configs, destinationTableIdentifier,
+	); err != nil {
+		if errors.Is(err, kerr.TopicAlreadyExists) {
+			// configs of existing topics are left as they are
+			c.logger.Info("[kafka] topic already exists", slog.String("topic", destinationTableIdentifier))
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to create topic %s: %w", destinationTableIdentifier, err)
+	}
+	return false, nil
+}
+
+func hasTopicOptions(tableMapping *protos.TableMapping) bool {
+	return tableMapping != nil && (tableMapping.TopicPartitions != nil || tableMapping.TopicReplicationFactor != nil ||
+		tableMapping.TopicCleanupPolicy != "" || len(tableMapping.TopicConfigs) > 0)
+}
+
+// topicOptions converts topic options of a table mapping to arguments of CreateTopic, -1 being the cluster default
+func topicOptions(tableMapping *protos.TableMapping) (int32, int16, map[string]*string, error) {
+	partitions := int32(-1)
+	if tableMapping.TopicPartitions != nil {
+		if tableMapping.GetTopicPartitions() == 0 || tableMapping.GetTopicPartitions() > math.MaxInt32 {
+			return 0, 0, nil, fmt.Errorf("invalid partition count %d for topic %s",
+				tableMapping.GetTopicPartitions(), tableMapping.DestinationTableIdentifier)
+		}
+		partitions = int32(tableMapping.GetTopicPartitions())
+	}
+	replicationFactor := int16(-1)
+	if tableMapping.TopicReplicationFactor != nil {
+		if tableMapping.GetTopicReplicationFactor() == 0 || tableMapping.GetTopicReplicationFactor() > math.MaxInt16 {
+			return 0, 0, nil, fmt.Errorf("invalid replication factor %d for topic %s",
+				tableMapping.GetTopicReplicationFactor(), tableMapping.DestinationTableIdentifier)
+		}
+		replicationFactor = int16(tableMapping.GetTopicReplicationFactor())
+	}
+
+	configs := make(map[string]*string, len(tableMapping.TopicConfigs)+1)
+	for key, value := range tableMapping.TopicConfigs {
+		configs[key] = &value
+	}
+	switch tableMapping.TopicCleanupPolicy {
+	case "":
+	case "delete", "compact", "compact,delete", "delete,compact":
+		configs["cleanup.policy"] = &tableMapping.TopicCleanupPolicy
+	default:
+		return 0, 0, nil, fmt.Errorf("invalid cleanup policy %s for topic %s, expected delete, compact or compact,delete",
+			tableMapping.TopicCleanupPolicy, tableMapping.DestinationTableIdentifier)
+	}
+	return partitions, replicationFactor, configs, nil
+}
@@ -0,0 +1,32 @@
+package connkafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestTopicOptions(t *testing.T) {
+	require.False(t, hasTopicOptions(&protos.TableMapping{DestinationTableIdentifier: "orders"}))
+
+	tableMapping := &protos.TableMapping{
+		DestinationTableIdentifier: "orders",
+		TopicPartitions:            proto.Uint32(12),
+		TopicCleanupPolicy:         "compact",
+		TopicConfigs:               map[string]string{"retention.ms": "86400000"},
+	}
+	require.True(t, hasTopicOptions(tableMapping))
+	partitions, replicationFactor, configs, err := topicOptions(tableMapping)
+	require.NoError(t, err)
+	require.Equal(t, int32(12), partitions)
+	require.Equal(t, int16(-1), replicationFactor)
+	require.Equal(t, "compact", *configs["cleanup.policy"])
+	require.Equal(t, "86400000", *configs["retention.ms"])
+
+	tableMapping.TopicCleanupPolicy = "forever"
+	_, _, _, err = topicOptions(tableMapping)
+	require.ErrorContains(t, err, "invalid cleanup policy")
+}
@@ -0,0 +1,126 @@
+package connkinesis
+
+import (
+	"crypto/md5"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// records are aggregated in the format of the Kinesis Producer Library so KCL consumers deaggregate them transparently,
+// https://github.com/awslabs/amazon-kinesis-producer/blob/master/aggregation-format.md
+var kplMagic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+
+const (
+	// limits of a single Kinesis record and of a PutRecords request, partition keys included
+	maxRecordSize       = 1024 * 1024
+	maxRequestSize      = 5 * 1024 * 1024
+	maxRequestRecords   = 500
+	maxPartitionKeySize = 256
+
+	// field numbers of AggregatedRecord & Record messages
+	kplPartitionKeyTableField = 1
+	kplRecordsField           = 3
+	kplPartitionKeyIndexField = 1
+	kplDataField              = 3
+)
+
+type kinesisRecord struct {
+	partitionKey string
+	data         []byte
+}
+
+func (r kinesisRecord) size() int {
+	return len(r.partitionKey) + len(r.data)
+}
+
+// aggregator builds a KPL aggregated record out of user records headed to the same shard
+type aggregator struct {
+	// starting hash key of shard, nil when aggregating records of one partition key
+	explicitHashKey *string
+	keyIndex        map[string]uint64
+	keys            []string
+	records         []kinesisRecord
+	// size of encoded AggregatedRecord message, without magic & checksum
+	size int
+}
+
+func newAggregator(explicitHashKey *string) *aggregator {
+	return &aggregator{explicitHashKey: explicitHashKey, keyIndex: make(map[string]uint64)}
+}
+
+func (a *aggregator) len() int {
+	return len(a.records)
+}
+
+// sizeWith returns the size the Kinesis record would have after adding record
+func (a *aggregator) sizeWith(record kinesisRecord) int {
+	size := a.size
+	index, ok := a.keyIndex[record.partitionKey]
+	if !ok {
+		index = uint64(len(a.keys))
+		size += protowire.SizeTag(kplPartitionKeyTableField) + protowire.SizeBytes(len(record.partitionKey))
+	}
+	size += protowire.SizeTag(kplRecordsField) + protowire.SizeBytes(userRecordSize(index, record.data))
+	return len(kplMagic) + size + md5.Size + len(a.partitionKey(record))
+}
+
+func (a *aggregator) add(record kinesisRecord) {
+	index, ok := a.keyIndex[record.partitionKey]
+	if !ok {
+		index = uint64(len(a.keys))
+		a.keyIndex[record.partitionKey] = index
+		a.keys = append(a.keys, record.partitionKey)
+		a.size += protowire.SizeTag(kplPartitionKeyTableField) + protowire.SizeBytes(len(record.partitionKey))
+	}
+	a.size += protowire.SizeTag(kplRecordsField) + protowire.SizeBytes(userRecordSize(index, record.data))
+	a.records = append(a.records, record)
+}
+
+// partitionKey of aggregated record, Kinesis routes it by its explicit hash key
+func (a *aggregator) partitionKey(next kinesisRecord) string {
+	if len(a.keys) > 0 {
+		return a.keys[0]
+	}
+	return next.partitionKey
+}
+
+// drain returns the Kinesis record of aggregated user records & resets aggregator,
+// a single user record is passed on as is since aggregating it only adds overhead
+func (a *aggregator) drain() kinesisRecord {
+	var record kinesisRecord
+	if len(a.records) == 1 {
+		record = a.records[0]
+	} else {
+		record = kinesisRecord{partitionKey: a.keys[0], data: a.encode()}
+	}
+	clear(a.keyIndex)
+	a.keys = a.keys[:0]
+	a.records = a.records[:0]
+	a.size = 0
+	return record
+}
+
+func (a *aggregator) encode() []byte {
+	buf := make([]byte, len(kplMagic), len(kplMagic)+a.size+md5.Size)
+	copy(buf, kplMagic)
+	for _, key := range a.keys {
+		buf = protowire.AppendTag(buf, kplPartitionKeyTableField, protowire.BytesType)
+		buf = protowire.AppendString(buf, key)
+	}
+	for _, record := range a.records {
+		index := a.keyIndex[record.partitionKey]
+		buf = protowire.AppendTag(buf, kplRecordsField, protowire.BytesType)
+		buf = protowire.AppendVarint(buf, uint64(userRecordSize(index, record.data)))
+		buf = protowire.AppendTag(buf, kplPartitionKeyIndexField, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, index)
+		buf = protowire.AppendTag(buf, kplDataField, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, record.data)
+	}
+	checksum := md5.Sum(buf[len(kplMagic):])
+	return append(buf, checksum[:]...)
+}
+
+func userRecordSize(partitionKeyIndex uint64, data []byte) int {
+	return protowire.SizeTag(kplPartitionKeyIndexField) + protowire.SizeVarint(partitionKeyIndex) +
+		protowire.SizeTag(kplDataField) + protowire.SizeBytes(len(data))
+}
@@ -0,0 +1,97 @@
+package connkinesis
+
+import (
+	"bytes"
+	"crypto/md5"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestAggregatorEncode(t *testing.T) {
+	agg := newAggregator(nil)
+	records := []kinesisRecord{
+		{partitionKey: "a", data: []byte("one")},
+		{partitionKey: "b", data: []byte("two")},
+		{partitionKey: "a", data: []byte("three")},
+	}
+	for _, record := range records {
+		agg.add(record)
+	}
+	size := agg.sizeWith(kinesisRecord{partitionKey: "a"})
+	encoded := agg.encode()
+	require.Equal(t, len(encoded)+len("a")+protowire.SizeTag(kplRecordsField)+protowire.SizeBytes(userRecordSize(0, nil)), size)
+
+	require.True(t, bytes.HasPrefix(encoded, kplMagic))
+	message := encoded[len(kplMagic) : len(encoded)-md5.Size]
+	checksum := md5.Sum(message)
+	require.Equal(t, checksum[:], encoded[len(encoded)-md5.Size:])
+
+	var keys []string
+	var decoded []kinesisRecord
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		require.Equal(t, protowire.BytesType, typ)
+		message = message[n:]
+		value, n := protowire.ConsumeBytes(message)
+		require.GreaterOrEqual(t, n, 0)
+		message = message[n:]
+		switch num {
+		case kplPartitionKeyTableField:
+			keys = append(keys, string(value))
+		case kplRecordsField:
+			_, _, n := protowire.ConsumeTag(value)
+			index, m := protowire.ConsumeVarint(value[n:])
+			_, _, o := protowire.ConsumeTag(value[n+m:])
+			data, _ := protowire.ConsumeBytes(value[n+m+o:])
+			decoded = append(decoded, kinesisRecord{partitionKey: keys[index], data: data})
+		}
+	}
+	require.Equal(t, []string{"a", "b"}, keys)
+	require.Equal(t, records, decoded)
+
+	record := agg.drain()
+	require.Equal(t, "a", record.partitionKey)
+	require.Equal(t, encoded, record.data)
+	require.Equal(t, 0, agg.len())
+
+	agg.add(records[1])
+	require.Equal(t, records[1], agg.drain())
+}
+
+func TestShardFor(t *testing.T) {
+	// two shards splitting hash key space in half
+	half := new(big.Int).Lsh(big.NewInt(1), 127)
+	m := &shardMap{
+		startingHashKeys: []string{"0", half.String()},
+		endingHashKeys:   []*big.Int{new(big.Int).Sub(half, big.NewInt(1)), new(big.Int).Sub(new(big.Int).Lsh(half, 1), big.NewInt(1))},
+	}
+	for _, key := range []string{"a", "b", "c", "d"} {
+		shard, ok := m.shardFor(key)
+		require.True(t, ok)
+		sum := md5.Sum([]byte(key))
+		if sum[0] >= 0x80 {
+			require.Equal(t, half.String(), shard)
+		} else {
+			require.Equal(t, "0", shard)
+		}
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 7})
+	items.AddColumn("tenant", types.QValueString{Val: "acme"})
+	require.Equal(t, "7", partitionKeyFromItems(items, []string{"id"}, 1))
+	require.Equal(t, "7,acme,", partitionKeyFromItems(items, []string{"id", "tenant", "missing"}, 1))
+	require.Equal(t, "3", partitionKeyFromItems(items, nil, 3))
+
+	require.Equal(t, "-", normalizePartitionKey(""))
+	require.Len(t, normalizePartitionKey(strings.Repeat("x", maxPartitionKeySize+1)), 32)
+}
@@ -0,0 +1,385 @@
+package connkinesis
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+type KinesisConnector struct {
+	*metadataStore.PostgresMetadata
+	client *kinesis.Client
+	config *protos.KinesisConfig
+	logger log.Logger
+}
+
+func NewKinesisConnector(
+	ctx context.Context,
+	env map[string]string,
+	config *protos.KinesisConfig,
+) (*KinesisConnector, error) {
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "kinesis", utils.PeerAWSCredentials{
+		Credentials: aws.Credentials{
+			AccessKeyID:     config.GetAccessKeyId(),
+			SecretAccessKey: config.GetSecretAccessKey(),
+		},
+		RoleArn:     config.RoleArn,
+		EndpointUrl: config.Endpoint,
+		Region:      config.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aws credentials: %w", err)
+	}
+
+	options := kinesis.Options{
+		Region:      provider.GetRegion(),
+		Credentials: provider.GetUnderlyingProvider(),
+	}
+	if endpoint := config.GetEndpoint(); endpoint != "" {
+		options.BaseEndpoint = aws.String(endpoint)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KinesisConnector{
+		PostgresMetadata: pgMetadata,
+		client:           kinesis.New(options),
+		config:           config,
+		logger:           internal.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *KinesisConnector) Close() error {
+	return nil
+}
+
+func (c *KinesisConnector) ConnectionActive(ctx context.Context) error {
+	if _, err := c.client.ListStreams(ctx, &kinesis.ListStreamsInput{Limit: aws.Int32(1)}); err != nil {
+		return fmt.Errorf("kinesis connection active check failure: %w", err)
+	}
+	return nil
+}
+
+func (c *KinesisConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *KinesisConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+// KinesisMessage is a record produced by script, Kinesis records have no headers
+// so schema versions are only published on the schema change stream
+type KinesisMessage struct {
+	kinesisRecord
+	Stream string
+}
+
+// lvalueToKinesisMessage converts script results, `topic` names the stream so scripts work across queue peers.
+// Partition key defaults to primary key of record when script does not set `key`
+func lvalueToKinesisMessage(ls *lua.LState, value lua.LValue) (*KinesisMessage, error) {
+	switch v := value.(type) {
+	case lua.LString:
+		return &KinesisMessage{kinesisRecord: kinesisRecord{data: shared.UnsafeFastStringToReadOnlyBytes(string(v))}}, nil
+	case *lua.LTable:
+		key, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "key"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key, %w", err)
+		}
+		value, err := utils.LVAsReadOnlyBytes(ls, ls.GetField(v, "value"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value, %w", err)
+		}
+		stream, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "topic"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid topic, %w", err)
+		}
+		return &KinesisMessage{
+			kinesisRecord: kinesisRecord{partitionKey: key, data: value},
+			Stream:        stream,
+		}, nil
+	case *lua.LNilType:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("script returned invalid value: %s", value)
+	}
+}
+
+// partitionKeyFromItems joins primary key values, keyless records are spread over shards by sequence
+func partitionKeyFromItems(items model.RecordItems, primaryKeyColumns []string, seq int64) string {
+	if len(primaryKeyColumns) == 0 {
+		return strconv.FormatInt(seq, 10)
+	}
+	parts := make([]string, 0, len(primaryKeyColumns))
+	for _, col := range primaryKeyColumns {
+		if qv := items.GetColumnValue(col); qv != nil && qv.Value() != nil {
+			parts = append(parts, fmt.Sprint(qv.Value()))
+		} else {
+			parts = append(parts, "")
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// normalizePartitionKey fits key into the 1 to 256 characters Kinesis accepts,
+// long keys are hashed which keeps records of the same key on the same shard
+func normalizePartitionKey(key string) string {
+	if key == "" {
+		return "-"
+	} else if len(key) > maxPartitionKeySize {
+		sum := md5.Sum([]byte(key))
+		return hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+type poolResult struct {
+	messages []KinesisMessage
+	lsn      int64
+}
+
+func (c *KinesisConnector) createPool(
+	ctx context.Context,
+	env map[string]string,
+	script string,
+	flowJobName string,
+	producer *producer,
+	queueErr func(error),
+) (*utils.LPool[poolResult], error) {
+	maxSize, err := internal.PeerDBQueueParallelism(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parallelism: %w", err)
+	}
+
+	return utils.LuaPool(int(maxSize), func() (*lua.LState, error) {
+		ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+			_ = c.LogFlowInfo(ctx, flowJobName, s)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("[kinesis] error loading script: %w", err)
+		}
+		if script == "" {
+			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
+		}
+		return ls, nil
+	}, func(result poolResult) {
+		if err := producer.Add(ctx, result.messages, result.lsn); err != nil {
+			queueErr(err)
+		}
+	})
+}
+
+// runScript calls onRecord of script, filling in stream & partition key of results left unset
+func runScript(
+	ls *lua.LState,
+	record model.Record[model.RecordItems],
+	primaryKeyColumns []string,
+	seq int64,
+) ([]KinesisMessage, error) {
+	lfn := ls.Env.RawGetString("onRecord")
+	fn, ok := lfn.(*lua.LFunction)
+	if !ok {
+		return nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+	}
+
+	ls.Push(fn)
+	ls.Push(pua.LuaRecord.New(ls, record))
+	if err := ls.PCall(1, -1, nil); err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	args := ls.GetTop()
+	results := make([]KinesisMessage, 0, args)
+	for i := range args {
+		msg, err := lvalueToKinesisMessage(ls, ls.Get(i-args))
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			if msg.Stream == "" {
+				msg.Stream = record.GetDestinationTableName()
+			}
+			if msg.partitionKey == "" {
+				msg.partitionKey = partitionKeyFromItems(record.GetItems(), primaryKeyColumns, seq)
+			}
+			msg.partitionKey = normalizePartitionKey(msg.partitionKey)
+			results = append(results, *msg)
+		}
+	}
+	ls.SetTop(0)
+	return results, nil
+}
+
+func (c *KinesisConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	numRecords := atomic.Int64{}
+	lastSeenLSN := atomic.Int64{}
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+
+	producer := c.newProducer(req.Env, &lastSeenLSN)
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, producer, queueErr)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	schemaVersions := utils.NewSchemaVersions(req.TableNameSchemaMapping)
+	flushLoopDone := make(chan struct{})
+	diffUpdate, err := utils.QueueUpdateDiffer(ctx, req.Env, req.TableNameSchemaMapping)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+		if err != nil {
+			c.logger.Warn("[kinesis] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
+			return
+		}
+		ticker := time.NewTicker(flushTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushLoopDone:
+				return
+			// flush loop doesn't block processing new messages
+			case <-ticker.C:
+				if err := producer.Flush(queueCtx); err != nil {
+					c.logger.Warn("[kinesis] flush error", slog.Any("error", err))
+					continue
+				}
+				if lastSeen := lastSeenLSN.Load(); lastSeen > req.ConsumedOffset.Load() {
+					if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: lastSeen}); err != nil {
+						c.logger.Warn("[kinesis] SetLastOffset error", slog.Any("error", err))
+					} else {
+						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
+						c.logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
+					}
+				}
+			}
+		}
+	}()
+
+Loop:
+	for {
+		select {
+		case record, ok := <-req.Records.GetRecords():
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			var primaryKeyColumns []string
+			if schema, ok := req.TableNameSchemaMapping[record.GetDestinationTableName()]; ok {
+				primaryKeyColumns = schema.PrimaryKeyColumns
+			}
+			seq := numRecords.Add(1)
+			pool.Run(func(ls *lua.LState) poolResult {
+				results, err := runScript(ls, diffUpdate(record), primaryKeyColumns, seq)
+				if err != nil {
+					queueErr(err)
+					return poolResult{}
+				}
+				for range results {
+					record.PopulateCountMap(tableNameRowsMapping)
+				}
+				return poolResult{
+					messages: results,
+					lsn:      record.GetCheckpointID(),
+				}
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	close(flushLoopDone)
+	if err := pool.Wait(queueCtx); err != nil {
+		return nil, err
+	}
+	if err := producer.Flush(queueCtx); err != nil {
+		return nil, fmt.Errorf("[kinesis] final flush error: %w", err)
+	}
+	if err := c.publishSchemaChanges(queueCtx, req, schemaVersions); err != nil {
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords.Load(),
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *KinesisConnector) publishSchemaChanges(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	schemaVersions *utils.SchemaVersions,
+) error {
+	if len(req.Records.SchemaDeltas) == 0 {
+		return nil
+	}
+	stream, err := internal.PeerDBQueueSchemaChangeTopic(ctx, req.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get schema change topic: %w", err)
+	} else if stream == "" {
+		return nil
+	}
+
+	producer := c.newProducer(req.Env, nil)
+	events := schemaVersions.ChangeEvents(req.FlowJobName, req.SyncBatchID, req.Records.SchemaDeltas)
+	messages := make([]KinesisMessage, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to serialize schema change: %w", err)
+		}
+		messages = append(messages, KinesisMessage{
+			kinesisRecord: kinesisRecord{partitionKey: normalizePartitionKey(event.DestinationTable), data: data},
+			Stream:        stream,
+		})
+	}
+	if err := producer.Add(ctx, messages, 0); err != nil {
+		return err
+	}
+	if err := producer.Flush(ctx); err != nil {
+		return fmt.Errorf("[kinesis] failed to publish schema changes: %w", err)
+	}
+	return nil
+}
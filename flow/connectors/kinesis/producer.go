@@ -0,0 +1,334 @@
+package connkinesis
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const (
+	// entries failing for reasons other than throttling are retried this many times before giving up,
+	// throttled entries are retried until they succeed or the sync is cancelled
+	maxFailedEntryRetries = 5
+	initialRetryBackoff   = 100 * time.Millisecond
+	maxRetryBackoff       = 10 * time.Second
+	streamCreationTimeout = 5 * time.Minute
+)
+
+// shardMap maps hash keys to the open shards of a stream, sorted by hash key range
+type shardMap struct {
+	startingHashKeys []string
+	endingHashKeys   []*big.Int
+}
+
+// shardFor returns the starting hash key of the shard a partition key is routed to
+func (m *shardMap) shardFor(partitionKey string) (string, bool) {
+	sum := md5.Sum([]byte(partitionKey))
+	hashKey := new(big.Int).SetBytes(sum[:])
+	idx, _ := slices.BinarySearchFunc(m.endingHashKeys, hashKey, func(end *big.Int, target *big.Int) int {
+		return end.Cmp(target)
+	})
+	if idx == len(m.endingHashKeys) {
+		return "", false
+	}
+	return m.startingHashKeys[idx], true
+}
+
+type streamBuffer struct {
+	// nil when shards could not be listed, records are then only aggregated with records of the same partition key
+	shards      *shardMap
+	aggregators map[string]*aggregator
+	entries     []types.PutRecordsRequestEntry
+	size        int
+}
+
+// producer batches records into PutRecords requests per stream, aggregating records headed to the same shard
+// the way the Kinesis Producer Library does. Add & Flush are serialized, sending blocks adding records.
+type producer struct {
+	client    *kinesis.Client
+	logger    log.Logger
+	env       map[string]string
+	streams   map[string]*streamBuffer
+	flushed   *atomic.Int64
+	lock      sync.Mutex
+	lsn       int64
+	aggregate bool
+}
+
+func (c *KinesisConnector) newProducer(env map[string]string, flushed *atomic.Int64) *producer {
+	return &producer{
+		client:    c.client,
+		logger:    c.logger,
+		env:       env,
+		streams:   make(map[string]*streamBuffer),
+		flushed:   flushed,
+		aggregate: !c.config.DisableAggregation,
+	}
+}
+
+// Add buffers records of a source record with the given checkpoint, sending requests of streams which are full
+func (p *producer) Add(ctx context.Context, messages []KinesisMessage, lsn int64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, message := range messages {
+		if message.size() > maxRecordSize {
+			return fmt.Errorf("[kinesis] record of %d bytes for stream %s exceeds limit of %d bytes",
+				message.size(), message.Stream, maxRecordSize)
+		}
+		buffer, err := p.streamBuffer(ctx, message.Stream)
+		if err != nil {
+			return err
+		}
+		if !p.aggregate {
+			if err := p.appendEntry(ctx, message.Stream, buffer, message.kinesisRecord, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		group := message.partitionKey
+		var explicitHashKey *string
+		if buffer.shards != nil {
+			if shard, ok := buffer.shards.shardFor(message.partitionKey); ok {
+				group = shard
+				explicitHashKey = &shard
+			}
+		}
+		agg, ok := buffer.aggregators[group]
+		if !ok {
+			agg = newAggregator(explicitHashKey)
+			buffer.aggregators[group] = agg
+		} else if agg.len() > 0 && agg.sizeWith(message.kinesisRecord) > maxRecordSize {
+			if err := p.appendEntry(ctx, message.Stream, buffer, agg.drain(), agg.explicitHashKey); err != nil {
+				return err
+			}
+		}
+		agg.add(message.kinesisRecord)
+	}
+	p.lsn = lsn
+	return nil
+}
+
+// Flush sends all buffered records, after which checkpoint of last added records is considered published
+func (p *producer) Flush(ctx context.Context) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for stream, buffer := range p.streams {
+		for _, agg := range buffer.aggregators {
+			if agg.len() == 0 {
+				continue
+			}
+			if err := p.appendEntry(ctx, stream, buffer, agg.drain(), agg.explicitHashKey); err != nil {
+				return err
+			}
+		}
+		if err := p.send(ctx, stream, buffer); err != nil {
+			return err
+		}
+	}
+	if p.flushed != nil {
+		shared.AtomicInt64Max(p.flushed, p.lsn)
+	}
+	return nil
+}
+
+func (p *producer) streamBuffer(ctx context.Context, stream string) (*streamBuffer, error) {
+	if buffer, ok := p.streams[stream]; ok {
+		return buffer, nil
+	}
+	shards, err := p.loadShardMap(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	buffer := &streamBuffer{
+		shards:      shards,
+		aggregators: make(map[string]*aggregator),
+	}
+	p.streams[stream] = buffer
+	return buffer, nil
+}
+
+func (p *producer) appendEntry(
+	ctx context.Context, stream string, buffer *streamBuffer, record kinesisRecord, explicitHashKey *string,
+) error {
+	if len(buffer.entries) >= maxRequestRecords || buffer.size+record.size() > maxRequestSize {
+		if err := p.send(ctx, stream, buffer); err != nil {
+			return err
+		}
+	}
+	buffer.entries = append(buffer.entries, types.PutRecordsRequestEntry{
+		Data:            record.data,
+		PartitionKey:    aws.String(record.partitionKey),
+		ExplicitHashKey: explicitHashKey,
+	})
+	buffer.size += record.size()
+	return nil
+}
+
+// send puts buffered entries of a stream, retrying entries which failed with backoff.
+// Retried entries may land after later entries of the same shard which succeeded on first attempt.
+func (p *producer) send(ctx context.Context, stream string, buffer *streamBuffer) error {
+	entries := buffer.entries
+	if len(entries) == 0 {
+		return nil
+	}
+
+	backoff := initialRetryBackoff
+	failures := 0
+	for {
+		// whole request throttling errors are already retried by the SDK
+		output, err := p.client.PutRecords(ctx, &kinesis.PutRecordsInput{
+			StreamName: aws.String(stream),
+			Records:    entries,
+		})
+		if err != nil {
+			return fmt.Errorf("[kinesis] failed to put records to stream %s: %w", stream, err)
+		}
+		if aws.ToInt32(output.FailedRecordCount) == 0 {
+			break
+		}
+
+		failed := make([]types.PutRecordsRequestEntry, 0, aws.ToInt32(output.FailedRecordCount))
+		throttled := true
+		var lastError string
+		for i, result := range output.Records {
+			if result.ErrorCode == nil {
+				continue
+			}
+			failed = append(failed, entries[i])
+			if *result.ErrorCode != "ProvisionedThroughputExceededException" {
+				throttled = false
+				lastError = *result.ErrorCode + ": " + aws.ToString(result.ErrorMessage)
+			}
+		}
+		if !throttled {
+			failures += 1
+			if failures > maxFailedEntryRetries {
+				return fmt.Errorf("[kinesis] failed to put %d records to stream %s: %s", len(failed), stream, lastError)
+			}
+		}
+		p.logger.Warn("[kinesis] retrying failed records",
+			slog.String("stream", stream),
+			slog.Int("failed", len(failed)),
+			slog.Bool("throttled", throttled),
+			slog.Duration("backoff", backoff))
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+		entries = failed
+	}
+
+	clear(buffer.entries)
+	buffer.entries = buffer.entries[:0]
+	buffer.size = 0
+	return nil
+}
+
+// loadShardMap lists open shards of stream, creating it when PEERDB_QUEUE_FORCE_TOPIC_CREATION is set
+func (p *producer) loadShardMap(ctx context.Context, stream string) (*shardMap, error) {
+	shards, err := p.listShards(ctx, stream)
+	if err != nil {
+		if notFound := (*types.ResourceNotFoundException)(nil); errors.As(err, &notFound) {
+			force, envErr := internal.PeerDBQueueForceTopicCreation(ctx, p.env)
+			if envErr != nil {
+				return nil, envErr
+			}
+			if !force {
+				return nil, fmt.Errorf("[kinesis] stream %s does not exist: %w", stream, err)
+			}
+			if err := p.createStream(ctx, stream); err != nil {
+				return nil, err
+			}
+			shards, err = p.listShards(ctx, stream)
+		}
+		if err != nil {
+			// aggregation falls back to grouping by partition key, putting records will surface real problems
+			p.logger.Warn("[kinesis] failed to list shards", slog.String("stream", stream), slog.Any("error", err))
+			return nil, nil
+		}
+	}
+
+	slices.SortFunc(shards, func(a types.Shard, b types.Shard) int {
+		return parseHashKey(a.HashKeyRange.EndingHashKey).Cmp(parseHashKey(b.HashKeyRange.EndingHashKey))
+	})
+	m := &shardMap{
+		startingHashKeys: make([]string, 0, len(shards)),
+		endingHashKeys:   make([]*big.Int, 0, len(shards)),
+	}
+	for _, shard := range shards {
+		m.startingHashKeys = append(m.startingHashKeys, aws.ToString(shard.HashKeyRange.StartingHashKey))
+		m.endingHashKeys = append(m.endingHashKeys, parseHashKey(shard.HashKeyRange.EndingHashKey))
+	}
+	return m, nil
+}
+
+func (p *producer) listShards(ctx context.Context, stream string) ([]types.Shard, error) {
+	var shards []types.Shard
+	input := &kinesis.ListShardsInput{
+		StreamName:  aws.String(stream),
+		ShardFilter: &types.ShardFilter{Type: types.ShardFilterTypeAtLatest},
+	}
+	for {
+		output, err := p.client.ListShards(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, shard := range output.Shards {
+			if shard.HashKeyRange != nil {
+				shards = append(shards, shard)
+			}
+		}
+		if output.NextToken == nil {
+			return shards, nil
+		}
+		// stream name may not be combined with next token
+		input = &kinesis.ListShardsInput{NextToken: output.NextToken}
+	}
+}
+
+func (p *producer) createStream(ctx context.Context, stream string) error {
+	p.logger.Info("[kinesis] force stream creation", slog.String("stream", stream))
+	if _, err := p.client.CreateStream(ctx, &kinesis.CreateStreamInput{
+		StreamName:        aws.String(stream),
+		StreamModeDetails: &types.StreamModeDetails{StreamMode: types.StreamModeOnDemand},
+	}); err != nil {
+		if inUse := (*types.ResourceInUseException)(nil); !errors.As(err, &inUse) {
+			return fmt.Errorf("[kinesis] failed to create stream %s: %w", stream, err)
+		}
+	}
+	if err := kinesis.NewStreamExistsWaiter(p.client).Wait(
+		ctx, &kinesis.DescribeStreamInput{StreamName: aws.String(stream)}, streamCreationTimeout,
+	); err != nil {
+		return fmt.Errorf("[kinesis] stream %s did not become active: %w", stream, err)
+	}
+	return nil
+}
+
+func parseHashKey(key *string) *big.Int {
+	value, ok := new(big.Int).SetString(aws.ToString(key), 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return value
+}
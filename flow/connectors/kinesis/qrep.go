@@ -0,0 +1,123 @@
+package connkinesis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*KinesisConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *KinesisConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	numRecords := atomic.Int64{}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+	producer := c.newProducer(config.Env, nil)
+	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, producer, queueErr)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer pool.Close()
+
+	shutdown := shared.Interval(ctx, time.Minute, func() {
+		c.logger.Info(fmt.Sprintf("sent %d records", numRecords.Load()))
+	})
+	defer shutdown()
+
+Loop:
+	for {
+		select {
+		case qrecord, ok := <-stream.Records:
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			seq := numRecords.Add(1)
+			pool.Run(func(ls *lua.LState) poolResult {
+				items := model.NewRecordItems(len(qrecord))
+				for i, val := range qrecord {
+					items.AddColumn(schema.Fields[i].Name, val)
+				}
+				record := &model.InsertRecord[model.RecordItems]{
+					BaseRecord:           model.BaseRecord{},
+					Items:                items,
+					SourceTableName:      config.WatermarkTable,
+					DestinationTableName: config.DestinationTableIdentifier,
+					CommitID:             0,
+				}
+
+				// snapshot carries no primary key, rows are spread over shards
+				results, err := runScript(ls, record, nil, seq)
+				if err != nil {
+					queueErr(err)
+					return poolResult{}
+				}
+				return poolResult{messages: results}
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	if err := pool.Wait(queueCtx); err != nil {
+		return 0, nil, err
+	}
+	if err := producer.Flush(queueCtx); err != nil {
+		return 0, nil, fmt.Errorf("[kinesis] final flush error: %w", err)
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords.Load(), nil, nil
+}
+
+// ConsolidateQRepPartitions publishes the snapshot completion marker to the destination stream of an initial copy
+func (c *KinesisConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
+	event := utils.NewSnapshotCompletedEvent(config)
+	if event == nil {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot completion: %w", err)
+	}
+	producer := c.newProducer(config.Env, nil)
+	if err := producer.Add(ctx, []KinesisMessage{{
+		kinesisRecord: kinesisRecord{partitionKey: normalizePartitionKey(event.DestinationTable), data: data},
+		Stream:        config.DestinationTableIdentifier,
+	}}, 0); err != nil {
+		return err
+	}
+	if err := producer.Flush(ctx); err != nil {
+		return fmt.Errorf("[kinesis] failed to publish snapshot completion of %s: %w", event.DestinationTable, err)
+	}
+	return nil
+}
+
+func (*KinesisConnector) CleanupQRepFlow(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
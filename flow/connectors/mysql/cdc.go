@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math/rand/v2"
 	"slices"
 	"strconv"
@@ -32,6 +33,11 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
+const (
+	binlogHeartbeatPeriod          = 30 * time.Second
+	snapshotLockWaitTimeoutSeconds = 10
+)
+
 func (c *MySqlConnector) GetTableSchema(
 	ctx context.Context,
 	env map[string]string,
@@ -164,18 +170,14 @@ func (c *MySqlConnector) SetupReplication(
 		gtidModeOn = c.config.ReplicationMechanism == protos.MySqlReplicationMechanism_MYSQL_GTID
 	}
 	var lastOffsetText string
-	if gtidModeOn {
-		set, err := c.GetMasterGTIDSet(ctx)
-		if err != nil {
-			return model.SetupReplicationResult{}, fmt.Errorf("[mysql] SetupReplication failed to GetMasterGTIDSet: %w", err)
-		}
-		lastOffsetText = set.String()
+	var err error
+	if req.DoInitialSnapshot {
+		lastOffsetText, err = c.getSnapshotOffsetText(ctx, gtidModeOn)
 	} else {
-		pos, err := c.GetMasterPos(ctx)
-		if err != nil {
-			return model.SetupReplicationResult{}, fmt.Errorf("[mysql] SetupReplication failed to GetMasterPos: %w", err)
-		}
-		lastOffsetText = posToOffsetText(pos)
+		lastOffsetText, err = c.getCurrentOffsetText(ctx, gtidModeOn)
+	}
+	if err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("[mysql] SetupReplication failed to get binlog position: %w", err)
 	}
 	if err := c.SetLastOffset(
 		ctx, req.FlowJobName, model.CdcCheckpoint{Text: lastOffsetText},
@@ -186,6 +188,108 @@ func (c *MySqlConnector) SetupReplication(
 	return model.SetupReplicationResult{}, nil
 }
 
+func (c *MySqlConnector) getCurrentOffsetText(ctx context.Context, gtidModeOn bool) (string, error) {
+	if gtidModeOn {
+		set, err := c.GetMasterGTIDSet(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to GetMasterGTIDSet: %w", err)
+		}
+		return set.String(), nil
+	}
+	pos, err := c.GetMasterPos(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to GetMasterPos: %w", err)
+	}
+	return posToOffsetText(pos), nil
+}
+
+// getSnapshotOffsetText reads the binlog position at a transaction boundary before the initial load starts,
+// so CDC begins at a point every row the snapshot reads is already committed at, changes made while
+// the snapshot runs are replayed on top of it. MariaDB reports the position of a consistent snapshot transaction,
+// MySQL takes a short global read lock, which managed services may not allow, then the position is read unlocked.
+func (c *MySqlConnector) getSnapshotOffsetText(ctx context.Context, gtidModeOn bool) (string, error) {
+	if c.Flavor() == mysql.MariaDBFlavor {
+		return c.getMariaSnapshotOffsetText(ctx, gtidModeOn)
+	}
+
+	if _, err := c.Execute(ctx, fmt.Sprintf("SET SESSION lock_wait_timeout = %d", snapshotLockWaitTimeoutSeconds)); err != nil {
+		return "", fmt.Errorf("failed to set lock_wait_timeout: %w", err)
+	}
+	defer func() {
+		if _, err := c.Execute(ctx, "SET SESSION lock_wait_timeout = DEFAULT"); err != nil {
+			c.logger.Warn("failed to reset lock_wait_timeout", slog.Any("error", err))
+		}
+	}()
+	if _, err := c.Execute(ctx, "FLUSH TABLES WITH READ LOCK"); err != nil {
+		c.logger.Warn("[mysql] could not take global read lock, reading binlog position without it", slog.Any("error", err))
+		return c.getCurrentOffsetText(ctx, gtidModeOn)
+	}
+	offsetText, err := c.getCurrentOffsetText(ctx, gtidModeOn)
+	if _, unlockErr := c.Execute(ctx, "UNLOCK TABLES"); unlockErr != nil {
+		return "", fmt.Errorf("failed to release global read lock: %w", unlockErr)
+	}
+	return offsetText, err
+}
+
+func (c *MySqlConnector) getMariaSnapshotOffsetText(ctx context.Context, gtidModeOn bool) (string, error) {
+	if _, err := c.Execute(ctx, "START TRANSACTION WITH CONSISTENT SNAPSHOT"); err != nil {
+		return "", fmt.Errorf("failed to start consistent snapshot: %w", err)
+	}
+	defer func() {
+		if _, err := c.Execute(ctx, "COMMIT"); err != nil {
+			c.logger.Warn("failed to end consistent snapshot", slog.Any("error", err))
+		}
+	}()
+
+	rr, err := c.Execute(ctx, "SHOW STATUS LIKE 'binlog_snapshot_%'")
+	if err != nil {
+		return "", fmt.Errorf("failed to get binlog snapshot position: %w", err)
+	}
+	var pos mysql.Position
+	for idx := range rr.RowNumber() {
+		name, err := rr.GetString(idx, 0)
+		if err != nil {
+			return "", err
+		}
+		switch strings.ToLower(name) {
+		case "binlog_snapshot_file":
+			if pos.Name, err = rr.GetString(idx, 1); err != nil {
+				return "", err
+			}
+		case "binlog_snapshot_position":
+			value, err := rr.GetString(idx, 1)
+			if err != nil {
+				return "", err
+			}
+			parsed, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse binlog_snapshot_position %s: %w", value, err)
+			}
+			pos.Pos = uint32(parsed)
+		}
+	}
+	if pos.Name == "" {
+		return "", errors.New("binlog snapshot position not reported, is binary logging enabled?")
+	}
+	if !gtidModeOn {
+		return posToOffsetText(pos), nil
+	}
+
+	rr, err = c.Execute(ctx, "SELECT BINLOG_GTID_POS(?, ?)", pos.Name, pos.Pos)
+	if err != nil {
+		return "", fmt.Errorf("failed to get gtid position of %s: %w", posToOffsetText(pos), err)
+	}
+	gtidPos, err := rr.GetString(0, 0)
+	if err != nil {
+		return "", err
+	}
+	set, err := mysql.ParseGTIDSet(mysql.MariaDBFlavor, gtidPos)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse gtid position %s: %w", gtidPos, err)
+	}
+	return set.String(), nil
+}
+
 func (c *MySqlConnector) SetupReplConn(ctx context.Context) error {
 	// mysql code will spin up new connection for each normalize for now
 	return nil
@@ -195,7 +299,7 @@ func (c *MySqlConnector) startSyncer(ctx context.Context) (*replication.BinlogSy
 	var tlsConfig *tls.Config
 	if !c.config.DisableTls {
 		var err error
-		tlsConfig, err = shared.CreateTlsConfig(
+		tlsConfig, err = utils.PeerTlsConfig(c.config.Tls,
 			tls.VersionTLS12, c.config.RootCa, c.config.Host, c.config.TlsHost, c.config.SkipCertVerification,
 		)
 		if err != nil {
@@ -237,6 +341,9 @@ func (c *MySqlConnector) startSyncer(ctx context.Context) (*replication.BinlogSy
 		UseDecimal: true,
 		ParseTime:  true,
 		TLSConfig:  tlsConfig,
+		// the server sends heartbeats while there are no events, so a connection gone silent is noticed and retried
+		HeartbeatPeriod: binlogHeartbeatPeriod,
+		ReadTimeout:     3 * binlogHeartbeatPeriod,
 	}), nil
 }
 
@@ -351,6 +458,8 @@ func (c *MySqlConnector) PullRecords(
 	var updatedOffset string
 	var inTx bool
 	var recordCount uint32
+	// size of row events backing the records in the current batch
+	var batchBytes uint64
 	// set when a tx is preventing us from respecting the timeout, immediately exit after we see inTx false
 	var overtime bool
 	defer func() {
@@ -380,7 +489,9 @@ func (c *MySqlConnector) PullRecords(
 	}
 
 	var mysqlParser *parser.Parser
-	for inTx || (!overtime && recordCount < req.MaxBatchSize) {
+	// column count of the last TABLE_MAP event per table, schema is only compared again once it changes
+	tableMapColumnCounts := make(map[string]uint64, len(req.TableNameMapping))
+	for inTx || (!overtime && recordCount < req.MaxBatchSize && (req.MaxBatchBytes == 0 || batchBytes < req.MaxBatchBytes)) {
 		var event *replication.BinlogEvent
 		// don't gamble on closed timeoutCtx.Done() being prioritized over event backlog channel
 		err := timeoutCtx.Err()
@@ -475,6 +586,14 @@ func (c *MySqlConnector) PullRecords(
 					}
 				}
 			}
+		case *replication.TableMapEvent:
+			sourceTableName := string(ev.Schema) + "." + string(ev.Table)
+			if columnCount, ok := tableMapColumnCounts[sourceTableName]; !ok || columnCount != ev.ColumnCount {
+				tableMapColumnCounts[sourceTableName] = ev.ColumnCount
+				if err := c.processTableMapEvent(ctx, catalogPool, req, ev, sourceTableName); err != nil {
+					return fmt.Errorf("failed to process TABLE_MAP event: %w", err)
+				}
+			}
 		case *replication.RowsEvent:
 			sourceTableName := string(ev.Table.Schema) + "." + string(ev.Table.Table) // TODO this is fragile
 			destinationTableName := req.TableNameMapping[sourceTableName].Name
@@ -482,6 +601,7 @@ func (c *MySqlConnector) PullRecords(
 			schema := req.TableNameSchemaMapping[destinationTableName]
 			if schema != nil {
 				otelManager.Metrics.FetchedBytesCounter.Add(ctx, int64(len(event.RawData)))
+				batchBytes += uint64(len(event.RawData))
 				inTx = true
 				enumMap := ev.Table.EnumStrValueMap()
 				setMap := ev.Table.SetStrValueMap()
@@ -670,6 +790,27 @@ func (c *MySqlConnector) processAlterTableQuery(ctx context.Context, catalogPool
 
 	for _, spec := range stmt.Specs {
 		if spec.NewColumns != nil {
+			// columns go after the last column unless placed with FIRST or AFTER,
+			// derived columns stay last so positions keep matching the binlog
+			insertAt := -1
+			if currentSchema != nil {
+				insertAt = len(currentSchema.Columns) - len(req.TableNameMapping[sourceTableName].Derived)
+				if spec.Position != nil {
+					switch spec.Position.Tp {
+					case ast.ColumnPositionFirst:
+						insertAt = 0
+					case ast.ColumnPositionAfter:
+						// excluded columns are not part of the schema, leaving the position unknown
+						if idx := slices.IndexFunc(currentSchema.Columns, func(column *protos.FieldDescription) bool {
+							return column.Name == spec.Position.RelativeColumn.Name.String()
+						}); idx != -1 {
+							insertAt = idx + 1
+						} else {
+							insertAt = -1
+						}
+					}
+				}
+			}
 			// these are added columns
 			for _, col := range spec.NewColumns {
 				if col.Tp == nil {
@@ -705,28 +846,177 @@ func (c *MySqlConnector) processAlterTableQuery(ctx context.Context, catalogPool
 					Nullable:     nullable,
 				}
 				tableSchemaDelta.AddedColumns = append(tableSchemaDelta.AddedColumns, fd)
-				// current assumption is the columns will be ordered like this
-				currentSchema.Columns = append(currentSchema.Columns, fd)
+				if insertAt == -1 {
+					if currentSchema != nil {
+						currentSchema.Columns = append(currentSchema.Columns, fd)
+					}
+					continue
+				}
+				if tableSchemaDelta.AddedColumnsAfter == nil {
+					tableSchemaDelta.AddedColumnsAfter = make(map[string]string)
+				}
+				if insertAt == 0 {
+					tableSchemaDelta.AddedColumnsAfter[fd.Name] = ""
+				} else {
+					tableSchemaDelta.AddedColumnsAfter[fd.Name] = currentSchema.Columns[insertAt-1].Name
+				}
+				currentSchema.Columns = slices.Insert(currentSchema.Columns, insertAt, fd)
+				insertAt += 1
 			}
 		} else if spec.OldColumnName != nil {
 			// this could be dropped or renamed column
 			if spec.NewColumnName != nil {
 				c.logger.Warn("renamed column detected but not propagating",
 					slog.String("columnOldName", spec.OldColumnName.String()), slog.String("columnNewName", spec.NewColumnName.String()))
-			} else {
+			} else if !req.PropagateDroppedColumns {
 				c.logger.Warn("dropped column detected but not propagating", slog.String("columnName", spec.OldColumnName.String()))
+			} else if currentSchema != nil && slices.ContainsFunc(currentSchema.Columns, func(column *protos.FieldDescription) bool {
+				return column.Name == spec.OldColumnName.Name.String()
+			}) {
+				c.logger.Info("dropped column detected", slog.String("columnName", spec.OldColumnName.String()))
+				tableSchemaDelta.DroppedColumns = append(tableSchemaDelta.DroppedColumns, spec.OldColumnName.Name.String())
 			}
 		}
 	}
-	if tableSchemaDelta.AddedColumns != nil {
-		c.logger.Info("Column added detected",
-			slog.String("table", destinationTableName), slog.Any("columns", tableSchemaDelta.AddedColumns))
+	if tableSchemaDelta.AddedColumns != nil || tableSchemaDelta.DroppedColumns != nil {
+		c.logger.Info("Column change detected", slog.String("table", destinationTableName),
+			slog.Any("addedColumns", tableSchemaDelta.AddedColumns), slog.Any("droppedColumns", tableSchemaDelta.DroppedColumns))
 		req.RecordStream.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
 		return monitoring.AuditSchemaDelta(ctx, catalogPool.Pool, req.FlowJobName, tableSchemaDelta)
 	}
 	return nil
 }
 
+// processTableMapEvent catches up on columns added without an ALTER TABLE we could parse,
+// like online schema change tools swapping in a copy of the table, by comparing the columns of a TABLE_MAP event
+// with the schema and taking added columns from information_schema
+func (c *MySqlConnector) processTableMapEvent(ctx context.Context, catalogPool shared.CatalogPool,
+	req *model.PullRecordsRequest[model.RecordItems], ev *replication.TableMapEvent, sourceTableName string,
+) error {
+	nameAndExclude, ok := req.TableNameMapping[sourceTableName]
+	if !ok {
+		return nil
+	}
+	currentSchema := req.TableNameSchemaMapping[nameAndExclude.Name]
+	if currentSchema == nil || !tableMapAddsColumns(ev, currentSchema, nameAndExclude) {
+		return nil
+	}
+
+	latestSchema, err := c.getTableSchemaForTable(ctx, req.Env, &protos.TableMapping{
+		SourceTableIdentifier: sourceTableName,
+		Exclude:               slices.Collect(maps.Keys(nameAndExclude.Exclude)),
+	}, currentSchema.System)
+	if err != nil {
+		return err
+	}
+
+	tableSchemaDelta := c.tableMapSchemaDelta(ev, sourceTableName, nameAndExclude, currentSchema, latestSchema)
+	if tableSchemaDelta == nil {
+		return nil
+	}
+	c.logger.Info("Column change detected from TABLE_MAP event", slog.String("table", nameAndExclude.Name),
+		slog.Any("addedColumns", tableSchemaDelta.AddedColumns))
+	req.RecordStream.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
+	return monitoring.AuditSchemaDelta(ctx, catalogPool.Pool, req.FlowJobName, tableSchemaDelta)
+}
+
+// tableMapAddsColumns reports whether a TABLE_MAP event has columns missing from the schema,
+// by name with binlog_row_metadata=FULL, otherwise only a grown column count shows added columns
+func tableMapAddsColumns(
+	ev *replication.TableMapEvent, currentSchema *protos.TableSchema, nameAndExclude model.NameAndExclude,
+) bool {
+	if ev.ColumnName != nil {
+		return slices.ContainsFunc(ev.ColumnName, func(name []byte) bool {
+			_, excluded := nameAndExclude.Exclude[string(name)]
+			return !excluded && !slices.ContainsFunc(currentSchema.Columns, func(column *protos.FieldDescription) bool {
+				return column.Name == string(name)
+			})
+		})
+	}
+	return ev.ColumnCount > uint64(len(currentSchema.Columns)-len(nameAndExclude.Derived)+len(nameAndExclude.Exclude))
+}
+
+// tableMapSchemaDelta adds columns of latestSchema missing from currentSchema, which it updates, nil if there are none.
+// latestSchema comes from information_schema, which shows the table as it is now rather than at the binlog position
+// of the event, so it may already miss columns dropped since or have columns added since. With column names
+// only columns named by the event are added. Without them row values map to columns by position,
+// so columns are only added when information_schema has as many columns as the event: guessing would shift values
+// into the wrong columns, and a later TABLE_MAP event with a different column count is checked again
+func (c *MySqlConnector) tableMapSchemaDelta(
+	ev *replication.TableMapEvent,
+	sourceTableName string,
+	nameAndExclude model.NameAndExclude,
+	currentSchema *protos.TableSchema,
+	latestSchema *protos.TableSchema,
+) *protos.TableSchemaDelta {
+	if ev.ColumnName == nil {
+		if latestCount := uint64(len(latestSchema.Columns) + len(nameAndExclude.Exclude)); ev.ColumnCount != latestCount {
+			c.logger.Warn("columns of TABLE_MAP event differ from information_schema, table was altered again since, "+
+				"not adding columns", slog.String("table", sourceTableName),
+				slog.Uint64("eventColumns", ev.ColumnCount), slog.Uint64("informationSchemaColumns", latestCount))
+			return nil
+		}
+	}
+
+	hasColumn := func(name string) bool {
+		return slices.ContainsFunc(currentSchema.Columns, func(column *protos.FieldDescription) bool {
+			return column.Name == name
+		})
+	}
+	inEvent := func(name string) bool {
+		return ev.ColumnName == nil || slices.ContainsFunc(ev.ColumnName, func(eventName []byte) bool {
+			return string(eventName) == name
+		})
+	}
+	tableSchemaDelta := &protos.TableSchemaDelta{
+		SrcTableName:    sourceTableName,
+		DstTableName:    nameAndExclude.Name,
+		System:          protos.TypeSystem_Q,
+		NullableEnabled: currentSchema.NullableEnabled,
+	}
+	for idx, column := range latestSchema.Columns {
+		if hasColumn(column.Name) {
+			continue
+		}
+		if !inEvent(column.Name) {
+			c.logger.Info("column added after TABLE_MAP event, left for a later event", slog.String("table", sourceTableName),
+				slog.String("column", column.Name))
+			continue
+		}
+		// after the closest preceding column in the schema, skipped columns are not
+		var after string
+		insertAt := 0
+		for _, previous := range slices.Backward(latestSchema.Columns[:idx]) {
+			if schemaIdx := slices.IndexFunc(currentSchema.Columns, func(column *protos.FieldDescription) bool {
+				return column.Name == previous.Name
+			}); schemaIdx != -1 {
+				after = previous.Name
+				insertAt = schemaIdx + 1
+				break
+			}
+		}
+		tableSchemaDelta.AddedColumns = append(tableSchemaDelta.AddedColumns, column)
+		if tableSchemaDelta.AddedColumnsAfter == nil {
+			tableSchemaDelta.AddedColumnsAfter = make(map[string]string)
+		}
+		tableSchemaDelta.AddedColumnsAfter[column.Name] = after
+		currentSchema.Columns = slices.Insert(currentSchema.Columns, insertAt, column)
+	}
+	if ev.ColumnName != nil {
+		for _, name := range ev.ColumnName {
+			if _, excluded := nameAndExclude.Exclude[string(name)]; !excluded && !hasColumn(string(name)) {
+				// its values get ignored as unknown columns
+				c.logger.Warn("column of TABLE_MAP event no longer in information_schema, dropped since",
+					slog.String("table", sourceTableName), slog.String("column", string(name)))
+			}
+		}
+	}
+	if tableSchemaDelta.AddedColumns == nil {
+		return nil
+	}
+	return tableSchemaDelta
+}
+
 func posToOffsetText(pos mysql.Position) string {
 	return fmt.Sprintf("!f:%s,%x", pos.Name, pos.Pos)
 }
@@ -0,0 +1,132 @@
+package connmysql
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+func tableMapTestSchema(names ...string) *protos.TableSchema {
+	schema := &protos.TableSchema{TableIdentifier: "db.t", System: protos.TypeSystem_Q}
+	for _, name := range names {
+		schema.Columns = append(schema.Columns, &protos.FieldDescription{Name: name, Type: "string"})
+	}
+	return schema
+}
+
+func tableMapTestEvent(names ...string) *replication.TableMapEvent {
+	ev := &replication.TableMapEvent{ColumnCount: uint64(len(names))}
+	for _, name := range names {
+		ev.ColumnName = append(ev.ColumnName, []byte(name))
+	}
+	return ev
+}
+
+func columnNames(schema *protos.TableSchema) []string {
+	names := make([]string, 0, len(schema.Columns))
+	for _, column := range schema.Columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+func TestTableMapAddsColumns(t *testing.T) {
+	t.Parallel()
+
+	schema := tableMapTestSchema("id", "name")
+	for _, tc := range []struct {
+		name     string
+		ev       *replication.TableMapEvent
+		mapping  model.NameAndExclude
+		expected bool
+	}{
+		{"full metadata with added column", tableMapTestEvent("id", "name", "email"), model.NameAndExclude{}, true},
+		{"full metadata unchanged", tableMapTestEvent("id", "name"), model.NameAndExclude{}, false},
+		{"full metadata with excluded column", tableMapTestEvent("id", "secret", "name"),
+			model.NameAndExclude{Exclude: map[string]struct{}{"secret": {}}}, false},
+		{"column count grown", &replication.TableMapEvent{ColumnCount: 3}, model.NameAndExclude{}, true},
+		{"column count unchanged", &replication.TableMapEvent{ColumnCount: 2}, model.NameAndExclude{}, false},
+		{"column count with excluded column", &replication.TableMapEvent{ColumnCount: 3},
+			model.NameAndExclude{Exclude: map[string]struct{}{"secret": {}}}, false},
+		{"column count with derived column", &replication.TableMapEvent{ColumnCount: 1},
+			model.NameAndExclude{Derived: map[string]struct{}{"name": {}}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tableMapAddsColumns(tc.ev, schema, tc.mapping))
+		})
+	}
+}
+
+func TestTableMapSchemaDelta(t *testing.T) {
+	t.Parallel()
+
+	c := &MySqlConnector{logger: log.NewStructuredLogger(slog.New(slog.DiscardHandler))}
+	mapping := model.NameAndExclude{Name: "public.t"}
+
+	t.Run("full metadata", func(t *testing.T) {
+		current := tableMapTestSchema("id", "name")
+		delta := c.tableMapSchemaDelta(tableMapTestEvent("id", "email", "name"), "db.t", mapping,
+			current, tableMapTestSchema("id", "email", "name"))
+		require.NotNil(t, delta)
+		require.Equal(t, "public.t", delta.DstTableName)
+		require.Equal(t, []string{"email"}, columnNames(&protos.TableSchema{Columns: delta.AddedColumns}))
+		require.Equal(t, map[string]string{"email": "id"}, delta.AddedColumnsAfter)
+		require.Equal(t, []string{"id", "email", "name"}, columnNames(current))
+	})
+
+	t.Run("full metadata skips columns added after the event", func(t *testing.T) {
+		current := tableMapTestSchema("id", "name")
+		delta := c.tableMapSchemaDelta(tableMapTestEvent("id", "name", "email"), "db.t", mapping,
+			current, tableMapTestSchema("id", "phone", "name", "email"))
+		require.NotNil(t, delta)
+		require.Equal(t, []string{"email"}, columnNames(&protos.TableSchema{Columns: delta.AddedColumns}))
+		require.Equal(t, map[string]string{"email": "name"}, delta.AddedColumnsAfter)
+		require.Equal(t, []string{"id", "name", "email"}, columnNames(current))
+	})
+
+	t.Run("full metadata with column dropped since", func(t *testing.T) {
+		current := tableMapTestSchema("id", "name")
+		require.Nil(t, c.tableMapSchemaDelta(tableMapTestEvent("id", "name", "tmp"), "db.t", mapping,
+			current, tableMapTestSchema("id", "name")))
+		require.Equal(t, []string{"id", "name"}, columnNames(current))
+	})
+
+	t.Run("column count", func(t *testing.T) {
+		current := tableMapTestSchema("id", "name")
+		delta := c.tableMapSchemaDelta(&replication.TableMapEvent{ColumnCount: 3}, "db.t", mapping,
+			current, tableMapTestSchema("id", "name", "email"))
+		require.NotNil(t, delta)
+		require.Equal(t, map[string]string{"email": "name"}, delta.AddedColumnsAfter)
+		require.Equal(t, []string{"id", "name", "email"}, columnNames(current))
+	})
+
+	t.Run("column count with excluded column", func(t *testing.T) {
+		current := tableMapTestSchema("id", "name")
+		excludeMapping := model.NameAndExclude{Name: "public.t", Exclude: map[string]struct{}{"secret": {}}}
+		delta := c.tableMapSchemaDelta(&replication.TableMapEvent{ColumnCount: 4}, "db.t", excludeMapping,
+			current, tableMapTestSchema("id", "name", "email"))
+		require.NotNil(t, delta)
+		require.Equal(t, []string{"id", "name", "email"}, columnNames(current))
+	})
+
+	t.Run("column count behind information_schema", func(t *testing.T) {
+		// a second column was added after the event, positions can't tell which of them the event has
+		current := tableMapTestSchema("id", "name")
+		require.Nil(t, c.tableMapSchemaDelta(&replication.TableMapEvent{ColumnCount: 3}, "db.t", mapping,
+			current, tableMapTestSchema("id", "name", "email", "phone")))
+		require.Equal(t, []string{"id", "name"}, columnNames(current))
+	})
+
+	t.Run("column count with column added then dropped", func(t *testing.T) {
+		current := tableMapTestSchema("id", "name")
+		require.Nil(t, c.tableMapSchemaDelta(&replication.TableMapEvent{ColumnCount: 3}, "db.t", mapping,
+			current, tableMapTestSchema("id", "name")))
+		require.Equal(t, []string{"id", "name"}, columnNames(current))
+	})
+}
@@ -138,7 +138,7 @@ func (c *MySqlConnector) connect(ctx context.Context) (*client.Conn, error) {
 				conn.SetCapability(mysql.CLIENT_COMPRESS)
 			}
 			if !c.config.DisableTls {
-				config, err := shared.CreateTlsConfig(
+				config, err := utils.PeerTlsConfig(c.config.Tls,
 					tls.VersionTLS12, c.config.RootCa, c.config.Host, c.config.TlsHost, c.config.SkipCertVerification,
 				)
 				if err != nil {
@@ -148,27 +148,13 @@ func (c *MySqlConnector) connect(ctx context.Context) (*client.Conn, error) {
 			}
 			return nil
 		}}
-		config := c.config
-		if c.rdsAuth != nil {
-			c.logger.Info("Setting up IAM auth for MySQL")
-			host := c.config.Host
-			if c.config.TlsHost != "" {
-				host = c.config.TlsHost
-			}
-			token, err := utils.GetRDSToken(ctx, utils.RDSConnectionConfig{
-				Host: host,
-				Port: config.Port,
-				User: config.User,
-			}, c.rdsAuth, "MYSQL")
-			if err != nil {
-				return nil, err
-			}
-			config = proto.CloneOf(config)
-			config.Password = token
-		}
 		var err error
-		conn, err = client.ConnectWithDialer(ctx, "", shared.JoinHostPort(config.Host, config.Port),
-			config.User, config.Password, config.Database, c.Dialer(), argF...)
+		conn, err = c.dial(ctx, argF)
+		if err != nil && c.rdsAuth != nil && isAccessDenied(err) {
+			c.logger.Warn("IAM auth token rejected, retrying with a new token", slog.Any("error", err))
+			c.rdsAuth.Invalidate()
+			conn, err = c.dial(ctx, argF)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -210,6 +196,35 @@ func (c *MySqlConnector) connect(ctx context.Context) (*client.Conn, error) {
 	return conn, nil
 }
 
+func (c *MySqlConnector) dial(ctx context.Context, argF []client.Option) (*client.Conn, error) {
+	config := c.config
+	if c.rdsAuth != nil {
+		c.logger.Info("Setting up IAM auth for MySQL")
+		host := c.config.Host
+		if c.config.TlsHost != "" {
+			host = c.config.TlsHost
+		}
+		token, err := utils.GetRDSToken(ctx, utils.RDSConnectionConfig{
+			Host: host,
+			Port: config.Port,
+			User: config.User,
+		}, c.rdsAuth, "MYSQL")
+		if err != nil {
+			return nil, err
+		}
+		config = proto.CloneOf(config)
+		config.Password = token
+	}
+	return client.ConnectWithDialer(ctx, "", shared.JoinHostPort(config.Host, config.Port),
+		config.User, config.Password, config.Database, c.Dialer(), argF...)
+}
+
+// isAccessDenied reports whether err is the server rejecting credentials, which for IAM auth means an expired token
+func isAccessDenied(err error) bool {
+	var mErr *mysql.MyError
+	return errors.As(err, &mErr) && mErr.Code == mysql.ER_ACCESS_DENIED_ERROR
+}
+
 // withRetries return an iterable over connections,
 // consumer should break out of loop on success or error,
 // to retry for mysql.ErrBadConn
@@ -294,6 +294,38 @@ func (c *MySqlConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.F
 	return nil
 }
 
+// PreflightMirrorSource runs the checks of ValidateMirrorSource, reporting each of them instead of stopping at the first failure
+func (c *MySqlConnector) PreflightMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigs) []*protos.PreflightCheck {
+	sourceTables := make([]*utils.SchemaTable, 0, len(cfg.TableMappings))
+	for _, tableMapping := range cfg.TableMappings {
+		parsedTable, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return []*protos.PreflightCheck{utils.PreflightCheckResult("source_tables", err)}
+		}
+		sourceTables = append(sourceTables, parsedTable)
+	}
+
+	checks := []*protos.PreflightCheck{
+		utils.PreflightCheckResult("source_tables", c.CheckSourceTables(ctx, sourceTables)),
+	}
+	if cfg.DoInitialSnapshot && cfg.InitialSnapshotOnly {
+		return checks
+	}
+
+	requireRowMetadata := false
+	for _, tm := range cfg.TableMappings {
+		if len(tm.Exclude) > 0 {
+			requireRowMetadata = true
+			break
+		}
+	}
+	return append(checks,
+		utils.PreflightCheckResult("replication_connectivity", c.CheckReplicationConnectivity(ctx)),
+		utils.PreflightCheckResult("binlog_settings", c.CheckBinlogSettings(ctx, requireRowMetadata)),
+		utils.PreflightCheckResult("rds_binlog_settings", c.CheckRDSBinlogSettings(ctx)),
+	)
+}
+
 func (c *MySqlConnector) ValidateCheck(ctx context.Context) error {
 	if c.config.Flavor == protos.MySqlFlavor_MYSQL_UNKNOWN {
 		return errors.New("flavor is set to unknown")
@@ -34,6 +34,9 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
+// prefix of the logical messages emitted by the default PEERDB_WAL_HEARTBEAT_QUERY, never forwarded to destinations
+const heartbeatMessagePrefix = "peerdb_heartbeat"
+
 type PostgresCDCSource struct {
 	*PostgresConnector
 	srcTableIDNameMapping  map[uint32]string
@@ -55,6 +58,7 @@ type PostgresCDCSource struct {
 	hushWarnUnknownTableDetected             map[uint32]struct{}
 	flowJobName                              string
 	handleInheritanceForNonPartitionedTables bool
+	propagateDroppedColumns                  bool
 	internalVersion                          uint32
 }
 
@@ -70,6 +74,7 @@ type PostgresCDCConfig struct {
 	Publication                              string
 	HandleInheritanceForNonPartitionedTables bool
 	SourceSchemaAsDestinationColumn          bool
+	PropagateDroppedColumns                  bool
 	InternalVersion                          uint32
 }
 
@@ -104,6 +109,7 @@ func (c *PostgresConnector) NewPostgresCDCSource(ctx context.Context, cdcConfig
 		hushWarnUnknownTableDetected:             make(map[uint32]struct{}),
 		flowJobName:                              cdcConfig.FlowJobName,
 		handleInheritanceForNonPartitionedTables: cdcConfig.HandleInheritanceForNonPartitionedTables,
+		propagateDroppedColumns:                  cdcConfig.PropagateDroppedColumns,
 		internalVersion:                          cdcConfig.InternalVersion,
 	}, nil
 }
@@ -131,12 +137,19 @@ func getChildToParentRelIDMap(ctx context.Context,
 		relkinds = "'p', 'r'"
 	}
 
+	// sub-partitions map to the mirrored table at the top of their tree,
+	// unless they are mirrored themselves
 	query := fmt.Sprintf(`
-		SELECT parent.oid AS parentrelid, child.oid AS childrelid
-		FROM pg_inherits
-		JOIN pg_class parent ON pg_inherits.inhparent = parent.oid
-		JOIN pg_class child ON pg_inherits.inhrelid = child.oid
-		WHERE parent.relkind IN (%s) AND parent.oid=ANY($1);
+		WITH RECURSIVE tree AS (
+			SELECT i.inhparent AS root, i.inhrelid AS relid
+			FROM pg_inherits i JOIN pg_class parent ON i.inhparent = parent.oid
+			WHERE parent.relkind IN (%[1]s) AND i.inhparent=ANY($1)
+			UNION ALL
+			SELECT tree.root, i.inhrelid
+			FROM tree JOIN pg_inherits i ON i.inhparent = tree.relid JOIN pg_class parent ON i.inhparent = parent.oid
+			WHERE parent.relkind IN (%[1]s) AND NOT tree.relid=ANY($1)
+		)
+		SELECT root, relid FROM tree WHERE NOT relid=ANY($1);
 	`, relkinds)
 
 	rows, err := conn.Query(ctx, query, parentTableOIDs)
@@ -327,8 +340,13 @@ func (p *PostgresCDCSource) decodeColumnData(
 	} else if dataType == pgtype.TimetzOID { // ugly TIMETZ workaround for CDC decoding.
 		return p.parseFieldFromPostgresOID(dataType, typmod, string(data), customTypeMapping, p.internalVersion)
 	} else if typeData, ok := customTypeMapping[dataType]; ok {
+		if baseOID := postgres.DomainBaseOID(typeData, version); baseOID != 0 {
+			return p.decodeColumnData(data, baseOID, typmod, formatCode, customTypeMapping, version)
+		}
 		customQKind := postgres.CustomTypeToQKind(typeData, version)
 		switch customQKind {
+		case types.QValueKindJSON:
+			return p.parseComposite(dataType, formatCode, data)
 		case types.QValueKindGeography, types.QValueKindGeometry:
 			wkt, err := geo.GeoValidate(string(data))
 			if err != nil {
@@ -414,6 +432,13 @@ func PullCdcRecords[Items model.Items](
 	}
 
 	var standByLastLogged time.Time
+	twoPhase, err := getTwoPhaseState[Items](ctx, req.Env, p)
+	if err != nil {
+		return err
+	}
+	if p.replState == nil {
+		defer twoPhase.records.Close()
+	}
 	cdcRecordsStorage, err := utils.NewCDCStore[Items](ctx, req.Env, p.flowJobName)
 	if err != nil {
 		return err
@@ -435,10 +460,13 @@ func PullCdcRecords[Items model.Items](
 
 	nextStandbyMessageDeadline := time.Now().Add(req.IdleTimeout)
 
+	// size of WAL data backing the records in the current batch, and of the message being processed
+	var batchBytes, messageBytes uint64
 	addRecordWithKey := func(key model.TableWithPkey, rec model.Record[Items]) error {
 		if err := cdcRecordsStorage.Set(logger, key, rec); err != nil {
 			return err
 		}
+		batchBytes += messageBytes
 		if err := records.AddRecord(ctx, rec); err != nil {
 			return err
 		}
@@ -451,6 +479,124 @@ func PullCdcRecords[Items model.Items](
 		return nil
 	}
 
+	handleRecord := func(rec model.Record[Items]) error {
+		tableName := rec.GetDestinationTableName()
+		switch r := rec.(type) {
+		case *model.UpdateRecord[Items]:
+			// tableName here is destination tableName.
+			// should be ideally sourceTableName as we are in PullRecords.
+			// will change in future
+			// TODO: replident is cached here, should not cache since it can change
+			isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+			if isFullReplica {
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			} else {
+				tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
+				if err != nil {
+					return err
+				}
+
+				latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
+				if err != nil {
+					return err
+				}
+				if ok {
+					// iterate through unchanged toast cols and set them in new record
+					updatedCols := r.NewItems.UpdateIfNotExists(latestRecord.GetItems())
+					for _, col := range updatedCols {
+						delete(r.UnchangedToastColumns, col)
+					}
+				}
+				if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
+					return err
+				}
+			}
+
+		case *model.InsertRecord[Items]:
+			isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+			if isFullReplica {
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			} else {
+				tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
+				if err != nil {
+					return err
+				}
+
+				if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
+					return err
+				}
+			}
+		case *model.DeleteRecord[Items]:
+			isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+			if isFullReplica {
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			} else {
+				tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
+				if err != nil {
+					return err
+				}
+
+				latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
+				if err != nil {
+					return err
+				}
+				if ok {
+					r.Items = latestRecord.GetItems()
+					if updateRecord, ok := latestRecord.(*model.UpdateRecord[Items]); ok {
+						r.UnchangedToastColumns = updateRecord.UnchangedToastColumns
+					}
+				} else {
+					// there is nothing to backfill the items in the delete record with,
+					// so don't update the row with this record
+					// add sentinel value to prevent update statements from selecting
+					r.UnchangedToastColumns = map[string]struct{}{
+						"_peerdb_not_backfilled_delete": {},
+					}
+				}
+
+				// A delete can only be followed by an INSERT, which does not need backfilling
+				// No need to store DeleteRecords in memory or disk.
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			}
+
+		case *model.RelationRecord[Items]:
+			tableSchemaDelta := r.TableSchemaDelta
+			if len(tableSchemaDelta.AddedColumns) > 0 || len(tableSchemaDelta.DroppedColumns) > 0 {
+				logger.Info(fmt.Sprintf("Detected schema change for table %s, addedColumns: %v, droppedColumns: %v",
+					tableSchemaDelta.SrcTableName, tableSchemaDelta.AddedColumns, tableSchemaDelta.DroppedColumns))
+				records.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
+			}
+
+		case *model.MessageRecord[Items]:
+			// if forwarding messages, push to records regardless so they reach the destination,
+			// else if cdc store empty, we can move lsn,
+			// otherwise push to records so destination can ack once all previous messages processed
+			if req.MessageDestination != "" && r.Prefix != heartbeatMessagePrefix {
+				r.DestinationTableName = req.MessageDestination
+				if err := records.AddRecord(ctx, rec); err != nil {
+					return err
+				}
+			} else if cdcRecordsStorage.IsEmpty() {
+				if consumedXLogPos := twoPhase.capLSN(clientXLogPos); int64(consumedXLogPos) > req.ConsumedOffset.Load() {
+					if err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, consumedXLogPos); err != nil {
+						return err
+					}
+				}
+			} else if err := records.AddRecord(ctx, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	pkmRequiresResponse := false
 	waitingForCommit := false
 
@@ -461,8 +607,9 @@ func PullCdcRecords[Items model.Items](
 	lastEmptyBatchPkmSentTime := time.Now()
 	for {
 		if pkmRequiresResponse {
-			if cdcRecordsStorage.IsEmpty() && int64(clientXLogPos) > req.ConsumedOffset.Load() {
-				err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, clientXLogPos)
+			if consumedXLogPos := twoPhase.capLSN(clientXLogPos); cdcRecordsStorage.IsEmpty() &&
+				int64(consumedXLogPos) > req.ConsumedOffset.Load() {
+				err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, consumedXLogPos)
 				if err != nil {
 					return err
 				}
@@ -486,6 +633,12 @@ func PullCdcRecords[Items model.Items](
 				return nil
 			}
 
+			if req.MaxBatchBytes > 0 && batchBytes >= req.MaxBatchBytes {
+				logger.Info("batch bytes limit reached, returning currently accumulated records",
+					slog.Int("records", cdcRecordsStorage.Len()), slog.Uint64("bytes", batchBytes))
+				return nil
+			}
+
 			if waitingForCommit {
 				logger.Info("commit received, returning currently accumulated records",
 					slog.Int("records", cdcRecordsStorage.Len()))
@@ -570,7 +723,8 @@ func PullCdcRecords[Items model.Items](
 
 				logger.Debug("XLogData",
 					slog.Any("WALStart", xld.WALStart), slog.Any("ServerWALEnd", xld.ServerWALEnd), slog.Any("ServerTime", xld.ServerTime))
-				rec, err := processMessage(ctx, p, records, xld, clientXLogPos, processor)
+				messageBytes = uint64(len(xld.WALData))
+				rec, err := processMessage(ctx, p, twoPhase, records, xld, clientXLogPos, processor)
 				if err != nil {
 					return fmt.Errorf("error processing message: %w", err)
 				}
@@ -580,115 +734,13 @@ func PullCdcRecords[Items model.Items](
 				}
 
 				if rec != nil {
-					tableName := rec.GetDestinationTableName()
-					switch r := rec.(type) {
-					case *model.UpdateRecord[Items]:
-						// tableName here is destination tableName.
-						// should be ideally sourceTableName as we are in PullRecords.
-						// will change in future
-						// TODO: replident is cached here, should not cache since it can change
-						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-						if isFullReplica {
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						} else {
-							tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
-							if err != nil {
-								return err
-							}
-
-							latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
-							if err != nil {
-								return err
-							}
-							if ok {
-								// iterate through unchanged toast cols and set them in new record
-								updatedCols := r.NewItems.UpdateIfNotExists(latestRecord.GetItems())
-								for _, col := range updatedCols {
-									delete(r.UnchangedToastColumns, col)
-								}
-							}
-							if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
-								return err
-							}
-						}
-
-					case *model.InsertRecord[Items]:
-						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-						if isFullReplica {
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						} else {
-							tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
-							if err != nil {
-								return err
-							}
-
-							if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
-								return err
-							}
-						}
-					case *model.DeleteRecord[Items]:
-						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-						if isFullReplica {
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						} else {
-							tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
-							if err != nil {
-								return err
-							}
-
-							latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
-							if err != nil {
-								return err
-							}
-							if ok {
-								r.Items = latestRecord.GetItems()
-								if updateRecord, ok := latestRecord.(*model.UpdateRecord[Items]); ok {
-									r.UnchangedToastColumns = updateRecord.UnchangedToastColumns
-								}
-							} else {
-								// there is nothing to backfill the items in the delete record with,
-								// so don't update the row with this record
-								// add sentinel value to prevent update statements from selecting
-								r.UnchangedToastColumns = map[string]struct{}{
-									"_peerdb_not_backfilled_delete": {},
-								}
-							}
-
-							// A delete can only be followed by an INSERT, which does not need backfilling
-							// No need to store DeleteRecords in memory or disk.
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						}
-
-					case *model.RelationRecord[Items]:
-						tableSchemaDelta := r.TableSchemaDelta
-						if len(tableSchemaDelta.AddedColumns) > 0 {
-							logger.Info(fmt.Sprintf("Detected schema change for table %s, addedColumns: %v",
-								tableSchemaDelta.SrcTableName, tableSchemaDelta.AddedColumns))
-							records.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
-						}
-
-					case *model.MessageRecord[Items]:
-						// if cdc store empty, we can move lsn,
-						// otherwise push to records so destination can ack once all previous messages processed
-						if cdcRecordsStorage.IsEmpty() {
-							if int64(clientXLogPos) > req.ConsumedOffset.Load() {
-								if err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, clientXLogPos); err != nil {
-									return err
-								}
-							}
-						} else if err := records.AddRecord(ctx, rec); err != nil {
-							return err
-						}
+					if err := handleRecord(rec); err != nil {
+						return err
 					}
 				}
+				if err := twoPhase.takeCommitted(handleRecord); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -724,15 +776,21 @@ func (p *PostgresCDCSource) baseRecord(lsn pglogrepl.LSN) model.BaseRecord {
 func processMessage[Items model.Items](
 	ctx context.Context,
 	p *PostgresCDCSource,
+	twoPhase *twoPhaseState[Items],
 	batch *model.CDCStream[Items],
 	xld pglogrepl.XLogData,
 	currentClientXlogPos pglogrepl.LSN,
 	processor replProcessor[Items],
 ) (model.Record[Items], error) {
 	logger := internal.LoggerFromCtx(ctx)
-	logicalMsg, err := pglogrepl.Parse(xld.WALData)
+	logicalMsg, err := parseTwoPhaseMessage(xld.WALData)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing logical message: %w", err)
+	} else if logicalMsg == nil {
+		logicalMsg, err = pglogrepl.Parse(xld.WALData)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing logical message: %w", err)
+		}
 	}
 	customTypeMapping, err := p.fetchCustomTypeMapping(ctx)
 	if err != nil {
@@ -744,19 +802,45 @@ func processMessage[Items model.Items](
 		logger.Debug("BeginMessage", slog.Any("FinalLSN", msg.FinalLSN), slog.Any("XID", msg.Xid))
 		p.commitLock = msg
 	case *pglogrepl.InsertMessage:
-		return processInsertMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		return twoPhase.hold(processInsertMessage(p, xld.WALStart, msg, processor, customTypeMapping))
 	case *pglogrepl.UpdateMessage:
-		return processUpdateMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		return twoPhase.hold(processUpdateMessage(p, xld.WALStart, msg, processor, customTypeMapping))
 	case *pglogrepl.DeleteMessage:
-		return processDeleteMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		return twoPhase.hold(processDeleteMessage(p, xld.WALStart, msg, processor, customTypeMapping))
 	case *pglogrepl.CommitMessage:
 		// for a commit message, update the last checkpoint id for the record batch.
 		logger.Debug("CommitMessage",
 			slog.Any("CommitLSN", msg.CommitLSN),
 			slog.Any("TransactionEndLSN", msg.TransactionEndLSN))
-		batch.UpdateLatestCheckpointID(int64(msg.CommitLSN))
+		batch.UpdateLatestCheckpointID(int64(twoPhase.capLSN(msg.CommitLSN)))
 		p.otelManager.Metrics.CommitLagGauge.Record(ctx, time.Now().UTC().Sub(msg.CommitTime).Microseconds())
 		p.commitLock = nil
+	case *beginPrepareMessage:
+		logger.Debug("BeginPrepareMessage", slog.Any("PrepareLSN", msg.PrepareLSN), slog.String("GID", msg.GID))
+		twoPhase.decoding = &preparedTransaction{gid: msg.GID, prepareLSN: msg.PrepareLSN}
+	case *prepareMessage:
+		logger.Debug("PrepareMessage", slog.Any("PrepareLSN", msg.PrepareLSN), slog.String("GID", msg.GID))
+		if twoPhase.decoding != nil {
+			twoPhase.prepared[msg.GID] = twoPhase.decoding
+			twoPhase.decoding = nil
+		}
+	case *commitPreparedMessage:
+		logger.Debug("CommitPreparedMessage", slog.Any("CommitLSN", msg.CommitLSN), slog.String("GID", msg.GID))
+		if txn, ok := twoPhase.prepared[msg.GID]; ok {
+			twoPhase.committed = append(twoPhase.committed, txn)
+			delete(twoPhase.prepared, msg.GID)
+		} else {
+			// checkpoints never pass a pending prepared transaction, so postgres streams it again after a restart
+			logger.Warn("COMMIT PREPARED for unknown prepared transaction", slog.String("GID", msg.GID))
+		}
+		batch.UpdateLatestCheckpointID(int64(twoPhase.capLSN(msg.CommitLSN)))
+		p.otelManager.Metrics.CommitLagGauge.Record(ctx, time.Now().UTC().Sub(msg.CommitTime).Microseconds())
+	case *rollbackPreparedMessage:
+		logger.Info("ROLLBACK PREPARED, dropping records of prepared transaction", slog.String("GID", msg.GID))
+		if err := twoPhase.rollback(msg.GID); err != nil {
+			return nil, err
+		}
+		batch.UpdateLatestCheckpointID(int64(twoPhase.capLSN(msg.EndLSN)))
 	case *pglogrepl.RelationMessage:
 		// treat all relation messages as corresponding to parent if partitioned.
 		msg.RelationID, err = p.checkIfUnknownTableInherits(ctx, msg.RelationID)
@@ -781,13 +865,18 @@ func processMessage[Items model.Items](
 			slog.String("Prefix", msg.Prefix),
 			slog.String("LSN", msg.LSN.String()))
 		if !msg.Transactional {
-			batch.UpdateLatestCheckpointID(int64(msg.LSN))
+			batch.UpdateLatestCheckpointID(int64(twoPhase.capLSN(msg.LSN)))
+			return &model.MessageRecord[Items]{
+				BaseRecord: p.baseRecord(msg.LSN),
+				Prefix:     msg.Prefix,
+				Content:    string(msg.Content),
+			}, nil
 		}
-		return &model.MessageRecord[Items]{
+		return twoPhase.hold(&model.MessageRecord[Items]{
 			BaseRecord: p.baseRecord(msg.LSN),
 			Prefix:     msg.Prefix,
 			Content:    string(msg.Content),
-		}, nil
+		}, nil)
 	default:
 		if _, ok := p.hushWarnUnhandledMessageType[msg.Type()]; !ok {
 			logger.Warn(fmt.Sprintf("Unhandled message type: %T", msg))
@@ -978,9 +1067,9 @@ func processRelationMessage[Items model.Items](
 		return nil, fmt.Errorf("cannot find table schema for %s", currRelDstInfo.Name)
 	}
 
-	prevRelMap := make(map[string]string, len(prevSchema.Columns))
+	prevRelMap := make(map[string]*protos.FieldDescription, len(prevSchema.Columns))
 	for _, column := range prevSchema.Columns {
-		prevRelMap[column.Name] = column.Type
+		prevRelMap[column.Name] = column
 	}
 
 	currRelMap := make(map[string]string, len(currRel.Columns))
@@ -1014,17 +1103,24 @@ func processRelationMessage[Items model.Items](
 		System:          prevSchema.System,
 		NullableEnabled: prevSchema.NullableEnabled,
 	}
+	// relation columns are in source order, excluded columns are skipped as they're not at the destination
+	var precedingColumn string
 	for _, column := range currRel.Columns {
+		_, excluded := p.tableNameMapping[p.srcTableIDNameMapping[currRel.RelationID]].Exclude[column.Name]
 		// not present in previous relation message, but in current one, so added.
 		if _, ok := prevRelMap[column.Name]; !ok {
 			// only add to delta if not excluded
-			if _, ok := p.tableNameMapping[p.srcTableIDNameMapping[currRel.RelationID]].Exclude[column.Name]; !ok {
+			if !excluded {
 				schemaDelta.AddedColumns = append(schemaDelta.AddedColumns, &protos.FieldDescription{
 					Name:         column.Name,
 					Type:         currRelMap[column.Name],
 					TypeModifier: column.TypeModifier,
 					Nullable:     false,
 				})
+				if schemaDelta.AddedColumnsAfter == nil {
+					schemaDelta.AddedColumnsAfter = make(map[string]string)
+				}
+				schemaDelta.AddedColumnsAfter[column.Name] = precedingColumn
 				// pg does not send nullable info, only whether column is part of replica identity
 				// After loop we will correct this based on pg_catalog,
 				// but can skip specific scenario where replident is default or index
@@ -1041,17 +1137,51 @@ func processRelationMessage[Items model.Items](
 					column.Name, schemaDelta.SrcTableName))
 			}
 			// present in previous and current relation messages, but data types have changed.
-			// so we add it to AddedColumns and DroppedColumns, knowing that we process DroppedColumns first.
-		} else if prevRelMap[column.Name] != currRelMap[column.Name] {
-			p.logger.Warn(fmt.Sprintf("Detected column %s with type changed from %s to %s in table %s, but not propagating",
-				column.Name, prevRelMap[column.Name], currRelMap[column.Name], schemaDelta.SrcTableName))
+			// only widening changes are propagated, destinations cannot hold values of other types
+		} else if prevColumn := prevRelMap[column.Name]; !excluded &&
+			(prevColumn.Type != currRelMap[column.Name] || prevColumn.TypeModifier != column.TypeModifier) {
+			currColumn := &protos.FieldDescription{
+				Name:         column.Name,
+				Type:         currRelMap[column.Name],
+				TypeModifier: column.TypeModifier,
+				Nullable:     prevColumn.Nullable,
+			}
+			if isWideningTypeChange(prevSchema.System, prevColumn, currColumn) {
+				schemaDelta.WidenedColumns = append(schemaDelta.WidenedColumns, &protos.WidenedColumn{
+					Previous: prevColumn,
+					Current:  currColumn,
+				})
+				p.logger.Info("Detected widened column",
+					slog.String("columnName", column.Name),
+					slog.String("previousType", prevColumn.Type),
+					slog.String("columnType", currColumn.Type),
+					slog.String("relationName", schemaDelta.SrcTableName))
+			} else if prevColumn.Type != currColumn.Type {
+				p.logger.Warn(fmt.Sprintf("Detected column %s with type changed from %s to %s in table %s, but not propagating",
+					column.Name, prevColumn.Type, currColumn.Type, schemaDelta.SrcTableName))
+			}
+		}
+		if !excluded {
+			precedingColumn = column.Name
 		}
 	}
+	derived := p.tableNameMapping[p.srcTableIDNameMapping[currRel.RelationID]].Derived
 	for _, column := range prevSchema.Columns {
 		// present in previous relation message, but not in current one, so dropped.
+		// derived columns are never in relation messages
+		if _, isDerived := derived[column.Name]; isDerived {
+			continue
+		}
 		if _, ok := currRelMap[column.Name]; !ok {
-			p.logger.Warn(fmt.Sprintf("Detected dropped column %s in table %s, but not propagating", column,
-				schemaDelta.SrcTableName))
+			if p.propagateDroppedColumns {
+				p.logger.Info("Detected dropped column",
+					slog.String("columnName", column.Name),
+					slog.String("relationName", schemaDelta.SrcTableName))
+				schemaDelta.DroppedColumns = append(schemaDelta.DroppedColumns, column.Name)
+			} else {
+				p.logger.Warn(fmt.Sprintf("Detected dropped column %s in table %s, but not propagating",
+					column.Name, schemaDelta.SrcTableName))
+			}
 		}
 	}
 	if len(potentiallyNullableAddedColumns) > 0 {
@@ -1086,7 +1216,7 @@ func processRelationMessage[Items model.Items](
 
 	p.relationMessageMapping[currRel.RelationID] = currRel
 	// only log audit if there is actionable delta
-	if len(schemaDelta.AddedColumns) > 0 {
+	if len(schemaDelta.AddedColumns) > 0 || len(schemaDelta.DroppedColumns) > 0 || len(schemaDelta.WidenedColumns) > 0 {
 		return &model.RelationRecord[Items]{
 			BaseRecord:       p.baseRecord(lsn),
 			TableSchemaDelta: schemaDelta,
@@ -1116,40 +1246,54 @@ func (p *PostgresCDCSource) getParentRelIDIfPartitioned(relID uint32) uint32 {
 }
 
 // since we generate the childToParent mapping at the beginning of the CDC stream
-// some child tables could be created after the CDC stream starts
-// and we need to check if they inherit from a known table
+// some child tables could be attached after the CDC stream starts, or detached from their parent,
+// postgres sends a relation message for a table before its first change after either happens
 // filtered by relkind; parent needs to be a partitioned table by default
 func (p *PostgresCDCSource) checkIfUnknownTableInherits(ctx context.Context,
 	relID uint32,
 ) (uint32, error) {
-	relID = p.getParentRelIDIfPartitioned(relID)
+	if _, ok := p.srcTableIDNameMapping[relID]; ok {
+		return relID, nil
+	}
 	relkinds := "'p'"
 	if p.handleInheritanceForNonPartitionedTables {
 		relkinds = "'p', 'r'"
 	}
 
-	if _, ok := p.srcTableIDNameMapping[relID]; !ok {
-		var parentRelID uint32
-		if err := p.conn.QueryRow(
-			ctx,
-			fmt.Sprintf(`SELECT inhparent FROM pg_inherits
+	var parentRelID uint32
+	if err := p.conn.QueryRow(
+		ctx,
+		fmt.Sprintf(`WITH RECURSIVE ancestors AS (
+			SELECT inhparent AS relid, 1 AS depth FROM pg_inherits
 			JOIN pg_class c ON pg_inherits.inhparent=c.oid
-			WHERE inhrelid=$1 AND c.relkind IN (%s)`, relkinds),
-			relID,
-		).Scan(&parentRelID); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return relID, nil
+			WHERE inhrelid=$1 AND c.relkind IN (%[1]s)
+			UNION ALL
+			SELECT inhparent, ancestors.depth+1 FROM ancestors
+			JOIN pg_inherits ON pg_inherits.inhrelid=ancestors.relid
+			JOIN pg_class c ON pg_inherits.inhparent=c.oid
+			WHERE c.relkind IN (%[1]s)
+		) SELECT relid FROM ancestors WHERE relid=ANY($2) ORDER BY depth LIMIT 1`, relkinds),
+		relID, slices.Collect(maps.Keys(p.srcTableIDNameMapping)),
+	).Scan(&parentRelID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if oldParentRelID, ok := p.childToParentRelIDMapping[relID]; ok {
+				delete(p.childToParentRelIDMapping, relID)
+				p.logger.Info("Child table detached from parent table, no longer remapping it",
+					slog.Uint64("childRelID", uint64(relID)),
+					slog.Uint64("parentRelID", uint64(oldParentRelID)),
+					slog.String("parentTableName", p.srcTableIDNameMapping[oldParentRelID]))
 			}
-			return 0, fmt.Errorf("failed to query pg_inherits: %w", err)
+			return relID, nil
 		}
+		return 0, fmt.Errorf("failed to query pg_inherits: %w", err)
+	}
+	if oldParentRelID, ok := p.childToParentRelIDMapping[relID]; !ok || oldParentRelID != parentRelID {
 		p.childToParentRelIDMapping[relID] = parentRelID
 		p.hushWarnUnknownTableDetected[relID] = struct{}{}
 		p.logger.Info("Detected new child table in CDC stream, remapping to parent table",
 			slog.Uint64("childRelID", uint64(relID)),
 			slog.Uint64("parentRelID", uint64(parentRelID)),
 			slog.String("parentTableName", p.srcTableIDNameMapping[parentRelID]))
-		return parentRelID, nil
 	}
-
-	return relID, nil
+	return parentRelID, nil
 }
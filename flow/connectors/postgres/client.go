@@ -61,7 +61,7 @@ const (
 	ON %s
 	WHEN NOT MATCHED AND src._peerdb_record_type!=2 THEN
 	INSERT (%s) VALUES (%s) %s
-	WHEN MATCHED AND src._peerdb_record_type=2 THEN %s`
+	WHEN MATCHED AND src._peerdb_record_type=2%s THEN %s`
 	fallbackUpsertStatementSQL = `WITH src_rank AS (
 		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
 		RANK() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
@@ -69,6 +69,22 @@ const (
 	)
 	INSERT INTO %s (%s) SELECT %s FROM src_rank WHERE _peerdb_rank=1 AND _peerdb_record_type!=2
 	ON CONFLICT (%s) DO UPDATE SET %s`
+	upsertStatementSQL = `WITH src_rank AS (
+		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
+		RANK() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
+		FROM %s.%s WHERE _peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3
+	)
+	INSERT INTO %s (%s) SELECT %s FROM src_rank WHERE _peerdb_rank=1 AND %s
+	ON CONFLICT (%s) DO UPDATE SET %s`
+	conflictStatementSQL = `WITH src_rank AS (
+		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
+		RANK() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
+		FROM %s.%s WHERE _peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3
+	)
+	SELECT json_build_object(%s)::text,src._peerdb_record_type,count(*) OVER ()
+	FROM %s dst
+	JOIN (SELECT %s,%s AS _peerdb_conflict_timestamp,_peerdb_record_type FROM src_rank WHERE _peerdb_rank=1) src
+	ON %s WHERE %s LIMIT %d`
 	fallbackDeleteStatementSQL = `WITH src_rank AS (
 		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
 		RANK() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
@@ -275,6 +291,21 @@ func (c *PostgresConnector) checkSlotAndPublication(ctx context.Context, slot st
 	}, nil
 }
 
+// slotTwoPhase returns whether the slot decodes prepared transactions at PREPARE TRANSACTION,
+// fixed when PEERDB_POSTGRES_CDC_TWO_PHASE created it since postgres cannot disable two_phase on a slot
+func (c *PostgresConnector) slotTwoPhase(ctx context.Context, slot string, pgVersion shared.PGVersion) (bool, error) {
+	if pgVersion < shared.POSTGRES_15 {
+		return false, nil
+	}
+	var twoPhase bool
+	if err := c.conn.QueryRow(ctx,
+		"SELECT two_phase FROM pg_replication_slots WHERE slot_name = $1", slot,
+	).Scan(&twoPhase); err != nil {
+		return false, fmt.Errorf("error checking two_phase of replication slot %s: %w", slot, err)
+	}
+	return twoPhase, nil
+}
+
 func getSlotInfo(ctx context.Context, conn *pgx.Conn, slotName string, database string) ([]*protos.SlotInfo, error) {
 	var whereClause string
 	if slotName != "" {
@@ -367,6 +398,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 	tableNameMapping map[string]model.NameAndExclude,
 	doInitialCopy bool,
 	skipSnapshotExport bool,
+	twoPhase bool,
 ) (model.SetupReplicationResult, error) {
 	// iterate through source tables and create publication,
 	// expecting tablenames to be schema qualified
@@ -413,6 +445,10 @@ func (c *PostgresConnector) createSlotAndPublication(
 			Temporary: false,
 			Mode:      pglogrepl.LogicalReplication,
 		}
+		if twoPhase && pgversion >= shared.POSTGRES_15 {
+			// legacy syntax keyword, keeps the default of exporting a snapshot
+			opts.SnapshotAction = "TWO_PHASE"
+		}
 		res, err := pglogrepl.CreateReplicationSlot(ctx, conn.PgConn(), slot, "pgoutput", opts)
 		if err != nil {
 			conn.Close(ctx)
@@ -477,9 +513,16 @@ func generateCreateTableSQLForNormalizedTable(
 		if tableSchema.NullableEnabled && !column.Nullable {
 			notNull = " NOT NULL"
 		}
+		// regenerated columns are computed here, their values are not synced
+		var generated string
+		if column.GenerationExpression != "" {
+			generated = fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", column.GenerationExpression)
+		} else if column.Identity {
+			generated = " GENERATED BY DEFAULT AS IDENTITY"
+		}
 
 		createTableSQLArray = append(createTableSQLArray,
-			fmt.Sprintf("%s %s%s", utils.QuoteIdentifier(column.Name), pgColumnType, notNull))
+			fmt.Sprintf("%s %s%s%s", utils.QuoteIdentifier(column.Name), pgColumnType, notNull, generated))
 	}
 
 	if config.SoftDeleteColName != "" {
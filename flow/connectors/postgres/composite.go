@@ -0,0 +1,59 @@
+package connpostgres
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// parseComposite decodes a composite value into a json object keyed by attribute name
+func (c *PostgresConnector) parseComposite(oid uint32, format int16, data []byte) (types.QValue, error) {
+	if c.compositeTypeMap == nil {
+		return nil, fmt.Errorf("composite type %d decoded before custom types were loaded", oid)
+	}
+	ty, ok := c.compositeTypeMap.TypeForOID(oid)
+	if !ok {
+		return nil, fmt.Errorf("unknown composite type %d", oid)
+	}
+	value, err := ty.Codec.DecodeValue(c.compositeTypeMap, oid, format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode composite %s: %w", ty.Name, err)
+	}
+	if value == nil {
+		return types.QValueNull(types.QValueKindJSON), nil
+	}
+	jsonVal, err := json.Marshal(compositeJSONValue(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize composite %s: %w", ty.Name, err)
+	}
+	return types.QValueJSON{Val: string(jsonVal)}, nil
+}
+
+// compositeJSONValue converts attributes decoded by pgx into values serializing to readable json
+func compositeJSONValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, attr := range v {
+			v[key] = compositeJSONValue(attr)
+		}
+		return v
+	case []any:
+		for idx, elem := range v {
+			v[idx] = compositeJSONValue(elem)
+		}
+		return v
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case json.Marshaler:
+		return v
+	case driver.Valuer:
+		if dv, err := v.Value(); err == nil {
+			return dv
+		}
+	}
+	return value
+}
@@ -0,0 +1,49 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/postgres"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestParseComposite(t *testing.T) {
+	customTypes := map[uint32]shared.CustomDataType{
+		90001: {Name: "address", Type: 'c', Fields: []shared.CompositeField{
+			{Name: "street", OID: pgtype.TextOID},
+			{Name: "zip", OID: 90002},
+			{Name: "mood", OID: 90004},
+		}},
+		90002: {Name: "zipcode", Type: 'd', BaseOID: pgtype.Int4OID},
+		90003: {Name: "customer", Type: 'c', Fields: []shared.CompositeField{
+			{Name: "id", OID: pgtype.UUIDOID},
+			{Name: "home", OID: 90001},
+			{Name: "balance", OID: pgtype.NumericOID},
+		}},
+		90004: {Name: "mood", Type: 'e'},
+	}
+	c := &PostgresConnector{compositeTypeMap: shared.NewCompositeTypeMap(customTypes)}
+
+	value, err := c.parseComposite(90003, pgtype.TextFormatCode,
+		[]byte(`(9b2f3c8e-4f1a-4d7e-9a39-2c1d5e6f7a8b,"(""1 Main St"",12345,happy)",10.50)`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id":"9b2f3c8e-4f1a-4d7e-9a39-2c1d5e6f7a8b",
+		"home":{"street":"1 Main St","zip":12345,"mood":"happy"},
+		"balance":10.50
+	}`, value.(types.QValueJSON).Val)
+
+	value, err = c.parseComposite(90001, pgtype.TextFormatCode, []byte(`(,,)`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"street":null,"zip":null,"mood":null}`, value.(types.QValueJSON).Val)
+
+	require.Equal(t, types.QValueKindJSON, postgres.CustomTypeToQKind(customTypes[90003], shared.InternalVersion_Latest))
+	require.Equal(t, types.QValueKindString, postgres.CustomTypeToQKind(customTypes[90003], shared.InternalVersion_PgVectorAsFloatArray))
+	kind, err := postgres.PostgresOIDToQValueKind(90002, customTypes, pgtype.NewMap(), shared.InternalVersion_Latest)
+	require.NoError(t, err)
+	require.Equal(t, types.QValueKindInt32, kind)
+}
@@ -0,0 +1,159 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// conflicts beyond this many per table and batch are counted but not logged
+const maxLoggedConflictsPerTable = 1000
+
+// conflictPolicy resolves changes to destination rows that were also modified out-of-band
+type conflictPolicy struct {
+	policy          protos.ConflictPolicy
+	timestampColumn string
+}
+
+// conflictPoliciesFromMappings returns the conflict policies of tables detecting conflicts, keyed by destination table
+func conflictPoliciesFromMappings(tableMappings []*protos.TableMapping) map[string]conflictPolicy {
+	var policies map[string]conflictPolicy
+	for _, tm := range tableMappings {
+		if tm.ConflictTimestampColumn == "" {
+			continue
+		}
+		if policies == nil {
+			policies = make(map[string]conflictPolicy)
+		}
+		policies[tm.DestinationTableIdentifier] = conflictPolicy{
+			policy:          tm.ConflictPolicy,
+			timestampColumn: tm.ConflictTimestampColumn,
+		}
+	}
+	return policies
+}
+
+// conflictCondition is true when the destination row dst conflicts with a change whose timestamp column is srcTimestamp,
+// empty when conflicts of dstTable are not detected
+func (n *normalizeStmtGenerator) conflictCondition(dstTable string, dst string, srcTimestamp string) string {
+	policy, ok := n.conflictPolicies[dstTable]
+	if !ok {
+		return ""
+	}
+	dstTimestamp := dst + "." + utils.QuoteIdentifier(policy.timestampColumn)
+	if policy.policy == protos.ConflictPolicy_CONFLICT_POLICY_LATEST_TIMESTAMP_WINS {
+		return fmt.Sprintf("coalesce(%s>%s,FALSE)", dstTimestamp, srcTimestamp)
+	}
+	if n.peerdbCols.SyncedAtColName == "" {
+		return ""
+	}
+	return fmt.Sprintf("coalesce(%s>%s.%s,FALSE)", dstTimestamp, dst, utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName))
+}
+
+// applyCondition is true when a change to dstTable does not lose a conflict with the destination row dst,
+// empty when every change is applied
+func (n *normalizeStmtGenerator) applyCondition(dstTable string, dst string, srcTimestamp string) string {
+	if n.conflictPolicies[dstTable].policy == protos.ConflictPolicy_CONFLICT_POLICY_SOURCE_WINS {
+		return ""
+	}
+	if condition := n.conflictCondition(dstTable, dst, srcTimestamp); condition != "" {
+		return "NOT " + condition
+	}
+	return ""
+}
+
+// conflictTimestampRef is the timestamp column of a change to dstTable in the relation src, NULL when it is not synced
+func (n *normalizeStmtGenerator) conflictTimestampRef(dstTable string, schema *protos.TableSchema, src string) string {
+	column := n.conflictPolicies[dstTable].timestampColumn
+	if column == "" || !slices.ContainsFunc(schema.Columns, func(c *protos.FieldDescription) bool { return c.Name == column }) {
+		return "NULL"
+	}
+	return src + "." + utils.QuoteIdentifier(column)
+}
+
+// conflictTimestampExpr is the timestamp column of a change to dstTable read from the raw table
+func (n *normalizeStmtGenerator) conflictTimestampExpr(dstTable string, schema *protos.TableSchema) string {
+	policy := n.conflictPolicies[dstTable]
+	idx := slices.IndexFunc(schema.Columns, func(column *protos.FieldDescription) bool {
+		return column.Name == policy.timestampColumn
+	})
+	if idx == -1 {
+		return "NULL"
+	}
+	column := schema.Columns[idx]
+	return n.generateExpr(schema, column.Type, utils.QuoteLiteral(column.Name), n.columnTypeToPg(schema, column.Type))
+}
+
+// generateConflictStatement selects the primary key and record type of the changes to dstTable that conflict
+// with its rows, along with the total number of conflicts. It is empty when conflicts of dstTable are not detected.
+func (n *normalizeStmtGenerator) generateConflictStatement(dstTable string) string {
+	schema := withoutRegeneratedColumns(n.tableSchemaMapping[dstTable])
+	condition := n.conflictCondition(dstTable, "dst", "src._peerdb_conflict_timestamp")
+	if condition == "" {
+		return ""
+	}
+	parsedDstTable, _ := utils.ParseSchemaTable(dstTable)
+
+	primaryKeyCasts := make([]string, 0, len(schema.PrimaryKeyColumns))
+	primaryKeySelects := make([]string, 0, len(schema.PrimaryKeyColumns))
+	primaryKeyJoins := make([]string, 0, len(schema.PrimaryKeyColumns))
+	primaryKeyJSON := make([]string, 0, 2*len(schema.PrimaryKeyColumns))
+	for _, column := range schema.Columns {
+		if !slices.Contains(schema.PrimaryKeyColumns, column.Name) {
+			continue
+		}
+		quotedCol := utils.QuoteIdentifier(column.Name)
+		stringCol := utils.QuoteLiteral(column.Name)
+		pgType := n.columnTypeToPg(schema, column.Type)
+		primaryKeyCasts = append(primaryKeyCasts, fmt.Sprintf("(_peerdb_data->>%s)::%s", stringCol, pgType))
+		primaryKeySelects = append(primaryKeySelects,
+			fmt.Sprintf("%s AS %s", n.generateExpr(schema, column.Type, stringCol, pgType), quotedCol))
+		primaryKeyJoins = append(primaryKeyJoins, fmt.Sprintf("src.%s=dst.%s", quotedCol, quotedCol))
+		primaryKeyJSON = append(primaryKeyJSON, stringCol, "src."+quotedCol)
+	}
+
+	return fmt.Sprintf(conflictStatementSQL, strings.Join(primaryKeyCasts, ","), n.metadataSchema, n.rawTableName,
+		strings.Join(primaryKeyJSON, ","), parsedDstTable.String(), strings.Join(primaryKeySelects, ","),
+		n.conflictTimestampExpr(dstTable, schema), strings.Join(primaryKeyJoins, " AND "), condition,
+		maxLoggedConflictsPerTable)
+}
+
+// detectConflicts runs the conflict statement of dstTable, it must run before the normalize statements change its rows
+func (n *normalizeStmtGenerator) detectConflicts(
+	ctx context.Context,
+	tx pgx.Tx,
+	dstTable string,
+	normBatchID int64,
+	syncBatchID int64,
+) ([]model.NormalizeConflict, int64, error) {
+	stmt := n.generateConflictStatement(dstTable)
+	if stmt == "" {
+		return nil, 0, nil
+	}
+	rows, err := tx.Query(ctx, stmt, normBatchID, syncBatchID, dstTable)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error detecting conflicts for table %s: %w", dstTable, err)
+	}
+	policy := n.conflictPolicies[dstTable].policy
+	var conflicts []model.NormalizeConflict
+	var total int64
+	conflict := model.NormalizeConflict{
+		DestinationTableName: dstTable,
+		Policy:               policy,
+		SourceApplied:        policy == protos.ConflictPolicy_CONFLICT_POLICY_SOURCE_WINS,
+	}
+	if _, err := pgx.ForEachRow(rows, []any{&conflict.PrimaryKey, &conflict.RecordType, &total}, func() error {
+		conflicts = append(conflicts, conflict)
+		return nil
+	}); err != nil {
+		return nil, 0, fmt.Errorf("error reading conflicts for table %s: %w", dstTable, err)
+	}
+	return conflicts, total, nil
+}
@@ -0,0 +1,302 @@
+package connpostgres
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// ConstraintKind orders the creation of constraints, foreign keys need the unique indexes they reference
+type ConstraintKind int8
+
+const (
+	ConstraintUniqueIndex ConstraintKind = iota
+	ConstraintCheck
+	ConstraintForeignKey
+)
+
+// TableConstraint is a unique index, check constraint or foreign key of a source table
+type TableConstraint struct {
+	// source table of the constraint
+	Table string
+	Name  string
+	// unique indexes: USING method (keys) WHERE predicate, check constraints: CHECK (expression)
+	Definition string
+	// foreign keys only, with the source table referenced
+	ReferencedTable   string
+	Columns           []string
+	ReferencedColumns []string
+	OnUpdate          string
+	OnDelete          string
+	Kind              ConstraintKind
+}
+
+// foreign key actions by their pg_constraint code
+var foreignKeyActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// GetTableConstraints returns the unique indexes, check constraints and foreign keys of the source tables of tableMappings,
+// leaving out those depending on excluded columns and foreign keys referencing tables outside of tableMappings
+func (c *PostgresConnector) GetTableConstraints(
+	ctx context.Context,
+	tableMappings []*protos.TableMapping,
+) ([]TableConstraint, error) {
+	mirrored := make(map[string]*protos.TableMapping, len(tableMappings))
+	for _, tm := range tableMappings {
+		schemaTable, err := utils.ParseSchemaTable(tm.SourceTableIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		mirrored[schemaTable.Schema+"."+schemaTable.Table] = tm
+	}
+
+	var constraints []TableConstraint
+	for _, tm := range tableMappings {
+		schemaTable, err := utils.ParseSchemaTable(tm.SourceTableIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		relID, err := c.getRelIDForTable(ctx, schemaTable)
+		if err != nil {
+			return nil, err
+		}
+		tableConstraints, err := c.getTableConstraints(ctx, relID, tm.SourceTableIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		for _, constraint := range tableConstraints {
+			if slices.ContainsFunc(constraint.Columns, func(col string) bool { return slices.Contains(tm.Exclude, col) }) {
+				c.logger.Warn("skipping constraint on excluded columns",
+					slog.String("table", tm.SourceTableIdentifier), slog.String("constraint", constraint.Name))
+				continue
+			}
+			if constraint.Kind == ConstraintForeignKey {
+				referenced, ok := mirrored[constraint.ReferencedTable]
+				if !ok {
+					c.logger.Warn("skipping foreign key referencing a table outside of the mirror",
+						slog.String("table", tm.SourceTableIdentifier), slog.String("constraint", constraint.Name))
+					continue
+				} else if slices.ContainsFunc(constraint.ReferencedColumns, func(col string) bool {
+					return slices.Contains(referenced.Exclude, col)
+				}) {
+					c.logger.Warn("skipping foreign key referencing excluded columns",
+						slog.String("table", tm.SourceTableIdentifier), slog.String("constraint", constraint.Name))
+					continue
+				}
+				constraint.ReferencedTable = referenced.SourceTableIdentifier
+			}
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints, nil
+}
+
+func (c *PostgresConnector) getTableConstraints(ctx context.Context, relID uint32, table string) ([]TableConstraint, error) {
+	var constraints []TableConstraint
+	var constraint TableConstraint
+
+	rows, err := c.conn.Query(ctx, `SELECT ic.relname,
+		'USING ' || am.amname || ' (' || array_to_string(array(
+			SELECT pg_get_indexdef(i.indexrelid, k, true) FROM generate_series(1, i.indnkeyatts) k ORDER BY k), ', ') || ')' ||
+			coalesce(' WHERE ' || pg_get_expr(i.indpred, i.indrelid, true), ''),
+		array(SELECT a.attname FROM pg_attribute a WHERE a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey))
+		FROM pg_index i
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_am am ON am.oid = ic.relam
+		WHERE i.indrelid = $1 AND i.indisunique AND NOT i.indisprimary AND i.indisvalid`, relID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting unique indexes of table %s: %w", table, err)
+	}
+	if _, err := pgx.ForEachRow(rows, []any{&constraint.Name, &constraint.Definition, &constraint.Columns}, func() error {
+		constraints = append(constraints, TableConstraint{
+			Table: table, Name: constraint.Name, Definition: constraint.Definition, Columns: constraint.Columns,
+			Kind: ConstraintUniqueIndex,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error getting unique indexes of table %s: %w", table, err)
+	}
+
+	rows, err = c.conn.Query(ctx, `SELECT con.conname, pg_get_constraintdef(con.oid, true),
+		array(SELECT a.attname FROM pg_attribute a WHERE a.attrelid = con.conrelid AND a.attnum = ANY(con.conkey))
+		FROM pg_constraint con
+		WHERE con.conrelid = $1 AND con.contype = 'c'`, relID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting check constraints of table %s: %w", table, err)
+	}
+	if _, err := pgx.ForEachRow(rows, []any{&constraint.Name, &constraint.Definition, &constraint.Columns}, func() error {
+		constraints = append(constraints, TableConstraint{
+			Table: table, Name: constraint.Name, Definition: constraint.Definition, Columns: constraint.Columns,
+			Kind: ConstraintCheck,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error getting check constraints of table %s: %w", table, err)
+	}
+
+	var onUpdate, onDelete string
+	rows, err = c.conn.Query(ctx, `SELECT con.conname, rn.nspname || '.' || rc.relname,
+		array(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+			JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.ord),
+		array(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+			JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.ord),
+		con.confupdtype::text, con.confdeltype::text
+		FROM pg_constraint con
+		JOIN pg_class rc ON rc.oid = con.confrelid
+		JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+		WHERE con.conrelid = $1 AND con.contype = 'f'`, relID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting foreign keys of table %s: %w", table, err)
+	}
+	if _, err := pgx.ForEachRow(rows, []any{
+		&constraint.Name, &constraint.ReferencedTable, &constraint.Columns, &constraint.ReferencedColumns, &onUpdate, &onDelete,
+	}, func() error {
+		constraints = append(constraints, TableConstraint{
+			Table: table, Name: constraint.Name, ReferencedTable: constraint.ReferencedTable,
+			Columns: constraint.Columns, ReferencedColumns: constraint.ReferencedColumns,
+			OnUpdate: foreignKeyActions[onUpdate], OnDelete: foreignKeyActions[onDelete],
+			Kind: ConstraintForeignKey,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error getting foreign keys of table %s: %w", table, err)
+	}
+	return constraints, nil
+}
+
+func quoteIdentifiers(identifiers []string) string {
+	quoted := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		quoted = append(quoted, utils.QuoteIdentifier(identifier))
+	}
+	return strings.Join(quoted, ",")
+}
+
+// constraintStatement is the statement creating constraint on table, with referenced the destination of its referenced table.
+// foreign keys are deferred to the end of transactions, as normalize writes the tables of a batch one after another
+func constraintStatement(constraint TableConstraint, table string, referenced string) string {
+	switch constraint.Kind {
+	case ConstraintUniqueIndex:
+		return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s %s", utils.QuoteIdentifier(constraint.Name), table, constraint.Definition)
+	case ConstraintCheck:
+		return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", table, utils.QuoteIdentifier(constraint.Name), constraint.Definition)
+	default:
+		return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) ON UPDATE %s ON DELETE %s "+
+			"DEFERRABLE INITIALLY DEFERRED",
+			table, utils.QuoteIdentifier(constraint.Name), quoteIdentifiers(constraint.Columns),
+			referenced, quoteIdentifiers(constraint.ReferencedColumns), constraint.OnUpdate, constraint.OnDelete)
+	}
+}
+
+// CreateTableConstraints creates constraints on the destination tables of tableMappings in one transaction,
+// unique indexes first as foreign keys depend on them, constraints already present are left as they are
+func (c *PostgresConnector) CreateTableConstraints(
+	ctx context.Context,
+	constraints []TableConstraint,
+	tableMappings []*protos.TableMapping,
+) error {
+	dstTables := make(map[string]*utils.SchemaTable, len(tableMappings))
+	for _, tm := range tableMappings {
+		dstTable, err := utils.ParseSchemaTable(tm.DestinationTableIdentifier)
+		if err != nil {
+			return err
+		}
+		dstTables[tm.SourceTableIdentifier] = dstTable
+	}
+	constraints = slices.Clone(constraints)
+	slices.SortStableFunc(constraints, func(a, b TableConstraint) int {
+		return cmp.Compare(a.Kind, b.Kind)
+	})
+
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction for creating constraints: %w", err)
+	}
+	defer shared.RollbackTx(tx, c.logger)
+
+	for _, constraint := range constraints {
+		dstTable, ok := dstTables[constraint.Table]
+		if !ok {
+			return fmt.Errorf("no destination table for constraint %s of table %s", constraint.Name, constraint.Table)
+		}
+		var referenced string
+		if constraint.Kind == ConstraintForeignKey {
+			referencedTable, ok := dstTables[constraint.ReferencedTable]
+			if !ok {
+				return fmt.Errorf("no destination table for table %s referenced by %s", constraint.ReferencedTable, constraint.Name)
+			}
+			referenced = referencedTable.String()
+		}
+
+		var onTable, inSchema bool
+		if constraint.Kind == ConstraintUniqueIndex {
+			if err := tx.QueryRow(ctx, `SELECT coalesce(bool_or(i.indrelid = $3::regclass), false), count(*) > 0
+				FROM pg_class ic JOIN pg_namespace n ON n.oid = ic.relnamespace
+				LEFT JOIN pg_index i ON i.indexrelid = ic.oid
+				WHERE n.nspname = $1 AND ic.relname = $2`,
+				dstTable.Schema, constraint.Name, dstTable.String(),
+			).Scan(&onTable, &inSchema); err != nil {
+				return fmt.Errorf("error checking for index %s: %w", constraint.Name, err)
+			}
+		} else if err := tx.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM pg_constraint WHERE conrelid = $1::regclass AND conname = $2)",
+			dstTable.String(), constraint.Name,
+		).Scan(&onTable); err != nil {
+			return fmt.Errorf("error checking for constraint %s: %w", constraint.Name, err)
+		}
+		if onTable {
+			continue
+		}
+
+		stmt := constraintStatement(constraint, dstTable.String(), referenced)
+		if inSchema {
+			// index names are unique per schema, like when an old table is kept while resyncing, let Postgres name it
+			stmt = fmt.Sprintf("CREATE UNIQUE INDEX ON %s %s", dstTable.String(), constraint.Definition)
+		}
+		if _, err := c.execWithLoggingTx(ctx, stmt, tx); err != nil {
+			return fmt.Errorf("error creating constraint %s on %s: %w", constraint.Name, dstTable, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("unable to commit transaction for creating constraints: %w", err)
+	}
+	return nil
+}
+
+// dropForeignKeysReferencing drops the foreign keys of other tables referencing table
+func (c *PostgresConnector) dropForeignKeysReferencing(ctx context.Context, tx pgx.Tx, table string) error {
+	rows, err := tx.Query(ctx, `SELECT conrelid::regclass::text, conname FROM pg_constraint
+		WHERE contype = 'f' AND confrelid = $1::regclass AND conrelid <> confrelid`, table)
+	if err != nil {
+		return fmt.Errorf("error getting foreign keys referencing %s: %w", table, err)
+	}
+	var referencing, name string
+	var stmts []string
+	if _, err := pgx.ForEachRow(rows, []any{&referencing, &name}, func() error {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", referencing, utils.QuoteIdentifier(name)))
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error getting foreign keys referencing %s: %w", table, err)
+	}
+	for _, stmt := range stmts {
+		if _, err := c.execWithLoggingTx(ctx, stmt, tx); err != nil {
+			return fmt.Errorf("error dropping foreign key referencing %s: %w", table, err)
+		}
+	}
+	return nil
+}
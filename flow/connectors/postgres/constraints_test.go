@@ -0,0 +1,33 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraintStatement(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, `CREATE UNIQUE INDEX "t_email_key" ON "public"."t" USING btree (lower(email)) WHERE deleted_at IS NULL`,
+		constraintStatement(TableConstraint{
+			Name:       "t_email_key",
+			Definition: "USING btree (lower(email)) WHERE deleted_at IS NULL",
+			Kind:       ConstraintUniqueIndex,
+		}, `"public"."t"`, ""))
+	require.Equal(t, `ALTER TABLE "public"."t" ADD CONSTRAINT "t_price_check" CHECK (price > 0)`,
+		constraintStatement(TableConstraint{
+			Name:       "t_price_check",
+			Definition: "CHECK (price > 0)",
+			Kind:       ConstraintCheck,
+		}, `"public"."t"`, ""))
+	require.Equal(t, `ALTER TABLE "public"."t" ADD CONSTRAINT "t_owner_fkey" FOREIGN KEY ("owner_id","tenant") `+
+		`REFERENCES "public"."owners" ("id","tenant") ON UPDATE NO ACTION ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED`,
+		constraintStatement(TableConstraint{
+			Name:              "t_owner_fkey",
+			Columns:           []string{"owner_id", "tenant"},
+			ReferencedColumns: []string{"id", "tenant"},
+			OnUpdate:          foreignKeyActions["a"],
+			OnDelete:          foreignKeyActions["c"],
+			Kind:              ConstraintForeignKey,
+		}, `"public"."t"`, `"public"."owners"`))
+}
@@ -0,0 +1,75 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// TableRowCountAndChecksum sums a 64-bit hash of every row, so the checksum does not depend on scan order
+func (c *PostgresConnector) TableRowCountAndChecksum(
+	ctx context.Context,
+	table string,
+	columns []string,
+	softDeleteColName string,
+) (int64, string, error) {
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return 0, "", err
+	}
+	checksumExpr := "''"
+	if len(columns) > 0 {
+		quotedColumns := make([]string, 0, len(columns))
+		for _, column := range columns {
+			quotedColumns = append(quotedColumns, utils.QuoteIdentifier(column))
+		}
+		checksumExpr = fmt.Sprintf("coalesce(sum(hashtextextended(ROW(%s)::text, 0)::numeric), 0)::text",
+			strings.Join(quotedColumns, ","))
+	}
+	query := fmt.Sprintf("SELECT count(*), %s FROM %s", checksumExpr, schemaTable.String())
+	if softDeleteColName != "" {
+		query += fmt.Sprintf(" WHERE %s IS NOT TRUE", utils.QuoteIdentifier(softDeleteColName))
+	}
+
+	var rowCount int64
+	var checksum string
+	if err := c.conn.QueryRow(ctx, query).Scan(&rowCount, &checksum); err != nil {
+		return 0, "", fmt.Errorf("failed to count rows of %s: %w", table, err)
+	}
+	return rowCount, checksum, nil
+}
+
+// GetOwnedSequences returns the current value of the sequences owned by columns of the given tables,
+// sequences that were never called report their start value
+func (c *PostgresConnector) GetOwnedSequences(ctx context.Context, tables []string) ([]*protos.CutoverSequence, error) {
+	quotedTables := make([]string, 0, len(tables))
+	for _, table := range tables {
+		schemaTable, err := utils.ParseSchemaTable(table)
+		if err != nil {
+			return nil, err
+		}
+		quotedTables = append(quotedTables, schemaTable.String())
+	}
+
+	rows, err := c.conn.Query(ctx, `SELECT s.seqrelid::regclass::text, d.refobjid::regclass::text, a.attname,
+		coalesce(pg_sequence_last_value(s.seqrelid), s.seqstart)
+		FROM pg_sequence s
+		JOIN pg_depend d ON d.classid = 'pg_class'::regclass AND d.objid = s.seqrelid
+			AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+		JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE d.refobjid = ANY($1::text[]::regclass[])
+		ORDER BY 1`, quotedTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sequences: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.CutoverSequence, error) {
+		var sequence protos.CutoverSequence
+		err := row.Scan(&sequence.SequenceName, &sequence.TableName, &sequence.ColumnName, &sequence.LastValue)
+		return &sequence, err
+	})
+}
@@ -0,0 +1,99 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// generatedColumn is a stored generated column, with its expression, or an identity column
+type generatedColumn struct {
+	expression string
+	identity   bool
+}
+
+func (col generatedColumn) policy(generated, identity protos.GeneratedColumnPolicy) protos.GeneratedColumnPolicy {
+	if col.identity {
+		return identity
+	}
+	return generated
+}
+
+func hasGeneratedColumnPolicy(generated, identity protos.GeneratedColumnPolicy) bool {
+	return generated != protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REPLICATE ||
+		identity != protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REPLICATE
+}
+
+// getGeneratedColumns returns the stored generated and identity columns of a table, keyed by name
+func (c *PostgresConnector) getGeneratedColumns(ctx context.Context, relID uint32) (map[string]generatedColumn, error) {
+	rows, err := c.conn.Query(ctx, `SELECT a.attname, a.attidentity <> '', coalesce(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped AND (a.attidentity <> '' OR a.attgenerated = 's')`, relID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting generated columns for table %v: %w", relID, err)
+	}
+
+	var name string
+	var col generatedColumn
+	generatedCols := make(map[string]generatedColumn)
+	_, err = pgx.ForEachRow(rows, []any{&name, &col.identity, &col.expression}, func() error {
+		if col.identity {
+			col.expression = ""
+		}
+		generatedCols[name] = col
+		return nil
+	})
+	return generatedCols, err
+}
+
+// excludeGeneratedColumns adds the columns skipped or regenerated at the destination to the excluded columns of tables,
+// their values are never synced
+func (c *PostgresConnector) excludeGeneratedColumns(
+	ctx context.Context,
+	tableNameMapping map[string]model.NameAndExclude,
+) (map[string]model.NameAndExclude, error) {
+	var excluded map[string]model.NameAndExclude
+	for srcTable, nameAndExclude := range tableNameMapping {
+		if !hasGeneratedColumnPolicy(nameAndExclude.GeneratedColumns, nameAndExclude.IdentityColumns) {
+			continue
+		}
+		schemaTable, err := utils.ParseSchemaTable(srcTable)
+		if err != nil {
+			return nil, err
+		}
+		relID, err := c.getRelIDForTable(ctx, schemaTable)
+		if err != nil {
+			return nil, err
+		}
+		generatedCols, err := c.getGeneratedColumns(ctx, relID)
+		if err != nil {
+			return nil, err
+		}
+		exclude := maps.Clone(nameAndExclude.Exclude)
+		for name, col := range generatedCols {
+			if col.policy(nameAndExclude.GeneratedColumns, nameAndExclude.IdentityColumns) !=
+				protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REPLICATE {
+				if exclude == nil {
+					exclude = make(map[string]struct{})
+				}
+				exclude[name] = struct{}{}
+			}
+		}
+		if excluded == nil {
+			excluded = maps.Clone(tableNameMapping)
+		}
+		nameAndExclude.Exclude = exclude
+		excluded[srcTable] = nameAndExclude
+	}
+	if excluded == nil {
+		return tableNameMapping, nil
+	}
+	return excluded, nil
+}
@@ -0,0 +1,132 @@
+package connpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// GetSlotLagBytes returns how far the confirmed position of a replication slot trails untilLSN,
+// or the current position of the source when untilLSN is empty
+func (c *PostgresConnector) GetSlotLagBytes(ctx context.Context, slotName string, untilLSN string) (int64, error) {
+	var lag int64
+	if err := c.conn.QueryRow(ctx, `SELECT greatest(coalesce(pg_wal_lsn_diff(
+		coalesce(nullif($2, '')::pg_lsn,
+			CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END),
+		confirmed_flush_lsn), 0), 0)::bigint
+		FROM pg_replication_slots WHERE slot_name = $1`, slotName, untilLSN).Scan(&lag); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("replication slot %s does not exist", slotName)
+		}
+		return 0, fmt.Errorf("failed to get lag of replication slot %s: %w", slotName, err)
+	}
+	return lag, nil
+}
+
+// GetCurrentLSN returns the current WAL position of the source as text
+func (c *PostgresConnector) GetCurrentLSN(ctx context.Context) (string, error) {
+	lsn, err := c.getCurrentLSN(ctx)
+	if err != nil {
+		return "", err
+	}
+	if lsn.Null {
+		return "", errors.New("current LSN is null")
+	}
+	return lsn.LSN.String(), nil
+}
+
+// GetTableWriteCounts returns the rows inserted, updated and deleted in each of tables since statistics were reset,
+// tables locked for writing by an open transaction count as -1 since their writes are not reflected yet
+func (c *PostgresConnector) GetTableWriteCounts(ctx context.Context, tables []string) (map[string]int64, error) {
+	quotedTables := make([]string, 0, len(tables))
+	for _, table := range tables {
+		schemaTable, err := utils.ParseSchemaTable(table)
+		if err != nil {
+			return nil, err
+		}
+		quotedTables = append(quotedTables, schemaTable.String())
+	}
+
+	rows, err := c.conn.Query(ctx, `SELECT t.relid::regclass::text,
+		CASE WHEN EXISTS (SELECT 1 FROM pg_locks l WHERE l.relation = t.relid AND l.mode = 'RowExclusiveLock'
+			AND l.granted AND l.pid <> pg_backend_pid()) THEN -1
+		ELSE t.n_tup_ins + t.n_tup_upd + t.n_tup_del END
+		FROM pg_stat_user_tables t WHERE t.relid = ANY($1::text[]::regclass[])`, quotedTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table write counts: %w", err)
+	}
+	var table string
+	var count int64
+	counts := make(map[string]int64, len(tables))
+	if _, err := pgx.ForEachRow(rows, []any{&table, &count}, func() error {
+		counts[table] = count
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read table write counts: %w", err)
+	}
+	return counts, nil
+}
+
+// SetSequenceValue advances the sequence owned by a column of table, or the sequence named fallbackName
+// when the column owns none, so the next value follows lastValue. It returns false when neither sequence exists.
+func (c *PostgresConnector) SetSequenceValue(
+	ctx context.Context, table string, column string, fallbackName string, lastValue int64,
+) (bool, error) {
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return false, err
+	}
+	var sequence *string
+	if err := c.conn.QueryRow(ctx, "SELECT coalesce(pg_get_serial_sequence($1, $2), to_regclass($3)::text)",
+		schemaTable.String(), column, fallbackName,
+	).Scan(&sequence); err != nil {
+		return false, fmt.Errorf("failed to find sequence of %s.%s: %w", table, column, err)
+	}
+	if sequence == nil {
+		return false, nil
+	}
+	if _, err := c.conn.Exec(ctx, "SELECT setval($1::regclass, $2)", *sequence, lastValue); err != nil {
+		return false, fmt.Errorf("failed to set value of sequence %s: %w", *sequence, err)
+	}
+	return true, nil
+}
+
+// SyncOwnedSequences sets the sequences of the destination tables to the values of the sequences owned by
+// their source tables on src, returning how many were set
+func (c *PostgresConnector) SyncOwnedSequences(
+	ctx context.Context, src *PostgresConnector, tableMappings []*protos.TableMapping,
+) (int32, error) {
+	var synced int32
+	for _, tm := range tableMappings {
+		sequences, err := src.GetOwnedSequences(ctx, []string{tm.SourceTableIdentifier})
+		if err != nil {
+			return synced, err
+		}
+		for _, sequence := range sequences {
+			column := sequence.ColumnName
+			for _, col := range tm.Columns {
+				if col.SourceName == sequence.ColumnName && col.DestinationName != "" {
+					column = col.DestinationName
+				}
+			}
+			ok, err := c.SetSequenceValue(ctx, tm.DestinationTableIdentifier, column, sequence.SequenceName, sequence.LastValue)
+			if err != nil {
+				return synced, err
+			}
+			if !ok {
+				c.logger.Warn("no destination sequence found, skipping",
+					slog.String("sequence", sequence.SequenceName),
+					slog.String("table", tm.DestinationTableIdentifier), slog.String("column", column))
+				continue
+			}
+			synced += 1
+		}
+	}
+	return synced, nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -29,6 +30,10 @@ type normalizeStmtGenerator struct {
 	metadataSchema string
 	// Postgres version 15 introduced MERGE, fallback statements before that
 	supportsMerge bool
+	// INSERT ... ON CONFLICT DO UPDATE instead of MERGE, set by PEERDB_POSTGRES_NORMALIZE_MODE
+	upsert bool
+	// conflict policies of tables detecting conflicts, keyed by destination table
+	conflictPolicies map[string]conflictPolicy
 }
 
 func (n *normalizeStmtGenerator) columnTypeToPg(schema *protos.TableSchema, columnType string) string {
@@ -59,8 +64,26 @@ func (n *normalizeStmtGenerator) generateExpr(
 	return fmt.Sprintf("(_peerdb_data->>%s)::%s", stringCol, pgType)
 }
 
+// withoutRegeneratedColumns leaves out the columns generated by the destination table, they can't be written
+func withoutRegeneratedColumns(schema *protos.TableSchema) *protos.TableSchema {
+	if !slices.ContainsFunc(schema.Columns, isRegenerated) {
+		return schema
+	}
+	filtered := proto.CloneOf(schema)
+	filtered.Columns = slices.DeleteFunc(filtered.Columns, isRegenerated)
+	return filtered
+}
+
+func isRegenerated(column *protos.FieldDescription) bool {
+	return column.GenerationExpression != "" || column.Identity
+}
+
 func (n *normalizeStmtGenerator) generateNormalizeStatements(dstTable string) []string {
-	normalizedTableSchema := n.tableSchemaMapping[dstTable]
+	normalizedTableSchema := withoutRegeneratedColumns(n.tableSchemaMapping[dstTable])
+	if n.upsert {
+		unchangedToastColumns := n.unchangedToastColumnsMap[dstTable]
+		return n.generateUpsertStatements(dstTable, normalizedTableSchema, unchangedToastColumns)
+	}
 	if n.supportsMerge {
 		unchangedToastColumns := n.unchangedToastColumnsMap[dstTable]
 		return []string{n.generateMergeStatement(dstTable, normalizedTableSchema, unchangedToastColumns)}
@@ -104,11 +127,19 @@ func (n *normalizeStmtGenerator) generateFallbackStatements(
 		updateColumnsSQLArray = append(updateColumnsSQLArray, fmt.Sprintf(`%s=EXCLUDED.%s`, quotedCol, quotedCol))
 	}
 	updateColumnsSQL := strings.Join(updateColumnsSQLArray, ",")
+	if apply := n.applyCondition(dstTableName, parsedDstTable.String(),
+		n.conflictTimestampRef(dstTableName, normalizedTableSchema, "EXCLUDED")); apply != "" {
+		updateColumnsSQL += " WHERE " + apply
+	}
 	deleteWhereClauseArray := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
 	for columnName, columnCast := range primaryKeyColumnCasts {
 		deleteWhereClauseArray = append(deleteWhereClauseArray, fmt.Sprintf(`%s.%s=%s`,
 			parsedDstTable.String(), utils.QuoteIdentifier(columnName), columnCast))
 	}
+	if apply := n.applyCondition(dstTableName, parsedDstTable.String(),
+		n.conflictTimestampExpr(dstTableName, normalizedTableSchema)); apply != "" {
+		deleteWhereClauseArray = append(deleteWhereClauseArray, apply)
+	}
 	deleteWhereClauseSQL := strings.Join(deleteWhereClauseArray, " AND ")
 
 	// make it update instead in case soft-delete is enabled
@@ -132,6 +163,103 @@ func (n *normalizeStmtGenerator) generateFallbackStatements(
 	return []string{fallbackUpsertStatement, fallbackDeleteStatement}
 }
 
+// generateUpsertStatements applies inserts and updates with one INSERT ... ON CONFLICT DO UPDATE
+// per combination of unchanged toast columns, leaving those columns as they are on conflict.
+// Deletes become an upsert setting the soft delete column, or a DELETE when soft delete is off.
+func (n *normalizeStmtGenerator) generateUpsertStatements(
+	dstTableName string,
+	normalizedTableSchema *protos.TableSchema,
+	unchangedToastColumns []string,
+) []string {
+	columnCount := len(normalizedTableSchema.Columns)
+	quotedColumnNames := make([]string, 0, columnCount+2)
+	selectExprs := make([]string, 0, columnCount+2)
+	primaryKeyColumnCasts := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+	quotedPrimaryKeyColumns := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+	deleteWhereClauseArray := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+	parsedDstTable, _ := utils.ParseSchemaTable(dstTableName)
+	for _, column := range normalizedTableSchema.Columns {
+		quotedCol := utils.QuoteIdentifier(column.Name)
+		stringCol := utils.QuoteLiteral(column.Name)
+		pgType := n.columnTypeToPg(normalizedTableSchema, column.Type)
+		expr := n.generateExpr(normalizedTableSchema, column.Type, stringCol, pgType)
+
+		quotedColumnNames = append(quotedColumnNames, quotedCol)
+		selectExprs = append(selectExprs, expr)
+		if slices.Contains(normalizedTableSchema.PrimaryKeyColumns, column.Name) {
+			primaryKeyColumnCasts = append(primaryKeyColumnCasts, fmt.Sprintf("(_peerdb_data->>%s)::%s", stringCol, pgType))
+			quotedPrimaryKeyColumns = append(quotedPrimaryKeyColumns, quotedCol)
+			deleteWhereClauseArray = append(deleteWhereClauseArray,
+				fmt.Sprintf("%s.%s=%s", parsedDstTable.String(), quotedCol, expr))
+		}
+	}
+	dataColumns := quotedColumnNames
+	var peerdbColumnsUpdate []string
+	if n.peerdbCols.SyncedAtColName != "" {
+		quotedColumnNames = append(quotedColumnNames, utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName))
+		selectExprs = append(selectExprs, "CURRENT_TIMESTAMP")
+		peerdbColumnsUpdate = append(peerdbColumnsUpdate,
+			utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName)+"=CURRENT_TIMESTAMP")
+	}
+	partitionBySQL := strings.Join(primaryKeyColumnCasts, ",")
+	conflictSQL := strings.Join(quotedPrimaryKeyColumns, ",")
+	var updateWhere string
+	if apply := n.applyCondition(dstTableName, parsedDstTable.String(),
+		n.conflictTimestampRef(dstTableName, normalizedTableSchema, "EXCLUDED")); apply != "" {
+		updateWhere = " WHERE " + apply
+	}
+
+	upsert := func(columns []string, exprs []string, filter string, updates []string) string {
+		return fmt.Sprintf(upsertStatementSQL, partitionBySQL, n.metadataSchema, n.rawTableName,
+			parsedDstTable.String(), strings.Join(columns, ","), strings.Join(exprs, ","), filter,
+			conflictSQL, strings.Join(updates, ",")) + updateWhere
+	}
+
+	upsertColumns := quotedColumnNames
+	upsertExprs := selectExprs
+	if n.peerdbCols.SoftDeleteColName != "" {
+		upsertColumns = append(slices.Clone(quotedColumnNames), utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName))
+		upsertExprs = append(slices.Clone(selectExprs), "FALSE")
+	}
+	stmts := make([]string, 0, len(unchangedToastColumns)+1)
+	for _, cols := range unchangedToastColumns {
+		var unchangedCols []string
+		if cols != "" {
+			for _, col := range strings.Split(cols, ",") {
+				unchangedCols = append(unchangedCols, utils.QuoteIdentifier(col))
+			}
+		}
+		updates := make([]string, 0, len(dataColumns)+2)
+		for _, col := range shared.ArrayMinus(dataColumns, unchangedCols) {
+			updates = append(updates, fmt.Sprintf("%s=EXCLUDED.%s", col, col))
+		}
+		updates = append(updates, peerdbColumnsUpdate...)
+		if n.peerdbCols.SoftDeleteColName != "" {
+			updates = append(updates, utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName)+"=FALSE")
+		}
+		stmts = append(stmts, upsert(upsertColumns, upsertExprs,
+			"_peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns="+utils.QuoteLiteral(cols), updates))
+	}
+
+	if n.peerdbCols.SoftDeleteColName != "" {
+		quotedSoftDeleteCol := utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName)
+		stmts = append(stmts, upsert(
+			append(slices.Clone(quotedColumnNames), quotedSoftDeleteCol),
+			append(slices.Clone(selectExprs), "TRUE"),
+			"_peerdb_record_type=2",
+			append([]string{quotedSoftDeleteCol + "=TRUE"}, peerdbColumnsUpdate...),
+		))
+	} else {
+		if apply := n.applyCondition(dstTableName, parsedDstTable.String(),
+			n.conflictTimestampExpr(dstTableName, normalizedTableSchema)); apply != "" {
+			deleteWhereClauseArray = append(deleteWhereClauseArray, apply)
+		}
+		stmts = append(stmts, fmt.Sprintf(fallbackDeleteStatementSQL, partitionBySQL, n.metadataSchema, n.rawTableName,
+			fmt.Sprintf("DELETE FROM %s USING ", parsedDstTable.String()), strings.Join(deleteWhereClauseArray, " AND ")))
+	}
+	return stmts
+}
+
 func (n *normalizeStmtGenerator) generateMergeStatement(
 	dstTableName string,
 	normalizedTableSchema *protos.TableSchema,
@@ -165,7 +293,12 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 		insertValuesSQLArray = append(insertValuesSQLArray, "src."+quotedCol)
 	}
 
-	updateStatementsforToastCols := n.generateUpdateStatements(quotedColumnNames, unchangedToastColumns)
+	var matchedFilter string
+	if apply := n.applyCondition(dstTableName, "dst",
+		n.conflictTimestampRef(dstTableName, normalizedTableSchema, "src")); apply != "" {
+		matchedFilter = " AND " + apply
+	}
+	updateStatementsforToastCols := n.generateUpdateStatements(quotedColumnNames, unchangedToastColumns, matchedFilter)
 	// append synced_at column
 	if n.peerdbCols.SyncedAtColName != "" {
 		quotedColumnNames = append(quotedColumnNames, utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName))
@@ -205,13 +338,19 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 		insertColumnsSQL,
 		insertValuesSQL,
 		updateStringToastCols,
+		matchedFilter,
 		conflictPart,
 	)
 
 	return mergeStmt
 }
 
-func (n *normalizeStmtGenerator) generateUpdateStatements(quotedCols []string, unchangedToastColumns []string) []string {
+// generateUpdateStatements generates the WHEN MATCHED clauses of MERGE, matchedFilter is appended to their conditions
+func (n *normalizeStmtGenerator) generateUpdateStatements(
+	quotedCols []string,
+	unchangedToastColumns []string,
+	matchedFilter string,
+) []string {
 	handleSoftDelete := n.peerdbCols.SoftDeleteColName != ""
 	stmtCount := len(unchangedToastColumns)
 	if handleSoftDelete {
@@ -241,8 +380,8 @@ func (n *normalizeStmtGenerator) generateUpdateStatements(quotedCols []string, u
 		quotedCols := utils.QuoteLiteral(cols)
 		ssep := strings.Join(tmpArray, ",")
 		updateStmt := fmt.Sprintf(`WHEN MATCHED AND
-			src._peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=%s
-			THEN UPDATE SET %s`, quotedCols, ssep)
+			src._peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=%s%s
+			THEN UPDATE SET %s`, quotedCols, matchedFilter, ssep)
 		updateStmts = append(updateStmts, updateStmt)
 
 		// generates update statements for the case where updates and deletes happen in the same branch
@@ -252,8 +391,8 @@ func (n *normalizeStmtGenerator) generateUpdateStatements(quotedCols []string, u
 			tmpArray[len(tmpArray)-1] = utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName) + `=TRUE`
 			ssep := strings.Join(tmpArray, ", ")
 			updateStmt := fmt.Sprintf(`WHEN MATCHED AND
-			src._peerdb_record_type=2 AND _peerdb_unchanged_toast_columns=%s
-			THEN UPDATE SET %s`, quotedCols, ssep)
+			src._peerdb_record_type=2 AND _peerdb_unchanged_toast_columns=%s%s
+			THEN UPDATE SET %s`, quotedCols, matchedFilter, ssep)
 			updateStmts = append(updateStmts, updateStmt)
 		}
 	}
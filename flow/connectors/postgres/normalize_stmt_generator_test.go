@@ -2,6 +2,7 @@ package connpostgres
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -23,7 +24,7 @@ func TestGenerateMergeUpdateStatement(t *testing.T) {
 			SoftDeleteColName: "",
 		},
 	}
-	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols)
+	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols, "")
 
 	for i := range expected {
 		expected[i] = utils.RemoveSpacesTabsNewlines(expected[i])
@@ -53,7 +54,7 @@ func TestGenerateMergeUpdateStatement_WithSoftDelete(t *testing.T) {
 			SoftDeleteColName: "_peerdb_soft_delete",
 		},
 	}
-	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols)
+	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols, "")
 
 	for i := range expected {
 		expected[i] = utils.RemoveSpacesTabsNewlines(expected[i])
@@ -85,7 +86,7 @@ func TestGenerateMergeUpdateStatement_WithUnchangedToastCols(t *testing.T) {
 			SoftDeleteColName: "",
 		},
 	}
-	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols)
+	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols, "")
 
 	for i := range expected {
 		expected[i] = utils.RemoveSpacesTabsNewlines(expected[i])
@@ -127,7 +128,7 @@ func TestGenerateMergeUpdateStatement_WithUnchangedToastColsAndSoftDelete(t *tes
 			SoftDeleteColName: "_peerdb_soft_delete",
 		},
 	}
-	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols)
+	result := normalizeGen.generateUpdateStatements(allCols, unchangedToastCols, "")
 
 	for i := range expected {
 		expected[i] = utils.RemoveSpacesTabsNewlines(expected[i])
@@ -138,3 +139,147 @@ func TestGenerateMergeUpdateStatement_WithUnchangedToastColsAndSoftDelete(t *tes
 		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
 	}
 }
+
+func TestGenerateUpsertStatements(t *testing.T) {
+	normalizeGen := normalizeStmtGenerator{
+		rawTableName:   "_peerdb_raw_mirror",
+		metadataSchema: "_peerdb_internal",
+		peerdbCols: &protos.PeerDBColumns{
+			SyncedAtColName:   "_peerdb_synced_at",
+			SoftDeleteColName: "_peerdb_soft_delete",
+		},
+		upsert: true,
+	}
+	schema := &protos.TableSchema{
+		System:            protos.TypeSystem_PG,
+		PrimaryKeyColumns: []string{"id"},
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: "integer"},
+			{Name: "doc", Type: "text"},
+		},
+	}
+	srcRank := `WITH src_rank AS (
+		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
+		RANK() OVER (PARTITION BY (_peerdb_data->>'id')::integer ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
+		FROM _peerdb_internal._peerdb_raw_mirror
+		WHERE _peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3)`
+	expected := []string{
+		srcRank + `INSERT INTO "public"."t" ("id","doc","_peerdb_synced_at","_peerdb_soft_delete")
+		SELECT (_peerdb_data->>'id')::integer,(_peerdb_data->>'doc')::text,CURRENT_TIMESTAMP,FALSE
+		FROM src_rank WHERE _peerdb_rank=1 AND _peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=''
+		ON CONFLICT ("id") DO UPDATE SET "id"=EXCLUDED."id","doc"=EXCLUDED."doc",
+		"_peerdb_synced_at"=CURRENT_TIMESTAMP,"_peerdb_soft_delete"=FALSE`,
+		srcRank + `INSERT INTO "public"."t" ("id","doc","_peerdb_synced_at","_peerdb_soft_delete")
+		SELECT (_peerdb_data->>'id')::integer,(_peerdb_data->>'doc')::text,CURRENT_TIMESTAMP,FALSE
+		FROM src_rank WHERE _peerdb_rank=1 AND _peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns='doc'
+		ON CONFLICT ("id") DO UPDATE SET "id"=EXCLUDED."id","_peerdb_synced_at"=CURRENT_TIMESTAMP,"_peerdb_soft_delete"=FALSE`,
+		srcRank + `INSERT INTO "public"."t" ("id","doc","_peerdb_synced_at","_peerdb_soft_delete")
+		SELECT (_peerdb_data->>'id')::integer,(_peerdb_data->>'doc')::text,CURRENT_TIMESTAMP,TRUE
+		FROM src_rank WHERE _peerdb_rank=1 AND _peerdb_record_type=2
+		ON CONFLICT ("id") DO UPDATE SET "_peerdb_soft_delete"=TRUE,"_peerdb_synced_at"=CURRENT_TIMESTAMP`,
+	}
+	result := normalizeGen.generateUpsertStatements("public.t", schema, []string{"", "doc"})
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d statements, got %d: %v", len(expected), len(result), result)
+	}
+	for i := range expected {
+		expected[i] = utils.RemoveSpacesTabsNewlines(expected[i])
+		result[i] = utils.RemoveSpacesTabsNewlines(result[i])
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	normalizeGen.peerdbCols.SoftDeleteColName = ""
+	result = normalizeGen.generateUpsertStatements("public.t", schema, nil)
+	expectedDelete := utils.RemoveSpacesTabsNewlines(srcRank + `DELETE FROM "public"."t" USING src_rank
+		WHERE "public"."t"."id"=(_peerdb_data->>'id')::integer AND src_rank._peerdb_rank=1 AND src_rank._peerdb_record_type=2`)
+	if len(result) != 1 || utils.RemoveSpacesTabsNewlines(result[0]) != expectedDelete {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expectedDelete, result)
+	}
+}
+
+func TestRegeneratedColumns(t *testing.T) {
+	schema := &protos.TableSchema{
+		System:            protos.TypeSystem_PG,
+		PrimaryKeyColumns: []string{"id"},
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: "integer"},
+			{Name: "price", Type: "integer"},
+			{Name: "total", Type: "integer", GenerationExpression: "(price * 2)"},
+			{Name: "seq", Type: "bigint", Identity: true},
+		},
+	}
+	dstTable, _ := utils.ParseSchemaTable("public.t")
+	expectedDDL := utils.RemoveSpacesTabsNewlines(`CREATE TABLE IF NOT EXISTS "public"."t"("id" integer,"price" integer,
+		"total" integer GENERATED ALWAYS AS ((price * 2)) STORED,"seq" bigint GENERATED BY DEFAULT AS IDENTITY,PRIMARY KEY("id"))`)
+	if ddl := utils.RemoveSpacesTabsNewlines(generateCreateTableSQLForNormalizedTable(
+		&protos.SetupNormalizedTableBatchInput{}, dstTable, schema,
+	)); ddl != expectedDDL {
+		t.Errorf("Unexpected DDL. Expected: %v, but got: %v", expectedDDL, ddl)
+	}
+
+	filtered := withoutRegeneratedColumns(schema)
+	if len(filtered.Columns) != 2 || filtered.Columns[0].Name != "id" || filtered.Columns[1].Name != "price" {
+		t.Errorf("Expected regenerated columns to be left out, got: %v", filtered.Columns)
+	}
+	if len(schema.Columns) != 4 {
+		t.Errorf("Expected schema to be left unchanged, got: %v", schema.Columns)
+	}
+}
+
+func TestConflictPolicies(t *testing.T) {
+	schema := &protos.TableSchema{
+		System:            protos.TypeSystem_PG,
+		PrimaryKeyColumns: []string{"id"},
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: "integer"},
+			{Name: "updated_at", Type: "timestamp"},
+		},
+	}
+	normalizeGen := &normalizeStmtGenerator{
+		rawTableName:             "_peerdb_raw_test",
+		tableSchemaMapping:       map[string]*protos.TableSchema{"public.t": schema},
+		unchangedToastColumnsMap: map[string][]string{"public.t": {""}},
+		peerdbCols:               &protos.PeerDBColumns{SyncedAtColName: "_peerdb_synced_at"},
+		metadataSchema:           "_peerdb_internal",
+		supportsMerge:            true,
+		conflictPolicies: conflictPoliciesFromMappings([]*protos.TableMapping{{
+			DestinationTableIdentifier: "public.t",
+			ConflictPolicy:             protos.ConflictPolicy_CONFLICT_POLICY_LATEST_TIMESTAMP_WINS,
+			ConflictTimestampColumn:    "updated_at",
+		}}),
+	}
+
+	merge := normalizeGen.generateNormalizeStatements("public.t")[0]
+	if strings.Count(merge, `AND NOT coalesce(dst."updated_at">src."updated_at",FALSE)`) != 2 {
+		t.Errorf("Expected updates and deletes of newer rows to be skipped, got: %s", merge)
+	}
+	conflicts := normalizeGen.generateConflictStatement("public.t")
+	if !strings.Contains(conflicts, `WHERE coalesce(dst."updated_at">src._peerdb_conflict_timestamp,FALSE)`) {
+		t.Errorf("Expected conflicts with newer rows to be detected, got: %s", conflicts)
+	}
+
+	normalizeGen.upsert = true
+	normalizeGen.conflictPolicies["public.t"] = conflictPolicy{
+		policy:          protos.ConflictPolicy_CONFLICT_POLICY_DESTINATION_WINS,
+		timestampColumn: "updated_at",
+	}
+	stmts := normalizeGen.generateNormalizeStatements("public.t")
+	condition := `NOT coalesce("public"."t"."updated_at">"public"."t"."_peerdb_synced_at",FALSE)`
+	if !strings.HasSuffix(stmts[0], " WHERE "+condition) || !strings.HasSuffix(stmts[1], " AND "+condition+
+		" AND src_rank._peerdb_rank=1 AND src_rank._peerdb_record_type=2") {
+		t.Errorf("Expected changes to rows modified out-of-band to be skipped, got: %v", stmts)
+	}
+
+	normalizeGen.conflictPolicies["public.t"] = conflictPolicy{
+		policy:          protos.ConflictPolicy_CONFLICT_POLICY_SOURCE_WINS,
+		timestampColumn: "updated_at",
+	}
+	if stmts := normalizeGen.generateNormalizeStatements("public.t"); strings.Contains(strings.Join(stmts, ";"), "coalesce") {
+		t.Errorf("Expected source to win conflicts, got: %v", stmts)
+	}
+	if normalizeGen.generateConflictStatement("public.t") == "" {
+		t.Error("Expected conflicts to still be detected when source wins")
+	}
+}
@@ -0,0 +1,50 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+)
+
+// GetLeafPartitions walks the partition tree of partitioned tables down to the partitions that store rows,
+// sub-partitions are partitioned tables themselves and are skipped
+func (c *PostgresConnector) GetLeafPartitions(ctx context.Context, tables []string) (map[string][]string, error) {
+	quotedTables := make([]string, 0, len(tables))
+	tableForQuoted := make(map[string]string, len(tables))
+	for _, table := range tables {
+		schemaTable, err := utils.ParseSchemaTable(table)
+		if err != nil {
+			return nil, err
+		}
+		quotedTables = append(quotedTables, schemaTable.String())
+		tableForQuoted[schemaTable.String()] = table
+	}
+
+	rows, err := c.conn.Query(ctx, `WITH RECURSIVE tree AS (
+		SELECT t.name, i.inhrelid AS relid FROM unnest($1::text[]) AS t(name)
+		JOIN pg_class root ON root.oid=to_regclass(t.name)
+		JOIN pg_inherits i ON i.inhparent=root.oid
+		WHERE root.relkind='p'
+		UNION ALL
+		SELECT tree.name, i.inhrelid FROM tree JOIN pg_inherits i ON i.inhparent=tree.relid
+	) SELECT tree.name, n.nspname, c.relname FROM tree
+	JOIN pg_class c ON c.oid=tree.relid JOIN pg_namespace n ON n.oid=c.relnamespace
+	WHERE c.relkind IN ('r', 'f') ORDER BY tree.name, n.nspname, c.relname`, quotedTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query partitions: %w", err)
+	}
+
+	leaves := make(map[string][]string)
+	var quotedTable, schemaName, partitionName string
+	if _, err := pgx.ForEachRow(rows, []any{&quotedTable, &schemaName, &partitionName}, func() error {
+		table := tableForQuoted[quotedTable]
+		leaves[table] = append(leaves[table], schemaName+"."+partitionName)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+	return leaves, nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -29,21 +30,26 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 )
 
 type ReplState struct {
 	Slot        string
 	Publication string
-	Offset      int64
-	LastOffset  atomic.Int64
+	// prepared transactions not yet committed, held by the pulling CDC source
+	twoPhase   twoPhaseStates
+	Offset     int64
+	LastOffset atomic.Int64
 }
 
 type PostgresConnector struct {
 	logger                 log.Logger
 	customTypeMapping      map[uint32]shared.CustomDataType
+	compositeTypeMap       *pgtype.Map
 	ssh                    utils.SSHTunnel
 	conn                   *pgx.Conn
+	connConfig             *pgx.ConnConfig
 	replConn               *pgx.Conn
 	replState              *ReplState
 	Config                 *protos.PostgresConfig
@@ -111,6 +117,7 @@ func NewPostgresConnector(ctx context.Context, env map[string]string, pgConfig *
 		Config:                 pgConfig,
 		ssh:                    tunnel,
 		conn:                   conn,
+		connConfig:             connConfig,
 		replConn:               nil,
 		replState:              nil,
 		customTypeMapping:      nil,
@@ -130,8 +137,10 @@ func ParseConfig(connectionString string, pgConfig *protos.PostgresConfig) (*pgx
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
-	if pgConfig.RequireTls || pgConfig.RootCa != nil {
-		tlsConfig, err := shared.CreateTlsConfig(tls.VersionTLS12, pgConfig.RootCa, connConfig.Host, pgConfig.TlsHost, false)
+	if pgConfig.RequireTls || pgConfig.RootCa != nil || pgConfig.Tls != nil {
+		tlsConfig, err := utils.PeerTlsConfig(
+			pgConfig.Tls, tls.VersionTLS12, pgConfig.RootCa, connConfig.Host, pgConfig.TlsHost, false,
+		)
 		if err != nil {
 			return nil, err
 		}
@@ -147,6 +156,7 @@ func (c *PostgresConnector) fetchCustomTypeMapping(ctx context.Context) (map[uin
 			return nil, err
 		}
 		c.customTypeMapping = customTypeMapping
+		c.compositeTypeMap = shared.NewCompositeTypeMap(customTypeMapping)
 	}
 	return c.customTypeMapping, nil
 }
@@ -208,6 +218,7 @@ func (c *PostgresConnector) MaybeStartReplication(
 	publicationName string,
 	lastOffset int64,
 	pgVersion shared.PGVersion,
+	twoPhase bool,
 ) error {
 	if c.replState != nil && (c.replState.Offset != lastOffset ||
 		c.replState.Slot != slotName ||
@@ -220,7 +231,7 @@ func (c *PostgresConnector) MaybeStartReplication(
 	}
 
 	if c.replState == nil {
-		replicationOpts, err := c.replicationOptions(publicationName, pgVersion)
+		replicationOpts, err := c.replicationOptions(publicationName, pgVersion, twoPhase)
 		if err != nil {
 			return fmt.Errorf("error getting replication options: %w", err)
 		}
@@ -250,9 +261,15 @@ func (c *PostgresConnector) MaybeStartReplication(
 	return nil
 }
 
-func (c *PostgresConnector) replicationOptions(publicationName string, pgVersion shared.PGVersion,
+func (c *PostgresConnector) replicationOptions(publicationName string, pgVersion shared.PGVersion, twoPhase bool,
 ) (pglogrepl.StartReplicationOptions, error) {
-	pluginArguments := append(make([]string, 0, 3), "proto_version '1'")
+	pluginArguments := make([]string, 0, 4)
+	// pgoutput needs protocol version 3 to send prepared transactions
+	if twoPhase && pgVersion >= shared.POSTGRES_15 {
+		pluginArguments = append(pluginArguments, "proto_version '3'", "two_phase 'true'")
+	} else {
+		pluginArguments = append(pluginArguments, "proto_version '1'")
+	}
 
 	if publicationName != "" {
 		pubOpt := "publication_names " + utils.QuoteLiteral(publicationName)
@@ -281,6 +298,9 @@ func (c *PostgresConnector) Close() error {
 			defer cancel()
 			replerr = c.replConn.Close(timeout)
 		}
+		if c.replState != nil {
+			replerr = errors.Join(replerr, c.replState.twoPhase.Close())
+		}
 
 		c.ssh.Close()
 	}
@@ -300,6 +320,21 @@ func (c *PostgresConnector) ConnectionActive(ctx context.Context) error {
 	return pingErr
 }
 
+// reconnectIfClosed replaces the connection once the server or network closed it,
+// authenticating again so short-lived credentials like IAM tokens get refreshed for long-lived connectors
+func (c *PostgresConnector) reconnectIfClosed(ctx context.Context) error {
+	if !c.conn.IsClosed() {
+		return nil
+	}
+	c.logger.Warn("connection closed, reconnecting")
+	conn, err := NewPostgresConnFromConfig(ctx, c.connConfig, c.Config.TlsHost, c.rdsAuth, c.ssh)
+	if err != nil {
+		return fmt.Errorf("failed to reconnect: %w", err)
+	}
+	c.conn = conn
+	return nil
+}
+
 // NeedsSetupMetadataTables returns true if the metadata tables need to be set up.
 func (c *PostgresConnector) NeedsSetupMetadataTables(ctx context.Context) (bool, error) {
 	result, err := c.tableExists(ctx, &utils.SchemaTable{
@@ -400,6 +435,11 @@ func pullCore[Items model.Items](
 		publicationName = req.OverridePublicationName
 	}
 
+	// the connector lives across batches of a sync flow, which may outlast the connection
+	if err := c.reconnectIfClosed(ctx); err != nil {
+		return err
+	}
+
 	// Check if the replication slot and publication exist
 	exists, err := c.checkSlotAndPublication(ctx, slotName, publicationName)
 	if err != nil {
@@ -425,7 +465,12 @@ func pullCore[Items model.Items](
 	if err != nil {
 		return err
 	}
-	if err := c.MaybeStartReplication(ctx, slotName, publicationName, req.LastOffset.ID, pgVersion); err != nil {
+	// a slot created with two_phase cannot have it disabled, so decode the way the slot was created
+	twoPhase, err := c.slotTwoPhase(ctx, slotName, pgVersion)
+	if err != nil {
+		return err
+	}
+	if err := c.MaybeStartReplication(ctx, slotName, publicationName, req.LastOffset.ID, pgVersion, twoPhase); err != nil {
 		// in case of Aurora error ERROR: replication slots cannot be used on RO (Read Only) node (SQLSTATE 55000)
 		if shared.IsSQLStateError(err, pgerrcode.ObjectNotInPrerequisiteState) &&
 			strings.Contains(err.Error(), "replication slots cannot be used on RO (Read Only) node") {
@@ -443,11 +488,16 @@ func pullCore[Items model.Items](
 		return fmt.Errorf("failed to get get setting for sourceSchemaAsDestinationColumn: %w", err)
 	}
 
+	tableNameMapping, err := c.excludeGeneratedColumns(ctx, req.TableNameMapping)
+	if err != nil {
+		return fmt.Errorf("failed to get generated columns: %w", err)
+	}
+
 	cdc, err := c.NewPostgresCDCSource(ctx, &PostgresCDCConfig{
 		CatalogPool:                              catalogPool,
 		OtelManager:                              otelManager,
 		SrcTableIDNameMapping:                    req.SrcTableIDNameMapping,
-		TableNameMapping:                         req.TableNameMapping,
+		TableNameMapping:                         tableNameMapping,
 		TableNameSchemaMapping:                   req.TableNameSchemaMapping,
 		RelationMessageMapping:                   c.relationMessageMapping,
 		FlowJobName:                              req.FlowJobName,
@@ -455,6 +505,7 @@ func pullCore[Items model.Items](
 		Publication:                              publicationName,
 		HandleInheritanceForNonPartitionedTables: handleInheritanceForNonPartitionedTables,
 		SourceSchemaAsDestinationColumn:          sourceSchemaAsDestinationColumn,
+		PropagateDroppedColumns:                  req.PropagateDroppedColumns,
 		InternalVersion:                          req.InternalVersion,
 	})
 	if err != nil {
@@ -510,7 +561,7 @@ func syncRecordsCore[Items model.Items](
 			var row []any
 			switch typedRecord := record.(type) {
 			case *model.InsertRecord[Items]:
-				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(model.ToJSONOptions{
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(ctx, model.ToJSONOptions{
 					UnnestColumns: nil,
 					HStoreAsJSON:  false,
 				})
@@ -530,14 +581,14 @@ func syncRecordsCore[Items model.Items](
 				}
 
 			case *model.UpdateRecord[Items]:
-				newItemsJSON, err := typedRecord.NewItems.ToJSONWithOptions(model.ToJSONOptions{
+				newItemsJSON, err := typedRecord.NewItems.ToJSONWithOptions(ctx, model.ToJSONOptions{
 					UnnestColumns: nil,
 					HStoreAsJSON:  false,
 				})
 				if err != nil {
 					return nil, fmt.Errorf("failed to serialize update record new items to JSON: %w", err)
 				}
-				oldItemsJSON, err := typedRecord.OldItems.ToJSONWithOptions(model.ToJSONOptions{
+				oldItemsJSON, err := typedRecord.OldItems.ToJSONWithOptions(ctx, model.ToJSONOptions{
 					UnnestColumns: nil,
 					HStoreAsJSON:  false,
 				})
@@ -557,7 +608,7 @@ func syncRecordsCore[Items model.Items](
 				}
 
 			case *model.DeleteRecord[Items]:
-				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(model.ToJSONOptions{
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(ctx, model.ToJSONOptions{
 					UnnestColumns: nil,
 					HStoreAsJSON:  false,
 				})
@@ -689,6 +740,10 @@ func (c *PostgresConnector) NormalizeRecords(
 	if err != nil {
 		return model.NormalizeResponse{}, err
 	}
+	normalizeMode, err := internal.PeerDBPostgresNormalizeMode(ctx, req.Env)
+	if err != nil {
+		return model.NormalizeResponse{}, err
+	}
 	totalRowsAffected := 0
 	normalizeStmtGen := normalizeStmtGenerator{
 		Logger:                   c.logger,
@@ -699,11 +754,26 @@ func (c *PostgresConnector) NormalizeRecords(
 			SoftDeleteColName: req.SoftDeleteColName,
 			SyncedAtColName:   req.SyncedAtColName,
 		},
-		supportsMerge:  pgversion >= shared.POSTGRES_15,
-		metadataSchema: c.metadataSchema,
+		supportsMerge:    pgversion >= shared.POSTGRES_15,
+		upsert:           normalizeMode == "upsert",
+		metadataSchema:   c.metadataSchema,
+		conflictPolicies: conflictPoliciesFromMappings(req.TableMappings),
 	}
 
+	var conflicts []model.NormalizeConflict
+	var conflictCount int64
 	for _, destinationTableName := range destinationTableNames {
+		tableConflicts, tableConflictCount, err := normalizeStmtGen.detectConflicts(
+			ctx, normalizeRecordsTx, destinationTableName, normBatchID, req.SyncBatchID)
+		if err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		if tableConflictCount > 0 {
+			c.logger.Warn("conflicts with rows modified out-of-band",
+				slog.String("destinationTableName", destinationTableName), slog.Int64("conflicts", tableConflictCount))
+			conflicts = append(conflicts, tableConflicts...)
+			conflictCount += tableConflictCount
+		}
 		normalizeStatements := normalizeStmtGen.generateNormalizeStatements(destinationTableName)
 		for _, normalizeStatement := range normalizeStatements {
 			ct, err := normalizeRecordsTx.Exec(ctx, normalizeStatement, normBatchID, req.SyncBatchID, destinationTableName)
@@ -732,8 +802,10 @@ func (c *PostgresConnector) NormalizeRecords(
 	}
 
 	return model.NormalizeResponse{
-		StartBatchID: normBatchID + 1,
-		EndBatchID:   req.SyncBatchID,
+		Conflicts:     conflicts,
+		StartBatchID:  normBatchID + 1,
+		EndBatchID:    req.SyncBatchID,
+		ConflictCount: conflictCount,
 	}, nil
 }
 
@@ -822,7 +894,8 @@ func (c *PostgresConnector) GetSelectedColumns(
 		WHERE n.nspname = $1
 		AND c.relname = $2
 		AND a.attnum > 0
-		AND NOT a.attisdropped ` + excludedColumnsSQL
+		AND NOT a.attisdropped ` + excludedColumnsSQL + `
+		ORDER BY a.attnum`
 
 	rows, err := c.conn.Query(ctx, getColumnsSQL, sourceTable.Schema, sourceTable.Table)
 	if err != nil {
@@ -887,9 +960,35 @@ func (c *PostgresConnector) getTableSchemaForTable(
 		return nil, err
 	}
 
+	// skipped columns are excluded, regenerated ones are kept with what the destination needs to generate them
+	excluded := tm.Exclude
+	var regenerated map[string]generatedColumn
+	if hasGeneratedColumnPolicy(tm.GeneratedColumns, tm.IdentityColumns) {
+		generatedCols, err := c.getGeneratedColumns(ctx, relID)
+		if err != nil {
+			return nil, err
+		}
+		for name, col := range generatedCols {
+			policy := col.policy(tm.GeneratedColumns, tm.IdentityColumns)
+			if policy == protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REPLICATE {
+				continue
+			} else if slices.Contains(pKeyCols, name) {
+				return nil, fmt.Errorf("column %s of table %s is part of the primary key, it cannot be skipped or regenerated",
+					name, schemaTable)
+			} else if policy == protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_SKIP {
+				excluded = append(slices.Clip(excluded), name)
+			} else {
+				if regenerated == nil {
+					regenerated = make(map[string]generatedColumn)
+				}
+				regenerated[name] = col
+			}
+		}
+	}
+
 	selectedColumnsStr := "*"
-	if len(tm.Exclude) > 0 {
-		selectedColumns, err := c.GetSelectedColumns(ctx, schemaTable, tm.Exclude)
+	if len(excluded) > 0 {
+		selectedColumns, err := c.GetSelectedColumns(ctx, schemaTable, excluded)
 		if err != nil {
 			return nil, err
 		}
@@ -932,12 +1031,17 @@ func (c *PostgresConnector) getTableSchemaForTable(
 
 		columnNames = append(columnNames, fieldDescription.Name)
 		_, nullable := nullableCols[fieldDescription.Name]
-		columns = append(columns, &protos.FieldDescription{
+		column := &protos.FieldDescription{
 			Name:         fieldDescription.Name,
 			Type:         colType,
 			TypeModifier: fieldDescription.TypeModifier,
 			Nullable:     nullable,
-		})
+		}
+		if col, ok := regenerated[fieldDescription.Name]; ok {
+			column.GenerationExpression = col.expression
+			column.Identity = col.identity
+		}
+		columns = append(columns, column)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -1035,9 +1139,13 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 	defer shared.RollbackTx(tableSchemaModifyTx, c.logger)
 
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil || (len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.WidenedColumns) == 0) {
 			continue
 		}
+		dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
+		if err != nil {
+			return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
+		}
 
 		for _, addedColumn := range schemaDelta.AddedColumns {
 			columnType := addedColumn.Type
@@ -1045,11 +1153,6 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 				columnType = qValueKindToPostgresType(columnType)
 			}
 
-			dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
-			if err != nil {
-				return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
-			}
-
 			_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(
 				"ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS %s %s",
 				utils.QuoteIdentifier(dstSchemaTable.Schema),
@@ -1065,6 +1168,32 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 				slog.String("dstTableName", schemaDelta.DstTableName),
 			)
 		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			columnType := widenedColumn.Current.Type
+			if schemaDelta.System == protos.TypeSystem_Q {
+				columnType = qValueKindToPostgresType(columnType)
+			}
+			if widenedColumn.Current.Type == "numeric" && widenedColumn.Current.TypeModifier != -1 {
+				precision, scale := datatypes.ParseNumericTypmod(widenedColumn.Current.TypeModifier)
+				columnType = fmt.Sprintf("numeric(%d,%d)", precision, scale)
+			}
+
+			if _, err := c.execWithLoggingTx(ctx, fmt.Sprintf(
+				"ALTER TABLE %s.%s ALTER COLUMN %s TYPE %s",
+				utils.QuoteIdentifier(dstSchemaTable.Schema),
+				utils.QuoteIdentifier(dstSchemaTable.Table),
+				utils.QuoteIdentifier(widenedColumn.Current.Name), columnType), tableSchemaModifyTx,
+			); err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Current.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s from data type %s to %s",
+				widenedColumn.Current.Name, widenedColumn.Previous.Type, columnType),
+				slog.String("srcTableName", schemaDelta.SrcTableName),
+				slog.String("dstTableName", schemaDelta.DstTableName),
+			)
+		}
 	}
 
 	if err := tableSchemaModifyTx.Commit(ctx); err != nil {
@@ -1217,8 +1346,14 @@ func (c *PostgresConnector) SetupReplication(
 			Exclude: make(map[string]struct{}, 0),
 		}
 	}
+	twoPhase, err := internal.PeerDBPostgresCDCTwoPhase(ctx, req.Env)
+	if err != nil {
+		return model.SetupReplicationResult{}, err
+	}
+
 	// Create the replication slot and publication
-	return c.createSlotAndPublication(ctx, exists, slotName, publicationName, tableNameMapping, req.DoInitialSnapshot, skipSnapshotExport)
+	return c.createSlotAndPublication(ctx, exists, slotName, publicationName, tableNameMapping,
+		req.DoInitialSnapshot, skipSnapshotExport, twoPhase)
 }
 
 func (c *PostgresConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
@@ -1592,6 +1727,14 @@ func (c *PostgresConnector) RenameTables(
 		// renaming and dropping such that the _resync table is the new destination
 		c.logger.Info(fmt.Sprintf("renaming table '%s' to '%s'...", src, dst))
 
+		// foreign keys of migrated constraints referencing the old table keep it from being dropped,
+		// the resynced tables have their own
+		if originalTableExists {
+			if err := c.dropForeignKeysReferencing(ctx, renameTablesTx, dst); err != nil {
+				return nil, err
+			}
+		}
+
 		// drop the dst table if exists
 		if _, err := c.execWithLoggingTx(ctx, "DROP TABLE IF EXISTS "+dst, renameTablesTx); err != nil {
 			return nil, fmt.Errorf("unable to drop table %s: %w", dst, err)
@@ -0,0 +1,36 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// ProbeThroughput times reading and writing size bytes, writes go to a temporary table dropped right after
+func (c *PostgresConnector) ProbeThroughput(ctx context.Context, size int) (time.Duration, time.Duration, error) {
+	start := time.Now()
+	var data string
+	if err := c.conn.QueryRow(ctx, "SELECT repeat('x', $1)", size).Scan(&data); err != nil {
+		return 0, 0, fmt.Errorf("failed to read probe data: %w", err)
+	}
+	readDuration := time.Since(start)
+
+	table := "peerdb_probe_" + strings.ToLower(shared.RandomString(4))
+	if _, err := c.conn.Exec(ctx, "CREATE TEMPORARY TABLE "+table+"(data text)"); err != nil {
+		return readDuration, 0, fmt.Errorf("failed to create probe table: %w", err)
+	}
+	defer func() {
+		if _, err := c.conn.Exec(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			c.logger.Warn("failed to drop probe table", slog.String("table", table), slog.Any("error", err))
+		}
+	}()
+	start = time.Now()
+	if _, err := c.conn.Exec(ctx, "INSERT INTO "+table+" VALUES ($1)", data); err != nil {
+		return readDuration, 0, fmt.Errorf("failed to write probe data: %w", err)
+	}
+	return readDuration, time.Since(start), nil
+}
@@ -86,6 +86,14 @@ func (qe *QRepQueryExecutor) fieldDescriptionsToSchema(fds []pgconn.FieldDescrip
 				Precision: precision,
 				Scale:     scale,
 			}
+		} else if ctype == types.QValueKindArrayFloat32 {
+			// real[] has no typmod, leaving pgvector columns with their dimensions
+			qfields[i] = types.QField{
+				Name:       fd.Name,
+				Type:       ctype,
+				Nullable:   true,
+				Dimensions: datatypes.ParseVectorTypmod(fd.TypeModifier),
+			}
 		} else {
 			qfields[i] = types.QField{
 				Name:     fd.Name,
@@ -482,6 +482,11 @@ func (c *PostgresConnector) parseFieldFromPostgresOID(
 		boolVal := value.(bool)
 		return types.QValueBoolean{Val: boolVal}, nil
 	case types.QValueKindJSON, types.QValueKindJSONB:
+		if str, ok := value.(string); ok {
+			if typeData, ok := customTypeMapping[oid]; ok && typeData.Type == 'c' {
+				return c.parseComposite(oid, pgtype.TextFormatCode, []byte(str))
+			}
+		}
 		tmp, err := parseJSON(value, false)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse JSON: %w", err)
@@ -2,12 +2,14 @@ package connpostgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"net"
 	"time"
 
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.temporal.io/sdk/log"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -22,13 +24,7 @@ func NewPostgresConnFromConfig(
 	tunnel utils.SSHTunnel,
 ) (*pgx.Conn, error) {
 	if tunnel.Client != nil {
-		connConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
-			conn, err := tunnel.Client.DialContext(ctx, network, addr)
-			if err != nil {
-				return nil, err
-			}
-			return &noDeadlineConn{Conn: conn}, nil
-		}
+		connConfig.DialFunc = tunnel.DialContext
 		// DNS lookup seems to happen before connection is established which can be an issue if given host
 		// can only be resolved on the SSH host https://github.com/jackc/pgx/issues/1724
 		connConfig.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
@@ -36,24 +32,12 @@ func NewPostgresConnFromConfig(
 		}
 	}
 	logger := internal.LoggerFromCtx(ctx)
-	if rdsAuth != nil {
-		host := connConfig.Host
-		if tlsHost != "" {
-			host = tlsHost
-		}
-		logger.Info("Setting up IAM auth for Postgres")
-		token, err := utils.GetRDSToken(ctx, utils.RDSConnectionConfig{
-			Host: host,
-			Port: uint32(connConfig.Port),
-			User: connConfig.User,
-		}, rdsAuth, "POSTGRES")
-		if err != nil {
-			return nil, err
-		}
-		connConfig = connConfig.Copy()
-		connConfig.Password = token
+	conn, err := connectWithRDSAuth(ctx, connConfig, tlsHost, rdsAuth)
+	if err != nil && rdsAuth != nil && isAuthError(err) {
+		logger.Warn("IAM auth token rejected, retrying with a new token", slog.Any("error", err))
+		rdsAuth.Invalidate()
+		conn, err = connectWithRDSAuth(ctx, connConfig, tlsHost, rdsAuth)
 	}
-	conn, err := pgx.ConnectConfig(ctx, connConfig)
 	if err != nil {
 		logger.Error("Failed to create pool", slog.Any("error", err))
 		return nil, err
@@ -75,6 +59,34 @@ func NewPostgresConnFromConfig(
 	return conn, nil
 }
 
+func connectWithRDSAuth(ctx context.Context, connConfig *pgx.ConnConfig, tlsHost string, rdsAuth *utils.RDSAuth) (*pgx.Conn, error) {
+	if rdsAuth != nil {
+		host := connConfig.Host
+		if tlsHost != "" {
+			host = tlsHost
+		}
+		internal.LoggerFromCtx(ctx).Info("Setting up IAM auth for Postgres")
+		token, err := utils.GetRDSToken(ctx, utils.RDSConnectionConfig{
+			Host: host,
+			Port: uint32(connConfig.Port),
+			User: connConfig.User,
+		}, rdsAuth, "POSTGRES")
+		if err != nil {
+			return nil, err
+		}
+		connConfig = connConfig.Copy()
+		connConfig.Password = token
+	}
+	return pgx.ConnectConfig(ctx, connConfig)
+}
+
+// isAuthError reports whether err is the server rejecting credentials, which for IAM auth means an expired token
+func isAuthError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		(pgErr.Code == pgerrcode.InvalidPassword || pgErr.Code == pgerrcode.InvalidAuthorizationSpecification)
+}
+
 type retryFunc func() error
 
 func retryWithBackoff(logger log.Logger, fn retryFunc, maxRetries int, backoff time.Duration) error {
@@ -93,10 +105,3 @@ func retryWithBackoff(logger log.Logger, fn retryFunc, maxRetries int, backoff t
 		}
 	}
 }
-
-// see: https://github.com/jackc/pgx/issues/382#issuecomment-1496586216
-type noDeadlineConn struct{ net.Conn }
-
-func (c *noDeadlineConn) SetDeadline(t time.Time) error      { return nil }
-func (c *noDeadlineConn) SetReadDeadline(t time.Time) error  { return nil }
-func (c *noDeadlineConn) SetWriteDeadline(t time.Time) error { return nil }
@@ -0,0 +1,335 @@
+package connpostgres
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// pgoutput sends these instead of Begin/Commit for prepared transactions when the slot was created with two_phase,
+// pglogrepl does not parse them
+const (
+	messageTypeBeginPrepare     pglogrepl.MessageType = 'b'
+	messageTypePrepare          pglogrepl.MessageType = 'P'
+	messageTypeCommitPrepared   pglogrepl.MessageType = 'K'
+	messageTypeRollbackPrepared pglogrepl.MessageType = 'r'
+)
+
+type beginPrepareMessage struct {
+	GID        string
+	PrepareLSN pglogrepl.LSN
+	EndLSN     pglogrepl.LSN
+	Xid        uint32
+}
+
+func (*beginPrepareMessage) Type() pglogrepl.MessageType { return messageTypeBeginPrepare }
+
+type prepareMessage struct {
+	GID        string
+	PrepareLSN pglogrepl.LSN
+	EndLSN     pglogrepl.LSN
+	Xid        uint32
+}
+
+func (*prepareMessage) Type() pglogrepl.MessageType { return messageTypePrepare }
+
+type commitPreparedMessage struct {
+	CommitTime time.Time
+	GID        string
+	CommitLSN  pglogrepl.LSN
+	EndLSN     pglogrepl.LSN
+	Xid        uint32
+}
+
+func (*commitPreparedMessage) Type() pglogrepl.MessageType { return messageTypeCommitPrepared }
+
+type rollbackPreparedMessage struct {
+	GID         string
+	PreparedLSN pglogrepl.LSN
+	EndLSN      pglogrepl.LSN
+	Xid         uint32
+}
+
+func (*rollbackPreparedMessage) Type() pglogrepl.MessageType { return messageTypeRollbackPrepared }
+
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// twoPhaseDecoder reads fields of a two phase message in wire order
+type twoPhaseDecoder struct {
+	src []byte
+	err error
+}
+
+func (d *twoPhaseDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	} else if len(d.src) < n {
+		d.err = errors.New("message too short")
+		return nil
+	}
+	b := d.src[:n]
+	d.src = d.src[n:]
+	return b
+}
+
+func (d *twoPhaseDecoder) uint8() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *twoPhaseDecoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *twoPhaseDecoder) lsn() pglogrepl.LSN {
+	if b := d.next(8); b != nil {
+		return pglogrepl.LSN(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *twoPhaseDecoder) time() time.Time {
+	if b := d.next(8); b != nil {
+		return pgEpoch.Add(time.Duration(int64(binary.BigEndian.Uint64(b))) * time.Microsecond)
+	}
+	return time.Time{}
+}
+
+func (d *twoPhaseDecoder) string() string {
+	if d.err != nil {
+		return ""
+	}
+	for i, b := range d.src {
+		if b == 0 {
+			s := string(d.src[:i])
+			d.src = d.src[i+1:]
+			return s
+		}
+	}
+	d.err = errors.New("unterminated string")
+	return ""
+}
+
+// parseTwoPhaseMessage parses messages of prepared transactions, returning nil for every other message type
+func parseTwoPhaseMessage(data []byte) (pglogrepl.Message, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	d := &twoPhaseDecoder{src: data[1:]}
+	var msg pglogrepl.Message
+	switch pglogrepl.MessageType(data[0]) {
+	case messageTypeBeginPrepare:
+		m := &beginPrepareMessage{}
+		m.PrepareLSN = d.lsn()
+		m.EndLSN = d.lsn()
+		d.time()
+		m.Xid = d.uint32()
+		m.GID = d.string()
+		msg = m
+	case messageTypePrepare:
+		m := &prepareMessage{}
+		d.uint8()
+		m.PrepareLSN = d.lsn()
+		m.EndLSN = d.lsn()
+		d.time()
+		m.Xid = d.uint32()
+		m.GID = d.string()
+		msg = m
+	case messageTypeCommitPrepared:
+		m := &commitPreparedMessage{}
+		d.uint8()
+		m.CommitLSN = d.lsn()
+		m.EndLSN = d.lsn()
+		m.CommitTime = d.time()
+		m.Xid = d.uint32()
+		m.GID = d.string()
+		msg = m
+	case messageTypeRollbackPrepared:
+		m := &rollbackPreparedMessage{}
+		d.uint8()
+		m.PreparedLSN = d.lsn()
+		m.EndLSN = d.lsn()
+		d.time()
+		d.time()
+		m.Xid = d.uint32()
+		m.GID = d.string()
+		msg = m
+	default:
+		return nil, nil
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("error parsing %c message: %w", data[0], d.err)
+	}
+	return msg, nil
+}
+
+// preparedTransaction tracks a transaction decoded at PREPARE TRANSACTION, its records are only passed on
+// once COMMIT PREPARED is decoded, and dropped on ROLLBACK PREPARED
+type preparedTransaction struct {
+	gid        string
+	prepareLSN pglogrepl.LSN
+	numRecords int
+}
+
+func (txn *preparedTransaction) recordKey(idx int) model.TableWithPkey {
+	// GIDs are unique among pending prepared transactions, and never a table name the store sees elsewhere
+	key := model.TableWithPkey{TableName: "prepared:" + txn.gid}
+	binary.BigEndian.PutUint64(key.PkeyColVal[:], uint64(idx))
+	return key
+}
+
+// preparedRecordStore is the CDC store from utils.NewCDCStore,
+// so records of large prepared transactions spill to disk past the same thresholds as a batch
+type preparedRecordStore[Items model.Items] interface {
+	Set(log.Logger, model.TableWithPkey, model.Record[Items]) error
+	Get(model.TableWithPkey) (model.Record[Items], bool, error)
+	Delete(model.TableWithPkey) error
+	Len() int
+	Close() error
+}
+
+// twoPhaseState outlives a single pull since prepared transactions may be resolved in a later batch,
+// it lives as long as the replication connection, which is where postgres resumes streaming from
+type twoPhaseState[Items model.Items] struct {
+	logger    log.Logger
+	records   preparedRecordStore[Items]
+	decoding  *preparedTransaction
+	prepared  map[string]*preparedTransaction
+	committed []*preparedTransaction
+}
+
+// twoPhaseStates holds the state of whichever record type the CDC source is pulled with
+type twoPhaseStates struct {
+	recordItems *twoPhaseState[model.RecordItems]
+	pgItems     *twoPhaseState[model.PgItems]
+}
+
+func (s *twoPhaseStates) Close() error {
+	var recordItemsErr, pgItemsErr error
+	if s.recordItems != nil {
+		recordItemsErr = s.recordItems.records.Close()
+	}
+	if s.pgItems != nil {
+		pgItemsErr = s.pgItems.records.Close()
+	}
+	return errors.Join(recordItemsErr, pgItemsErr)
+}
+
+func twoPhaseStateOf[Items model.Items](states *twoPhaseStates) **twoPhaseState[Items] {
+	var state any
+	switch any(*new(Items)).(type) {
+	case model.RecordItems:
+		state = &states.recordItems
+	case model.PgItems:
+		state = &states.pgItems
+	}
+	return state.(**twoPhaseState[Items])
+}
+
+func newTwoPhaseState[Items model.Items](ctx context.Context, env map[string]string, flowJobName string,
+) (*twoPhaseState[Items], error) {
+	records, err := utils.NewCDCStore[Items](ctx, env, flowJobName+"_prepared")
+	if err != nil {
+		return nil, err
+	}
+	return &twoPhaseState[Items]{
+		logger:   internal.LoggerFromCtx(ctx),
+		records:  records,
+		prepared: make(map[string]*preparedTransaction),
+	}, nil
+}
+
+// getTwoPhaseState returns the state kept with the replication connection,
+// or one for this pull only if replication has not started
+func getTwoPhaseState[Items model.Items](ctx context.Context, env map[string]string, p *PostgresCDCSource,
+) (*twoPhaseState[Items], error) {
+	if p.replState == nil {
+		return newTwoPhaseState[Items](ctx, env, p.flowJobName)
+	}
+	state := twoPhaseStateOf[Items](&p.replState.twoPhase)
+	if *state == nil {
+		var err error
+		if *state, err = newTwoPhaseState[Items](ctx, env, p.flowJobName); err != nil {
+			return nil, err
+		}
+	}
+	return *state, nil
+}
+
+// capLSN keeps acknowledged positions before every pending prepared transaction,
+// so postgres streams them again if replication restarts before they are committed
+func (s *twoPhaseState[Items]) capLSN(lsn pglogrepl.LSN) pglogrepl.LSN {
+	if s.decoding != nil {
+		lsn = min(lsn, s.decoding.prepareLSN-1)
+	}
+	for _, txn := range s.prepared {
+		lsn = min(lsn, txn.prepareLSN-1)
+	}
+	return lsn
+}
+
+// hold keeps records decoded between BEGIN PREPARE and PREPARE until the transaction is committed
+func (s *twoPhaseState[Items]) hold(rec model.Record[Items], err error) (model.Record[Items], error) {
+	if err != nil || rec == nil || s.decoding == nil {
+		return rec, err
+	}
+	if err := s.records.Set(s.logger, s.decoding.recordKey(s.decoding.numRecords), rec); err != nil {
+		return nil, fmt.Errorf("failed to hold record of prepared transaction %s: %w", s.decoding.gid, err)
+	}
+	s.decoding.numRecords += 1
+	return nil, nil
+}
+
+// rollback drops the records of a prepared transaction
+func (s *twoPhaseState[Items]) rollback(gid string) error {
+	txn, ok := s.prepared[gid]
+	if !ok {
+		return nil
+	}
+	delete(s.prepared, gid)
+	for idx := range txn.numRecords {
+		if err := s.records.Delete(txn.recordKey(idx)); err != nil {
+			return fmt.Errorf("failed to drop record of prepared transaction %s: %w", gid, err)
+		}
+	}
+	return nil
+}
+
+// takeCommitted passes on the records of prepared transactions committed since the last call, in commit order
+func (s *twoPhaseState[Items]) takeCommitted(handle func(model.Record[Items]) error) error {
+	for len(s.committed) > 0 {
+		txn := s.committed[0]
+		for idx := range txn.numRecords {
+			key := txn.recordKey(idx)
+			rec, ok, err := s.records.Get(key)
+			if err != nil {
+				return fmt.Errorf("failed to read record of prepared transaction %s: %w", txn.gid, err)
+			} else if !ok {
+				return fmt.Errorf("record %d of prepared transaction %s is missing", idx, txn.gid)
+			}
+			if err := handle(rec); err != nil {
+				return err
+			}
+			if err := s.records.Delete(key); err != nil {
+				return fmt.Errorf("failed to drop record of prepared transaction %s: %w", txn.gid, err)
+			}
+		}
+		s.committed = s.committed[1:]
+	}
+	s.committed = nil
+	return nil
+}
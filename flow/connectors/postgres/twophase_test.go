@@ -0,0 +1,125 @@
+package connpostgres
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestParseTwoPhaseMessage(t *testing.T) {
+	data := []byte{byte(messageTypeCommitPrepared), 0}
+	data = binary.BigEndian.AppendUint64(data, 100)
+	data = binary.BigEndian.AppendUint64(data, 120)
+	data = binary.BigEndian.AppendUint64(data, 0)
+	data = binary.BigEndian.AppendUint32(data, 42)
+	data = append(data, "txn1\x00"...)
+
+	msg, err := parseTwoPhaseMessage(data)
+	require.NoError(t, err)
+	commit, ok := msg.(*commitPreparedMessage)
+	require.True(t, ok)
+	require.Equal(t, pglogrepl.LSN(100), commit.CommitLSN)
+	require.Equal(t, pglogrepl.LSN(120), commit.EndLSN)
+	require.Equal(t, uint32(42), commit.Xid)
+	require.Equal(t, "txn1", commit.GID)
+	require.True(t, commit.CommitTime.Equal(pgEpoch))
+
+	_, err = parseTwoPhaseMessage(data[:len(data)-1])
+	require.Error(t, err)
+
+	msg, err = parseTwoPhaseMessage([]byte{'B'})
+	require.NoError(t, err)
+	require.Nil(t, msg)
+}
+
+func newTestTwoPhaseState(t *testing.T) *twoPhaseState[model.RecordItems] {
+	t.Helper()
+	// spill every held record past the first to disk
+	state, err := newTwoPhaseState[model.RecordItems](t.Context(), map[string]string{
+		"PEERDB_CDC_DISK_SPILL_RECORDS_THRESHOLD":     "1",
+		"PEERDB_CDC_DISK_SPILL_MEM_PERCENT_THRESHOLD": "0",
+	}, "test_two_phase")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, state.records.Close()) })
+	return state
+}
+
+func TestTwoPhaseStateHoldsPreparedRecords(t *testing.T) {
+	state := newTestTwoPhaseState(t)
+	rec := &model.InsertRecord[model.RecordItems]{}
+
+	held, err := state.hold(rec, nil)
+	require.NoError(t, err)
+	require.Equal(t, rec, held)
+	require.Equal(t, pglogrepl.LSN(500), state.capLSN(500))
+
+	state.decoding = &preparedTransaction{gid: "txn1", prepareLSN: 200}
+	for i := range 3 {
+		held, err = state.hold(&model.InsertRecord[model.RecordItems]{BaseRecord: model.BaseRecord{CheckpointID: int64(i)}}, nil)
+		require.NoError(t, err)
+		require.Nil(t, held)
+	}
+	require.Equal(t, pglogrepl.LSN(199), state.capLSN(500))
+
+	state.prepared["txn1"] = state.decoding
+	state.decoding = nil
+	require.Equal(t, pglogrepl.LSN(199), state.capLSN(500))
+	require.Equal(t, pglogrepl.LSN(150), state.capLSN(150))
+	require.Equal(t, 3, state.records.Len())
+
+	state.committed = append(state.committed, state.prepared["txn1"])
+	delete(state.prepared, "txn1")
+	var checkpoints []int64
+	require.NoError(t, state.takeCommitted(func(rec model.Record[model.RecordItems]) error {
+		checkpoints = append(checkpoints, rec.GetCheckpointID())
+		return nil
+	}))
+	require.Equal(t, []int64{0, 1, 2}, checkpoints)
+	require.Empty(t, state.committed)
+	require.Equal(t, 0, state.records.Len())
+	require.Equal(t, pglogrepl.LSN(500), state.capLSN(500))
+}
+
+func TestTwoPhaseStateRollback(t *testing.T) {
+	state := newTestTwoPhaseState(t)
+
+	state.decoding = &preparedTransaction{gid: "txn1", prepareLSN: 200}
+	for range 2 {
+		_, err := state.hold(&model.InsertRecord[model.RecordItems]{}, nil)
+		require.NoError(t, err)
+	}
+	state.prepared["txn1"] = state.decoding
+	state.decoding = nil
+
+	require.NoError(t, state.rollback("txn1"))
+	require.Empty(t, state.prepared)
+	require.Equal(t, 0, state.records.Len())
+	// unknown transactions were already resolved before replication restarted
+	require.NoError(t, state.rollback("txn2"))
+}
+
+func TestTwoPhaseStateOf(t *testing.T) {
+	var states twoPhaseStates
+	recordItems := twoPhaseStateOf[model.RecordItems](&states)
+	pgItems := twoPhaseStateOf[model.PgItems](&states)
+	require.Same(t, &states.recordItems, recordItems)
+	require.Same(t, &states.pgItems, pgItems)
+	require.NoError(t, states.Close())
+}
+
+func TestReplicationOptionsTwoPhase(t *testing.T) {
+	c := &PostgresConnector{}
+	opts, err := c.replicationOptions("pub", shared.POSTGRES_15, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"proto_version '3'", "two_phase 'true'", "publication_names 'pub'", "messages 'true'"}, opts.PluginArgs)
+
+	// prepared transactions are only decoded from Postgres 15
+	opts, err = c.replicationOptions("pub", shared.POSTGRES_14, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"proto_version '1'", "publication_names 'pub'", "messages 'true'"}, opts.PluginArgs)
+}
@@ -0,0 +1,46 @@
+package connpostgres
+
+import (
+	"slices"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// wideningTypeChanges lists source type changes where every previous value is representable in the new type,
+// keyed by the previous type of the type system
+var wideningTypeChanges = map[protos.TypeSystem]map[string][]string{
+	protos.TypeSystem_Q: {
+		string(types.QValueKindInt16):   {string(types.QValueKindInt32), string(types.QValueKindInt64)},
+		string(types.QValueKindInt32):   {string(types.QValueKindInt64)},
+		string(types.QValueKindFloat32): {string(types.QValueKindFloat64)},
+	},
+	protos.TypeSystem_PG: {
+		"int2":    {"int4", "int8"},
+		"int4":    {"int8"},
+		"float4":  {"float8"},
+		"varchar": {"text"},
+		"bpchar":  {"varchar", "text"},
+	},
+}
+
+// isWideningTypeChange reports whether a column can keep replicating by widening its destination type
+func isWideningTypeChange(system protos.TypeSystem, previous *protos.FieldDescription, current *protos.FieldDescription) bool {
+	if previous.Type == current.Type {
+		// numeric is the only type whose modifier is kept at destinations
+		return current.Type == "numeric" && isWideningNumericTypmod(previous.TypeModifier, current.TypeModifier)
+	}
+	return slices.Contains(wideningTypeChanges[system][previous.Type], current.Type)
+}
+
+func isWideningNumericTypmod(previous int32, current int32) bool {
+	if previous == current || previous == -1 {
+		return false
+	} else if current == -1 {
+		return true
+	}
+	previousPrecision, previousScale := datatypes.ParseNumericTypmod(previous)
+	currentPrecision, currentScale := datatypes.ParseNumericTypmod(current)
+	return currentScale >= previousScale && currentPrecision-currentScale >= previousPrecision-previousScale
+}
@@ -0,0 +1,31 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+)
+
+func TestIsWideningTypeChange(t *testing.T) {
+	column := func(typ string, typmod int32) *protos.FieldDescription {
+		return &protos.FieldDescription{Name: "c", Type: typ, TypeModifier: typmod}
+	}
+
+	require.True(t, isWideningTypeChange(protos.TypeSystem_Q, column("int32", -1), column("int64", -1)))
+	require.False(t, isWideningTypeChange(protos.TypeSystem_Q, column("int64", -1), column("int32", -1)))
+	require.True(t, isWideningTypeChange(protos.TypeSystem_PG, column("varchar", 54), column("text", -1)))
+	require.False(t, isWideningTypeChange(protos.TypeSystem_PG, column("text", -1), column("varchar", 54)))
+	require.False(t, isWideningTypeChange(protos.TypeSystem_Q, column("int32", -1), column("string", -1)))
+
+	require.True(t, isWideningTypeChange(protos.TypeSystem_Q,
+		column("numeric", datatypes.MakeNumericTypmod(10, 2)), column("numeric", datatypes.MakeNumericTypmod(12, 2))))
+	require.True(t, isWideningTypeChange(protos.TypeSystem_Q,
+		column("numeric", datatypes.MakeNumericTypmod(10, 2)), column("numeric", -1)))
+	require.False(t, isWideningTypeChange(protos.TypeSystem_Q,
+		column("numeric", datatypes.MakeNumericTypmod(10, 2)), column("numeric", datatypes.MakeNumericTypmod(10, 4))))
+	require.False(t, isWideningTypeChange(protos.TypeSystem_Q,
+		column("numeric", -1), column("numeric", datatypes.MakeNumericTypmod(10, 2))))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -25,6 +26,50 @@ func (c *PostgresConnector) ValidateCheck(ctx context.Context) error {
 	return nil
 }
 
+// PreflightMirrorDestination tests creating tables in the schemas a mirror writes to and inserting into them,
+// in a transaction that is rolled back so nothing is left behind
+func (c *PostgresConnector) PreflightMirrorDestination(
+	ctx context.Context, cfg *protos.FlowConnectionConfigs,
+) []*protos.PreflightCheck {
+	checks := []*protos.PreflightCheck{utils.PreflightCheckResult("version", c.ValidateCheck(ctx))}
+
+	// the raw table goes in the metadata schema, created like CreateRawTable does, destination schemas must exist
+	schemas := []string{c.metadataSchema}
+	for _, tm := range cfg.TableMappings {
+		if dstTable, err := utils.ParseSchemaTable(tm.DestinationTableIdentifier); err == nil {
+			if schema := utils.QuoteIdentifier(dstTable.Schema); !slices.Contains(schemas, schema) {
+				schemas = append(schemas, schema)
+			}
+		}
+	}
+
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return append(checks, utils.PreflightCheckResult("create_table", fmt.Errorf("failed to begin transaction: %w", err)))
+	}
+	defer shared.RollbackTx(tx, c.logger)
+
+	dummyTable := "peerdb_validate_dummy_" + strings.ToLower(shared.RandomString(4))
+	return append(checks, utils.PreflightTableChecks(func() error {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(createSchemaSQL, c.metadataSchema)); err != nil {
+			return fmt.Errorf("failed to create schema %s: %w", c.metadataSchema, err)
+		}
+		for _, schema := range schemas {
+			if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE TABLE %s.%s(id int)", schema, dummyTable)); err != nil {
+				return fmt.Errorf("failed to create table in schema %s: %w", schema, err)
+			}
+		}
+		return nil
+	}, func() error {
+		for _, schema := range schemas {
+			if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s.%s VALUES (1)", schema, dummyTable)); err != nil {
+				return fmt.Errorf("failed to insert into table in schema %s: %w", schema, err)
+			}
+		}
+		return nil
+	})...)
+}
+
 func (c *PostgresConnector) CheckSourceTables(
 	ctx context.Context,
 	tableNames []*utils.SchemaTable,
@@ -37,11 +82,8 @@ func (c *PostgresConnector) CheckSourceTables(
 	}
 
 	// Check that we can select from all tables
-	tableArr := make([]string, 0, len(tableNames))
 	for idx, parsedTable := range tableNames {
 		var row pgx.Row
-		tableArr = append(tableArr, fmt.Sprintf(`(%s::text,%s::text)`,
-			utils.QuoteLiteral(parsedTable.Schema), utils.QuoteLiteral(parsedTable.Table)))
 
 		selectedColumnsStr := "*"
 		if excludedColumns := tableMappings[idx].Exclude; len(excludedColumns) != 0 {
@@ -64,47 +106,60 @@ func (c *PostgresConnector) CheckSourceTables(
 	}
 
 	if pubName != "" && !noCDC {
-		// Check if publication exists
-		var alltables bool
-		if err := c.conn.QueryRow(ctx, "SELECT puballtables FROM pg_publication WHERE pubname=$1", pubName).Scan(&alltables); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return fmt.Errorf("publication does not exist: %s", pubName)
-			}
-			return fmt.Errorf("error while checking for publication existence: %w", err)
+		return c.CheckPublicationCoverage(ctx, tableNames, pubName)
+	}
+
+	return nil
+}
+
+// CheckPublicationCoverage checks that the publication exists and includes all tables
+func (c *PostgresConnector) CheckPublicationCoverage(ctx context.Context, tableNames []*utils.SchemaTable, pubName string) error {
+	tableArr := make([]string, 0, len(tableNames))
+	for _, parsedTable := range tableNames {
+		tableArr = append(tableArr, fmt.Sprintf(`(%s::text,%s::text)`,
+			utils.QuoteLiteral(parsedTable.Schema), utils.QuoteLiteral(parsedTable.Table)))
+	}
+
+	// Check if publication exists
+	var alltables bool
+	if err := c.conn.QueryRow(ctx, "SELECT puballtables FROM pg_publication WHERE pubname=$1", pubName).Scan(&alltables); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("publication does not exist: %s", pubName)
 		}
+		return fmt.Errorf("error while checking for publication existence: %w", err)
+	}
 
-		if !alltables {
-			// Check if tables belong to publication
-			tableStr := strings.Join(tableArr, ",")
-
-			rows, err := c.conn.Query(
-				ctx,
-				fmt.Sprintf(`select schemaname,tablename
-				from (values %s) as input(schemaname,tablename)
-				where not exists (
-					select * from pg_publication_tables pub
-					where pubname=$1 and pub.schemaname=input.schemaname and pub.tablename=input.tablename
-				)`, tableStr),
-				pubName,
-			)
-			if err != nil {
-				return err
-			}
-			missing, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (string, error) {
-				var schema string
-				var table string
-				if err := row.Scan(&schema, &table); err != nil {
-					return "", err
-				}
-				return fmt.Sprintf("%s.%s", utils.QuoteIdentifier(schema), utils.QuoteIdentifier(table)), nil
-			})
-			if err != nil {
-				return err
+	if !alltables {
+		// Check if tables belong to publication
+		tableStr := strings.Join(tableArr, ",")
+
+		rows, err := c.conn.Query(
+			ctx,
+			fmt.Sprintf(`select schemaname,tablename
+			from (values %s) as input(schemaname,tablename)
+			where not exists (
+				select * from pg_publication_tables pub
+				where pubname=$1 and pub.schemaname=input.schemaname and pub.tablename=input.tablename
+			)`, tableStr),
+			pubName,
+		)
+		if err != nil {
+			return err
+		}
+		missing, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (string, error) {
+			var schema string
+			var table string
+			if err := row.Scan(&schema, &table); err != nil {
+				return "", err
 			}
+			return fmt.Sprintf("%s.%s", utils.QuoteIdentifier(schema), utils.QuoteIdentifier(table)), nil
+		})
+		if err != nil {
+			return err
+		}
 
-			if len(missing) != 0 {
-				return errors.New("some tables missing from publication: " + strings.Join(missing, ", "))
-			}
+		if len(missing) != 0 {
+			return errors.New("some tables missing from publication: " + strings.Join(missing, ", "))
 		}
 	}
 
@@ -138,16 +193,10 @@ func (c *PostgresConnector) CheckReplicationPermissions(ctx context.Context, use
 		}
 	}
 
-	// check wal_level
-	var walLevel string
-	if err := c.conn.QueryRow(ctx, "SHOW wal_level").Scan(&walLevel); err != nil {
+	if err := c.CheckWalLevel(ctx); err != nil {
 		return err
 	}
 
-	if walLevel != "logical" {
-		return errors.New("wal_level is not logical")
-	}
-
 	// max_wal_senders must be at least 2
 	var insufficientMaxWalSenders bool
 	if err := c.conn.QueryRow(ctx,
@@ -188,6 +237,18 @@ func (c *PostgresConnector) CheckReplicationPermissions(ctx context.Context, use
 	return nil
 }
 
+func (c *PostgresConnector) CheckWalLevel(ctx context.Context) error {
+	var walLevel string
+	if err := c.conn.QueryRow(ctx, "SHOW wal_level").Scan(&walLevel); err != nil {
+		return err
+	}
+
+	if walLevel != "logical" {
+		return errors.New("wal_level is not logical")
+	}
+	return nil
+}
+
 func (c *PostgresConnector) CheckReplicationConnectivity(ctx context.Context) error {
 	// Check if we can create a replication connection
 	conn, err := c.CreateReplConn(ctx)
@@ -257,3 +318,55 @@ func (c *PostgresConnector) ValidateMirrorSource(ctx context.Context, cfg *proto
 
 	return nil
 }
+
+// PreflightMirrorSource runs the checks of ValidateMirrorSource, reporting each of them instead of stopping at the first failure
+func (c *PostgresConnector) PreflightMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigs) []*protos.PreflightCheck {
+	sourceTables := make([]*utils.SchemaTable, 0, len(cfg.TableMappings))
+	for _, tableMapping := range cfg.TableMappings {
+		parsedTable, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return []*protos.PreflightCheck{utils.PreflightCheckResult("source_tables", err)}
+		}
+		sourceTables = append(sourceTables, parsedTable)
+	}
+
+	checks := []*protos.PreflightCheck{
+		utils.PreflightCheckResult("source_tables", c.CheckSourceTables(ctx, sourceTables, cfg.TableMappings, "", true)),
+	}
+	if cfg.DoInitialSnapshot && cfg.InitialSnapshotOnly {
+		return checks
+	}
+
+	checks = append(checks,
+		utils.PreflightCheckResult("wal_level", c.CheckWalLevel(ctx)),
+		utils.PreflightCheckResult("replication_connectivity", c.CheckReplicationConnectivity(ctx)),
+		utils.PreflightCheckResult("replication_privileges", c.CheckReplicationPermissions(ctx, c.Config.User)),
+	)
+	if cfg.PublicationName != "" {
+		checks = append(checks, utils.PreflightCheckResult("publication_coverage",
+			c.CheckPublicationCoverage(ctx, sourceTables, cfg.PublicationName)))
+	} else {
+		srcTableNames := make([]string, 0, len(sourceTables))
+		for _, srcTable := range sourceTables {
+			srcTableNames = append(srcTableNames, srcTable.String())
+		}
+		checks = append(checks, utils.PreflightCheckResult("publication_creation",
+			c.CheckPublicationCreationPermissions(ctx, srcTableNames)))
+	}
+
+	// a slot is needed for the mirror, running out of them only fails once the mirror starts
+	slotsCheck := &protos.PreflightCheck{Name: "replication_slots"}
+	var freeSlots int64
+	if err := c.conn.QueryRow(ctx,
+		"SELECT current_setting('max_replication_slots')::int - (SELECT count(*) FROM pg_replication_slots)",
+	).Scan(&freeSlots); err != nil {
+		slotsCheck = utils.PreflightCheckResult(slotsCheck.Name, fmt.Errorf("failed to count replication slots: %w", err))
+	} else if freeSlots < 1 {
+		slotsCheck.Status = protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_FAIL
+		slotsCheck.Message = "no replication slots left, increase max_replication_slots"
+	} else if freeSlots == 1 {
+		slotsCheck.Status = protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_WARN
+		slotsCheck.Message = "this mirror uses the last free replication slot"
+	}
+	return append(checks, slotsCheck)
+}
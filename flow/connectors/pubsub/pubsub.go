@@ -2,6 +2,7 @@ package connpubsub
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -24,6 +25,7 @@ import (
 type PubSubConnector struct {
 	*metadataStore.PostgresMetadata
 	client *pubsub.Client
+	config *protos.PubSubConfig
 	logger log.Logger
 }
 
@@ -45,6 +47,7 @@ func NewPubSubConnector(
 
 	return &PubSubConnector{
 		client:           client,
+		config:           config,
 		PostgresMetadata: pgMetadata,
 		logger:           internal.LoggerFromCtx(ctx),
 	}, nil
@@ -132,6 +135,88 @@ func lvalueToPubSubMessage(ls *lua.LState, value lua.LValue) (PubSubMessage, err
 	}, nil
 }
 
+func outboxPubSubMessage(msg *utils.OutboxMessage) PubSubMessage {
+	message := &pubsub.Message{
+		OrderingKey: string(msg.Key),
+		Data:        msg.Value,
+	}
+	if len(msg.Headers) > 0 {
+		message.Attributes = make(map[string]string, len(msg.Headers))
+		for _, header := range msg.Headers {
+			message.Attributes[header.Name] = string(header.Value)
+		}
+	}
+	return PubSubMessage{
+		Message: message,
+		Topic:   msg.Topic,
+	}
+}
+
+const (
+	tableAttribute       = "peerdb-table"
+	sourceTableAttribute = "peerdb-source-table"
+	opAttribute          = "peerdb-op"
+)
+
+// decorate sets the ordering key & metadata attributes of msg enabled by the peer,
+// op is the kind of change record is, those set by the script are left as they are
+func (c *PubSubConnector) decorate(
+	msg PubSubMessage,
+	record model.Record[model.RecordItems],
+	op string,
+	primaryKeys map[string][]string,
+) error {
+	if c.config.OrderingKeyFromPrimaryKey && msg.OrderingKey == "" {
+		key, err := orderingKey(record, primaryKeys[record.GetDestinationTableName()])
+		if err != nil {
+			return err
+		}
+		msg.OrderingKey = key
+	}
+	if c.config.MetadataAttributes {
+		if msg.Attributes == nil {
+			msg.Attributes = make(map[string]string, 3)
+		}
+		for attribute, value := range map[string]string{
+			tableAttribute:       record.GetDestinationTableName(),
+			sourceTableAttribute: record.GetSourceTableName(),
+			opAttribute:          op,
+		} {
+			if _, ok := msg.Attributes[attribute]; !ok {
+				msg.Attributes[attribute] = value
+			}
+		}
+	}
+	return nil
+}
+
+// orderingKey is the primary key of the row changed by record as json, empty for tables without one
+func orderingKey(record model.Record[model.RecordItems], primaryKey []string) (string, error) {
+	if len(primaryKey) == 0 {
+		return "", nil
+	}
+	var items model.RecordItems
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		items = r.Items
+	case *model.UpdateRecord[model.RecordItems]:
+		items = r.NewItems
+	case *model.DeleteRecord[model.RecordItems]:
+		items = r.Items
+	default:
+		return "", nil
+	}
+	keyItems := model.NewRecordItems(len(primaryKey))
+	for _, column := range primaryKey {
+		keyItems.AddColumn(column, items.GetColumnValue(column))
+	}
+	key, err := keyItems.MarshalJSON()
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize ordering key of %s: %w", record.GetDestinationTableName(), err)
+	}
+	return string(key), nil
+}
+
 func (c *PubSubConnector) createPool(
 	ctx context.Context,
 	env map[string]string,
@@ -161,7 +246,7 @@ func (c *PubSubConnector) createPool(
 		for _, message := range result.messages {
 			topicClient, err := topiccache.GetOrSet(message.Topic, func() (*pubsub.Topic, error) {
 				topicClient := c.client.Topic(message.Topic)
-				if message.OrderingKey != "" {
+				if message.OrderingKey != "" || c.config.OrderingKeyFromPrimaryKey {
 					topicClient.EnableMessageOrdering = true
 				}
 
@@ -169,7 +254,7 @@ func (c *PubSubConnector) createPool(
 				if envErr != nil {
 					return nil, envErr
 				}
-				if force {
+				if force || c.config.CreateTopics {
 					exists, err := topicClient.Exists(ctx)
 					if err != nil {
 						return nil, fmt.Errorf("error checking if topic exists: %w", err)
@@ -240,7 +325,17 @@ func (c *PubSubConnector) SyncRecords(ctx context.Context, req *model.SyncRecord
 	numRecords := atomic.Int64{}
 	lastSeenLSN := atomic.Int64{}
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	outbox := utils.NewOutboxRouter(req.TableMappings)
+	schemaVersions := utils.NewSchemaVersions(req.TableNameSchemaMapping)
+	primaryKeys := make(map[string][]string, len(req.TableNameSchemaMapping))
+	for table, tableSchema := range req.TableNameSchemaMapping {
+		primaryKeys[table] = tableSchema.PrimaryKeyColumns
+	}
 	topiccache := topicCache{cache: make(map[string]*pubsub.Topic)}
+	diffUpdate, err := utils.QueueUpdateDiffer(ctx, req.Env, req.TableNameSchemaMapping)
+	if err != nil {
+		return nil, err
+	}
 	publish := make(chan publishResult, 32)
 	waitChan := make(chan struct{})
 
@@ -305,6 +400,22 @@ Loop:
 			}
 
 			pool.Run(func(ls *lua.LState) poolResult {
+				if msg, ok, err := outbox.Route(record); err != nil {
+					queueErr(err)
+					return poolResult{}
+				} else if ok {
+					var results []PubSubMessage
+					if msg != nil {
+						results = append(results, outboxPubSubMessage(msg))
+						record.PopulateCountMap(tableNameRowsMapping)
+					}
+					numRecords.Add(1)
+					return poolResult{
+						messages: results,
+						lsn:      record.GetCheckpointID(),
+					}
+				}
+
 				lfn := ls.Env.RawGetString("onRecord")
 				fn, ok := lfn.(*lua.LFunction)
 				if !ok {
@@ -313,7 +424,7 @@ Loop:
 				}
 
 				ls.Push(fn)
-				ls.Push(pua.LuaRecord.New(ls, record))
+				ls.Push(pua.LuaRecord.New(ls, diffUpdate(record)))
 				err := ls.PCall(1, -1, nil)
 				if err != nil {
 					queueErr(fmt.Errorf("script failed: %w", err))
@@ -332,6 +443,18 @@ Loop:
 						if msg.Topic == "" {
 							msg.Topic = record.GetDestinationTableName()
 						}
+						if err := c.decorate(msg, record, record.Kind(), primaryKeys); err != nil {
+							queueErr(err)
+							return poolResult{}
+						}
+						if version, ok := schemaVersions.Get(record.GetDestinationTableName()); ok {
+							if msg.Attributes == nil {
+								msg.Attributes = make(map[string]string, 1)
+							}
+							if _, ok := msg.Attributes[utils.SchemaVersionHeader]; !ok {
+								msg.Attributes[utils.SchemaVersionHeader] = version
+							}
+						}
 						results = append(results, msg)
 						record.PopulateCountMap(tableNameRowsMapping)
 					}
@@ -354,6 +477,9 @@ Loop:
 		return nil, fmt.Errorf("[pubsub] pool.Wait error: %w", err)
 	}
 	close(publish)
+	if err := c.publishSchemaChanges(queueCtx, req, schemaVersions); err != nil {
+		return nil, err
+	}
 	topiccache.Stop(queueCtx)
 	select {
 	case <-queueCtx.Done():
@@ -385,3 +511,35 @@ func (tc *topicCache) forEach(ctx context.Context, f func(topic *pubsub.Topic))
 		f(topicClient)
 	}
 }
+
+func (c *PubSubConnector) publishSchemaChanges(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	schemaVersions *utils.SchemaVersions,
+) error {
+	if len(req.Records.SchemaDeltas) == 0 {
+		return nil
+	}
+	topic, err := internal.PeerDBQueueSchemaChangeTopic(ctx, req.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get schema change topic: %w", err)
+	} else if topic == "" {
+		return nil
+	}
+
+	topicClient := c.client.Topic(topic)
+	defer topicClient.Stop()
+	for _, event := range schemaVersions.ChangeEvents(req.FlowJobName, req.SyncBatchID, req.Records.SchemaDeltas) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to serialize schema change: %w", err)
+		}
+		if _, err := topicClient.Publish(ctx, &pubsub.Message{
+			Data:       data,
+			Attributes: map[string]string{utils.SchemaVersionHeader: event.SchemaVersion},
+		}).Get(ctx); err != nil {
+			return fmt.Errorf("[pubsub] failed to publish schema change of %s: %w", event.DestinationTable, err)
+		}
+	}
+	return nil
+}
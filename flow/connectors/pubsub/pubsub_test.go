@@ -0,0 +1,41 @@
+package connpubsub
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestDecorate(t *testing.T) {
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 7})
+	items.AddColumn("name", types.QValueString{Val: "seven"})
+	record := &model.InsertRecord[model.RecordItems]{
+		Items:                items,
+		SourceTableName:      "public.orders",
+		DestinationTableName: "orders",
+	}
+	primaryKeys := map[string][]string{"orders": {"id"}}
+
+	c := &PubSubConnector{config: &protos.PubSubConfig{OrderingKeyFromPrimaryKey: true, MetadataAttributes: true}}
+	msg := PubSubMessage{Message: &pubsub.Message{Attributes: map[string]string{opAttribute: "custom"}}}
+	require.NoError(t, c.decorate(msg, record, record.Kind(), primaryKeys))
+	require.JSONEq(t, `{"id":7}`, msg.OrderingKey)
+	require.Equal(t, "orders", msg.Attributes[tableAttribute])
+	require.Equal(t, "public.orders", msg.Attributes[sourceTableAttribute])
+	require.Equal(t, "custom", msg.Attributes[opAttribute])
+
+	msg = PubSubMessage{Message: &pubsub.Message{OrderingKey: "script"}}
+	require.NoError(t, c.decorate(msg, record, record.Kind(), primaryKeys))
+	require.Equal(t, "script", msg.OrderingKey)
+
+	msg = PubSubMessage{Message: &pubsub.Message{}}
+	require.NoError(t, c.decorate(msg, record, "snapshot", nil))
+	require.Empty(t, msg.OrderingKey)
+	require.Equal(t, "snapshot", msg.Attributes[opAttribute])
+}
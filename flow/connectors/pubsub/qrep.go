@@ -2,6 +2,7 @@ package connpubsub
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"cloud.google.com/go/pubsub"
 	lua "github.com/yuin/gopher-lua"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/pua"
@@ -103,6 +105,11 @@ Loop:
 						if msg.Topic == "" {
 							msg.Topic = record.GetDestinationTableName()
 						}
+						// initial loads have no table schema to order snapshots by
+						if err := c.decorate(msg, record, "snapshot", nil); err != nil {
+							queueErr(err)
+							return poolResult{}
+						}
 						results = append(results, msg)
 					}
 				}
@@ -132,3 +139,28 @@ Loop:
 	}
 	return numRecords.Load(), nil, nil
 }
+
+// ConsolidateQRepPartitions publishes the snapshot completion marker to the destination topic of an initial copy
+func (c *PubSubConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
+	event := utils.NewSnapshotCompletedEvent(config)
+	if event == nil {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot completion: %w", err)
+	}
+	topicClient := c.client.Topic(config.DestinationTableIdentifier)
+	defer topicClient.Stop()
+	if _, err := topicClient.Publish(ctx, &pubsub.Message{
+		Data:       data,
+		Attributes: map[string]string{utils.SnapshotEventHeader: event.Event},
+	}).Get(ctx); err != nil {
+		return fmt.Errorf("[pubsub] failed to publish snapshot completion of %s: %w", event.DestinationTable, err)
+	}
+	return nil
+}
+
+func (*PubSubConnector) CleanupQRepFlow(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
@@ -0,0 +1,217 @@
+package conns3
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+type csvOptions struct {
+	nullString string
+	delimiter  rune
+	header     bool
+}
+
+func newCSVOptions(config *protos.S3Config) (csvOptions, error) {
+	opts := csvOptions{delimiter: ',', header: config.CsvHeader, nullString: config.CsvNullString}
+	if config.CsvDelimiter != "" {
+		delimiter, size := utf8.DecodeRuneInString(config.CsvDelimiter)
+		if size != len(config.CsvDelimiter) || delimiter == '"' || delimiter == '\r' || delimiter == '\n' ||
+			delimiter == utf8.RuneError {
+			return opts, fmt.Errorf("invalid csv delimiter %q, expected a single character other than quotes or newlines",
+				config.CsvDelimiter)
+		}
+		opts.delimiter = delimiter
+	}
+	return opts, nil
+}
+
+// textCompression maps the codec of a peer to compression of csv & jsonl files, Deflate is written as gzip.
+// It returns the file extension suffix along with a constructor of the compressing writer, nil when uncompressed
+func textCompression(codec protos.AvroCodec) (string, func(io.Writer) (io.WriteCloser, error), error) {
+	switch codec {
+	case protos.AvroCodec_Null:
+		return "", nil, nil
+	case protos.AvroCodec_Deflate:
+		return ".gz", func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		}, nil
+	case protos.AvroCodec_ZStandard:
+		return ".zst", func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w)
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported codec %s for csv and jsonl, expected Null, Deflate or ZStandard", codec)
+	}
+}
+
+func (c *S3Connector) writeToDelimitedFile(
+	ctx context.Context,
+	env map[string]string,
+	stream *model.QRecordStream,
+	partitionID string,
+	jobName string,
+	fileFormat protos.S3FileFormat,
+	codec protos.AvroCodec,
+) (int64, error) {
+	s3o, err := utils.NewS3BucketAndPrefix(c.url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse bucket path: %w", err)
+	}
+	compressionExt, compressor, err := textCompression(codec)
+	if err != nil {
+		return 0, err
+	}
+	ext := ".jsonl"
+	write := func(w io.Writer) (int64, error) {
+		return WriteJSONL(w, stream)
+	}
+	if fileFormat == protos.S3FileFormat_S3_CSV {
+		ext = ".csv"
+		write = func(w io.Writer) (int64, error) {
+			return writeCSV(w, stream, c.csvOptions)
+		}
+	}
+	key := fmt.Sprintf("%s/%s/%s%s%s", s3o.Prefix, jobName, partitionID, ext, compressionExt)
+
+	var numRecords int64
+	if _, err := c.uploadStream(ctx, env, s3o.Bucket, key, func(w io.Writer) error {
+		if compressor == nil {
+			var err error
+			numRecords, err = write(w)
+			return err
+		}
+		cw, err := compressor(w)
+		if err != nil {
+			return fmt.Errorf("failed to create compressor: %w", err)
+		}
+		numRecords, err = write(cw)
+		return errors.Join(err, cw.Close())
+	}); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
+
+// WriteJSONL encodes every record of stream as a json object on its own line,
+// values are formatted the same as records of CDC destinations like Kafka
+func WriteJSONL(w io.Writer, stream *model.QRecordStream) (int64, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	bw := bufio.NewWriter(w)
+	var numRecords int64
+	for record := range stream.Records {
+		items := model.NewRecordItems(len(record))
+		for idx, value := range record {
+			items.AddColumn(schema.Fields[idx].Name, value)
+		}
+		line, err := items.MarshalJSON()
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode record as json: %w", err)
+		}
+		if _, err := bw.Write(line); err != nil {
+			return 0, err
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return 0, err
+		}
+		numRecords += 1
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	return numRecords, bw.Flush()
+}
+
+func writeCSV(w io.Writer, stream *model.QRecordStream, opts csvOptions) (int64, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.delimiter
+	if opts.header {
+		if err := cw.Write(schema.GetColumnNames()); err != nil {
+			return 0, fmt.Errorf("failed to write csv header: %w", err)
+		}
+	}
+
+	row := make([]string, len(schema.Fields))
+	var numRecords int64
+	for record := range stream.Records {
+		for idx, value := range record {
+			field, err := csvField(value, opts.nullString)
+			if err != nil {
+				return 0, fmt.Errorf("failed to convert column %s: %w", schema.Fields[idx].Name, err)
+			}
+			row[idx] = field
+		}
+		if err := cw.Write(row); err != nil {
+			return 0, fmt.Errorf("failed to write csv row: %w", err)
+		}
+		numRecords += 1
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	cw.Flush()
+	return numRecords, cw.Error()
+}
+
+// csvField formats scalars like the json of CDC records do, composite values are written as json
+func csvField(value types.QValue, nullString string) (string, error) {
+	if value == nil || value.Value() == nil {
+		return nullString, nil
+	}
+	switch v := value.(type) {
+	case types.QValueString:
+		return v.Val, nil
+	case types.QValueQChar:
+		return string(rune(v.Val)), nil
+	case types.QValueJSON:
+		return v.Val, nil
+	case types.QValueBytes:
+		return base64.StdEncoding.EncodeToString(v.Val), nil
+	case types.QValueTimestamp:
+		return v.Val.Format("2006-01-02 15:04:05.999999"), nil
+	case types.QValueTimestampTZ:
+		return v.Val.Format("2006-01-02 15:04:05.999999-0700"), nil
+	case types.QValueDate:
+		return v.Val.Format("2006-01-02"), nil
+	case types.QValueTime:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	case types.QValueTimeTZ:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	}
+	switch v := value.Value().(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool, int8, int16, int32, int64, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
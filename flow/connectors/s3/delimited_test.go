@@ -0,0 +1,71 @@
+package conns3
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func delimitedTestStream() *model.QRecordStream {
+	stream := model.NewQRecordStream(4)
+	stream.SetSchema(types.QRecordSchema{Fields: []types.QField{
+		{Name: "id", Type: types.QValueKindInt64, Nullable: false},
+		{Name: "name", Type: types.QValueKindString, Nullable: true},
+		{Name: "amount", Type: types.QValueKindNumeric, Nullable: true},
+		{Name: "created_at", Type: types.QValueKindTimestamp, Nullable: true},
+		{Name: "tags", Type: types.QValueKindArrayString, Nullable: true},
+	}})
+	stream.Records <- []types.QValue{
+		types.QValueInt64{Val: 1},
+		types.QValueString{Val: "one; \"quoted\""},
+		types.QValueNumeric{Val: decimal.RequireFromString("1.50")},
+		types.QValueTimestamp{Val: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)},
+		types.QValueArrayString{Val: []string{"a", "b"}},
+	}
+	stream.Records <- []types.QValue{
+		types.QValueInt64{Val: 2},
+		types.QValueNull(types.QValueKindString),
+		types.QValueNull(types.QValueKindNumeric),
+		types.QValueNull(types.QValueKindTimestamp),
+		types.QValueNull(types.QValueKindArrayString),
+	}
+	close(stream.Records)
+	return stream
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	numRecords, err := writeCSV(&buf, delimitedTestStream(), csvOptions{delimiter: ';', header: true, nullString: `\N`})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), numRecords)
+	require.Equal(t, strings.Join([]string{
+		"id;name;amount;created_at;tags",
+		`1;"one; ""quoted""";1.5;2024-05-01 12:30:00;"[""a"",""b""]"`,
+		`2;\N;\N;\N;\N`,
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	numRecords, err := WriteJSONL(&buf, delimitedTestStream())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), numRecords)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var first map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.Equal(t, "1.5", first["amount"])
+	require.Equal(t, "2024-05-01 12:30:00", first["created_at"])
+	require.Equal(t, []any{"a", "b"}, first["tags"])
+	require.JSONEq(t, `{"id":2,"name":null,"amount":null,"created_at":null,"tags":null}`, lines[1])
+}
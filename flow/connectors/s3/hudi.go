@@ -0,0 +1,430 @@
+package conns3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// Hudi tables are written following the layout of table version 6 (Hudi 0.14+): a non-partitioned table,
+// one parquet base file per file group version and a timeline of instants under .hoodie,
+// where data files of instants which never completed are ignored by readers.
+const (
+	hudiMetaFolder        = ".hoodie"
+	hudiPropertiesFile    = "hoodie.properties"
+	hudiRecordKeyColName  = "_hoodie_record_key"
+	hudiIsDeletedColName  = "_hoodie_is_deleted"
+	hudiVersionColName    = "_peerdb_version"
+	hudiWriteToken        = "0-0-0"
+	hudiInstantTimeFormat = "20060102150405.000"
+	// inserts are added to the smallest base file under this size instead of a new file group,
+	// same as the default of hoodie.parquet.small.file.limit
+	hudiSmallFileLimit = 100 << 20
+)
+
+var (
+	hudiMetaColumns = []string{
+		"_hoodie_commit_time", "_hoodie_commit_seqno", hudiRecordKeyColName, "_hoodie_partition_path", "_hoodie_file_name",
+	}
+	hudiCompletedInstantRe = regexp.MustCompile(`^(\d{17})\.(commit|deltacommit)$`)
+	hudiBaseFileRe         = regexp.MustCompile(`^([^_./]+)_([^_]+)_(\d{17})\.parquet$`)
+	hudiInvalidNameRe      = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+type hudiTable struct {
+	bucket          string
+	path            string
+	name            string
+	tableType       protos.HudiTableType
+	recordKeyFields []string
+	precombineField string
+}
+
+type hudiFileSlice struct {
+	fileID      string
+	baseInstant string
+	baseFile    string
+	size        int64
+	// base files of earlier versions of the file group, oldest first
+	olderBaseFiles []string
+}
+
+type hudiWriteStat struct {
+	FileID           string `json:"fileId"`
+	Path             string `json:"path"`
+	PrevCommit       string `json:"prevCommit"`
+	PartitionPath    string `json:"partitionPath"`
+	NumWrites        int64  `json:"numWrites"`
+	NumDeletes       int64  `json:"numDeletes"`
+	NumUpdateWrites  int64  `json:"numUpdateWrites"`
+	NumInserts       int64  `json:"numInserts"`
+	TotalWriteBytes  int64  `json:"totalWriteBytes"`
+	TotalWriteErrors int64  `json:"totalWriteErrors"`
+	FileSizeInBytes  int64  `json:"fileSizeInBytes"`
+	// log files appended to by a delta commit of a merge on read table
+	BaseFile   string   `json:"baseFile,omitempty"`
+	LogFiles   []string `json:"logFiles,omitempty"`
+	LogVersion int      `json:"logVersion,omitempty"`
+}
+
+type hudiCommitMetadata struct {
+	PartitionToWriteStats map[string][]*hudiWriteStat `json:"partitionToWriteStats"`
+	ExtraMetadata         map[string]string           `json:"extraMetadata"`
+	OperationType         string                      `json:"operationType"`
+	Compacted             bool                        `json:"compacted"`
+}
+
+func isS3ErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
+
+func (c *S3Connector) hudiTableLocation(table string) (string, string, error) {
+	s3o, err := utils.NewS3BucketAndPrefix(c.url)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse bucket path: %w", err)
+	}
+	return s3o.Bucket, strings.TrimPrefix(s3o.Prefix+"/"+table, "/"), nil
+}
+
+func (t *hudiTable) key(name string) string {
+	return t.path + "/" + name
+}
+
+func (t *hudiTable) metaKey(name string) string {
+	return t.path + "/" + hudiMetaFolder + "/" + name
+}
+
+func (t *hudiTable) commitAction() string {
+	if t.tableType == protos.HudiTableType_HUDI_MERGE_ON_READ {
+		return "deltacommit"
+	}
+	return "commit"
+}
+
+func hudiTableName(table string) string {
+	return hudiInvalidNameRe.ReplaceAllString(table, "_")
+}
+
+func (t *hudiTable) properties() [][2]string {
+	tableType := "COPY_ON_WRITE"
+	if t.tableType == protos.HudiTableType_HUDI_MERGE_ON_READ {
+		tableType = "MERGE_ON_READ"
+	}
+	return [][2]string{
+		{"hoodie.table.name", t.name},
+		{"hoodie.table.type", tableType},
+		{"hoodie.table.version", "6"},
+		{"hoodie.timeline.layout.version", "1"},
+		{"hoodie.table.timeline.timezone", "UTC"},
+		{"hoodie.table.base.file.format", "PARQUET"},
+		{"hoodie.table.recordkey.fields", strings.Join(t.recordKeyFields, ",")},
+		{"hoodie.table.precombine.field", t.precombineField},
+		{"hoodie.table.partition.fields", ""},
+		{"hoodie.table.keygenerator.class", "org.apache.hudi.keygen.NonpartitionedKeyGenerator"},
+		{"hoodie.populate.meta.fields", "true"},
+		{"hoodie.archivelog.folder", "archived"},
+		{"hoodie.compaction.payload.class", "org.apache.hudi.common.model.OverwriteWithLatestAvroPayload"},
+		// checked by readers against the table name, computed like HoodieTableConfig.generateChecksum
+		{"hoodie.table.checksum", fmt.Sprint(crc32.ChecksumIEEE([]byte(t.name)))},
+	}
+}
+
+// escapeHudiProperty escapes a value the way java.util.Properties stores it
+func escapeHudiProperty(value string) string {
+	var sb strings.Builder
+	for idx, r := range value {
+		switch r {
+		case '\\', '=', ':', '#', '!':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case ' ':
+			if idx == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func parseHudiProperties(r io.Reader) (map[string]string, error) {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		var key, value strings.Builder
+		target := &key
+		escaped := false
+		for _, r := range line {
+			switch {
+			case escaped:
+				switch r {
+				case 'n':
+					target.WriteByte('\n')
+				case 'r':
+					target.WriteByte('\r')
+				case 't':
+					target.WriteByte('\t')
+				default:
+					target.WriteRune(r)
+				}
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case (r == '=' || r == ':') && target == &key:
+				target = &value
+			default:
+				target.WriteRune(r)
+			}
+		}
+		props[strings.TrimSpace(key.String())] = value.String()
+	}
+	return props, scanner.Err()
+}
+
+func (c *S3Connector) createHudiTable(ctx context.Context, t *hudiTable) error {
+	var buf bytes.Buffer
+	buf.WriteString("#Properties saved on " + time.Now().UTC().Format(time.UnixDate) + "\n")
+	for _, prop := range t.properties() {
+		buf.WriteString(prop[0] + "=" + escapeHudiProperty(prop[1]) + "\n")
+	}
+	if _, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.metaKey(hudiPropertiesFile)),
+		Body:   bytes.NewReader(buf.Bytes()),
+	}); err != nil {
+		return fmt.Errorf("failed to create hudi table %s: %w", t.name, err)
+	}
+	return nil
+}
+
+// loadHudiTable reads the table config of the Hudi table at path, returning nil if there is no table yet
+func (c *S3Connector) loadHudiTable(ctx context.Context, bucket string, path string) (*hudiTable, error) {
+	t := &hudiTable{bucket: bucket, path: path}
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(t.metaKey(hudiPropertiesFile)),
+	})
+	if err != nil {
+		if isS3ErrorCode(err, "NoSuchKey") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read hudi table config of s3://%s/%s: %w", bucket, path, err)
+	}
+	defer out.Body.Close()
+	props, err := parseHudiProperties(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse hudi table config of s3://%s/%s: %w", bucket, path, err)
+	}
+
+	t.name = props["hoodie.table.name"]
+	if props["hoodie.table.type"] == "MERGE_ON_READ" {
+		t.tableType = protos.HudiTableType_HUDI_MERGE_ON_READ
+	}
+	if recordKeyFields := props["hoodie.table.recordkey.fields"]; recordKeyFields != "" {
+		t.recordKeyFields = strings.Split(recordKeyFields, ",")
+	}
+	t.precombineField = props["hoodie.table.precombine.field"]
+	if len(t.recordKeyFields) == 0 {
+		return nil, fmt.Errorf("hudi table at s3://%s/%s has no record key fields", bucket, path)
+	}
+	return t, nil
+}
+
+// listHudiObjects lists the names and sizes of the objects directly under prefix
+func (c *S3Connector) listHudiObjects(ctx context.Context, bucket string, prefix string) (map[string]int64, error) {
+	objects := make(map[string]int64)
+	paginator := s3.NewListObjectsV2Paginator(&c.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucket),
+		Prefix:    aws.String(prefix + "/"),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list s3://%s/%s: %w", bucket, prefix, err)
+		}
+		for _, object := range page.Contents {
+			objects[strings.TrimPrefix(aws.ToString(object.Key), prefix+"/")] = aws.ToInt64(object.Size)
+		}
+	}
+	return objects, nil
+}
+
+// latestFileSlices returns the latest committed base file of every file group of the table
+func (c *S3Connector) latestFileSlices(ctx context.Context, t *hudiTable) (map[string]*hudiFileSlice, error) {
+	timeline, err := c.listHudiObjects(ctx, t.bucket, t.path+"/"+hudiMetaFolder)
+	if err != nil {
+		return nil, err
+	}
+	completed := make(map[string]struct{})
+	for name := range timeline {
+		if match := hudiCompletedInstantRe.FindStringSubmatch(name); match != nil {
+			completed[match[1]] = struct{}{}
+		}
+	}
+
+	files, err := c.listHudiObjects(ctx, t.bucket, t.path)
+	if err != nil {
+		return nil, err
+	}
+	type baseFile struct {
+		name    string
+		fileID  string
+		instant string
+	}
+	baseFiles := make([]baseFile, 0, len(files))
+	for name := range files {
+		if match := hudiBaseFileRe.FindStringSubmatch(name); match != nil {
+			if _, ok := completed[match[3]]; ok {
+				baseFiles = append(baseFiles, baseFile{name: name, fileID: match[1], instant: match[3]})
+			}
+		}
+	}
+	// ordered by instant so later versions of a file group replace earlier ones
+	slices.SortFunc(baseFiles, func(a baseFile, b baseFile) int {
+		return strings.Compare(a.instant, b.instant)
+	})
+	fileSlices := make(map[string]*hudiFileSlice)
+	for _, file := range baseFiles {
+		fileSlice := &hudiFileSlice{fileID: file.fileID, baseInstant: file.instant, baseFile: file.name, size: files[file.name]}
+		if previous, ok := fileSlices[file.fileID]; ok {
+			fileSlice.olderBaseFiles = append(previous.olderBaseFiles, previous.baseFile)
+		}
+		fileSlices[file.fileID] = fileSlice
+	}
+	return fileSlices, nil
+}
+
+// startHudiInstant creates a requested and inflight instant on the timeline, instant times are unique
+// even with concurrent writers since the requested file is only created if it does not exist yet
+func (c *S3Connector) startHudiInstant(ctx context.Context, t *hudiTable) (string, error) {
+	action := t.commitAction()
+	for range 10 {
+		instant := strings.Replace(time.Now().UTC().Format(hudiInstantTimeFormat), ".", "", 1)
+		if _, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(t.bucket),
+			Key:         aws.String(t.metaKey(instant + "." + action + ".requested")),
+			Body:        bytes.NewReader(nil),
+			IfNoneMatch: aws.String("*"),
+		}); err != nil {
+			if isS3ErrorCode(err, "PreconditionFailed") {
+				time.Sleep(time.Millisecond)
+				continue
+			}
+			return "", fmt.Errorf("failed to request hudi instant: %w", err)
+		}
+
+		inflight := instant + "." + action + ".inflight"
+		if action == "commit" {
+			inflight = instant + ".inflight"
+		}
+		if _, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(t.bucket),
+			Key:    aws.String(t.metaKey(inflight)),
+			Body:   bytes.NewReader(nil),
+		}); err != nil {
+			return "", fmt.Errorf("failed to start hudi instant: %w", err)
+		}
+		return instant, nil
+	}
+	return "", errors.New("failed to find an unused hudi instant time")
+}
+
+// completeHudiInstant makes the files written for instant visible to readers
+func (c *S3Connector) completeHudiInstant(
+	ctx context.Context,
+	t *hudiTable,
+	instant string,
+	operationType string,
+	avroSchema string,
+	writeStats []*hudiWriteStat,
+) error {
+	metadata, err := json.Marshal(&hudiCommitMetadata{
+		PartitionToWriteStats: map[string][]*hudiWriteStat{"": writeStats},
+		ExtraMetadata:         map[string]string{"schema": avroSchema},
+		OperationType:         operationType,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.metaKey(instant + "." + t.commitAction())),
+		Body:   bytes.NewReader(metadata),
+	}); err != nil {
+		return fmt.Errorf("failed to complete hudi instant %s: %w", instant, err)
+	}
+	return nil
+}
+
+func (c *S3Connector) deleteHudiFiles(ctx context.Context, t *hudiTable, names []string) error {
+	for _, name := range names {
+		if _, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(t.bucket),
+			Key:    aws.String(t.key(name)),
+		}); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func hudiBaseFileName(fileID string, instant string) string {
+	return fmt.Sprintf("%s_%s_%s.parquet", fileID, hudiWriteToken, instant)
+}
+
+func hudiLogFileName(fileID string, baseInstant string, version int) string {
+	return fmt.Sprintf(".%s_%s.log.%d_%s", fileID, baseInstant, version, hudiWriteToken)
+}
+
+// nextHudiLogVersion returns the version after the highest log file of a file slice, log files are never appended to
+func (c *S3Connector) nextHudiLogVersion(ctx context.Context, t *hudiTable, fileSlice *hudiFileSlice) (int, error) {
+	prefix := fmt.Sprintf(".%s_%s.log.", fileSlice.fileID, fileSlice.baseInstant)
+	paginator := s3.NewListObjectsV2Paginator(&c.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(t.bucket),
+		Prefix: aws.String(t.key(prefix)),
+	})
+	version := 1
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list log files of %s: %w", fileSlice.fileID, err)
+		}
+		for _, object := range page.Contents {
+			var existing int
+			if _, err := fmt.Sscanf(strings.TrimPrefix(aws.ToString(object.Key), t.key(prefix)), "%d_", &existing); err == nil {
+				version = max(version, existing+1)
+			}
+		}
+	}
+	return version, nil
+}
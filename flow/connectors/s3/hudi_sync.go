@@ -0,0 +1,657 @@
+package conns3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func (c *S3Connector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *S3Connector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *S3Connector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+// SetupNormalizedTable creates the Hudi table, files written as avro or parquet need no setup
+func (c *S3Connector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	sourceTableSchema *protos.TableSchema,
+) (bool, error) {
+	if c.fileFormat != protos.S3FileFormat_S3_HUDI {
+		return false, nil
+	}
+
+	var precombineField string
+	for _, tableMapping := range config.TableMappings {
+		if tableMapping.DestinationTableIdentifier == destinationTableIdentifier {
+			precombineField = tableMapping.PrecombineField
+		}
+	}
+	if precombineField != "" && !slices.ContainsFunc(sourceTableSchema.Columns, func(column *protos.FieldDescription) bool {
+		return column.Name == precombineField
+	}) {
+		return false, fmt.Errorf("precombine field %s is not a column of %s", precombineField, destinationTableIdentifier)
+	}
+
+	_, existed, err := c.ensureHudiTable(ctx, destinationTableIdentifier, sourceTableSchema.PrimaryKeyColumns, precombineField)
+	return existed, err
+}
+
+func (c *S3Connector) ensureHudiTable(
+	ctx context.Context,
+	table string,
+	keyColumns []string,
+	precombineField string,
+) (*hudiTable, bool, error) {
+	bucket, path, err := c.hudiTableLocation(table)
+	if err != nil {
+		return nil, false, err
+	}
+	t, err := c.loadHudiTable(ctx, bucket, path)
+	if err != nil {
+		return nil, false, err
+	} else if t != nil {
+		return t, true, nil
+	}
+
+	if len(keyColumns) == 0 {
+		return nil, false, fmt.Errorf("hudi table %s needs record key columns, the source table has no primary key", table)
+	}
+	if precombineField == "" {
+		precombineField = hudiVersionColName
+	}
+	t = &hudiTable{
+		bucket:          bucket,
+		path:            path,
+		name:            hudiTableName(table),
+		tableType:       c.hudiTableType,
+		recordKeyFields: keyColumns,
+		precombineField: precombineField,
+	}
+	if err := c.createHudiTable(ctx, t); err != nil {
+		return nil, false, err
+	}
+	c.logger.Info("created hudi table", slog.String("table", table), slog.String("path", path))
+	return t, false, nil
+}
+
+// syncQRepRecordsToHudi writes a partition as a new file group of the table
+func (c *S3Connector) syncQRepRecordsToHudi(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	stream *model.QRecordStream,
+) (int64, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	var keyColumns []string
+	if config.WriteMode != nil {
+		keyColumns = config.WriteMode.UpsertKeyColumns
+	}
+	t, _, err := c.ensureHudiTable(ctx, config.DestinationTableIdentifier, keyColumns, "")
+	if err != nil {
+		return 0, err
+	}
+
+	dataFields := hudiDataFields(schema.Fields)
+	fieldIndex := make(map[string]int, len(schema.Fields))
+	for idx, field := range schema.Fields {
+		fieldIndex[field.Name] = idx
+	}
+	for _, keyField := range t.recordKeyFields {
+		if _, ok := fieldIndex[keyField]; !ok {
+			return 0, fmt.Errorf("record key column %s is not part of %s", keyField, config.DestinationTableIdentifier)
+		}
+	}
+	avroSchema, err := hudiAvroSchema(t.name, dataFields)
+	if err != nil {
+		return 0, err
+	}
+	compression, err := ParquetCompression(c.codec)
+	if err != nil {
+		return 0, err
+	}
+
+	instant, err := c.startHudiInstant(ctx, t)
+	if err != nil {
+		return 0, err
+	}
+	fileID := uuid.NewString() + "-0"
+	fileName := hudiBaseFileName(fileID, instant)
+	var numRecords int64
+	size, err := c.uploadStream(ctx, config.Env, t.bucket, t.key(fileName), func(w io.Writer) error {
+		hw, err := newHudiFileWriter(w, hudiArrowSchema(dataFields), compression, instant, fileName)
+		if err != nil {
+			return err
+		}
+		for record := range stream.Records {
+			values := make([]types.QValue, 0, len(dataFields))
+			values = append(values, record...)
+			values = append(values, types.QValueInt64{Val: 0}, types.QValueBoolean{Val: false})
+			if err := hw.writeRow(&hudiRow{
+				key: hudiRecordKey(t.recordKeyFields, func(field string) types.QValue {
+					return record[fieldIndex[field]]
+				}),
+				values: values,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := stream.Err(); err != nil {
+			return err
+		}
+		numRecords = hw.numRows
+		return hw.close()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.completeHudiInstant(ctx, t, instant, "BULK_INSERT", avroSchema, []*hudiWriteStat{{
+		FileID:          fileID,
+		Path:            fileName,
+		PrevCommit:      "null",
+		NumWrites:       numRecords,
+		NumInserts:      numRecords,
+		TotalWriteBytes: size,
+		FileSizeInBytes: size,
+	}}); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
+
+// syncRecordsToHudi upserts the changes of a batch into the Hudi table of each destination table
+func (c *S3Connector) syncRecordsToHudi(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	batches := make(map[string]*hudiBatch)
+	var tables []string
+	var numRecords int64
+	for record := range req.Records.GetRecords() {
+		var items model.RecordItems
+		var oldItems model.RecordItems
+		deleted := false
+		switch r := record.(type) {
+		case *model.InsertRecord[model.RecordItems]:
+			items = r.Items
+		case *model.UpdateRecord[model.RecordItems]:
+			items = r.NewItems
+			oldItems = r.OldItems
+		case *model.DeleteRecord[model.RecordItems]:
+			items = r.Items
+			deleted = true
+		default:
+			continue
+		}
+		record.PopulateCountMap(tableNameRowsMapping)
+		numRecords += 1
+
+		destinationTableName := record.GetDestinationTableName()
+		batch, ok := batches[destinationTableName]
+		if !ok {
+			tableSchema, ok := req.TableNameSchemaMapping[destinationTableName]
+			if !ok {
+				return nil, fmt.Errorf("no schema for %s", destinationTableName)
+			}
+			t, _, err := c.ensureHudiTable(ctx, destinationTableName, tableSchema.PrimaryKeyColumns, "")
+			if err != nil {
+				return nil, err
+			}
+			batch = newHudiBatch(t, tableSchema)
+			batches[destinationTableName] = batch
+			tables = append(tables, destinationTableName)
+		}
+
+		version := time.Now().UnixNano()
+		// a changed primary key moves the row, deleting it at the old key
+		if oldItems.ColToVal != nil {
+			if oldKey, ok := batch.recordKey(oldItems); ok {
+				if newKey, _ := batch.recordKey(items); oldKey != newKey {
+					batch.add(batch.row(oldKey, oldItems, version, true))
+				}
+			}
+		}
+		key, _ := batch.recordKey(items)
+		// unchanged toast columns are not part of updates and end up null
+		batch.add(batch.row(key, items, version, deleted))
+	}
+
+	for _, table := range tables {
+		if err := c.upsertHudi(ctx, req.Env, batches[table]); err != nil {
+			return nil, fmt.Errorf("failed to upsert into hudi table %s: %w", table, err)
+		}
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, err
+	}
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   req.SyncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+// hudiBatch holds the latest change to every row of a table within a batch
+type hudiBatch struct {
+	table           *hudiTable
+	dataFields      []types.QField
+	rows            map[string]*hudiRow
+	keys            []string
+	precombineIndex int
+}
+
+func newHudiBatch(t *hudiTable, tableSchema *protos.TableSchema) *hudiBatch {
+	fields := make([]types.QField, 0, len(tableSchema.Columns))
+	for _, column := range tableSchema.Columns {
+		fields = append(fields, types.QField{Name: column.Name, Type: types.QValueKind(column.Type), Nullable: true})
+	}
+	dataFields := hudiDataFields(fields)
+	precombineIndex := slices.IndexFunc(dataFields, func(field types.QField) bool {
+		return field.Name == t.precombineField
+	})
+	if precombineIndex == -1 {
+		precombineIndex = len(dataFields) - 2
+	}
+	return &hudiBatch{
+		table:           t,
+		dataFields:      dataFields,
+		rows:            make(map[string]*hudiRow),
+		precombineIndex: precombineIndex,
+	}
+}
+
+func (b *hudiBatch) recordKey(items model.RecordItems) (string, bool) {
+	hasKey := true
+	key := hudiRecordKey(b.table.recordKeyFields, func(field string) types.QValue {
+		qv := items.GetColumnValue(field)
+		if qv == nil {
+			hasKey = false
+		}
+		return qv
+	})
+	return key, hasKey
+}
+
+func (b *hudiBatch) row(key string, items model.RecordItems, version int64, deleted bool) *hudiRow {
+	values := make([]types.QValue, 0, len(b.dataFields))
+	for _, field := range b.dataFields {
+		switch field.Name {
+		case hudiVersionColName:
+			values = append(values, types.QValueInt64{Val: version})
+		case hudiIsDeletedColName:
+			values = append(values, types.QValueBoolean{Val: deleted})
+		default:
+			if qv := items.GetColumnValue(field.Name); qv != nil {
+				values = append(values, qv)
+			} else {
+				values = append(values, types.QValueNull(field.Type))
+			}
+		}
+	}
+	return &hudiRow{key: key, values: values, deleted: deleted}
+}
+
+// add keeps the later of two upserts to a row by the precombine field, deletes and changes after deletes always win
+func (b *hudiBatch) add(row *hudiRow) {
+	existing, ok := b.rows[row.key]
+	if !ok {
+		b.keys = append(b.keys, row.key)
+	} else if !existing.deleted && !row.deleted && compareHudiPrecombine(
+		row.values[b.precombineIndex].Value(), existing.values[b.precombineIndex].Value(),
+	) < 0 {
+		return
+	}
+	b.rows[row.key] = row
+}
+
+// upsertHudi finds the file groups holding the rows of a batch through the record keys of the latest base files.
+// Copy on write tables get new versions of those file groups, merge on read tables get log files with the changes.
+// Inserts go into a new file group or, for copy on write tables, into the smallest file group being rewritten anyway.
+func (c *S3Connector) upsertHudi(ctx context.Context, env map[string]string, batch *hudiBatch) error {
+	t := batch.table
+	fileSlices, err := c.latestFileSlices(ctx, t)
+	if err != nil {
+		return err
+	}
+	located, err := c.locateHudiKeys(ctx, t, fileSlices, batch.rows)
+	if err != nil {
+		return err
+	}
+	var inserts []*hudiRow
+	for _, key := range batch.keys {
+		if _, ok := located[key]; !ok && !batch.rows[key].deleted {
+			inserts = append(inserts, batch.rows[key])
+		}
+	}
+	changedFileIDs := make(map[string][]*hudiRow)
+	for _, key := range batch.keys {
+		if fileID, ok := located[key]; ok {
+			changedFileIDs[fileID] = append(changedFileIDs[fileID], batch.rows[key])
+		}
+	}
+
+	avroSchema, err := hudiAvroSchema(t.name, batch.dataFields)
+	if err != nil {
+		return err
+	}
+	compression, err := ParquetCompression(c.codec)
+	if err != nil {
+		return err
+	}
+	schema := hudiArrowSchema(batch.dataFields)
+	if len(changedFileIDs) == 0 && len(inserts) == 0 {
+		return nil
+	}
+	instant, err := c.startHudiInstant(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	var writeStats []*hudiWriteStat
+	var replacedBaseFiles []string
+	if t.tableType == protos.HudiTableType_HUDI_COPY_ON_WRITE {
+		var smallFileID string
+		for fileID, fileSlice := range fileSlices {
+			if fileSlice.size < hudiSmallFileLimit && (smallFileID == "" || fileSlice.size < fileSlices[smallFileID].size) {
+				smallFileID = fileID
+			}
+		}
+		if len(inserts) > 0 && smallFileID != "" {
+			if _, ok := changedFileIDs[smallFileID]; !ok {
+				changedFileIDs[smallFileID] = nil
+			}
+		}
+		for _, fileID := range slices.Sorted(maps.Keys(changedFileIDs)) {
+			var fileInserts []*hudiRow
+			if fileID == smallFileID {
+				fileInserts = inserts
+				inserts = nil
+			}
+			writeStat, err := c.rewriteHudiFileGroup(
+				ctx, env, t, schema, compression, instant, fileSlices[fileID], changedFileIDs[fileID], fileInserts)
+			if err != nil {
+				return err
+			}
+			writeStats = append(writeStats, writeStat)
+			replacedBaseFiles = append(replacedBaseFiles, fileSlices[fileID].olderBaseFiles...)
+		}
+	} else {
+		for _, fileID := range slices.Sorted(maps.Keys(changedFileIDs)) {
+			writeStat, err := c.appendHudiLogFile(
+				ctx, t, schema, compression, instant, avroSchema, fileSlices[fileID], changedFileIDs[fileID])
+			if err != nil {
+				return err
+			}
+			writeStats = append(writeStats, writeStat)
+		}
+	}
+	if len(inserts) > 0 {
+		writeStat, err := c.rewriteHudiFileGroup(ctx, env, t, schema, compression, instant, nil, nil, inserts)
+		if err != nil {
+			return err
+		}
+		writeStats = append(writeStats, writeStat)
+	}
+
+	if err := c.completeHudiInstant(ctx, t, instant, "UPSERT", avroSchema, writeStats); err != nil {
+		return err
+	}
+	// like the cleaner keeping two file versions, readers still on the previous version are not affected
+	return c.deleteHudiFiles(ctx, t, replacedBaseFiles)
+}
+
+// locateHudiKeys maps the keys of rows to the file groups holding them
+func (c *S3Connector) locateHudiKeys(
+	ctx context.Context,
+	t *hudiTable,
+	fileSlices map[string]*hudiFileSlice,
+	rows map[string]*hudiRow,
+) (map[string]string, error) {
+	located := make(map[string]string)
+	for _, fileSlice := range fileSlices {
+		if len(located) == len(rows) {
+			break
+		}
+		if err := func() error {
+			fr, err := c.openHudiBaseFile(ctx, t, fileSlice)
+			if err != nil {
+				return err
+			}
+			defer fr.ParquetReader().Close()
+			keyColumn := fr.ParquetReader().MetaData().Schema.ColumnIndexByName(hudiRecordKeyColName)
+			if keyColumn == -1 {
+				return fmt.Errorf("base file %s has no record keys", fileSlice.baseFile)
+			}
+			rr, err := fr.GetRecordReader(ctx, []int{keyColumn}, nil)
+			if err != nil {
+				return err
+			}
+			defer rr.Release()
+			for rr.Next() {
+				keys, ok := rr.Record().Column(0).(*array.String)
+				if !ok {
+					return fmt.Errorf("unexpected record keys in base file %s", fileSlice.baseFile)
+				}
+				for idx := range keys.Len() {
+					if _, ok := rows[keys.Value(idx)]; ok {
+						located[keys.Value(idx)] = fileSlice.fileID
+					}
+				}
+			}
+			return rr.Err()
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	return located, nil
+}
+
+// rewriteHudiFileGroup writes a new version of a file group with changed rows replaced and inserts added,
+// a new file group is created when fileSlice is nil
+func (c *S3Connector) rewriteHudiFileGroup(
+	ctx context.Context,
+	env map[string]string,
+	t *hudiTable,
+	schema *arrow.Schema,
+	compression compress.Compression,
+	instant string,
+	fileSlice *hudiFileSlice,
+	changes []*hudiRow,
+	inserts []*hudiRow,
+) (*hudiWriteStat, error) {
+	writeStat := &hudiWriteStat{PrevCommit: "null"}
+	if fileSlice != nil {
+		writeStat.FileID = fileSlice.fileID
+		writeStat.PrevCommit = fileSlice.baseInstant
+	} else {
+		writeStat.FileID = uuid.NewString() + "-0"
+	}
+	fileName := hudiBaseFileName(writeStat.FileID, instant)
+	writeStat.Path = fileName
+
+	changed := make(map[string]*hudiRow, len(changes))
+	for _, row := range changes {
+		changed[row.key] = row
+	}
+	size, err := c.uploadStream(ctx, env, t.bucket, t.key(fileName), func(w io.Writer) error {
+		hw, err := newHudiFileWriter(w, schema, compression, instant, fileName)
+		if err != nil {
+			return err
+		}
+		if fileSlice != nil {
+			if err := c.copyHudiBaseFile(ctx, t, fileSlice, hw, changed); err != nil {
+				return err
+			}
+		}
+		for _, row := range changes {
+			if row.deleted {
+				writeStat.NumDeletes += 1
+				continue
+			}
+			writeStat.NumUpdateWrites += 1
+			if err := hw.writeRow(row); err != nil {
+				return err
+			}
+		}
+		for _, row := range inserts {
+			writeStat.NumInserts += 1
+			if err := hw.writeRow(row); err != nil {
+				return err
+			}
+		}
+		writeStat.NumWrites = hw.numRows
+		return hw.close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeStat.TotalWriteBytes = size
+	writeStat.FileSizeInBytes = size
+	return writeStat, nil
+}
+
+// copyHudiBaseFile copies the rows of a base file which are not changed
+func (c *S3Connector) copyHudiBaseFile(
+	ctx context.Context,
+	t *hudiTable,
+	fileSlice *hudiFileSlice,
+	hw *hudiFileWriter,
+	changed map[string]*hudiRow,
+) error {
+	fr, err := c.openHudiBaseFile(ctx, t, fileSlice)
+	if err != nil {
+		return err
+	}
+	defer fr.ParquetReader().Close()
+	rr, err := fr.GetRecordReader(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rr.Release()
+	keyColumns := rr.Schema().FieldIndices(hudiRecordKeyColName)
+	if len(keyColumns) == 0 {
+		return fmt.Errorf("base file %s has no record keys", fileSlice.baseFile)
+	}
+	for rr.Next() {
+		record := rr.Record()
+		keys, ok := record.Column(keyColumns[0]).(*array.String)
+		if !ok {
+			return fmt.Errorf("unexpected record keys in base file %s", fileSlice.baseFile)
+		}
+		start := 0
+		for idx := range keys.Len() {
+			if _, ok := changed[keys.Value(idx)]; !ok {
+				continue
+			}
+			if idx > start {
+				if err := writeHudiSlice(hw, record, start, idx); err != nil {
+					return err
+				}
+			}
+			start = idx + 1
+		}
+		if start < keys.Len() {
+			if err := writeHudiSlice(hw, record, start, keys.Len()); err != nil {
+				return err
+			}
+		}
+	}
+	return rr.Err()
+}
+
+func writeHudiSlice(hw *hudiFileWriter, record arrow.Record, start int, end int) error {
+	slice := record.NewSlice(int64(start), int64(end))
+	defer slice.Release()
+	return hw.writeRecord(slice)
+}
+
+// appendHudiLogFile writes the changes to a file group of a merge on read table as a new log file
+func (c *S3Connector) appendHudiLogFile(
+	ctx context.Context,
+	t *hudiTable,
+	schema *arrow.Schema,
+	compression compress.Compression,
+	instant string,
+	avroSchema string,
+	fileSlice *hudiFileSlice,
+	changes []*hudiRow,
+) (*hudiWriteStat, error) {
+	version, err := c.nextHudiLogVersion(ctx, t, fileSlice)
+	if err != nil {
+		return nil, err
+	}
+	logFileName := hudiLogFileName(fileSlice.fileID, fileSlice.baseInstant, version)
+	writeStat := &hudiWriteStat{
+		FileID:     fileSlice.fileID,
+		Path:       logFileName,
+		PrevCommit: fileSlice.baseInstant,
+		BaseFile:   fileSlice.baseFile,
+		LogFiles:   []string{logFileName},
+		LogVersion: version,
+	}
+
+	var content bytes.Buffer
+	hw, err := newHudiFileWriter(&content, schema, compression, instant, fileSlice.fileID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range changes {
+		if row.deleted {
+			writeStat.NumDeletes += 1
+		} else {
+			writeStat.NumUpdateWrites += 1
+		}
+		if err := hw.writeRow(row); err != nil {
+			return nil, err
+		}
+	}
+	writeStat.NumWrites = hw.numRows
+	if err := hw.close(); err != nil {
+		return nil, err
+	}
+
+	logFile := encodeHudiLogBlock(instant, avroSchema, content.Bytes())
+	if _, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.key(logFileName)),
+		Body:   bytes.NewReader(logFile),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write log file %s: %w", logFileName, err)
+	}
+	writeStat.TotalWriteBytes = int64(len(logFile))
+	writeStat.FileSizeInBytes = int64(len(logFile))
+	return writeStat, nil
+}
@@ -0,0 +1,148 @@
+package conns3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestHudiProperties(t *testing.T) {
+	table := &hudiTable{
+		name:            hudiTableName("public.users"),
+		tableType:       protos.HudiTableType_HUDI_MERGE_ON_READ,
+		recordKeyFields: []string{"id", "tenant"},
+		precombineField: "updated_at",
+	}
+	require.Equal(t, "public_users", table.name)
+	require.Equal(t, "deltacommit", table.commitAction())
+
+	var buf bytes.Buffer
+	for _, prop := range table.properties() {
+		buf.WriteString(prop[0] + "=" + escapeHudiProperty(prop[1]) + "\n")
+	}
+	buf.WriteString("hoodie.test=a\\=b\\:c\n")
+	props, err := parseHudiProperties(&buf)
+	require.NoError(t, err)
+	require.Equal(t, "MERGE_ON_READ", props["hoodie.table.type"])
+	require.Equal(t, "id,tenant", props["hoodie.table.recordkey.fields"])
+	require.Equal(t, "updated_at", props["hoodie.table.precombine.field"])
+	require.Equal(t, "org.apache.hudi.keygen.NonpartitionedKeyGenerator", props["hoodie.table.keygenerator.class"])
+	require.Equal(t, "a=b:c", props["hoodie.test"])
+}
+
+func TestHudiRecordKey(t *testing.T) {
+	values := map[string]types.QValue{
+		"id":     types.QValueInt64{Val: 7},
+		"tenant": types.QValueString{Val: ""},
+	}
+	value := func(field string) types.QValue { return values[field] }
+	require.Equal(t, "7", hudiRecordKey([]string{"id"}, value))
+	require.Equal(t, "id:7,tenant:__empty__,missing:__null__", hudiRecordKey([]string{"id", "tenant", "missing"}, value))
+}
+
+func TestHudiBatch(t *testing.T) {
+	batch := newHudiBatch(&hudiTable{recordKeyFields: []string{"id"}, precombineField: "seq"}, &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "seq", Type: string(types.QValueKindInt32)},
+		},
+	})
+	items := func(id int64, seq int32) model.RecordItems {
+		items := model.NewRecordItems(2)
+		items.AddColumn("id", types.QValueInt64{Val: id})
+		items.AddColumn("seq", types.QValueInt32{Val: seq})
+		return items
+	}
+	add := func(id int64, seq int32, deleted bool) {
+		key, ok := batch.recordKey(items(id, seq))
+		require.True(t, ok)
+		batch.add(batch.row(key, items(id, seq), 0, deleted))
+	}
+
+	add(1, 5, false)
+	add(1, 3, false)
+	require.Equal(t, types.QValueInt32{Val: 5}, batch.rows["1"].values[1])
+	add(1, 1, true)
+	require.True(t, batch.rows["1"].deleted)
+	add(1, 0, false)
+	require.False(t, batch.rows["1"].deleted)
+	add(2, 1, false)
+	require.Equal(t, []string{"1", "2"}, batch.keys)
+	require.Len(t, batch.rows["2"].values, 4)
+}
+
+func TestHudiFileWriter(t *testing.T) {
+	oldFields := hudiDataFields([]types.QField{{Name: "id", Type: types.QValueKindInt64}})
+	newFields := hudiDataFields([]types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "name", Type: types.QValueKindString},
+	})
+
+	var old bytes.Buffer
+	hw, err := newHudiFileWriter(&old, hudiArrowSchema(oldFields), compress.Codecs.Snappy, "20240101000000000", "f1")
+	require.NoError(t, err)
+	for id := range int64(3) {
+		require.NoError(t, hw.writeRow(&hudiRow{
+			key:    string(rune('0' + id)),
+			values: []types.QValue{types.QValueInt64{Val: id}, types.QValueInt64{Val: 0}, types.QValueBoolean{Val: false}},
+		}))
+	}
+	require.NoError(t, hw.close())
+
+	oldTable, err := pqarrow.ReadTable(t.Context(), bytes.NewReader(old.Bytes()), nil, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	defer oldTable.Release()
+	reader := array.NewTableReader(oldTable, 0)
+	defer reader.Release()
+	require.True(t, reader.Next())
+
+	var rewritten bytes.Buffer
+	hw, err = newHudiFileWriter(&rewritten, hudiArrowSchema(newFields), compress.Codecs.Snappy, "20240102000000000", "f2")
+	require.NoError(t, err)
+	require.NoError(t, writeHudiSlice(hw, reader.Record(), 0, 1))
+	require.NoError(t, writeHudiSlice(hw, reader.Record(), 2, 3))
+	require.NoError(t, hw.writeRow(&hudiRow{
+		key: "1",
+		values: []types.QValue{
+			types.QValueInt64{Val: 1}, types.QValueString{Val: "one"}, types.QValueInt64{Val: 1}, types.QValueBoolean{Val: false},
+		},
+	}))
+	require.NoError(t, hw.close())
+	require.Equal(t, int64(3), hw.numRows)
+
+	table, err := pqarrow.ReadTable(t.Context(), bytes.NewReader(rewritten.Bytes()), nil, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	defer table.Release()
+	require.Equal(t, int64(3), table.NumRows())
+	keys := table.Column(2).Data().Chunk(0).(*array.String)
+	require.Equal(t, []string{"0", "2", "1"}, []string{keys.Value(0), keys.Value(1), keys.Value(2)})
+	names := table.Column(len(hudiMetaColumns) + 1).Data().Chunk(0).(*array.String)
+	require.True(t, names.IsNull(0))
+	require.Equal(t, "one", names.Value(2))
+	commitTimes := table.Column(0).Data().Chunk(0).(*array.String)
+	require.Equal(t, "20240101000000000", commitTimes.Value(0))
+	require.Equal(t, "20240102000000000", commitTimes.Value(2))
+}
+
+func TestEncodeHudiLogBlock(t *testing.T) {
+	content := []byte("parquet")
+	block := encodeHudiLogBlock("20240101000000000", `{"type":"record"}`, content)
+	require.True(t, strings.HasPrefix(string(block), hudiLogMagic))
+	blockSize := int64(binary.BigEndian.Uint64(block[len(hudiLogMagic):]))
+	require.Equal(t, int64(len(block)-len(hudiLogMagic)-8), blockSize)
+	require.Equal(t, uint32(hudiLogFormatVersion), binary.BigEndian.Uint32(block[len(hudiLogMagic)+8:]))
+	require.Equal(t, uint32(hudiParquetDataBlockType), binary.BigEndian.Uint32(block[len(hudiLogMagic)+12:]))
+	require.Equal(t, int64(len(block)-8), int64(binary.BigEndian.Uint64(block[len(block)-8:])))
+	require.Contains(t, string(block), string(content))
+}
@@ -0,0 +1,412 @@
+package conns3
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// log block format of HoodieLogFormat version 1
+const (
+	hudiLogMagic              = "#HUDI#"
+	hudiLogFormatVersion      = 1
+	hudiParquetDataBlockType  = 5
+	hudiInstantTimeHeader     = 0
+	hudiSchemaHeader          = 2
+	hudiNullKeyPlaceholder    = "__null__"
+	hudiEmptyKeyPlaceholder   = "__empty__"
+	hudiCompositeKeySeparator = ","
+)
+
+type hudiRow struct {
+	key string
+	// aligned with the data fields of the table
+	values  []types.QValue
+	deleted bool
+}
+
+// hudiDataFields adds the version and delete marker columns to the columns of a table
+func hudiDataFields(fields []types.QField) []types.QField {
+	dataFields := make([]types.QField, 0, len(fields)+2)
+	for _, field := range fields {
+		if field.Name != hudiVersionColName && field.Name != hudiIsDeletedColName {
+			dataFields = append(dataFields, field)
+		}
+	}
+	return append(dataFields,
+		types.QField{Name: hudiVersionColName, Type: types.QValueKindInt64},
+		types.QField{Name: hudiIsDeletedColName, Type: types.QValueKindBoolean},
+	)
+}
+
+func hudiArrowSchema(dataFields []types.QField) *arrow.Schema {
+	arrowFields := make([]arrow.Field, 0, len(hudiMetaColumns)+len(dataFields))
+	for _, column := range hudiMetaColumns {
+		arrowFields = append(arrowFields, arrow.Field{Name: column, Type: arrow.BinaryTypes.String, Nullable: true})
+	}
+	for _, field := range dataFields {
+		arrowFields = append(arrowFields, arrow.Field{Name: field.Name, Type: parquetDataType(field.Type), Nullable: true})
+	}
+	return arrow.NewSchema(arrowFields, nil)
+}
+
+type hudiAvroField struct {
+	Type    any    `json:"type"`
+	Default any    `json:"default"`
+	Name    string `json:"name"`
+}
+
+// hudiAvroSchema is the table schema Hudi readers pick up from commit metadata,
+// types follow how parquetDataType writes each kind
+func hudiAvroSchema(tableName string, dataFields []types.QField) (string, error) {
+	avroFields := make([]hudiAvroField, 0, len(hudiMetaColumns)+len(dataFields))
+	for _, column := range hudiMetaColumns {
+		avroFields = append(avroFields, hudiAvroField{Name: column, Type: []any{"null", "string"}})
+	}
+	for _, field := range dataFields {
+		var avroType any
+		switch parquetDataType(field.Type).ID() {
+		case arrow.BOOL:
+			avroType = "boolean"
+		case arrow.INT32:
+			avroType = "int"
+		case arrow.INT64, arrow.UINT64:
+			avroType = "long"
+		case arrow.FLOAT32:
+			avroType = "float"
+		case arrow.FLOAT64:
+			avroType = "double"
+		case arrow.TIMESTAMP:
+			avroType = map[string]string{"type": "long", "logicalType": "timestamp-micros"}
+		case arrow.DATE32:
+			avroType = map[string]string{"type": "int", "logicalType": "date"}
+		case arrow.TIME64:
+			avroType = map[string]string{"type": "long", "logicalType": "time-micros"}
+		case arrow.BINARY:
+			avroType = "bytes"
+		default:
+			avroType = "string"
+		}
+		avroFields = append(avroFields, hudiAvroField{Name: field.Name, Type: []any{"null", avroType}})
+	}
+	schema, err := json.Marshal(map[string]any{
+		"type":      "record",
+		"name":      tableName + "_record",
+		"namespace": "hoodie." + tableName,
+		"fields":    avroFields,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build hudi avro schema: %w", err)
+	}
+	return string(schema), nil
+}
+
+// hudiRecordKey formats a record key like Hudi key generators do,
+// the value alone for a single field and field:value pairs for composite keys
+func hudiRecordKey(keyFields []string, value func(string) types.QValue) string {
+	keyValue := func(field string) string {
+		qv := value(field)
+		if qv == nil || qv.Value() == nil {
+			return hudiNullKeyPlaceholder
+		}
+		if s := fmt.Sprint(qv.Value()); s != "" {
+			return s
+		}
+		return hudiEmptyKeyPlaceholder
+	}
+	if len(keyFields) == 1 {
+		return keyValue(keyFields[0])
+	}
+	parts := make([]string, 0, len(keyFields))
+	for _, field := range keyFields {
+		parts = append(parts, field+":"+keyValue(field))
+	}
+	return strings.Join(parts, hudiCompositeKeySeparator)
+}
+
+// compareHudiPrecombine orders two values of the precombine field, values which cannot be compared are equal
+func compareHudiPrecombine(a any, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == b:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch av := a.(type) {
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Compare(bv)
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv)
+		}
+	case decimal.Decimal:
+		if bv, ok := b.(decimal.Decimal); ok {
+			return av.Cmp(bv)
+		}
+	case float32:
+		if bv, ok := b.(float32); ok {
+			return cmp.Compare(av, bv)
+		}
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return cmp.Compare(av, bv)
+		}
+	case uint64:
+		if bv, ok := b.(uint64); ok {
+			return cmp.Compare(av, bv)
+		}
+	}
+	if ai, ok := hudiPrecombineInt(a); ok {
+		if bi, ok := hudiPrecombineInt(b); ok {
+			return cmp.Compare(ai, bi)
+		}
+	}
+	return 0
+}
+
+func hudiPrecombineInt(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// hudiFileWriter writes a parquet file with Hudi meta columns,
+// taking both rows read from an earlier version of the file group and new rows
+type hudiFileWriter struct {
+	fw       *pqarrow.FileWriter
+	builder  *array.RecordBuilder
+	schema   *arrow.Schema
+	instant  string
+	fileName string
+	numRows  int64
+	buffered int
+}
+
+func newHudiFileWriter(
+	w io.Writer,
+	schema *arrow.Schema,
+	compression compress.Compression,
+	instant string,
+	fileName string,
+) (*hudiFileWriter, error) {
+	fw, err := pqarrow.NewFileWriter(schema, w,
+		parquet.NewWriterProperties(parquet.WithCompression(compression), parquet.WithMaxRowGroupLength(parquetRowGroupSize)),
+		pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	return &hudiFileWriter{
+		fw:       fw,
+		builder:  array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		schema:   schema,
+		instant:  instant,
+		fileName: fileName,
+	}, nil
+}
+
+func (hw *hudiFileWriter) flush() error {
+	if hw.buffered == 0 {
+		return nil
+	}
+	record := hw.builder.NewRecord()
+	defer record.Release()
+	hw.buffered = 0
+	return hw.fw.WriteBuffered(record)
+}
+
+// writeRecord copies rows of an existing file as they are, columns added since are null
+func (hw *hudiFileWriter) writeRecord(record arrow.Record) error {
+	if err := hw.flush(); err != nil {
+		return err
+	}
+	columns := make([]arrow.Array, 0, hw.schema.NumFields())
+	defer func() {
+		for _, column := range columns {
+			column.Release()
+		}
+	}()
+	for _, field := range hw.schema.Fields() {
+		if indices := record.Schema().FieldIndices(field.Name); len(indices) > 0 &&
+			arrow.TypeEqual(record.Column(indices[0]).DataType(), field.Type) {
+			column := record.Column(indices[0])
+			column.Retain()
+			columns = append(columns, column)
+		} else {
+			columns = append(columns, array.MakeArrayOfNull(memory.DefaultAllocator, field.Type, int(record.NumRows())))
+		}
+	}
+	projected := array.NewRecord(hw.schema, columns, record.NumRows())
+	defer projected.Release()
+	hw.numRows += record.NumRows()
+	return hw.fw.WriteBuffered(projected)
+}
+
+func (hw *hudiFileWriter) writeRow(row *hudiRow) error {
+	metaValues := []string{
+		hw.instant, fmt.Sprintf("%s_0_%d", hw.instant, hw.numRows), row.key, "", hw.fileName,
+	}
+	for idx, value := range metaValues {
+		hw.builder.Field(idx).(*array.StringBuilder).Append(value)
+	}
+	for idx, value := range row.values {
+		if err := appendParquetValue(hw.builder.Field(len(hudiMetaColumns)+idx), value.Value()); err != nil {
+			return fmt.Errorf("failed to convert column %s: %w", hw.schema.Field(len(hudiMetaColumns)+idx).Name, err)
+		}
+	}
+	hw.numRows += 1
+	hw.buffered += 1
+	if hw.buffered == parquetRowGroupSize {
+		return hw.flush()
+	}
+	return nil
+}
+
+func (hw *hudiFileWriter) close() error {
+	defer hw.builder.Release()
+	if err := hw.flush(); err != nil {
+		return err
+	}
+	if err := hw.fw.Close(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return nil
+}
+
+func writeHudiLogMetadata(buf *bytes.Buffer, metadata map[int32]string) {
+	_ = binary.Write(buf, binary.BigEndian, int32(len(metadata)))
+	for key, value := range metadata {
+		_ = binary.Write(buf, binary.BigEndian, key)
+		_ = binary.Write(buf, binary.BigEndian, int32(len(value)))
+		buf.WriteString(value)
+	}
+}
+
+// encodeHudiLogBlock wraps a parquet file into a log file holding a single parquet data block
+func encodeHudiLogBlock(instant string, avroSchema string, content []byte) []byte {
+	var header, footer bytes.Buffer
+	writeHudiLogMetadata(&header, map[int32]string{hudiInstantTimeHeader: instant, hudiSchemaHeader: avroSchema})
+	writeHudiLogMetadata(&footer, nil)
+
+	var buf bytes.Buffer
+	buf.WriteString(hudiLogMagic)
+	// block size excluding magic: version, type, header, content size, content, footer and the trailing block size
+	blockSize := 4 + 4 + header.Len() + 8 + len(content) + footer.Len() + 8
+	_ = binary.Write(&buf, binary.BigEndian, int64(blockSize))
+	_ = binary.Write(&buf, binary.BigEndian, int32(hudiLogFormatVersion))
+	_ = binary.Write(&buf, binary.BigEndian, int32(hudiParquetDataBlockType))
+	buf.Write(header.Bytes())
+	_ = binary.Write(&buf, binary.BigEndian, int64(len(content)))
+	buf.Write(content)
+	buf.Write(footer.Bytes())
+	// everything written so far, for reading the log backwards
+	_ = binary.Write(&buf, binary.BigEndian, int64(buf.Len()))
+	return buf.Bytes()
+}
+
+// s3ReaderAt reads ranges of an object, so only the footer and needed columns of parquet files are downloaded
+type s3ReaderAt struct {
+	ctx    context.Context
+	client *s3.Client
+	bucket string
+	key    string
+	size   int64
+	offset int64
+}
+
+func (r *s3ReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), r.size)
+	out, err := r.client.GetObject(r.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", off, end-1)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read s3://%s/%s: %w", r.bucket, r.key, err)
+	}
+	defer out.Body.Close()
+	n, err := io.ReadFull(out.Body, p[:end-off])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *s3ReaderAt) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (c *S3Connector) openHudiBaseFile(ctx context.Context, t *hudiTable, fileSlice *hudiFileSlice) (*pqarrow.FileReader, error) {
+	pf, err := file.NewParquetReader(&s3ReaderAt{
+		ctx:    ctx,
+		client: &c.client,
+		bucket: t.bucket,
+		key:    t.key(fileSlice.baseFile),
+		size:   fileSlice.size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open base file %s: %w", fileSlice.baseFile, err)
+	}
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: parquetRowGroupSize}, memory.DefaultAllocator)
+	if err != nil {
+		pf.Close()
+		return nil, fmt.Errorf("failed to read base file %s: %w", fileSlice.baseFile, err)
+	}
+	return fr, nil
+}
@@ -0,0 +1,356 @@
+package conns3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/extensions"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// rows per row group, also how many rows are buffered in memory before being encoded
+const parquetRowGroupSize = 1 << 16
+
+// json annotation of string columns, string storage is always supported
+var parquetJSONType, _ = extensions.NewJSONType(arrow.BinaryTypes.String)
+
+func (c *S3Connector) writeToParquetFile(
+	ctx context.Context,
+	env map[string]string,
+	stream *model.QRecordStream,
+	partitionID string,
+	jobName string,
+	codec protos.AvroCodec,
+	rowGroupSize int,
+) (int64, error) {
+	s3o, err := utils.NewS3BucketAndPrefix(c.url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse bucket path: %w", err)
+	}
+	key := fmt.Sprintf("%s/%s/%s.parquet", s3o.Prefix, jobName, partitionID)
+	compression, err := ParquetCompression(codec)
+	if err != nil {
+		return 0, err
+	}
+
+	var numRecords int64
+	if _, err := c.uploadStream(ctx, env, s3o.Bucket, key, func(w io.Writer) error {
+		var err error
+		numRecords, err = WriteParquet(w, stream, compression, rowGroupSize)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
+
+// uploadStream uploads what write writes to key while it is being written, returning the size of the object
+func (c *S3Connector) uploadStream(
+	ctx context.Context,
+	env map[string]string,
+	bucket string,
+	key string,
+	write func(io.Writer) error,
+) (int64, error) {
+	return utils.UploadS3Stream(ctx, env, &c.client, bucket, key, write)
+}
+
+// ParquetCompression maps the codec of a peer to parquet compression, Deflate is written as gzip
+func ParquetCompression(codec protos.AvroCodec) (compress.Compression, error) {
+	switch codec {
+	case protos.AvroCodec_Null:
+		return compress.Codecs.Uncompressed, nil
+	case protos.AvroCodec_Deflate:
+		return compress.Codecs.Gzip, nil
+	case protos.AvroCodec_Snappy:
+		return compress.Codecs.Snappy, nil
+	case protos.AvroCodec_ZStandard:
+		return compress.Codecs.Zstd, nil
+	default:
+		return compress.Codecs.Uncompressed, fmt.Errorf("unsupported codec %s", codec)
+	}
+}
+
+// WriteParquet encodes stream as a parquet file to w, returning the number of records written.
+// rowGroupSize of 0 or less uses the default of parquetRowGroupSize
+func WriteParquet(w io.Writer, stream *model.QRecordStream, compression compress.Compression, rowGroupSize int) (int64, error) {
+	if rowGroupSize <= 0 {
+		rowGroupSize = parquetRowGroupSize
+	}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	arrowFields := make([]arrow.Field, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		arrowFields = append(arrowFields, arrow.Field{Name: field.Name, Type: parquetFieldType(field), Nullable: true})
+	}
+	arrowSchema := arrow.NewSchema(arrowFields, nil)
+
+	fw, err := pqarrow.NewFileWriter(arrowSchema, w,
+		parquet.NewWriterProperties(parquet.WithCompression(compression), parquet.WithMaxRowGroupLength(int64(rowGroupSize))),
+		pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, arrowSchema)
+	defer builder.Release()
+
+	flush := func() error {
+		record := builder.NewRecord()
+		defer record.Release()
+		return fw.Write(record)
+	}
+
+	var numRecords int64
+	for record := range stream.Records {
+		for idx, value := range record {
+			if err := appendParquetValue(builder.Field(idx), value.Value()); err != nil {
+				return 0, fmt.Errorf("failed to convert column %s: %w", schema.Fields[idx].Name, err)
+			}
+		}
+		numRecords += 1
+		if numRecords%int64(rowGroupSize) == 0 {
+			if err := flush(); err != nil {
+				return 0, fmt.Errorf("failed to write parquet row group: %w", err)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if numRecords%int64(rowGroupSize) != 0 {
+		if err := flush(); err != nil {
+			return 0, fmt.Errorf("failed to write parquet row group: %w", err)
+		}
+	}
+	if err := fw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return numRecords, nil
+}
+
+// parquetDataType maps kinds to their closest arrow type, kinds without one are written as strings
+func parquetDataType(kind types.QValueKind) arrow.DataType {
+	switch kind {
+	case types.QValueKindBoolean:
+		return arrow.FixedWidthTypes.Boolean
+	case types.QValueKindInt8, types.QValueKindInt16, types.QValueKindInt32:
+		return arrow.PrimitiveTypes.Int32
+	case types.QValueKindInt64, types.QValueKindUInt8, types.QValueKindUInt16, types.QValueKindUInt32:
+		return arrow.PrimitiveTypes.Int64
+	case types.QValueKindUInt64:
+		return arrow.PrimitiveTypes.Uint64
+	case types.QValueKindFloat32:
+		return arrow.PrimitiveTypes.Float32
+	case types.QValueKindFloat64:
+		return arrow.PrimitiveTypes.Float64
+	case types.QValueKindTimestamp:
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	case types.QValueKindTimestampTZ:
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case types.QValueKindDate:
+		return arrow.FixedWidthTypes.Date32
+	case types.QValueKindTime:
+		return arrow.FixedWidthTypes.Time64us
+	case types.QValueKindBytes:
+		return arrow.BinaryTypes.Binary
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+// parquetFieldType refines parquetDataType for plain parquet files with exact integer widths,
+// decimals for numerics of bounded precision, and uuid & json annotations.
+// Hudi base files stay on parquetDataType so that existing tables keep their schema
+func parquetFieldType(field types.QField) arrow.DataType {
+	switch field.Type {
+	case types.QValueKindInt8:
+		return arrow.PrimitiveTypes.Int8
+	case types.QValueKindInt16:
+		return arrow.PrimitiveTypes.Int16
+	case types.QValueKindUInt8:
+		return arrow.PrimitiveTypes.Uint8
+	case types.QValueKindUInt16:
+		return arrow.PrimitiveTypes.Uint16
+	case types.QValueKindUInt32:
+		return arrow.PrimitiveTypes.Uint32
+	case types.QValueKindNumeric:
+		if field.Precision > 0 && field.Precision <= decimal128.MaxPrecision && field.Scale >= 0 && field.Scale <= field.Precision {
+			return &arrow.Decimal128Type{Precision: int32(field.Precision), Scale: int32(field.Scale)}
+		}
+	case types.QValueKindUUID:
+		return extensions.NewUUIDType()
+	case types.QValueKindJSON, types.QValueKindJSONB:
+		return parquetJSONType
+	}
+	return parquetDataType(field.Type)
+}
+
+func appendParquetValue(builder array.Builder, value any) error {
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+	switch b := builder.(type) {
+	case *array.BooleanBuilder:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool, got %T", value)
+		}
+		b.Append(v)
+	case *array.Int8Builder:
+		v, ok := value.(int8)
+		if !ok {
+			return fmt.Errorf("expected int8, got %T", value)
+		}
+		b.Append(v)
+	case *array.Int16Builder:
+		v, ok := value.(int16)
+		if !ok {
+			return fmt.Errorf("expected int16, got %T", value)
+		}
+		b.Append(v)
+	case *array.Uint8Builder:
+		v, ok := value.(uint8)
+		if !ok {
+			return fmt.Errorf("expected uint8, got %T", value)
+		}
+		b.Append(v)
+	case *array.Uint16Builder:
+		v, ok := value.(uint16)
+		if !ok {
+			return fmt.Errorf("expected uint16, got %T", value)
+		}
+		b.Append(v)
+	case *array.Uint32Builder:
+		v, ok := value.(uint32)
+		if !ok {
+			return fmt.Errorf("expected uint32, got %T", value)
+		}
+		b.Append(v)
+	case *array.Int32Builder:
+		switch v := value.(type) {
+		case int8:
+			b.Append(int32(v))
+		case int16:
+			b.Append(int32(v))
+		case int32:
+			b.Append(v)
+		default:
+			return fmt.Errorf("expected int32, got %T", value)
+		}
+	case *array.Int64Builder:
+		switch v := value.(type) {
+		case int64:
+			b.Append(v)
+		case uint8:
+			b.Append(int64(v))
+		case uint16:
+			b.Append(int64(v))
+		case uint32:
+			b.Append(int64(v))
+		default:
+			return fmt.Errorf("expected int64, got %T", value)
+		}
+	case *array.Uint64Builder:
+		v, ok := value.(uint64)
+		if !ok {
+			return fmt.Errorf("expected uint64, got %T", value)
+		}
+		b.Append(v)
+	case *array.Float32Builder:
+		v, ok := value.(float32)
+		if !ok {
+			return fmt.Errorf("expected float32, got %T", value)
+		}
+		b.Append(v)
+	case *array.Float64Builder:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("expected float64, got %T", value)
+		}
+		b.Append(v)
+	case *array.TimestampBuilder:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("expected time, got %T", value)
+		}
+		b.Append(arrow.Timestamp(v.UnixMicro()))
+	case *array.Date32Builder:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("expected date, got %T", value)
+		}
+		b.Append(arrow.Date32FromTime(v))
+	case *array.Time64Builder:
+		v, ok := value.(time.Duration)
+		if !ok {
+			return fmt.Errorf("expected time of day, got %T", value)
+		}
+		b.Append(arrow.Time64(v.Microseconds()))
+	case *array.Decimal128Builder:
+		v, ok := value.(decimal.Decimal)
+		if !ok {
+			return fmt.Errorf("expected numeric, got %T", value)
+		}
+		dt := b.Type().(*arrow.Decimal128Type)
+		num := decimal128.FromBigInt(v.Shift(dt.Scale).Round(0).BigInt())
+		if !num.FitsInPrecision(dt.Precision) {
+			// like numeric truncation elsewhere, values out of range of the column are nulled
+			b.AppendNull()
+		} else {
+			b.Append(num)
+		}
+	case *extensions.UUIDBuilder:
+		v, ok := value.(uuid.UUID)
+		if !ok {
+			return fmt.Errorf("expected uuid, got %T", value)
+		}
+		b.Append(v)
+	case *array.ExtensionBuilder:
+		return appendParquetValue(b.Builder, value)
+	case *array.BinaryBuilder:
+		v, ok := value.([]byte)
+		if !ok {
+			return fmt.Errorf("expected bytes, got %T", value)
+		}
+		b.Append(v)
+	case *array.StringBuilder:
+		switch v := value.(type) {
+		case string:
+			b.Append(v)
+		case uint8:
+			// qchar
+			b.Append(string(rune(v)))
+		case fmt.Stringer:
+			b.Append(v.String())
+		default:
+			// arrays and other composite values are written as json
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			b.Append(string(encoded))
+		}
+	default:
+		return fmt.Errorf("unsupported parquet builder %T", builder)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package conns3
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestWriteParquet(t *testing.T) {
+	stream := model.NewQRecordStream(4)
+	stream.SetSchema(types.QRecordSchema{Fields: []types.QField{
+		{Name: "id", Type: types.QValueKindInt16, Nullable: false},
+		{Name: "name", Type: types.QValueKindString, Nullable: true},
+		{Name: "amount", Type: types.QValueKindNumeric, Nullable: true},
+		{Name: "created_at", Type: types.QValueKindTimestampTZ, Nullable: true},
+		{Name: "tags", Type: types.QValueKindArrayString, Nullable: true},
+	}})
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	stream.Records <- []types.QValue{
+		types.QValueInt16{Val: 1},
+		types.QValueString{Val: "one"},
+		types.QValueNumeric{Val: decimal.RequireFromString("1.50")},
+		types.QValueTimestampTZ{Val: createdAt},
+		types.QValueArrayString{Val: []string{"a", "b"}},
+	}
+	stream.Records <- []types.QValue{
+		types.QValueInt16{Val: 2},
+		types.QValueNull(types.QValueKindString),
+		types.QValueNull(types.QValueKindNumeric),
+		types.QValueNull(types.QValueKindTimestampTZ),
+		types.QValueNull(types.QValueKindArrayString),
+	}
+	close(stream.Records)
+
+	var buf bytes.Buffer
+	numRecords, err := WriteParquet(&buf, stream, compress.Codecs.Snappy, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), numRecords)
+
+	table, err := pqarrow.ReadTable(t.Context(), bytes.NewReader(buf.Bytes()),
+		parquet.NewReaderProperties(memory.DefaultAllocator), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	defer table.Release()
+	require.Equal(t, int64(2), table.NumRows())
+
+	reader := array.NewTableReader(table, 0)
+	defer reader.Release()
+	require.True(t, reader.Next())
+	record := reader.Record()
+	require.Equal(t, int16(2), record.Column(0).(*array.Int16).Value(1))
+	require.Equal(t, "one", record.Column(1).(*array.String).Value(0))
+	require.True(t, record.Column(1).IsNull(1))
+	require.Equal(t, "1.5", record.Column(2).(*array.String).Value(0))
+	require.Equal(t, createdAt, record.Column(3).(*array.Timestamp).Value(0).ToTime(arrow.Microsecond).UTC())
+	require.JSONEq(t, `["a","b"]`, record.Column(4).(*array.String).Value(0))
+}
+
+func TestWriteParquetLogicalTypes(t *testing.T) {
+	stream := model.NewQRecordStream(4)
+	stream.SetSchema(types.QRecordSchema{Fields: []types.QField{
+		{Name: "small", Type: types.QValueKindUInt32, Nullable: true},
+		{Name: "price", Type: types.QValueKindNumeric, Precision: 6, Scale: 2, Nullable: true},
+		{Name: "id", Type: types.QValueKindUUID, Nullable: true},
+		{Name: "doc", Type: types.QValueKindJSONB, Nullable: true},
+	}})
+	id := uuid.New()
+	stream.Records <- []types.QValue{
+		types.QValueUInt32{Val: 7},
+		types.QValueNumeric{Val: decimal.RequireFromString("1234.567"), Precision: 6, Scale: 2},
+		types.QValueUUID{Val: id},
+		types.QValueJSON{Val: `{"a":1}`},
+	}
+	stream.Records <- []types.QValue{
+		types.QValueNull(types.QValueKindUInt32),
+		// does not fit in numeric(6,2)
+		types.QValueNumeric{Val: decimal.RequireFromString("123456.7"), Precision: 6, Scale: 2},
+		types.QValueNull(types.QValueKindUUID),
+		types.QValueNull(types.QValueKindJSONB),
+	}
+	close(stream.Records)
+
+	var buf bytes.Buffer
+	numRecords, err := WriteParquet(&buf, stream, compress.Codecs.Zstd, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), numRecords)
+
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer pf.Close()
+	require.Equal(t, 2, pf.NumRowGroups())
+	columns := pf.MetaData().Schema
+	intType, ok := columns.Column(0).LogicalType().(schema.IntLogicalType)
+	require.True(t, ok)
+	require.Equal(t, int8(32), intType.BitWidth())
+	require.False(t, intType.IsSigned())
+	decimalType, ok := columns.Column(1).LogicalType().(schema.DecimalLogicalType)
+	require.True(t, ok)
+	require.Equal(t, int32(6), decimalType.Precision())
+	require.Equal(t, int32(2), decimalType.Scale())
+	require.IsType(t, schema.UUIDLogicalType{}, columns.Column(2).LogicalType())
+	require.IsType(t, schema.JSONLogicalType{}, columns.Column(3).LogicalType())
+
+	table, err := pqarrow.ReadTable(t.Context(), bytes.NewReader(buf.Bytes()),
+		parquet.NewReaderProperties(memory.DefaultAllocator), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	defer table.Release()
+	prices := table.Column(1).Data().Chunk(0).(*array.Decimal128)
+	require.Equal(t, "1234.57", prices.Value(0).ToString(2))
+	require.True(t, prices.IsNull(1))
+}
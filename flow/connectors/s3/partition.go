@@ -0,0 +1,198 @@
+package conns3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// value of null partitions, as written by Hive
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+type hivePartitioner struct {
+	config   *protos.S3PartitionConfig
+	syncTime time.Time
+	table    string
+	// index of the partition column, -1 partitions by time of sync
+	columnIdx int
+	// partition column is a timestamp or date, bucketed like time of sync
+	columnIsTime bool
+	// index of the column holding the destination table of raw CDC records, -1 uses table
+	tableIdx int
+}
+
+func newHivePartitioner(
+	config *protos.S3PartitionConfig,
+	schema types.QRecordSchema,
+	table string,
+	tableColumn string,
+) (*hivePartitioner, error) {
+	p := &hivePartitioner{
+		config:    config,
+		syncTime:  time.Now().UTC(),
+		table:     table,
+		columnIdx: -1,
+		tableIdx:  -1,
+	}
+	names := schema.GetColumnNames()
+	if config.Column != "" {
+		p.columnIdx = slices.Index(names, config.Column)
+		if p.columnIdx == -1 {
+			return nil, fmt.Errorf("partition column %s not found in records of %s", config.Column, table)
+		}
+		switch schema.Fields[p.columnIdx].Type {
+		case types.QValueKindTimestamp, types.QValueKindTimestampTZ, types.QValueKindDate:
+			p.columnIsTime = true
+		}
+	}
+	if config.ByTable && tableColumn != "" {
+		p.tableIdx = slices.Index(names, tableColumn)
+	}
+	return p, nil
+}
+
+// prefix returns the hive-style path of record, without leading or trailing slashes
+func (p *hivePartitioner) prefix(record []types.QValue) (string, error) {
+	var parts []string
+	if p.columnIdx == -1 {
+		parts = p.timeParts(p.syncTime)
+	} else if p.columnIsTime {
+		switch v := record[p.columnIdx].(type) {
+		case types.QValueTimestamp:
+			parts = p.timeParts(v.Val.UTC())
+		case types.QValueTimestampTZ:
+			parts = p.timeParts(v.Val.UTC())
+		case types.QValueDate:
+			parts = p.timeParts(v.Val.UTC())
+		default:
+			parts = []string{p.key("dt") + "=" + hiveDefaultPartition}
+		}
+	} else {
+		field, err := csvField(record[p.columnIdx], hiveDefaultPartition)
+		if err != nil {
+			return "", fmt.Errorf("failed to format partition column %s: %w", p.config.Column, err)
+		}
+		parts = []string{p.key(p.config.Column) + "=" + escapePartitionValue(field)}
+	}
+	if p.config.ByTable {
+		table := p.table
+		if p.tableIdx != -1 {
+			if v, ok := record[p.tableIdx].(types.QValueString); ok {
+				table = v.Val
+			}
+		}
+		parts = append(parts, "table="+escapePartitionValue(table))
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func (p *hivePartitioner) key(defaultKey string) string {
+	if p.config.Key != "" {
+		return escapePartitionPath(p.config.Key)
+	}
+	return escapePartitionPath(defaultKey)
+}
+
+func (p *hivePartitioner) timeParts(t time.Time) []string {
+	key := p.key("dt")
+	switch p.config.Granularity {
+	case protos.S3PartitionGranularity_S3_PARTITION_MONTH:
+		return []string{key + "=" + t.Format("2006-01")}
+	case protos.S3PartitionGranularity_S3_PARTITION_HOUR:
+		return []string{key + "=" + t.Format("2006-01-02"), "hr=" + t.Format("15")}
+	default:
+		return []string{key + "=" + t.Format("2006-01-02")}
+	}
+}
+
+// escapePartitionPath percent-encodes characters Hive escapes in partition paths
+func escapePartitionPath(s string) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		if b < 0x20 || b == 0x7f || strings.IndexByte("\"#%'*/:=?\\{[]^", b) != -1 {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		} else {
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String()
+}
+
+func escapePartitionValue(s string) string {
+	if s == "" {
+		return hiveDefaultPartition
+	}
+	return escapePartitionPath(s)
+}
+
+// writePartitioned splits stream by the hive-style prefix of every record,
+// each prefix is written concurrently by write with a stream of its own
+func writePartitioned(
+	ctx context.Context,
+	stream *model.QRecordStream,
+	config *protos.S3PartitionConfig,
+	table string,
+	tableColumn string,
+	write func(ctx context.Context, prefix string, stream *model.QRecordStream) (int64, error),
+) (int64, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	p, err := newHivePartitioner(config, schema, table, tableColumn)
+	if err != nil {
+		return 0, err
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	streams := make(map[string]*model.QRecordStream)
+	var numRecords atomic.Int64
+	closeStreams := func(err error) {
+		for _, s := range streams {
+			s.Close(err)
+		}
+	}
+
+	for record := range stream.Records {
+		prefix, err := p.prefix(record)
+		if err != nil {
+			closeStreams(err)
+			return 0, errors.Join(err, group.Wait())
+		}
+		partitionStream, ok := streams[prefix]
+		if !ok {
+			partitionStream = model.NewQRecordStream(1024)
+			partitionStream.SetSchema(schema)
+			streams[prefix] = partitionStream
+			group.Go(func() error {
+				n, err := write(groupCtx, prefix, partitionStream)
+				numRecords.Add(n)
+				return err
+			})
+		}
+		select {
+		case partitionStream.Records <- record:
+		case <-groupCtx.Done():
+			closeStreams(groupCtx.Err())
+			return 0, group.Wait()
+		}
+	}
+	closeStreams(stream.Err())
+	if err := group.Wait(); err != nil {
+		return 0, err
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	return numRecords.Load(), nil
+}
@@ -0,0 +1,98 @@
+package conns3
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestHivePartitionPrefix(t *testing.T) {
+	schema := types.QRecordSchema{Fields: []types.QField{
+		{Name: "region", Type: types.QValueKindString, Nullable: true},
+		{Name: "created_at", Type: types.QValueKindTimestampTZ, Nullable: true},
+	}}
+	createdAt := time.Date(2024, 5, 1, 13, 30, 0, 0, time.FixedZone("", 2*60*60))
+	record := []types.QValue{types.QValueString{Val: "us/east"}, types.QValueTimestampTZ{Val: createdAt}}
+	nullRecord := []types.QValue{types.QValueNull(types.QValueKindString), types.QValueNull(types.QValueKindTimestampTZ)}
+
+	for _, tc := range []struct {
+		config   *protos.S3PartitionConfig
+		expected string
+		null     string
+	}{
+		{
+			config:   &protos.S3PartitionConfig{Column: "created_at", ByTable: true},
+			expected: "dt=2024-05-01/table=orders",
+			null:     "dt=__HIVE_DEFAULT_PARTITION__/table=orders",
+		},
+		{
+			config:   &protos.S3PartitionConfig{Column: "created_at", Granularity: protos.S3PartitionGranularity_S3_PARTITION_HOUR},
+			expected: "dt=2024-05-01/hr=11",
+			null:     "dt=__HIVE_DEFAULT_PARTITION__",
+		},
+		{
+			config:   &protos.S3PartitionConfig{Column: "created_at", Key: "month", Granularity: protos.S3PartitionGranularity_S3_PARTITION_MONTH},
+			expected: "month=2024-05",
+			null:     "month=__HIVE_DEFAULT_PARTITION__",
+		},
+		{
+			config:   &protos.S3PartitionConfig{Column: "region"},
+			expected: "region=us%2Feast",
+			null:     "region=__HIVE_DEFAULT_PARTITION__",
+		},
+	} {
+		p, err := newHivePartitioner(tc.config, schema, "orders", "")
+		require.NoError(t, err)
+		prefix, err := p.prefix(record)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, prefix)
+		prefix, err = p.prefix(nullRecord)
+		require.NoError(t, err)
+		require.Equal(t, tc.null, prefix)
+	}
+
+	_, err := newHivePartitioner(&protos.S3PartitionConfig{Column: "missing"}, schema, "orders", "")
+	require.Error(t, err)
+}
+
+func TestWritePartitioned(t *testing.T) {
+	stream := model.NewQRecordStream(8)
+	stream.SetSchema(types.QRecordSchema{Fields: []types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "_peerdb_destination_table_name", Type: types.QValueKindString},
+	}})
+	for i, table := range []string{"orders", "users", "orders"} {
+		stream.Records <- []types.QValue{types.QValueInt64{Val: int64(i)}, types.QValueString{Val: table}}
+	}
+	close(stream.Records)
+
+	var mu sync.Mutex
+	written := make(map[string][]int64)
+	numRecords, err := writePartitioned(t.Context(), stream, &protos.S3PartitionConfig{ByTable: true}, "raw_table",
+		"_peerdb_destination_table_name",
+		func(_ context.Context, prefix string, stream *model.QRecordStream) (int64, error) {
+			var n int64
+			for record := range stream.Records {
+				mu.Lock()
+				written[prefix] = append(written[prefix], record[0].Value().(int64))
+				mu.Unlock()
+				n += 1
+			}
+			return n, stream.Err()
+		})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), numRecords)
+
+	dt := "dt=" + time.Now().UTC().Format("2006-01-02")
+	require.Equal(t, map[string][]int64{
+		dt + "/table=orders": {0, 2},
+		dt + "/table=users":  {1},
+	}, written)
+}
@@ -19,26 +19,92 @@ func (c *S3Connector) SyncQRepRecords(
 	partition *protos.QRepPartition,
 	stream *model.QRecordStream,
 ) (int64, shared.QRepWarnings, error) {
-	schema, err := stream.Schema()
+	numRecords, err := c.syncQRepRecords(ctx, config, partition, stream, "")
 	if err != nil {
 		return 0, nil, err
 	}
+	return numRecords, nil, nil
+}
 
-	dstTableName := config.DestinationTableIdentifier
-	avroSchema, err := getAvroSchema(ctx, config.Env, dstTableName, schema)
+// syncQRepRecords writes stream under the prefixes of config.S3Partition when set,
+// tableColumn names the column holding destination tables of raw CDC records
+func (c *S3Connector) syncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+	tableColumn string,
+) (int64, error) {
+	if c.fileFormat == protos.S3FileFormat_S3_HUDI {
+		return c.syncQRepRecordsToHudi(ctx, config, stream)
+	}
+
+	fileFormat, codec, err := QRepFileOptions(config, c.fileFormat, c.codec)
 	if err != nil {
-		return 0, nil, err
+		return 0, err
 	}
+	if config.S3Partition == nil {
+		return c.writeFile(ctx, config, stream, partition.PartitionId, config.FlowJobName, fileFormat, codec)
+	}
+	return writePartitioned(ctx, stream, config.S3Partition, config.DestinationTableIdentifier, tableColumn,
+		func(ctx context.Context, prefix string, stream *model.QRecordStream) (int64, error) {
+			return c.writeFile(ctx, config, stream, partition.PartitionId, config.FlowJobName+"/"+prefix, fileFormat, codec)
+		})
+}
 
-	numRecords, err := c.writeToAvroFile(ctx, config.Env, stream, avroSchema, partition.PartitionId, config.FlowJobName)
+// writeFile writes stream to <prefix>/<dir>/<partitionID>.<format>
+func (c *S3Connector) writeFile(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	stream *model.QRecordStream,
+	partitionID string,
+	dir string,
+	fileFormat protos.S3FileFormat,
+	codec protos.AvroCodec,
+) (int64, error) {
+	switch fileFormat {
+	case protos.S3FileFormat_S3_PARQUET:
+		return c.writeToParquetFile(ctx, config.Env, stream, partitionID, dir, codec, int(config.ParquetRowGroupSize))
+	case protos.S3FileFormat_S3_CSV, protos.S3FileFormat_S3_JSONL:
+		return c.writeToDelimitedFile(ctx, config.Env, stream, partitionID, dir, fileFormat, codec)
+	}
+
+	schema, err := stream.Schema()
 	if err != nil {
-		return 0, nil, err
+		return 0, err
 	}
+	avroSchema, err := GetAvroSchema(ctx, config.Env, config.DestinationTableIdentifier, schema)
+	if err != nil {
+		return 0, err
+	}
+	if c.registry != nil {
+		if _, err := c.registry.Header(ctx, config.DestinationTableIdentifier+"-value", avroSchema.Schema.String()); err != nil {
+			return 0, err
+		}
+	}
+	return c.writeToAvroFile(ctx, config.Env, stream, avroSchema, partitionID, dir, codec)
+}
 
-	return numRecords, nil, nil
+// QRepFileOptions applies the file format & codec set on a mirror over those of the peer,
+// only avro & parquet can be chosen per mirror as hudi tables are laid out by the peer
+func QRepFileOptions(
+	config *protos.QRepConfig,
+	fileFormat protos.S3FileFormat,
+	codec protos.AvroCodec,
+) (protos.S3FileFormat, protos.AvroCodec, error) {
+	if config.FileFormat != nil {
+		fileFormat = config.GetFileFormat()
+		if fileFormat != protos.S3FileFormat_S3_AVRO && fileFormat != protos.S3FileFormat_S3_PARQUET {
+			return fileFormat, codec, fmt.Errorf("unsupported file format %s for mirror, expected avro or parquet", fileFormat)
+		}
+	}
+	if config.Codec != nil {
+		codec = config.GetCodec()
+	}
+	return fileFormat, codec, nil
 }
 
-func getAvroSchema(
+func GetAvroSchema(
 	ctx context.Context,
 	env map[string]string,
 	dstTableName string,
@@ -60,6 +126,7 @@ func (c *S3Connector) writeToAvroFile(
 	avroSchema *model.QRecordAvroSchemaDefinition,
 	partitionID string,
 	jobName string,
+	codec protos.AvroCodec,
 ) (int64, error) {
 	s3o, err := utils.NewS3BucketAndPrefix(c.url)
 	if err != nil {
@@ -68,21 +135,12 @@ func (c *S3Connector) writeToAvroFile(
 
 	s3AvroFileKey := fmt.Sprintf("%s/%s/%s.avro", s3o.Prefix, jobName, partitionID)
 
-	var codec ocf.CodecName
-	switch c.codec {
-	case protos.AvroCodec_Null:
-		codec = ocf.Null
-	case protos.AvroCodec_Deflate:
-		codec = ocf.Deflate
-	case protos.AvroCodec_Snappy:
-		codec = ocf.Snappy
-	case protos.AvroCodec_ZStandard:
-		codec = ocf.ZStandard
-	default:
-		return 0, fmt.Errorf("unsupported codec %s", c.codec)
+	ocfCodec, err := OCFCodec(codec)
+	if err != nil {
+		return 0, err
 	}
 
-	writer := utils.NewPeerDBOCFWriter(stream, avroSchema, codec, protos.DBType_S3)
+	writer := utils.NewPeerDBOCFWriter(stream, avroSchema, ocfCodec, protos.DBType_S3)
 	avroFile, err := writer.WriteRecordsToS3(ctx, env, s3o.Bucket, s3AvroFileKey, c.credentialsProvider, nil, nil, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to write records to S3: %w", err)
@@ -92,6 +150,21 @@ func (c *S3Connector) writeToAvroFile(
 	return avroFile.NumRecords, nil
 }
 
+func OCFCodec(codec protos.AvroCodec) (ocf.CodecName, error) {
+	switch codec {
+	case protos.AvroCodec_Null:
+		return ocf.Null, nil
+	case protos.AvroCodec_Deflate:
+		return ocf.Deflate, nil
+	case protos.AvroCodec_Snappy:
+		return ocf.Snappy, nil
+	case protos.AvroCodec_ZStandard:
+		return ocf.ZStandard, nil
+	default:
+		return "", fmt.Errorf("unsupported codec %s", codec)
+	}
+}
+
 // S3 just sets up destination, not metadata tables
 func (c *S3Connector) SetupQRepMetadataTables(_ context.Context, config *protos.QRepConfig) error {
 	c.logger.Info("QRep metadata setup not needed for S3.")
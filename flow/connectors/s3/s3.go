@@ -10,6 +10,7 @@ import (
 
 	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils/schemaregistry"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -22,6 +23,11 @@ type S3Connector struct {
 	client              s3.Client
 	url                 string
 	codec               protos.AvroCodec
+	fileFormat          protos.S3FileFormat
+	hudiTableType       protos.HudiTableType
+	csvOptions          csvOptions
+	// avro schemas of files are checked against the registry when set
+	registry schemaregistry.Registry
 }
 
 func NewS3Connector(
@@ -30,6 +36,24 @@ func NewS3Connector(
 ) (*S3Connector, error) {
 	logger := internal.LoggerFromCtx(ctx)
 
+	csvOptions, err := newCSVOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.FileFormat == protos.S3FileFormat_S3_CSV || config.FileFormat == protos.S3FileFormat_S3_JSONL {
+		if _, _, err := textCompression(config.Codec); err != nil {
+			return nil, err
+		}
+	}
+
+	var registry schemaregistry.Registry
+	if config.SchemaRegistry != nil {
+		registry, err = schemaregistry.New(ctx, config.SchemaRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema registry client: %w", err)
+		}
+	}
+
 	provider, err := utils.GetAWSCredentialsProvider(ctx, "s3", utils.NewPeerAWSCredentials(config))
 	if err != nil {
 		return nil, err
@@ -51,6 +75,10 @@ func NewS3Connector(
 		logger:              logger,
 		url:                 config.Url,
 		codec:               config.Codec,
+		fileFormat:          config.FileFormat,
+		hudiTableType:       config.HudiTableType,
+		csvOptions:          csvOptions,
+		registry:            registry,
 	}, nil
 }
 
@@ -77,11 +105,15 @@ func (c *S3Connector) ConnectionActive(ctx context.Context) error {
 }
 
 func (c *S3Connector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	if c.fileFormat == protos.S3FileFormat_S3_HUDI {
+		return c.syncRecordsToHudi(ctx, req)
+	}
+
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
 	streamReq := model.NewRecordsToStreamRequest(
 		req.Records.GetRecords(), tableNameRowsMapping, req.SyncBatchID, false, protos.DBType_S3,
 	)
-	recordStream, err := utils.RecordsToRawTableStream(streamReq, nil)
+	recordStream, err := utils.RecordsToRawTableStream(ctx, req.Env, req.FlowJobName, streamReq, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
 	}
@@ -91,10 +123,20 @@ func (c *S3Connector) SyncRecords(ctx context.Context, req *model.SyncRecordsReq
 		Env:                        req.Env,
 		Version:                    req.Version,
 	}
+	if req.S3Partition != nil {
+		// raw records only carry the destination table, columns of their data can't partition them
+		qrepConfig.S3Partition = &protos.S3PartitionConfig{
+			Granularity: req.S3Partition.Granularity,
+			ByTable:     req.S3Partition.ByTable,
+		}
+		if req.S3Partition.Column == "" {
+			qrepConfig.S3Partition.Key = req.S3Partition.Key
+		}
+	}
 	partition := &protos.QRepPartition{
 		PartitionId: strconv.FormatInt(req.SyncBatchID, 10),
 	}
-	numRecords, _, err := c.SyncQRepRecords(ctx, qrepConfig, partition, recordStream)
+	numRecords, err := c.syncQRepRecords(ctx, qrepConfig, partition, recordStream, "_peerdb_destination_table_name")
 	if err != nil {
 		return nil, err
 	}
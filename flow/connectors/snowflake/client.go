@@ -52,7 +52,7 @@ func (c *SnowflakeConnector) columnTypeToQField(ct *sql.ColumnType) (types.QFiel
 	}, nil
 }
 
-func (c *SnowflakeConnector) processRows(rows *sql.Rows) (*model.QRecordBatch, error) {
+func (c *SnowflakeConnector) qfieldsFromRows(rows *sql.Rows) ([]types.QField, error) {
 	dbColTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return nil, err
@@ -69,60 +69,72 @@ func (c *SnowflakeConnector) processRows(rows *sql.Rows) (*model.QRecordBatch, e
 		}
 		qfields[i] = qfield
 	}
+	return qfields, nil
+}
 
-	var records [][]types.QValue
-	totalRowsProcessed := 0
-	const logEveryNumRows = 50000
+// scanQValues scans the current row of rows into qvalues of the kinds in qfields
+func (c *SnowflakeConnector) scanQValues(rows *sql.Rows, qfields []types.QField) ([]types.QValue, error) {
+	values := make([]any, len(qfields))
+	for i := range values {
+		switch qfields[i].Type {
+		case types.QValueKindTimestamp, types.QValueKindTimestampTZ, types.QValueKindTime, types.QValueKindDate:
+			var t sql.NullTime
+			values[i] = &t
+		case types.QValueKindInt32:
+			var n sql.NullInt32
+			values[i] = &n
+		case types.QValueKindInt64:
+			var n sql.NullInt64
+			values[i] = &n
+		case types.QValueKindFloat64:
+			var f sql.NullFloat64
+			values[i] = &f
+		case types.QValueKindBoolean:
+			var b sql.NullBool
+			values[i] = &b
+		case types.QValueKindString, types.QValueKindHStore:
+			var s sql.NullString
+			values[i] = &s
+		case types.QValueKindBytes:
+			values[i] = new([]byte)
+		case types.QValueKindNumeric:
+			var s sql.Null[decimal.Decimal]
+			values[i] = &s
+		default:
+			values[i] = new(any)
+		}
+	}
 
-	for rows.Next() {
-		columns, err := rows.Columns()
+	if err := rows.Scan(values...); err != nil {
+		return nil, err
+	}
+
+	qValues := make([]types.QValue, len(values))
+	for i, val := range values {
+		qv, err := toQValue(qfields[i].Type, val)
 		if err != nil {
+			c.logger.Error("failed to convert value", slog.Any("error", err))
 			return nil, err
 		}
+		qValues[i] = qv
+	}
+	return qValues, nil
+}
 
-		values := make([]any, len(columns))
-		for i := range values {
-			switch qfields[i].Type {
-			case types.QValueKindTimestamp, types.QValueKindTimestampTZ, types.QValueKindTime, types.QValueKindDate:
-				var t sql.NullTime
-				values[i] = &t
-			case types.QValueKindInt32:
-				var n sql.NullInt32
-				values[i] = &n
-			case types.QValueKindInt64:
-				var n sql.NullInt64
-				values[i] = &n
-			case types.QValueKindFloat64:
-				var f sql.NullFloat64
-				values[i] = &f
-			case types.QValueKindBoolean:
-				var b sql.NullBool
-				values[i] = &b
-			case types.QValueKindString, types.QValueKindHStore:
-				var s sql.NullString
-				values[i] = &s
-			case types.QValueKindBytes:
-				values[i] = new([]byte)
-			case types.QValueKindNumeric:
-				var s sql.Null[decimal.Decimal]
-				values[i] = &s
-			default:
-				values[i] = new(any)
-			}
-		}
+func (c *SnowflakeConnector) processRows(rows *sql.Rows) (*model.QRecordBatch, error) {
+	qfields, err := c.qfieldsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := rows.Scan(values...); err != nil {
-			return nil, err
-		}
+	var records [][]types.QValue
+	totalRowsProcessed := 0
+	const logEveryNumRows = 50000
 
-		qValues := make([]types.QValue, len(values))
-		for i, val := range values {
-			qv, err := toQValue(qfields[i].Type, val)
-			if err != nil {
-				c.logger.Error("failed to convert value", slog.Any("error", err))
-				return nil, err
-			}
-			qValues[i] = qv
+	for rows.Next() {
+		qValues, err := c.scanQValues(rows, qfields)
+		if err != nil {
+			return nil, err
 		}
 
 		records = append(records, qValues)
@@ -263,6 +275,18 @@ func toQValue(kind types.QValueKind, val any) (types.QValue, error) {
 		}
 
 		return types.QValueJSON{Val: vstring}, nil
+	case types.QValueKindGeography, types.QValueKindGeometry:
+		if v, ok := val.(*any); ok {
+			if *v == nil {
+				return types.QValueNull(kind), nil
+			}
+			if vstring, ok := (*v).(string); ok {
+				if kind == types.QValueKindGeography {
+					return types.QValueGeography{Val: vstring}, nil
+				}
+				return types.QValueGeometry{Val: vstring}, nil
+			}
+		}
 	}
 
 	// If type is unsupported or doesn't match the specified kind, return error
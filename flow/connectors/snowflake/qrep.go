@@ -3,9 +3,11 @@ package connsnowflake
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -103,6 +105,12 @@ func (c *SnowflakeConnector) createStage(ctx context.Context, stageName string,
 			return err
 		}
 		createStageStmt = stmt
+	} else if utils.IsAzureBlobURL(config.StagingPath) {
+		stmt, err := c.createAzureStage(stageName, config)
+		if err != nil {
+			return err
+		}
+		createStageStmt = stmt
 	} else {
 		createStageStmt = fmt.Sprintf(`CREATE OR REPLACE STAGE %s FILE_FORMAT = (TYPE = AVRO)`, stageName)
 	}
@@ -154,6 +162,35 @@ func (c *SnowflakeConnector) createExternalStage(ctx context.Context, stageName
 	}
 }
 
+// createAzureStage reads blobs staged on Azure through the storage integration when one is set,
+// Snowflake can't use the identity of workers so a SAS token is needed otherwise
+func (c *SnowflakeConnector) createAzureStage(stageName string, config *protos.QRepConfig) (string, error) {
+	path, err := utils.NewAzureBlobPath(config.StagingPath)
+	if err != nil {
+		return "", err
+	}
+	stageURL := path.StageURL(config.FlowJobName)
+
+	if c.config.S3Integration != "" {
+		stageStatement := `
+		CREATE OR REPLACE STAGE %s
+		URL = '%s'
+		STORAGE_INTEGRATION = %s
+		FILE_FORMAT = (TYPE = AVRO);`
+		return fmt.Sprintf(stageStatement, stageName, stageURL, c.config.S3Integration), nil
+	}
+	creds := utils.AzureStagingCredentials()
+	if creds.SasToken == "" {
+		return "", errors.New("azure stage for Snowflake requires a storage integration or AZURE_STORAGE_SAS_TOKEN")
+	}
+	stageStatement := `
+		CREATE OR REPLACE STAGE %s
+		URL = '%s'
+		CREDENTIALS=(AZURE_SAS_TOKEN='%s')
+		FILE_FORMAT = (TYPE = AVRO);`
+	return fmt.Sprintf(stageStatement, stageName, stageURL, creds.SasToken), nil
+}
+
 func (c *SnowflakeConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
 	ctx = c.withMirrorNameQueryTag(ctx, config.FlowJobName)
 
@@ -173,7 +210,11 @@ func (c *SnowflakeConnector) ConsolidateQRepPartitions(ctx context.Context, conf
 // CleanupQRepFlow function for snowflake connector
 func (c *SnowflakeConnector) CleanupQRepFlow(ctx context.Context, config *protos.QRepConfig) error {
 	c.logger.Info("Cleaning up flow job")
-	return c.dropStage(ctx, config.StagingPath, config.FlowJobName)
+	cutoff, err := utils.StagingRetentionCutoff(ctx, config.Env)
+	if err != nil {
+		return err
+	}
+	return c.dropStage(ctx, config.StagingPath, config.FlowJobName, cutoff)
 }
 
 func (c *SnowflakeConnector) getColsFromTable(ctx context.Context, tableName string) ([]SnowflakeTableColumn, error) {
@@ -220,8 +261,8 @@ func (c *SnowflakeConnector) getColsFromTable(ctx context.Context, tableName str
 	return cols, nil
 }
 
-// dropStage drops the stage for the given job.
-func (c *SnowflakeConnector) dropStage(ctx context.Context, stagingPath string, job string) error {
+// dropStage drops the stage for the given job, keeping staged files modified after a non-zero cutoff.
+func (c *SnowflakeConnector) dropStage(ctx context.Context, stagingPath string, job string, cutoff time.Time) error {
 	stageName := c.getStageNameForJob(job)
 	stmt := "DROP STAGE IF EXISTS " + stageName
 
@@ -264,6 +305,9 @@ func (c *SnowflakeConnector) dropStage(ctx context.Context, stagingPath string,
 			}
 
 			for _, object := range page.Contents {
+				if !utils.StagedBefore(object.LastModified, cutoff) {
+					continue
+				}
 				if _, err := s3svc.DeleteObject(ctx, &s3.DeleteObjectInput{
 					Bucket: aws.String(s3o.Bucket),
 					Key:    object.Key,
@@ -277,6 +321,23 @@ func (c *SnowflakeConnector) dropStage(ctx context.Context, stagingPath string,
 		c.logger.Info(fmt.Sprintf("Deleted contents of bucket %s with prefix %s/%s", s3o.Bucket, s3o.Prefix, job))
 	}
 
+	if utils.IsAzureBlobURL(stagingPath) {
+		path, err := utils.NewAzureBlobPath(stagingPath)
+		if err != nil {
+			return err
+		}
+		client, err := utils.CreateAzureBlobClient(path, utils.AzureStagingCredentials())
+		if err != nil {
+			return err
+		}
+		prefix := path.BlobName(job) + "/"
+		if err := utils.DeleteAzureBlobPrefix(ctx, client, path.Container, prefix, cutoff); err != nil {
+			c.logger.Error("failed to delete blobs from container", slog.Any("error", err))
+			return fmt.Errorf("failed to delete blobs of stage: %w", err)
+		}
+		c.logger.Info(fmt.Sprintf("Deleted blobs of container %s with prefix %s", path.Container, prefix))
+	}
+
 	c.logger.Info("Dropped stage " + stageName)
 	return nil
 }
@@ -187,6 +187,27 @@ func (s *SnowflakeAvroSyncHandler) writeToAvroFile(
 			return utils.AvroFile{}, fmt.Errorf("failed to write records to S3: %w", err)
 		}
 
+		return avroFile, nil
+	} else if utils.IsAzureBlobURL(s.config.StagingPath) {
+		ocfWriter := utils.NewPeerDBOCFWriter(stream, avroSchema, ocf.ZStandard, protos.DBType_SNOWFLAKE)
+		path, err := utils.NewAzureBlobPath(s.config.StagingPath)
+		if err != nil {
+			return utils.AvroFile{}, fmt.Errorf("failed to parse staging path: %w", err)
+		}
+
+		blobName := path.BlobName(s.config.FlowJobName, partitionID+".avro")
+		s.logger.Info("OCF: Writing records to Azure Blob Storage",
+			slog.String(string(shared.PartitionIDKey), partitionID))
+
+		client, err := utils.CreateAzureBlobClient(path, utils.AzureStagingCredentials())
+		if err != nil {
+			return utils.AvroFile{}, err
+		}
+		avroFile, err := ocfWriter.WriteRecordsToAzureBlob(ctx, env, client, path.Container, blobName, nil, nil, nil)
+		if err != nil {
+			return utils.AvroFile{}, fmt.Errorf("failed to write records to Azure Blob Storage: %w", err)
+		}
+
 		return avroFile, nil
 	}
 
@@ -0,0 +1,187 @@
+package connsnowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const fullTablePartitionID = "snowflake-full-table-partition-id"
+
+func (c *SnowflakeConnector) GetQRepPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkColumn == "" {
+		// if no watermark column is specified, return a single partition
+		return []*protos.QRepPartition{
+			{
+				PartitionId:        fullTablePartitionID,
+				Range:              nil,
+				FullTablePartition: true,
+			},
+		}, nil
+	}
+
+	if config.NumRowsPerPartition <= 0 {
+		return nil, errors.New("num rows per partition must be greater than 0")
+	}
+
+	parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse watermark table %s: %w", config.WatermarkTable, err)
+	}
+	watermarkTable := snowflakeSchemaTableNormalize(parsedWatermarkTable)
+	quotedWatermarkColumn := SnowflakeIdentifierNormalize(config.WatermarkColumn)
+
+	whereClause := ""
+	var args []any
+	if last != nil && last.Range != nil {
+		whereClause = fmt.Sprintf("WHERE %s > ?", quotedWatermarkColumn)
+		switch lastRange := last.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = append(args, lastRange.IntRange.End)
+		case *protos.PartitionRange_UintRange:
+			args = append(args, int64(lastRange.UintRange.End))
+		case *protos.PartitionRange_TimestampRange:
+			args = append(args, lastRange.TimestampRange.End.AsTime())
+		default:
+			return nil, fmt.Errorf("unsupported partition range type %T", lastRange)
+		}
+	}
+
+	var totalRows int64
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", watermarkTable, whereClause)
+	if err := c.QueryRowContext(ctx, countQuery, args...).Scan(&totalRows); err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	if totalRows == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return make([]*protos.QRepPartition, 0), nil
+	}
+
+	numRowsPerPartition := int64(config.NumRowsPerPartition)
+	numPartitions := totalRows / numRowsPerPartition
+	if totalRows%numRowsPerPartition != 0 {
+		numPartitions++
+	}
+	c.logger.Info(fmt.Sprintf("total rows: %d, num partitions: %d, num rows per partition: %d",
+		totalRows, numPartitions, numRowsPerPartition))
+
+	partitionsQuery := fmt.Sprintf(`SELECT MIN(w), MAX(w) FROM (
+		SELECT %[1]s AS w, NTILE(%[2]d) OVER (ORDER BY %[1]s) AS bucket FROM %[3]s %[4]s
+	) GROUP BY bucket ORDER BY MIN(w)`,
+		quotedWatermarkColumn, numPartitions, watermarkTable, whereClause)
+	c.logger.Info("partitions query", slog.String("query", partitionsQuery))
+	rows, err := c.QueryContext(ctx, partitionsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for partitions: %w", err)
+	}
+	defer rows.Close()
+
+	partitionHelper := utils.NewPartitionHelper(c.logger)
+	for rows.Next() {
+		var start, end any
+		if err := rows.Scan(&start, &end); err != nil {
+			return nil, err
+		}
+		if start, err = watermarkValue(start); err != nil {
+			return nil, err
+		}
+		if end, err = watermarkValue(end); err != nil {
+			return nil, err
+		}
+		if err := partitionHelper.AddPartition(start, end); err != nil {
+			return nil, fmt.Errorf("failed to add partition: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	return partitionHelper.GetPartitions(), nil
+}
+
+// watermarkValue converts a watermark scanned by gosnowflake into a type partitions can be made of,
+// fixed point numbers are scanned as strings
+func watermarkValue(v any) (any, error) {
+	switch val := v.(type) {
+	case nil, int64, time.Time:
+		return val, nil
+	case string:
+		i, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("watermark column must be an integer or timestamp, got %q", val)
+		}
+		return i, nil
+	default:
+		return nil, fmt.Errorf("unsupported watermark type %T", v)
+	}
+}
+
+func (c *SnowflakeConnector) PullQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	query := config.Query
+	var args []any
+	if !partition.FullTablePartition {
+		var rangeStart, rangeEnd any
+		switch x := partition.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			rangeStart, rangeEnd = x.IntRange.Start, x.IntRange.End
+		case *protos.PartitionRange_UintRange:
+			rangeStart, rangeEnd = int64(x.UintRange.Start), int64(x.UintRange.End)
+		case *protos.PartitionRange_TimestampRange:
+			rangeStart, rangeEnd = x.TimestampRange.Start.AsTime(), x.TimestampRange.End.AsTime()
+		default:
+			return 0, 0, fmt.Errorf("unknown range type: %v", x)
+		}
+		query = strings.NewReplacer("{{.start}}", ":1", "{{.end}}", ":2").Replace(query)
+		args = append(args, rangeStart, rangeEnd)
+	}
+
+	c.logger.Info("[snowflake] pulling partition", slog.String("query", query), slog.String("partition", partition.PartitionId))
+	rows, err := c.QueryContext(c.withMirrorNameQueryTag(ctx, config.FlowJobName), query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query partition: %w", err)
+	}
+	defer rows.Close()
+
+	qfields, err := c.qfieldsFromRows(rows)
+	if err != nil {
+		return 0, 0, err
+	}
+	schema := types.NewQRecordSchema(qfields)
+	stream.SetSchema(schema)
+
+	start := time.Now()
+	var totalRecords int64
+	for rows.Next() {
+		record, err := c.scanQValues(rows, qfields)
+		if err != nil {
+			return 0, 0, err
+		}
+		stream.Records <- record
+		totalRecords += 1
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	c.logger.Info("[snowflake] pulled partition", slog.Int64("records", totalRecords), slog.Duration("duration", time.Since(start)))
+	close(stream.Records)
+	return totalRecords, 0, nil
+}
@@ -20,6 +20,7 @@ var snowflakeTypeToQValueKindMap = map[string]types.QValueKind{
 	"TIMESTAMP":     types.QValueKindTimestamp,
 	"TIMESTAMP_NTZ": types.QValueKindTimestamp,
 	"TIMESTAMP_TZ":  types.QValueKindTimestampTZ,
+	"TIMESTAMP_LTZ": types.QValueKindTimestampTZ,
 	"TIME":          types.QValueKindTime,
 	"DATE":          types.QValueKindDate,
 	"BLOB":          types.QValueKindBytes,
@@ -30,6 +31,8 @@ var snowflakeTypeToQValueKindMap = map[string]types.QValueKind{
 	"DECIMAL":       types.QValueKindNumeric,
 	"NUMERIC":       types.QValueKindNumeric,
 	"VARIANT":       types.QValueKindJSON,
+	"OBJECT":        types.QValueKindJSON,
+	"ARRAY":         types.QValueKindJSON,
 	"GEOMETRY":      types.QValueKindGeometry,
 	"GEOGRAPHY":     types.QValueKindGeography,
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
@@ -36,8 +37,8 @@ const (
 		_PEERDB_UNCHANGED_TOAST_COLUMNS STRING)`
 	createDummyTableSQL               = "CREATE TABLE IF NOT EXISTS %s.%s(_PEERDB_DUMMY_COL STRING)"
 	rawTableMultiValueInsertSQL       = "INSERT INTO %s.%s VALUES%s"
-	createNormalizedTableSQL          = "CREATE TABLE IF NOT EXISTS %s(%s)"
-	createOrReplaceNormalizedTableSQL = "CREATE OR REPLACE TABLE %s(%s)"
+	createNormalizedTableSQL          = "CREATE %sTABLE IF NOT EXISTS %s(%s)%s"
+	createOrReplaceNormalizedTableSQL = "CREATE OR REPLACE %sTABLE %s(%s)%s"
 	toVariantColumnName               = "VAR_COLS"
 	mergeStatementSQL                 = `MERGE INTO %s TARGET USING (WITH VARIANT_CONVERTED AS (
 		SELECT _PEERDB_UID,_PEERDB_TIMESTAMP,TO_VARIANT(PARSE_JSON(_PEERDB_DATA)) %s,_PEERDB_RECORD_TYPE,
@@ -74,6 +75,7 @@ type SnowflakeConnector struct {
 	*sql.DB
 	logger    log.Logger
 	config    *protos.SnowflakeConfig
+	snowpipe  *snowpipeStreamingClient
 	rawSchema string
 }
 
@@ -135,6 +137,7 @@ func NewSnowflakeConnector(
 		rawSchema:        rawSchema,
 		logger:           logger,
 		config:           snowflakeProtoConfig,
+		snowpipe:         newSnowpipeStreamingClient(snowflakeProtoConfig, PrivateKeyRSA),
 	}, nil
 }
 
@@ -210,6 +213,58 @@ func (c *SnowflakeConnector) ValidateCheck(ctx context.Context) error {
 	return nil
 }
 
+// PreflightMirrorDestination tests what ValidateCheck does outside a transaction, reporting creating a table
+// and inserting into it separately, along with access to the stages batches are loaded from
+func (c *SnowflakeConnector) PreflightMirrorDestination(
+	ctx context.Context, cfg *protos.FlowConnectionConfigs,
+) []*protos.PreflightCheck {
+	dummyTable := "PEERDB_DUMMY_TABLE_" + shared.RandomString(4)
+	checks := utils.PreflightTableChecks(func() error {
+		schemaExists, err := c.checkIfRawSchemaExists(ctx)
+		if err != nil {
+			return fmt.Errorf("error while checking if schema exists: %w", err)
+		}
+		if !schemaExists {
+			if _, err := c.ExecContext(ctx, fmt.Sprintf(createSchemaSQL, c.rawSchema)); err != nil {
+				return fmt.Errorf("failed to create schema %s: %w", c.rawSchema, err)
+			}
+		}
+		if _, err := c.ExecContext(ctx, fmt.Sprintf(createDummyTableSQL, c.rawSchema, dummyTable)); err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+		return nil
+	}, func() error {
+		if _, err := c.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s.%s VALUES ('dummy')", c.rawSchema, dummyTable)); err != nil {
+			return fmt.Errorf("failed to insert row: %w", err)
+		}
+		return nil
+	})
+	if _, err := c.ExecContext(ctx, fmt.Sprintf(dropTableIfExistsSQL, c.rawSchema, dummyTable)); err != nil {
+		c.logger.Error("preflight failed to drop table", slog.String("table", dummyTable), slog.Any("error", err))
+	}
+
+	checks = append(checks, utils.PreflightCheckResult("stage", c.validateStage(ctx, cfg.CdcStagingPath)))
+	if cfg.DoInitialSnapshot && cfg.SnapshotStagingPath != cfg.CdcStagingPath {
+		checks = append(checks, utils.PreflightCheckResult("snapshot_stage", c.validateStage(ctx, cfg.SnapshotStagingPath)))
+	}
+	return checks
+}
+
+// validateStage creates a stage at stagingPath the way mirrors do and lists it,
+// which reads an external location with the credentials of the stage
+func (c *SnowflakeConnector) validateStage(ctx context.Context, stagingPath string) error {
+	job := "peerdb_validation_" + shared.RandomString(4)
+	stageName := c.getStageNameForJob(job)
+	if err := c.createStage(ctx, stageName, &protos.QRepConfig{StagingPath: stagingPath, FlowJobName: job}); err != nil {
+		return err
+	}
+	var listErr error
+	if _, err := c.ExecContext(ctx, "LIST @"+stageName); err != nil {
+		listErr = fmt.Errorf("failed to list stage %s: %w", stageName, err)
+	}
+	return errors.Join(listErr, c.dropStage(ctx, stagingPath, job, time.Time{}))
+}
+
 func (c *SnowflakeConnector) Close() error {
 	if c != nil {
 		return c.DB.Close()
@@ -323,7 +378,15 @@ func (c *SnowflakeConnector) SetupNormalizedTable(
 		return true, nil
 	}
 
-	normalizedTableCreateSQL := generateCreateTableSQLForNormalizedTable(ctx, config, normalizedSchemaTable, tableSchema)
+	var tableMapping *protos.TableMapping
+	for _, tm := range config.TableMappings {
+		if tm.DestinationTableIdentifier == tableIdentifier {
+			tableMapping = tm
+			break
+		}
+	}
+
+	normalizedTableCreateSQL := generateCreateTableSQLForNormalizedTable(ctx, config, normalizedSchemaTable, tableMapping, tableSchema)
 	if _, err := c.execWithLogging(ctx, normalizedTableCreateSQL); err != nil {
 		return false, fmt.Errorf("[sf] error while creating normalized table: %w", err)
 	}
@@ -355,7 +418,7 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	}()
 
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil || (len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.WidenedColumns) == 0) {
 			continue
 		}
 
@@ -381,6 +444,38 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 				"destination table name", schemaDelta.DstTableName,
 				"source table name", schemaDelta.SrcTableName)
 		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			// integers share NUMBER(38,0) and floats share FLOAT, only numeric precision can change
+			if widenedColumn.Current.Type != string(types.QValueKindNumeric) {
+				continue
+			}
+			previousPrecision, previousScale := datatypes.GetNumericTypeForWarehouse(
+				widenedColumn.Previous.TypeModifier, datatypes.SnowflakeNumericCompatibility{})
+			precision, scale := datatypes.GetNumericTypeForWarehouse(
+				widenedColumn.Current.TypeModifier, datatypes.SnowflakeNumericCompatibility{})
+			if precision == previousPrecision && scale == previousScale {
+				continue
+			} else if scale != previousScale || precision < previousPrecision {
+				c.logger.Warn(fmt.Sprintf("[schema delta replay] not widening column %s from NUMERIC(%d,%d) to NUMERIC(%d,%d), "+
+					"snowflake can only increase precision", widenedColumn.Current.Name, previousPrecision, previousScale, precision, scale),
+					"destination table name", schemaDelta.DstTableName,
+					"source table name", schemaDelta.SrcTableName)
+				continue
+			}
+
+			if _, err := tableSchemaModifyTx.ExecContext(ctx,
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN \"%s\" SET DATA TYPE NUMERIC(%d,%d)",
+					schemaDelta.DstTableName, strings.ToUpper(widenedColumn.Current.Name), precision, scale),
+			); err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Current.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s to NUMERIC(%d,%d)",
+				widenedColumn.Current.Name, precision, scale),
+				"destination table name", schemaDelta.DstTableName,
+				"source table name", schemaDelta.SrcTableName)
+		}
 	}
 
 	if err := tableSchemaModifyTx.Commit(); err != nil {
@@ -401,7 +496,16 @@ func (c *SnowflakeConnector) SyncRecords(ctx context.Context, req *model.SyncRec
 	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
 	c.logger.Info("pushing records to Snowflake table " + rawTableIdentifier)
 
-	res, err := c.syncRecordsViaAvro(ctx, req, rawTableIdentifier, req.SyncBatchID)
+	writeMode, err := getWriteMode(ctx, req.Env)
+	if err != nil {
+		return nil, err
+	}
+	var res *model.SyncResponse
+	if writeMode == writeModeSnowpipeStreaming {
+		res, err = c.syncRecordsViaSnowpipeStreaming(ctx, req, rawTableIdentifier, req.SyncBatchID)
+	} else {
+		res, err = c.syncRecordsViaAvro(ctx, req, rawTableIdentifier, req.SyncBatchID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -423,7 +527,7 @@ func (c *SnowflakeConnector) syncRecordsViaAvro(
 	streamReq := model.NewRecordsToStreamRequest(
 		req.Records.GetRecords(), tableNameRowsMapping, syncBatchID, false, protos.DBType_SNOWFLAKE,
 	)
-	stream, err := utils.RecordsToRawTableStream(streamReq, nil)
+	stream, err := utils.RecordsToRawTableStream(ctx, req.Env, req.FlowJobName, streamReq, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
 	}
@@ -616,10 +720,14 @@ func (c *SnowflakeConnector) SyncFlowCleanup(ctx context.Context, jobName string
 	} else if schemaExists {
 		// delete raw table if exists
 		rawTableIdentifier := getRawTableIdentifier(jobName)
+		if _, err := c.execWithLogging(ctx,
+			fmt.Sprintf(dropPipeIfExistsSQL, c.rawSchema, getRawTablePipeIdentifier(rawTableIdentifier))); err != nil {
+			return fmt.Errorf("[snowflake] unable to drop raw table pipe: %w", err)
+		}
 		if _, err := c.execWithLogging(ctx, fmt.Sprintf(dropTableIfExistsSQL, c.rawSchema, rawTableIdentifier)); err != nil {
 			return fmt.Errorf("[snowflake] unable to drop raw table: %w", err)
 		}
-		if err := c.dropStage(ctx, "", jobName); err != nil {
+		if err := c.dropStage(ctx, "", jobName, time.Time{}); err != nil {
 			return err
 		}
 	}
@@ -653,6 +761,7 @@ func generateCreateTableSQLForNormalizedTable(
 	ctx context.Context,
 	config *protos.SetupNormalizedTableBatchInput,
 	dstSchemaTable *utils.SchemaTable,
+	tableMapping *protos.TableMapping,
 	tableSchema *protos.TableSchema,
 ) string {
 	createTableSQLArray := make([]string, 0, len(tableSchema.Columns)+2)
@@ -706,8 +815,22 @@ func generateCreateTableSQLForNormalizedTable(
 		createSQL = createOrReplaceNormalizedTableSQL
 	}
 
-	return fmt.Sprintf(createSQL, snowflakeSchemaTableNormalize(dstSchemaTable),
-		strings.Join(createTableSQLArray, ","))
+	var tableKind string
+	var tableOptions strings.Builder
+	if tableMapping != nil {
+		if tableMapping.Transient {
+			tableKind = "TRANSIENT "
+		}
+		if tableMapping.ClusterBy != "" {
+			fmt.Fprintf(&tableOptions, " CLUSTER BY (%s)", tableMapping.ClusterBy)
+		}
+		if tableMapping.DataRetentionDays != nil {
+			fmt.Fprintf(&tableOptions, " DATA_RETENTION_TIME_IN_DAYS = %d", *tableMapping.DataRetentionDays)
+		}
+	}
+
+	return fmt.Sprintf(createSQL, tableKind, snowflakeSchemaTableNormalize(dstSchemaTable),
+		strings.Join(createTableSQLArray, ","), tableOptions.String())
 }
 
 func getRawTableIdentifier(jobName string) string {
@@ -0,0 +1,49 @@
+package connsnowflake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestGenerateCreateTableSQLWithTableOptions(t *testing.T) {
+	tableSchema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "region", Type: string(types.QValueKindString)},
+		},
+		PrimaryKeyColumns: []string{"id"},
+	}
+	dstSchemaTable := &utils.SchemaTable{Schema: "public", Table: "orders"}
+	retentionDays := uint32(1)
+
+	query := generateCreateTableSQLForNormalizedTable(t.Context(), &protos.SetupNormalizedTableBatchInput{},
+		dstSchemaTable, nil, tableSchema)
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "PUBLIC"."ORDERS"("ID" INTEGER,"REGION" STRING,PRIMARY KEY("ID"))`, query)
+
+	query = generateCreateTableSQLForNormalizedTable(t.Context(), &protos.SetupNormalizedTableBatchInput{IsResync: true},
+		dstSchemaTable, &protos.TableMapping{
+			ClusterBy:         "region, id",
+			Transient:         true,
+			DataRetentionDays: &retentionDays,
+		}, tableSchema)
+	require.Equal(t, `CREATE OR REPLACE TRANSIENT TABLE "PUBLIC"."ORDERS"("ID" INTEGER,"REGION" STRING,PRIMARY KEY("ID"))`+
+		` CLUSTER BY (region, id) DATA_RETENTION_TIME_IN_DAYS = 1`, query)
+}
+
+func TestWatermarkValue(t *testing.T) {
+	v, err := watermarkValue("42")
+	require.NoError(t, err)
+	require.Equal(t, int64(42), v)
+
+	v, err = watermarkValue(nil)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	_, err = watermarkValue("4.2")
+	require.Error(t, err)
+}
@@ -0,0 +1,391 @@
+package connsnowflake
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+const (
+	// batches are staged as Avro files and loaded into the raw table with COPY INTO
+	writeModeCopy = "copy"
+	// batches are appended to the raw table through a Snowpipe Streaming channel, skipping the stage
+	writeModeSnowpipeStreaming = "snowpipe_streaming"
+
+	// appends are limited to 16MB, leave room for the row that crosses the limit
+	snowpipeMaxAppendBytes     = 8 << 20
+	snowpipeCommitPollInterval = 500 * time.Millisecond
+	// scoped tokens are valid for an hour, renew well before that
+	snowpipeTokenLifetime = 50 * time.Minute
+
+	createRawTablePipeSQL = `CREATE PIPE IF NOT EXISTS %s.%s AS COPY INTO %s.%s FROM (SELECT $1:_peerdb_uid,
+		$1:_peerdb_timestamp,$1:_peerdb_destination_table_name,$1:_peerdb_data,$1:_peerdb_record_type,
+		$1:_peerdb_match_data,$1:_peerdb_batch_id,$1:_peerdb_unchanged_toast_columns
+		FROM TABLE(DATA_SOURCE(TYPE => 'STREAMING')))`
+	dropPipeIfExistsSQL = "DROP PIPE IF EXISTS %s.%s"
+)
+
+func getWriteMode(ctx context.Context, env map[string]string) (string, error) {
+	mode, err := internal.PeerDBSnowflakeWriteMode(ctx, env)
+	if err != nil {
+		return "", err
+	}
+	switch mode {
+	case "", writeModeCopy:
+		return writeModeCopy, nil
+	case writeModeSnowpipeStreaming:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown Snowflake write mode %q, expected %s or %s", mode, writeModeCopy, writeModeSnowpipeStreaming)
+	}
+}
+
+func getRawTablePipeIdentifier(rawTableIdentifier string) string {
+	return rawTableIdentifier + "_PIPE"
+}
+
+// snowpipeStreamingClient talks to the Snowpipe Streaming REST API with the key pair the peer already uses
+type snowpipeStreamingClient struct {
+	httpClient  *http.Client
+	config      *protos.SnowflakeConfig
+	privateKey  *rsa.PrivateKey
+	ingestHost  string
+	token       string
+	tokenExpiry time.Time
+}
+
+func newSnowpipeStreamingClient(config *protos.SnowflakeConfig, privateKey *rsa.PrivateKey) *snowpipeStreamingClient {
+	return &snowpipeStreamingClient{
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		config:     config,
+		privateKey: privateKey,
+	}
+}
+
+func (s *snowpipeStreamingClient) accountURL() string {
+	return "https://" + strings.ReplaceAll(strings.ToLower(s.config.AccountId), "_", "-") + ".snowflakecomputing.com"
+}
+
+// keypairJWT is the same JWT the driver authenticates with
+func (s *snowpipeStreamingClient) keypairJWT() (string, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(s.privateKey.Public())
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	fingerprint := sha256.Sum256(publicKey)
+	account, _, _ := strings.Cut(strings.ToUpper(s.config.AccountId), ".")
+	user := strings.ToUpper(s.config.Username)
+	now := time.Now()
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": fmt.Sprintf("%s.%s.SHA256:%s", account, user, base64.StdEncoding.EncodeToString(fingerprint[:])),
+		"sub": fmt.Sprintf("%s.%s", account, user),
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}).SignedString(s.privateKey)
+}
+
+// authenticate looks up the ingest host of the account and exchanges the key pair JWT for a token scoped to it
+func (s *snowpipeStreamingClient) authenticate(ctx context.Context) error {
+	if s.token != "" && time.Now().Before(s.tokenExpiry) {
+		return nil
+	}
+	keypairJWT, err := s.keypairJWT()
+	if err != nil {
+		return fmt.Errorf("failed to create JWT for Snowpipe Streaming: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.accountURL()+"/v2/streaming/hostname", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+keypairJWT)
+	req.Header.Set("X-Snowflake-Authorization-Token-Type", "KEYPAIR_JWT")
+	hostname, err := s.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to get Snowpipe Streaming hostname: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"scope":      {strings.TrimSpace(string(hostname))},
+		"assertion":  {keypairJWT},
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, s.accountURL()+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	token, err := s.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to get Snowpipe Streaming token: %w", err)
+	}
+
+	s.ingestHost = strings.TrimSpace(string(hostname))
+	s.token = strings.TrimSpace(string(token))
+	s.tokenExpiry = time.Now().Add(snowpipeTokenLifetime)
+	return nil
+}
+
+func (s *snowpipeStreamingClient) send(req *http.Request) ([]byte, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &snowpipeStatusError{method: req.Method, path: req.URL.Path, status: resp.StatusCode, body: body}
+	}
+	return body, nil
+}
+
+type snowpipeStatusError struct {
+	method string
+	path   string
+	body   []byte
+	status int
+}
+
+func (e *snowpipeStatusError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d: %s", e.method, e.path, e.status, e.body)
+}
+
+func (s *snowpipeStreamingClient) do(
+	ctx context.Context, method string, path string, query url.Values, contentType string, body []byte, out any,
+) error {
+	respBody, err := s.sendAuthenticated(ctx, method, path, query, contentType, body)
+	var statusErr *snowpipeStatusError
+	if errors.As(err, &statusErr) && statusErr.status == http.StatusUnauthorized {
+		// token revoked or expired early, authenticate again rather than failing the batch
+		s.token = ""
+		respBody, err = s.sendAuthenticated(ctx, method, path, query, contentType, body)
+	}
+	if err != nil {
+		return err
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to parse response of %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+func (s *snowpipeStreamingClient) sendAuthenticated(
+	ctx context.Context, method string, path string, query url.Values, contentType string, body []byte,
+) ([]byte, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	u := url.URL{Scheme: "https", Host: s.ingestHost, Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", contentType)
+	return s.send(req)
+}
+
+func (s *snowpipeStreamingClient) pipePath(schema string, pipe string) string {
+	return fmt.Sprintf("/v2/streaming/databases/%s/schemas/%s/pipes/%s",
+		url.PathEscape(strings.ToUpper(s.config.Database)), url.PathEscape(strings.ToUpper(schema)),
+		url.PathEscape(strings.ToUpper(pipe)))
+}
+
+// openChannel (re)opens channel, dropping anything a previous client appended but Snowflake did not commit yet
+func (s *snowpipeStreamingClient) openChannel(
+	ctx context.Context, schema string, pipe string, channel string,
+) (string, string, error) {
+	var resp struct {
+		NextContinuationToken string `json:"next_continuation_token"`
+		ChannelStatus         struct {
+			LastCommittedOffsetToken string `json:"last_committed_offset_token"`
+		} `json:"channel_status"`
+	}
+	if err := s.do(ctx, http.MethodPut, s.pipePath(schema, pipe)+"/channels/"+url.PathEscape(channel),
+		nil, "application/json", []byte("{}"), &resp,
+	); err != nil {
+		return "", "", fmt.Errorf("failed to open Snowpipe Streaming channel %s: %w", channel, err)
+	}
+	return resp.NextContinuationToken, resp.ChannelStatus.LastCommittedOffsetToken, nil
+}
+
+func (s *snowpipeStreamingClient) appendRows(
+	ctx context.Context, schema string, pipe string, channel string, continuationToken string, offsetToken string, rows []byte,
+) (string, error) {
+	var resp struct {
+		NextContinuationToken string `json:"next_continuation_token"`
+	}
+	if err := s.do(ctx, http.MethodPost,
+		"/v2/streaming/data"+strings.TrimPrefix(s.pipePath(schema, pipe), "/v2/streaming")+"/channels/"+url.PathEscape(channel)+"/rows",
+		url.Values{"continuationToken": {continuationToken}, "offsetToken": {offsetToken}},
+		"application/x-ndjson", rows, &resp,
+	); err != nil {
+		return "", fmt.Errorf("failed to append rows to Snowpipe Streaming channel %s: %w", channel, err)
+	}
+	return resp.NextContinuationToken, nil
+}
+
+func (s *snowpipeStreamingClient) committedOffsetToken(ctx context.Context, schema string, pipe string, channel string) (string, error) {
+	var resp struct {
+		ChannelStatuses map[string]struct {
+			CommittedOffsetToken string `json:"committed_offset_token"`
+		} `json:"channel_statuses"`
+	}
+	request, err := json.Marshal(map[string][]string{"channel_names": {channel}})
+	if err != nil {
+		return "", err
+	}
+	if err := s.do(ctx, http.MethodPost, s.pipePath(schema, pipe)+":bulk-channel-status",
+		nil, "application/json", request, &resp,
+	); err != nil {
+		return "", fmt.Errorf("failed to get status of Snowpipe Streaming channel %s: %w", channel, err)
+	}
+	return resp.ChannelStatuses[channel].CommittedOffsetToken, nil
+}
+
+// syncRecordsViaSnowpipeStreaming appends the batch to the raw table through a channel per mirror,
+// then waits for Snowflake to commit it so normalize sees the whole batch.
+// The last append of a batch carries the batch id as offset token and earlier ones batch id and chunk,
+// so a retried batch is skipped when it was committed and replaced when it was committed in part
+func (c *SnowflakeConnector) syncRecordsViaSnowpipeStreaming(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	rawTableIdentifier string,
+	syncBatchID int64,
+) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	streamReq := model.NewRecordsToStreamRequest(
+		req.Records.GetRecords(), tableNameRowsMapping, syncBatchID, false, protos.DBType_SNOWFLAKE,
+	)
+	stream, err := utils.RecordsToRawTableStream(ctx, req.Env, req.FlowJobName, streamReq, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+	}
+	schema, err := stream.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := getRawTablePipeIdentifier(rawTableIdentifier)
+	if _, err := c.ExecContext(ctx,
+		fmt.Sprintf(createRawTablePipeSQL, c.rawSchema, pipe, c.rawSchema, rawTableIdentifier),
+	); err != nil {
+		return nil, fmt.Errorf("failed to create pipe for raw table: %w", err)
+	}
+	channel := req.FlowJobName
+	continuationToken, committedToken, err := c.snowpipe.openChannel(ctx, c.rawSchema, pipe, channel)
+	if err != nil {
+		return nil, err
+	}
+	batchToken := strconv.FormatInt(syncBatchID, 10)
+	alreadyCommitted := committedToken == batchToken
+	if !alreadyCommitted && strings.HasPrefix(committedToken, batchToken+"-") {
+		c.logger.Info("removing partially committed batch from raw table", slog.Int64("batchID", syncBatchID))
+		if _, err := c.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s.%s WHERE _PEERDB_BATCH_ID = %d",
+			c.rawSchema, rawTableIdentifier, syncBatchID)); err != nil {
+			return nil, fmt.Errorf("failed to remove partially committed batch from raw table: %w", err)
+		}
+	}
+
+	var numRecords int64
+	var chunk int
+	var rows bytes.Buffer
+	row := make(map[string]any, len(schema.Fields))
+	for record := range stream.Records {
+		numRecords += 1
+		if alreadyCommitted {
+			continue
+		}
+		for idx, value := range record {
+			row[schema.Fields[idx].Name] = value.Value()
+		}
+		encoded, err := json.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode raw table row: %w", err)
+		}
+		rows.Write(encoded)
+		rows.WriteByte('\n')
+		if rows.Len() >= snowpipeMaxAppendBytes {
+			chunk += 1
+			if continuationToken, err = c.snowpipe.appendRows(ctx, c.rawSchema, pipe, channel, continuationToken,
+				fmt.Sprintf("%s-%d", batchToken, chunk), rows.Bytes(),
+			); err != nil {
+				return nil, err
+			}
+			rows.Reset()
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	if alreadyCommitted {
+		c.logger.Info("batch was already committed through Snowpipe Streaming", slog.Int64("batchID", syncBatchID))
+	} else if numRecords > 0 {
+		if _, err := c.snowpipe.appendRows(ctx, c.rawSchema, pipe, channel, continuationToken, batchToken, rows.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := c.waitForSnowpipeCommit(ctx, pipe, channel, batchToken); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.Env, req.FlowJobName, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: req.Records.GetLastCheckpoint(),
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   syncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *SnowflakeConnector) waitForSnowpipeCommit(ctx context.Context, pipe string, channel string, offsetToken string) error {
+	start := time.Now()
+	ticker := time.NewTicker(snowpipeCommitPollInterval)
+	defer ticker.Stop()
+	for {
+		committedToken, err := c.snowpipe.committedOffsetToken(ctx, c.rawSchema, pipe, channel)
+		if err != nil {
+			return err
+		}
+		if committedToken == offsetToken {
+			c.logger.Info("Snowpipe Streaming committed batch",
+				slog.String("offsetToken", offsetToken), slog.Duration("waited", time.Since(start)))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for Snowpipe Streaming to commit %s: %w", offsetToken, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package connsnowflake
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestSnowpipeKeypairJWT(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	client := newSnowpipeStreamingClient(&protos.SnowflakeConfig{
+		AccountId: "myorg-my_account.us-east-1",
+		Username:  "peerdb_user",
+	}, privateKey)
+	require.Equal(t, "https://myorg-my-account.us-east-1.snowflakecomputing.com", client.accountURL())
+
+	token, err := client.keypairJWT()
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return &privateKey.PublicKey, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "MYORG-MY_ACCOUNT.PEERDB_USER", claims["sub"])
+	iss, ok := claims["iss"].(string)
+	require.True(t, ok)
+	require.True(t, strings.HasPrefix(iss, "MYORG-MY_ACCOUNT.PEERDB_USER.SHA256:"))
+}
+
+func TestGetWriteMode(t *testing.T) {
+	mode, err := getWriteMode(t.Context(), map[string]string{"PEERDB_SNOWFLAKE_WRITE_MODE": ""})
+	require.NoError(t, err)
+	require.Equal(t, writeModeCopy, mode)
+
+	mode, err = getWriteMode(t.Context(), map[string]string{"PEERDB_SNOWFLAKE_WRITE_MODE": "snowpipe_streaming"})
+	require.NoError(t, err)
+	require.Equal(t, writeModeSnowpipeStreaming, mode)
+
+	_, err = getWriteMode(t.Context(), map[string]string{"PEERDB_SNOWFLAKE_WRITE_MODE": "kafka"})
+	require.Error(t, err)
+}
@@ -0,0 +1,267 @@
+package connsqlserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/alerting"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// how long to wait before polling for changes again after finding none
+const changePollInterval = 5 * time.Second
+
+func (c *SqlServerConnector) EnsurePullability(
+	ctx context.Context, req *protos.EnsurePullabilityBatchInput,
+) (*protos.EnsurePullabilityBatchOutput, error) {
+	for _, table := range req.SourceTableIdentifiers {
+		if err := c.checkTableTracked(ctx, table); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (c *SqlServerConnector) checkTableTracked(ctx context.Context, table string) error {
+	query := "SELECT CAST(COUNT(*) AS BIT) FROM cdc.change_tables WHERE source_object_id = OBJECT_ID(@p1)"
+	hint := "enable it with sys.sp_cdc_enable_table"
+	if c.config.ReplicationMechanism == protos.SqlServerReplicationMechanism_SQLSERVER_CHANGE_TRACKING {
+		query = "SELECT CAST(COUNT(*) AS BIT) FROM sys.change_tracking_tables WHERE object_id = OBJECT_ID(@p1)"
+		hint = "enable it with ALTER TABLE ... ENABLE CHANGE_TRACKING"
+	}
+	parsedTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return err
+	}
+	var tracked bool
+	if err := c.db.QueryRowContext(ctx, query, parsedTable.SqlServer()).Scan(&tracked); err != nil {
+		return fmt.Errorf("failed to check if changes of %s are tracked: %w", table, err)
+	}
+	if !tracked {
+		return fmt.Errorf("changes of table %s are not tracked, %s", table, hint)
+	}
+	return nil
+}
+
+func (c *SqlServerConnector) ExportTxSnapshot(context.Context, map[string]string) (*protos.ExportTxSnapshotOutput, any, error) {
+	return nil, nil, nil
+}
+
+func (c *SqlServerConnector) FinishExport(any) error {
+	return nil
+}
+
+// SetupReplication records the current position of the change stream, the initial load runs after this,
+// so changes made while it runs are replayed on top of it
+func (c *SqlServerConnector) SetupReplication(
+	ctx context.Context,
+	req *protos.SetupReplicationInput,
+) (model.SetupReplicationResult, error) {
+	offset, err := c.getCurrentOffset(ctx)
+	if err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("[sqlserver] SetupReplication failed to get current offset: %w", err)
+	}
+	if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{Text: offset}); err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("[sqlserver] SetupReplication failed to SetLastOffset: %w", err)
+	}
+	return model.SetupReplicationResult{}, nil
+}
+
+// getCurrentOffset returns the latest LSN in hex for CDC, or the current change tracking version
+func (c *SqlServerConnector) getCurrentOffset(ctx context.Context) (string, error) {
+	if c.config.ReplicationMechanism == protos.SqlServerReplicationMechanism_SQLSERVER_CHANGE_TRACKING {
+		var version sql.NullInt64
+		if err := c.db.QueryRowContext(ctx, "SELECT CHANGE_TRACKING_CURRENT_VERSION()").Scan(&version); err != nil {
+			return "", err
+		}
+		if !version.Valid {
+			return "", errors.New("change tracking is not enabled on database")
+		}
+		return strconv.FormatInt(version.Int64, 10), nil
+	}
+
+	var lsn []byte
+	if err := c.db.QueryRowContext(ctx, "SELECT sys.fn_cdc_get_max_lsn()").Scan(&lsn); err != nil {
+		return "", err
+	}
+	if lsn == nil {
+		return "", errors.New("no max lsn, is change data capture enabled and the capture job running?")
+	}
+	return hex.EncodeToString(lsn), nil
+}
+
+func (c *SqlServerConnector) SetupReplConn(context.Context) error {
+	return nil
+}
+
+func (c *SqlServerConnector) ReplPing(context.Context) error {
+	return nil
+}
+
+func (c *SqlServerConnector) UpdateReplStateLastOffset(ctx context.Context, lastOffset model.CdcCheckpoint) error {
+	flowName := ctx.Value(shared.FlowNameKey).(string)
+	return c.SetLastOffset(ctx, flowName, lastOffset)
+}
+
+func (c *SqlServerConnector) PullFlowCleanup(context.Context, string) error {
+	return nil
+}
+
+func (c *SqlServerConnector) HandleSlotInfo(
+	ctx context.Context,
+	alerter *alerting.Alerter,
+	catalogPool shared.CatalogPool,
+	alertKeys *alerting.AlertKeys,
+	slotMetricGauges otel_metrics.SlotMetricGauges,
+) error {
+	return nil
+}
+
+func (c *SqlServerConnector) GetSlotInfo(context.Context, string) ([]*protos.SlotInfo, error) {
+	return nil, nil
+}
+
+func (c *SqlServerConnector) AddTablesToPublication(context.Context, *protos.AddTablesToPublicationInput) error {
+	return nil
+}
+
+func (c *SqlServerConnector) RemoveTablesFromPublication(context.Context, *protos.RemoveTablesFromPublicationInput) error {
+	return nil
+}
+
+// PullRecords polls for changes since the last offset, each poll reads all tables up to the same offset,
+// so a batch always ends at an offset every table has been read up to,
+// and its limits on records and bytes are only checked between polls
+func (c *SqlServerConnector) PullRecords(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	otelManager *otel_metrics.OtelManager,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	defer req.RecordStream.Close()
+
+	sourceSchemaAsDestinationColumn, err := internal.PeerDBSourceSchemaAsDestinationColumn(ctx, req.Env)
+	if err != nil {
+		return err
+	}
+
+	var recordCount uint32
+	// approximate size of the values of records in the batch
+	var batchBytes uint64
+	defer func() {
+		if recordCount == 0 {
+			req.RecordStream.SignalAsEmpty()
+		}
+		c.logger.Info("[sqlserver] PullRecords finished streaming", slog.Uint64("records", uint64(recordCount)))
+	}()
+
+	// like binlog streaming, wait up to an hour for a first record, then up to the idle timeout for more
+	deadline := time.Now().Add(time.Hour)
+	addRecord := func(ctx context.Context, record model.Record[model.RecordItems]) error {
+		if sourceSchemaAsDestinationColumn {
+			if parsedTable, err := utils.ParseSchemaTable(record.GetSourceTableName()); err == nil {
+				record.GetItems().AddColumn("_peerdb_source_schema", types.QValueString{Val: parsedTable.Schema})
+			}
+		}
+		recordCount += 1
+		batchBytes += itemsBytes(record.GetItems())
+		if err := req.RecordStream.AddRecord(ctx, record); err != nil {
+			return err
+		}
+		if recordCount == 1 {
+			req.RecordStream.SignalAsNotEmpty()
+			deadline = time.Now().Add(req.IdleTimeout)
+		}
+		return nil
+	}
+
+	readChanges := c.readCdcChanges
+	if c.config.ReplicationMechanism == protos.SqlServerReplicationMechanism_SQLSERVER_CHANGE_TRACKING {
+		readChanges = c.readChangeTrackingChanges
+	}
+
+	offset := req.LastOffset.Text
+	for recordCount < req.MaxBatchSize && (req.MaxBatchBytes == 0 || batchBytes < req.MaxBatchBytes) && time.Now().Before(deadline) {
+		currentOffset, err := c.getCurrentOffset(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get current offset: %w", err)
+		}
+
+		if currentOffset != offset {
+			for sourceTableName := range req.TableNameMapping {
+				if err := readChanges(ctx, req, sourceTableName, offset, currentOffset, addRecord); err != nil {
+					return fmt.Errorf("failed to read changes of %s: %w", sourceTableName, err)
+				}
+			}
+			offset = currentOffset
+			req.RecordStream.UpdateLatestCheckpointText(offset)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info("[sqlserver] PullRecords context canceled, stopping polling")
+			return ctx.Err()
+		case <-time.After(min(changePollInterval, time.Until(deadline))):
+		}
+	}
+
+	if recordCount == 0 && offset != req.LastOffset.Text {
+		// progress offset while no records read to avoid falling behind when all tables inactive
+		c.logger.Info("[sqlserver] updating inactive offset", slog.String("offset", offset))
+		if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{Text: offset}); err != nil {
+			c.logger.Error("[sqlserver] failed to update offset, ignoring", slog.Any("error", err))
+		}
+	}
+	return nil
+}
+
+// itemsBytes approximates the size of record items by the length of their text and binary values,
+// counting other values as 8 bytes
+func itemsBytes(items model.RecordItems) uint64 {
+	var size uint64
+	for _, qv := range items.ColToVal {
+		if qv == nil {
+			continue
+		}
+		switch v := qv.Value().(type) {
+		case string:
+			size += uint64(len(v))
+		case []byte:
+			size += uint64(len(v))
+		default:
+			size += 8
+		}
+	}
+	return size
+}
+
+// rowItems converts the columns of a row that are part of the table schema to record items
+func rowItems(schema *protos.TableSchema, columns []string, values []any) (model.RecordItems, error) {
+	items := model.NewRecordItems(len(schema.Columns))
+	for idx, column := range columns {
+		for _, fd := range schema.Columns {
+			if fd.Name == column {
+				qv, err := qvalueFromSqlServer(types.QValueKind(fd.Type), values[idx])
+				if err != nil {
+					return model.RecordItems{}, fmt.Errorf("could not convert sql server value for %s: %w", column, err)
+				}
+				items.AddColumn(column, qv)
+				break
+			}
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,173 @@
+package connsqlserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// operations in __$operation of cdc capture tables
+const (
+	cdcOperationDelete       = 1
+	cdcOperationInsert       = 2
+	cdcOperationUpdateBefore = 3
+	cdcOperationUpdateAfter  = 4
+)
+
+// getCaptureInstance returns the newest capture instance of a table, while a table has two
+// after a schema change the newer one is the one which has the new columns
+func (c *SqlServerConnector) getCaptureInstance(ctx context.Context, sourceTableName string) (string, error) {
+	parsedTable, err := utils.ParseSchemaTable(sourceTableName)
+	if err != nil {
+		return "", err
+	}
+	var captureInstance string
+	if err := c.db.QueryRowContext(ctx, `SELECT TOP 1 capture_instance FROM cdc.change_tables
+		WHERE source_object_id = OBJECT_ID(@p1) ORDER BY create_date DESC`, parsedTable.SqlServer(),
+	).Scan(&captureInstance); err != nil {
+		return "", fmt.Errorf("failed to get capture instance of %s: %w", sourceTableName, err)
+	}
+	return captureInstance, nil
+}
+
+// readCdcChanges reads changes committed after LSN from up to and including LSN to from the capture table
+func (c *SqlServerConnector) readCdcChanges(
+	ctx context.Context, req *model.PullRecordsRequest[model.RecordItems], sourceTableName string, from string, to string,
+	addRecord func(context.Context, model.Record[model.RecordItems]) error,
+) error {
+	fromLSN, err := hex.DecodeString(from)
+	if err != nil {
+		return fmt.Errorf("invalid lsn %s: %w", from, err)
+	}
+	toLSN, err := hex.DecodeString(to)
+	if err != nil {
+		return fmt.Errorf("invalid lsn %s: %w", to, err)
+	}
+
+	captureInstance, err := c.getCaptureInstance(ctx, sourceTableName)
+	if err != nil {
+		return err
+	}
+	var startLSN, minLSN []byte
+	if err := c.db.QueryRowContext(ctx, "SELECT sys.fn_cdc_increment_lsn(@p1), sys.fn_cdc_get_min_lsn(@p2)",
+		fromLSN, captureInstance,
+	).Scan(&startLSN, &minLSN); err != nil {
+		return fmt.Errorf("failed to get lsn range of %s: %w", captureInstance, err)
+	}
+	if bytes.Compare(toLSN, minLSN) < 0 {
+		// capture instance created after the range, nothing to read yet
+		return nil
+	}
+	if bytes.Compare(startLSN, minLSN) < 0 {
+		// either the capture instance is newer than the offset, like when a table was added to the mirror,
+		// or cleanup removed changes which were never read
+		c.logger.Warn("[sqlserver] capture instance starts after offset, reading from its start",
+			slog.String("captureInstance", captureInstance), slog.String("offset", from),
+			slog.String("minLSN", hex.EncodeToString(minLSN)))
+		startLSN = minLSN
+	}
+
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`SELECT sys.fn_cdc_map_lsn_to_time(__$start_lsn) AS [__$commit_time], *
+		FROM cdc.%s(@p1, @p2, N'all update old') ORDER BY __$start_lsn, __$seqval, __$operation`,
+		utils.QuoteSqlServerIdentifier("fn_cdc_get_all_changes_"+captureInstance)), startLSN, toLSN)
+	if err != nil {
+		return fmt.Errorf("failed to query changes of %s: %w", captureInstance, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	commitTimeIdx, operationIdx := -1, -1
+	for idx, column := range columns {
+		switch column {
+		case "__$commit_time":
+			commitTimeIdx = idx
+		case "__$operation":
+			operationIdx = idx
+		}
+	}
+	if commitTimeIdx == -1 || operationIdx == -1 {
+		return fmt.Errorf("unexpected columns from capture instance %s: %v", captureInstance, columns)
+	}
+	// metadata columns are not part of the schema, rowItems skips them
+	for idx, column := range columns {
+		if strings.HasPrefix(column, "__$") {
+			columns[idx] = ""
+		}
+	}
+
+	destinationTableName := req.TableNameMapping[sourceTableName].Name
+	schema := req.TableNameSchemaMapping[destinationTableName]
+	if schema == nil {
+		return fmt.Errorf("no schema for %s", destinationTableName)
+	}
+
+	values := make([]any, len(columns))
+	scanArgs := make([]any, len(columns))
+	for idx := range values {
+		scanArgs[idx] = &values[idx]
+	}
+	oldItems := model.NewRecordItems(0)
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		items, err := rowItems(schema, columns, values)
+		if err != nil {
+			return err
+		}
+		var commitTimeNano int64
+		if commitTime, ok := values[commitTimeIdx].(time.Time); ok {
+			commitTimeNano = commitTime.UnixNano()
+		}
+		operation, ok := values[operationIdx].(int64)
+		if !ok {
+			return fmt.Errorf("unexpected operation %v from capture instance %s", values[operationIdx], captureInstance)
+		}
+
+		var record model.Record[model.RecordItems]
+		switch operation {
+		case cdcOperationDelete:
+			record = &model.DeleteRecord[model.RecordItems]{
+				BaseRecord:           model.BaseRecord{CommitTimeNano: commitTimeNano},
+				Items:                items,
+				SourceTableName:      sourceTableName,
+				DestinationTableName: destinationTableName,
+			}
+		case cdcOperationInsert:
+			record = &model.InsertRecord[model.RecordItems]{
+				BaseRecord:           model.BaseRecord{CommitTimeNano: commitTimeNano},
+				Items:                items,
+				SourceTableName:      sourceTableName,
+				DestinationTableName: destinationTableName,
+			}
+		case cdcOperationUpdateBefore:
+			oldItems = items
+			continue
+		case cdcOperationUpdateAfter:
+			record = &model.UpdateRecord[model.RecordItems]{
+				BaseRecord:           model.BaseRecord{CommitTimeNano: commitTimeNano},
+				OldItems:             oldItems,
+				NewItems:             items,
+				SourceTableName:      sourceTableName,
+				DestinationTableName: destinationTableName,
+			}
+			oldItems = model.NewRecordItems(0)
+		default:
+			return fmt.Errorf("unknown operation %d from capture instance %s", operation, captureInstance)
+		}
+		if err := addRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
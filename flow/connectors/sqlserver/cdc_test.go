@@ -0,0 +1,20 @@
+package connsqlserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestItemsBytes(t *testing.T) {
+	items := model.NewRecordItems(4)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	items.AddColumn("name", types.QValueString{Val: "abc"})
+	items.AddColumn("blob", types.QValueBytes{Val: []byte{1, 2}})
+	items.AddColumn("missing", types.QValueNull(types.QValueKindString))
+	require.Equal(t, uint64(8+3+2+8), itemsBytes(items))
+	require.Zero(t, itemsBytes(model.NewRecordItems(0)))
+}
@@ -0,0 +1,148 @@
+package connsqlserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// readChangeTrackingChanges reads rows changed after version from up to and including version to.
+// Change tracking only keeps primary keys of changed rows, so rows are read as they currently are
+// and deletes carry the primary key alone. A row changed again after version to is left for the next read.
+func (c *SqlServerConnector) readChangeTrackingChanges(
+	ctx context.Context, req *model.PullRecordsRequest[model.RecordItems], sourceTableName string, from string, to string,
+	addRecord func(context.Context, model.Record[model.RecordItems]) error,
+) error {
+	fromVersion, err := strconv.ParseInt(from, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid change tracking version %s: %w", from, err)
+	}
+	toVersion, err := strconv.ParseInt(to, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid change tracking version %s: %w", to, err)
+	}
+
+	destinationTableName := req.TableNameMapping[sourceTableName].Name
+	schema := req.TableNameSchemaMapping[destinationTableName]
+	if schema == nil {
+		return fmt.Errorf("no schema for %s", destinationTableName)
+	}
+	if len(schema.PrimaryKeyColumns) == 0 {
+		return fmt.Errorf("change tracking requires a primary key on %s", sourceTableName)
+	}
+	parsedTable, err := utils.ParseSchemaTable(sourceTableName)
+	if err != nil {
+		return err
+	}
+	quotedTable := parsedTable.SqlServer()
+
+	var minValidVersion *int64
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT CHANGE_TRACKING_MIN_VALID_VERSION(OBJECT_ID(@p1))", quotedTable,
+	).Scan(&minValidVersion); err != nil {
+		return fmt.Errorf("failed to get min valid change tracking version of %s: %w", sourceTableName, err)
+	}
+	if minValidVersion == nil {
+		return fmt.Errorf("change tracking is not enabled on %s", sourceTableName)
+	} else if *minValidVersion > fromVersion {
+		return fmt.Errorf("changes of %s after version %d were cleaned up before being read, resync is required",
+			sourceTableName, fromVersion)
+	}
+
+	keySelect := make([]string, 0, len(schema.PrimaryKeyColumns))
+	keyJoin := make([]string, 0, len(schema.PrimaryKeyColumns))
+	for idx, column := range schema.PrimaryKeyColumns {
+		quotedColumn := utils.QuoteSqlServerIdentifier(column)
+		keySelect = append(keySelect, fmt.Sprintf("ct.%s AS [__$key_%d]", quotedColumn, idx))
+		keyJoin = append(keyJoin, fmt.Sprintf("t.%s = ct.%s", quotedColumn, quotedColumn))
+	}
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`SELECT ct.SYS_CHANGE_OPERATION AS [__$operation],
+		CAST(CASE WHEN t.%[1]s IS NULL THEN 0 ELSE 1 END AS BIT) AS [__$exists], %[2]s, t.*
+		FROM CHANGETABLE(CHANGES %[3]s, @p1) AS ct LEFT JOIN %[3]s AS t ON %[4]s
+		WHERE ct.SYS_CHANGE_VERSION <= @p2 ORDER BY ct.SYS_CHANGE_VERSION`,
+		utils.QuoteSqlServerIdentifier(schema.PrimaryKeyColumns[0]), strings.Join(keySelect, ", "),
+		quotedTable, strings.Join(keyJoin, " AND ")), fromVersion, toVersion)
+	if err != nil {
+		return fmt.Errorf("failed to query changes of %s: %w", sourceTableName, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	// operation, existence and keys come first, the row follows
+	metadataColumns := 2 + len(schema.PrimaryKeyColumns)
+	keyColumns := make([]string, metadataColumns)
+	copy(keyColumns[2:], schema.PrimaryKeyColumns)
+	rowColumns := columns[metadataColumns:]
+
+	values := make([]any, len(columns))
+	scanArgs := make([]any, len(columns))
+	for idx := range values {
+		scanArgs[idx] = &values[idx]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		operation, ok := values[0].(string)
+		if !ok {
+			return fmt.Errorf("unexpected change tracking operation %v on %s", values[0], sourceTableName)
+		}
+		exists, _ := values[1].(bool)
+		// commit time of a change is only kept in sys.dm_tran_commit_table, which needs VIEW DATABASE STATE
+		commitTimeNano := time.Now().UnixNano()
+
+		var record model.Record[model.RecordItems]
+		switch operation {
+		case "D":
+			keyItems, err := rowItems(schema, keyColumns, values[:metadataColumns])
+			if err != nil {
+				return err
+			}
+			record = &model.DeleteRecord[model.RecordItems]{
+				BaseRecord:           model.BaseRecord{CommitTimeNano: commitTimeNano},
+				Items:                keyItems,
+				SourceTableName:      sourceTableName,
+				DestinationTableName: destinationTableName,
+			}
+		case "I", "U":
+			if !exists {
+				// deleted since, the delete is read next time
+				continue
+			}
+			items, err := rowItems(schema, rowColumns, values[metadataColumns:])
+			if err != nil {
+				return err
+			}
+			if operation == "I" {
+				record = &model.InsertRecord[model.RecordItems]{
+					BaseRecord:           model.BaseRecord{CommitTimeNano: commitTimeNano},
+					Items:                items,
+					SourceTableName:      sourceTableName,
+					DestinationTableName: destinationTableName,
+				}
+			} else {
+				record = &model.UpdateRecord[model.RecordItems]{
+					BaseRecord:           model.BaseRecord{CommitTimeNano: commitTimeNano},
+					OldItems:             model.NewRecordItems(0),
+					NewItems:             items,
+					SourceTableName:      sourceTableName,
+					DestinationTableName: destinationTableName,
+				}
+			}
+		default:
+			return fmt.Errorf("unknown change tracking operation %s on %s", operation, sourceTableName)
+		}
+		if err := addRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,219 @@
+package connsqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const fullTablePartitionID = "sqlserver-full-table-partition-id"
+
+func (c *SqlServerConnector) GetQRepPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkColumn == "" {
+		// if no watermark column is specified, return a single partition
+		return []*protos.QRepPartition{
+			{
+				PartitionId:        fullTablePartitionID,
+				Range:              nil,
+				FullTablePartition: true,
+			},
+		}, nil
+	}
+
+	if config.NumRowsPerPartition <= 0 {
+		return nil, errors.New("num rows per partition must be greater than 0")
+	}
+
+	parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse watermark table %s: %w", config.WatermarkTable, err)
+	}
+	quotedWatermarkColumn := utils.QuoteSqlServerIdentifier(config.WatermarkColumn)
+
+	whereClause := ""
+	var args []any
+	if last != nil && last.Range != nil {
+		whereClause = fmt.Sprintf("WHERE %s > @p1", quotedWatermarkColumn)
+		switch lastRange := last.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = append(args, lastRange.IntRange.End)
+		case *protos.PartitionRange_UintRange:
+			args = append(args, int64(lastRange.UintRange.End))
+		case *protos.PartitionRange_TimestampRange:
+			args = append(args, lastRange.TimestampRange.End.AsTime())
+		default:
+			return nil, fmt.Errorf("unsupported partition range type %T", lastRange)
+		}
+	}
+
+	var totalRows int64
+	countQuery := fmt.Sprintf("SELECT COUNT_BIG(*) FROM %s %s", parsedWatermarkTable.SqlServer(), whereClause)
+	if err := c.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalRows); err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	if totalRows == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return make([]*protos.QRepPartition, 0), nil
+	}
+
+	numRowsPerPartition := int64(config.NumRowsPerPartition)
+	numPartitions := totalRows / numRowsPerPartition
+	if totalRows%numRowsPerPartition != 0 {
+		numPartitions++
+	}
+	c.logger.Info(fmt.Sprintf("total rows: %d, num partitions: %d, num rows per partition: %d",
+		totalRows, numPartitions, numRowsPerPartition))
+
+	partitionsQuery := fmt.Sprintf(`SELECT MIN(w), MAX(w) FROM (
+		SELECT %[1]s AS w, NTILE(%[2]d) OVER (ORDER BY %[1]s) AS bucket FROM %[3]s %[4]s
+	) AS buckets GROUP BY bucket ORDER BY MIN(w)`,
+		quotedWatermarkColumn, numPartitions, parsedWatermarkTable.SqlServer(), whereClause)
+	c.logger.Info("partitions query", slog.String("query", partitionsQuery))
+	rows, err := c.db.QueryContext(ctx, partitionsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for partitions: %w", err)
+	}
+	defer rows.Close()
+
+	partitionHelper := utils.NewPartitionHelper(c.logger)
+	for rows.Next() {
+		var start, end any
+		if err := rows.Scan(&start, &end); err != nil {
+			return nil, err
+		}
+		if err := partitionHelper.AddPartition(start, end); err != nil {
+			return nil, fmt.Errorf("failed to add partition: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	return partitionHelper.GetPartitions(), nil
+}
+
+func (c *SqlServerConnector) PullQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	tableSchema, err := c.getTableSchemaForTable(ctx, config.Env,
+		&protos.TableMapping{SourceTableIdentifier: config.WatermarkTable}, protos.TypeSystem_Q)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get schema for watermark table %s: %w", config.WatermarkTable, err)
+	}
+
+	query := config.Query
+	var args []any
+	if !partition.FullTablePartition {
+		var rangeStart, rangeEnd any
+		switch x := partition.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			rangeStart, rangeEnd = x.IntRange.Start, x.IntRange.End
+		case *protos.PartitionRange_UintRange:
+			rangeStart, rangeEnd = int64(x.UintRange.Start), int64(x.UintRange.End)
+		case *protos.PartitionRange_TimestampRange:
+			rangeStart, rangeEnd = x.TimestampRange.Start.AsTime(), x.TimestampRange.End.AsTime()
+		default:
+			return 0, 0, fmt.Errorf("unknown range type: %v", x)
+		}
+		query = strings.NewReplacer("{{.start}}", "@start", "{{.end}}", "@end").Replace(query)
+		args = append(args, sql.Named("start", rangeStart), sql.Named("end", rangeEnd))
+	}
+
+	c.logger.Info("[sqlserver] pulling partition", slog.String("query", query), slog.String("partition", partition.PartitionId))
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query partition: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, 0, err
+	}
+	schema, err := qrecordSchemaFromColumnTypes(tableSchema, columnTypes)
+	if err != nil {
+		return 0, 0, err
+	}
+	stream.SetSchema(schema)
+
+	start := time.Now()
+	var totalRecords int64
+	values := make([]any, len(columnTypes))
+	scanArgs := make([]any, len(columnTypes))
+	for idx := range values {
+		scanArgs[idx] = &values[idx]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return 0, 0, err
+		}
+		record := make([]types.QValue, 0, len(values))
+		for idx, val := range values {
+			qv, err := qvalueFromSqlServer(schema.Fields[idx].Type, val)
+			if err != nil {
+				return 0, 0, fmt.Errorf("could not convert sql server value for %s: %w", schema.Fields[idx].Name, err)
+			}
+			record = append(record, qv)
+		}
+		stream.Records <- record
+		totalRecords += 1
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	c.logger.Info("[sqlserver] pulled partition", slog.Int64("records", totalRecords), slog.Duration("duration", time.Since(start)))
+	close(stream.Records)
+	return totalRecords, 0, nil
+}
+
+func qrecordSchemaFromColumnTypes(tableSchema *protos.TableSchema, columnTypes []*sql.ColumnType) (types.QRecordSchema, error) {
+	tableColumns := make(map[string]*protos.FieldDescription, len(tableSchema.Columns))
+	for _, col := range tableSchema.Columns {
+		tableColumns[col.Name] = col
+	}
+
+	fields := make([]types.QField, 0, len(columnTypes))
+	for _, columnType := range columnTypes {
+		var precision, scale int16
+		var qkind types.QValueKind
+		if col, ok := tableColumns[columnType.Name()]; ok {
+			qkind = types.QValueKind(col.Type)
+			if qkind == types.QValueKindNumeric {
+				precision, scale = datatypes.ParseNumericTypmod(col.TypeModifier)
+			}
+		} else {
+			var err error
+			qkind, err = qkindFromSqlServerType(columnType.DatabaseTypeName())
+			if err != nil {
+				return types.QRecordSchema{}, err
+			}
+		}
+		nullable, _ := columnType.Nullable()
+		fields = append(fields, types.QField{
+			Name:      columnType.Name(),
+			Type:      qkind,
+			Precision: precision,
+			Scale:     scale,
+			Nullable:  nullable,
+		})
+	}
+	return types.NewQRecordSchema(fields), nil
+}
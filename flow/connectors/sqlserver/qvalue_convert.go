@@ -0,0 +1,120 @@
+package connsqlserver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// qkindFromSqlServerType maps a type name as reported by information_schema or the driver to a QValueKind
+func qkindFromSqlServerType(dataType string) (types.QValueKind, error) {
+	switch strings.ToLower(dataType) {
+	case "bit":
+		return types.QValueKindBoolean, nil
+	case "tinyint":
+		return types.QValueKindUInt8, nil
+	case "smallint":
+		return types.QValueKindInt16, nil
+	case "int":
+		return types.QValueKindInt32, nil
+	case "bigint":
+		return types.QValueKindInt64, nil
+	case "real":
+		return types.QValueKindFloat32, nil
+	case "float":
+		return types.QValueKindFloat64, nil
+	case "decimal", "numeric", "money", "smallmoney":
+		return types.QValueKindNumeric, nil
+	case "char", "varchar", "text", "nchar", "nvarchar", "ntext", "xml", "sysname":
+		return types.QValueKindString, nil
+	case "binary", "varbinary", "image", "timestamp", "rowversion", "geography", "geometry", "hierarchyid":
+		return types.QValueKindBytes, nil
+	case "uniqueidentifier":
+		return types.QValueKindUUID, nil
+	case "date":
+		return types.QValueKindDate, nil
+	case "time":
+		return types.QValueKindTime, nil
+	case "datetime", "datetime2", "smalldatetime":
+		return types.QValueKindTimestamp, nil
+	case "datetimeoffset":
+		return types.QValueKindTimestampTZ, nil
+	default:
+		return types.QValueKind(""), fmt.Errorf("unsupported sql server type %s", dataType)
+	}
+}
+
+// qvalueFromSqlServer converts a value scanned by database/sql into the QValue of its column's kind
+func qvalueFromSqlServer(qkind types.QValueKind, val any) (types.QValue, error) {
+	if val == nil {
+		return types.QValueNull(qkind), nil
+	}
+
+	switch v := val.(type) {
+	case bool:
+		if qkind == types.QValueKindBoolean {
+			return types.QValueBoolean{Val: v}, nil
+		}
+	case int64:
+		switch qkind {
+		case types.QValueKindUInt8:
+			return types.QValueUInt8{Val: uint8(v)}, nil
+		case types.QValueKindInt16:
+			return types.QValueInt16{Val: int16(v)}, nil
+		case types.QValueKindInt32:
+			return types.QValueInt32{Val: int32(v)}, nil
+		case types.QValueKindInt64:
+			return types.QValueInt64{Val: v}, nil
+		}
+	case float64:
+		switch qkind {
+		case types.QValueKindFloat32:
+			return types.QValueFloat32{Val: float32(v)}, nil
+		case types.QValueKindFloat64:
+			return types.QValueFloat64{Val: v}, nil
+		}
+	case string:
+		if qkind == types.QValueKindString {
+			return types.QValueString{Val: v}, nil
+		}
+	case []byte:
+		switch qkind {
+		case types.QValueKindNumeric:
+			// decimal and money are returned as their text representation
+			d, err := decimal.NewFromString(string(v))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse decimal %s: %w", v, err)
+			}
+			return types.QValueNumeric{Val: d}, nil
+		case types.QValueKindUUID:
+			// uniqueidentifier is stored with its first three groups little endian
+			var u mssql.UniqueIdentifier
+			if err := u.Scan(v); err != nil {
+				return nil, err
+			}
+			return types.QValueUUID{Val: uuid.UUID(u)}, nil
+		case types.QValueKindBytes:
+			return types.QValueBytes{Val: v}, nil
+		case types.QValueKindString:
+			return types.QValueString{Val: string(v)}, nil
+		}
+	case time.Time:
+		switch qkind {
+		case types.QValueKindDate:
+			return types.QValueDate{Val: v}, nil
+		case types.QValueKindTime:
+			return types.QValueTime{Val: v.Sub(v.Truncate(24 * time.Hour))}, nil
+		case types.QValueKindTimestamp:
+			return types.QValueTimestamp{Val: v}, nil
+		case types.QValueKindTimestampTZ:
+			return types.QValueTimestampTZ{Val: v.UTC()}, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", val, qkind)
+}
@@ -0,0 +1,60 @@
+package connsqlserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestQValueFromSqlServer(t *testing.T) {
+	//nolint:govet
+	for _, tc := range []struct {
+		dataType string
+		in       any
+		out      types.QValue
+	}{
+		{"tinyint", int64(200), types.QValueUInt8{Val: 200}},
+		{"int", int64(-5), types.QValueInt32{Val: -5}},
+		{"bit", true, types.QValueBoolean{Val: true}},
+		{"real", float64(1.5), types.QValueFloat32{Val: 1.5}},
+		{"money", []byte("12.3400"), types.QValueNumeric{Val: decimal.RequireFromString("12.34")}},
+		{"nvarchar", "abc", types.QValueString{Val: "abc"}},
+		{"varbinary", []byte{1, 2}, types.QValueBytes{Val: []byte{1, 2}}},
+		{
+			"uniqueidentifier",
+			[]byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+			types.QValueUUID{Val: uuid.MustParse("00112233-4455-6677-8899-aabbccddeeff")},
+		},
+		{
+			"time",
+			time.Date(1, 1, 1, 13, 14, 15, 0, time.UTC),
+			types.QValueTime{Val: 13*time.Hour + 14*time.Minute + 15*time.Second},
+		},
+		{
+			"datetimeoffset",
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600)),
+			types.QValueTimestampTZ{Val: time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)},
+		},
+		{"date", nil, types.QValueNull(types.QValueKindDate)},
+	} {
+		qkind, err := qkindFromSqlServerType(tc.dataType)
+		require.NoError(t, err)
+		qv, err := qvalueFromSqlServer(qkind, tc.in)
+		require.NoError(t, err, tc.dataType)
+		if numeric, ok := tc.out.(types.QValueNumeric); ok {
+			require.True(t, numeric.Val.Equal(qv.(types.QValueNumeric).Val), tc.dataType)
+		} else {
+			require.Equal(t, tc.out, qv, tc.dataType)
+		}
+	}
+
+	_, err := qkindFromSqlServerType("sql_variant")
+	require.Error(t, err)
+	_, err = qvalueFromSqlServer(types.QValueKindInt32, "1")
+	require.Error(t, err)
+}
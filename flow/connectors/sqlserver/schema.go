@@ -0,0 +1,239 @@
+package connsqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	shared_mysql "github.com/PeerDB-io/peerdb/flow/shared/mysql"
+)
+
+func (c *SqlServerConnector) GetTableSchema(
+	ctx context.Context,
+	env map[string]string,
+	version uint32,
+	system protos.TypeSystem,
+	tableMappings []*protos.TableMapping,
+) (map[string]*protos.TableSchema, error) {
+	res := make(map[string]*protos.TableSchema, len(tableMappings))
+	for _, tm := range tableMappings {
+		tableSchema, err := c.getTableSchemaForTable(ctx, env, tm, system)
+		if err != nil {
+			c.logger.Info("error fetching schema", slog.String("table", tm.SourceTableIdentifier), slog.Any("error", err))
+			return nil, err
+		}
+		res[tm.SourceTableIdentifier] = tableSchema
+		c.logger.Info("fetched schema", slog.String("table", tm.SourceTableIdentifier))
+	}
+
+	return res, nil
+}
+
+func (c *SqlServerConnector) getTableSchemaForTable(
+	ctx context.Context,
+	env map[string]string,
+	tm *protos.TableMapping,
+	system protos.TypeSystem,
+) (*protos.TableSchema, error) {
+	schemaTable, err := utils.ParseSchemaTable(tm.SourceTableIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	nullableEnabled, err := internal.PeerDBNullable(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, `SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE, NUMERIC_PRECISION, NUMERIC_SCALE
+	FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2 ORDER BY ORDINAL_POSITION`,
+		schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]*protos.FieldDescription, 0)
+	for rows.Next() {
+		var columnName, dataType, isNullable string
+		var numericPrecision, numericScale sql.NullInt32
+		if err := rows.Scan(&columnName, &dataType, &isNullable, &numericPrecision, &numericScale); err != nil {
+			return nil, err
+		}
+		if slices.Contains(tm.Exclude, columnName) {
+			continue
+		}
+		qkind, err := qkindFromSqlServerType(dataType)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", columnName, err)
+		}
+		columns = append(columns, &protos.FieldDescription{
+			Name:         columnName,
+			Type:         string(qkind),
+			TypeModifier: datatypes.MakeNumericTypmod(numericPrecision.Int32, numericScale.Int32),
+			Nullable:     isNullable == "YES",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found or has no columns", tm.SourceTableIdentifier)
+	}
+
+	primary, err := c.getPrimaryKeyColumns(ctx, schemaTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protos.TableSchema{
+		TableIdentifier:       tm.SourceTableIdentifier,
+		PrimaryKeyColumns:     primary,
+		IsReplicaIdentityFull: false,
+		System:                system,
+		NullableEnabled:       nullableEnabled,
+		Columns:               columns,
+	}, nil
+}
+
+func (c *SqlServerConnector) getPrimaryKeyColumns(ctx context.Context, schemaTable *utils.SchemaTable) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT ku.COLUMN_NAME
+	FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+	JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE ku
+	ON ku.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND ku.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+	WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY' AND tc.TABLE_SCHEMA = @p1 AND tc.TABLE_NAME = @p2
+	ORDER BY ku.ORDINAL_POSITION`, schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key of %s: %w", schemaTable.SqlServer(), err)
+	}
+	defer rows.Close()
+
+	var primary []string
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			return nil, err
+		}
+		primary = append(primary, columnName)
+	}
+	return primary, rows.Err()
+}
+
+func (c *SqlServerConnector) GetAllTables(ctx context.Context) (*protos.AllTablesResponse, error) {
+	tables, err := c.queryStrings(ctx, `SELECT TABLE_SCHEMA + '.' + TABLE_NAME FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT IN ('cdc', 'sys')`)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.AllTablesResponse{Tables: tables}, nil
+}
+
+func (c *SqlServerConnector) GetSchemas(ctx context.Context) (*protos.PeerSchemasResponse, error) {
+	// schemas of database roles have ids from 16384
+	schemas, err := c.queryStrings(ctx, `SELECT name FROM sys.schemas
+		WHERE schema_id < 16384 AND name NOT IN ('cdc', 'guest', 'INFORMATION_SCHEMA', 'sys') ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.PeerSchemasResponse{Schemas: schemas}, nil
+}
+
+func (c *SqlServerConnector) GetTablesInSchema(
+	ctx context.Context, schema string, cdcEnabled bool,
+) (*protos.SchemaTablesResponse, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT t.name,
+		(SELECT CAST(COALESCE(SUM(a.total_pages), 0) * 8192 AS BIGINT) FROM sys.partitions p
+			JOIN sys.allocation_units a ON a.container_id = p.partition_id WHERE p.object_id = t.object_id),
+		CAST(CASE WHEN EXISTS(SELECT 1 FROM sys.indexes i WHERE i.object_id = t.object_id AND i.is_primary_key = 1)
+			THEN 1 ELSE 0 END AS BIT)
+	FROM sys.tables t JOIN sys.schemas s ON s.schema_id = t.schema_id
+	WHERE s.name = @p1 AND t.is_ms_shipped = 0 ORDER BY t.name`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []*protos.TableResponse
+	for rows.Next() {
+		var tableName string
+		var tableSizeInBytes int64
+		var hasPrimaryKey bool
+		if err := rows.Scan(&tableName, &tableSizeInBytes, &hasPrimaryKey); err != nil {
+			return nil, err
+		}
+		tables = append(tables, &protos.TableResponse{
+			TableName: tableName,
+			// change tracking requires a primary key
+			CanMirror: !cdcEnabled || hasPrimaryKey ||
+				c.config.ReplicationMechanism == protos.SqlServerReplicationMechanism_SQLSERVER_CDC,
+			TableSize: shared_mysql.PrettyBytes(tableSizeInBytes),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &protos.SchemaTablesResponse{Tables: tables}, nil
+}
+
+func (c *SqlServerConnector) GetColumns(
+	ctx context.Context, version uint32, schema string, table string,
+) (*protos.TableColumnsResponse, error) {
+	schemaTable := &utils.SchemaTable{Schema: schema, Table: table}
+	primary, err := c.getPrimaryKeyColumns(ctx, schemaTable)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, `SELECT COLUMN_NAME, DATA_TYPE FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2 ORDER BY COLUMN_NAME`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*protos.ColumnsItem
+	for rows.Next() {
+		var columnName, dataType string
+		if err := rows.Scan(&columnName, &dataType); err != nil {
+			return nil, err
+		}
+		qkind, err := qkindFromSqlServerType(dataType)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, &protos.ColumnsItem{
+			Name:  columnName,
+			Type:  dataType,
+			IsKey: slices.Contains(primary, columnName),
+			Qkind: string(qkind),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &protos.TableColumnsResponse{Columns: columns}, nil
+}
+
+func (c *SqlServerConnector) queryStrings(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,167 @@
+package connsqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+type SqlServerConnector struct {
+	*metadataStore.PostgresMetadata
+	config *protos.SqlServerConfig
+	db     *sql.DB
+	logger log.Logger
+	ssh    utils.SSHTunnel
+}
+
+// tunnelDialer connects through the SSH tunnel, resolving server on the far side of it
+type tunnelDialer struct {
+	utils.SSHTunnel
+	server string
+}
+
+func (d tunnelDialer) HostName() string {
+	return d.server
+}
+
+func NewSqlServerConnector(ctx context.Context, config *protos.SqlServerConfig) (*SqlServerConnector, error) {
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := mssql.NewConnector(connectionString(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SQL Server connector: %w", err)
+	}
+	tunnel, err := utils.NewSSHTunnel(ctx, config.SshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ssh tunnel: %w", err)
+	}
+	if tunnel.Client != nil {
+		connector.Dialer = tunnelDialer{SSHTunnel: tunnel, server: config.Server}
+	}
+	db := sql.OpenDB(connector)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		tunnel.Close()
+		return nil, fmt.Errorf("failed to connect to SQL Server: %w", err)
+	}
+
+	return &SqlServerConnector{
+		PostgresMetadata: pgMetadata,
+		config:           config,
+		db:               db,
+		logger:           internal.LoggerFromCtx(ctx),
+		ssh:              tunnel,
+	}, nil
+}
+
+func connectionString(config *protos.SqlServerConfig) string {
+	query := url.Values{}
+	query.Set("database", config.Database)
+	query.Set("app name", "peerdb")
+	if config.DisableTls {
+		query.Set("encrypt", "disable")
+	} else {
+		query.Set("encrypt", "true")
+	}
+	if config.TrustServerCertificate {
+		query.Set("TrustServerCertificate", "true")
+	}
+	return (&url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     net.JoinHostPort(config.Server, strconv.FormatUint(uint64(config.Port), 10)),
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+func (c *SqlServerConnector) Close() error {
+	var dbErr error
+	if c.db != nil {
+		dbErr = c.db.Close()
+	}
+	// closed after the pool so no connection outlives the tunnel it goes through
+	return errors.Join(dbErr, c.ssh.Close())
+}
+
+func (c *SqlServerConnector) ConnectionActive(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+func (c *SqlServerConnector) GetVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := c.db.QueryRowContext(ctx, "SELECT CAST(SERVERPROPERTY('ProductVersion') AS NVARCHAR(128))").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
+	}
+	c.logger.Info("[sqlserver] version", slog.String("version", version))
+	return version, nil
+}
+
+func (c *SqlServerConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	for _, tableMapping := range cfg.TableMappings {
+		parsedTable, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return fmt.Errorf("invalid source table identifier: %w", err)
+		}
+		if _, err := c.db.ExecContext(ctx, fmt.Sprintf("SELECT TOP 0 * FROM %s", parsedTable.SqlServer())); err != nil {
+			return fmt.Errorf("error checking table %s: %w", parsedTable.SqlServer(), err)
+		}
+	}
+	// no need to check change tracking for initial snapshot only mirrors
+	if cfg.DoInitialSnapshot && cfg.InitialSnapshotOnly {
+		return nil
+	}
+
+	if err := c.ValidateCheck(ctx); err != nil {
+		return err
+	}
+	for _, tableMapping := range cfg.TableMappings {
+		if err := c.checkTableTracked(ctx, tableMapping.SourceTableIdentifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *SqlServerConnector) ValidateCheck(ctx context.Context) error {
+	switch c.config.ReplicationMechanism {
+	case protos.SqlServerReplicationMechanism_SQLSERVER_CDC:
+		var enabled bool
+		if err := c.db.QueryRowContext(ctx,
+			"SELECT is_cdc_enabled FROM sys.databases WHERE name = DB_NAME()",
+		).Scan(&enabled); err != nil {
+			return fmt.Errorf("failed to check if change data capture is enabled: %w", err)
+		}
+		if !enabled {
+			return errors.New("change data capture is not enabled on database, run sys.sp_cdc_enable_db")
+		}
+	case protos.SqlServerReplicationMechanism_SQLSERVER_CHANGE_TRACKING:
+		var enabled bool
+		if err := c.db.QueryRowContext(ctx,
+			"SELECT CAST(COUNT(*) AS BIT) FROM sys.change_tracking_databases WHERE database_id = DB_ID()",
+		).Scan(&enabled); err != nil {
+			return fmt.Errorf("failed to check if change tracking is enabled: %w", err)
+		}
+		if !enabled {
+			return errors.New("change tracking is not enabled on database, run ALTER DATABASE ... SET CHANGE_TRACKING = ON")
+		}
+	default:
+		return fmt.Errorf("unknown replication mechanism %s", c.config.ReplicationMechanism)
+	}
+	return nil
+}
@@ -2,19 +2,20 @@ package utils
 
 import (
 	"bufio"
+	"compress/flate"
 	"context"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
-	"runtime/debug"
+	"strings"
 	"sync/atomic"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/hamba/avro/v2"
 	"github.com/hamba/avro/v2/ocf"
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
@@ -31,6 +32,7 @@ const (
 	AvroLocalStorage = iota
 	AvroS3Storage
 	AvroGCSStorage
+	AvroAzureBlobStorage
 )
 
 type peerDBOCFWriter struct {
@@ -41,7 +43,9 @@ type peerDBOCFWriter struct {
 }
 
 type AvroFile struct {
-	FilePath        string              `json:"filePath"`
+	FilePath string `json:"filePath"`
+	// columns of the file in order, unset for files staged before they were recorded
+	Columns         []string            `json:"columns,omitempty"`
 	StorageLocation AvroStorageLocation `json:"storageLocation"`
 	NumRecords      int64               `json:"numRecords"`
 }
@@ -75,7 +79,7 @@ func (p *peerDBOCFWriter) WriteOCF(
 	typeConversions map[string]types.TypeConversion,
 	numericTruncator *model.SnapshotTableNumericTruncator,
 ) (int64, error) {
-	ocfWriter, err := p.createOCFWriter(w)
+	ocfWriter, err := p.createOCFWriter(ctx, env, w)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create OCF writer: %w", err)
 	}
@@ -105,60 +109,53 @@ func (p *peerDBOCFWriter) WriteRecordsToS3(
 		return AvroFile{}, fmt.Errorf("failed to create S3 client: %w", err)
 	}
 
-	r, w := io.Pipe()
-	defer r.Close()
-
-	var writeOcfError error
 	var numRows int64
-
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				writeOcfError = fmt.Errorf("panic occurred during WriteOCF: %v", r)
-				stack := string(debug.Stack())
-				logger.Error("panic during WriteOCF", slog.Any("error", writeOcfError), slog.String("stack", stack))
-			}
-			w.Close()
-		}()
-		var writer io.Writer
-		if avroSize == nil {
-			writer = w
-		} else {
+	if _, err := UploadS3Stream(ctx, env, s3svc, bucketName, key, func(w io.Writer) error {
+		var writer io.Writer = w
+		if avroSize != nil {
 			writer = shared.NewWatchWriter(w, avroSize)
 		}
-		numRows, writeOcfError = p.WriteOCF(ctx, env, writer, typeConversions, numericTruncator)
-	}()
-
-	partSize, err := internal.PeerDBS3PartSize(ctx, env)
-	if err != nil {
-		return AvroFile{}, fmt.Errorf("could not get s3 part size config: %w", err)
+		var err error
+		numRows, err = p.WriteOCF(ctx, env, writer, typeConversions, numericTruncator)
+		return err
+	}); err != nil {
+		logger.Error("failed to write records to S3", slog.Any("error", err))
+		return AvroFile{}, err
 	}
 
-	// Create the uploader using the AWS SDK v2 manager
-	uploader := manager.NewUploader(s3svc, func(u *manager.Uploader) {
-		if partSize > 0 {
-			u.PartSize = partSize
-		}
-	})
+	return AvroFile{
+		StorageLocation: AvroS3Storage,
+		FilePath:        key,
+		NumRecords:      numRows,
+	}, nil
+}
 
-	if _, err := uploader.Upload(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(key),
-		Body:   r,
+func (p *peerDBOCFWriter) WriteRecordsToAzureBlob(
+	ctx context.Context,
+	env map[string]string,
+	client *azblob.Client,
+	container string,
+	blobName string,
+	avroSize *atomic.Int64,
+	typeConversions map[string]types.TypeConversion,
+	numericTruncator *model.SnapshotTableNumericTruncator,
+) (AvroFile, error) {
+	var numRows int64
+	if err := UploadAzureBlobStream(ctx, client, container, blobName, func(w io.Writer) error {
+		var writer io.Writer = w
+		if avroSize != nil {
+			writer = shared.NewWatchWriter(w, avroSize)
+		}
+		var err error
+		numRows, err = p.WriteOCF(ctx, env, writer, typeConversions, numericTruncator)
+		return err
 	}); err != nil {
-		s3Path := "s3://" + bucketName + "/" + key
-		logger.Error("failed to upload file", slog.Any("error", err), slog.String("s3_path", s3Path))
-		return AvroFile{}, fmt.Errorf("failed to upload file: %w", err)
-	}
-
-	if writeOcfError != nil {
-		logger.Error("failed to write records to OCF", slog.Any("error", writeOcfError))
-		return AvroFile{}, writeOcfError
+		return AvroFile{}, fmt.Errorf("failed to write records to azure blob %s: %w", blobName, err)
 	}
 
 	return AvroFile{
-		StorageLocation: AvroS3Storage,
-		FilePath:        key,
+		StorageLocation: AvroAzureBlobStorage,
+		FilePath:        blobName,
 		NumRecords:      numRows,
 	}, nil
 }
@@ -197,10 +194,12 @@ func (p *peerDBOCFWriter) WriteRecordsToAvroFile(ctx context.Context, env map[st
 	}, nil
 }
 
-func (p *peerDBOCFWriter) createOCFWriter(w io.Writer) (*ocf.Encoder, error) {
-	ocfWriter, err := ocf.NewEncoderWithSchema(
-		p.avroSchema.Schema, w, ocf.WithCodec(p.avroCompressionCodec),
-	)
+func (p *peerDBOCFWriter) createOCFWriter(ctx context.Context, env map[string]string, w io.Writer) (*ocf.Encoder, error) {
+	codecOptions, err := p.codecOptions(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	ocfWriter, err := ocf.NewEncoderWithSchema(p.avroSchema.Schema, w, codecOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OCF writer: %w", err)
 	}
@@ -208,6 +207,63 @@ func (p *peerDBOCFWriter) createOCFWriter(w io.Writer) (*ocf.Encoder, error) {
 	return ocfWriter, nil
 }
 
+// codecOptions applies PEERDB_AVRO_STAGING_CODEC and its level to files staged for ClickHouse and Snowflake,
+// both read the codec from the file header so loads need no changes
+func (p *peerDBOCFWriter) codecOptions(ctx context.Context, env map[string]string) ([]ocf.EncoderFunc, error) {
+	codec := p.avroCompressionCodec
+	if p.targetDWH != protos.DBType_CLICKHOUSE && p.targetDWH != protos.DBType_SNOWFLAKE {
+		return []ocf.EncoderFunc{ocf.WithCodec(codec)}, nil
+	}
+
+	codecName, err := internal.PeerDBAvroStagingCodec(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	if codecName != "" {
+		codec, err = ParseAvroCodec(codecName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	level, err := internal.PeerDBAvroStagingCodecLevel(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []ocf.EncoderFunc{ocf.WithCodec(codec)}
+	if level != 0 {
+		switch codec {
+		case ocf.Deflate:
+			if level < flate.HuffmanOnly || level > flate.BestCompression {
+				return nil, fmt.Errorf("invalid deflate level %d", level)
+			}
+			options = append(options, ocf.WithCompressionLevel(level))
+		case ocf.ZStandard:
+			if level < 1 || level > 22 {
+				return nil, fmt.Errorf("invalid zstandard level %d", level)
+			}
+			options = append(options, ocf.WithZStandardEncoderOptions(zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level))))
+		}
+	}
+	return options, nil
+}
+
+// ParseAvroCodec parses codec names of PEERDB_AVRO_STAGING_CODEC
+func ParseAvroCodec(name string) (ocf.CodecName, error) {
+	switch strings.ToLower(name) {
+	case "null", "none":
+		return ocf.Null, nil
+	case "deflate":
+		return ocf.Deflate, nil
+	case "snappy":
+		return ocf.Snappy, nil
+	case "zstandard", "zstd":
+		return ocf.ZStandard, nil
+	default:
+		return "", fmt.Errorf("unsupported avro codec %s, must be one of null, deflate, snappy or zstandard", name)
+	}
+}
+
 func (p *peerDBOCFWriter) getAvroFieldNamesFromSchemaJSON() ([]string, error) {
 	fields := p.avroSchema.Schema.Fields()
 	avroFieldNames := make([]string, len(fields))
@@ -238,6 +294,8 @@ func (p *peerDBOCFWriter) writeRecordsToOCFWriter(
 	}
 
 	numRows := atomic.Int64{}
+	// records are encoded into one reused buffer and copied to the OCF block
+	encoder := avro.NewWriter(nil, 4096)
 
 	shutdown := shared.Interval(ctx, time.Minute, func() {
 		logger.Info(fmt.Sprintf("written %d records to OCF", numRows.Load()))
@@ -248,13 +306,13 @@ func (p *peerDBOCFWriter) writeRecordsToOCFWriter(
 		if err := ctx.Err(); err != nil {
 			return numRows.Load(), err
 		} else {
-			avroMap, err := avroConverter.Convert(ctx, env, qrecord, typeConversions, numericTruncator)
-			if err != nil {
-				logger.Error("Failed to convert QRecord to Avro compatible map", slog.Any("error", err))
-				return numRows.Load(), fmt.Errorf("failed to convert QRecord to Avro compatible map: %w", err)
+			encoder.Reset(nil)
+			if err := avroConverter.Encode(ctx, env, encoder, qrecord, typeConversions, numericTruncator); err != nil {
+				logger.Error("Failed to encode QRecord to Avro", slog.Any("error", err))
+				return numRows.Load(), fmt.Errorf("failed to encode QRecord to Avro: %w", err)
 			}
 
-			if err := ocfWriter.Encode(avroMap); err != nil {
+			if _, err := ocfWriter.Write(encoder.Buffer()); err != nil {
 				logger.Error("Failed to write record to OCF", slog.Any("error", err))
 				return numRows.Load(), fmt.Errorf("failed to write record to OCF: %w", err)
 			}
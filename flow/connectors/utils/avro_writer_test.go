@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/hamba/avro/v2/ocf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAvroCodec(t *testing.T) {
+	t.Parallel()
+	for name, codec := range map[string]ocf.CodecName{
+		"null":      ocf.Null,
+		"deflate":   ocf.Deflate,
+		"Snappy":    ocf.Snappy,
+		"zstandard": ocf.ZStandard,
+		"zstd":      ocf.ZStandard,
+	} {
+		parsed, err := ParseAvroCodec(name)
+		require.NoError(t, err)
+		require.Equal(t, codec, parsed)
+	}
+	_, err := ParseAvroCodec("lz4")
+	require.Error(t, err)
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -16,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	smithyendpoints "github.com/aws/smithy-go/endpoints"
@@ -50,12 +53,20 @@ type PeerAWSCredentials struct {
 	Region         string
 	RootCAs        *string
 	TlsHost        string
+	UseAccelerate  bool
+	Encryption     S3Encryption
+	// GCS without HMAC keys, authenticated with GcsServiceAccount or workload identity when nil
+	Gcs               bool
+	GcsServiceAccount *GcpServiceAccount
 }
 
 func NewPeerAWSCredentials(s3 *protos.S3Config) PeerAWSCredentials {
 	if s3 == nil {
 		return PeerAWSCredentials{}
 	}
+	if strings.HasPrefix(s3.Url, "gs://") {
+		return newGcsPeerCredentials(s3)
+	}
 	return PeerAWSCredentials{
 		Credentials: aws.Credentials{
 			AccessKeyID:     s3.GetAccessKeyId(),
@@ -67,6 +78,44 @@ func NewPeerAWSCredentials(s3 *protos.S3Config) PeerAWSCredentials {
 		Region:         s3.GetRegion(),
 		RootCAs:        s3.RootCa,
 		TlsHost:        s3.TlsHost,
+		UseAccelerate:  s3.UseAccelerate,
+		Encryption: S3Encryption{
+			KmsKeyArn:              s3.GetSseKmsKeyArn(),
+			CustomerKey:            s3.GetSseCustomerKey(),
+			BucketOwnerFullControl: s3.BucketOwnerFullControl,
+		},
+	}
+}
+
+// newGcsPeerCredentials uses HMAC keys through the S3 interoperability API when given, OAuth tokens otherwise
+func newGcsPeerCredentials(s3 *protos.S3Config) PeerAWSCredentials {
+	endpoint := s3.GetEndpoint()
+	if endpoint == "" {
+		endpoint = GcsEndpoint
+	}
+	region := s3.GetRegion()
+	if region == "" {
+		region = gcsRegion
+	}
+	if s3.GetAccessKeyId() != "" || s3.GetSecretAccessKey() != "" {
+		return PeerAWSCredentials{
+			Credentials: aws.Credentials{
+				AccessKeyID:     s3.GetAccessKeyId(),
+				SecretAccessKey: s3.GetSecretAccessKey(),
+			},
+			EndpointUrl: &endpoint,
+			Region:      region,
+		}
+	}
+	var sa *GcpServiceAccount
+	if s3.GcpServiceAccount != nil {
+		sa = GcpServiceAccountFromProto(s3.GcpServiceAccount)
+	}
+	return PeerAWSCredentials{
+		EndpointUrl:       &endpoint,
+		Region:            region,
+		Gcs:               true,
+		GcsServiceAccount: sa,
 	}
 }
 
@@ -78,6 +127,9 @@ type ClickHouseS3Credentials struct {
 type AWSCredentials struct {
 	EndpointUrl *string
 	AWS         aws.Credentials
+	// S3 transfer acceleration, ignored with custom endpoints
+	UseAccelerate bool
+	Encryption    S3Encryption
 }
 
 type AWSCredentialsProvider interface {
@@ -89,7 +141,9 @@ type AWSCredentialsProvider interface {
 }
 
 type ConfigBasedAWSCredentialsProvider struct {
-	config aws.Config
+	config        aws.Config
+	useAccelerate bool
+	encryption    S3Encryption
 }
 
 func NewConfigBasedAWSCredentialsProvider(config aws.Config) *ConfigBasedAWSCredentialsProvider {
@@ -124,8 +178,10 @@ func (r *ConfigBasedAWSCredentialsProvider) Retrieve(ctx context.Context) (AWSCr
 		return AWSCredentials{}, err
 	}
 	return AWSCredentials{
-		AWS:         retrieved,
-		EndpointUrl: r.config.BaseEndpoint,
+		AWS:           retrieved,
+		EndpointUrl:   r.config.BaseEndpoint,
+		UseAccelerate: r.useAccelerate,
+		Encryption:    r.encryption,
 	}, nil
 }
 
@@ -170,8 +226,10 @@ func (s *StaticAWSCredentialsProvider) GetTlsConfig() (*string, string) {
 }
 
 type AssumeRoleBasedAWSCredentialsProvider struct {
-	Provider aws.CredentialsProvider // New Credentials
-	config   aws.Config              // Initial Config
+	Provider      aws.CredentialsProvider // New Credentials
+	config        aws.Config              // Initial Config
+	useAccelerate bool
+	encryption    S3Encryption
 }
 
 func NewAssumeRoleBasedAWSCredentialsProvider(
@@ -199,8 +257,10 @@ func (a *AssumeRoleBasedAWSCredentialsProvider) Retrieve(ctx context.Context) (A
 		return AWSCredentials{}, err
 	}
 	return AWSCredentials{
-		AWS:         retrieved,
-		EndpointUrl: ptr.String(a.GetEndpointURL()),
+		AWS:           retrieved,
+		EndpointUrl:   ptr.String(a.GetEndpointURL()),
+		UseAccelerate: a.useAccelerate,
+		Encryption:    a.encryption,
 	}, nil
 }
 
@@ -236,6 +296,12 @@ func LoadPeerDBAWSEnvConfigProvider(connectorName string) *StaticAWSCredentialsP
 	endpointUrl := getPeerDBAWSEnv(connectorName, "AWS_ENDPOINT_URL_S3")
 	rootCa := getPeerDBAWSEnv(connectorName, "ROOT_CA")
 	tlsHost := getPeerDBAWSEnv(connectorName, "TLS_HOST")
+	useAccelerate := getPeerDBAWSEnv(connectorName, "AWS_S3_USE_ACCELERATE") == "true"
+	encryption := S3Encryption{
+		KmsKeyArn:              getPeerDBAWSEnv(connectorName, "AWS_S3_SSE_KMS_KEY_ARN"),
+		CustomerKey:            getPeerDBAWSEnv(connectorName, "AWS_S3_SSE_CUSTOMER_KEY"),
+		BucketOwnerFullControl: getPeerDBAWSEnv(connectorName, "AWS_S3_BUCKET_OWNER_FULL_CONTROL") == "true",
+	}
 	var endpointUrlPtr *string
 	if endpointUrl != "" {
 		endpointUrlPtr = &endpointUrl
@@ -255,19 +321,27 @@ func LoadPeerDBAWSEnvConfigProvider(connectorName string) *StaticAWSCredentialsP
 			AccessKeyID:     accessKeyId,
 			SecretAccessKey: secretAccessKey,
 		},
-		EndpointUrl: endpointUrlPtr,
+		EndpointUrl:   endpointUrlPtr,
+		UseAccelerate: useAccelerate,
+		Encryption:    encryption,
 	}, region, rootCAs, tlsHost)
 }
 
 func GetAWSCredentialsProvider(ctx context.Context, connectorName string, peerCredentials PeerAWSCredentials) (AWSCredentialsProvider, error) {
 	logger := internal.LoggerFromCtx(ctx)
+	if peerCredentials.Gcs {
+		logger.Info("Received GCS credentials from peer for connector: " + connectorName)
+		return NewGcsCredentialsProvider(ctx, peerCredentials.GcsServiceAccount, peerCredentials.EndpointUrl)
+	}
 	if peerCredentials.Credentials.AccessKeyID != "" || peerCredentials.Credentials.SecretAccessKey != "" ||
 		peerCredentials.Region != "" || (peerCredentials.RoleArn != nil && *peerCredentials.RoleArn != "") ||
 		(peerCredentials.ChainedRoleArn != nil && *peerCredentials.ChainedRoleArn != "") ||
 		(peerCredentials.EndpointUrl != nil && *peerCredentials.EndpointUrl != "") {
 		staticProvider := NewStaticAWSCredentialsProvider(AWSCredentials{
-			AWS:         peerCredentials.Credentials,
-			EndpointUrl: peerCredentials.EndpointUrl,
+			AWS:           peerCredentials.Credentials,
+			EndpointUrl:   peerCredentials.EndpointUrl,
+			UseAccelerate: peerCredentials.UseAccelerate,
+			Encryption:    peerCredentials.Encryption,
 		}, peerCredentials.Region, peerCredentials.RootCAs, peerCredentials.TlsHost)
 		if peerCredentials.RoleArn == nil || *peerCredentials.RoleArn == "" {
 			logger.Info("Received AWS credentials from peer for connector: " + connectorName)
@@ -277,6 +351,13 @@ func GetAWSCredentialsProvider(ctx context.Context, connectorName string, peerCr
 		if err != nil {
 			return nil, err
 		}
+		// region and endpoint of the peer take precedence over the worker's
+		if peerCredentials.Region != "" {
+			awsConfig.Region = peerCredentials.Region
+		}
+		if peerCredentials.EndpointUrl != nil && *peerCredentials.EndpointUrl != "" {
+			awsConfig.BaseEndpoint = peerCredentials.EndpointUrl
+		}
 		awsConfig.Credentials = stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsConfig), *peerCredentials.RoleArn,
 			func(options *stscreds.AssumeRoleOptions) {
 				options.RoleSessionName = getAssumedRoleSessionName()
@@ -284,10 +365,20 @@ func GetAWSCredentialsProvider(ctx context.Context, connectorName string, peerCr
 		)
 		if peerCredentials.ChainedRoleArn != nil && *peerCredentials.ChainedRoleArn != "" {
 			logger.Info("Received AWS credentials with chained role from peer for connector: " + connectorName)
-			return NewAssumeRoleBasedAWSCredentialsProvider(ctx, awsConfig, *peerCredentials.ChainedRoleArn, getChainedRoleSessionName())
+			provider, err := NewAssumeRoleBasedAWSCredentialsProvider(ctx, awsConfig, *peerCredentials.ChainedRoleArn,
+				getChainedRoleSessionName())
+			if err != nil {
+				return nil, err
+			}
+			provider.useAccelerate = peerCredentials.UseAccelerate
+			provider.encryption = peerCredentials.Encryption
+			return provider, nil
 		}
 		logger.Info("Received AWS credentials from peer for connector: " + connectorName)
-		return NewConfigBasedAWSCredentialsProvider(awsConfig), nil
+		provider := NewConfigBasedAWSCredentialsProvider(awsConfig)
+		provider.useAccelerate = peerCredentials.UseAccelerate
+		provider.encryption = peerCredentials.Encryption
+		return provider, nil
 	}
 	envCredentialsProvider := LoadPeerDBAWSEnvConfigProvider(connectorName)
 	if envCredentialsProvider != nil {
@@ -343,10 +434,10 @@ type S3BucketAndPrefix struct {
 	Prefix string
 }
 
-// path would be something like s3://bucket/prefix
+// path would be something like s3://bucket/prefix, or gs://bucket/prefix for GCS
 func NewS3BucketAndPrefix(s3Path string) (*S3BucketAndPrefix, error) {
 	// Remove s3:// prefix
-	stagingPath := strings.TrimPrefix(s3Path, "s3://")
+	stagingPath := strings.TrimPrefix(strings.TrimPrefix(s3Path, "s3://"), "gs://")
 
 	// Split into bucket and prefix
 	bucket, prefix, _ := strings.Cut(stagingPath, "/")
@@ -381,7 +472,20 @@ func CreateS3Client(ctx context.Context, credsProvider AWSCredentialsProvider) (
 		Region:      credsProvider.GetRegion(),
 		Credentials: credsProvider.GetUnderlyingProvider(),
 	}
-	if awsCredentials.EndpointUrl != nil && *awsCredentials.EndpointUrl != "" {
+	if !awsCredentials.Encryption.IsZero() {
+		if err := awsCredentials.Encryption.Validate(); err != nil {
+			return nil, err
+		}
+		options.APIOptions = append(options.APIOptions, awsCredentials.Encryption.addMiddleware)
+	}
+	if awsCredentials.EndpointUrl == nil || *awsCredentials.EndpointUrl == "" {
+		// buckets may live outside the configured region, requests are routed and signed for the bucket's own region
+		options.UseAccelerate = awsCredentials.UseAccelerate
+		options.EndpointResolverV2 = &bucketRegionResolver{
+			next:   s3.NewDefaultEndpointResolverV2(),
+			region: options.Region,
+		}
+	} else {
 		options.BaseEndpoint = awsCredentials.EndpointUrl
 		options.UsePathStyle = true
 		url, err := url.Parse(*awsCredentials.EndpointUrl)
@@ -392,7 +496,21 @@ func CreateS3Client(ctx context.Context, credsProvider AWSCredentialsProvider) (
 			URL: *url,
 		}
 
-		if strings.Contains(*awsCredentials.EndpointUrl, "storage.googleapis.com") {
+		gcsProvider, gcsTokens := credsProvider.(*GcsCredentialsProvider)
+		if gcsTokens || isGcsEndpoint(*awsCredentials.EndpointUrl) {
+			// GCS rejects aws-chunked uploads, checksums are only sent when operations require them
+			options.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			options.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+
+		if gcsTokens {
+			options.HTTPClient = &http.Client{
+				Transport: &gcsBearerTransport{
+					next:        http.DefaultTransport,
+					tokenSource: gcsProvider.tokenSource,
+				},
+			}
+		} else if isGcsEndpoint(*awsCredentials.EndpointUrl) {
 			// Assign custom client with our own transport
 			options.HTTPClient = &http.Client{
 				Transport: &RecalculateV4Signature{
@@ -421,6 +539,57 @@ func CreateS3Client(ctx context.Context, credsProvider AWSCredentialsProvider) (
 	return s3.New(options), nil
 }
 
+// s3BucketRegions caches discovered bucket regions, buckets can't move between regions
+var s3BucketRegions sync.Map
+
+// bucketRegionResolver resolves endpoints in the region of the bucket, discovered once per bucket
+type bucketRegionResolver struct {
+	next   s3.EndpointResolverV2
+	region string
+}
+
+func (r *bucketRegionResolver) ResolveEndpoint(ctx context.Context, params s3.EndpointParameters) (
+	smithyendpoints.Endpoint, error,
+) {
+	if params.Bucket != nil && *params.Bucket != "" {
+		if region := lookupS3BucketRegion(ctx, r.region, *params.Bucket); region != "" {
+			params.Region = &region
+		}
+	}
+	return r.next.ResolveEndpoint(ctx, params)
+}
+
+// lookupS3BucketRegion returns the region of bucket, or an empty string when it can't be discovered
+func lookupS3BucketRegion(ctx context.Context, region string, bucket string) string {
+	if cached, ok := s3BucketRegions.Load(bucket); ok {
+		return cached.(string)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	// HeadBucket is sent unsigned, S3 reports the region of the bucket even when access is denied
+	bucketRegion, err := manager.GetBucketRegion(ctx, s3.New(s3.Options{Region: region}), bucket)
+	if err != nil || bucketRegion == "" {
+		internal.LoggerFromCtx(ctx).Warn("failed to discover S3 bucket region, using configured region",
+			slog.String("bucket", bucket), slog.String("region", region), slog.Any("error", err))
+		// not retried, requests keep going to the configured region as they did before discovery
+		s3BucketRegions.Store(bucket, "")
+		return ""
+	}
+	s3BucketRegions.Store(bucket, bucketRegion)
+	return bucketRegion
+}
+
+// S3BucketRegion is the region requests for bucket should be made in, for callers building their own urls
+func S3BucketRegion(ctx context.Context, credsProvider AWSCredentialsProvider, bucket string) string {
+	if credsProvider.GetEndpointURL() == "" {
+		if region := lookupS3BucketRegion(ctx, credsProvider.GetRegion(), bucket); region != "" {
+			return region
+		}
+	}
+	return credsProvider.GetRegion()
+}
+
 // RecalculateV4Signature allow GCS over S3, removing Accept-Encoding header from sign
 // https://stackoverflow.com/a/74382598/1204665
 // https://github.com/aws/aws-sdk-go-v2/issues/1816
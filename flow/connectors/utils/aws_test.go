@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketRegionResolver(t *testing.T) {
+	t.Parallel()
+	s3BucketRegions.Store("peerdb-test-eu-bucket", "eu-west-1")
+	resolver := &bucketRegionResolver{next: s3.NewDefaultEndpointResolverV2(), region: "us-east-1"}
+
+	endpoint, err := resolver.ResolveEndpoint(t.Context(), s3.EndpointParameters{
+		Bucket: aws.String("peerdb-test-eu-bucket"),
+		Region: aws.String("us-east-1"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "peerdb-test-eu-bucket.s3.eu-west-1.amazonaws.com", endpoint.URI.Host)
+
+	accelerated, err := resolver.ResolveEndpoint(t.Context(), s3.EndpointParameters{
+		Bucket:     aws.String("peerdb-test-eu-bucket"),
+		Region:     aws.String("us-east-1"),
+		Accelerate: aws.Bool(true),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "peerdb-test-eu-bucket.s3-accelerate.amazonaws.com", accelerated.URI.Host)
+}
@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// AzureBlobPath locates blobs as azure://<account>.blob.core.windows.net/<container>/<prefix>,
+// which is also how Snowflake external stages address Azure
+type AzureBlobPath struct {
+	Host      string
+	Container string
+	Prefix    string
+}
+
+// NewAzureBlobPath parses azure:// and https:// urls of the blob endpoint,
+// and abfss://<container>@<account>.dfs.core.windows.net/<prefix> urls of ADLS Gen2 which are served by the blob endpoint too
+func NewAzureBlobPath(blobURL string) (*AzureBlobPath, error) {
+	u, err := url.Parse(blobURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse azure url: %w", err)
+	}
+	path := &AzureBlobPath{Host: u.Host}
+	rest := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "azure", "https":
+		path.Container, path.Prefix, _ = strings.Cut(rest, "/")
+	case "abfs", "abfss":
+		path.Container = u.User.Username()
+		path.Host = strings.Replace(u.Host, ".dfs.", ".blob.", 1)
+		path.Prefix = rest
+	default:
+		return nil, fmt.Errorf("unsupported azure url scheme %s, expected azure://", u.Scheme)
+	}
+	if path.Host == "" || path.Container == "" {
+		return nil, fmt.Errorf("azure url %s must include storage account and container", blobURL)
+	}
+	return path, nil
+}
+
+func IsAzureBlobURL(blobURL string) bool {
+	return strings.HasPrefix(blobURL, "azure://") || strings.HasPrefix(blobURL, "abfss://") || strings.HasPrefix(blobURL, "abfs://")
+}
+
+func (p *AzureBlobPath) Account() string {
+	account, _, _ := strings.Cut(p.Host, ".")
+	return account
+}
+
+func (p *AzureBlobPath) ServiceURL() string {
+	return "https://" + p.Host + "/"
+}
+
+// BlobName joins elements to prefix of path
+func (p *AzureBlobPath) BlobName(elems ...string) string {
+	if p.Prefix != "" {
+		elems = append([]string{p.Prefix}, elems...)
+	}
+	return strings.Join(elems, "/")
+}
+
+// StageURL is the azure:// url Snowflake stages read blobs under prefix/suffix from
+func (p *AzureBlobPath) StageURL(suffix string) string {
+	return fmt.Sprintf("azure://%s/%s/%s/", p.Host, p.Container, p.BlobName(suffix))
+}
+
+type PeerAzureCredentials struct {
+	SasToken string
+	// client id of a user-assigned managed identity, default credentials are used when empty
+	ClientID string
+}
+
+func NewPeerAzureCredentials(config *protos.AzureBlobConfig) PeerAzureCredentials {
+	if config == nil {
+		return PeerAzureCredentials{}
+	}
+	return PeerAzureCredentials{
+		SasToken: strings.TrimPrefix(config.GetSasToken(), "?"),
+		ClientID: config.GetClientId(),
+	}
+}
+
+// AzureStagingCredentials are used for stages configured on mirrors rather than peers,
+// a SAS token can be set through AZURE_STORAGE_SAS_TOKEN, otherwise the identity of the worker is used
+func AzureStagingCredentials() PeerAzureCredentials {
+	return PeerAzureCredentials{
+		SasToken: strings.TrimPrefix(internal.GetEnvString("AZURE_STORAGE_SAS_TOKEN", ""), "?"),
+	}
+}
+
+func CreateAzureBlobClient(path *AzureBlobPath, creds PeerAzureCredentials) (*azblob.Client, error) {
+	if creds.SasToken != "" {
+		client, err := azblob.NewClientWithNoCredential(path.ServiceURL()+"?"+creds.SasToken, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create azure blob client with SAS token: %w", err)
+		}
+		return client, nil
+	}
+
+	var credential azcore.TokenCredential
+	var err error
+	if creds.ClientID != "" {
+		credential, err = azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
+			ID: azidentity.ClientID(creds.ClientID),
+		})
+	} else {
+		// covers system-assigned managed identity & workload identity
+		credential, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get azure credentials: %w", err)
+	}
+	client, err := azblob.NewClient(path.ServiceURL(), credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure blob client: %w", err)
+	}
+	return client, nil
+}
+
+// UploadAzureBlobStream uploads what write writes to blobName while it is being written
+func UploadAzureBlobStream(
+	ctx context.Context,
+	client *azblob.Client,
+	container string,
+	blobName string,
+	write func(io.Writer) error,
+) error {
+	logger := internal.LoggerFromCtx(ctx)
+	r, w := io.Pipe()
+	defer r.Close()
+
+	var writeErr error
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				writeErr = fmt.Errorf("panic occurred while writing blob: %v", r)
+				logger.Error("panic while writing blob", slog.Any("error", writeErr), slog.String("stack", string(debug.Stack())))
+			}
+			w.Close()
+		}()
+		if writeErr = write(w); writeErr != nil {
+			w.CloseWithError(writeErr)
+		}
+	}()
+
+	if _, err := client.UploadStream(ctx, container, blobName, r, &azblob.UploadStreamOptions{
+		BlockSize: 8 * 1024 * 1024,
+	}); err != nil {
+		logger.Error("failed to upload blob", slog.Any("error", err), slog.String("container", container), slog.String("blob", blobName))
+		if writeErr != nil {
+			return writeErr
+		}
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	return writeErr
+}
+
+// DeleteAzureBlobPrefix deletes all blobs under prefix
+// DeleteAzureBlobPrefix deletes blobs under prefix last modified before cutoff, all of them when cutoff is zero
+func DeleteAzureBlobPrefix(ctx context.Context, client *azblob.Client, container string, prefix string, cutoff time.Time) error {
+	pager := client.NewListBlobsFlatPager(container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list blobs: %w", err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			if item.Properties != nil && !StagedBefore(item.Properties.LastModified, cutoff) {
+				continue
+			}
+			if _, err := client.DeleteBlob(ctx, container, *item.Name, nil); err != nil {
+				return fmt.Errorf("failed to delete blob %s: %w", *item.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func PutAndRemoveAzureBlob(ctx context.Context, client *azblob.Client, path *AzureBlobPath) error {
+	blobName := path.BlobName(_peerDBCheck + uuid.New().String())
+	if _, err := client.UploadBuffer(ctx, path.Container, blobName, []byte(time.Now().Format(time.RFC3339)), nil); err != nil {
+		return fmt.Errorf("failed to write to container: %w", err)
+	}
+	if _, err := client.DeleteBlob(ctx, path.Container, blobName, nil); err != nil {
+		return fmt.Errorf("failed to delete from container: %w", err)
+	}
+	return nil
+}
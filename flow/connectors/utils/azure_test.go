@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureBlobPath(t *testing.T) {
+	t.Parallel()
+	path, err := NewAzureBlobPath("azure://acct.blob.core.windows.net/stage/peerdb/avro")
+	require.NoError(t, err)
+	require.Equal(t, &AzureBlobPath{Host: "acct.blob.core.windows.net", Container: "stage", Prefix: "peerdb/avro"}, path)
+	require.Equal(t, "acct", path.Account())
+	require.Equal(t, "https://acct.blob.core.windows.net/", path.ServiceURL())
+	require.Equal(t, "peerdb/avro/job/1.avro", path.BlobName("job", "1.avro"))
+	require.Equal(t, "azure://acct.blob.core.windows.net/stage/peerdb/avro/job/", path.StageURL("job"))
+
+	path, err = NewAzureBlobPath("abfss://lake@acct.dfs.core.windows.net/raw")
+	require.NoError(t, err)
+	require.Equal(t, &AzureBlobPath{Host: "acct.blob.core.windows.net", Container: "lake", Prefix: "raw"}, path)
+
+	path, err = NewAzureBlobPath("azure://acct.blob.core.windows.net/stage")
+	require.NoError(t, err)
+	require.Equal(t, "job/1.avro", path.BlobName("job", "1.avro"))
+
+	_, err = NewAzureBlobPath("azure://acct.blob.core.windows.net")
+	require.Error(t, err)
+	_, err = NewAzureBlobPath("s3://bucket/prefix")
+	require.Error(t, err)
+}
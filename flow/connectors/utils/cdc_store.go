@@ -242,6 +242,26 @@ func (c *cdcStore[T]) Get(key model.TableWithPkey) (model.Record[T], bool, error
 	return nil, false, nil
 }
 
+// Delete removes a record stored with Set, whether it was kept in memory or spilled to disk
+func (c *cdcStore[T]) Delete(key model.TableWithPkey) error {
+	if _, ok := c.inMemoryRecords[key]; ok {
+		delete(c.inMemoryRecords, key)
+	} else if c.pebbleDB != nil {
+		encodedKey, err := encVal(key)
+		if err != nil {
+			return err
+		}
+		if err := c.pebbleDB.Delete(encodedKey, &pebble.WriteOptions{Sync: false}); err != nil {
+			return fmt.Errorf("unable to delete value with key %v: %w", key, err)
+		}
+	} else {
+		return nil
+	}
+
+	c.numRecords.Add(-1)
+	return nil
+}
+
 func (c *cdcStore[T]) Len() int {
 	return int(c.numRecords.Load())
 }
@@ -141,3 +141,29 @@ func TestNullKeyDoesntStore(t *testing.T) {
 
 	require.NoError(t, cdcRecordsStore.Close())
 }
+
+func TestDeleteSpilledRecord(t *testing.T) {
+	t.Parallel()
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), map[string]string{
+		"PEERDB_CDC_DISK_SPILL_RECORDS_THRESHOLD":     "1",
+		"PEERDB_CDC_DISK_SPILL_MEM_PERCENT_THRESHOLD": "0",
+	}, "test_delete_spilled_record")
+	require.NoError(t, err)
+
+	memKey, memRec := genKeyAndRec(t)
+	require.NoError(t, cdcRecordsStore.Set(slog.Default(), memKey, memRec))
+	diskKey, diskRec := genKeyAndRec(t)
+	require.NoError(t, cdcRecordsStore.Set(slog.Default(), diskKey, diskRec))
+	require.NotNil(t, cdcRecordsStore.pebbleDB)
+	require.Equal(t, 2, cdcRecordsStore.Len())
+
+	for _, key := range []model.TableWithPkey{memKey, diskKey} {
+		require.NoError(t, cdcRecordsStore.Delete(key))
+		_, ok, err := cdcRecordsStore.Get(key)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+	require.True(t, cdcRecordsStore.IsEmpty())
+
+	require.NoError(t, cdcRecordsStore.Close())
+}
@@ -55,3 +55,8 @@ func QuoteIdentifier(name string) string {
 	}
 	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
 }
+
+// QuoteSqlServerIdentifier quotes with brackets, which unlike double quotes do not depend on QUOTED_IDENTIFIER
+func QuoteSqlServerIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
@@ -3,10 +3,12 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 
 	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
 	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/option"
@@ -14,6 +16,10 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 )
 
+// GcpWorkloadIdentityAuthType authenticates with application default credentials of the deployment,
+// such as GKE workload identity, instead of a service account key
+const GcpWorkloadIdentityAuthType = "workload_identity"
+
 type GcpServiceAccount struct {
 	Type                    string `json:"type"`
 	ProjectID               string `json:"project_id"`
@@ -43,7 +49,14 @@ func GcpServiceAccountFromProto(sa *protos.GcpServiceAccount) *GcpServiceAccount
 }
 
 // Validates a GcpServiceAccount, that none of the fields are empty.
+// Workload identity only needs a project.
 func (sa *GcpServiceAccount) Validate() error {
+	if sa.Type == GcpWorkloadIdentityAuthType {
+		if sa.ProjectID == "" {
+			return errors.New("field ProjectID is empty")
+		}
+		return nil
+	}
 	v := reflect.ValueOf(*sa)
 	for i := range v.NumField() {
 		if v.Field(i).String() == "" {
@@ -53,17 +66,28 @@ func (sa *GcpServiceAccount) Validate() error {
 	return nil
 }
 
-// CreateBigQueryClient creates a new BigQuery client from a GcpServiceAccount.
-func (sa *GcpServiceAccount) CreateBigQueryClient(ctx context.Context) (*bigquery.Client, error) {
+func (sa *GcpServiceAccount) clientOptions() ([]option.ClientOption, error) {
+	if sa.Type == GcpWorkloadIdentityAuthType {
+		return nil, nil
+	}
 	saJSON, err := json.Marshal(sa)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get json: %v", err)
 	}
+	return []option.ClientOption{option.WithCredentialsJSON(saJSON)}, nil
+}
+
+// CreateBigQueryClient creates a new BigQuery client from a GcpServiceAccount.
+func (sa *GcpServiceAccount) CreateBigQueryClient(ctx context.Context) (*bigquery.Client, error) {
+	opts, err := sa.clientOptions()
+	if err != nil {
+		return nil, err
+	}
 
 	client, err := bigquery.NewClient(
 		ctx,
 		sa.ProjectID,
-		option.WithCredentialsJSON(saJSON),
+		opts...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create BigQuery client: %v", err)
@@ -72,16 +96,35 @@ func (sa *GcpServiceAccount) CreateBigQueryClient(ctx context.Context) (*bigquer
 	return client, nil
 }
 
+// CreateBigQueryWriteClient creates a new BigQuery Storage Write API client from a GcpServiceAccount.
+func (sa *GcpServiceAccount) CreateBigQueryWriteClient(ctx context.Context, projectID string) (*managedwriter.Client, error) {
+	opts, err := sa.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := managedwriter.NewClient(
+		ctx,
+		projectID,
+		opts...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery write client: %v", err)
+	}
+
+	return client, nil
+}
+
 // CreateStorageClient creates a new Storage client from a GcpServiceAccount.
 func (sa *GcpServiceAccount) CreateStorageClient(ctx context.Context) (*storage.Client, error) {
-	saJSON, err := json.Marshal(sa)
+	opts, err := sa.clientOptions()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get json: %v", err)
+		return nil, err
 	}
 
 	client, err := storage.NewClient(
 		ctx,
-		option.WithCredentialsJSON(saJSON),
+		opts...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Storage client: %v", err)
@@ -92,15 +135,15 @@ func (sa *GcpServiceAccount) CreateStorageClient(ctx context.Context) (*storage.
 
 // CreatePubSubClient creates a new PubSub client from a GcpServiceAccount.
 func (sa *GcpServiceAccount) CreatePubSubClient(ctx context.Context) (*pubsub.Client, error) {
-	saJSON, err := json.Marshal(sa)
+	opts, err := sa.clientOptions()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get json: %v", err)
+		return nil, err
 	}
 
 	client, err := pubsub.NewClient(
 		ctx,
 		sa.ProjectID,
-		option.WithCredentialsJSON(saJSON),
+		opts...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create BigQuery client: %v", err)
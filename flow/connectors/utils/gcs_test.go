@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestGcsPeerCredentials(t *testing.T) {
+	t.Parallel()
+	hmac := NewPeerAWSCredentials(&protos.S3Config{
+		Url:             "gs://bucket/prefix",
+		AccessKeyId:     proto.String("key"),
+		SecretAccessKey: proto.String("secret"),
+	})
+	require.False(t, hmac.Gcs)
+	require.Equal(t, "key", hmac.Credentials.AccessKeyID)
+	require.Equal(t, GcsEndpoint, *hmac.EndpointUrl)
+	require.Equal(t, gcsRegion, hmac.Region)
+
+	workloadIdentity := NewPeerAWSCredentials(&protos.S3Config{
+		Url:      "gs://bucket/prefix",
+		Endpoint: proto.String("https://private.googleapis.com"),
+	})
+	require.True(t, workloadIdentity.Gcs)
+	require.Nil(t, workloadIdentity.GcsServiceAccount)
+	require.Equal(t, "https://private.googleapis.com", *workloadIdentity.EndpointUrl)
+
+	s3 := NewPeerAWSCredentials(&protos.S3Config{
+		Url:             "s3://bucket/prefix",
+		AccessKeyId:     proto.String("key"),
+		SecretAccessKey: proto.String("secret"),
+	})
+	require.False(t, s3.Gcs)
+	require.Nil(t, s3.EndpointUrl)
+
+	object, err := NewS3BucketAndPrefix("gs://bucket/prefix/path")
+	require.NoError(t, err)
+	require.Equal(t, "bucket", object.Bucket)
+	require.Equal(t, "prefix/path", object.Prefix)
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// NewJSONPathExtractors returns the extractors of table mappings with json path columns, keyed by destination table
+func NewJSONPathExtractors(tableMappings []*protos.TableMapping) (map[string]*model.JSONPathExtractor, error) {
+	var extractors map[string]*model.JSONPathExtractor
+	for _, mapping := range tableMappings {
+		extractor, err := model.NewJSONPathExtractor(mapping.JsonPathColumns)
+		if err != nil {
+			return nil, err
+		} else if extractor != nil {
+			if extractors == nil {
+				extractors = make(map[string]*model.JSONPathExtractor)
+			}
+			extractors[mapping.DestinationTableIdentifier] = extractor
+		}
+	}
+	return extractors, nil
+}
+
+// AttachJSONPathsToCdcStream adds the json path columns of their tables to records
+func AttachJSONPathsToCdcStream(
+	ctx context.Context,
+	extractors map[string]*model.JSONPathExtractor,
+	stream *model.CDCStream[model.RecordItems],
+	onErr context.CancelCauseFunc,
+) *model.CDCStream[model.RecordItems] {
+	return transformCdcStream(ctx, stream, onErr, func(record model.Record[model.RecordItems]) {
+		if extractor, ok := extractors[record.GetDestinationTableName()]; ok {
+			switch r := record.(type) {
+			case *model.InsertRecord[model.RecordItems]:
+				r.Items = extractor.ExtractItems(r.Items)
+			case *model.UpdateRecord[model.RecordItems]:
+				r.NewItems = extractor.ExtractItems(r.NewItems)
+				r.OldItems = extractor.ExtractItems(r.OldItems)
+				extractor.MarkUnchanged(r.UnchangedToastColumns)
+			case *model.DeleteRecord[model.RecordItems]:
+				r.Items = extractor.ExtractItems(r.Items)
+				extractor.MarkUnchanged(r.UnchangedToastColumns)
+			}
+		}
+	}, nil)
+}
+
+// AttachJSONPathsToQRepStream appends the json path columns to the schema and rows of stream
+func AttachJSONPathsToQRepStream(extractor *model.JSONPathExtractor, stream *model.QRecordStream) *model.QRecordStream {
+	output := model.NewQRecordStream(0)
+	go func() {
+		schema, err := stream.Schema()
+		if err != nil {
+			output.Close(err)
+			return
+		}
+		index := make(map[string]int, len(schema.Fields))
+		for idx, field := range schema.Fields {
+			index[field.Name] = idx
+		}
+		numColumns := len(schema.Fields)
+		output.SetSchema(types.NewQRecordSchema(append(schema.Fields[:numColumns:numColumns], extractor.Fields()...)))
+		for record := range stream.Records {
+			output.Records <- extractor.Extract(record[:numColumns:numColumns], func(name string) types.QValue {
+				if idx, ok := index[name]; ok {
+					return record[idx]
+				}
+				return nil
+			})
+		}
+		output.Close(stream.Err())
+	}()
+	return output
+}
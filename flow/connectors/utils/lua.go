@@ -81,7 +81,7 @@ func LoadScript(ctx context.Context, script string, printfn lua.LGFunction) (*lu
 
 func DefaultOnRecord(ls *lua.LState) int {
 	ud, record := pua.LuaRecord.Check(ls, 1)
-	switch record.(type) {
+	switch rec := record.(type) {
 	case *model.InsertRecord[model.RecordItems],
 		*model.UpdateRecord[model.RecordItems],
 		*model.DeleteRecord[model.RecordItems]:
@@ -89,6 +89,15 @@ func DefaultOnRecord(ls *lua.LState) int {
 		ls.Push(ud)
 		ls.Call(1, 1)
 		return 1
+	case *model.MessageRecord[model.RecordItems]:
+		// only forwarded messages have a destination, others pass through for acknowledgement
+		if rec.DestinationTableName == "" {
+			return 0
+		}
+		ls.Push(ls.NewFunction(gluajson.LuaJsonEncode))
+		ls.Push(ud)
+		ls.Call(1, 1)
+		return 1
 	default:
 		return 0
 	}
@@ -13,6 +13,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
@@ -62,6 +63,17 @@ func UpdateLatestLSNAtTargetForCDCFlow(ctx context.Context, pool shared.CatalogP
 	return nil
 }
 
+// UpdateLastSyncForCDCFlow records that a sync completed, including syncs that found no records
+func UpdateLastSyncForCDCFlow(ctx context.Context, pool shared.CatalogPool, flowJobName string) error {
+	if _, err := pool.Exec(ctx,
+		"UPDATE peerdb_stats.cdc_flows SET last_sync_at=now() WHERE flow_name=$1",
+		flowJobName,
+	); err != nil {
+		return fmt.Errorf("error while updating last sync in cdc_flows: %w", err)
+	}
+	return nil
+}
+
 func AddCDCBatchForFlow(ctx context.Context, pool shared.CatalogPool, flowJobName string,
 	batchInfo CDCBatchInfo,
 ) error {
@@ -120,20 +132,24 @@ func AddCDCBatchTablesForFlow(ctx context.Context, pool shared.CatalogPool, flow
 	}
 	defer shared.RollbackTx(insertBatchTablesTx, internal.LoggerFromCtx(ctx))
 
+	var oversizedRows int64
 	for destinationTableName, rowCounts := range tableNameRowsMapping {
 		inserts := rowCounts.InsertCount.Load()
 		updates := rowCounts.UpdateCount.Load()
 		deletes := rowCounts.DeleteCount.Load()
+		oversized := rowCounts.OversizedCount.Load()
+		numericOverflow := rowCounts.NumericOverflowCount.Load()
 		totalRows := inserts + updates + deletes
+		oversizedRows += int64(oversized)
 
 		// Insert into cdc_batch_table
 		if _, err := insertBatchTablesTx.Exec(ctx,
 			`INSERT INTO peerdb_stats.cdc_batch_table
 			(flow_name,batch_id,destination_table_name,num_rows,
-			insert_count,update_count,delete_count)
-			 VALUES($1,$2,$3,$4,$5,$6,$7) ON CONFLICT DO NOTHING`,
+			insert_count,update_count,delete_count,oversized_count,numeric_overflow_count)
+			 VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9) ON CONFLICT DO NOTHING`,
 			flowJobName, batchID, destinationTableName,
-			totalRows, inserts, updates, deletes,
+			totalRows, inserts, updates, deletes, oversized, numericOverflow,
 		); err != nil {
 			return fmt.Errorf("error while inserting statistics into cdc_batch_table: %w", err)
 		}
@@ -182,12 +198,48 @@ func AddCDCBatchTablesForFlow(ctx context.Context, pool shared.CatalogPool, flow
 			return fmt.Errorf("error while updating aggregate statistics in cdc_table_aggregate_counts: %w", err)
 		}
 	}
+	if oversizedRows > 0 {
+		if _, err := insertBatchTablesTx.Exec(ctx,
+			"UPDATE peerdb_stats.cdc_batches SET oversized_rows=$1 WHERE flow_name=$2 AND batch_id=$3",
+			oversizedRows, flowJobName, batchID,
+		); err != nil {
+			return fmt.Errorf("error while updating oversized rows of cdc_batches: %w", err)
+		}
+	}
 	if err := insertBatchTablesTx.Commit(ctx); err != nil {
 		return fmt.Errorf("error while committing transaction for inserting and updating statistics: %w", err)
 	}
 	return nil
 }
 
+// AddNormalizeConflicts logs changes that conflicted with destination rows modified out-of-band
+func AddNormalizeConflicts(ctx context.Context, pool shared.CatalogPool, flowJobName string,
+	batchID int64, conflicts []model.NormalizeConflict,
+) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error while beginning transaction for inserting normalize conflicts: %w", err)
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"peerdb_stats", "normalize_conflicts"},
+		[]string{"flow_name", "batch_id", "destination_table_name", "primary_key", "record_type", "policy", "source_applied"},
+		pgx.CopyFromSlice(len(conflicts), func(i int) ([]any, error) {
+			conflict := conflicts[i]
+			return []any{
+				flowJobName, batchID, conflict.DestinationTableName, conflict.PrimaryKey,
+				conflict.RecordType, conflict.Policy.String(), conflict.SourceApplied,
+			}, nil
+		}),
+	); err != nil {
+		return fmt.Errorf("error while inserting normalize conflicts: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
 func InitializeQRepRun(
 	ctx context.Context,
 	logger log.Logger,
@@ -328,12 +380,17 @@ func addPartitionToQRepRun(ctx context.Context, tx pgx.Tx, flowJobName string,
 		internal.LoggerFromCtx(ctx).Warn("[monitoring]: partition "+partition.PartitionId+" has nil range",
 			slog.String(string(shared.FlowNameKey), parentMirrorName))
 	}
+	// kept so that failed partitions can be retried on their own
+	partitionBytes, err := proto.Marshal(partition)
+	if err != nil {
+		return fmt.Errorf("unable to marshal partition: %w", err)
+	}
 	if _, err := tx.Exec(ctx,
 		`INSERT INTO peerdb_stats.qrep_partitions
-		(flow_name,run_uuid,partition_uuid,partition_start,partition_end,restart_count,parent_mirror_name)
-		 VALUES($1,$2,$3,$4,$5,$6,$7) ON CONFLICT(run_uuid,partition_uuid) DO UPDATE SET
+		(flow_name,run_uuid,partition_uuid,partition_start,partition_end,restart_count,parent_mirror_name,partition_proto)
+		 VALUES($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT(run_uuid,partition_uuid) DO UPDATE SET
 		 restart_count=qrep_partitions.restart_count+1`,
-		flowJobName, runUUID, partition.PartitionId, rangeStart, rangeEnd, 0, parentMirrorName,
+		flowJobName, runUUID, partition.PartitionId, rangeStart, rangeEnd, 0, parentMirrorName, partitionBytes,
 	); err != nil {
 		return fmt.Errorf("error while inserting qrep partition in qrep_partitions: %w", err)
 	}
@@ -349,7 +406,8 @@ func UpdateStartTimeForPartition(
 	startTime time.Time,
 ) error {
 	if _, err := pool.Exec(ctx,
-		`UPDATE peerdb_stats.qrep_partitions SET start_time=$1 WHERE run_uuid=$2 AND partition_uuid=$3`,
+		`UPDATE peerdb_stats.qrep_partitions SET start_time=$1,last_error=NULL,last_error_time=NULL
+		WHERE run_uuid=$2 AND partition_uuid=$3`,
 		startTime, runUUID, partition.PartitionId,
 	); err != nil {
 		return fmt.Errorf("error while updating qrep partition in qrep_partitions: %w", err)
@@ -381,6 +439,18 @@ func UpdateEndTimeForPartition(ctx context.Context, pool shared.CatalogPool, run
 	return nil
 }
 
+func UpdateErrorForPartition(ctx context.Context, pool shared.CatalogPool, runUUID string,
+	partition *protos.QRepPartition, partitionErr error,
+) error {
+	if _, err := pool.Exec(ctx,
+		`UPDATE peerdb_stats.qrep_partitions SET last_error=$1,last_error_time=$2 WHERE run_uuid=$3 AND partition_uuid=$4`,
+		partitionErr.Error(), time.Now(), runUUID, partition.PartitionId,
+	); err != nil {
+		return fmt.Errorf("error while updating last_error in qrep_partitions: %w", err)
+	}
+	return nil
+}
+
 func UpdateRowsSyncedForPartition(ctx context.Context, pool shared.CatalogPool, rowsSynced int64, runUUID string,
 	partition *protos.QRepPartition,
 ) error {
@@ -420,6 +490,10 @@ func DeleteMirrorStats(ctx context.Context, logger log.Logger, pool shared.Catal
 		return fmt.Errorf("error while deleting cdc_table_aggregate_counts: %w", err)
 	}
 
+	if _, err := tx.Exec(ctx, `DELETE FROM peerdb_stats.normalize_conflicts WHERE flow_name = $1`, flowJobName); err != nil {
+		return fmt.Errorf("error while deleting normalize_conflicts: %w", err)
+	}
+
 	if _, err := tx.Exec(ctx, `DELETE FROM peerdb_stats.cdc_flows WHERE flow_name = $1`, flowJobName); err != nil {
 		return fmt.Errorf("error while deleting cdc_flows: %w", err)
 	}
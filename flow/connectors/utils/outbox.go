@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// OutboxMessage is a row of an outbox table as a message for queues, nil fields were null or not configured
+type OutboxMessage struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers []OutboxHeader
+}
+
+type OutboxHeader struct {
+	Name  string
+	Value []byte
+}
+
+// OutboxRouter turns records of outbox tables into messages, keyed by destination table
+type OutboxRouter map[string]*protos.OutboxConfig
+
+func NewOutboxRouter(tableMappings []*protos.TableMapping) OutboxRouter {
+	router := make(OutboxRouter)
+	for _, tableMapping := range tableMappings {
+		if tableMapping.Outbox != nil {
+			router[tableMapping.DestinationTableIdentifier] = tableMapping.Outbox
+		}
+	}
+	return router
+}
+
+// Route returns whether record belongs to an outbox table, along with its message if it has one
+func (r OutboxRouter) Route(record model.Record[model.RecordItems]) (*OutboxMessage, bool, error) {
+	config, ok := r[record.GetDestinationTableName()]
+	if !ok {
+		return nil, false, nil
+	}
+	insert, ok := record.(*model.InsertRecord[model.RecordItems])
+	if !ok {
+		return nil, true, nil
+	}
+
+	topic := outboxColumnBytes(insert.Items.GetColumnValue(config.TopicColumn))
+	if len(topic) == 0 {
+		return nil, true, fmt.Errorf("outbox row of %s has no topic in column %s", insert.DestinationTableName, config.TopicColumn)
+	}
+	msg := &OutboxMessage{
+		Topic:   string(topic),
+		Headers: make([]OutboxHeader, 0, len(config.HeaderColumns)),
+	}
+	if config.KeyColumn != "" {
+		msg.Key = outboxColumnBytes(insert.Items.GetColumnValue(config.KeyColumn))
+	}
+	if config.PayloadColumn != "" {
+		msg.Value = outboxColumnBytes(insert.Items.GetColumnValue(config.PayloadColumn))
+	} else {
+		value, err := insert.Items.MarshalJSON()
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to serialize outbox row of %s: %w", insert.DestinationTableName, err)
+		}
+		msg.Value = value
+	}
+	for _, column := range config.HeaderColumns {
+		if value := outboxColumnBytes(insert.Items.GetColumnValue(column)); value != nil {
+			msg.Headers = append(msg.Headers, OutboxHeader{Name: column, Value: value})
+		}
+	}
+	return msg, true, nil
+}
+
+func outboxColumnBytes(value types.QValue) []byte {
+	switch v := value.(type) {
+	case nil, types.QValueNull:
+		return nil
+	case types.QValueBytes:
+		return v.Val
+	case types.QValueString:
+		return shared.UnsafeFastStringToReadOnlyBytes(v.Val)
+	case types.QValueJSON:
+		return shared.UnsafeFastStringToReadOnlyBytes(v.Val)
+	default:
+		return fmt.Append(nil, v.Value())
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestOutboxRouter(t *testing.T) {
+	router := NewOutboxRouter([]*protos.TableMapping{
+		{DestinationTableIdentifier: "orders"},
+		{DestinationTableIdentifier: "outbox", Outbox: &protos.OutboxConfig{
+			TopicColumn:   "aggregate_type",
+			KeyColumn:     "aggregate_id",
+			PayloadColumn: "payload",
+			HeaderColumns: []string{"event_type", "trace_id"},
+		}},
+	})
+
+	items := model.NewRecordItems(5)
+	items.AddColumn("aggregate_type", types.QValueString{Val: "order_events"})
+	items.AddColumn("aggregate_id", types.QValueInt64{Val: 42})
+	items.AddColumn("payload", types.QValueJSON{Val: `{"status":"shipped"}`})
+	items.AddColumn("event_type", types.QValueString{Val: "OrderShipped"})
+	items.AddColumn("trace_id", types.QValueNull(types.QValueKindString))
+	msg, ok, err := router.Route(&model.InsertRecord[model.RecordItems]{Items: items, DestinationTableName: "outbox"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "order_events", msg.Topic)
+	require.Equal(t, []byte("42"), msg.Key)
+	require.JSONEq(t, `{"status":"shipped"}`, string(msg.Value))
+	require.Equal(t, []OutboxHeader{{Name: "event_type", Value: []byte("OrderShipped")}}, msg.Headers)
+
+	// cleaning up delivered rows publishes nothing
+	msg, ok, err = router.Route(&model.DeleteRecord[model.RecordItems]{Items: items, DestinationTableName: "outbox"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Nil(t, msg)
+
+	_, ok, err = router.Route(&model.InsertRecord[model.RecordItems]{Items: items, DestinationTableName: "orders"})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -51,12 +51,24 @@ func CreatePeerNoValidate(
 			return wrongConfigResponse, nil
 		}
 		innerConfig = s3ConfigObject.S3Config
+	case protos.DBType_AZURE_BLOB:
+		azConfigObject, ok := config.(*protos.Peer_AzureBlobConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = azConfigObject.AzureBlobConfig
 	case protos.DBType_MYSQL:
 		myConfigObject, ok := config.(*protos.Peer_MysqlConfig)
 		if !ok {
 			return wrongConfigResponse, nil
 		}
 		innerConfig = myConfigObject.MysqlConfig
+	case protos.DBType_SQLSERVER:
+		ssConfigObject, ok := config.(*protos.Peer_SqlserverConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = ssConfigObject.SqlserverConfig
 	case protos.DBType_CLICKHOUSE:
 		chConfigObject, ok := config.(*protos.Peer_ClickhouseConfig)
 		if !ok {
@@ -75,6 +87,12 @@ func CreatePeerNoValidate(
 			return wrongConfigResponse, nil
 		}
 		innerConfig = psConfigObject.PubsubConfig
+	case protos.DBType_KINESIS:
+		kiConfigObject, ok := config.(*protos.Peer_KinesisConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = kiConfigObject.KinesisConfig
 	case protos.DBType_EVENTHUBS:
 		ehConfigObject, ok := config.(*protos.Peer_EventhubGroupConfig)
 		if !ok {
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// PreflightCheckResult reports a check as failed with the error, or as passed without one
+func PreflightCheckResult(name string, err error) *protos.PreflightCheck {
+	if err != nil {
+		return &protos.PreflightCheck{
+			Name:    name,
+			Status:  protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_FAIL,
+			Message: err.Error(),
+		}
+	}
+	return &protos.PreflightCheck{Name: name, Status: protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_PASS}
+}
+
+// PreflightTableChecks reports creating a table and inserting into it as separate checks,
+// inserting is only tested once the table could be created
+func PreflightTableChecks(createTable func() error, insert func() error) []*protos.PreflightCheck {
+	createCheck := PreflightCheckResult("create_table", createTable())
+	if createCheck.Status != protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_PASS {
+		return []*protos.PreflightCheck{createCheck, {
+			Name:    "insert",
+			Status:  protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_WARN,
+			Message: "not tested since the table could not be created",
+		}}
+	}
+	return []*protos.PreflightCheck{createCheck, PreflightCheckResult("insert", insert())}
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestPreflightTableChecks(t *testing.T) {
+	inserted := false
+	checks := PreflightTableChecks(func() error {
+		return nil
+	}, func() error {
+		inserted = true
+		return errors.New("permission denied")
+	})
+	require.True(t, inserted)
+	require.Len(t, checks, 2)
+	require.Equal(t, "create_table", checks[0].Name)
+	require.Equal(t, protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_PASS, checks[0].Status)
+	require.Equal(t, "insert", checks[1].Name)
+	require.Equal(t, protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_FAIL, checks[1].Status)
+	require.Equal(t, "permission denied", checks[1].Message)
+
+	// without a table there is nothing to insert into
+	checks = PreflightTableChecks(func() error {
+		return errors.New("permission denied")
+	}, func() error {
+		require.Fail(t, "insert tested without a table")
+		return nil
+	})
+	require.Len(t, checks, 2)
+	require.Equal(t, protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_FAIL, checks[0].Status)
+	require.Equal(t, "insert", checks[1].Name)
+	require.Equal(t, protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_WARN, checks[1].Status)
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// RateLimiter is a token bucket kept in the catalog, so that a limit of a peer holds across all workers and mirrors,
+// e.g. load jobs per day or bulk requests per second
+type RateLimiter struct {
+	catalogPool shared.CatalogPool
+	peerName    string
+	key         string
+	// tokens added per second
+	rate float64
+	// most tokens the bucket holds
+	burst float64
+}
+
+// NewRateLimiter allows limit tokens per interval, all of which can be taken at once
+func NewRateLimiter(catalogPool shared.CatalogPool, peerName string, key string, limit float64, interval time.Duration) (*RateLimiter, error) {
+	if limit <= 0 || interval <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %s of peer %s: %v per %s", key, peerName, limit, interval)
+	}
+	return &RateLimiter{
+		catalogPool: catalogPool,
+		peerName:    peerName,
+		key:         key,
+		rate:        limit / interval.Seconds(),
+		burst:       limit,
+	}, nil
+}
+
+// Wait blocks until n tokens are available and takes them. Tokens are reserved before waiting,
+// so concurrent callers are served in the order they arrived
+func (l *RateLimiter) Wait(ctx context.Context, n float64) error {
+	if n > l.burst {
+		return fmt.Errorf("cannot take %v tokens of rate limit %s of peer %s, it allows at most %v", n, l.key, l.peerName, l.burst)
+	}
+
+	// tokens go negative when reserved ahead of time, the debt is paid off by waiting for the refill
+	var tokens float64
+	if err := l.catalogPool.QueryRow(ctx, `
+		INSERT INTO peer_rate_limits AS l (peer_name, limit_key, tokens) VALUES ($1, $2, $3 - $4)
+		ON CONFLICT (peer_name, limit_key) DO UPDATE SET
+			tokens = least($3, l.tokens + extract(epoch FROM now() - l.updated_at) * $5) - $4,
+			updated_at = now()
+		RETURNING tokens`,
+		l.peerName, l.key, l.burst, n, l.rate,
+	).Scan(&tokens); err != nil {
+		return fmt.Errorf("failed to take from rate limit %s of peer %s: %w", l.key, l.peerName, err)
+	}
+
+	delay := rateLimitDelay(tokens, l.rate)
+	if delay == 0 {
+		return nil
+	}
+	if delay >= time.Minute {
+		internal.LoggerFromCtx(ctx).Info("waiting for rate limit of peer",
+			slog.String("peer", l.peerName), slog.String("limit", l.key), slog.Duration("delay", delay))
+	}
+	select {
+	case <-ctx.Done():
+		l.refund(n)
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// refund returns tokens that were reserved but not used
+func (l *RateLimiter) refund(n float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := l.catalogPool.Exec(ctx,
+		"UPDATE peer_rate_limits SET tokens = least($3, tokens + $4) WHERE peer_name = $1 AND limit_key = $2",
+		l.peerName, l.key, l.burst, n,
+	); err != nil {
+		slog.Warn("failed to refund rate limit tokens", slog.String("peer", l.peerName), slog.String("limit", l.key), slog.Any("error", err))
+	}
+}
+
+// rateLimitDelay is how long a bucket left with tokens takes to refill back to zero
+func rateLimitDelay(tokens float64, rate float64) time.Duration {
+	if tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tokens / rate * float64(time.Second))
+}
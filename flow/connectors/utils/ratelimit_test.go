@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestRateLimitDelay(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.Duration(0), rateLimitDelay(3, 1))
+	require.Equal(t, time.Duration(0), rateLimitDelay(0, 1))
+	require.Equal(t, 2*time.Second, rateLimitDelay(-2, 1))
+	require.Equal(t, 500*time.Millisecond, rateLimitDelay(-50, 100))
+	// 1500 per day
+	require.Equal(t, 24*time.Hour/1500, rateLimitDelay(-1, 1500/(24*time.Hour).Seconds()).Round(time.Millisecond))
+}
+
+func TestNewRateLimiterRejectsInvalidLimits(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRateLimiter(shared.CatalogPool{}, "peer", "requests", 0, time.Second)
+	require.Error(t, err)
+	_, err = NewRateLimiter(shared.CatalogPool{}, "peer", "requests", 10, 0)
+	require.Error(t, err)
+	limiter, err := NewRateLimiter(shared.CatalogPool{}, "peer", "requests", 10, time.Second)
+	require.NoError(t, err)
+	require.ErrorContains(t, limiter.Wait(t.Context(), 11), "at most 10")
+}
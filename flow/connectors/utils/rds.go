@@ -47,6 +47,14 @@ func (r *RDSAuth) VerifyAuthConfig() error {
 	return nil
 }
 
+// Invalidate drops the cached token so the next connection generates a new one,
+// for when the database rejected it before its TTL ran out, e.g. after the role's credentials were rotated
+func (r *RDSAuth) Invalidate() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.token = ""
+}
+
 type RDSConnectionConfig struct {
 	Host string
 	User string
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+const sseCustomerAlgorithm = "AES256"
+
+// S3Encryption is applied to every object written through CreateS3Client, reads and multipart parts carry SSE-C keys
+type S3Encryption struct {
+	KmsKeyArn string
+	// base64 encoded 256-bit key
+	CustomerKey            string
+	BucketOwnerFullControl bool
+}
+
+func (e S3Encryption) Validate() error {
+	if e.KmsKeyArn != "" && e.CustomerKey != "" {
+		return errors.New("SSE-KMS and SSE-C are mutually exclusive")
+	}
+	if e.CustomerKey != "" {
+		key, err := base64.StdEncoding.DecodeString(e.CustomerKey)
+		if err != nil {
+			return fmt.Errorf("SSE-C key is not valid base64: %w", err)
+		}
+		if len(key) != 32 {
+			return fmt.Errorf("SSE-C key must be 256 bits, got %d", len(key)*8)
+		}
+	}
+	return nil
+}
+
+func (e S3Encryption) IsZero() bool {
+	return e == S3Encryption{}
+}
+
+func (e S3Encryption) customerKeyMD5() string {
+	key, _ := base64.StdEncoding.DecodeString(e.CustomerKey)
+	sum := md5.Sum(key)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// S3Headers are the SSE-C headers needed by external readers of staged objects, like ClickHouse's s3 table function
+func (e S3Encryption) S3Headers() map[string]string {
+	if e.CustomerKey == "" {
+		return nil
+	}
+	return map[string]string{
+		"x-amz-server-side-encryption-customer-algorithm": sseCustomerAlgorithm,
+		"x-amz-server-side-encryption-customer-key":       e.CustomerKey,
+		"x-amz-server-side-encryption-customer-key-MD5":   e.customerKeyMD5(),
+	}
+}
+
+func (e S3Encryption) addMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("PeerDBS3Encryption", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		e.apply(in.Parameters)
+		return next.HandleInitialize(ctx, in)
+	}), middleware.Before)
+}
+
+func (e S3Encryption) apply(params any) {
+	var algorithm, key, keyMD5 *string
+	if e.CustomerKey != "" {
+		algorithm = aws.String(sseCustomerAlgorithm)
+		key = aws.String(e.CustomerKey)
+		keyMD5 = aws.String(e.customerKeyMD5())
+	}
+	var acl types.ObjectCannedACL
+	if e.BucketOwnerFullControl {
+		acl = types.ObjectCannedACLBucketOwnerFullControl
+	}
+
+	switch in := params.(type) {
+	case *s3.PutObjectInput:
+		if e.KmsKeyArn != "" {
+			in.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+			in.SSEKMSKeyId = aws.String(e.KmsKeyArn)
+		}
+		in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5 = algorithm, key, keyMD5
+		if acl != "" {
+			in.ACL = acl
+		}
+	case *s3.CreateMultipartUploadInput:
+		if e.KmsKeyArn != "" {
+			in.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+			in.SSEKMSKeyId = aws.String(e.KmsKeyArn)
+		}
+		in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5 = algorithm, key, keyMD5
+		if acl != "" {
+			in.ACL = acl
+		}
+	case *s3.UploadPartInput:
+		in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5 = algorithm, key, keyMD5
+	case *s3.CompleteMultipartUploadInput:
+		in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5 = algorithm, key, keyMD5
+	case *s3.GetObjectInput:
+		in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5 = algorithm, key, keyMD5
+	case *s3.HeadObjectInput:
+		in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5 = algorithm, key, keyMD5
+	}
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3Encryption(t *testing.T) {
+	t.Parallel()
+	customerKey := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+
+	require.NoError(t, S3Encryption{}.Validate())
+	require.Error(t, S3Encryption{KmsKeyArn: "arn:aws:kms:us-east-1:1:key/a", CustomerKey: customerKey}.Validate())
+	require.Error(t, S3Encryption{CustomerKey: base64.StdEncoding.EncodeToString([]byte("short"))}.Validate())
+
+	kms := S3Encryption{KmsKeyArn: "arn:aws:kms:us-east-1:1:key/a", BucketOwnerFullControl: true}
+	put := &s3.PutObjectInput{}
+	kms.apply(put)
+	require.Equal(t, types.ServerSideEncryptionAwsKms, put.ServerSideEncryption)
+	require.Equal(t, "arn:aws:kms:us-east-1:1:key/a", *put.SSEKMSKeyId)
+	require.Equal(t, types.ObjectCannedACLBucketOwnerFullControl, put.ACL)
+	require.Nil(t, put.SSECustomerKey)
+	require.Empty(t, kms.S3Headers())
+
+	sseC := S3Encryption{CustomerKey: customerKey}
+	require.NoError(t, sseC.Validate())
+	part := &s3.UploadPartInput{}
+	sseC.apply(part)
+	require.Equal(t, "AES256", *part.SSECustomerAlgorithm)
+	require.Equal(t, customerKey, *part.SSECustomerKey)
+	require.Equal(t, sseC.customerKeyMD5(), *part.SSECustomerKeyMD5)
+	get := &s3.GetObjectInput{}
+	sseC.apply(get)
+	require.Equal(t, customerKey, *get.SSECustomerKey)
+	require.Len(t, sseC.S3Headers(), 3)
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// UploadS3Stream uploads what write writes to key while it is being written, returning the size of the object.
+// write is blocked while every part buffer is in flight, so memory stays under PEERDB_S3_UPLOAD_MEMORY_LIMIT
+// regardless of how large the object grows
+func UploadS3Stream(
+	ctx context.Context,
+	env map[string]string,
+	client *s3.Client,
+	bucket string,
+	key string,
+	write func(io.Writer) error,
+) (int64, error) {
+	configuredPartSize, err := internal.PeerDBS3PartSize(ctx, env)
+	if err != nil {
+		return 0, fmt.Errorf("could not get s3 part size config: %w", err)
+	}
+	configuredConcurrency, err := internal.PeerDBS3UploadConcurrency(ctx, env)
+	if err != nil {
+		return 0, fmt.Errorf("could not get s3 upload concurrency config: %w", err)
+	}
+	memoryLimit, err := internal.PeerDBS3UploadMemoryLimit(ctx, env)
+	if err != nil {
+		return 0, fmt.Errorf("could not get s3 upload memory limit config: %w", err)
+	}
+	partSize, concurrency := s3UploadBuffers(configuredPartSize, configuredConcurrency, memoryLimit)
+
+	r, w := io.Pipe()
+	defer r.Close()
+
+	var size int64
+	var writeErr error
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				writeErr = fmt.Errorf("panic occurred during write: %v", r)
+				internal.LoggerFromCtx(ctx).Error("panic during write",
+					slog.Any("error", writeErr), slog.String("stack", string(debug.Stack())))
+			}
+			// a failed write aborts the multipart upload instead of completing a truncated object
+			w.CloseWithError(writeErr)
+		}()
+		cw := &countingWriter{w: w}
+		writeErr = write(cw)
+		size = cw.n
+	}()
+
+	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = concurrency
+	})
+	if _, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	}); err != nil {
+		if writeErr != nil {
+			return 0, fmt.Errorf("failed to write s3://%s/%s: %w", bucket, key, writeErr)
+		}
+		return 0, fmt.Errorf("failed to upload file to s3://%s/%s: %w", bucket, key, err)
+	}
+	if writeErr != nil {
+		return 0, fmt.Errorf("failed to write s3://%s/%s: %w", bucket, key, writeErr)
+	}
+	return size, nil
+}
+
+// s3UploadBuffers fits part size and concurrency under memoryLimit, the uploader holds concurrency+1 part buffers
+func s3UploadBuffers(partSize int64, concurrency int, memoryLimit int64) (int64, int) {
+	if partSize <= 0 {
+		partSize = manager.DefaultUploadPartSize
+	}
+	if concurrency <= 0 {
+		concurrency = manager.DefaultUploadConcurrency
+	}
+	if memoryLimit <= 0 {
+		return partSize, concurrency
+	}
+	if maxConcurrency := memoryLimit/partSize - 1; maxConcurrency < 1 {
+		// not even two parts fit, shrink parts rather than exceed the limit
+		return max(memoryLimit/2, manager.MinUploadPartSize), 1
+	} else if int64(concurrency) > maxConcurrency {
+		concurrency = int(maxConcurrency)
+	}
+	return partSize, concurrency
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
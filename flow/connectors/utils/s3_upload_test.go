@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3UploadBuffers(t *testing.T) {
+	t.Parallel()
+	const mib = 1024 * 1024
+
+	partSize, concurrency := s3UploadBuffers(0, 0, 0)
+	require.Equal(t, int64(manager.DefaultUploadPartSize), partSize)
+	require.Equal(t, manager.DefaultUploadConcurrency, concurrency)
+
+	partSize, concurrency = s3UploadBuffers(64*mib, 5, 0)
+	require.Equal(t, int64(64*mib), partSize)
+	require.Equal(t, 5, concurrency)
+
+	// 4 buffers fit, one is kept for reading
+	partSize, concurrency = s3UploadBuffers(64*mib, 5, 256*mib)
+	require.Equal(t, int64(64*mib), partSize)
+	require.Equal(t, 3, concurrency)
+
+	partSize, concurrency = s3UploadBuffers(64*mib, 5, 64*mib)
+	require.Equal(t, int64(32*mib), partSize)
+	require.Equal(t, 1, concurrency)
+
+	partSize, concurrency = s3UploadBuffers(64*mib, 5, mib)
+	require.Equal(t, int64(manager.MinUploadPartSize), partSize)
+	require.Equal(t, 1, concurrency)
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// sampleBuckets is the resolution of sample percentages, allowing samples down to 0.0001%
+const sampleBuckets = 1_000_000
+
+// IsSampled deterministically picks values so that percent of distinct values are kept
+func IsSampled(value types.QValue, percent float64) bool {
+	hash := fnv.New64a()
+	_, _ = fmt.Fprint(hash, value.Value())
+	return hash.Sum64()%sampleBuckets < uint64(percent*sampleBuckets/100)
+}
+
+// SampleQRepStream drops rows outside of the configured sample, the stream is returned as is when sampling is disabled
+func SampleQRepStream(config *protos.QRepConfig, stream *model.QRecordStream) *model.QRecordStream {
+	if config.SamplePercent <= 0 || config.SamplePercent >= 100 {
+		return stream
+	}
+	column := config.SampleColumn
+	if column == "" {
+		column = config.WatermarkColumn
+	}
+
+	output := model.NewQRecordStream(0)
+	go func() {
+		schema, err := stream.Schema()
+		if err != nil {
+			output.Close(err)
+			return
+		}
+		output.SetSchema(schema)
+		idx := -1
+		for i, field := range schema.Fields {
+			if field.Name == column {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			output.Close(fmt.Errorf("sample column %s not found in query result", column))
+			return
+		}
+		for record := range stream.Records {
+			if IsSampled(record[idx], config.SamplePercent) {
+				output.Records <- record
+			}
+		}
+		output.Close(stream.Err())
+	}()
+	return output
+}
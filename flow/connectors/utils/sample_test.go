@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestSampleQRepStream(t *testing.T) {
+	t.Parallel()
+	config := &protos.QRepConfig{WatermarkColumn: "id", SamplePercent: 10}
+	run := func() []int64 {
+		stream := model.NewQRecordStream(0)
+		output := SampleQRepStream(config, stream)
+		go func() {
+			stream.SetSchema(types.QRecordSchema{Fields: []types.QField{{Name: "name"}, {Name: "id"}}})
+			for i := range int64(10000) {
+				stream.Records <- []types.QValue{types.QValueString{Val: "x"}, types.QValueInt64{Val: i}}
+			}
+			stream.Close(nil)
+		}()
+		var ids []int64
+		for record := range output.Records {
+			ids = append(ids, record[1].Value().(int64))
+		}
+		require.NoError(t, output.Err())
+		return ids
+	}
+
+	ids := run()
+	require.InDelta(t, 1000, len(ids), 150)
+	require.Equal(t, ids, run())
+}
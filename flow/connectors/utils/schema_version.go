@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// SchemaVersionHeader is attached to messages sent to queues, identifying the schema of the destination table
+const SchemaVersionHeader = "peerdb-schema-version"
+
+// SchemaVersionID fingerprints columns by name and type in order,
+// so a table refetched after a schema change ends up with the same id its schema change event announced
+func SchemaVersionID(columns []*protos.FieldDescription) string {
+	h := fnv.New64a()
+	for _, column := range columns {
+		h.Write([]byte(column.Name))
+		h.Write([]byte{0})
+		h.Write([]byte(column.Type))
+		h.Write([]byte{0})
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+type SchemaChangeColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+type SchemaChangeEvent struct {
+	FlowJobName           string               `json:"flow_name"`
+	SourceTable           string               `json:"source_table"`
+	DestinationTable      string               `json:"destination_table"`
+	PreviousSchemaVersion string               `json:"previous_schema_version"`
+	SchemaVersion         string               `json:"schema_version"`
+	AddedColumns          []SchemaChangeColumn `json:"added_columns"`
+	DroppedColumns        []string             `json:"dropped_columns"`
+	// records of later batches carry SchemaVersion,
+	// records of this batch carry the version the batch started with
+	BatchID int64 `json:"batch_id"`
+}
+
+// SchemaVersions snapshots destination table columns at the start of a batch,
+// since pulling records may update table schemas while the batch is being synced
+type SchemaVersions struct {
+	columns map[string][]*protos.FieldDescription
+	ids     map[string]string
+}
+
+func NewSchemaVersions(tableNameSchemaMapping map[string]*protos.TableSchema) *SchemaVersions {
+	versions := &SchemaVersions{
+		columns: make(map[string][]*protos.FieldDescription, len(tableNameSchemaMapping)),
+		ids:     make(map[string]string, len(tableNameSchemaMapping)),
+	}
+	for tableName, schema := range tableNameSchemaMapping {
+		columns := slices.Clone(schema.Columns)
+		versions.columns[tableName] = columns
+		versions.ids[tableName] = SchemaVersionID(columns)
+	}
+	return versions
+}
+
+func (v *SchemaVersions) Get(destinationTableName string) (string, bool) {
+	id, ok := v.ids[destinationTableName]
+	return id, ok
+}
+
+// insertColumnAfter places an added column where it is at source if known, appending it otherwise
+func insertColumnAfter(
+	columns []*protos.FieldDescription, column *protos.FieldDescription, addedColumnsAfter map[string]string,
+) []*protos.FieldDescription {
+	after, ok := addedColumnsAfter[column.Name]
+	if !ok {
+		return append(columns, column)
+	} else if after == "" {
+		return slices.Insert(columns, 0, column)
+	}
+	if idx := slices.IndexFunc(columns, func(existing *protos.FieldDescription) bool {
+		return existing.Name == after
+	}); idx != -1 {
+		return slices.Insert(columns, idx+1, column)
+	}
+	return append(columns, column)
+}
+
+// ChangeEvents applies schema deltas in order, producing an event per delta
+func (v *SchemaVersions) ChangeEvents(flowJobName string, batchID int64, deltas []*protos.TableSchemaDelta) []SchemaChangeEvent {
+	columns := make(map[string][]*protos.FieldDescription, len(deltas))
+	events := make([]SchemaChangeEvent, 0, len(deltas))
+	for _, delta := range deltas {
+		prevColumns, ok := columns[delta.DstTableName]
+		if !ok {
+			prevColumns = v.columns[delta.DstTableName]
+		}
+		nextColumns := slices.DeleteFunc(slices.Clone(prevColumns), func(column *protos.FieldDescription) bool {
+			return slices.Contains(delta.DroppedColumns, column.Name)
+		})
+		addedColumns := make([]SchemaChangeColumn, 0, len(delta.AddedColumns))
+		for _, column := range delta.AddedColumns {
+			if !slices.ContainsFunc(nextColumns, func(existing *protos.FieldDescription) bool {
+				return existing.Name == column.Name
+			}) {
+				nextColumns = insertColumnAfter(nextColumns, column, delta.AddedColumnsAfter)
+			}
+			addedColumns = append(addedColumns, SchemaChangeColumn{
+				Name:     column.Name,
+				Type:     column.Type,
+				Nullable: column.Nullable,
+			})
+		}
+		columns[delta.DstTableName] = nextColumns
+
+		events = append(events, SchemaChangeEvent{
+			FlowJobName:           flowJobName,
+			SourceTable:           delta.SrcTableName,
+			DestinationTable:      delta.DstTableName,
+			PreviousSchemaVersion: SchemaVersionID(prevColumns),
+			SchemaVersion:         SchemaVersionID(nextColumns),
+			AddedColumns:          addedColumns,
+			DroppedColumns:        slices.Clone(delta.DroppedColumns),
+			BatchID:               batchID,
+		})
+	}
+	return events
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestSchemaVersionsChangeEvents(t *testing.T) {
+	t.Parallel()
+
+	id := &protos.FieldDescription{Name: "id", Type: "int64"}
+	name := &protos.FieldDescription{Name: "name", Type: "string"}
+	email := &protos.FieldDescription{Name: "email", Type: "string", Nullable: true}
+
+	versions := NewSchemaVersions(map[string]*protos.TableSchema{
+		"users": {Columns: []*protos.FieldDescription{id, name}},
+	})
+	initial, ok := versions.Get("users")
+	require.True(t, ok)
+
+	events := versions.ChangeEvents("flow", 7, []*protos.TableSchemaDelta{
+		{SrcTableName: "public.users", DstTableName: "users", AddedColumns: []*protos.FieldDescription{email}},
+		{SrcTableName: "public.users", DstTableName: "users", DroppedColumns: []string{"name"}},
+	})
+	require.Len(t, events, 2)
+	require.Equal(t, initial, events[0].PreviousSchemaVersion)
+	require.Equal(t, SchemaVersionID([]*protos.FieldDescription{id, name, email}), events[0].SchemaVersion)
+	require.Equal(t, events[0].SchemaVersion, events[1].PreviousSchemaVersion)
+	// matches the schema fetched from source once the batch is done
+	require.Equal(t, SchemaVersionID([]*protos.FieldDescription{id, email}), events[1].SchemaVersion)
+	require.Equal(t, int64(7), events[1].BatchID)
+
+	// records of the batch keep the version it started with
+	current, _ := versions.Get("users")
+	require.Equal(t, initial, current)
+
+	// columns placed at source keep their place
+	events = versions.ChangeEvents("flow", 8, []*protos.TableSchemaDelta{
+		{
+			SrcTableName: "public.users", DstTableName: "users", AddedColumns: []*protos.FieldDescription{email},
+			AddedColumnsAfter: map[string]string{"email": "id"},
+		},
+	})
+	require.Equal(t, SchemaVersionID([]*protos.FieldDescription{id, email, name}), events[0].SchemaVersion)
+}
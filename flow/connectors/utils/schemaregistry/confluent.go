@@ -0,0 +1,108 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+type confluentRegistry struct {
+	http         *http.Client
+	url          string
+	username     string
+	password     string
+	autoRegister bool
+}
+
+func newConfluentRegistry(config *protos.SchemaRegistryConfig) *confluentRegistry {
+	return &confluentRegistry{
+		http:         &http.Client{Timeout: 30 * time.Second},
+		url:          strings.TrimSuffix(config.Url, "/"),
+		username:     config.Username,
+		password:     config.Password,
+		autoRegister: config.AutoRegister,
+	}
+}
+
+type confluentSchemaRequest struct {
+	Schema string `json:"schema"`
+}
+
+type confluentSchemaResponse struct {
+	ID int32 `json:"id"`
+}
+
+type confluentErrorResponse struct {
+	Message   string `json:"message"`
+	ErrorCode int    `json:"error_code"`
+}
+
+// Header registers schema as a new version of subject, or only looks it up without auto registration.
+// Values are prefixed by a zero magic byte followed by the big-endian schema id
+func (r *confluentRegistry) Header(ctx context.Context, subject string, schema string) ([]byte, error) {
+	path := "/subjects/" + url.PathEscape(subject)
+	if r.autoRegister {
+		path += "/versions"
+	}
+	body, err := json.Marshal(confluentSchemaRequest{Schema: schema})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize schema registry request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("schema registry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema registry response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp confluentErrorResponse
+		_ = json.Unmarshal(respBody, &errResp)
+		switch {
+		case resp.StatusCode == http.StatusConflict:
+			return nil, exceptions.NewSchemaRegistryError(
+				fmt.Errorf("schema of %s is incompatible with its registered versions: %s", subject, errResp.Message), subject)
+		case resp.StatusCode == http.StatusNotFound && !r.autoRegister:
+			return nil, exceptions.NewSchemaRegistryError(
+				fmt.Errorf("schema of %s is not registered and auto registration is disabled: %s", subject, errResp.Message), subject)
+		case resp.StatusCode == http.StatusUnprocessableEntity:
+			return nil, exceptions.NewSchemaRegistryError(
+				fmt.Errorf("schema of %s was rejected as invalid: %s", subject, errResp.Message), subject)
+		default:
+			return nil, fmt.Errorf("unexpected response from schema registry. status: %d. body: %s", resp.StatusCode, respBody)
+		}
+	}
+
+	var schemaResp confluentSchemaResponse
+	if err := json.Unmarshal(respBody, &schemaResp); err != nil {
+		return nil, fmt.Errorf("failed to parse schema registry response: %w", err)
+	}
+	if schemaResp.ID == 0 {
+		return nil, errors.New("schema registry response is missing schema id")
+	}
+	return binary.BigEndian.AppendUint32([]byte{0}, uint32(schemaResp.ID)), nil
+}
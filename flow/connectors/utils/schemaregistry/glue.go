@@ -0,0 +1,205 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+const (
+	glueSchemaAvailable = "AVAILABLE"
+	glueSchemaFailure   = "FAILURE"
+	glueSchemaPolls     = 20
+)
+
+var errGlueEntityNotFound = errors.New("glue entity not found")
+
+// glueRegistry talks to the JSON API of AWS Glue Schema Registry directly,
+// subjects map to schema names within the configured registry
+type glueRegistry struct {
+	http         *http.Client
+	provider     utils.AWSCredentialsProvider
+	signer       *v4.Signer
+	endpoint     string
+	region       string
+	registryName string
+	autoRegister bool
+}
+
+func newGlueRegistry(ctx context.Context, config *protos.SchemaRegistryConfig) (*glueRegistry, error) {
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "glue", utils.PeerAWSCredentials{
+		Credentials: aws.Credentials{
+			AccessKeyID:     config.GetAccessKeyId(),
+			SecretAccessKey: config.GetSecretAccessKey(),
+		},
+		RoleArn: config.RoleArn,
+		Region:  config.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials for Glue schema registry: %w", err)
+	}
+	region := config.Region
+	if region == "" {
+		region = provider.GetRegion()
+	}
+	if region == "" {
+		return nil, errors.New("region is required for Glue schema registry")
+	}
+	endpoint := config.Url
+	if endpoint == "" {
+		endpoint = "https://glue." + region + ".amazonaws.com/"
+	}
+	return &glueRegistry{
+		http:         &http.Client{Timeout: 30 * time.Second},
+		provider:     provider,
+		signer:       v4.NewSigner(),
+		endpoint:     endpoint,
+		region:       region,
+		registryName: config.RegistryName,
+		autoRegister: config.AutoRegister,
+	}, nil
+}
+
+type glueSchemaID struct {
+	RegistryName string `json:"RegistryName"`
+	SchemaName   string `json:"SchemaName"`
+}
+
+type glueSchemaVersion struct {
+	SchemaVersionID string `json:"SchemaVersionId"`
+	Status          string `json:"Status"`
+}
+
+// Header registers schema as a new version of the schema named subject, creating the schema if missing,
+// or only looks up the version without auto registration.
+// Values are prefixed by the header version byte, a byte for no compression and the 16 byte version id
+func (r *glueRegistry) Header(ctx context.Context, subject string, schema string) ([]byte, error) {
+	schemaID := glueSchemaID{RegistryName: r.registryName, SchemaName: subject}
+	var version glueSchemaVersion
+	err := r.call(ctx, "GetSchemaByDefinition", map[string]any{
+		"SchemaId":         schemaID,
+		"SchemaDefinition": schema,
+	}, &version)
+	if errors.Is(err, errGlueEntityNotFound) {
+		if !r.autoRegister {
+			return nil, exceptions.NewSchemaRegistryError(
+				fmt.Errorf("schema of %s is not registered and auto registration is disabled", subject), subject)
+		}
+		version, err = r.register(ctx, schemaID, schema)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for range glueSchemaPolls {
+		switch version.Status {
+		case glueSchemaAvailable:
+			versionID, err := uuid.Parse(version.SchemaVersionID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Glue schema version id %s: %w", version.SchemaVersionID, err)
+			}
+			return append([]byte{3, 0}, versionID[:]...), nil
+		case glueSchemaFailure:
+			return nil, exceptions.NewSchemaRegistryError(
+				fmt.Errorf("schema of %s is incompatible with its registered versions", subject), subject)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+		if err := r.call(ctx, "GetSchemaVersion", map[string]any{"SchemaVersionId": version.SchemaVersionID}, &version); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("Glue schema version %s of %s is still %s", version.SchemaVersionID, subject, version.Status)
+}
+
+func (r *glueRegistry) register(ctx context.Context, schemaID glueSchemaID, schema string) (glueSchemaVersion, error) {
+	var version glueSchemaVersion
+	err := r.call(ctx, "RegisterSchemaVersion", map[string]any{
+		"SchemaId":         schemaID,
+		"SchemaDefinition": schema,
+	}, &version)
+	if !errors.Is(err, errGlueEntityNotFound) {
+		return version, err
+	}
+
+	var created struct {
+		SchemaVersionID     string `json:"SchemaVersionId"`
+		SchemaVersionStatus string `json:"SchemaVersionStatus"`
+	}
+	if err := r.call(ctx, "CreateSchema", map[string]any{
+		"RegistryId":       map[string]string{"RegistryName": schemaID.RegistryName},
+		"SchemaName":       schemaID.SchemaName,
+		"DataFormat":       "AVRO",
+		"Compatibility":    "BACKWARD",
+		"SchemaDefinition": schema,
+	}, &created); err != nil {
+		return version, err
+	}
+	return glueSchemaVersion{SchemaVersionID: created.SchemaVersionID, Status: created.SchemaVersionStatus}, nil
+}
+
+func (r *glueRegistry) call(ctx context.Context, action string, input any, output any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to serialize Glue %s request: %w", action, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSGlue."+action)
+
+	credentials, err := r.provider.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials for Glue: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := r.signer.SignHTTP(ctx, credentials.AWS, req, hex.EncodeToString(payloadHash[:]), "glue", r.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign Glue %s request: %w", action, err)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("Glue %s request failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Glue %s response: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		if strings.HasSuffix(errResp.Type, "EntityNotFoundException") {
+			return fmt.Errorf("%w: %s", errGlueEntityNotFound, errResp.Message)
+		}
+		return fmt.Errorf("Glue %s failed. status: %d. body: %s", action, resp.StatusCode, respBody)
+	}
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return fmt.Errorf("failed to parse Glue %s response: %w", action, err)
+	}
+	return nil
+}
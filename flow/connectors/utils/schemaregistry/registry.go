@@ -0,0 +1,71 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// Registry resolves avro schemas to the header of the wire format of their registry,
+// which prefixes every value encoded with the schema
+type Registry interface {
+	// Header looks up schema under subject, registering it first when auto registration is enabled.
+	// Rejected schemas are returned as exceptions.SchemaRegistryError
+	Header(ctx context.Context, subject string, schema string) ([]byte, error)
+}
+
+func New(ctx context.Context, config *protos.SchemaRegistryConfig) (Registry, error) {
+	var registry Registry
+	switch config.Type {
+	case protos.SchemaRegistryType_SCHEMA_REGISTRY_CONFLUENT:
+		if config.Url == "" {
+			return nil, fmt.Errorf("url is required for Confluent schema registry")
+		}
+		registry = newConfluentRegistry(config)
+	case protos.SchemaRegistryType_SCHEMA_REGISTRY_GLUE:
+		if config.RegistryName == "" {
+			return nil, fmt.Errorf("registry name is required for Glue schema registry")
+		}
+		glue, err := newGlueRegistry(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		registry = glue
+	default:
+		return nil, fmt.Errorf("unsupported schema registry %s", config.Type)
+	}
+	return &cachedRegistry{registry: registry, headers: make(map[cacheKey][]byte)}, nil
+}
+
+type cacheKey struct {
+	subject string
+	schema  string
+}
+
+// cachedRegistry only asks the registry about schemas it hasn't seen, as every batch encodes with the same few
+type cachedRegistry struct {
+	registry Registry
+	headers  map[cacheKey][]byte
+	mu       sync.Mutex
+}
+
+func (r *cachedRegistry) Header(ctx context.Context, subject string, schema string) ([]byte, error) {
+	key := cacheKey{subject: subject, schema: schema}
+	r.mu.Lock()
+	header, ok := r.headers[key]
+	r.mu.Unlock()
+	if ok {
+		return header, nil
+	}
+
+	header, err := r.registry.Header(ctx, subject, schema)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.headers[key] = header
+	r.mu.Unlock()
+	return header, nil
+}
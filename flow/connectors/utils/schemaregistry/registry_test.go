@@ -0,0 +1,59 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+func TestConfluentHeader(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests += 1
+		user, password, _ := r.BasicAuth()
+		require.Equal(t, "user", user)
+		require.Equal(t, "secret", password)
+
+		var req confluentSchemaRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch r.URL.Path {
+		case "/subjects/orders-value/versions":
+			if req.Schema == `"string"` {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"error_code":409,"message":"incompatible"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":258}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry, err := New(t.Context(), &protos.SchemaRegistryConfig{
+		Type:         protos.SchemaRegistryType_SCHEMA_REGISTRY_CONFLUENT,
+		Url:          server.URL + "/",
+		Username:     "user",
+		Password:     "secret",
+		AutoRegister: true,
+	})
+	require.NoError(t, err)
+
+	for range 2 {
+		header, err := registry.Header(t.Context(), "orders-value", `"long"`)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0, 0, 0, 1, 2}, header)
+	}
+	require.Equal(t, 1, requests)
+
+	_, err = registry.Header(t.Context(), "orders-value", `"string"`)
+	var registryErr *exceptions.SchemaRegistryError
+	require.ErrorAs(t, err, &registryErr)
+	require.Equal(t, "orders-value", registryErr.Subject)
+}
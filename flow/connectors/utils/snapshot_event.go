@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// SnapshotEventHeader is attached to the marker sent to queues once the initial snapshot of a table is fully published,
+// rows of the snapshot itself are sent as regular insert records
+const SnapshotEventHeader = "peerdb-snapshot-event"
+
+const SnapshotEventCompleted = "completed"
+
+type SnapshotCompletedEvent struct {
+	CompletedAt      time.Time `json:"completed_at"`
+	FlowJobName      string    `json:"flow_name"`
+	Event            string    `json:"event"`
+	SourceTable      string    `json:"source_table"`
+	DestinationTable string    `json:"destination_table"`
+}
+
+// NewSnapshotCompletedEvent returns the completion marker of an initial copy, nil when config is not one
+func NewSnapshotCompletedEvent(config *protos.QRepConfig) *SnapshotCompletedEvent {
+	if !config.InitialCopyOnly {
+		return nil
+	}
+	flowJobName := config.ParentMirrorName
+	if flowJobName == "" {
+		flowJobName = config.FlowJobName
+	}
+	return &SnapshotCompletedEvent{
+		CompletedAt:      time.Now().UTC(),
+		FlowJobName:      flowJobName,
+		Event:            SnapshotEventCompleted,
+		SourceTable:      config.WatermarkTable,
+		DestinationTable: config.DestinationTableIdentifier,
+	}
+}
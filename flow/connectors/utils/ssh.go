@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 
@@ -91,6 +93,25 @@ func NewSSHTunnel(
 	return SSHTunnel{}, nil
 }
 
+// DialContext connects to addr through the tunnel, or directly when the peer has no SSH config
+func (tunnel SSHTunnel) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	if tunnel.Client == nil {
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	conn, err := tunnel.Client.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &noDeadlineConn{Conn: conn}, nil
+}
+
+// ssh channels don't support deadlines, see: https://github.com/jackc/pgx/issues/382#issuecomment-1496586216
+type noDeadlineConn struct{ net.Conn }
+
+func (c *noDeadlineConn) SetDeadline(t time.Time) error      { return nil }
+func (c *noDeadlineConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *noDeadlineConn) SetWriteDeadline(t time.Time) error { return nil }
+
 func (tunnel SSHTunnel) Close() error {
 	if tunnel.Client != nil {
 		return tunnel.Client.Close()
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// StagingRetentionCutoff is the time staged files must be last modified before to be dropped,
+// zero when PEERDB_STAGING_RETENTION_HOURS is unset and stages are dropped entirely
+func StagingRetentionCutoff(ctx context.Context, env map[string]string) (time.Time, error) {
+	retentionHours, err := internal.PeerDBStagingRetentionHours(ctx, env)
+	if err != nil || retentionHours == 0 {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-time.Duration(retentionHours) * time.Hour), nil
+}
+
+// StagedBefore is whether a staged file last modified at lastModified falls under the cutoff
+func StagedBefore(lastModified *time.Time, cutoff time.Time) bool {
+	return cutoff.IsZero() || lastModified == nil || lastModified.Before(cutoff)
+}
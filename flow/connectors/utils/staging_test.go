@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagedBefore(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	require.True(t, StagedBefore(&now, time.Time{}))
+	require.True(t, StagedBefore(nil, now.Add(-time.Hour)))
+	require.True(t, StagedBefore(&old, now.Add(-time.Hour)))
+	require.False(t, StagedBefore(&now, now.Add(-time.Hour)))
+}
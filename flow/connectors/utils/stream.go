@@ -1,23 +1,40 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
+// RawTableCheckpointIDColumn is the source position of a change, 0 for sources that have none per change
+const RawTableCheckpointIDColumn = "_peerdb_checkpoint_id"
+
 func RecordsToRawTableStream[Items model.Items](
+	ctx context.Context, env map[string]string, flowJobName string,
 	req *model.RecordsToStreamRequest[Items], numericTruncator model.StreamNumericTruncator,
 ) (*model.QRecordStream, error) {
+	jsonOpts, err := rawTableJSONOptions(ctx, env, flowJobName, req.BatchID, req.TargetDWH)
+	if err != nil {
+		return nil, err
+	}
+	numericOverflow, err := qvalue.NumericOverflowPolicyFromEnv(ctx, env)
+	if err != nil {
+		return nil, err
+	}
 	recordStream := model.NewQRecordStream(1 << 17)
-	recordStream.SetSchema(types.QRecordSchema{
+	schema := types.QRecordSchema{
 		Fields: []types.QField{
 			{
 				Name:     "_peerdb_uid",
@@ -60,41 +77,127 @@ func RecordsToRawTableStream[Items model.Items](
 				Nullable: true,
 			},
 		},
-	})
+	}
+	// ClickHouse keeps the source position of changes to tell changes pulled twice apart from repeated ones
+	withCheckpointID := req.TargetDWH == protos.DBType_CLICKHOUSE
+	if withCheckpointID {
+		schema.Fields = append(schema.Fields, types.QField{
+			Name:     RawTableCheckpointIDColumn,
+			Type:     types.QValueKindInt64,
+			Nullable: false,
+		})
+	}
+	recordStream.SetSchema(schema)
 
 	go func() {
 		for record := range req.GetRecords() {
 			record.PopulateCountMap(req.TableMapping)
-			qRecord, err := recordToQRecordOrError(
-				req.BatchID, record, req.TargetDWH, req.UnboundedNumericAsString, numericTruncator,
+			oversized := jsonOpts.Oversized.Load()
+			qRecord, numericOverflowed, err := recordToQRecordOrError(
+				ctx, req.BatchID, record, req.TargetDWH, req.UnboundedNumericAsString, numericOverflow, numericTruncator, jsonOpts,
 			)
 			if err != nil {
 				recordStream.Close(err)
 				return
-			} else if qRecord != nil {
+			}
+			if numericOverflowed {
+				if counts, ok := req.TableMapping[record.GetDestinationTableName()]; ok {
+					counts.NumericOverflowCount.Add(1)
+				}
+			}
+			if jsonOpts.Oversized.Load() > oversized {
+				if counts, ok := req.TableMapping[record.GetDestinationTableName()]; ok {
+					counts.OversizedCount.Add(1)
+				}
+			}
+			if qRecord != nil {
+				if withCheckpointID {
+					qRecord = append(qRecord, types.QValueInt64{Val: record.GetCheckpointID()})
+				}
 				recordStream.Records <- qRecord
 			}
 		}
 
+		if oversized := jsonOpts.Oversized.Load(); oversized > 0 {
+			internal.LoggerFromCtx(ctx).Warn("oversized values were replaced in raw table records",
+				slog.Int64("count", oversized), slog.String("policy", string(jsonOpts.OversizedPolicy)),
+				slog.Bool("spilled", jsonOpts.Spill != nil), slog.Int64("batchID", req.BatchID))
+		}
 		close(recordStream.Records)
 	}()
 	return recordStream, nil
 }
 
+func rawTableJSONOptions(
+	ctx context.Context, env map[string]string, flowJobName string, batchID int64, targetDWH protos.DBType,
+) (model.ToJSONOptions, error) {
+	opts := model.NewToJSONOptions(nil, true)
+	maxValueSize, err := internal.PeerDBOversizedValueBytes(ctx, env)
+	if err != nil {
+		return opts, err
+	}
+	switch {
+	case maxValueSize < 0:
+		opts.MaxValueSize = -1
+	case maxValueSize == 0:
+		opts.MaxValueSize = destinationMaxValueSize(targetDWH)
+	default:
+		opts.MaxValueSize = maxValueSize
+	}
+
+	policy, err := internal.PeerDBOversizedValuePolicy(ctx, env)
+	if err != nil {
+		return opts, err
+	}
+	if opts.OversizedPolicy, err = model.ParseOversizedValuePolicy(policy); err != nil {
+		return opts, err
+	}
+	if opts.OversizedPolicy == model.OversizedValueDefault || opts.OversizedPolicy == model.OversizedValueSpill {
+		if opts.Spill, err = NewValueSpiller(ctx, env, flowJobName, batchID); err != nil {
+			return opts, err
+		}
+		if opts.Spill == nil && opts.OversizedPolicy == model.OversizedValueSpill {
+			return opts, errors.New("oversized value policy spill needs PEERDB_OVERSIZED_VALUE_SPILL_PATH")
+		}
+	}
+	opts.Oversized = &atomic.Int64{}
+	return opts, nil
+}
+
+// destinationMaxValueSize is the largest value staged for targetDWH, -1 when unlimited
+func destinationMaxValueSize(targetDWH protos.DBType) int {
+	switch targetDWH {
+	case protos.DBType_CLICKHOUSE:
+		// ClickHouse strings are not limited and raw rows are inserted as avro or over the native protocol
+		return -1
+	case protos.DBType_BIGQUERY:
+		// rows appended through the Storage Write API must fit a 10MB request along with the other raw columns
+		return 9 * 1024 * 1024
+	default:
+		return model.DefaultMaxJSONValueSize
+	}
+}
+
 func recordToQRecordOrError[Items model.Items](
-	batchID int64, record model.Record[Items], targetDWH protos.DBType, unboundedNumericAsString bool,
-	numericTruncator model.StreamNumericTruncator,
-) ([]types.QValue, error) {
-	var entries [8]types.QValue
+	ctx context.Context, batchID int64, record model.Record[Items], targetDWH protos.DBType, unboundedNumericAsString bool,
+	numericOverflow qvalue.NumericOverflowPolicy, numericTruncator model.StreamNumericTruncator, jsonOpts model.ToJSONOptions,
+) ([]types.QValue, bool, error) {
+	// room for the checkpoint id appended for ClickHouse
+	entries := make([]types.QValue, 8, 9)
+	var numericOverflowed bool
 	switch typedRecord := record.(type) {
 	case *model.InsertRecord[Items]:
 		tableNumericTruncator := numericTruncator.Get(typedRecord.DestinationTableName)
-		preprocessedItems := truncateNumerics(
-			typedRecord.Items, targetDWH, unboundedNumericAsString, tableNumericTruncator,
+		preprocessedItems, overflowed, err := truncateNumerics(
+			typedRecord.Items, targetDWH, unboundedNumericAsString, numericOverflow, tableNumericTruncator,
 		)
-		itemsJSON, err := model.ItemsToJSON(preprocessedItems)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize insert record items to JSON: %w", err)
+			return nil, false, err
+		}
+		numericOverflowed = overflowed
+		itemsJSON, err := preprocessedItems.ToJSONWithOptions(ctx, jsonOpts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to serialize insert record items to JSON: %w", err)
 		}
 
 		entries[3] = types.QValueString{Val: itemsJSON}
@@ -103,16 +206,20 @@ func recordToQRecordOrError[Items model.Items](
 		entries[7] = types.QValueString{Val: ""}
 	case *model.UpdateRecord[Items]:
 		tableNumericTruncator := numericTruncator.Get(typedRecord.DestinationTableName)
-		preprocessedItems := truncateNumerics(
-			typedRecord.NewItems, targetDWH, unboundedNumericAsString, tableNumericTruncator,
+		preprocessedItems, overflowed, err := truncateNumerics(
+			typedRecord.NewItems, targetDWH, unboundedNumericAsString, numericOverflow, tableNumericTruncator,
 		)
-		newItemsJSON, err := model.ItemsToJSON(preprocessedItems)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize update record new items to JSON: %w", err)
+			return nil, false, err
+		}
+		numericOverflowed = overflowed
+		newItemsJSON, err := preprocessedItems.ToJSONWithOptions(ctx, jsonOpts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to serialize update record new items to JSON: %w", err)
 		}
-		oldItemsJSON, err := model.ItemsToJSON(typedRecord.OldItems)
+		oldItemsJSON, err := typedRecord.OldItems.ToJSONWithOptions(ctx, jsonOpts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize update record old items to JSON: %w", err)
+			return nil, false, fmt.Errorf("failed to serialize update record old items to JSON: %w", err)
 		}
 
 		entries[3] = types.QValueString{Val: newItemsJSON}
@@ -121,9 +228,9 @@ func recordToQRecordOrError[Items model.Items](
 		entries[7] = types.QValueString{Val: KeysToString(typedRecord.UnchangedToastColumns)}
 
 	case *model.DeleteRecord[Items]:
-		itemsJSON, err := model.ItemsToJSON(typedRecord.Items)
+		itemsJSON, err := typedRecord.Items.ToJSONWithOptions(ctx, jsonOpts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize delete record items to JSON: %w", err)
+			return nil, false, fmt.Errorf("failed to serialize delete record items to JSON: %w", err)
 		}
 
 		entries[3] = types.QValueString{Val: itemsJSON}
@@ -132,10 +239,10 @@ func recordToQRecordOrError[Items model.Items](
 		entries[7] = types.QValueString{Val: KeysToString(typedRecord.UnchangedToastColumns)}
 
 	case *model.MessageRecord[Items]:
-		return nil, nil
+		return nil, false, nil
 
 	default:
-		return nil, fmt.Errorf("unknown record type: %T", typedRecord)
+		return nil, false, fmt.Errorf("unknown record type: %T", typedRecord)
 	}
 
 	entries[0] = types.QValueUUID{Val: uuid.New()}
@@ -143,7 +250,7 @@ func recordToQRecordOrError[Items model.Items](
 	entries[2] = types.QValueString{Val: record.GetDestinationTableName()}
 	entries[6] = types.QValueInt64{Val: batchID}
 
-	return entries[:], nil
+	return entries, numericOverflowed, nil
 }
 
 func InitialiseTableRowsMap(tableMaps []*protos.TableMapping) map[string]*model.RecordTypeCounts {
@@ -157,11 +264,11 @@ func InitialiseTableRowsMap(tableMaps []*protos.TableMapping) map[string]*model.
 
 func truncateNumerics(
 	items model.Items, targetDWH protos.DBType, unboundedNumericAsString bool,
-	numericTruncator *model.CdcTableNumericTruncator,
-) model.Items {
+	numericOverflow qvalue.NumericOverflowPolicy, numericTruncator *model.CdcTableNumericTruncator,
+) (model.Items, bool, error) {
 	recordItems, ok := items.(model.RecordItems)
 	if !ok {
-		return items
+		return items, false, nil
 	}
 	hasNumerics := false
 	for col, val := range recordItems.ColToVal {
@@ -173,9 +280,10 @@ func truncateNumerics(
 		}
 	}
 	if !hasNumerics {
-		return items
+		return items, false, nil
 	}
 
+	var overflowed bool
 	newItems := model.NewRecordItems(recordItems.Len())
 	for col, val := range recordItems.ColToVal {
 		newVal := val
@@ -190,12 +298,13 @@ func truncateNumerics(
 				if destType.IsString {
 					newVal = val
 				} else {
-					truncated, ok := qvalue.TruncateNumeric(
-						numeric.Val, destType.Precision, destType.Scale, targetDWH, columnTruncator.Stat,
+					truncated, fit, err := qvalue.TruncateNumeric(
+						numeric.Val, destType.Precision, destType.Scale, targetDWH, numericOverflow, columnTruncator.Stat,
 					)
-					if !ok {
-						truncated = decimal.Zero
+					if err != nil {
+						return nil, false, err
 					}
+					overflowed = overflowed || fit.Overflowed()
 					newVal = types.QValueNumeric{
 						Val:       truncated,
 						Precision: destType.Precision,
@@ -211,12 +320,13 @@ func truncateNumerics(
 				} else {
 					truncatedArr := make([]decimal.Decimal, 0, len(numeric.Val))
 					for _, num := range numeric.Val {
-						truncated, ok := qvalue.TruncateNumeric(
-							num, destType.Precision, destType.Scale, targetDWH, columnTruncator.Stat,
+						truncated, fit, err := qvalue.TruncateNumeric(
+							num, destType.Precision, destType.Scale, targetDWH, numericOverflow, columnTruncator.Stat,
 						)
-						if !ok {
-							truncated = decimal.Zero
+						if err != nil {
+							return nil, false, err
 						}
+						overflowed = overflowed || fit.Overflowed()
 						truncatedArr = append(truncatedArr, truncated)
 					}
 					newVal = types.QValueArrayNumeric{
@@ -229,5 +339,45 @@ func truncateNumerics(
 		}
 		newItems.ColToVal[col] = newVal
 	}
-	return newItems
+	return newItems, overflowed, nil
+}
+
+// transformCdcStream passes the records of stream through transform, and its schema deltas through transformDeltas when set
+func transformCdcStream(
+	ctx context.Context,
+	stream *model.CDCStream[model.RecordItems],
+	onErr context.CancelCauseFunc,
+	transform func(model.Record[model.RecordItems]),
+	transformDeltas func([]*protos.TableSchemaDelta) []*protos.TableSchemaDelta,
+) *model.CDCStream[model.RecordItems] {
+	outstream := model.NewCDCStream[model.RecordItems](0)
+
+	go func() {
+		if stream.WaitAndCheckEmpty() {
+			outstream.SignalAsEmpty()
+			<-stream.GetRecords() // needed because empty signal comes before Close
+		} else {
+			outstream.SignalAsNotEmpty()
+			for record := range stream.GetRecords() {
+				transform(record)
+				if err := outstream.AddRecord(ctx, record); err != nil {
+					onErr(err)
+					<-ctx.Done()
+					for range stream.GetRecords() {
+						// still read records to make sure input closes first
+					}
+					break
+				}
+			}
+		}
+		outstream.SchemaDeltas = stream.SchemaDeltas
+		if transformDeltas != nil {
+			outstream.SchemaDeltas = transformDeltas(outstream.SchemaDeltas)
+		}
+		lastCP := stream.GetLastCheckpoint()
+		outstream.UpdateLatestCheckpointID(lastCP.ID)
+		outstream.UpdateLatestCheckpointText(lastCP.Text)
+		outstream.Close()
+	}()
+	return outstream
 }
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+type SubsetTable struct {
+	Name  string
+	Query string
+}
+
+type subsetTableState struct {
+	name      string
+	condition string
+	// relationships the rows were selected through, nil for the root filter
+	sources []*protos.SubsetRelationship
+	// tables reached from a root through parents are copied with all their rows referencing the subset,
+	// others only with the rows referenced from the subset
+	downward bool
+}
+
+// PlanSubset builds queries selecting a subset of tables starting at the filtered roots.
+// Children referencing selected rows are followed from the roots, then every parent referenced by a selected row is added.
+// Relationships that would make a query depend on itself are skipped, a warning is returned when rows may then
+// reference rows outside of the subset
+func PlanSubset(roots []*protos.SubsetRoot, relationships []*protos.SubsetRelationship) ([]SubsetTable, []string, error) {
+	if len(roots) == 0 {
+		return nil, nil, errors.New("subset requires at least one root table")
+	}
+	for _, rel := range relationships {
+		if len(rel.ChildColumns) == 0 || len(rel.ChildColumns) != len(rel.ParentColumns) {
+			return nil, nil, fmt.Errorf("relationship from %s to %s must have the same non-zero number of columns on both sides",
+				rel.ChildTable, rel.ParentTable)
+		}
+	}
+
+	tables := make([]*subsetTableState, 0, len(roots))
+	positions := make(map[string]int, len(roots))
+	add := func(name string, downward bool) {
+		positions[name] = len(tables)
+		tables = append(tables, &subsetTableState{name: name, downward: downward})
+	}
+	for _, root := range roots {
+		if _, ok := positions[root.Table]; ok {
+			return nil, nil, fmt.Errorf("table %s is listed as root more than once", root.Table)
+		}
+		add(root.Table, true)
+		tables[len(tables)-1].sources = []*protos.SubsetRelationship{nil}
+		if root.Filter == "" {
+			tables[len(tables)-1].condition = "TRUE"
+		} else {
+			tables[len(tables)-1].condition = "(" + root.Filter + ")"
+		}
+	}
+	for i := 0; i < len(tables); i++ {
+		for _, rel := range relationships {
+			if _, ok := positions[rel.ChildTable]; rel.ParentTable == tables[i].name && !ok {
+				add(rel.ChildTable, true)
+			}
+		}
+	}
+	for i := 0; i < len(tables); i++ {
+		for _, rel := range relationships {
+			if _, ok := positions[rel.ParentTable]; rel.ChildTable == tables[i].name && !ok {
+				add(rel.ParentTable, false)
+			}
+		}
+	}
+
+	var skipped []*protos.SubsetRelationship
+	for i, table := range tables {
+		conditions := make([]string, 0, len(relationships)+1)
+		if table.condition != "" {
+			conditions = append(conditions, table.condition)
+		}
+		for _, rel := range relationships {
+			if rel.ChildTable == table.name && table.downward {
+				if parent := positions[rel.ParentTable]; parent < i && tables[parent].downward {
+					condition, err := subsetCondition(rel.ChildColumns, rel.ParentColumns, tables[parent])
+					if err != nil {
+						return nil, nil, err
+					}
+					conditions = append(conditions, condition)
+					table.sources = append(table.sources, rel)
+				}
+			}
+			if rel.ParentTable == table.name {
+				if child := positions[rel.ChildTable]; child < i {
+					condition, err := subsetCondition(rel.ParentColumns, rel.ChildColumns, tables[child])
+					if err != nil {
+						return nil, nil, err
+					}
+					conditions = append(conditions, condition)
+					table.sources = append(table.sources, rel)
+				} else {
+					skipped = append(skipped, rel)
+				}
+			}
+		}
+		table.condition = strings.Join(conditions, " OR ")
+	}
+
+	var warnings []string
+	for _, rel := range skipped {
+		// children only selected through this relationship cannot reference parent rows outside the subset
+		if sources := tables[positions[rel.ChildTable]].sources; len(sources) != 1 || sources[0] != rel {
+			warnings = append(warnings, fmt.Sprintf("rows of %s may reference rows of %s outside of the subset",
+				rel.ChildTable, rel.ParentTable))
+		}
+	}
+
+	plan := make([]SubsetTable, 0, len(tables))
+	for _, table := range tables {
+		parsedTable, err := ParseSchemaTable(table.name)
+		if err != nil {
+			return nil, nil, err
+		}
+		query := "SELECT * FROM " + parsedTable.String()
+		if table.condition != "TRUE" {
+			query += " WHERE " + table.condition
+		}
+		plan = append(plan, SubsetTable{Name: table.name, Query: query})
+	}
+	return plan, warnings, nil
+}
+
+// subsetCondition selects rows whose columns match the referenced columns of the rows selected from other
+func subsetCondition(columns []string, referencedColumns []string, other *subsetTableState) (string, error) {
+	parsedTable, err := ParseSchemaTable(other.name)
+	if err != nil {
+		return "", err
+	}
+	quote := func(columns []string) string {
+		quoted := make([]string, 0, len(columns))
+		for _, column := range columns {
+			quoted = append(quoted, QuoteIdentifier(column))
+		}
+		return strings.Join(quoted, ",")
+	}
+	return fmt.Sprintf("(%s) IN (SELECT %s FROM %s WHERE %s)",
+		quote(columns), quote(referencedColumns), parsedTable.String(), other.condition), nil
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestPlanSubset(t *testing.T) {
+	t.Parallel()
+	relationships := []*protos.SubsetRelationship{
+		{ChildTable: "public.orders", ChildColumns: []string{"customer_id"}, ParentTable: "public.customers", ParentColumns: []string{"id"}},
+		{ChildTable: "public.items", ChildColumns: []string{"order_id"}, ParentTable: "public.orders", ParentColumns: []string{"id"}},
+		{ChildTable: "public.items", ChildColumns: []string{"product_id"}, ParentTable: "public.products", ParentColumns: []string{"id"}},
+		{ChildTable: "public.customers", ChildColumns: []string{"referrer_id"}, ParentTable: "public.customers", ParentColumns: []string{"id"}},
+	}
+	plan, warnings, err := PlanSubset([]*protos.SubsetRoot{{Table: "public.customers", Filter: "region = 'eu'"}}, relationships)
+	require.NoError(t, err)
+
+	customers := `SELECT "id" FROM "public"."customers" WHERE (region = 'eu')`
+	orders := `SELECT "id" FROM "public"."orders" WHERE ("customer_id") IN (` + customers + `)`
+	items := `SELECT "product_id" FROM "public"."items" WHERE ("order_id") IN (` + orders + `)`
+	require.Equal(t, []SubsetTable{
+		{Name: "public.customers", Query: `SELECT * FROM "public"."customers" WHERE (region = 'eu')`},
+		{Name: "public.orders", Query: `SELECT * FROM "public"."orders" WHERE ("customer_id") IN (` + customers + `)`},
+		{Name: "public.items", Query: `SELECT * FROM "public"."items" WHERE ("order_id") IN (` + orders + `)`},
+		{Name: "public.products", Query: `SELECT * FROM "public"."products" WHERE ("id") IN (` + items + `)`},
+	}, plan)
+	require.Equal(t, []string{"rows of public.customers may reference rows of public.customers outside of the subset"}, warnings)
+
+	_, _, err = PlanSubset([]*protos.SubsetRoot{{Table: "public.customers"}}, []*protos.SubsetRelationship{
+		{ChildTable: "public.orders", ChildColumns: []string{"customer_id"}, ParentTable: "public.customers"},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func normalizeTimestampItems(zones *types.TimestampZones, items model.RecordItems) {
+	for col, val := range items.ColToVal {
+		items.ColToVal[col] = zones.Value(val)
+	}
+}
+
+// AttachTimestampZonesToCdcStream normalizes the timestamps of records and the timestamp columns added by schema deltas
+func AttachTimestampZonesToCdcStream(
+	ctx context.Context,
+	zones *types.TimestampZones,
+	stream *model.CDCStream[model.RecordItems],
+	onErr context.CancelCauseFunc,
+) *model.CDCStream[model.RecordItems] {
+	return transformCdcStream(ctx, stream, onErr, func(record model.Record[model.RecordItems]) {
+		switch r := record.(type) {
+		case *model.InsertRecord[model.RecordItems]:
+			normalizeTimestampItems(zones, r.Items)
+		case *model.UpdateRecord[model.RecordItems]:
+			normalizeTimestampItems(zones, r.NewItems)
+			normalizeTimestampItems(zones, r.OldItems)
+		case *model.DeleteRecord[model.RecordItems]:
+			normalizeTimestampItems(zones, r.Items)
+		}
+	}, func(deltas []*protos.TableSchemaDelta) []*protos.TableSchemaDelta {
+		return TimestampZonesSchemaDeltas(zones, deltas)
+	})
+}
+
+// TimestampZonesSchemaDeltas converts the naive timestamp columns added by deltas to timestamptz
+func TimestampZonesSchemaDeltas(zones *types.TimestampZones, deltas []*protos.TableSchemaDelta) []*protos.TableSchemaDelta {
+	if zones == nil || zones.NaiveSource == nil {
+		return deltas
+	}
+	converted := make([]*protos.TableSchemaDelta, len(deltas))
+	for idx, delta := range deltas {
+		if delta.System == protos.TypeSystem_Q {
+			delta = proto.CloneOf(delta)
+			for _, column := range delta.AddedColumns {
+				column.Type = string(zones.Kind(types.QValueKind(column.Type)))
+			}
+		}
+		converted[idx] = delta
+	}
+	return converted
+}
+
+// AttachTimestampZonesToQRepStream normalizes the timestamp columns of the schema and rows of stream
+func AttachTimestampZonesToQRepStream(zones *types.TimestampZones, stream *model.QRecordStream) *model.QRecordStream {
+	output := model.NewQRecordStream(0)
+	go func() {
+		schema, err := stream.Schema()
+		if err != nil {
+			output.Close(err)
+			return
+		}
+		output.SetSchema(types.NewQRecordSchema(zones.Fields(schema.Fields)))
+		for record := range stream.Records {
+			for idx, val := range record {
+				record[idx] = zones.Value(val)
+			}
+			output.Records <- record
+		}
+		output.Close(stream.Err())
+	}()
+	return output
+}
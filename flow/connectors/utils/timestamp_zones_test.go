@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestAttachTimestampZonesToQRepStream(t *testing.T) {
+	t.Parallel()
+	zones, err := types.NewTimestampZones("Asia/Kolkata", "America/New_York")
+	require.NoError(t, err)
+
+	instant := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stream := model.NewQRecordStream(0)
+	output := AttachTimestampZonesToQRepStream(zones, stream)
+	go func() {
+		stream.SetSchema(types.QRecordSchema{Fields: []types.QField{
+			{Name: "tz", Type: types.QValueKindTimestampTZ},
+			{Name: "naive", Type: types.QValueKindTimestamp},
+			{Name: "naives", Type: types.QValueKindArrayTimestamp},
+			{Name: "missing", Type: types.QValueKindTimestamp},
+		}})
+		stream.Records <- []types.QValue{
+			types.QValueTimestampTZ{Val: instant},
+			types.QValueTimestamp{Val: instant},
+			types.QValueArrayTimestamp{Val: []time.Time{instant}},
+			types.QValueNull(types.QValueKindTimestamp),
+		}
+		stream.Close(nil)
+	}()
+
+	schema, err := output.Schema()
+	require.NoError(t, err)
+	require.Equal(t, []types.QValueKind{
+		types.QValueKindTimestampTZ, types.QValueKindTimestampTZ, types.QValueKindArrayTimestampTZ, types.QValueKindTimestampTZ,
+	}, []types.QValueKind{schema.Fields[0].Type, schema.Fields[1].Type, schema.Fields[2].Type, schema.Fields[3].Type})
+
+	var records [][]types.QValue
+	for record := range output.Records {
+		records = append(records, record)
+	}
+	require.NoError(t, output.Err())
+	require.Len(t, records, 1)
+
+	tz := records[0][0].(types.QValueTimestampTZ).Val
+	require.True(t, tz.Equal(instant))
+	require.Equal(t, "17:30", tz.Format("15:04"))
+
+	// noon in New York is 17:00 UTC
+	naive := records[0][1].(types.QValueTimestampTZ).Val
+	require.True(t, naive.Equal(time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC)))
+	require.Equal(t, "22:30", naive.Format("15:04"))
+	require.True(t, records[0][2].(types.QValueArrayTimestampTZ).Val[0].Equal(naive))
+	require.Equal(t, types.QValueNull(types.QValueKindTimestampTZ), records[0][3])
+}
+
+func TestTimestampZonesSchemaDeltas(t *testing.T) {
+	t.Parallel()
+	zones, err := types.NewTimestampZones("", "UTC")
+	require.NoError(t, err)
+
+	delta := &protos.TableSchemaDelta{
+		System: protos.TypeSystem_Q,
+		AddedColumns: []*protos.FieldDescription{
+			{Name: "a", Type: string(types.QValueKindTimestamp)},
+			{Name: "b", Type: string(types.QValueKindInt64)},
+		},
+	}
+	converted := TimestampZonesSchemaDeltas(zones, []*protos.TableSchemaDelta{delta})
+	require.Equal(t, string(types.QValueKindTimestampTZ), converted[0].AddedColumns[0].Type)
+	require.Equal(t, string(types.QValueKindInt64), converted[0].AddedColumns[1].Type)
+	// deltas of the stream are not modified in place
+	require.Equal(t, string(types.QValueKindTimestamp), delta.AddedColumns[0].Type)
+
+	_, err = types.NewTimestampZones("Not/AZone", "")
+	require.Error(t, err)
+}
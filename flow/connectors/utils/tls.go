@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// PeerTlsConfig is the TLS config of a connection to host, settings of peerTls take precedence
+// over the minimum version, root CA & TLS host the connector uses otherwise
+func PeerTlsConfig(
+	peerTls *protos.TlsConfig,
+	minVersion uint16,
+	rootCA *string,
+	host string,
+	tlsHost string,
+	skipCertVerification bool,
+) (*tls.Config, error) {
+	if peerTls != nil {
+		switch peerTls.MinVersion {
+		case protos.TlsVersion_TLS_VERSION_1_2:
+			minVersion = tls.VersionTLS12
+		case protos.TlsVersion_TLS_VERSION_1_3:
+			minVersion = tls.VersionTLS13
+		}
+		if peerTls.RootCa != nil {
+			rootCA = peerTls.RootCa
+		}
+		if peerTls.ServerName != "" {
+			tlsHost = peerTls.ServerName
+		}
+	}
+	config, err := shared.CreateTlsConfig(minVersion, rootCA, host, tlsHost, skipCertVerification)
+	if err != nil {
+		return nil, err
+	}
+	if peerTls != nil && (peerTls.Certificate != nil || peerTls.PrivateKey != nil) {
+		if peerTls.Certificate == nil || peerTls.PrivateKey == nil {
+			return nil, errors.New("both certificate and private key must be provided for mutual TLS")
+		}
+		cert, err := tls.X509KeyPair([]byte(*peerTls.Certificate), []byte(*peerTls.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse provided certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestPeerTlsConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := PeerTlsConfig(nil, tls.VersionTLS12, nil, "db.example.com", "", false)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	require.Equal(t, "db.example.com", config.ServerName)
+
+	config, err = PeerTlsConfig(&protos.TlsConfig{
+		MinVersion: protos.TlsVersion_TLS_VERSION_1_3,
+		ServerName: "sni.example.com",
+	}, tls.VersionTLS12, nil, "db.example.com", "tls.example.com", false)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	require.Equal(t, "sni.example.com", config.ServerName)
+
+	_, err = PeerTlsConfig(&protos.TlsConfig{Certificate: new(string)}, tls.VersionTLS12, nil, "db.example.com", "", false)
+	require.ErrorContains(t, err, "both certificate and private key")
+
+	rootCA := "not a certificate"
+	_, err = PeerTlsConfig(&protos.TlsConfig{RootCa: &rootCA}, tls.VersionTLS12, nil, "db.example.com", "", false)
+	require.ErrorContains(t, err, "root CA")
+}
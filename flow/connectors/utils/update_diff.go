@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"slices"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// UpdateRecordDiff returns a copy of an update record only holding the primary key and changed columns.
+// Columns missing from the old tuple, as with replica identity default, cannot be compared and are kept
+func UpdateRecordDiff(
+	rec *model.UpdateRecord[model.RecordItems],
+	primaryKeyColumns []string,
+) *model.UpdateRecord[model.RecordItems] {
+	diff := *rec
+	diff.NewItems = model.NewRecordItems(len(primaryKeyColumns))
+	hasOldItems := rec.OldItems.ColToVal != nil
+	if hasOldItems {
+		diff.OldItems = model.NewRecordItems(len(primaryKeyColumns))
+	}
+	for col, newVal := range rec.NewItems.ColToVal {
+		oldVal, hasOld := rec.OldItems.ColToVal[col]
+		if slices.Contains(primaryKeyColumns, col) || !hasOld || !qvaluesIdentical(oldVal, newVal) {
+			diff.NewItems.AddColumn(col, newVal)
+			if hasOld {
+				diff.OldItems.AddColumn(col, oldVal)
+			}
+		}
+	}
+	// primary key columns of the old tuple stay even when the new one lacks them
+	for _, col := range primaryKeyColumns {
+		if oldVal, ok := rec.OldItems.ColToVal[col]; ok {
+			diff.OldItems.AddColumn(col, oldVal)
+		}
+	}
+	return &diff
+}
+
+func qvaluesIdentical(a types.QValue, b types.QValue) bool {
+	return a.Kind() == b.Kind() && reflect.DeepEqual(a.Value(), b.Value())
+}
+
+// QueueUpdateDiffer returns a function passing records on to queue scripts,
+// which diffs updates when PEERDB_QUEUE_UPDATE_DIFF is enabled
+func QueueUpdateDiffer(
+	ctx context.Context,
+	env map[string]string,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) (func(model.Record[model.RecordItems]) model.Record[model.RecordItems], error) {
+	enabled, err := internal.PeerDBQueueUpdateDiff(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	return func(record model.Record[model.RecordItems]) model.Record[model.RecordItems] {
+		if ur, ok := record.(*model.UpdateRecord[model.RecordItems]); ok && enabled {
+			var primaryKeyColumns []string
+			if schema, ok := tableNameSchemaMapping[ur.DestinationTableName]; ok {
+				primaryKeyColumns = schema.PrimaryKeyColumns
+			}
+			return UpdateRecordDiff(ur, primaryKeyColumns)
+		}
+		return record
+	}, nil
+}
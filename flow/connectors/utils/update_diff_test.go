@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestUpdateRecordDiff(t *testing.T) {
+	t.Parallel()
+	oldItems := model.NewRecordItems(3)
+	oldItems.AddColumn("id", types.QValueInt64{Val: 1})
+	oldItems.AddColumn("name", types.QValueString{Val: "a"})
+	oldItems.AddColumn("score", types.QValueInt32{Val: 5})
+	newItems := model.NewRecordItems(4)
+	newItems.AddColumn("id", types.QValueInt64{Val: 1})
+	newItems.AddColumn("name", types.QValueString{Val: "a"})
+	newItems.AddColumn("score", types.QValueInt32{Val: 6})
+	newItems.AddColumn("note", types.QValueString{Val: "x"})
+	rec := &model.UpdateRecord[model.RecordItems]{
+		OldItems:             oldItems,
+		NewItems:             newItems,
+		DestinationTableName: "t",
+	}
+
+	diff := UpdateRecordDiff(rec, []string{"id"})
+	require.Equal(t, "t", diff.DestinationTableName)
+	require.ElementsMatch(t, []string{"id", "score", "note"}, columnNames(diff.NewItems))
+	require.ElementsMatch(t, []string{"id", "score"}, columnNames(diff.OldItems))
+	require.Len(t, rec.NewItems.ColToVal, 4)
+
+	rec.OldItems = model.RecordItems{}
+	diff = UpdateRecordDiff(rec, []string{"id"})
+	require.Len(t, diff.NewItems.ColToVal, 4)
+	require.Nil(t, diff.OldItems.ColToVal)
+}
+
+func columnNames(items model.RecordItems) []string {
+	names := make([]string, 0, len(items.ColToVal))
+	for col := range items.ColToVal {
+		names = append(names, col)
+	}
+	return names
+}
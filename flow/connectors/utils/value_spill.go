@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// NewValueSpiller returns where oversized values of a sync batch are spilled under PEERDB_OVERSIZED_VALUE_SPILL_PATH,
+// nil when it is unset. The path must be an s3:// url, references to files local to a worker are of no use to readers
+// of the destination, and do not outlive the worker
+func NewValueSpiller(ctx context.Context, env map[string]string, flowJobName string, batchID int64) (model.ValueSpiller, error) {
+	spillPath, err := internal.PeerDBOversizedValueSpillPath(ctx, env)
+	if err != nil || spillPath == "" {
+		return nil, err
+	}
+	if !strings.HasPrefix(spillPath, "s3://") {
+		return nil, fmt.Errorf("PEERDB_OVERSIZED_VALUE_SPILL_PATH must be an s3:// url, got %s", spillPath)
+	}
+
+	s3Path, err := NewS3BucketAndPrefix(spillPath)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := GetAWSCredentialsProvider(ctx, "SPILL", PeerAWSCredentials{})
+	if err != nil {
+		return nil, err
+	}
+	client, err := CreateS3Client(ctx, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client for spilling values: %w", err)
+	}
+	return &s3ValueSpiller{
+		client: client,
+		bucket: s3Path.Bucket,
+		prefix: path.Join(s3Path.Prefix, flowJobName, strconv.FormatInt(batchID, 10)),
+	}, nil
+}
+
+type s3ValueSpiller struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func (s *s3ValueSpiller) Spill(ctx context.Context, column string, value string) (string, error) {
+	key := path.Join(s.prefix, spillFileName(column))
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   strings.NewReader(value),
+	}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key), nil
+}
+
+func spillFileName(column string) string {
+	return fmt.Sprintf("%s-%s", strings.ReplaceAll(column, "/", "_"), uuid.NewString())
+}
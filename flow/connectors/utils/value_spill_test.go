@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewValueSpiller(t *testing.T) {
+	spiller, err := NewValueSpiller(t.Context(), map[string]string{"PEERDB_OVERSIZED_VALUE_SPILL_PATH": ""}, "mirror", 1)
+	require.NoError(t, err)
+	require.Nil(t, spiller)
+
+	// files local to a worker cannot be read from the destination
+	for _, spillPath := range []string{"/tmp/spill", "file:///tmp/spill"} {
+		_, err := NewValueSpiller(t.Context(), map[string]string{"PEERDB_OVERSIZED_VALUE_SPILL_PATH": spillPath}, "mirror", 1)
+		require.ErrorContains(t, err, "must be an s3:// url", spillPath)
+	}
+}
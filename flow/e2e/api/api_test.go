@@ -241,6 +241,48 @@ func (s Suite) TestClickHouseMirrorValidation_Pass() {
 	require.NotNil(s.t, response)
 }
 
+func (s Suite) TestPreflightCDCMirror() {
+	require.NoError(s.t, s.source.Exec(s.t.Context(),
+		fmt.Sprintf("CREATE TABLE %s(id int primary key, val text)", e2e.AttachSchema(s, "preflight"))))
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      "ch_preflight_" + s.suffix,
+		TableNameMapping: map[string]string{e2e.AttachSchema(s, "preflight"): "preflight"},
+		Destination:      s.ch.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+	response, err := s.PreflightCDCMirror(s.t.Context(), &protos.CreateCDCFlowRequest{ConnectionConfigs: flowConnConfig})
+	require.NoError(s.t, err)
+	checks := make(map[string]*protos.PreflightCheck, len(response.Checks))
+	for _, check := range response.Checks {
+		checks[check.Target+"/"+check.Name] = check
+	}
+	for _, name := range []string{
+		"source/source_tables", "source/replication_connectivity", "source/table_schemas",
+		"destination/create_table", "destination/insert", "destination/stage",
+	} {
+		require.Contains(s.t, checks, name)
+		require.Equal(s.t, protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_PASS, checks[name].Status, checks[name].Message)
+	}
+	require.True(s.t, response.Ok)
+
+	// every check is reported even once one fails
+	flowConnConfig.TableMappings[0].SourceTableIdentifier = e2e.AttachSchema(s, "preflight_missing")
+	response, err = s.PreflightCDCMirror(s.t.Context(), &protos.CreateCDCFlowRequest{ConnectionConfigs: flowConnConfig})
+	require.NoError(s.t, err)
+	require.False(s.t, response.Ok)
+	var failed, destinationChecks []string
+	for _, check := range response.Checks {
+		if check.Status == protos.PreflightCheckStatus_PREFLIGHT_CHECK_STATUS_FAIL {
+			failed = append(failed, check.Target+"/"+check.Name)
+		}
+		if check.Target == "destination" {
+			destinationChecks = append(destinationChecks, check.Name)
+		}
+	}
+	require.Contains(s.t, failed, "source/source_tables")
+	require.Contains(s.t, destinationChecks, "create_table")
+}
+
 func (s Suite) TestSchemaEndpoints() {
 	peerInfo, err := s.GetPeerInfo(s.t.Context(), &protos.PeerInfoRequest{
 		PeerName: s.source.GeneratePeer(s.t).Name,
@@ -943,3 +985,71 @@ func (s Suite) TestQRep() {
 	env.Cancel(s.t.Context())
 	e2e.RequireEnvCanceled(s.t, env)
 }
+
+func (s Suite) TestMirrorStateExportImport() {
+	peerType, err := s.GetPeerType(s.t.Context(), &protos.PeerInfoRequest{
+		PeerName: s.source.GeneratePeer(s.t).Name,
+	})
+	require.NoError(s.t, err)
+	tblName := "qrepstate"
+	schemaQualified := e2e.AttachSchema(s, tblName)
+	require.NoError(s.t, s.source.Exec(s.t.Context(),
+		fmt.Sprintf("CREATE TABLE %s(id int primary key, val text)", schemaQualified)))
+	require.NoError(s.t, s.source.Exec(s.t.Context(),
+		fmt.Sprintf("INSERT INTO %s(id, val) values (1,'first'),(2,'second')", schemaQualified)))
+
+	qrepConfig := e2e.CreateQRepWorkflowConfig(
+		s.t,
+		"qrepstateflow"+"_"+peerType.PeerType,
+		schemaQualified,
+		tblName,
+		fmt.Sprintf("SELECT * FROM %s WHERE id BETWEEN {{.start}} AND {{.end}}", schemaQualified),
+		s.ch.Peer().Name,
+		"",
+		true,
+		"",
+		"",
+	)
+	qrepConfig.SourceName = s.source.GeneratePeer(s.t).Name
+	qrepConfig.WatermarkColumn = "id"
+	qrepConfig.NumRowsPerPartition = 1
+	_, err = s.CreateQRepFlow(s.t.Context(), &protos.CreateQRepFlowRequest{
+		QrepConfig:         qrepConfig,
+		CreateCatalogEntry: true,
+	})
+	require.NoError(s.t, err)
+
+	tc := e2e.NewTemporalClient(s.t)
+	env, err := e2e.GetPeerflow(s.t.Context(), s.pg.PostgresConnector.Conn(), tc, qrepConfig.FlowJobName)
+	require.NoError(s.t, err)
+	e2e.EnvWaitForEqualTables(env, s.ch, "qrep initial load", tblName, "id,val")
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+
+	exported, err := s.ExportMirrorState(s.t.Context(), &protos.ExportMirrorStateRequest{
+		FlowNames: []string{qrepConfig.FlowJobName},
+	})
+	require.NoError(s.t, err)
+	require.Len(s.t, exported.Mirrors, 1)
+	require.Len(s.t, exported.Mirrors[0].SyncedPartitionStates, 2)
+	for _, partition := range exported.Mirrors[0].SyncedPartitionStates {
+		require.NotNil(s.t, partition.SyncStartTime)
+		require.NotNil(s.t, partition.SyncFinishTime)
+	}
+
+	_, err = s.ImportMirrorState(s.t.Context(), &protos.ImportMirrorStateRequest{Mirrors: exported.Mirrors})
+	require.Error(s.t, err, "import over an existing mirror needs overwrite")
+	imported, err := s.ImportMirrorState(s.t.Context(), &protos.ImportMirrorStateRequest{
+		Mirrors:   exported.Mirrors,
+		Overwrite: true,
+	})
+	require.NoError(s.t, err)
+	require.Equal(s.t, []string{qrepConfig.FlowJobName}, imported.FlowNames)
+
+	reexported, err := s.ExportMirrorState(s.t.Context(), &protos.ExportMirrorStateRequest{
+		FlowNames: []string{qrepConfig.FlowJobName},
+	})
+	require.NoError(s.t, err)
+	require.True(s.t, proto.Equal(exported.Mirrors[0], reexported.Mirrors[0]),
+		"export after import differs: %v\n%v", exported.Mirrors[0], reexported.Mirrors[0])
+}
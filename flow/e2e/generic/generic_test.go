@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
@@ -17,6 +18,7 @@ import (
 	e2e_snowflake "github.com/PeerDB-io/peerdb/flow/e2e/snowflake"
 	"github.com/PeerDB-io/peerdb/flow/e2eshared"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
@@ -370,6 +372,66 @@ func (s Generic) Test_Simple_Schema_Changes() {
 	e2e.RequireEnvCanceled(t, env)
 }
 
+func (s Generic) Test_Dropped_Column_Keeps_Schema_Mapping() {
+	t := s.T()
+
+	srcTable := "test_dropped_column_mapping"
+	dstTable := "test_dropped_column_mapping_dst"
+	srcTableName := e2e.AttachSchema(s, srcTable)
+	dstTableName := s.DestinationTable(dstTable)
+
+	require.NoError(t, s.Source().Exec(t.Context(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id SERIAL PRIMARY KEY,
+			c1 BIGINT,
+			c2 BIGINT
+		);
+	`, srcTableName)))
+
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:   e2e.AddSuffix(s, srcTable),
+		TableMappings: e2e.TableMappings(s, srcTable, dstTable),
+		Destination:   s.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(t.Context())
+	require.NoError(t, err)
+	mappedColumns := func() []string {
+		var schemaBytes []byte
+		require.NoError(t, pool.QueryRow(t.Context(),
+			"select table_schema from table_schema_mapping where flow_name = $1 and table_name = $2",
+			flowConnConfig.FlowJobName, dstTableName,
+		).Scan(&schemaBytes))
+		var tableSchema protos.TableSchema
+		require.NoError(t, proto.Unmarshal(schemaBytes, &tableSchema))
+		columns := make([]string, 0, len(tableSchema.Columns))
+		for _, column := range tableSchema.Columns {
+			columns = append(columns, column.Name)
+		}
+		return columns
+	}
+
+	tc := e2e.NewTemporalClient(t)
+	env := e2e.ExecutePeerflow(t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(t, env, flowConnConfig)
+	e2e.EnvNoError(t, env, s.Source().Exec(t.Context(), fmt.Sprintf(`INSERT INTO %s(c1,c2) VALUES(1,1)`, srcTableName)))
+	e2e.EnvWaitForEqualTablesWithNames(env, s, "normalize insert", srcTable, dstTable, "id,c1,c2")
+	columns := mappedColumns()
+	require.Contains(t, columns, "c2")
+
+	// a dropped column is only removed from the schema mapping of queues,
+	// other destinations keep the column and normalize writes null for it
+	e2e.EnvNoError(t, env, s.Source().Exec(t.Context(), fmt.Sprintf(`ALTER TABLE %s DROP COLUMN c2`, srcTableName)))
+	e2e.EnvNoError(t, env, s.Source().Exec(t.Context(), fmt.Sprintf(`INSERT INTO %s(c1) VALUES(2)`, srcTableName)))
+	e2e.EnvNoError(t, env, s.Source().Exec(t.Context(), fmt.Sprintf(`UPDATE %s SET c1 = 3 WHERE id = 1`, srcTableName)))
+	e2e.EnvWaitForEqualTablesWithNames(env, s, "normalize after dropped column", srcTable, dstTable, "id,c1")
+	require.Equal(t, columns, mappedColumns())
+
+	env.Cancel(t.Context())
+	e2e.RequireEnvCanceled(t, env)
+}
+
 func (s Generic) Test_Partitioned_Table() {
 	t := s.T()
 
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// ApiKeyPrefix starts every API key so they can be told apart from OAuth tokens
+const ApiKeyPrefix = "pdb_"
+
+var ErrInvalidApiKey = errors.New("invalid or expired API key")
+
+// ApiKeyGrant is what an API key permits, as stored in the catalog
+type ApiKeyGrant struct {
+	Name    string
+	Role    string
+	Peers   []string
+	Mirrors []string
+	ID      int64
+}
+
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, ApiKeyPrefix)
+}
+
+// HashApiKey is how keys are stored, they are random enough for an unsalted hash
+func HashApiKey(key string) []byte {
+	hash := sha256.Sum256([]byte(key))
+	return hash[:]
+}
+
+// GenerateApiKey returns a new key along with the prefix shown to identify it
+func GenerateApiKey() (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("unable to generate API key: %w", err)
+	}
+	key := ApiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, key[:len(ApiKeyPrefix)+8], nil
+}
+
+// apiKeyLastUsedInterval is how often the use of a key is recorded, so each request does not write to the catalog
+const apiKeyLastUsedInterval = time.Minute
+
+// LookupApiKey finds the unexpired key in the catalog and records its use, at most once a minute
+func LookupApiKey(ctx context.Context, pool shared.CatalogPool, key string) (*ApiKeyGrant, error) {
+	var grant ApiKeyGrant
+	var expiresAt, lastUsedAt pgtype.Timestamptz
+	var now time.Time
+	if err := pool.QueryRow(ctx, `SELECT id, name, role, peers, mirrors, expires_at, last_used_at, now() FROM api_keys
+		WHERE key_hash = $1`, HashApiKey(key),
+	).Scan(&grant.ID, &grant.Name, &grant.Role, &grant.Peers, &grant.Mirrors, &expiresAt, &lastUsedAt, &now); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidApiKey
+		}
+		return nil, fmt.Errorf("unable to query API key: %w", err)
+	}
+	if apiKeyExpired(expiresAt, now) {
+		return nil, ErrInvalidApiKey
+	}
+
+	if apiKeyUseUnrecorded(lastUsedAt, now) {
+		// checked again in the update so concurrent requests record the use once
+		if _, err := pool.Exec(ctx, `UPDATE api_keys SET last_used_at = now()
+			WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - $2::interval)`, grant.ID, apiKeyLastUsedInterval,
+		); err != nil {
+			slog.Warn("unable to record API key use", slog.String("name", grant.Name), slog.Any("error", err))
+		}
+	}
+	return &grant, nil
+}
+
+// apiKeyExpired is evaluated against the catalog's clock, as expiry is set by it
+func apiKeyExpired(expiresAt pgtype.Timestamptz, now time.Time) bool {
+	return expiresAt.Valid && !expiresAt.Time.After(now)
+}
+
+func apiKeyUseUnrecorded(lastUsedAt pgtype.Timestamptz, now time.Time) bool {
+	return !lastUsedAt.Valid || lastUsedAt.Time.Before(now.Add(-apiKeyLastUsedInterval))
+}
+
+// NormalizeApiKeyScope trims the peer or mirror names an API key is scoped to, dropping empty and repeated ones
+func NormalizeApiKeyScope(names []string) []string {
+	scope := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(scope, name) {
+			scope = append(scope, name)
+		}
+	}
+	return scope
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateApiKey(t *testing.T) {
+	key, prefix, err := GenerateApiKey()
+	require.NoError(t, err)
+	require.True(t, IsApiKey(key))
+	require.True(t, strings.HasPrefix(key, prefix))
+	require.Len(t, prefix, len(ApiKeyPrefix)+8)
+
+	other, _, err := GenerateApiKey()
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+	require.NotEqual(t, HashApiKey(key), HashApiKey(other))
+}
+
+func TestHashApiKey(t *testing.T) {
+	hash := HashApiKey("pdb_secret")
+	require.Len(t, hash, sha256.Size)
+	require.Equal(t, hash, HashApiKey("pdb_secret"))
+	require.NotEqual(t, hash, HashApiKey("pdb_secreT"))
+}
+
+func TestIsApiKey(t *testing.T) {
+	require.True(t, IsApiKey("pdb_abc"))
+	require.False(t, IsApiKey("eyJhbGciOiJSUzI1NiJ9.e30.sig"))
+	require.False(t, IsApiKey(""))
+}
+
+func TestApiKeyExpired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		expiresAt pgtype.Timestamptz
+		expired   bool
+	}{
+		{name: "no expiry"},
+		{name: "expires later", expiresAt: pgtype.Timestamptz{Time: now.Add(time.Second), Valid: true}},
+		{name: "expires now", expiresAt: pgtype.Timestamptz{Time: now, Valid: true}, expired: true},
+		{name: "expired", expiresAt: pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}, expired: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expired, apiKeyExpired(tc.expiresAt, now))
+		})
+	}
+}
+
+func TestApiKeyUseUnrecorded(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		lastUsedAt pgtype.Timestamptz
+		unrecorded bool
+	}{
+		{name: "never used", unrecorded: true},
+		{name: "used just now", lastUsedAt: pgtype.Timestamptz{Time: now, Valid: true}},
+		{name: "used within the interval", lastUsedAt: pgtype.Timestamptz{Time: now.Add(-59 * time.Second), Valid: true}},
+		{name: "used an interval ago", lastUsedAt: pgtype.Timestamptz{Time: now.Add(-apiKeyLastUsedInterval), Valid: true}},
+		{
+			name:       "used before the interval",
+			lastUsedAt: pgtype.Timestamptz{Time: now.Add(-apiKeyLastUsedInterval - time.Second), Valid: true},
+			unrecorded: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.unrecorded, apiKeyUseUnrecorded(tc.lastUsedAt, now))
+		})
+	}
+}
+
+func TestNormalizeApiKeyScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		expected []string
+	}{
+		{name: "nil", expected: []string{}},
+		{name: "unchanged", names: []string{"pg", "ch"}, expected: []string{"pg", "ch"}},
+		{name: "trimmed", names: []string{" pg", "ch "}, expected: []string{"pg", "ch"}},
+		{name: "empty dropped", names: []string{"", "pg", "  "}, expected: []string{"pg"}},
+		{name: "repeats dropped", names: []string{"pg", " pg", "ch", "pg"}, expected: []string{"pg", "ch"}},
+		{name: "only empty", names: []string{"", " "}, expected: []string{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, NormalizeApiKeyScope(tc.names))
+		})
+	}
+}
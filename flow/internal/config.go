@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -39,6 +40,24 @@ func PeerFlowTaskQueueName(taskQueueID shared.TaskQueueID) string {
 	return fmt.Sprintf("%s-%s", deploymentUID, taskQueueID)
 }
 
+// WorkerPoolTaskQueueName is the task queue of mirrors assigned to a worker pool, the peer flow queue for the default pool
+func WorkerPoolTaskQueueName(workerPool string) string {
+	if workerPool == "" {
+		return PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
+	}
+	return PeerFlowTaskQueueName(shared.PeerFlowTaskQueue + shared.TaskQueueID("-"+workerPool))
+}
+
+var workerPoolNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidateWorkerPool checks the name of a worker pool can be part of a task queue name, empty is the default pool
+func ValidateWorkerPool(workerPool string) error {
+	if workerPool != "" && !workerPoolNameRe.MatchString(workerPool) {
+		return fmt.Errorf("invalid worker pool %q, use lowercase letters, digits, '-' and '_'", workerPool)
+	}
+	return nil
+}
+
 // env variable doesn't exist anymore, but tests appear to depend on this
 // in lieu of an actual value of IdleTimeoutSeconds
 func PeerDBCDCIdleTimeoutSeconds(providedValue int) time.Duration {
@@ -46,7 +65,7 @@ func PeerDBCDCIdleTimeoutSeconds(providedValue int) time.Duration {
 	if providedValue > 0 {
 		x = providedValue
 	} else {
-		x = getEnvConvert("", 10, strconv.Atoi)
+		x = getEnvConvert("", int(shared.DefaultCDCIdleTimeoutSeconds), strconv.Atoi)
 	}
 	return time.Duration(x) * time.Second
 }
@@ -188,6 +207,11 @@ func PeerDBRAPIRequestLoggingEnabled() bool {
 	return requestLoggingEnabled
 }
 
+// PEERDB_DYNAMIC_SETTINGS_CACHE_TTL_SECONDS is how long dynamic settings read from the catalog are cached, 0 disables caching
+func PeerDBDynamicSettingsCacheTTL() time.Duration {
+	return time.Duration(getEnvUint[uint32]("PEERDB_DYNAMIC_SETTINGS_CACHE_TTL_SECONDS", 60)) * time.Second
+}
+
 // PEERDB_MAINTENANCE_MODE_WAIT_ALERT_SECONDS is how long to wait before alerting that peerdb's been stuck in maintenance mode too long
 func PeerDBMaintenanceModeWaitAlertSeconds() int {
 	return getEnvConvert("PEERDB_MAINTENANCE_MODE_WAIT_ALERT_SECONDS", 600, strconv.Atoi)
@@ -5,14 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/smithy-go/ptr"
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgtype"
 	"golang.org/x/exp/constraints"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -41,6 +41,16 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_CDC_BACKPRESSURE_PENDING_NORMALIZE",
+		Description: "Pulling of the next CDC batch waits while this many synced batches are waiting on normalize, " +
+			"so a destination falling behind doesn't balloon memory and staging storage, 0 disables. " +
+			"At most PEERDB_NORMALIZE_CHANNEL_BUFFER_SIZE+1 batches can wait, higher values are lowered to that",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name:             "PEERDB_QUEUE_FLUSH_TIMEOUT_SECONDS",
 		Description:      "Frequency of flushing to queue, applicable for PeerDB Streams mirrors only",
@@ -51,12 +61,37 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 	},
 	{
 		Name:             "PEERDB_QUEUE_PARALLELISM",
-		Description:      "Parallelism for Lua script processing data, applicable for CDC mirrors to Kakfa and PubSub",
+		Description:      "Parallelism for Lua script processing data, applicable for CDC mirrors to Kakfa, PubSub and Kinesis",
 		DefaultValue:     "4",
 		ValueType:        protos.DynconfValueType_INT,
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_QUEUES,
 	},
+	{
+		Name:             "PEERDB_QUEUE_SCHEMA_CHANGE_TOPIC",
+		Description:      "Topic to publish source schema changes to, applicable for CDC mirrors to Kafka, PubSub, Kinesis and Event Hubs (namespace.eventhub.partition_column), empty disables",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_QUEUES,
+	},
+	{
+		Name:             "PEERDB_QUEUE_GEO_FORMAT",
+		Description:      "Format of geometry and geography values in JSON messages to Kafka (Debezium envelope) and Event Hubs: wkt or geojson",
+		DefaultValue:     "wkt",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_QUEUES,
+	},
+	{
+		Name: "PEERDB_QUEUE_LOGICAL_MESSAGE_TOPIC",
+		Description: "Topic to forward logical messages emitted with pg_logical_emit_message on Postgres sources to, " +
+			"scripts receive them as records of kind message with prefix and content, empty disables",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_QUEUES,
+	},
 	{
 		Name:             "PEERDB_CDC_DISK_SPILL_RECORDS_THRESHOLD",
 		Description:      "CDC: number of records beyond which records are written to disk instead",
@@ -136,6 +171,25 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_SNOWFLAKE,
 	},
+	{
+		Name: "PEERDB_SNOWFLAKE_WRITE_MODE",
+		Description: "How CDC batches reach the raw table of mirrors with Snowflake targets: " +
+			"copy stages Avro files and runs COPY INTO, snowpipe_streaming appends rows through the Snowpipe Streaming API",
+		DefaultValue:     "copy",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_SNOWFLAKE,
+	},
+	{
+		Name: "PEERDB_POSTGRES_NORMALIZE_MODE",
+		Description: "How CDC batches are applied to tables of mirrors with Postgres targets: " +
+			"merge runs MERGE (falling back to upsert and delete before Postgres 15), " +
+			"upsert runs INSERT ... ON CONFLICT DO UPDATE on the primary key and only deletes rows for hard deletes",
+		DefaultValue:     "merge",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name:             "PEERDB_CLICKHOUSE_AWS_S3_BUCKET_NAME",
 		Description:      "S3 buckets to store Avro files for mirrors with ClickHouse target",
@@ -144,6 +198,68 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name:             "PEERDB_S3_UPLOAD_CONCURRENCY",
+		Description:      "Parts of an S3 upload sent concurrently, each holding a part sized buffer",
+		DefaultValue:     "5",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_S3_UPLOAD_MEMORY_LIMIT",
+		Description: "Bytes buffered by a single S3 upload, lowers concurrency and then part size to fit, " +
+			"0 buffers concurrency + 1 parts",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_OVERSIZED_VALUE_BYTES",
+		Description: "Text, JSON and HStore values larger than this are handled by PEERDB_OVERSIZED_VALUE_POLICY when CDC records " +
+			"are staged as JSON for the raw table, 0 uses the limit of the destination and negative keeps values of any size",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_OVERSIZED_VALUE_POLICY",
+		Description: "What happens to values over PEERDB_OVERSIZED_VALUE_BYTES: empty, truncate, null, error or spill, " +
+			"when unset they are spilled if PEERDB_OVERSIZED_VALUE_SPILL_PATH is set and emptied otherwise",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_OVERSIZED_VALUE_SPILL_PATH",
+		Description: "s3:// url oversized values are spilled to, " +
+			"leaving a reference to them in the record",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_AVRO_STAGING_CODEC",
+		Description: "Codec of Avro files staged for ClickHouse and Snowflake, one of null, deflate, snappy or zstandard, " +
+			"empty keeps zstandard. Trades CPU for network on constrained links",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_AVRO_STAGING_CODEC_LEVEL",
+		Description: "Compression level of PEERDB_AVRO_STAGING_CODEC, 1-22 for zstandard and 1-9 for deflate, " +
+			"0 uses the default level of the codec",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name: "PEERDB_S3_PART_SIZE",
 		Description: "S3 upload part size in bytes, may need to increase for large batches. " +
@@ -162,12 +278,21 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 	},
 	{
 		Name:             "PEERDB_QUEUE_FORCE_TOPIC_CREATION",
-		Description:      "Force auto topic creation in mirrors, applies to Kafka and PubSub mirrors",
+		Description:      "Force auto topic creation in mirrors, applies to Kafka, PubSub and Kinesis mirrors",
 		DefaultValue:     "false",
 		ValueType:        protos.DynconfValueType_BOOL,
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
 		TargetForSetting: protos.DynconfTarget_QUEUES,
 	},
+	{
+		Name: "PEERDB_QUEUE_UPDATE_DIFF",
+		Description: "Only send primary key and changed columns of updates, in both old and new rows, " +
+			"applies to Kafka, PubSub, Kinesis and Event Hubs mirrors",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_QUEUES,
+	},
 	{
 		Name:             "PEERDB_ALERTING_GAP_MINUTES",
 		Description:      "Duration in minutes before reraising alerts, 0 disables all alerting entirely",
@@ -225,6 +350,16 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name: "PEERDB_CLICKHOUSE_DELETE_MODE",
+		Description: "How source deletes reach ClickHouse: soft only sets _peerdb_is_deleted, " +
+			"is_deleted creates ReplacingMergeTree tables with _peerdb_is_deleted as their is_deleted column, " +
+			"lightweight removes deleted rows with DELETE after every normalize",
+		DefaultValue:     "soft",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
 	{
 		Name:             "PEERDB_CLICKHOUSE_PARALLEL_NORMALIZE",
 		Description:      "Divide tables in batch into N insert selects. Helps distribute load to multiple nodes",
@@ -233,6 +368,24 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name: "PEERDB_CLICKHOUSE_JSONB_TYPE",
+		Description: "ClickHouse type of jsonb columns: String, JSON or Map(String, String). " +
+			"Columns can override it with their destination type",
+		DefaultValue:     "String",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
+	{
+		Name: "PEERDB_CLICKHOUSE_GEO_TYPES",
+		Description: "Map PostGIS columns constrained to Point, LineString, Polygon, MultiLineString or MultiPolygon, " +
+			"and Postgres points, to ClickHouse geo types instead of WKT strings",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
 	{
 		Name:             "PEERDB_CLICKHOUSE_UNBOUNDED_NUMERIC_AS_STRING",
 		Description:      "Map unbounded numerics in Postgres to String in ClickHouse to preserve precision and scale",
@@ -241,6 +394,43 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name: "PEERDB_NUMERIC_OVERFLOW_POLICY",
+		Description: "What happens to NUMERIC values with more integer digits than their destination column allows: " +
+			"null clears them, error fails the sync, clamp replaces them with the largest value of the same sign the column holds " +
+			"and string also maps unbounded numerics in Postgres to String in ClickHouse, behaving like null elsewhere",
+		DefaultValue:     "null",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_TIMESTAMPTZ_ZONE",
+		Description: "IANA time zone, like UTC or Europe/Berlin, timestamptz values are converted to before being synced, " +
+			"ClickHouse columns of converted values are created with this zone, empty keeps values as read from the source",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_TIMESTAMP_SOURCE_ZONE",
+		Description: "IANA time zone naive timestamps at the source are taken to be in, " +
+			"when set naive timestamps are synced as timestamptz, empty keeps them naive",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_PRESERVE_COLUMN_ORDER",
+		Description: "Place columns added at source after the same column as at source, instead of after PeerDB columns. " +
+			"Only ClickHouse supports placing columns, other destinations append them",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
 	{
 		Name:             "PEERDB_INTERVAL_SINCE_LAST_NORMALIZE_THRESHOLD_MINUTES",
 		Description:      "Duration in minutes since last normalize to start alerting, 0 disables all alerting entirely",
@@ -249,6 +439,31 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name:             "PEERDB_TABLE_LAG_ALERT_THRESHOLD_MINUTES",
+		Description:      "Replication lag in minutes of a single table to start alerting, 0 disables table lag alerting",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_STALE_FLOW_ALERT_THRESHOLD_MINUTES",
+		Description: "Duration in minutes a running mirror can go without syncing a batch or completing an empty sync " +
+			"before alerting, 0 disables stale mirror alerting",
+		DefaultValue:     "30",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_STALE_FLOW_AUTO_RESET",
+		Description:      "Reset the workflow of a stale mirror to its last completed workflow task, restarting its sync",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name:             "PEERDB_APPLICATION_NAME_PER_MIRROR_NAME",
 		Description:      "Set Postgres application_name to have mirror name as suffix for each mirror",
@@ -290,6 +505,23 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name: "PEERDB_STAGING_RETENTION_HOURS",
+		Description: "Hours staged files of S3/GCS/Azure stages are kept after a batch before being deleted, " +
+			"for inspecting or replaying recent batches, 0 deletes them immediately",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_CLICKHOUSE_QREP_SOURCE_S3_EXPORT",
+		Description:      "Export partitions of ClickHouse sources to the S3 stage before reading them, instead of streaming query results",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
 	{
 		Name:             "PEERDB_SKIP_SNAPSHOT_EXPORT",
 		Description:      "This avoids initial load failing due to connectivity drops, but risks data consistency unless precautions are taken",
@@ -307,6 +539,16 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_POSTGRES_CDC_TWO_PHASE",
+		Description: "For Postgres CDC on Postgres 15+: decode prepared transactions at PREPARE TRANSACTION, " +
+			"holding their changes until COMMIT PREPARED and dropping them on ROLLBACK PREPARED. " +
+			"Applies to the replication slot when the mirror creates it, Postgres cannot disable two_phase on a slot afterwards",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name: "PEERDB_POSTGRES_CDC_HANDLE_INHERITANCE_FOR_NON_PARTITIONED_TABLES",
 		Description: "For Postgres CDC: attempt to fetch/remap child tables for tables that aren't partitioned by Postgres." +
@@ -316,6 +558,22 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name:             "PEERDB_WASM_MEMORY_LIMIT_MB",
+		Description:      "Memory limit in megabytes of each instance of a WASM script transforming rows",
+		DefaultValue:     "64",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_WASM_CALL_TIMEOUT_MS",
+		Description:      "Time in milliseconds a WASM script can take to transform or route a single row before the sync fails",
+		DefaultValue:     "1000",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 }
 
 var DynamicIndex = func() map[string]int {
@@ -326,6 +584,38 @@ var DynamicIndex = func() map[string]int {
 	return defaults
 }()
 
+// ValidateDynamicSettingValue checks that value parses as the type of setting
+func ValidateDynamicSettingValue(setting *protos.DynamicSetting, value string) error {
+	var err error
+	switch setting.ValueType {
+	case protos.DynconfValueType_INT:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case protos.DynconfValueType_UINT:
+		_, err = strconv.ParseUint(value, 10, 64)
+	case protos.DynconfValueType_BOOL:
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s, expected %s: %w",
+			value, setting.Name, strings.ToLower(setting.ValueType.String()), err)
+	}
+	return nil
+}
+
+// ValidateMirrorEnv checks that env only overrides recognized dynamic settings with values of their type
+func ValidateMirrorEnv(env map[string]string) error {
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		idx, ok := DynamicIndex[key]
+		if !ok {
+			return fmt.Errorf("unrecognized setting %s", key)
+		}
+		if err := ValidateDynamicSettingValue(DynamicSettings[idx], env[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type BinaryFormat int
 
 const (
@@ -351,9 +641,8 @@ func dynLookup(ctx context.Context, env map[string]string, key string) (string,
 		setting = DynamicSettings[idx]
 	}
 
-	var value pgtype.Text
-	query := "SELECT config_value FROM dynamic_settings WHERE config_name=$1"
-	if err := conn.QueryRow(ctx, query, key).Scan(&value); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+	value, err := lookupDynamicSetting(ctx, conn, key)
+	if err != nil {
 		LoggerFromCtx(ctx).Error("Failed to get key", slog.Any("error", err))
 		return "", fmt.Errorf("failed to get key: %w", err)
 	}
@@ -421,17 +710,75 @@ func dynamicConfBool(ctx context.Context, env map[string]string, key string) (bo
 }
 
 func UpdateDynamicSetting(ctx context.Context, pool shared.CatalogPool, name string, value *string) error {
+	return UpdateScopedDynamicSetting(ctx, pool, name, protos.DynconfScope_SCOPE_GLOBAL, "", value)
+}
+
+// UpdateScopedDynamicSetting sets name for the peer or flow named scopeName, nil value removing the override
+func UpdateScopedDynamicSetting(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	name string,
+	scope protos.DynconfScope,
+	scopeName string,
+	value *string,
+) error {
+	scopeColumn, err := dynamicSettingScopeColumn(scope, scopeName)
+	if err != nil {
+		return err
+	}
 	if pool.Pool == nil {
-		var err error
 		pool, err = GetCatalogConnectionPoolFromEnv(ctx)
 		if err != nil {
 			LoggerFromCtx(ctx).Error("Failed to get catalog connection pool for dynamic setting update", slog.Any("error", err))
 			return fmt.Errorf("failed to get catalog connection pool: %w", err)
 		}
 	}
-	_, err := pool.Exec(ctx, `insert into dynamic_settings (config_name, config_value) values ($1, $2)
-			on conflict (config_name) do update set config_value = $2`, name, value)
-	return err
+	if value == nil && scope != protos.DynconfScope_SCOPE_GLOBAL {
+		if _, err := pool.Exec(ctx, "delete from dynamic_settings where config_name=$1 and scope=$2 and scope_name=$3",
+			name, scopeColumn, scopeName); err != nil {
+			return err
+		}
+	} else if _, err := pool.Exec(ctx, `insert into dynamic_settings (config_name, config_value, scope, scope_name)
+			values ($1, $2, $3, $4) on conflict (config_name, scope, scope_name) do update set config_value = $2`,
+		name, value, scopeColumn, scopeName); err != nil {
+		return err
+	}
+	// other processes are notified by the catalog, this one shouldn't wait on it
+	invalidateDynamicSetting(name)
+	return nil
+}
+
+// dynamicSettingScopeColumn is the value of dynamic_settings.scope for scope
+func dynamicSettingScopeColumn(scope protos.DynconfScope, scopeName string) (string, error) {
+	switch scope {
+	case protos.DynconfScope_SCOPE_GLOBAL:
+		if scopeName != "" {
+			return "", errors.New("global settings cannot have a scope name")
+		}
+		return "global", nil
+	case protos.DynconfScope_SCOPE_PEER, protos.DynconfScope_SCOPE_FLOW:
+		if scopeName == "" {
+			return "", fmt.Errorf("%s settings need a scope name", scope)
+		}
+		if scope == protos.DynconfScope_SCOPE_PEER {
+			return "peer", nil
+		}
+		return "flow", nil
+	default:
+		return "", fmt.Errorf("unknown dynamic setting scope %d", scope)
+	}
+}
+
+// DynamicSettingScopeFromColumn is the inverse of dynamicSettingScopeColumn
+func DynamicSettingScopeFromColumn(scope string) protos.DynconfScope {
+	switch scope {
+	case "peer":
+		return protos.DynconfScope_SCOPE_PEER
+	case "flow":
+		return protos.DynconfScope_SCOPE_FLOW
+	default:
+		return protos.DynconfScope_SCOPE_GLOBAL
+	}
 }
 
 // PEERDB_SLOT_LAG_MB_ALERT_THRESHOLD, 0 disables slot lag alerting entirely
@@ -473,6 +820,10 @@ func PeerDBNormalizeChannelBufferSize(ctx context.Context, env map[string]string
 	return dynamicConfSigned[int](ctx, env, "PEERDB_NORMALIZE_CHANNEL_BUFFER_SIZE")
 }
 
+func PeerDBCDCBackpressurePendingNormalize(ctx context.Context, env map[string]string) (int, error) {
+	return dynamicConfSigned[int](ctx, env, "PEERDB_CDC_BACKPRESSURE_PENDING_NORMALIZE")
+}
+
 func PeerDBQueueFlushTimeoutSeconds(ctx context.Context, env map[string]string) (time.Duration, error) {
 	x, err := dynamicConfSigned[int64](ctx, env, "PEERDB_QUEUE_FLUSH_TIMEOUT_SECONDS")
 	if err != nil {
@@ -485,6 +836,30 @@ func PeerDBQueueParallelism(ctx context.Context, env map[string]string) (int64,
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_QUEUE_PARALLELISM")
 }
 
+// PeerDBQueueGeoJSON reports whether geometry and geography values of JSON messages are GeoJSON rather than WKT
+func PeerDBQueueGeoJSON(ctx context.Context, env map[string]string) (bool, error) {
+	format, err := dynLookup(ctx, env, "PEERDB_QUEUE_GEO_FORMAT")
+	if err != nil {
+		return false, err
+	}
+	switch format {
+	case "", "wkt":
+		return false, nil
+	case "geojson":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported geo format %q, expected wkt or geojson", format)
+	}
+}
+
+func PeerDBQueueSchemaChangeTopic(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_QUEUE_SCHEMA_CHANGE_TOPIC")
+}
+
+func PeerDBQueueLogicalMessageTopic(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_QUEUE_LOGICAL_MESSAGE_TOPIC")
+}
+
 func PeerDBCDCDiskSpillRecordsThreshold(ctx context.Context, env map[string]string) (int64, error) {
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_CDC_DISK_SPILL_RECORDS_THRESHOLD")
 }
@@ -542,26 +917,90 @@ func PeerDBClickHouseMaxInsertThreads(ctx context.Context, env map[string]string
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_CLICKHOUSE_MAX_INSERT_THREADS")
 }
 
+func PeerDBClickHouseDeleteMode(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_CLICKHOUSE_DELETE_MODE")
+}
+
 func PeerDBClickHouseParallelNormalize(ctx context.Context, env map[string]string) (int, error) {
 	return dynamicConfSigned[int](ctx, env, "PEERDB_CLICKHOUSE_PARALLEL_NORMALIZE")
 }
 
 func PeerDBEnableClickHouseNumericAsString(ctx context.Context, env map[string]string) (bool, error) {
-	return dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_UNBOUNDED_NUMERIC_AS_STRING")
+	if asString, err := dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_UNBOUNDED_NUMERIC_AS_STRING"); err != nil || asString {
+		return asString, err
+	}
+	policy, err := PeerDBNumericOverflowPolicy(ctx, env)
+	return policy == "string", err
+}
+
+func PeerDBNumericOverflowPolicy(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_NUMERIC_OVERFLOW_POLICY")
+}
+
+func PeerDBTimestampTZZone(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_TIMESTAMPTZ_ZONE")
+}
+
+func PeerDBTimestampSourceZone(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_TIMESTAMP_SOURCE_ZONE")
+}
+
+func PeerDBClickHouseGeoTypes(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_GEO_TYPES")
+}
+
+func PeerDBClickHouseJSONBType(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_CLICKHOUSE_JSONB_TYPE")
+}
+
+func PeerDBSnowflakeWriteMode(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_SNOWFLAKE_WRITE_MODE")
 }
 
 func PeerDBSnowflakeMergeParallelism(ctx context.Context, env map[string]string) (int64, error) {
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_SNOWFLAKE_MERGE_PARALLELISM")
 }
 
+func PeerDBPostgresNormalizeMode(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_POSTGRES_NORMALIZE_MODE")
+}
+
 func PeerDBClickHouseAWSS3BucketName(ctx context.Context, env map[string]string) (string, error) {
 	return dynLookup(ctx, env, "PEERDB_CLICKHOUSE_AWS_S3_BUCKET_NAME")
 }
 
+func PeerDBOversizedValueBytes(ctx context.Context, env map[string]string) (int, error) {
+	return dynamicConfSigned[int](ctx, env, "PEERDB_OVERSIZED_VALUE_BYTES")
+}
+
+func PeerDBOversizedValuePolicy(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_OVERSIZED_VALUE_POLICY")
+}
+
+func PeerDBOversizedValueSpillPath(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_OVERSIZED_VALUE_SPILL_PATH")
+}
+
+func PeerDBAvroStagingCodec(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_AVRO_STAGING_CODEC")
+}
+
+func PeerDBAvroStagingCodecLevel(ctx context.Context, env map[string]string) (int, error) {
+	return dynamicConfSigned[int](ctx, env, "PEERDB_AVRO_STAGING_CODEC_LEVEL")
+}
+
 func PeerDBS3PartSize(ctx context.Context, env map[string]string) (int64, error) {
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_S3_PART_SIZE")
 }
 
+func PeerDBS3UploadConcurrency(ctx context.Context, env map[string]string) (int, error) {
+	return dynamicConfSigned[int](ctx, env, "PEERDB_S3_UPLOAD_CONCURRENCY")
+}
+
+func PeerDBS3UploadMemoryLimit(ctx context.Context, env map[string]string) (int64, error) {
+	return dynamicConfSigned[int64](ctx, env, "PEERDB_S3_UPLOAD_MEMORY_LIMIT")
+}
+
 func PeerDBS3BytesPerAvroFile(ctx context.Context, env map[string]string) (int64, error) {
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_S3_BYTES_PER_AVRO_FILE")
 }
@@ -572,15 +1011,37 @@ func PeerDBQueueForceTopicCreation(ctx context.Context, env map[string]string) (
 	return dynamicConfBool(ctx, env, "PEERDB_QUEUE_FORCE_TOPIC_CREATION")
 }
 
+func PeerDBQueueUpdateDiff(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_QUEUE_UPDATE_DIFF")
+}
+
+func PeerDBPreserveColumnOrder(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_PRESERVE_COLUMN_ORDER")
+}
+
 // PEERDB_INTERVAL_SINCE_LAST_NORMALIZE_THRESHOLD_MINUTES, 0 disables normalize gap alerting entirely
 func PeerDBIntervalSinceLastNormalizeThresholdMinutes(ctx context.Context, env map[string]string) (uint32, error) {
 	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_INTERVAL_SINCE_LAST_NORMALIZE_THRESHOLD_MINUTES")
 }
 
+// PEERDB_TABLE_LAG_ALERT_THRESHOLD_MINUTES, 0 disables table lag alerting
+func PeerDBTableLagAlertThresholdMinutes(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_TABLE_LAG_ALERT_THRESHOLD_MINUTES")
+}
+
 func PeerDBApplicationNamePerMirrorName(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_APPLICATION_NAME_PER_MIRROR_NAME")
 }
 
+// PEERDB_STALE_FLOW_ALERT_THRESHOLD_MINUTES, 0 disables stale mirror alerting
+func PeerDBStaleFlowAlertThresholdMinutes(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_STALE_FLOW_ALERT_THRESHOLD_MINUTES")
+}
+
+func PeerDBStaleFlowAutoReset(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_STALE_FLOW_AUTO_RESET")
+}
+
 func PeerDBMaintenanceModeEnabled(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_MAINTENANCE_MODE_ENABLED")
 }
@@ -601,6 +1062,15 @@ func PeerDBClickHouseInitialLoadPartsPerPartition(ctx context.Context, env map[s
 	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_CLICKHOUSE_INITIAL_LOAD_PARTS_PER_PARTITION")
 }
 
+// PEERDB_STAGING_RETENTION_HOURS, 0 deletes staged files immediately
+func PeerDBStagingRetentionHours(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_STAGING_RETENTION_HOURS")
+}
+
+func PeerDBClickHouseQRepSourceS3Export(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_QREP_SOURCE_S3_EXPORT")
+}
+
 func PeerDBSkipSnapshotExport(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_SKIP_SNAPSHOT_EXPORT")
 }
@@ -612,3 +1082,19 @@ func PeerDBSourceSchemaAsDestinationColumn(ctx context.Context, env map[string]s
 func PeerDBPostgresCDCHandleInheritanceForNonPartitionedTables(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_POSTGRES_CDC_HANDLE_INHERITANCE_FOR_NON_PARTITIONED_TABLES")
 }
+
+func PeerDBPostgresCDCTwoPhase(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_POSTGRES_CDC_TWO_PHASE")
+}
+
+func PeerDBWasmMemoryLimitMB(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_WASM_MEMORY_LIMIT_MB")
+}
+
+func PeerDBWasmCallTimeout(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfSigned[int64](ctx, env, "PEERDB_WASM_CALL_TIMEOUT_MS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Millisecond, nil
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// notified by a trigger on dynamic_settings with the name of the changed setting, empty when all changed
+const dynamicSettingsChannel = "peerdb_dynamic_settings"
+
+const dynamicSettingsListenRetryInterval = 10 * time.Second
+
+// settings can be overridden for the peers of a flow and for the flow itself,
+// the flow scope wins over the destination peer, which wins over the source peer
+const scopedDynamicSettingQuery = `SELECT s.config_value FROM dynamic_settings s
+LEFT JOIN flows f ON f.name=$2
+LEFT JOIN peers p ON s.scope='peer' AND p.name=s.scope_name
+WHERE s.config_name=$1 AND (s.scope='global' OR (s.scope='flow' AND s.scope_name=$2)
+	OR (s.scope='peer' AND p.id IN (f.source_peer, f.destination_peer)))
+ORDER BY CASE WHEN s.scope='flow' THEN 0 WHEN p.id=f.destination_peer THEN 1 WHEN s.scope='peer' THEN 2 ELSE 3 END
+LIMIT 1`
+
+type cachedDynamicSetting struct {
+	expires time.Time
+	value   pgtype.Text
+}
+
+// dynamicSettingsCache holds values read from dynamic_settings by setting name then flow name,
+// so per-batch reads don't hit the catalog. Entries expire after PEERDB_DYNAMIC_SETTINGS_CACHE_TTL_SECONDS
+// and are invalidated early on change notifications.
+var dynamicSettingsCache = struct {
+	entries map[string]map[string]cachedDynamicSetting
+	sync.RWMutex
+}{entries: make(map[string]map[string]cachedDynamicSetting)}
+
+var dynamicSettingsListenOnce sync.Once
+
+func getCachedDynamicSetting(key string, flowName string) (pgtype.Text, bool) {
+	dynamicSettingsCache.RLock()
+	defer dynamicSettingsCache.RUnlock()
+	entry, ok := dynamicSettingsCache.entries[key][flowName]
+	if !ok || time.Now().After(entry.expires) {
+		return pgtype.Text{}, false
+	}
+	return entry.value, true
+}
+
+func setCachedDynamicSetting(key string, flowName string, value pgtype.Text, ttl time.Duration) {
+	dynamicSettingsCache.Lock()
+	defer dynamicSettingsCache.Unlock()
+	byFlow, ok := dynamicSettingsCache.entries[key]
+	if !ok {
+		byFlow = make(map[string]cachedDynamicSetting)
+		dynamicSettingsCache.entries[key] = byFlow
+	}
+	byFlow[flowName] = cachedDynamicSetting{value: value, expires: time.Now().Add(ttl)}
+}
+
+// invalidateDynamicSetting drops key of every scope from the cache, or every setting when key is empty
+func invalidateDynamicSetting(key string) {
+	dynamicSettingsCache.Lock()
+	defer dynamicSettingsCache.Unlock()
+	if key == "" {
+		clear(dynamicSettingsCache.entries)
+	} else {
+		delete(dynamicSettingsCache.entries, key)
+	}
+}
+
+// lookupDynamicSetting reads the catalog value of key through the cache, invalid when it is not set.
+// When ctx carries a flow name, values scoped to the flow or its peers take precedence over the global value.
+func lookupDynamicSetting(ctx context.Context, conn shared.CatalogPool, key string) (pgtype.Text, error) {
+	flowName, _ := ctx.Value(shared.FlowNameKey).(string)
+	ttl := PeerDBDynamicSettingsCacheTTL()
+	if ttl > 0 {
+		dynamicSettingsListenOnce.Do(func() {
+			go listenForDynamicSettingChanges(conn)
+		})
+		if value, ok := getCachedDynamicSetting(key, flowName); ok {
+			return value, nil
+		}
+	}
+
+	var value pgtype.Text
+	if err := conn.QueryRow(ctx, scopedDynamicSettingQuery, key, flowName).Scan(&value); err != nil &&
+		!errors.Is(err, pgx.ErrNoRows) {
+		return value, err
+	}
+	if ttl > 0 {
+		setCachedDynamicSetting(key, flowName, value, ttl)
+	}
+	return value, nil
+}
+
+// listenForDynamicSettingChanges invalidates cached settings as they change for as long as the process runs
+func listenForDynamicSettingChanges(conn shared.CatalogPool) {
+	ctx := context.Background()
+	for {
+		if err := waitForDynamicSettingChanges(ctx, conn); err != nil {
+			slog.Warn("stopped receiving dynamic setting changes, retrying", slog.Any("error", err))
+		}
+		// changes may have been missed, so fall back to the catalog until listening again
+		invalidateDynamicSetting("")
+		time.Sleep(dynamicSettingsListenRetryInterval)
+	}
+}
+
+func waitForDynamicSettingChanges(ctx context.Context, conn shared.CatalogPool) error {
+	poolConn, err := conn.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire catalog connection: %w", err)
+	}
+	// the connection keeps listening, so it must not go back to the pool
+	listenConn := poolConn.Hijack()
+	defer listenConn.Close(ctx)
+
+	if _, err := listenConn.Exec(ctx, "LISTEN "+dynamicSettingsChannel); err != nil {
+		return fmt.Errorf("failed to listen for dynamic setting changes: %w", err)
+	}
+	// settings may have changed before listening
+	invalidateDynamicSetting("")
+	for {
+		notification, err := listenConn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for dynamic setting changes: %w", err)
+		}
+		invalidateDynamicSetting(notification.Payload)
+	}
+}
@@ -12,6 +12,9 @@ type PeerDBOAuthConfig struct {
 	// This is a custom claim we may wish to validate (if needed)
 	OAuthJwtClaimKey string `json:"oauth_jwt_claim_key"`
 	OAuthClaimValue  string `json:"oauth_jwt_claim_value"`
+	// Claims holding the role & peer scope of the token's subject, every subject is an admin without a role claim
+	OAuthRoleClaim  string `json:"oauth_role_claim"`
+	OAuthPeersClaim string `json:"oauth_peers_claim"`
 	// Enabling uses /.well-known/ OpenID discovery endpoints, thus key-set etc. don't need to be specified
 	OAuthDiscoveryEnabled bool `json:"oauth_discovery_enabled"`
 }
@@ -28,6 +31,8 @@ func GetPeerDBOAuthConfig() PeerDBOAuthConfig {
 
 	oauthJwtClaimKey := GetEnvString("PEERDB_OAUTH_JWT_CLAIM_KEY", "")
 	oauthJwtClaimValue := GetEnvString("PEERDB_OAUTH_JWT_CLAIM_VALUE", "")
+	oauthRoleClaim := GetEnvString("PEERDB_OAUTH_ROLE_CLAIM", "")
+	oauthPeersClaim := GetEnvString("PEERDB_OAUTH_PEERS_CLAIM", "")
 
 	return PeerDBOAuthConfig{
 		OAuthIssuerUrl:        oauthIssuerUrl,
@@ -35,5 +40,7 @@ func GetPeerDBOAuthConfig() PeerDBOAuthConfig {
 		KeySetJson:            oauthKeysetJson,
 		OAuthJwtClaimKey:      oauthJwtClaimKey,
 		OAuthClaimValue:       oauthJwtClaimValue,
+		OAuthRoleClaim:        oauthRoleClaim,
+		OAuthPeersClaim:       oauthPeersClaim,
 	}
 }
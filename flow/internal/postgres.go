@@ -40,7 +40,7 @@ func UpdateCDCConfigInCatalog(ctx context.Context, pool shared.CatalogPool,
 ) error {
 	logger.Info("syncing state to catalog: updating config_proto in flows", slog.String("flowName", cfg.FlowJobName))
 
-	cfgBytes, err := proto.Marshal(cfg)
+	cfgBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("unable to marshal flow config: %w", err)
 	}
@@ -49,11 +49,30 @@ func UpdateCDCConfigInCatalog(ctx context.Context, pool shared.CatalogPool,
 		logger.Error("failed to update catalog", slog.Any("error", err), slog.String("flowName", cfg.FlowJobName))
 		return fmt.Errorf("failed to update catalog: %w", err)
 	}
+	if err := RecordConfigVersion(ctx, pool, cfg.FlowJobName, cfgBytes); err != nil {
+		logger.Error("failed to record config version", slog.Any("error", err), slog.String("flowName", cfg.FlowJobName))
+		return err
+	}
 
 	logger.Info("synced state to catalog: updated config_proto in flows", slog.String("flowName", cfg.FlowJobName))
 	return nil
 }
 
+// RecordConfigVersion stores a serialized mirror config as its next version, unless it is the latest version already.
+// Configs should be marshaled deterministically so unchanged configs are recognized
+func RecordConfigVersion(ctx context.Context, pool shared.CatalogPool, flowName string, cfgBytes []byte) error {
+	if _, err := pool.Exec(ctx, `WITH latest AS (
+			SELECT version, config_proto FROM flow_config_versions WHERE flow_name=$1 ORDER BY version DESC LIMIT 1
+		)
+		INSERT INTO flow_config_versions(flow_name, version, config_proto)
+		SELECT $1, coalesce((SELECT version FROM latest), 0) + 1, $2
+		WHERE NOT EXISTS (SELECT 1 FROM latest WHERE config_proto=$2)`, flowName, cfgBytes,
+	); err != nil {
+		return fmt.Errorf("failed to record config version: %w", err)
+	}
+	return nil
+}
+
 func LoadTableSchemaFromCatalog(
 	ctx context.Context,
 	pool shared.CatalogPool,
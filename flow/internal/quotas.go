@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// MirrorQuota limits the resources of a mirror, 0 for no limit
+type MirrorQuota struct {
+	MaxConcurrentPartitionWorkflows int32
+	MaxStagedBytes                  int64
+	MaxRowsPerDay                   int64
+}
+
+// CapBatchBytes caps the size in bytes of a batch, 0 for no limit, by the staged bytes quota
+func (q MirrorQuota) CapBatchBytes(maxBatchBytes uint64) uint64 {
+	if q.MaxStagedBytes <= 0 {
+		return maxBatchBytes
+	}
+	if maxStaged := uint64(q.MaxStagedBytes); maxBatchBytes == 0 || maxBatchBytes > maxStaged {
+		return maxStaged
+	}
+	return maxBatchBytes
+}
+
+// LoadMirrorQuota is the quota of the mirror, falling back to the default quota set under the empty name
+func LoadMirrorQuota(ctx context.Context, pool shared.CatalogPool, flowName string) (MirrorQuota, error) {
+	var quota MirrorQuota
+	rows, err := pool.Query(ctx, `SELECT max_concurrent_partition_workflows, max_staged_bytes, max_rows_per_day
+		FROM mirror_quotas WHERE flow_name = $1 OR flow_name = '' ORDER BY flow_name DESC LIMIT 1`, flowName)
+	if err != nil {
+		return quota, fmt.Errorf("unable to query quota of mirror %s: %w", flowName, err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&quota.MaxConcurrentPartitionWorkflows, &quota.MaxStagedBytes, &quota.MaxRowsPerDay); err != nil {
+			return quota, fmt.Errorf("unable to scan quota of mirror %s: %w", flowName, err)
+		}
+	}
+	return quota, rows.Err()
+}
+
+// MirrorRowsLastDay counts rows synced by the mirror over the last 24 hours, CDC batches and partitions of its initial load alike
+func MirrorRowsLastDay(ctx context.Context, pool shared.CatalogPool, flowName string) (int64, error) {
+	var numRows int64
+	if err := pool.QueryRow(ctx, `SELECT (
+		coalesce((SELECT sum(rows_in_batch) FROM peerdb_stats.cdc_batches
+			WHERE flow_name = $1 AND start_time > now() - interval '1 day'), 0) +
+		coalesce((SELECT sum(rows_synced) FROM peerdb_stats.qrep_partitions
+			WHERE (flow_name = $1 OR parent_mirror_name = $1) AND end_time > now() - interval '1 day'), 0)
+	)::bigint`, flowName,
+	).Scan(&numRows); err != nil {
+		return 0, fmt.Errorf("unable to count rows synced by mirror %s: %w", flowName, err)
+	}
+	return numRows, nil
+}
@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapBatchBytes(t *testing.T) {
+	tests := []struct {
+		name          string
+		quota         MirrorQuota
+		maxBatchBytes uint64
+		expected      uint64
+	}{
+		{name: "no quota, no limit", maxBatchBytes: 0, expected: 0},
+		{name: "no quota", maxBatchBytes: 1000, expected: 1000},
+		{name: "quota, no limit", quota: MirrorQuota{MaxStagedBytes: 500}, maxBatchBytes: 0, expected: 500},
+		{name: "quota under limit", quota: MirrorQuota{MaxStagedBytes: 500}, maxBatchBytes: 1000, expected: 500},
+		{name: "quota over limit", quota: MirrorQuota{MaxStagedBytes: 5000}, maxBatchBytes: 1000, expected: 1000},
+		{name: "negative quota", quota: MirrorQuota{MaxStagedBytes: -1}, maxBatchBytes: 1000, expected: 1000},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.quota.CapBatchBytes(tc.maxBatchBytes))
+		})
+	}
+}
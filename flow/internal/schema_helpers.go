@@ -1,14 +1,17 @@
 package internal
 
 import (
+	"context"
 	"log/slog"
 	"maps"
 	"slices"
 
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 func AdditionalTablesHasOverlap(currentTableMappings []*protos.TableMapping,
@@ -32,12 +35,27 @@ func AdditionalTablesHasOverlap(currentTableMappings []*protos.TableMapping,
 		shared.ArraysHaveOverlap(currentDstTables, additionalDstTables)
 }
 
+// TimestampZonesFromEnv loads the timestamp zones of a mirror, nil when timestamps are left unchanged
+func TimestampZonesFromEnv(ctx context.Context, env map[string]string) (*types.TimestampZones, error) {
+	target, err := PeerDBTimestampTZZone(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	naiveSource, err := PeerDBTimestampSourceZone(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewTimestampZones(target, naiveSource)
+}
+
 // given the output of GetTableSchema, processes it to be used by CDCFlow
 // 1) changes the map key to be the destination table name instead of the source table name
 // 2) performs column exclusion using protos.TableMapping as input.
+// 3) converts naive timestamp columns to timestamptz when zones has a naive source zone.
 func BuildProcessedSchemaMapping(
 	tableMappings []*protos.TableMapping,
 	tableNameSchemaMapping map[string]*protos.TableSchema,
+	zones *types.TimestampZones,
 	logger log.Logger,
 ) map[string]*protos.TableSchema {
 	sortedSourceTables := slices.Sorted(maps.Keys(tableNameSchemaMapping))
@@ -70,9 +88,46 @@ func BuildProcessedSchemaMapping(
 						Columns:               columns,
 					}
 				}
+				// json path columns are extracted from q values, they are not supported with the pg type system
+				if len(mapping.JsonPathColumns) != 0 && tableSchema.System == protos.TypeSystem_Q {
+					columns := slices.Grow(slices.Clone(tableSchema.Columns), len(mapping.JsonPathColumns))
+					for _, col := range mapping.JsonPathColumns {
+						columnType := col.Type
+						if columnType == "" {
+							columnType = string(types.QValueKindString)
+						}
+						columns = append(columns, &protos.FieldDescription{
+							Name:         col.DestinationName,
+							Type:         columnType,
+							TypeModifier: -1,
+							Nullable:     true,
+						})
+					}
+					tableSchema = &protos.TableSchema{
+						TableIdentifier:       tableSchema.TableIdentifier,
+						PrimaryKeyColumns:     tableSchema.PrimaryKeyColumns,
+						IsReplicaIdentityFull: tableSchema.IsReplicaIdentityFull,
+						NullableEnabled:       tableSchema.NullableEnabled,
+						System:                tableSchema.System,
+						Columns:               columns,
+					}
+				}
 				break
 			}
 		}
+		// timestamps are normalized on q values, they are not supported with the pg type system
+		if zones != nil && zones.NaiveSource != nil && tableSchema.System == protos.TypeSystem_Q {
+			columns := make([]*protos.FieldDescription, len(tableSchema.Columns))
+			for idx, column := range tableSchema.Columns {
+				if kind := zones.Kind(types.QValueKind(column.Type)); kind != types.QValueKind(column.Type) {
+					column = proto.CloneOf(column)
+					column.Type = string(kind)
+				}
+				columns[idx] = column
+			}
+			tableSchema = proto.CloneOf(tableSchema)
+			tableSchema.Columns = columns
+		}
 		processedSchemaMapping[dstTableName] = tableSchema
 
 		logger.Info("normalized table schema",
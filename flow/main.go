@@ -8,9 +8,9 @@ import (
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/urfave/cli/v3"
-	"go.temporal.io/sdk/worker"
 	_ "go.uber.org/automaxprocs"
 
 	"github.com/PeerDB-io/peerdb/flow/cmd"
@@ -70,6 +70,13 @@ func main() {
 		Sources: cli.EnvVars("TEMPORAL_MAX_CONCURRENT_WORKFLOW_TASKS"),
 	}
 
+	workerStopTimeoutFlag := &cli.DurationFlag{
+		Name:    "worker-stop-timeout",
+		Value:   2 * time.Minute,
+		Usage:   "Time in-flight activities get to flush their progress when the worker is stopped",
+		Sources: cli.EnvVars("WORKER_STOP_TIMEOUT"),
+	}
+
 	maintenanceModeWorkflowFlag := &cli.StringFlag{
 		Name:    "run-maintenance-flow",
 		Value:   "",
@@ -119,6 +126,13 @@ func main() {
 		Sources: cli.EnvVars("USE_MAINTENANCE_TASK_QUEUE"),
 	}
 
+	workerPoolFlag := &cli.StringFlag{
+		Name:    "worker-pool",
+		Value:   "",
+		Usage:   "Worker pool to run mirrors of, the default pool when empty",
+		Sources: cli.EnvVars("PEERDB_WORKER_POOL"),
+	}
+
 	assumedSkippedMaintenanceWorkflowsFlag := &cli.BoolFlag{
 		Name:  "assume-skipped-workflow",
 		Value: false,
@@ -146,13 +160,48 @@ func main() {
 						TemporalMaxConcurrentActivities:    clicmd.Int("temporal-max-concurrent-activities"),
 						TemporalMaxConcurrentWorkflowTasks: clicmd.Int("temporal-max-concurrent-workflow-tasks"),
 						UseMaintenanceTaskQueue:            clicmd.Bool(useMaintenanceTaskQueueFlag.Name),
+						WorkerPool:                         clicmd.String(workerPoolFlag.Name),
 						PprofPort:                          clicmd.Int(pprofPortFlag.Name),
+						WorkerStopTimeout:                  clicmd.Duration(workerStopTimeoutFlag.Name),
 					})
 					if err != nil {
 						return err
 					}
 					defer res.Close(context.Background())
-					return res.Worker.Run(worker.InterruptCh())
+					return res.Run()
+				},
+				Commands: []*cli.Command{
+					{
+						Name:  "replay",
+						Usage: "Replay recent workflow histories to check that workflow changes are deterministic",
+						Flags: []cli.Flag{
+							temporalHostPortFlag,
+							temporalNamespaceFlag,
+							&cli.StringSliceFlag{
+								Name:  "workflow-type",
+								Usage: "Workflow types to replay, defaults to the CDC, snapshot and QRep workflows",
+							},
+							&cli.DurationFlag{
+								Name:  "since",
+								Value: 24 * time.Hour,
+								Usage: "Replay workflows started within this duration, running workflows are always replayed",
+							},
+							&cli.IntFlag{
+								Name:  "limit",
+								Value: 100,
+								Usage: "Maximum number of histories replayed per workflow type, 0 for no limit",
+							},
+						},
+						Action: func(ctx context.Context, clicmd *cli.Command) error {
+							return cmd.WorkflowReplayMain(ctx, &cmd.WorkflowReplayCLIParams{
+								TemporalHostPort:  clicmd.String(temporalHostPortFlag.Name),
+								TemporalNamespace: clicmd.String(temporalNamespaceFlag.Name),
+								WorkflowTypes:     clicmd.StringSlice("workflow-type"),
+								Since:             clicmd.Duration("since"),
+								Limit:             clicmd.Int("limit"),
+							})
+						},
+					},
 				},
 				Flags: []cli.Flag{
 					temporalHostPortFlag,
@@ -163,6 +212,8 @@ func main() {
 					temporalMaxConcurrentActivitiesFlag,
 					temporalMaxConcurrentWorkflowTasksFlag,
 					useMaintenanceTaskQueueFlag,
+					workerPoolFlag,
+					workerStopTimeoutFlag,
 				},
 			},
 			{
@@ -173,17 +224,19 @@ func main() {
 						EnableOtelMetrics: clicmd.Bool("enable-otel-metrics"),
 						TemporalHostPort:  temporalHostPort,
 						TemporalNamespace: clicmd.String("temporal-namespace"),
+						WorkerStopTimeout: clicmd.Duration(workerStopTimeoutFlag.Name),
 					})
 					if err != nil {
 						return err
 					}
 					defer res.Close(context.Background())
-					return res.Worker.Run(worker.InterruptCh())
+					return res.Run()
 				},
 				Flags: []cli.Flag{
 					otelMetricsFlag,
 					temporalHostPortFlag,
 					temporalNamespaceFlag,
+					workerStopTimeoutFlag,
 				},
 			},
 			{
@@ -248,6 +301,37 @@ func main() {
 					})
 				},
 			},
+			{
+				Name:  "apply",
+				Usage: "Apply a declarative spec of peers and mirrors",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "file",
+						Aliases:  []string{"f"},
+						Usage:    "Path of the YAML or JSON spec",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only print the changes the spec would make",
+					},
+					&cli.BoolFlag{
+						Name:  "prune",
+						Usage: "Drop peers and mirrors missing from the spec",
+					},
+					flowGrpcAddressFlag,
+					flowTlsEnabledFlag,
+				},
+				Action: func(ctx context.Context, clicmd *cli.Command) error {
+					return cmd.DeclarativeApplyMain(ctx, &cmd.DeclarativeCLIParams{
+						SpecPath:        clicmd.String("file"),
+						FlowGrpcAddress: clicmd.String(flowGrpcAddressFlag.Name),
+						FlowTlsEnabled:  clicmd.Bool(flowTlsEnabledFlag.Name),
+						DryRun:          clicmd.Bool("dry-run"),
+						Prune:           clicmd.Bool("prune"),
+					})
+				},
+			},
 		},
 	}
 
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// Role is what an identity may do through the flow API, each role may do everything the ones below it may
+type Role int
+
+const (
+	RoleReadOnly Role = iota
+	RoleOperator
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleReadOnly:
+		return "read-only"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+}
+
+// ParseRole accepts the role names as they appear in tokens, case insensitively
+func ParseRole(role string) (Role, error) {
+	switch strings.ToLower(role) {
+	case "read-only", "read_only", "readonly":
+		return RoleReadOnly, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return 0, fmt.Errorf("unknown role %q", role)
+	}
+}
+
+// Identity is who made a request, there is none when authentication is disabled
+type Identity struct {
+	Subject string
+	// peers the identity may create, edit or drop peers and mirrors of, any when empty
+	Peers []string
+	// mirrors the identity may create, edit or drop, any when empty
+	Mirrors []string
+	Role    Role
+}
+
+// CanManagePeer reports whether the identity is scoped to include peer
+func (i *Identity) CanManagePeer(peer string) bool {
+	return len(i.Peers) == 0 || slices.Contains(i.Peers, peer)
+}
+
+// CanManageMirror reports whether the identity is scoped to include mirror
+func (i *Identity) CanManageMirror(mirror string) bool {
+	return len(i.Mirrors) == 0 || slices.Contains(i.Mirrors, mirror)
+}
+
+// IdentityFromContext is the identity of the request being handled, nil when authentication is disabled
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(shared.RequestIdentityKey).(*Identity)
+	return identity
+}
+
+func contextWithIdentity(ctx context.Context, identity *Identity) context.Context {
+	ctx = context.WithValue(ctx, shared.RequestActorKey, identity.Subject)
+	return context.WithValue(ctx, shared.RequestIdentityKey, identity)
+}
+
+// methodRoles is the least role allowed to call each method, methods not listed need RoleAdmin
+var methodRoles = map[string]Role{
+	protos.FlowService_ValidatePeer_FullMethodName:             RoleReadOnly,
+	protos.FlowService_ValidateCDCMirror_FullMethodName:        RoleReadOnly,
+	protos.FlowService_PreflightCDCMirror_FullMethodName:       RoleReadOnly,
+	protos.FlowService_GetAlertConfigs_FullMethodName:          RoleReadOnly,
+	protos.FlowService_GetAlertRules_FullMethodName:            RoleReadOnly,
+	protos.FlowService_GetDynamicSettings_FullMethodName:       RoleReadOnly,
+	protos.FlowService_GetScripts_FullMethodName:               RoleReadOnly,
+	protos.FlowService_CDCTableTotalCounts_FullMethodName:      RoleReadOnly,
+	protos.FlowService_CDCTableGroupStats_FullMethodName:       RoleReadOnly,
+	protos.FlowService_GetSchemas_FullMethodName:               RoleReadOnly,
+	protos.FlowService_GetPublications_FullMethodName:          RoleReadOnly,
+	protos.FlowService_GetTablesInSchema_FullMethodName:        RoleReadOnly,
+	protos.FlowService_GetAllTables_FullMethodName:             RoleReadOnly,
+	protos.FlowService_GetColumns_FullMethodName:               RoleReadOnly,
+	protos.FlowService_GetColumnsTypeConversion_FullMethodName: RoleReadOnly,
+	protos.FlowService_GetSlotInfo_FullMethodName:              RoleReadOnly,
+	protos.FlowService_GetSlotLagHistory_FullMethodName:        RoleReadOnly,
+	protos.FlowService_GetStatInfo_FullMethodName:              RoleReadOnly,
+	protos.FlowService_ListMirrorLogs_FullMethodName:           RoleReadOnly,
+	protos.FlowService_GetMirrorConfig_FullMethodName:          RoleReadOnly,
+	protos.FlowService_ListMirrorConfigVersions_FullMethodName: RoleReadOnly,
+	protos.FlowService_DiffDeclarativeSpec_FullMethodName:      RoleReadOnly,
+	protos.FlowService_ListMirrorTemplates_FullMethodName:      RoleReadOnly,
+	protos.FlowService_ListQRepPartitions_FullMethodName:       RoleReadOnly,
+	protos.FlowService_ListMirrorAuditEvents_FullMethodName:    RoleReadOnly,
+	protos.FlowService_ListMirrors_FullMethodName:              RoleReadOnly,
+	protos.FlowService_ListMirrorNames_FullMethodName:          RoleReadOnly,
+	protos.FlowService_ExportMirrorState_FullMethodName:        RoleReadOnly,
+	protos.FlowService_GetCutoverReport_FullMethodName:         RoleReadOnly,
+	protos.FlowService_GetMigrationState_FullMethodName:        RoleReadOnly,
+	protos.FlowService_MirrorStatus_FullMethodName:             RoleReadOnly,
+	protos.FlowService_GetCDCBatches_FullMethodName:            RoleReadOnly,
+	protos.FlowService_CDCBatches_FullMethodName:               RoleReadOnly,
+	protos.FlowService_CDCGraph_FullMethodName:                 RoleReadOnly,
+	protos.FlowService_InitialLoadSummary_FullMethodName:       RoleReadOnly,
+	protos.FlowService_ListPeerProbes_FullMethodName:           RoleReadOnly,
+	protos.FlowService_GetPeerInfo_FullMethodName:              RoleReadOnly,
+	protos.FlowService_GetPeerType_FullMethodName:              RoleReadOnly,
+	protos.FlowService_ListPeers_FullMethodName:                RoleReadOnly,
+	protos.FlowService_GetVersion_FullMethodName:               RoleReadOnly,
+	protos.FlowService_GetInstanceInfo_FullMethodName:          RoleReadOnly,
+	protos.FlowService_GetFlowTags_FullMethodName:              RoleReadOnly,
+	protos.FlowService_GetMirrorQuotas_FullMethodName:          RoleReadOnly,
+	protos.FlowService_GetAutoscalingHints_FullMethodName:      RoleReadOnly,
+
+	protos.FlowService_CreateCDCFlow_FullMethodName:               RoleOperator,
+	protos.FlowService_CreateQRepFlow_FullMethodName:              RoleOperator,
+	protos.FlowService_CustomSyncFlow_FullMethodName:              RoleOperator,
+	protos.FlowService_RollbackMirrorConfig_FullMethodName:        RoleOperator,
+	protos.FlowService_ApplyDeclarativeSpec_FullMethodName:        RoleOperator,
+	protos.FlowService_CreateMirrorTemplate_FullMethodName:        RoleOperator,
+	protos.FlowService_DropMirrorTemplate_FullMethodName:          RoleOperator,
+	protos.FlowService_CreateSubsetFlow_FullMethodName:            RoleOperator,
+	protos.FlowService_CloneMirror_FullMethodName:                 RoleOperator,
+	protos.FlowService_RetryQRepPartitions_FullMethodName:         RoleOperator,
+	protos.FlowService_FlowStateChange_FullMethodName:             RoleOperator,
+	protos.FlowService_UpdateMirrorEnv_FullMethodName:             RoleOperator,
+	protos.FlowService_MoveMirrorToWorkerPool_FullMethodName:      RoleOperator,
+	protos.FlowService_ReplayRecords_FullMethodName:               RoleOperator,
+	protos.FlowService_DeduplicateMirror_FullMethodName:           RoleOperator,
+	protos.FlowService_ImportMirrorState_FullMethodName:           RoleOperator,
+	protos.FlowService_CreateCutoverReport_FullMethodName:         RoleOperator,
+	protos.FlowService_CreateMigration_FullMethodName:             RoleOperator,
+	protos.FlowService_ConfirmMigrationWriteFreeze_FullMethodName: RoleOperator,
+	protos.FlowService_ProbePeer_FullMethodName:                   RoleOperator,
+	protos.FlowService_CreateOrReplaceFlowTags_FullMethodName:     RoleOperator,
+}
+
+// MethodRole is the least role allowed to call fullMethod
+func MethodRole(fullMethod string) Role {
+	if role, ok := methodRoles[fullMethod]; ok {
+		return role
+	}
+	return RoleAdmin
+}
+
+// authorizeMethod checks identity has the role fullMethod needs
+func authorizeMethod(identity *Identity, fullMethod string) error {
+	required := MethodRole(fullMethod)
+	if identity.Role < required {
+		return status.Errorf(codes.PermissionDenied, "%s requires the %s role, %s has %s",
+			fullMethod, required, identity.Subject, identity.Role)
+	}
+	return nil
+}
+
+// identityFromClaims reads the role & peers of the token's subject from the configured claims,
+// without a role claim configured every authenticated identity is an admin
+func identityFromClaims(subject string, claims map[string]any, roleClaim string, peersClaim string) (*Identity, error) {
+	identity := &Identity{Subject: subject, Role: RoleAdmin}
+	if roleClaim != "" {
+		identity.Role = RoleReadOnly
+		if claim, ok := claims[roleClaim]; ok {
+			roleName, ok := claim.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %s is not a string", roleClaim)
+			}
+			role, err := ParseRole(roleName)
+			if err != nil {
+				return nil, err
+			}
+			identity.Role = role
+		}
+	}
+	if peersClaim != "" {
+		switch peers := claims[peersClaim].(type) {
+		case nil:
+		case string:
+			for peer := range strings.SplitSeq(peers, ",") {
+				if name := strings.TrimSpace(peer); name != "" {
+					identity.Peers = append(identity.Peers, name)
+				}
+			}
+		case []any:
+			for _, peer := range peers {
+				name, ok := peer.(string)
+				if !ok {
+					return nil, fmt.Errorf("claim %s has a peer that is not a string", peersClaim)
+				}
+				if name = strings.TrimSpace(name); name != "" {
+					identity.Peers = append(identity.Peers, name)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("claim %s is neither a list nor a string", peersClaim)
+		}
+		// no peers would scope the identity to all of them
+		if claims[peersClaim] != nil && len(identity.Peers) == 0 {
+			return nil, fmt.Errorf("claim %s does not name any peer", peersClaim)
+		}
+	}
+	return identity, nil
+}
+
+func identityFromApiKey(grant *internal.ApiKeyGrant) (*Identity, error) {
+	role, err := ParseRole(grant.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Subject: "api-key:" + grant.Name,
+		Role:    role,
+		Peers:   grant.Peers,
+		Mirrors: grant.Mirrors,
+	}, nil
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		expected Role
+		wantErr  bool
+	}{
+		{name: "read-only", role: "read-only", expected: RoleReadOnly},
+		{name: "read_only", role: "read_only", expected: RoleReadOnly},
+		{name: "readonly", role: "ReadOnly", expected: RoleReadOnly},
+		{name: "operator", role: "OPERATOR", expected: RoleOperator},
+		{name: "admin", role: "admin", expected: RoleAdmin},
+		{name: "unknown", role: "owner", wantErr: true},
+		{name: "empty", role: "", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			role, err := ParseRole(tc.role)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, role)
+			// names written back to the catalog parse to the same role
+			roundTrip, err := ParseRole(role.String())
+			require.NoError(t, err)
+			require.Equal(t, role, roundTrip)
+		})
+	}
+}
+
+func TestIdentityFromClaims(t *testing.T) {
+	tests := []struct {
+		claims     map[string]any
+		name       string
+		roleClaim  string
+		peersClaim string
+		expected   *Identity
+		wantErr    bool
+	}{
+		{
+			name:     "no claims configured",
+			claims:   map[string]any{"role": "read-only", "peers": "pg"},
+			expected: &Identity{Subject: "sub", Role: RoleAdmin},
+		},
+		{
+			name:      "role claim missing",
+			claims:    map[string]any{},
+			roleClaim: "role",
+			expected:  &Identity{Subject: "sub", Role: RoleReadOnly},
+		},
+		{
+			name:      "role claim",
+			claims:    map[string]any{"role": "operator"},
+			roleClaim: "role",
+			expected:  &Identity{Subject: "sub", Role: RoleOperator},
+		},
+		{
+			name:      "role claim not a string",
+			claims:    map[string]any{"role": []any{"admin"}},
+			roleClaim: "role",
+			wantErr:   true,
+		},
+		{
+			name:      "unknown role",
+			claims:    map[string]any{"role": "owner"},
+			roleClaim: "role",
+			wantErr:   true,
+		},
+		{
+			name:       "peers claim missing",
+			claims:     map[string]any{},
+			peersClaim: "peers",
+			expected:   &Identity{Subject: "sub", Role: RoleAdmin},
+		},
+		{
+			name:       "peers claim null",
+			claims:     map[string]any{"peers": nil},
+			peersClaim: "peers",
+			expected:   &Identity{Subject: "sub", Role: RoleAdmin},
+		},
+		{
+			name:       "comma separated peers",
+			claims:     map[string]any{"peers": "pg, ch ,,sf"},
+			peersClaim: "peers",
+			expected:   &Identity{Subject: "sub", Role: RoleAdmin, Peers: []string{"pg", "ch", "sf"}},
+		},
+		{
+			name:       "list of peers",
+			claims:     map[string]any{"peers": []any{" pg", "", "ch"}},
+			peersClaim: "peers",
+			expected:   &Identity{Subject: "sub", Role: RoleAdmin, Peers: []string{"pg", "ch"}},
+		},
+		{
+			name:       "peers claim without peers",
+			claims:     map[string]any{"peers": " , "},
+			peersClaim: "peers",
+			wantErr:    true,
+		},
+		{
+			name:       "empty list of peers",
+			claims:     map[string]any{"peers": []any{}},
+			peersClaim: "peers",
+			wantErr:    true,
+		},
+		{
+			name:       "list with a peer that is not a string",
+			claims:     map[string]any{"peers": []any{"pg", 1.0}},
+			peersClaim: "peers",
+			wantErr:    true,
+		},
+		{
+			name:       "peers claim neither list nor string",
+			claims:     map[string]any{"peers": 1.0},
+			peersClaim: "peers",
+			wantErr:    true,
+		},
+		{
+			name:       "role and peers",
+			claims:     map[string]any{"role": "read_only", "peers": "pg"},
+			roleClaim:  "role",
+			peersClaim: "peers",
+			expected:   &Identity{Subject: "sub", Role: RoleReadOnly, Peers: []string{"pg"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := identityFromClaims("sub", tc.claims, tc.roleClaim, tc.peersClaim)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, identity)
+		})
+	}
+}
+
+func TestIdentityFromApiKey(t *testing.T) {
+	identity, err := identityFromApiKey(&internal.ApiKeyGrant{
+		Name: "ci", Role: "operator", Peers: []string{"pg"}, Mirrors: []string{"mirror"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Identity{Subject: "api-key:ci", Role: RoleOperator, Peers: []string{"pg"}, Mirrors: []string{"mirror"}}, identity)
+
+	_, err = identityFromApiKey(&internal.ApiKeyGrant{Name: "ci", Role: "owner"})
+	require.Error(t, err)
+}
+
+func TestIdentityScopes(t *testing.T) {
+	unscoped := &Identity{Subject: "sub"}
+	require.True(t, unscoped.CanManagePeer("pg"))
+	require.True(t, unscoped.CanManageMirror("mirror"))
+
+	scoped := &Identity{Subject: "sub", Peers: []string{"pg"}, Mirrors: []string{"mirror"}}
+	require.True(t, scoped.CanManagePeer("pg"))
+	require.False(t, scoped.CanManagePeer("ch"))
+	require.True(t, scoped.CanManageMirror("mirror"))
+	require.False(t, scoped.CanManageMirror("other"))
+}
+
+func TestMethodRoles(t *testing.T) {
+	tests := []struct {
+		method   string
+		expected Role
+	}{
+		{method: protos.FlowService_MirrorStatus_FullMethodName, expected: RoleReadOnly},
+		{method: protos.FlowService_ListPeers_FullMethodName, expected: RoleReadOnly},
+		{method: protos.FlowService_FlowStateChange_FullMethodName, expected: RoleOperator},
+		{method: protos.FlowService_CreateCDCFlow_FullMethodName, expected: RoleOperator},
+		{method: protos.FlowService_CreatePeer_FullMethodName, expected: RoleAdmin},
+		{method: protos.FlowService_CreateApiKey_FullMethodName, expected: RoleAdmin},
+		{method: "/peerdb_route.FlowService/Unknown", expected: RoleAdmin},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method, func(t *testing.T) {
+			require.Equal(t, tc.expected, MethodRole(tc.method))
+			for _, role := range []Role{RoleReadOnly, RoleOperator, RoleAdmin} {
+				err := authorizeMethod(&Identity{Subject: "sub", Role: role}, tc.method)
+				if role >= tc.expected {
+					require.NoError(t, err)
+				} else {
+					require.Equal(t, codes.PermissionDenied, status.Code(err))
+				}
+			}
+		})
+	}
+
+	// every role in the table is a known role, with a method of the service
+	methods := make(map[string]struct{})
+	for _, method := range protos.FlowService_ServiceDesc.Methods {
+		methods["/"+protos.FlowService_ServiceDesc.ServiceName+"/"+method.MethodName] = struct{}{}
+	}
+	for method, role := range methodRoles {
+		require.Contains(t, methods, method)
+		require.Contains(t, []Role{RoleReadOnly, RoleOperator}, role, method)
+	}
+}
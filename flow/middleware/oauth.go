@@ -17,6 +17,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
 //nolint:lll
@@ -34,7 +35,7 @@ type identityProvider struct {
 	issuer      string
 }
 
-func AuthGrpcMiddleware(unauthenticatedMethods []string) (grpc.UnaryServerInterceptor, error) {
+func AuthGrpcMiddleware(pool shared.CatalogPool, unauthenticatedMethods []string) (grpc.UnaryServerInterceptor, error) {
 	oauthConfig := internal.GetPeerDBOAuthConfig()
 	oauthJwtClaims := map[string]string{}
 	if oauthConfig.OAuthJwtClaimKey != "" {
@@ -58,6 +59,17 @@ func AuthGrpcMiddleware(unauthenticatedMethods []string) (grpc.UnaryServerInterc
 		slog.Warn("authentication is disabled")
 
 		return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			// API keys are still restricted to what they were granted
+			authHeaders := metadata.ValueFromIncomingContext(ctx, "Authorization")
+			if len(authHeaders) == 1 {
+				if key := strings.TrimPrefix(authHeaders[0], "Bearer "); internal.IsApiKey(key) {
+					identity, err := authenticateApiKey(ctx, pool, key, info.FullMethod)
+					if err != nil {
+						return nil, err
+					}
+					ctx = contextWithIdentity(ctx, identity)
+				}
+			}
 			return handler(ctx, req)
 		}, nil
 	}
@@ -80,18 +92,54 @@ func AuthGrpcMiddleware(unauthenticatedMethods []string) (grpc.UnaryServerInterc
 				slog.Warn("Multiple Authorization headers supplied, request rejected", slog.String("method", info.FullMethod))
 				return nil, status.Errorf(codes.Unauthenticated, "multiple Authorization headers supplied, request rejected")
 			}
-			_, err := validateRequestToken(authHeader, cfg.OauthJwtCustomClaims, ip...)
+			if key := strings.TrimPrefix(authHeader, "Bearer "); internal.IsApiKey(key) {
+				identity, err := authenticateApiKey(ctx, pool, key, info.FullMethod)
+				if err != nil {
+					return nil, err
+				}
+				return handler(contextWithIdentity(ctx, identity), req)
+			}
+			token, err := validateRequestToken(authHeader, cfg.OauthJwtCustomClaims, ip...)
 			if err != nil {
 				slog.Debug("Failed to validate request token", slog.String("method", info.FullMethod), slog.Any("error", err))
 				return nil, status.Error(codes.Unauthenticated, err.Error())
 			}
+			identity, err := identityFromClaims(token.Subject(), token.PrivateClaims(), oauthConfig.OAuthRoleClaim, oauthConfig.OAuthPeersClaim)
+			if err != nil {
+				slog.Debug("Failed to read identity from token", slog.String("method", info.FullMethod), slog.Any("error", err))
+				return nil, status.Error(codes.PermissionDenied, err.Error())
+			}
+			if err := authorizeMethod(identity, info.FullMethod); err != nil {
+				return nil, err
+			}
+			ctx = contextWithIdentity(ctx, identity)
 		}
 
 		return handler(ctx, req)
 	}, nil
 }
 
-func validateRequestToken(authHeader string, claims map[string]string, ip ...identityProvider) ([]byte, error) {
+// authenticateApiKey resolves a key to the identity it was granted and checks that may call fullMethod
+func authenticateApiKey(ctx context.Context, pool shared.CatalogPool, key string, fullMethod string) (*Identity, error) {
+	grant, err := internal.LookupApiKey(ctx, pool, key)
+	if err != nil {
+		slog.Debug("Failed to validate API key", slog.String("method", fullMethod), slog.Any("error", err))
+		if errors.Is(err, internal.ErrInvalidApiKey) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	identity, err := identityFromApiKey(grant)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err := authorizeMethod(identity, fullMethod); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func validateRequestToken(authHeader string, claims map[string]string, ip ...identityProvider) (jwt.Token, error) {
 	payload, err := jwtFromRequest(authHeader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse authorization header: %w", err)
@@ -123,7 +171,7 @@ func validateRequestToken(authHeader string, claims map[string]string, ip ...ide
 		}
 	}
 
-	return payload, nil
+	return token, nil
 }
 
 // jwtFromRequest extracts the JWT token from the Authorization header.
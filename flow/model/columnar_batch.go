@@ -0,0 +1,314 @@
+package model
+
+import (
+	"slices"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// ColumnarBatch holds rows column by column with one schema shared by all rows,
+// integer, float, string and boolean columns are kept as typed slices instead of a QValue per row and column.
+// It buffers rows on their way to destinations that write whole columns, e.g. ClickHouse native inserts,
+// CDC records still carry their values as RecordItems until they are synced
+type ColumnarBatch struct {
+	Schema  types.QRecordSchema
+	index   map[string]int
+	columns []columnVector
+	numRows int
+}
+
+type columnVector struct {
+	kind types.QValueKind
+	// nil until the column gets its first null
+	nulls  []bool
+	ints   []int64
+	floats []float64
+	strs   []string
+	bools  []bool
+	// columns of other kinds, or whose values do not match their kind, fall back to boxed values
+	values  []types.QValue
+	generic bool
+}
+
+func NewColumnarBatch(schema types.QRecordSchema, capacity int) *ColumnarBatch {
+	index := make(map[string]int, len(schema.Fields))
+	columns := make([]columnVector, len(schema.Fields))
+	for idx, field := range schema.Fields {
+		index[field.Name] = idx
+		columns[idx] = newColumnVector(field.Type, capacity)
+	}
+	return &ColumnarBatch{
+		Schema:  schema,
+		index:   index,
+		columns: columns,
+	}
+}
+
+func newColumnVector(kind types.QValueKind, capacity int) columnVector {
+	col := columnVector{kind: kind}
+	switch kind {
+	case types.QValueKindInt8, types.QValueKindInt16, types.QValueKindInt32, types.QValueKindInt64,
+		types.QValueKindUInt8, types.QValueKindUInt16, types.QValueKindUInt32, types.QValueKindUInt64:
+		col.ints = make([]int64, 0, capacity)
+	case types.QValueKindFloat32, types.QValueKindFloat64:
+		col.floats = make([]float64, 0, capacity)
+	case types.QValueKindString:
+		col.strs = make([]string, 0, capacity)
+	case types.QValueKindBoolean:
+		col.bools = make([]bool, 0, capacity)
+	default:
+		col.values = make([]types.QValue, 0, capacity)
+		col.generic = true
+	}
+	return col
+}
+
+func (b *ColumnarBatch) Len() int {
+	return b.numRows
+}
+
+// ColumnIndex returns the position of column name in the schema, or -1
+func (b *ColumnarBatch) ColumnIndex(name string) int {
+	if idx, ok := b.index[name]; ok {
+		return idx
+	}
+	return -1
+}
+
+// AppendRow copies the values of record, which must follow the batch schema
+func (b *ColumnarBatch) AppendRow(record []types.QValue) {
+	for idx := range b.columns {
+		var value types.QValue
+		if idx < len(record) {
+			value = record[idx]
+		}
+		b.columns[idx].append(b.numRows, value)
+	}
+	b.numRows += 1
+}
+
+// AppendItems adds a row from items, columns missing from items are null
+func (b *ColumnarBatch) AppendItems(items RecordItems) {
+	for idx, field := range b.Schema.Fields {
+		b.columns[idx].append(b.numRows, items.GetColumnValue(field.Name))
+	}
+	b.numRows += 1
+}
+
+// Value returns the value of column col of row as the QValue it was appended as
+func (b *ColumnarBatch) Value(row int, col int) types.QValue {
+	return b.columns[col].get(row)
+}
+
+// Column returns the values of column col as []int64, []float64, []string or []bool,
+// only for columns held as typed slices without nulls. Integers of every width are widened to int64,
+// unsigned integers keep their bits, and float32 values are widened to float64
+func (b *ColumnarBatch) Column(col int) (any, bool) {
+	c := &b.columns[col]
+	if c.generic || slices.Contains(c.nulls, true) {
+		return nil, false
+	}
+	switch {
+	case c.ints != nil:
+		return c.ints, true
+	case c.floats != nil:
+		return c.floats, true
+	case c.strs != nil:
+		return c.strs, true
+	case c.bools != nil:
+		return c.bools, true
+	default:
+		return nil, false
+	}
+}
+
+// Row fills dst with the values of row, allocating it when too short
+func (b *ColumnarBatch) Row(row int, dst []types.QValue) []types.QValue {
+	if cap(dst) < len(b.columns) {
+		dst = make([]types.QValue, len(b.columns))
+	}
+	dst = dst[:len(b.columns)]
+	for idx := range b.columns {
+		dst[idx] = b.columns[idx].get(row)
+	}
+	return dst
+}
+
+// Items adapts row for consumers of RecordItems
+func (b *ColumnarBatch) Items(row int) RecordItems {
+	items := NewRecordItems(len(b.columns))
+	for idx, field := range b.Schema.Fields {
+		items.AddColumn(field.Name, b.columns[idx].get(row))
+	}
+	return items
+}
+
+// Reset empties the batch, keeping the memory of its columns for the next rows
+func (b *ColumnarBatch) Reset() {
+	for idx := range b.columns {
+		col := &b.columns[idx]
+		col.nulls = col.nulls[:0]
+		col.ints = col.ints[:0]
+		col.floats = col.floats[:0]
+		col.strs = col.strs[:0]
+		col.bools = col.bools[:0]
+		clear(col.values)
+		col.values = col.values[:0]
+	}
+	b.numRows = 0
+}
+
+func (b *ColumnarBatch) ToQRecordBatch() *QRecordBatch {
+	records := make([][]types.QValue, 0, b.numRows)
+	for row := range b.numRows {
+		records = append(records, b.Row(row, nil))
+	}
+	return &QRecordBatch{
+		Schema:  b.Schema,
+		Records: records,
+	}
+}
+
+func (b *ColumnarBatch) ToQRecordStream(buffer int) *QRecordStream {
+	stream := NewQRecordStream(min(buffer, b.numRows))
+	go b.FeedToQRecordStream(stream)
+	return stream
+}
+
+func (b *ColumnarBatch) FeedToQRecordStream(stream *QRecordStream) {
+	stream.SetSchema(b.Schema)
+
+	for row := range b.numRows {
+		stream.Records <- b.Row(row, nil)
+	}
+	close(stream.Records)
+}
+
+func (q *QRecordBatch) ToColumnarBatch() *ColumnarBatch {
+	batch := NewColumnarBatch(q.Schema, len(q.Records))
+	for _, record := range q.Records {
+		batch.AppendRow(record)
+	}
+	return batch
+}
+
+func (c *columnVector) append(row int, value types.QValue) {
+	if value == nil {
+		value = types.QValueNull(c.kind)
+	}
+	if c.generic {
+		c.values = append(c.values, value)
+		return
+	}
+	null, ok := value.(types.QValueNull)
+	if ok && types.QValueKind(null) == c.kind {
+		if c.nulls == nil {
+			c.nulls = make([]bool, row, max(row, cap(c.ints), cap(c.floats), cap(c.strs), cap(c.bools)))
+		}
+		c.nulls = append(c.nulls, true)
+		c.appendZero()
+		return
+	}
+	if !c.appendTyped(value) {
+		c.toGeneric(row)
+		c.values = append(c.values, value)
+		return
+	}
+	if c.nulls != nil {
+		c.nulls = append(c.nulls, false)
+	}
+}
+
+func (c *columnVector) appendZero() {
+	switch {
+	case c.ints != nil:
+		c.ints = append(c.ints, 0)
+	case c.floats != nil:
+		c.floats = append(c.floats, 0)
+	case c.strs != nil:
+		c.strs = append(c.strs, "")
+	case c.bools != nil:
+		c.bools = append(c.bools, false)
+	}
+}
+
+func (c *columnVector) appendTyped(value types.QValue) bool {
+	if value.Kind() != c.kind {
+		return false
+	}
+	switch v := value.(type) {
+	case types.QValueInt8:
+		c.ints = append(c.ints, int64(v.Val))
+	case types.QValueInt16:
+		c.ints = append(c.ints, int64(v.Val))
+	case types.QValueInt32:
+		c.ints = append(c.ints, int64(v.Val))
+	case types.QValueInt64:
+		c.ints = append(c.ints, v.Val)
+	case types.QValueUInt8:
+		c.ints = append(c.ints, int64(v.Val))
+	case types.QValueUInt16:
+		c.ints = append(c.ints, int64(v.Val))
+	case types.QValueUInt32:
+		c.ints = append(c.ints, int64(v.Val))
+	case types.QValueUInt64:
+		c.ints = append(c.ints, int64(v.Val))
+	case types.QValueFloat32:
+		c.floats = append(c.floats, float64(v.Val))
+	case types.QValueFloat64:
+		c.floats = append(c.floats, v.Val)
+	case types.QValueString:
+		c.strs = append(c.strs, v.Val)
+	case types.QValueBoolean:
+		c.bools = append(c.bools, v.Val)
+	default:
+		return false
+	}
+	return true
+}
+
+// toGeneric boxes the first rows values of a typed column, for when a value does not match its kind
+func (c *columnVector) toGeneric(rows int) {
+	values := make([]types.QValue, 0, max(rows+1, cap(c.ints), cap(c.floats), cap(c.strs), cap(c.bools)))
+	for row := range rows {
+		values = append(values, c.get(row))
+	}
+	*c = columnVector{kind: c.kind, values: values, generic: true}
+}
+
+func (c *columnVector) get(row int) types.QValue {
+	if c.generic {
+		return c.values[row]
+	}
+	if c.nulls != nil && c.nulls[row] {
+		return types.QValueNull(c.kind)
+	}
+	switch c.kind {
+	case types.QValueKindInt8:
+		return types.QValueInt8{Val: int8(c.ints[row])}
+	case types.QValueKindInt16:
+		return types.QValueInt16{Val: int16(c.ints[row])}
+	case types.QValueKindInt32:
+		return types.QValueInt32{Val: int32(c.ints[row])}
+	case types.QValueKindInt64:
+		return types.QValueInt64{Val: c.ints[row]}
+	case types.QValueKindUInt8:
+		return types.QValueUInt8{Val: uint8(c.ints[row])}
+	case types.QValueKindUInt16:
+		return types.QValueUInt16{Val: uint16(c.ints[row])}
+	case types.QValueKindUInt32:
+		return types.QValueUInt32{Val: uint32(c.ints[row])}
+	case types.QValueKindUInt64:
+		return types.QValueUInt64{Val: uint64(c.ints[row])}
+	case types.QValueKindFloat32:
+		return types.QValueFloat32{Val: float32(c.floats[row])}
+	case types.QValueKindFloat64:
+		return types.QValueFloat64{Val: c.floats[row]}
+	case types.QValueKindString:
+		return types.QValueString{Val: c.strs[row]}
+	case types.QValueKindBoolean:
+		return types.QValueBoolean{Val: c.bools[row]}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestColumnarBatch(t *testing.T) {
+	schema := types.NewQRecordSchema([]types.QField{
+		{Name: "id", Type: types.QValueKindInt32},
+		{Name: "ratio", Type: types.QValueKindFloat32, Nullable: true},
+		{Name: "name", Type: types.QValueKindString, Nullable: true},
+		{Name: "big", Type: types.QValueKindUInt64},
+		{Name: "payload", Type: types.QValueKindJSON},
+	})
+	rows := [][]types.QValue{
+		{
+			types.QValueInt32{Val: 1}, types.QValueFloat32{Val: 0.5}, types.QValueString{Val: "a"},
+			types.QValueUInt64{Val: 1 << 63}, types.QValueJSON{Val: "{}"},
+		},
+		{
+			types.QValueInt32{Val: -2}, types.QValueNull(types.QValueKindFloat32), types.QValueString{Val: "b"},
+			types.QValueUInt64{Val: 2}, types.QValueNull(types.QValueKindJSON),
+		},
+		{
+			// a value not matching its column kind moves the column to boxed values
+			types.QValueInt32{Val: 3}, types.QValueFloat32{Val: 1.25}, types.QValueQChar{Val: 'c'},
+			types.QValueUInt64{Val: 3}, types.QValueJSON{Val: "[]", IsArray: true},
+		},
+	}
+
+	batch := NewColumnarBatch(schema, 2)
+	for _, row := range rows {
+		batch.AppendRow(row)
+	}
+	require.Equal(t, len(rows), batch.Len())
+	for idx, row := range rows {
+		require.Equal(t, row, batch.Row(idx, nil))
+	}
+	require.Equal(t, types.QValueString{Val: "b"}, batch.Items(1).GetColumnValue("name"))
+	require.Equal(t, 3, batch.ColumnIndex("big"))
+	require.Equal(t, -1, batch.ColumnIndex("missing"))
+	require.Equal(t, rows, batch.ToQRecordBatch().Records)
+
+	ids, ok := batch.Column(0)
+	require.True(t, ok)
+	require.Equal(t, []int64{1, -2, 3}, ids)
+	bigs, ok := batch.Column(3)
+	require.True(t, ok)
+	require.Equal(t, []int64{-1 << 63, 2, 3}, bigs)
+	// columns with nulls or boxed values
+	for _, col := range []int{1, 2, 4} {
+		_, ok := batch.Column(col)
+		require.False(t, ok)
+	}
+
+	batch.Reset()
+	require.Equal(t, 0, batch.Len())
+	items := NewRecordItems(1)
+	items.AddColumn("id", types.QValueInt32{Val: 4})
+	batch.AppendItems(items)
+	require.Equal(t, types.QValueInt32{Val: 4}, batch.Value(0, 0))
+	require.Equal(t, types.QValueNull(types.QValueKindString), batch.Value(0, 2))
+	// nulls cleared by reset no longer keep the column from being handed out whole
+	batch.Reset()
+	batch.AppendRow(rows[0])
+	ratios, ok := batch.Column(1)
+	require.True(t, ok)
+	require.Equal(t, []float64{0.5}, ratios)
+}
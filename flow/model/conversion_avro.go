@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/hamba/avro/v2"
@@ -19,6 +20,7 @@ type QRecordAvroConverter struct {
 	Schema                   *QRecordAvroSchemaDefinition
 	ColNames                 []string
 	TargetDWH                protos.DBType
+	NumericOverflow          qvalue.NumericOverflowPolicy
 	UnboundedNumericAsString bool
 }
 
@@ -39,11 +41,23 @@ func NewQRecordAvroConverter(
 		}
 	}
 
+	numericOverflow := qvalue.NumericOverflowNull
+	if slices.ContainsFunc(schema.Fields, func(field types.QField) bool {
+		return field.Type == types.QValueKindNumeric || field.Type == types.QValueKindArrayNumeric
+	}) {
+		var err error
+		numericOverflow, err = qvalue.NumericOverflowPolicyFromEnv(ctx, env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &QRecordAvroConverter{
 		Schema:                   schema,
 		TargetDWH:                targetDWH,
 		ColNames:                 colNames,
 		logger:                   logger,
+		NumericOverflow:          numericOverflow,
 		UnboundedNumericAsString: unboundedNumericAsString,
 	}, nil
 }
@@ -62,7 +76,7 @@ func (qac *QRecordAvroConverter) Convert(
 		}
 		avroVal, err := qvalue.QValueToAvro(
 			ctx, env, val,
-			&qac.Schema.Fields[idx], qac.TargetDWH, qac.logger, qac.UnboundedNumericAsString,
+			&qac.Schema.Fields[idx], qac.TargetDWH, qac.logger, qac.UnboundedNumericAsString, qac.NumericOverflow,
 			numericTruncator.Get(idx),
 		)
 		if err != nil {
@@ -75,6 +89,136 @@ func (qac *QRecordAvroConverter) Convert(
 	return m, nil
 }
 
+// Encode writes qrecord to w in the binary encoding of the record schema without building a map per record,
+// values of primitive fields are written directly and others are converted by QValueToAvro
+func (qac *QRecordAvroConverter) Encode(
+	ctx context.Context,
+	env map[string]string,
+	w *avro.Writer,
+	qrecord []types.QValue,
+	typeConversions map[string]types.TypeConversion,
+	numericTruncator *SnapshotTableNumericTruncator,
+) error {
+	avroFields := qac.Schema.Schema.Fields()
+	for idx, val := range qrecord {
+		field := &qac.Schema.Fields[idx]
+		if typeConversion, ok := typeConversions[field.Name]; ok {
+			val = typeConversion.ValueConversion(val)
+		}
+		fieldSchema := avroFields[idx].Type()
+		if !qac.encodePrimitive(w, fieldSchema, val) {
+			avroVal, err := qvalue.QValueToAvro(
+				ctx, env, val,
+				field, qac.TargetDWH, qac.logger, qac.UnboundedNumericAsString, qac.NumericOverflow,
+				numericTruncator.Get(idx),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to convert QValue to Avro-compatible value: %w", err)
+			}
+			w.WriteVal(fieldSchema, avroVal)
+		}
+		if w.Error != nil {
+			return fmt.Errorf("failed to encode %s: %w", field.Name, w.Error)
+		}
+	}
+	return nil
+}
+
+// encodePrimitive writes val when both it and schema are primitives without logical type,
+// producing the same bytes as encoding the value of QValueToAvro, and reports whether it did
+func (qac *QRecordAvroConverter) encodePrimitive(w *avro.Writer, schema avro.Schema, val types.QValue) bool {
+	nullable := false
+	if union, ok := schema.(*avro.UnionSchema); ok {
+		branches := union.Types()
+		if len(branches) != 2 || branches[0].Type() != avro.Null {
+			return false
+		}
+		nullable = true
+		schema = branches[1]
+	}
+	primitive, ok := schema.(*avro.PrimitiveSchema)
+	if !ok || primitive.Logical() != nil {
+		return false
+	}
+	if nullable && val.Value() == nil {
+		w.WriteLong(0)
+		return true
+	}
+
+	// union branch of the value, only written once the value is known to be encodable
+	branch := func() {
+		if nullable {
+			w.WriteLong(1)
+		}
+	}
+	switch primitive.Type() {
+	case avro.Long:
+		var i int64
+		switch v := val.(type) {
+		case types.QValueInt8:
+			i = int64(v.Val)
+		case types.QValueInt16:
+			i = int64(v.Val)
+		case types.QValueInt32:
+			i = int64(v.Val)
+		case types.QValueInt64:
+			i = v.Val
+		case types.QValueUInt8:
+			i = int64(v.Val)
+		case types.QValueUInt16:
+			i = int64(v.Val)
+		case types.QValueUInt32:
+			i = int64(v.Val)
+		case types.QValueUInt64:
+			i = int64(v.Val)
+		default:
+			return false
+		}
+		branch()
+		w.WriteLong(i)
+		return true
+	case avro.Double:
+		switch v := val.(type) {
+		case types.QValueFloat64:
+			branch()
+			w.WriteDouble(v.Val)
+			return true
+		case types.QValueFloat32:
+			branch()
+			w.WriteDouble(float64(v.Val))
+			return true
+		}
+	case avro.Float:
+		if v, ok := val.(types.QValueFloat32); ok {
+			branch()
+			w.WriteFloat(v.Val)
+			return true
+		}
+	case avro.Boolean:
+		if v, ok := val.(types.QValueBoolean); ok {
+			branch()
+			w.WriteBool(v.Val)
+			return true
+		}
+	case avro.String:
+		switch v := val.(type) {
+		case types.QValueString:
+			// large values are cleared for Snowflake by QValueToAvro
+			if qac.TargetDWH == protos.DBType_SNOWFLAKE && len(v.Val) > 15*1024*1024 {
+				return false
+			}
+			branch()
+			w.WriteString(v.Val)
+			return true
+		case types.QValueQChar:
+			branch()
+			w.WriteString(string(v.Val))
+			return true
+		}
+	}
+	return false
+}
+
 type QRecordAvroField struct {
 	Type any    `json:"type"`
 	Name string `json:"name"`
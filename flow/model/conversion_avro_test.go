@@ -0,0 +1,89 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func avroEncodeTestData(t testing.TB) (*QRecordAvroConverter, [][]types.QValue) {
+	t.Helper()
+	schema := types.NewQRecordSchema([]types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "small", Type: types.QValueKindInt16, Nullable: true},
+		{Name: "ratio", Type: types.QValueKindFloat32, Nullable: true},
+		{Name: "price", Type: types.QValueKindFloat64},
+		{Name: "flag", Type: types.QValueKindBoolean, Nullable: true},
+		{Name: "name", Type: types.QValueKindString, Nullable: true},
+		{Name: "code", Type: types.QValueKindQChar},
+		{Name: "ts", Type: types.QValueKindTimestamp, Nullable: true},
+		{Name: "doc", Type: types.QValueKindJSON, Nullable: true},
+		{Name: "uid", Type: types.QValueKindUUID},
+		{Name: "tags", Type: types.QValueKindArrayString, Nullable: true},
+	})
+	avroSchema, err := GetAvroSchemaDefinition(t.Context(), nil, "test", schema, protos.DBType_BIGQUERY, nil)
+	require.NoError(t, err)
+	converter, err := NewQRecordAvroConverter(t.Context(), nil, avroSchema, protos.DBType_BIGQUERY, schema.GetColumnNames(), nil)
+	require.NoError(t, err)
+
+	records := [][]types.QValue{
+		{
+			types.QValueInt64{Val: 1}, types.QValueInt16{Val: -7}, types.QValueFloat32{Val: 1.5}, types.QValueFloat64{Val: 2.25},
+			types.QValueBoolean{Val: true}, types.QValueString{Val: "peerdb"}, types.QValueQChar{Val: 'x'},
+			types.QValueTimestamp{Val: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}, types.QValueJSON{Val: `{"a":1}`},
+			types.QValueUUID{Val: uuid.New()}, types.QValueArrayString{Val: []string{"a", "b"}},
+		},
+		{
+			types.QValueInt64{Val: -1 << 40}, types.QValueNull(types.QValueKindInt16), types.QValueNull(types.QValueKindFloat32),
+			types.QValueFloat64{Val: 0}, types.QValueNull(types.QValueKindBoolean), types.QValueNull(types.QValueKindString),
+			types.QValueQChar{Val: 'y'}, types.QValueNull(types.QValueKindTimestamp), types.QValueNull(types.QValueKindJSON),
+			types.QValueUUID{Val: uuid.New()}, types.QValueNull(types.QValueKindArrayString),
+		},
+	}
+	return converter, records
+}
+
+func TestAvroEncodeMatchesConvert(t *testing.T) {
+	converter, records := avroEncodeTestData(t)
+	w := avro.NewWriter(nil, 0)
+	for _, record := range records {
+		m, err := converter.Convert(t.Context(), nil, record, nil, nil)
+		require.NoError(t, err)
+		expected, err := avro.Marshal(converter.Schema.Schema, m)
+		require.NoError(t, err)
+
+		w.Reset(nil)
+		require.NoError(t, converter.Encode(t.Context(), nil, w, record, nil, nil))
+		require.Equal(t, expected, w.Buffer())
+	}
+}
+
+func BenchmarkAvroConvert(b *testing.B) {
+	converter, records := avroEncodeTestData(b)
+	for b.Loop() {
+		m, err := converter.Convert(b.Context(), nil, records[0], nil, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := avro.Marshal(converter.Schema.Schema, m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAvroEncode(b *testing.B) {
+	converter, records := avroEncodeTestData(b)
+	w := avro.NewWriter(nil, 4096)
+	for b.Loop() {
+		w.Reset(nil)
+		if err := converter.Encode(b.Context(), nil, w, records[0], nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// JSONPathExtractor derives the json path columns of a table from the json columns of its rows
+type JSONPathExtractor struct {
+	columns []jsonPathColumn
+	// parsed documents of the row being extracted, keyed by source column
+	docs map[string]any
+}
+
+type jsonPathColumn struct {
+	source string
+	name   string
+	keys   []string
+	kind   types.QValueKind
+}
+
+// NewJSONPathExtractor returns nil when there are no columns to extract
+func NewJSONPathExtractor(columns []*protos.JsonPathColumn) (*JSONPathExtractor, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	extractor := &JSONPathExtractor{
+		columns: make([]jsonPathColumn, 0, len(columns)),
+		docs:    make(map[string]any),
+	}
+	for _, column := range columns {
+		if column.SourceName == "" || column.DestinationName == "" || column.Path == "" {
+			return nil, fmt.Errorf("json path column needs a source column, a path and a destination column, got %v", column)
+		}
+		kind := JSONPathColumnKind(column)
+		switch kind {
+		case types.QValueKindString, types.QValueKindJSON, types.QValueKindBoolean,
+			types.QValueKindInt16, types.QValueKindInt32, types.QValueKindInt64,
+			types.QValueKindFloat32, types.QValueKindFloat64, types.QValueKindNumeric,
+			types.QValueKindTimestamp, types.QValueKindTimestampTZ, types.QValueKindDate, types.QValueKindUUID:
+		default:
+			return nil, fmt.Errorf("unsupported type %s for json path column %s", kind, column.DestinationName)
+		}
+		extractor.columns = append(extractor.columns, jsonPathColumn{
+			source: column.SourceName,
+			name:   column.DestinationName,
+			keys:   strings.Split(column.Path, "."),
+			kind:   kind,
+		})
+	}
+	return extractor, nil
+}
+
+func JSONPathColumnKind(column *protos.JsonPathColumn) types.QValueKind {
+	if column.Type == "" {
+		return types.QValueKindString
+	}
+	return types.QValueKind(column.Type)
+}
+
+func (e *JSONPathExtractor) Fields() []types.QField {
+	fields := make([]types.QField, 0, len(e.columns))
+	for _, column := range e.columns {
+		fields = append(fields, types.QField{
+			Name:     column.name,
+			Type:     column.kind,
+			Nullable: true,
+		})
+	}
+	return fields
+}
+
+// Names of the derived columns
+func (e *JSONPathExtractor) Names() []string {
+	names := make([]string, 0, len(e.columns))
+	for _, column := range e.columns {
+		names = append(names, column.name)
+	}
+	return names
+}
+
+// Extract appends the values of the derived columns to values, in the order of Fields,
+// getValue returns the value of a source column of the row or nil
+func (e *JSONPathExtractor) Extract(values []types.QValue, getValue func(string) types.QValue) []types.QValue {
+	clear(e.docs)
+	for _, column := range e.columns {
+		doc, ok := e.docs[column.source]
+		if !ok {
+			doc = parseJSONDocument(getValue(column.source))
+			e.docs[column.source] = doc
+		}
+		values = append(values, column.extract(doc))
+	}
+	return values
+}
+
+// ExtractItems adds the derived columns to items, a row without its source columns,
+// like the old row of an update without replica identity full, is left as is
+func (e *JSONPathExtractor) ExtractItems(items RecordItems) RecordItems {
+	if items.ColToVal == nil {
+		return items
+	}
+	values := e.Extract(nil, items.GetColumnValue)
+	for idx, column := range e.columns {
+		if _, ok := items.ColToVal[column.source]; ok {
+			items.AddColumn(column.name, values[idx])
+		}
+	}
+	return items
+}
+
+// MarkUnchanged adds the derived columns of unchanged toast source columns to unchanged
+func (e *JSONPathExtractor) MarkUnchanged(unchanged map[string]struct{}) {
+	if len(unchanged) == 0 {
+		return
+	}
+	for _, column := range e.columns {
+		if _, ok := unchanged[column.source]; ok {
+			unchanged[column.name] = struct{}{}
+		}
+	}
+}
+
+func parseJSONDocument(value types.QValue) any {
+	var raw string
+	switch v := value.(type) {
+	case types.QValueJSON:
+		raw = v.Val
+	case types.QValueString:
+		raw = v.Val
+	default:
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+func (c *jsonPathColumn) extract(doc any) types.QValue {
+	for _, key := range c.keys {
+		switch node := doc.(type) {
+		case map[string]any:
+			doc = node[key]
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return types.QValueNull(c.kind)
+			}
+			doc = node[idx]
+		default:
+			return types.QValueNull(c.kind)
+		}
+	}
+	if doc == nil {
+		return types.QValueNull(c.kind)
+	}
+	if value := convertJSONPathValue(c.kind, doc); value != nil {
+		return value
+	}
+	return types.QValueNull(c.kind)
+}
+
+// QValueFromJSON converts a value decoded from JSON with numbers as json.Number to kind,
+// returning nil when it does not convert
+func QValueFromJSON(kind types.QValueKind, doc any) types.QValue {
+	return convertJSONPathValue(kind, doc)
+}
+
+// convertJSONPathValue returns nil when doc does not convert to kind
+func convertJSONPathValue(kind types.QValueKind, doc any) types.QValue {
+	str, isString := doc.(string)
+	if !isString {
+		if number, ok := doc.(json.Number); ok {
+			str = number.String()
+		}
+	}
+
+	switch kind {
+	case types.QValueKindString:
+		if _, ok := doc.(string); ok {
+			return types.QValueString{Val: str}
+		}
+		raw, err := marshalJSONPathValue(doc)
+		if err != nil {
+			return nil
+		}
+		return types.QValueString{Val: raw}
+	case types.QValueKindJSON:
+		raw, err := marshalJSONPathValue(doc)
+		if err != nil {
+			return nil
+		}
+		_, isArray := doc.([]any)
+		return types.QValueJSON{Val: raw, IsArray: isArray}
+	case types.QValueKindBoolean:
+		if b, ok := doc.(bool); ok {
+			return types.QValueBoolean{Val: b}
+		}
+		if b, err := strconv.ParseBool(str); err == nil {
+			return types.QValueBoolean{Val: b}
+		}
+	case types.QValueKindInt16:
+		if i, err := strconv.ParseInt(str, 10, 16); err == nil {
+			return types.QValueInt16{Val: int16(i)}
+		}
+	case types.QValueKindInt32:
+		if i, err := strconv.ParseInt(str, 10, 32); err == nil {
+			return types.QValueInt32{Val: int32(i)}
+		}
+	case types.QValueKindInt64:
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return types.QValueInt64{Val: i}
+		}
+	case types.QValueKindFloat32:
+		if f, err := strconv.ParseFloat(str, 32); err == nil {
+			return types.QValueFloat32{Val: float32(f)}
+		}
+	case types.QValueKindFloat64:
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return types.QValueFloat64{Val: f}
+		}
+	case types.QValueKindNumeric:
+		if d, err := decimal.NewFromString(str); err == nil {
+			return types.QValueNumeric{Val: d}
+		}
+	case types.QValueKindTimestamp:
+		if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			return types.QValueTimestamp{Val: t.UTC()}
+		}
+	case types.QValueKindTimestampTZ:
+		if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			return types.QValueTimestampTZ{Val: t.UTC()}
+		}
+	case types.QValueKindDate:
+		if t, err := time.Parse(time.DateOnly, str); err == nil {
+			return types.QValueDate{Val: t}
+		}
+	case types.QValueKindUUID:
+		if isString {
+			if u, err := uuid.Parse(str); err == nil {
+				return types.QValueUUID{Val: u}
+			}
+		}
+	}
+	return nil
+}
+
+func marshalJSONPathValue(doc any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
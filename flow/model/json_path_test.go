@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestJSONPathExtractor(t *testing.T) {
+	extractor, err := NewJSONPathExtractor([]*protos.JsonPathColumn{
+		{SourceName: "payload", Path: "customer.id", DestinationName: "customer_id", Type: string(types.QValueKindInt64)},
+		{SourceName: "payload", Path: "customer.name", DestinationName: "customer_name"},
+		{SourceName: "payload", Path: "items.1.price", DestinationName: "second_price", Type: string(types.QValueKindNumeric)},
+		{SourceName: "payload", Path: "customer", DestinationName: "customer", Type: string(types.QValueKindJSON)},
+		{SourceName: "payload", Path: "customer.missing", DestinationName: "missing", Type: string(types.QValueKindBoolean)},
+		{SourceName: "payload", Path: "customer.name", DestinationName: "mistyped", Type: string(types.QValueKindInt32)},
+	})
+	require.NoError(t, err)
+
+	items := NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	items.AddColumn("payload", types.QValueJSON{
+		Val: `{"customer":{"id":12345678901,"name":"ada"},"items":[{"price":1.5},{"price":2.25}]}`,
+	})
+	items = extractor.ExtractItems(items)
+	require.Equal(t, types.QValueInt64{Val: 12345678901}, items.GetColumnValue("customer_id"))
+	require.Equal(t, types.QValueString{Val: "ada"}, items.GetColumnValue("customer_name"))
+	require.Equal(t, types.QValueNumeric{Val: decimal.RequireFromString("2.25")}, items.GetColumnValue("second_price"))
+	require.Equal(t, types.QValueJSON{Val: `{"id":12345678901,"name":"ada"}`}, items.GetColumnValue("customer"))
+	require.Equal(t, types.QValueNull(types.QValueKindBoolean), items.GetColumnValue("missing"))
+	require.Equal(t, types.QValueNull(types.QValueKindInt32), items.GetColumnValue("mistyped"))
+
+	// rows without the source column, like old rows of updates, are left as is
+	oldItems := NewRecordItems(1)
+	oldItems.AddColumn("id", types.QValueInt64{Val: 1})
+	oldItems = extractor.ExtractItems(oldItems)
+	require.Len(t, oldItems.ColToVal, 1)
+
+	unchanged := map[string]struct{}{"payload": {}}
+	extractor.MarkUnchanged(unchanged)
+	require.Contains(t, unchanged, "customer_id")
+	require.Contains(t, unchanged, "mistyped")
+
+	record := extractor.Extract([]types.QValue{types.QValueString{Val: `{"customer":null}`}}, func(string) types.QValue {
+		return types.QValueString{Val: `{"customer":null}`}
+	})
+	require.Len(t, record, 7)
+	require.Equal(t, types.QValueNull(types.QValueKindInt64), record[1])
+
+	_, err = NewJSONPathExtractor([]*protos.JsonPathColumn{
+		{SourceName: "payload", Path: "customer.id", DestinationName: "customer_id", Type: string(types.QValueKindArrayInt64)},
+	})
+	require.Error(t, err)
+}
@@ -15,7 +15,12 @@ import (
 
 type NameAndExclude struct {
 	Exclude map[string]struct{}
+	// destination columns derived from source columns, like json path columns, not present at the source
+	Derived map[string]struct{}
 	Name    string
+	// handling of generated and identity columns, the source adds the columns not replicated to Exclude
+	GeneratedColumns protos.GeneratedColumnPolicy
+	IdentityColumns  protos.GeneratedColumnPolicy
 }
 
 func NewNameAndExclude(name string, exclude []string) NameAndExclude {
@@ -33,6 +38,24 @@ type RecordTypeCounts struct {
 	InsertCount atomic.Int32
 	UpdateCount atomic.Int32
 	DeleteCount atomic.Int32
+	// records with values over the size limit of the destination
+	OversizedCount atomic.Int32
+	// records with NUMERIC values too big for their destination column
+	NumericOverflowCount atomic.Int32
+	// source commit time of the oldest record counted, 0 if none
+	OldestCommitTimeNano atomic.Int64
+}
+
+func (c *RecordTypeCounts) observeCommitTime(commitTimeNano int64) {
+	if commitTimeNano == 0 {
+		return
+	}
+	for {
+		oldest := c.OldestCommitTimeNano.Load()
+		if (oldest != 0 && oldest <= commitTimeNano) || c.OldestCommitTimeNano.CompareAndSwap(oldest, commitTimeNano) {
+			return
+		}
+	}
 }
 
 type RecordsToStreamRequest[T Items] struct {
@@ -86,15 +109,32 @@ type PullRecordsRequest[T Items] struct {
 	LastOffset CdcCheckpoint
 	// MaxBatchSize is the max number of records to fetch.
 	MaxBatchSize uint32
+	// MaxBatchBytes is the max size of source changes to fetch, 0 for no limit.
+	MaxBatchBytes uint64
 	// peerdb versioning to prevent breaking changes
 	InternalVersion uint32
 	// IdleTimeout is the timeout to wait for new records.
 	IdleTimeout time.Duration
+	// MessageDestination is where logical messages are forwarded to as records, empty only passes them
+	// to the destination along with pending records, for acknowledgement
+	MessageDestination string
+	// PropagateDroppedColumns adds dropped columns to schema deltas, only set for queues,
+	// other destinations keep writing dropped columns as null so their schema mapping must keep them
+	PropagateDroppedColumns bool
 }
 
 type ToJSONOptions struct {
 	UnnestColumns map[string]struct{}
-	HStoreAsJSON  bool
+	// Spill stores text, json and hstore values over MaxValueSize bytes for the spill policy
+	Spill ValueSpiller
+	// Oversized counts values over MaxValueSize when set
+	Oversized *atomic.Int64
+	// 0 uses DefaultMaxJSONValueSize, negative keeps values of any size
+	MaxValueSize    int
+	OversizedPolicy OversizedValuePolicy
+	HStoreAsJSON    bool
+	// GeoJSON outputs geometry, geography and point values as GeoJSON objects instead of WKT
+	GeoJSON bool
 }
 
 func NewToJSONOptions(unnestCols []string, hstoreAsJSON bool) ToJSONOptions {
@@ -152,6 +192,8 @@ type SyncRecordsRequest[T Items] struct {
 	StagingPath string
 	// Lua script
 	Script string
+	// hive-style prefixes of files written to S3
+	S3Partition *protos.S3PartitionConfig
 	// source:destination mappings
 	TableMappings []*protos.TableMapping
 	SyncBatchID   int64
@@ -169,6 +211,29 @@ type NormalizeRecordsRequest struct {
 	Version                uint32
 }
 
+// ReplayStagedBatchesRequest asks a destination to re-apply already synced batches
+// in the inclusive range [StartBatchID, EndBatchID] from whatever it retained of them.
+type ReplayStagedBatchesRequest struct {
+	Env                    map[string]string
+	TableNameSchemaMapping map[string]*protos.TableSchema
+	FlowJobName            string
+	TableMappings          []*protos.TableMapping
+	StartBatchID           int64
+	EndBatchID             int64
+	Version                uint32
+}
+
+// DeduplicateBatchesRequest asks a destination to remove rows that were applied more than once
+// for batches in the inclusive range [StartBatchID, EndBatchID], e.g. after a sync was interrupted.
+type DeduplicateBatchesRequest struct {
+	Env                    map[string]string
+	TableNameSchemaMapping map[string]*protos.TableSchema
+	FlowJobName            string
+	TableMappings          []*protos.TableMapping
+	StartBatchID           int64
+	EndBatchID             int64
+}
+
 //nolint:govet // no need to save on fieldalignment
 type SyncResponse struct {
 	// TableNameRowsMapping tells how many records need to be synced to each destination table.
@@ -184,8 +249,22 @@ type SyncResponse struct {
 }
 
 type NormalizeResponse struct {
-	StartBatchID int64
-	EndBatchID   int64
+	// conflicts detected while normalizing, up to a limit per table
+	Conflicts     []NormalizeConflict
+	StartBatchID  int64
+	EndBatchID    int64
+	ConflictCount int64
+}
+
+// NormalizeConflict is a change to a destination row that was also modified out-of-band
+type NormalizeConflict struct {
+	DestinationTableName string
+	// primary key of the row as json
+	PrimaryKey string
+	Policy     protos.ConflictPolicy
+	// 0 for inserts, 1 for updates and 2 for deletes
+	RecordType    int32
+	SourceApplied bool
 }
 
 type RelationMessageMapping map[uint32]*pglogrepl.RelationMessage
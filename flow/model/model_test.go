@@ -2,10 +2,13 @@ package model
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 func TestCdcStreamGetLastCheckpointPanic(t *testing.T) {
@@ -34,3 +37,65 @@ func TestJsonOptionsUnnestCols(t *testing.T) {
 	require.True(t, ok1)
 	require.False(t, ok2)
 }
+
+func TestRecordTypeCountsOldestCommitTime(t *testing.T) {
+	counts := map[string]*RecordTypeCounts{"t": {}}
+	for _, commitTime := range []int64{30, 10, 0, 20} {
+		(&InsertRecord[RecordItems]{DestinationTableName: "t", BaseRecord: BaseRecord{CommitTimeNano: commitTime}}).PopulateCountMap(counts)
+	}
+	require.Equal(t, int32(4), counts["t"].InsertCount.Load())
+	require.Equal(t, int64(10), counts["t"].OldestCommitTimeNano.Load())
+}
+
+type testSpiller map[string]string
+
+func (s testSpiller) Spill(_ context.Context, column string, value string) (string, error) {
+	s[column] = value
+	return "spilled://" + column, nil
+}
+
+func TestRecordItemsOversizedValues(t *testing.T) {
+	items := NewRecordItems(3)
+	items.AddColumn("small", types.QValueString{Val: "ok"})
+	items.AddColumn("big", types.QValueString{Val: "0123456789"})
+	items.AddColumn("doc", types.QValueJSON{Val: `{"k":"0123456789"}`})
+
+	counter := &atomic.Int64{}
+	opts := NewToJSONOptions(nil, true)
+	opts.MaxValueSize = 5
+	opts.Oversized = counter
+	cleared, err := items.toMap(t.Context(), opts)
+	require.NoError(t, err)
+	require.Equal(t, "ok", cleared["small"])
+	require.Empty(t, cleared["big"])
+	require.Equal(t, "{}", cleared["doc"])
+	require.Equal(t, int64(2), counter.Load())
+
+	spiller := testSpiller{}
+	opts.Spill = spiller
+	spilled, err := items.toMap(t.Context(), opts)
+	require.NoError(t, err)
+	require.Equal(t, "spilled://big", spilled["big"])
+	require.JSONEq(t, `{"peerdb_spilled":"spilled://doc"}`, spilled["doc"].(string))
+	require.Equal(t, "0123456789", spiller["big"])
+
+	opts.OversizedPolicy = OversizedValueTruncate
+	truncated, err := items.toMap(t.Context(), opts)
+	require.NoError(t, err)
+	require.Equal(t, "01234", truncated["big"])
+	require.Equal(t, "{}", truncated["doc"])
+
+	opts.OversizedPolicy = OversizedValueNull
+	nulled, err := items.toMap(t.Context(), opts)
+	require.NoError(t, err)
+	require.Nil(t, nulled["big"])
+
+	opts.OversizedPolicy = OversizedValueError
+	_, err = items.toMap(t.Context(), opts)
+	require.Error(t, err)
+
+	opts.MaxValueSize = -1
+	kept, err := items.toMap(t.Context(), opts)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", kept["big"])
+}
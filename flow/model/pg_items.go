@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -54,13 +55,13 @@ func (r PgItems) Len() int {
 	return len(r.ColToVal)
 }
 
-func (r PgItems) ToJSONWithOptions(options ToJSONOptions) (string, error) {
+func (r PgItems) ToJSONWithOptions(ctx context.Context, options ToJSONOptions) (string, error) {
 	bytes, err := r.MarshalJSON()
 	return shared.UnsafeFastReadOnlyBytesToString(bytes), err
 }
 
 func (r PgItems) ToJSON() (string, error) {
-	return r.ToJSONWithOptions(NewToJSONOptions(nil, true))
+	return r.ToJSONWithOptions(context.Background(), NewToJSONOptions(nil, true))
 }
 
 func (r PgItems) MarshalJSON() ([]byte, error) {
@@ -173,6 +173,7 @@ type QValueAvroConverter struct {
 	*types.QField
 	logger                   log.Logger
 	Stat                     *NumericStat
+	NumericOverflow          NumericOverflowPolicy
 	TargetDWH                protos.DBType
 	UnboundedNumericAsString bool
 }
@@ -180,7 +181,7 @@ type QValueAvroConverter struct {
 func QValueToAvro(
 	ctx context.Context, env map[string]string,
 	value types.QValue, field *types.QField, targetDWH protos.DBType, logger log.Logger,
-	unboundedNumericAsString bool, stat *NumericStat,
+	unboundedNumericAsString bool, numericOverflow NumericOverflowPolicy, stat *NumericStat,
 ) (any, error) {
 	if value.Value() == nil {
 		return nil, nil
@@ -190,6 +191,7 @@ func QValueToAvro(
 		QField:                   field,
 		logger:                   logger,
 		Stat:                     stat,
+		NumericOverflow:          numericOverflow,
 		TargetDWH:                targetDWH,
 		UnboundedNumericAsString: unboundedNumericAsString,
 	}
@@ -247,7 +249,7 @@ func QValueToAvro(
 	case types.QValueBoolean:
 		return c.processNullableUnion(v.Val)
 	case types.QValueNumeric:
-		return c.processNumeric(v.Val), nil
+		return c.processNumeric(v.Val)
 	case types.QValueBytes:
 		format, err := internal.PeerDBBinaryFormat(ctx, env)
 		if err != nil {
@@ -287,7 +289,7 @@ func QValueToAvro(
 	case types.QValueArrayUUID:
 		return c.processArrayUUID(v.Val), nil
 	case types.QValueArrayNumeric:
-		return c.processArrayNumeric(v.Val), nil
+		return c.processArrayNumeric(v.Val)
 	default:
 		return nil, fmt.Errorf("[toavro] unsupported %T", value)
 	}
@@ -362,48 +364,52 @@ func (c *QValueAvroConverter) processNullableUnion(
 	return value, nil
 }
 
-func (c *QValueAvroConverter) processNumeric(num decimal.Decimal) any {
+func (c *QValueAvroConverter) processNumeric(num decimal.Decimal) (any, error) {
 	destType := GetNumericDestinationType(c.Precision, c.Scale, c.TargetDWH, c.UnboundedNumericAsString)
 	if destType.IsString {
 		numStr, _ := c.processNullableUnion(num.String())
-		return numStr
+		return numStr, nil
 	}
 
-	num, ok := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.Stat)
-	if !ok {
+	num, fit, err := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.NumericOverflow, c.Stat)
+	if err != nil {
+		return nil, err
+	} else if fit == NumericCleared {
 		if c.Nullable {
-			return nil
+			return nil, nil
 		}
-		return big.Rat{}
+		return big.Rat{}, nil
 	}
 
 	rat := num.Rat()
 	if c.Nullable {
-		return &rat
+		return &rat, nil
 	}
-	return rat
+	return rat, nil
 }
 
-func (c *QValueAvroConverter) processArrayNumeric(arrayNum []decimal.Decimal) any {
+func (c *QValueAvroConverter) processArrayNumeric(arrayNum []decimal.Decimal) (any, error) {
 	destType := GetNumericDestinationType(c.Precision, c.Scale, c.TargetDWH, c.UnboundedNumericAsString)
 	if destType.IsString {
 		transformedNumArr := make([]string, 0, len(arrayNum))
 		for _, num := range arrayNum {
 			transformedNumArr = append(transformedNumArr, num.String())
 		}
-		return transformedNumArr
+		return transformedNumArr, nil
 	}
 
 	transformedNumArr := make([]*big.Rat, 0, len(arrayNum))
 	for _, num := range arrayNum {
-		num, ok := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.Stat)
-		if !ok {
+		num, fit, err := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.NumericOverflow, c.Stat)
+		if err != nil {
+			return nil, err
+		} else if fit == NumericCleared {
 			transformedNumArr = append(transformedNumArr, &big.Rat{})
 			continue
 		}
 		transformedNumArr = append(transformedNumArr, num.Rat())
 	}
-	return transformedNumArr
+	return transformedNumArr, nil
 }
 
 func (c *QValueAvroConverter) processBytes(byteData []byte, format internal.BinaryFormat) any {
@@ -560,9 +566,12 @@ func (c *QValueAvroConverter) processArrayString(arrayData []string) any {
 	return arrayData
 }
 
+// TruncateNumeric fits num into NUMERIC(targetPrecision, targetScale) of the destination,
+// dropping digits past the scale and handling too many integer digits according to overflow
 func TruncateNumeric(
-	num decimal.Decimal, targetPrecision, targetScale int16, targetDWH protos.DBType, stat *NumericStat,
-) (decimal.Decimal, bool) {
+	num decimal.Decimal, targetPrecision, targetScale int16, targetDWH protos.DBType,
+	overflow NumericOverflowPolicy, stat *NumericStat,
+) (decimal.Decimal, NumericFit, error) {
 	switch targetDWH {
 	case protos.DBType_CLICKHOUSE, protos.DBType_SNOWFLAKE, protos.DBType_BIGQUERY:
 		bi := num.BigInt()
@@ -571,20 +580,38 @@ func TruncateNumeric(
 			bidigi = 0
 		}
 		if bidigi+int(targetScale) > int(targetPrecision) {
-			if stat != nil {
-				stat.LongIntegersClearedCount++
-				stat.MaxIntegerDigits = max(int32(bidigi), stat.MaxIntegerDigits)
+			switch overflow {
+			case NumericOverflowError:
+				err := fmt.Errorf("NUMERIC value with %d integer digits does not fit into NUMERIC(%d,%d)",
+					bidigi, targetPrecision, targetScale)
+				if stat != nil {
+					return decimal.Zero, NumericCleared, exceptions.NewNumericOutOfRangeError(
+						fmt.Errorf("column %s.%s: %w", stat.DestinationTable, stat.DestinationColumn, err),
+						stat.DestinationTable, stat.DestinationColumn)
+				}
+				return decimal.Zero, NumericCleared, exceptions.NewNumericOutOfRangeError(err, "", "")
+			case NumericOverflowClamp:
+				if stat != nil {
+					stat.ClampedCount++
+					stat.MaxIntegerDigits = max(int32(bidigi), stat.MaxIntegerDigits)
+				}
+				return clampNumeric(num, targetPrecision, targetScale), NumericClamped, nil
+			default:
+				if stat != nil {
+					stat.LongIntegersClearedCount++
+					stat.MaxIntegerDigits = max(int32(bidigi), stat.MaxIntegerDigits)
+				}
+				return decimal.Zero, NumericCleared, nil
 			}
-			return decimal.Zero, false
 		} else if num.Exponent() < -int32(targetScale) {
 			if stat != nil {
 				stat.TruncatedCount++
 				stat.MaxExponent = max(-num.Exponent(), stat.MaxExponent)
 			}
-			return num.Truncate(int32(targetScale)), true
+			return num.Truncate(int32(targetScale)), NumericTruncated, nil
 		}
 	}
-	return num, true
+	return num, NumericFits, nil
 }
 
 //nolint:govet // logically grouped, fieldalignment confuses things
@@ -594,6 +621,7 @@ type NumericStat struct {
 	TruncatedCount           uint64
 	MaxExponent              int32
 	LongIntegersClearedCount uint64
+	ClampedCount             uint64
 	MaxIntegerDigits         int32
 }
 
@@ -616,6 +644,17 @@ func (ns *NumericStat) CollectWarnings(warnings *shared.QRepWarnings) {
 		warning := exceptions.NewNumericOutOfRangeError(err, ns.DestinationTable, ns.DestinationColumn)
 		*warnings = append(*warnings, warning)
 	}
+	if ns.ClampedCount > 0 {
+		plural := ""
+		if ns.ClampedCount > 1 {
+			plural = "s"
+		}
+		err := fmt.Errorf(
+			"column %s.%s: clamped %d NUMERIC value%s too big to fit into the destination column (got %d integer digits)",
+			ns.DestinationTable, ns.DestinationColumn, ns.ClampedCount, plural, ns.MaxIntegerDigits)
+		warning := exceptions.NewNumericOutOfRangeError(err, ns.DestinationTable, ns.DestinationColumn)
+		*warnings = append(*warnings, warning)
+	}
 	if ns.TruncatedCount > 0 {
 		plural := ""
 		if ns.TruncatedCount > 1 {
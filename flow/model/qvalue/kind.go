@@ -6,6 +6,7 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
 	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
@@ -47,6 +48,52 @@ func getClickHouseTypeForNumericColumn(ctx context.Context, env map[string]strin
 	return fmt.Sprintf("Decimal(%d, %d)", destinationType.Precision, destinationType.Scale), nil
 }
 
+func getClickHouseTypeForJSONBColumn(ctx context.Context, env map[string]string) (string, error) {
+	jsonbType, err := internal.PeerDBClickHouseJSONBType(ctx, env)
+	if err != nil {
+		return "", err
+	}
+	if jsonbType != "String" && !clickhouse.IsJSONDestinationType(jsonbType) {
+		return "", fmt.Errorf("unsupported ClickHouse jsonb type %q, expected String, %s or %s",
+			jsonbType, clickhouse.JSONType, clickhouse.MapStringStringType)
+	}
+	return jsonbType, nil
+}
+
+// getClickHouseTypeForGeoColumn maps PostGIS columns constrained to a 2D geometry type ClickHouse has, and points, to geo types
+func getClickHouseTypeForGeoColumn(
+	ctx context.Context, env map[string]string, kind types.QValueKind, typeModifier int32,
+) (string, error) {
+	geoTypes, err := internal.PeerDBClickHouseGeoTypes(ctx, env)
+	if err != nil || !geoTypes {
+		return "String", err
+	}
+	if kind == types.QValueKindPoint {
+		return "Point", nil
+	}
+	geometryType, _, hasZ, hasM := datatypes.ParsePostGISTypmod(typeModifier)
+	if hasZ || hasM || !clickhouse.IsGeoDestinationType(geometryType) {
+		return "String", nil
+	}
+	return geometryType, nil
+}
+
+// getClickHouseTypeForTimestampTZColumn keeps the zone timestamptz values are converted to in the column type
+func getClickHouseTypeForTimestampTZColumn(ctx context.Context, env map[string]string, kind types.QValueKind) (string, error) {
+	zones, err := internal.TimestampZonesFromEnv(ctx, env)
+	if err != nil {
+		return "", err
+	}
+	colType := "DateTime64(6)"
+	if zones != nil && zones.Target != nil {
+		colType = fmt.Sprintf("DateTime64(6,'%s')", zones.Target)
+	}
+	if kind == types.QValueKindArrayTimestampTZ {
+		return fmt.Sprintf("Array(%s)", colType), nil
+	}
+	return colType, nil
+}
+
 func ToDWHColumnType(
 	ctx context.Context,
 	kind types.QValueKind,
@@ -83,12 +130,32 @@ func ToDWHColumnType(
 				return "", err
 			}
 			colType = fmt.Sprintf("Array(%s)", colType)
+		} else if kind == types.QValueKindJSONB {
+			var err error
+			colType, err = getClickHouseTypeForJSONBColumn(ctx, env)
+			if err != nil {
+				return "", err
+			}
+		} else if kind == types.QValueKindGeometry || kind == types.QValueKindGeography || kind == types.QValueKindPoint {
+			var err error
+			colType, err = getClickHouseTypeForGeoColumn(ctx, env, kind, column.TypeModifier)
+			if err != nil {
+				return "", err
+			}
+		} else if kind == types.QValueKindTimestampTZ || kind == types.QValueKindArrayTimestampTZ {
+			var err error
+			colType, err = getClickHouseTypeForTimestampTZColumn(ctx, env, kind)
+			if err != nil {
+				return "", err
+			}
 		} else if val, ok := types.QValueKindToClickHouseTypeMap[kind]; ok {
 			colType = val
 		} else {
 			colType = "String"
 		}
-		if nullableEnabled && column.Nullable && !kind.IsArray() {
+		// JSON, Map and geo types can't be Nullable, nulls become empty values
+		if nullableEnabled && column.Nullable && !kind.IsArray() &&
+			!clickhouse.IsJSONDestinationType(colType) && !clickhouse.IsGeoDestinationType(colType) {
 			if colType == "LowCardinality(String)" {
 				colType = "LowCardinality(Nullable(String))"
 			} else {
@@ -0,0 +1,66 @@
+package qvalue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// NumericOverflowPolicy is what happens to NUMERIC values with more integer digits than their destination column allows
+type NumericOverflowPolicy string
+
+const (
+	NumericOverflowNull   NumericOverflowPolicy = "null"
+	NumericOverflowError  NumericOverflowPolicy = "error"
+	NumericOverflowClamp  NumericOverflowPolicy = "clamp"
+	NumericOverflowString NumericOverflowPolicy = "string"
+)
+
+func ParseNumericOverflowPolicy(policy string) (NumericOverflowPolicy, error) {
+	switch p := NumericOverflowPolicy(policy); p {
+	case "":
+		return NumericOverflowNull, nil
+	case NumericOverflowNull, NumericOverflowError, NumericOverflowClamp, NumericOverflowString:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown numeric overflow policy %s, must be one of null, error, clamp or string", policy)
+	}
+}
+
+func NumericOverflowPolicyFromEnv(ctx context.Context, env map[string]string) (NumericOverflowPolicy, error) {
+	policy, err := internal.PeerDBNumericOverflowPolicy(ctx, env)
+	if err != nil {
+		return "", err
+	}
+	return ParseNumericOverflowPolicy(policy)
+}
+
+// NumericFit is how a NUMERIC value was made to fit its destination column
+type NumericFit int8
+
+const (
+	NumericFits NumericFit = iota
+	// digits past the scale were dropped
+	NumericTruncated
+	// too many integer digits, the value is cleared
+	NumericCleared
+	// too many integer digits, the value is replaced by the bound of the column
+	NumericClamped
+)
+
+// Overflowed is true when the integer digits of the value did not fit
+func (f NumericFit) Overflowed() bool {
+	return f == NumericCleared || f == NumericClamped
+}
+
+// clampNumeric returns the value of the largest magnitude NUMERIC(precision, scale) holds, with the sign of num
+func clampNumeric(num decimal.Decimal, precision, scale int16) decimal.Decimal {
+	bound := decimal.New(1, int32(precision-scale)).Sub(decimal.New(1, -int32(scale)))
+	if num.Sign() < 0 {
+		return bound.Neg()
+	}
+	return bound
+}
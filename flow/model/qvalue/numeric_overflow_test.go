@@ -0,0 +1,50 @@
+package qvalue
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+func TestTruncateNumericOverflow(t *testing.T) {
+	big := decimal.RequireFromString("-123456.789")
+
+	stat := NewNumericStat("t", "amount")
+	num, fit, err := TruncateNumeric(big, 5, 2, protos.DBType_BIGQUERY, NumericOverflowNull, stat)
+	require.NoError(t, err)
+	require.Equal(t, NumericCleared, fit)
+	require.True(t, num.IsZero())
+	require.Equal(t, uint64(1), stat.LongIntegersClearedCount)
+
+	stat = NewNumericStat("t", "amount")
+	num, fit, err = TruncateNumeric(big, 5, 2, protos.DBType_CLICKHOUSE, NumericOverflowClamp, stat)
+	require.NoError(t, err)
+	require.Equal(t, NumericClamped, fit)
+	require.Equal(t, "-999.99", num.String())
+	require.Equal(t, uint64(1), stat.ClampedCount)
+	var warnings shared.QRepWarnings
+	stat.CollectWarnings(&warnings)
+	require.Len(t, warnings, 1)
+
+	_, _, err = TruncateNumeric(big, 5, 2, protos.DBType_SNOWFLAKE, NumericOverflowError, NewNumericStat("t", "amount"))
+	var outOfRange *exceptions.NumericOutOfRangeError
+	require.True(t, errors.As(err, &outOfRange))
+	require.Equal(t, "amount", outOfRange.DestinationColumn)
+
+	num, fit, err = TruncateNumeric(decimal.RequireFromString("1.23456"), 5, 2, protos.DBType_SNOWFLAKE, NumericOverflowError, nil)
+	require.NoError(t, err)
+	require.Equal(t, NumericTruncated, fit)
+	require.Equal(t, "1.23", num.String())
+
+	_, err = ParseNumericOverflowPolicy("round")
+	require.Error(t, err)
+	policy, err := ParseNumericOverflowPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, NumericOverflowNull, policy)
+}
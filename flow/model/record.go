@@ -64,6 +64,7 @@ func (r *InsertRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeCou
 	recordCount, ok := mapOfCounts[r.DestinationTableName]
 	if ok {
 		recordCount.InsertCount.Add(1)
+		recordCount.observeCommitTime(r.CommitTimeNano)
 	}
 }
 
@@ -101,6 +102,7 @@ func (r *UpdateRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeCou
 	recordCount, ok := mapOfCounts[r.DestinationTableName]
 	if ok {
 		recordCount.UpdateCount.Add(1)
+		recordCount.observeCommitTime(r.CommitTimeNano)
 	}
 }
 
@@ -136,6 +138,7 @@ func (r *DeleteRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeCou
 	recordCount, ok := mapOfCounts[r.DestinationTableName]
 	if ok {
 		recordCount.DeleteCount.Add(1)
+		recordCount.observeCommitTime(r.CommitTimeNano)
 	}
 }
 
@@ -168,6 +171,8 @@ func (r *RelationRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeC
 type MessageRecord[T Items] struct {
 	Prefix  string
 	Content string
+	// set when logical messages are forwarded to the destination, see PullRecordsRequest.MessageDestination
+	DestinationTableName string
 	BaseRecord
 }
 
@@ -176,7 +181,7 @@ func (*MessageRecord[T]) Kind() string {
 }
 
 func (r *MessageRecord[T]) GetDestinationTableName() string {
-	return ""
+	return r.DestinationTableName
 }
 
 func (r *MessageRecord[T]) GetSourceTableName() string {
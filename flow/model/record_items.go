@@ -1,9 +1,12 @@
 package model
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
@@ -14,7 +17,7 @@ type Items interface {
 	json.Marshaler
 	UpdateIfNotExists(Items) []string
 	GetBytesByColName(string) ([]byte, error)
-	ToJSONWithOptions(ToJSONOptions) (string, error)
+	ToJSONWithOptions(context.Context, ToJSONOptions) (string, error)
 	DeleteColName(string)
 }
 
@@ -23,6 +26,90 @@ func ItemsToJSON(items Items) (string, error) {
 	return string(bytes), err
 }
 
+// values over this are cleared by default, staying under the 16MB limit of Snowflake variants
+const DefaultMaxJSONValueSize = 15 * 1024 * 1024
+
+// OversizedValuePolicy is what happens to text, json and hstore values over the size limit of ToJSONOptions
+type OversizedValuePolicy string
+
+const (
+	// replace with empty text or object, or spill when a spiller is set
+	OversizedValueDefault  OversizedValuePolicy = ""
+	OversizedValueEmpty    OversizedValuePolicy = "empty"
+	OversizedValueTruncate OversizedValuePolicy = "truncate"
+	OversizedValueNull     OversizedValuePolicy = "null"
+	OversizedValueError    OversizedValuePolicy = "error"
+	OversizedValueSpill    OversizedValuePolicy = "spill"
+)
+
+func ParseOversizedValuePolicy(policy string) (OversizedValuePolicy, error) {
+	switch p := OversizedValuePolicy(policy); p {
+	case OversizedValueDefault, OversizedValueEmpty, OversizedValueTruncate,
+		OversizedValueNull, OversizedValueError, OversizedValueSpill:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown oversized value policy %s, must be one of empty, truncate, null, error or spill", policy)
+	}
+}
+
+// ValueSpiller stores values too large to be kept in a record, returning a reference put in their place
+type ValueSpiller interface {
+	Spill(ctx context.Context, column string, value string) (string, error)
+}
+
+// oversized returns what replaces value when it is over the size limit of opts according to its policy.
+// spilled values are replaced by their reference, wrapped in an object for json columns to remain valid json,
+// json values cannot be truncated and are emptied instead
+func (opts ToJSONOptions) oversized(ctx context.Context, col string, value string, isJSON bool) (any, bool, error) {
+	limit := opts.MaxValueSize
+	if limit == 0 {
+		limit = DefaultMaxJSONValueSize
+	}
+	if limit < 0 || len(value) <= limit {
+		return value, false, nil
+	}
+	if opts.Oversized != nil {
+		opts.Oversized.Add(1)
+	}
+
+	empty := ""
+	if isJSON {
+		empty = "{}"
+	}
+	switch opts.OversizedPolicy {
+	case OversizedValueEmpty:
+		return empty, true, nil
+	case OversizedValueNull:
+		return nil, true, nil
+	case OversizedValueError:
+		return nil, true, fmt.Errorf("value of column %s is %d bytes, over the limit of %d bytes", col, len(value), limit)
+	case OversizedValueTruncate:
+		if isJSON {
+			return empty, true, nil
+		}
+		return strings.ToValidUTF8(value[:limit], ""), true, nil
+	}
+
+	if opts.Spill == nil {
+		if opts.OversizedPolicy == OversizedValueSpill {
+			return nil, true, fmt.Errorf("value of column %s is %d bytes and cannot be spilled without a spill path", col, len(value))
+		}
+		return empty, true, nil
+	}
+	ref, err := opts.Spill.Spill(ctx, col, value)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to spill oversized value of column %s: %w", col, err)
+	}
+	if isJSON {
+		wrapped, err := json.Marshal(map[string]string{"peerdb_spilled": ref})
+		if err != nil {
+			return nil, true, err
+		}
+		return string(wrapped), true, nil
+	}
+	return ref, true, nil
+}
+
 // encoding/gob cannot encode unexported fields
 type RecordItems struct {
 	ColToVal map[string]types.QValue
@@ -38,6 +125,21 @@ func (r RecordItems) AddColumn(col string, val types.QValue) {
 	r.ColToVal[col] = val
 }
 
+// AppendMissingFields returns fields followed by nullable fields for the columns of r not among them, sorted by name
+func (r RecordItems) AppendMissingFields(fields []types.QField) []types.QField {
+	var added []types.QField
+	for name, qv := range r.ColToVal {
+		if !slices.ContainsFunc(fields, func(field types.QField) bool { return field.Name == name }) {
+			added = append(added, types.QField{Name: name, Type: qv.Kind(), Nullable: true})
+		}
+	}
+	if len(added) == 0 {
+		return fields
+	}
+	slices.SortFunc(added, func(a, b types.QField) int { return strings.Compare(a.Name, b.Name) })
+	return append(slices.Clip(fields), added...)
+}
+
 func (r RecordItems) GetColumnValue(col string) types.QValue {
 	return r.ColToVal[col]
 }
@@ -78,7 +180,7 @@ func (r RecordItems) Len() int {
 	return len(r.ColToVal)
 }
 
-func (r RecordItems) toMap(opts ToJSONOptions) (map[string]any, error) {
+func (r RecordItems) toMap(ctx context.Context, opts ToJSONOptions) (map[string]any, error) {
 	jsonStruct := make(map[string]any, len(r.ColToVal))
 	for col, qv := range r.ColToVal {
 		if qv == nil {
@@ -92,16 +194,18 @@ func (r RecordItems) toMap(opts ToJSONOptions) (map[string]any, error) {
 		case types.QValueQChar:
 			jsonStruct[col] = string(v.Val)
 		case types.QValueString:
-			strVal := v.Val
-
-			if len(strVal) > 15*1024*1024 {
-				jsonStruct[col] = ""
-			} else {
-				jsonStruct[col] = strVal
+			strVal, _, err := opts.oversized(ctx, col, v.Val, false)
+			if err != nil {
+				return nil, err
 			}
+			jsonStruct[col] = strVal
 		case types.QValueJSON:
-			if len(v.Val) > 15*1024*1024 {
-				jsonStruct[col] = "{}"
+			jsonVal, isOversized, err := opts.oversized(ctx, col, v.Val, true)
+			if err != nil {
+				return nil, err
+			}
+			if isOversized {
+				jsonStruct[col] = jsonVal
 			} else if _, ok := opts.UnnestColumns[col]; ok {
 				var unnestStruct map[string]any
 				if err := json.Unmarshal([]byte(v.Val), &unnestStruct); err != nil {
@@ -114,6 +218,15 @@ func (r RecordItems) toMap(opts ToJSONOptions) (map[string]any, error) {
 			} else {
 				jsonStruct[col] = v.Val
 			}
+		case types.QValueGeometry, types.QValueGeography, types.QValuePoint:
+			wkt := v.Value().(string)
+			if !opts.GeoJSON {
+				jsonStruct[col] = wkt
+			} else if geoJSON, err := datatypes.GeoToGeoJSON(wkt); err != nil {
+				return nil, fmt.Errorf("unable to convert geo column %s to GeoJSON: %w", col, err)
+			} else {
+				jsonStruct[col] = json.RawMessage(geoJSON)
+			}
 		case types.QValueHStore:
 			hstoreVal := v.Val
 
@@ -125,11 +238,11 @@ func (r RecordItems) toMap(opts ToJSONOptions) (map[string]any, error) {
 					return nil, fmt.Errorf("unable to convert hstore column %s to json for value %T: %w", col, v, err)
 				}
 
-				if len(jsonVal) > 15*1024*1024 {
-					jsonStruct[col] = ""
-				} else {
-					jsonStruct[col] = jsonVal
+				hstoreJSON, _, err := opts.oversized(ctx, col, jsonVal, false)
+				if err != nil {
+					return nil, err
 				}
+				jsonStruct[col] = hstoreJSON
 			}
 
 		case types.QValueTimestamp:
@@ -200,17 +313,17 @@ func (r RecordItems) toMap(opts ToJSONOptions) (map[string]any, error) {
 	return jsonStruct, nil
 }
 
-func (r RecordItems) ToJSONWithOptions(options ToJSONOptions) (string, error) {
-	bytes, err := r.MarshalJSONWithOptions(options)
+func (r RecordItems) ToJSONWithOptions(ctx context.Context, options ToJSONOptions) (string, error) {
+	bytes, err := r.MarshalJSONWithOptions(ctx, options)
 	return string(bytes), err
 }
 
 func (r RecordItems) MarshalJSON() ([]byte, error) {
-	return r.MarshalJSONWithOptions(NewToJSONOptions(nil, true))
+	return r.MarshalJSONWithOptions(context.Background(), NewToJSONOptions(nil, true))
 }
 
-func (r RecordItems) MarshalJSONWithOptions(opts ToJSONOptions) ([]byte, error) {
-	jsonStruct, err := r.toMap(opts)
+func (r RecordItems) MarshalJSONWithOptions(ctx context.Context, opts ToJSONOptions) ([]byte, error) {
+	jsonStruct, err := r.toMap(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -134,10 +134,28 @@ var CDCDynamicPropertiesSignal = TypedSignal[*protos.CDCFlowConfigUpdate]{
 	Name: "cdc-dynamic-properties",
 }
 
+var MirrorEnvUpdateSignal = TypedSignal[*protos.MirrorEnvUpdate]{
+	Name: "mirror-env-update",
+}
+
+// carries the worker pool a CDC mirror moves to, empty for the default pool
+var WorkerPoolSignal = TypedSignal[string]{
+	Name: "worker-pool-move",
+}
+
 var StartMaintenanceSignal = TypedSignal[*protos.StartMaintenanceSignal]{
 	Name: "start-maintenance-signal",
 }
 
+var TableLagSignal = TypedSignal[[]*protos.TableReplicationLag]{
+	Name: "cdc-table-lag",
+}
+
+// sent to MigrationFlowWorkflow once writes to the source tables have been frozen
+var MigrationWriteFreezeSignal = TypedSignal[struct{}]{
+	Name: "migration-write-freeze",
+}
+
 func SleepFuture(ctx workflow.Context, d time.Duration) workflow.Future {
 	f, set := workflow.NewFuture(ctx)
 	workflow.Go(ctx, func(ctx workflow.Context) {
@@ -14,6 +14,7 @@ const (
 	DeploymentVersionKey       = "deploymentVersion"
 	WorkflowTypeKey            = "workflowType"
 	BatchIdKey                 = "batchId"
+	DestinationTableKey        = "destinationTable"
 	SourcePeerType             = "sourcePeerType"
 	DestinationPeerType        = "destinationPeerType"
 	SourcePeerName             = "sourcePeerName"
@@ -26,6 +27,8 @@ const (
 	TemporalActivityTypeKey    = "temporalActivityType"
 	TemporalWorkflowTypeKey    = "temporalWorkflowType"
 	IsFlowActiveKey            = "isFlowActive"
+	TaskQueueKey               = "taskQueue"
+	WorkerPoolKey              = "workerPool"
 )
 
 const (
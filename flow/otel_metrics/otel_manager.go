@@ -37,6 +37,7 @@ const (
 	IntervalSinceLastNormalizeGaugeName = "interval_since_last_normalize"
 	FetchedBytesCounterName             = "fetched_bytes"
 	CommitLagGaugeName                  = "commit_lag"
+	TableReplicationLagGaugeName        = "table_replication_lag"
 	ErrorEmittedGaugeName               = "error_emitted"
 	ErrorsEmittedCounterName            = "errors_emitted"
 	RecordsSyncedGaugeName              = "records_synced"
@@ -46,6 +47,12 @@ const (
 	MaintenanceStatusGaugeName          = "maintenance_status"
 	FlowStatusGaugeName                 = "flow_status"
 	ActiveFlowsGaugeName                = "active_flows"
+	TaskQueueBacklogGaugeName           = "task_queue_backlog"
+	TaskQueueBacklogAgeGaugeName        = "task_queue_backlog_age"
+	TaskQueuePollersGaugeName           = "task_queue_pollers"
+	PendingSyncFlowsGaugeName           = "pending_sync_flows"
+	QueuedPartitionsGaugeName           = "queued_partitions"
+	RunningPartitionsGaugeName          = "running_partitions"
 )
 
 type Metrics struct {
@@ -60,6 +67,7 @@ type Metrics struct {
 	IntervalSinceLastNormalizeGauge metric.Float64Gauge
 	FetchedBytesCounter             metric.Int64Counter
 	CommitLagGauge                  metric.Int64Gauge
+	TableReplicationLagGauge        metric.Float64Gauge
 	ErrorEmittedGauge               metric.Int64Gauge
 	ErrorsEmittedCounter            metric.Int64Counter
 	RecordsSyncedGauge              metric.Int64Gauge
@@ -229,6 +237,13 @@ func (om *OtelManager) setupMetrics() error {
 		return err
 	}
 
+	if om.Metrics.TableReplicationLagGauge, err = om.GetOrInitFloat64Gauge(BuildMetricName(TableReplicationLagGaugeName),
+		metric.WithUnit("s"),
+		metric.WithDescription("Seconds between source commit of the oldest change in a batch & it being applied to the destination table"),
+	); err != nil {
+		return err
+	}
+
 	if om.Metrics.ErrorEmittedGauge, err = om.GetOrInitInt64Gauge(BuildMetricName(ErrorEmittedGaugeName),
 		// This mostly tells whether an error is emitted or not, used for hooking up event based alerting
 		metric.WithDescription("Whether an error was emitted, 1 if emitted, 0 otherwise"),
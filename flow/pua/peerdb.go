@@ -189,14 +189,53 @@ func LVAsDuration(ls *lua.LState, lv lua.LValue) time.Duration {
 	return 0
 }
 
+// LVKind is the kind of the column a Lua value is stored in when a script adds it to a row
+func LVKind(lv lua.LValue) types.QValueKind {
+	switch v := lv.(type) {
+	case lua.LBool:
+		return types.QValueKindBoolean
+	case lua.LNumber:
+		return types.QValueKindFloat64
+	case lua.LString:
+		return types.QValueKindString
+	case *lua.LUserData:
+		switch v.Value.(type) {
+		case int64:
+			return types.QValueKindInt64
+		case uint64:
+			return types.QValueKindUInt64
+		case time.Time:
+			return types.QValueKindTimestampTZ
+		case decimal.Decimal:
+			return types.QValueKindNumeric
+		case uuid.UUID:
+			return types.QValueKindUUID
+		}
+	}
+	return types.QValueKindInvalid
+}
+
 func LuaRowNewIndex(ls *lua.LState) int {
 	_, row := LuaRow.Check(ls, 1)
 	key := ls.CheckString(2)
 	val := ls.Get(3)
 	qv := row.GetColumnValue(key)
+	if qv == nil {
+		// column added by the script, its kind follows from the value
+		if val == lua.LNil {
+			return 0
+		}
+		kind := LVKind(val)
+		if kind == types.QValueKindInvalid {
+			ls.RaiseError("cannot add column %s of type %s", key, val.Type())
+			return 0
+		}
+		qv = types.QValueNull(kind)
+	}
 	kind := qv.Kind()
 	if val == lua.LNil {
 		row.AddColumn(key, types.QValueNull(kind))
+		return 0
 	}
 	var newqv types.QValue
 	switch kind {
@@ -532,9 +571,9 @@ func LuaRecordIndex(ls *lua.LState) int {
 
 func LuaRecordJson(ls *lua.LState) int {
 	ud := ls.CheckUserData(1)
-	tbl := ls.CreateTable(0, 7)
+	tbl := ls.CreateTable(0, 9)
 	for _, key := range []string{
-		"kind", "old", "new", "checkpoint", "commit_time", "source",
+		"kind", "old", "new", "checkpoint", "commit_time", "source", "prefix", "content",
 	} {
 		tbl.RawSetString(key, ls.GetField(ud, key))
 	}
@@ -32,6 +32,10 @@ func Test(t *testing.T) {
 	row_empty_array.AddColumn("a", types.QValueArrayInt32{Val: nil})
 	ls.Env.RawSetString("row_empty_array", LuaRow.New(ls, row_empty_array))
 
+	ls.Env.RawSetString("message", LuaRecord.New(ls, &model.MessageRecord[model.RecordItems]{
+		Prefix: "marker", Content: "end-of-batch", DestinationTableName: "messages",
+	}))
+
 	assert(t, ls, `
 assert(require('bit32').band(173, 21) == 5)
 assert(dofile == nil)
@@ -122,5 +126,12 @@ assert(msgpack.encode(row_empty_array.a) == string.char(0x90))
 local json = require "json"
 assert(json.encode(row) == "{\"a\":5040}")
 assert(json.encode(row_empty_array.a) == "[]")
+
+assert(message.kind == "message")
+assert(message.target == "messages")
+assert(message.prefix == "marker")
+local message_json = json.decode(json.encode(message))
+assert(message_json.prefix == "marker")
+assert(message_json.content == "end-of-batch")
 `)
 }
@@ -6,8 +6,23 @@ import (
 	lua "github.com/yuin/gopher-lua"
 
 	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
+// callTransform calls lfn with ud, a row or record, returning false when lfn returned false to drop it
+func callTransform(ls *lua.LState, lfn *lua.LFunction, ud lua.LValue) (bool, error) {
+	ls.Push(lfn)
+	ls.Push(ud)
+	if err := ls.PCall(1, 1, nil); err != nil {
+		return false, err
+	}
+	keep := ls.Get(-1) != lua.LFalse
+	ls.Pop(1)
+	return keep, nil
+}
+
+// AttachToStream runs lfn on every row of stream, dropping rows for which it returns false.
+// Columns it adds to the first row are appended to the schema, later rows lacking them get nulls.
 func AttachToStream(ls *lua.LState, lfn *lua.LFunction, stream *model.QRecordStream) *model.QRecordStream {
 	output := model.NewQRecordStream(0)
 	go func() {
@@ -16,28 +31,43 @@ func AttachToStream(ls *lua.LState, lfn *lua.LFunction, stream *model.QRecordStr
 			output.Close(err)
 			return
 		}
-		output.SetSchema(schema)
+		var fields []types.QField
 		for record := range stream.Records {
 			row := model.NewRecordItems(len(record))
 			for i, qv := range record {
 				row.AddColumn(schema.Fields[i].Name, qv)
 			}
-			ls.Push(lfn)
-			ls.Push(LuaRow.New(ls, row))
-			if err := ls.PCall(1, 0, nil); err != nil {
+			keep, err := callTransform(ls, lfn, LuaRow.New(ls, row))
+			if err != nil {
 				output.Close(err)
 				return
 			}
-			for i, field := range schema.Fields {
-				record[i] = row.GetColumnValue(field.Name)
+			if fields == nil {
+				fields = row.AppendMissingFields(schema.Fields)
+				output.SetSchema(types.NewQRecordSchema(fields))
 			}
-			output.Records <- record
+			if !keep {
+				continue
+			}
+			transformed := make([]types.QValue, len(fields))
+			for i, field := range fields {
+				if qv := row.GetColumnValue(field.Name); qv != nil {
+					transformed[i] = qv
+				} else {
+					transformed[i] = types.QValueNull(field.Type)
+				}
+			}
+			output.Records <- transformed
+		}
+		if fields == nil {
+			output.SetSchema(schema)
 		}
 		output.Close(stream.Err())
 	}()
 	return output
 }
 
+// AttachToCdcStream runs lfn on every record of stream, dropping records for which it returns false
 func AttachToCdcStream(
 	ctx context.Context,
 	ls *lua.LState,
@@ -62,13 +92,15 @@ func AttachToCdcStream(
 		} else {
 			outstream.SignalAsNotEmpty()
 			for record := range stream.GetRecords() {
-				ls.Push(lfn)
-				ls.Push(LuaRecord.New(ls, record))
-				if err := ls.PCall(1, 0, nil); err != nil {
+				keep, err := callTransform(ls, lfn, LuaRecord.New(ls, record))
+				if err != nil {
 					handleErr(err)
 					break
 				}
-				err := outstream.AddRecord(ctx, record)
+				if !keep {
+					continue
+				}
+				err = outstream.AddRecord(ctx, record)
 				if err != nil {
 					handleErr(err)
 					break
@@ -0,0 +1,57 @@
+package pua
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestAttachToStream(t *testing.T) {
+	t.Parallel()
+
+	ls := lua.NewState(lua.Options{})
+	RegisterTypes(ls)
+	require.NoError(t, ls.DoString(`
+function transformRow(row)
+	if row.name == "b" then
+		return false
+	end
+	row.name = row.name .. "!"
+	row.length = #row.name
+end`))
+	fn, ok := ls.Env.RawGetString("transformRow").(*lua.LFunction)
+	require.True(t, ok)
+
+	stream := model.NewQRecordStream(3)
+	stream.SetSchema(types.NewQRecordSchema([]types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "name", Type: types.QValueKindString, Nullable: true},
+	}))
+	for id, name := range []string{"a", "b", "c"} {
+		stream.Records <- []types.QValue{types.QValueInt64{Val: int64(id + 1)}, types.QValueString{Val: name}}
+	}
+	stream.Close(nil)
+
+	output := AttachToStream(ls, fn, stream)
+	schema, err := output.Schema()
+	require.NoError(t, err)
+	require.Equal(t, []types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "name", Type: types.QValueKindString, Nullable: true},
+		{Name: "length", Type: types.QValueKindFloat64, Nullable: true},
+	}, schema.Fields)
+
+	var records [][]types.QValue
+	for record := range output.Records {
+		records = append(records, record)
+	}
+	require.NoError(t, output.Err())
+	require.Equal(t, [][]types.QValue{
+		{types.QValueInt64{Val: 1}, types.QValueString{Val: "a!"}, types.QValueFloat64{Val: 2}},
+		{types.QValueInt64{Val: 3}, types.QValueString{Val: "c!"}, types.QValueFloat64{Val: 2}},
+	}, records)
+}
@@ -26,6 +26,39 @@ var SupportedDestinationTypes = map[string][]types.TypeConversion{
 		types.NumericToStringSchemaConversion,
 		types.NumericToStringValueConversion,
 	)},
+	JSONType:            {types.PassthroughConversion{Kind: types.QValueKindJSONB}},
+	MapStringStringType: {types.PassthroughConversion{Kind: types.QValueKindJSONB}},
+}
+
+// ClickHouse types json can be stored as besides String, values are staged as json text either way
+const (
+	JSONType            = "JSON"
+	MapStringStringType = "Map(String, String)"
+)
+
+// IsJSONDestinationType reports whether chType parses json text into a structured ClickHouse type
+func IsJSONDestinationType(chType string) bool {
+	return chType == JSONType || chType == MapStringStringType
+}
+
+// ClickHouse geo types PostGIS geometries map to, values are staged as WKT either way
+var geoTypeWKTReaders = map[string]string{
+	"Point":           "readWKTPoint",
+	"LineString":      "readWKTLineString",
+	"Polygon":         "readWKTPolygon",
+	"MultiLineString": "readWKTMultiLineString",
+	"MultiPolygon":    "readWKTMultiPolygon",
+}
+
+// IsGeoDestinationType reports whether chType is a geo type, which cannot be Nullable
+func IsGeoDestinationType(chType string) bool {
+	_, ok := geoTypeWKTReaders[chType]
+	return ok
+}
+
+// GeoWKTReader returns the function parsing WKT into geo type chType
+func GeoWKTReader(chType string) string {
+	return geoTypeWKTReaders[chType]
 }
 
 var NumericDestinationTypes = map[string]struct{}{
@@ -18,6 +18,8 @@ const (
 const (
 	InternalVersion_First uint32 = iota
 	InternalVersion_PgVectorAsFloatArray
+	// composite columns become json, domain columns take the type of their base
+	InternalVersion_PgCompositeAsJSON
 
 	TotalNumberOfInternalVersions
 	InternalVersion_Latest = TotalNumberOfInternalVersions - 1
@@ -35,9 +37,12 @@ const (
 	MaintenanceFlowTaskQueue TaskQueueID = "maintenance-flow-task-queue"
 
 	// Queries
-	CDCFlowStateQuery  = "q-cdc-flow-state"
-	QRepFlowStateQuery = "q-qrep-flow-state"
-	FlowStatusQuery    = "q-flow-status"
+	CDCFlowStateQuery    = "q-cdc-flow-state"
+	QRepFlowStateQuery   = "q-qrep-flow-state"
+	FlowStatusQuery      = "q-flow-status"
+	CDCTableLagQuery     = "q-cdc-table-lag"
+	CDCFreshnessSloQuery = "q-cdc-freshness-slo"
+	MigrationStateQuery  = "q-migration-state"
 )
 
 var MirrorNameSearchAttribute = temporal.NewSearchAttributeKeyString("MirrorName")
@@ -47,13 +52,27 @@ func NewSearchAttributes(mirrorName string) temporal.SearchAttributes {
 }
 
 const (
-	FlowNameKey      ContextKey = "flowName"
-	PartitionIDKey   ContextKey = "partitionId"
-	DeploymentUIDKey ContextKey = "deploymentUid"
+	FlowNameKey        ContextKey = "flowName"
+	PartitionIDKey     ContextKey = "partitionId"
+	DeploymentUIDKey   ContextKey = "deploymentUid"
+	RequestActorKey    ContextKey = "requestActor"
+	RequestIdentityKey ContextKey = "requestIdentity"
 )
 
 const FetchAndChannelSize = 256 * 1024
 
+// defaults of mirror settings left unset in their configs
+const (
+	DefaultCDCBatchSize                  uint32 = 250_000
+	DefaultCDCIdleTimeoutSeconds         uint64 = 10
+	DefaultSnapshotMaxParallelWorkers    uint32 = 8
+	DefaultSnapshotNumRowsPerPartition   uint32 = 250_000
+	DefaultSnapshotNumTablesInParallel   uint32 = 1
+	DefaultQRepMaxParallelWorkers        uint32 = 16
+	DefaultQRepWaitBetweenBatchesSeconds uint32 = 5
+	DefaultSyncedAtColName                      = "_PEERDB_SYNCED_AT"
+)
+
 func Ptr[T any](x T) *T {
 	return &x
 }
@@ -1,8 +1,10 @@
 package shared
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
@@ -124,6 +126,22 @@ func (key PeerDBEncKey) Encrypt(plaintext []byte) ([]byte, error) {
 	return ciphertext, nil
 }
 
+// Sign returns the HMAC-SHA256 of data keyed by the PeerDBEncKey.
+func (key PeerDBEncKey) Sign(data []byte) ([]byte, error) {
+	if key.ID == "" {
+		return nil, errors.New("no encryption key configured to sign with")
+	}
+
+	decodedKey, err := base64.StdEncoding.DecodeString(key.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 key: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, decodedKey)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
 // modified from https://github.com/golang/go/blob/master/src/crypto/tls/example_test.go
 func verifyPeerCertificateWithoutHostname(rootCAs *x509.CertPool) func(certificates [][]byte, _ [][]*x509.Certificate) error {
 	return func(certificates [][]byte, _ [][]*x509.Certificate) error {
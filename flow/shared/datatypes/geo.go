@@ -2,10 +2,14 @@ package datatypes
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
 	geom "github.com/twpayne/go-geos"
 )
 
@@ -47,3 +51,59 @@ func GeoToWKB(wkt string) ([]byte, error) {
 
 	return geometryObject.ToWKB(), nil
 }
+
+// PostGIS typmods of geometry(type, srid) & geography(type, srid) columns pack the srid,
+// the geometry type and whether coordinates have Z & M dimensions
+var postGISGeometryTypes = [...]string{
+	1: "Point",
+	2: "LineString",
+	3: "Polygon",
+	4: "MultiPoint",
+	5: "MultiLineString",
+	6: "MultiPolygon",
+	7: "GeometryCollection",
+}
+
+// ParsePostGISTypmod returns an empty geometry type for columns not constrained to one type
+func ParsePostGISTypmod(typmod int32) (string, int32, bool, bool) {
+	if typmod < 0 {
+		return "", 0, false, false
+	}
+	geometryType := ""
+	if typeCode := (typmod & 0xfc) >> 2; typeCode < int32(len(postGISGeometryTypes)) {
+		geometryType = postGISGeometryTypes[typeCode]
+	}
+	srid := ((typmod & 0x0fffff00) - (typmod & 0x10000000)) >> 8
+	return geometryType, srid, typmod&0x2 != 0, typmod&0x1 != 0
+}
+
+// StripSRID returns the WKT of a geometry in EWKT, which prefixes WKT with SRID=<srid>;
+func StripSRID(ewkt string) string {
+	if strings.HasPrefix(ewkt, "SRID=") {
+		if idx := strings.IndexByte(ewkt, ';'); idx != -1 {
+			return ewkt[idx+1:]
+		}
+	}
+	return ewkt
+}
+
+// GeoToGeoJSON converts a geometry in (E)WKT to a GeoJSON geometry object
+func GeoToGeoJSON(ewkt string) ([]byte, error) {
+	geometry, err := wkt.Unmarshal(StripSRID(ewkt))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geoJSONGeometry(geometry))
+}
+
+func geoJSONGeometry(geometry orb.Geometry) map[string]any {
+	if collection, ok := geometry.(orb.Collection); ok {
+		geometries := make([]map[string]any, 0, len(collection))
+		for _, member := range collection {
+			geometries = append(geometries, geoJSONGeometry(member))
+		}
+		return map[string]any{"type": collection.GeoJSONType(), "geometries": geometries}
+	}
+	// coordinates of orb geometries marshal to nested arrays as GeoJSON expects
+	return map[string]any{"type": geometry.GeoJSONType(), "coordinates": geometry}
+}
@@ -19,3 +19,36 @@ func TestInvalidWkt(t *testing.T) {
 	_, err := GeoToWKB("invalid")
 	require.Error(t, err)
 }
+
+func TestParsePostGISTypmod(t *testing.T) {
+	// geometry(Polygon, 4326)
+	geometryType, srid, hasZ, hasM := ParsePostGISTypmod(4326<<8 | 3<<2)
+	require.Equal(t, "Polygon", geometryType)
+	require.Equal(t, int32(4326), srid)
+	require.False(t, hasZ)
+	require.False(t, hasM)
+
+	// geography(PointZ)
+	geometryType, _, hasZ, _ = ParsePostGISTypmod(4326<<8 | 1<<2 | 2)
+	require.Equal(t, "Point", geometryType)
+	require.True(t, hasZ)
+
+	geometryType, _, _, _ = ParsePostGISTypmod(-1)
+	require.Empty(t, geometryType)
+}
+
+func TestGeoToGeoJSON(t *testing.T) {
+	require.Equal(t, "POINT(1 2)", StripSRID("SRID=4326;POINT(1 2)"))
+
+	geoJSON, err := GeoToGeoJSON("SRID=4326;POINT(1 2)")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"Point","coordinates":[1,2]}`, string(geoJSON))
+
+	geoJSON, err = GeoToGeoJSON("GEOMETRYCOLLECTION(POINT(1 2),LINESTRING(0 0,1 1))")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"GeometryCollection","geometries":[`+
+		`{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[0,0],[1,1]]}]}`, string(geoJSON))
+
+	_, err = GeoToGeoJSON("invalid")
+	require.Error(t, err)
+}
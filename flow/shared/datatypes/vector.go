@@ -0,0 +1,6 @@
+package datatypes
+
+// ParseVectorTypmod returns the dimensions of a vector, halfvec or sparsevec column, 0 when unconstrained
+func ParseVectorTypmod(typmod int32) int32 {
+	return max(typmod, 0)
+}
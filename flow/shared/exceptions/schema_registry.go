@@ -0,0 +1,20 @@
+package exceptions
+
+// SchemaRegistryError is returned when a schema registry rejects a schema,
+// as incompatible with earlier versions of its subject or as not registered when auto registration is off
+type SchemaRegistryError struct {
+	error
+	Subject string
+}
+
+func NewSchemaRegistryError(err error, subject string) *SchemaRegistryError {
+	return &SchemaRegistryError{err, subject}
+}
+
+func (e *SchemaRegistryError) Error() string {
+	return e.error.Error()
+}
+
+func (e *SchemaRegistryError) Unwrap() error {
+	return e.error
+}
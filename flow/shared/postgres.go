@@ -32,11 +32,20 @@ type CustomDataType struct {
 	Name  string
 	Type  byte
 	Delim byte // non-zero character for arrays
+	// underlying type of domains
+	BaseOID uint32
+	// attributes of composite types, in order
+	Fields []CompositeField
+}
+
+type CompositeField struct {
+	Name string
+	OID  uint32
 }
 
 func GetCustomDataTypes(ctx context.Context, conn *pgx.Conn) (map[uint32]CustomDataType, error) {
 	rows, err := conn.Query(ctx, `
-		SELECT t.oid, t.typname, coalesce(at.typtype, t.typtype), coalesce(at.typdelim, 0::"char")
+		SELECT t.oid, t.typname, coalesce(at.typtype, t.typtype), coalesce(at.typdelim, 0::"char"), t.typbasetype
 		FROM pg_catalog.pg_type t
 		LEFT JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
 		LEFT JOIN pg_catalog.pg_class c ON c.oid = t.typrelid
@@ -50,16 +59,80 @@ func GetCustomDataTypes(ctx context.Context, conn *pgx.Conn) (map[uint32]CustomD
 
 	customTypeMap := map[uint32]CustomDataType{}
 	var typeID pgtype.Uint32
+	var baseTypeID pgtype.Uint32
 	var cdt CustomDataType
-	if _, err := pgx.ForEachRow(rows, []any{&typeID, &cdt.Name, &cdt.Type, &cdt.Delim}, func() error {
+	if _, err := pgx.ForEachRow(rows, []any{&typeID, &cdt.Name, &cdt.Type, &cdt.Delim, &baseTypeID}, func() error {
+		cdt.BaseOID = baseTypeID.Uint32
 		customTypeMap[typeID.Uint32] = cdt
 		return nil
 	}); err != nil {
 		return nil, fmt.Errorf("failed to scan into custom type mapping: %w", err)
 	}
+
+	rows, err = conn.Query(ctx, `
+		SELECT t.oid, a.attname, a.atttypid
+		FROM pg_catalog.pg_type t
+		JOIN pg_catalog.pg_class c ON c.oid = t.typrelid AND c.relkind = 'c'
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid
+		WHERE a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY t.oid, a.attnum
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get composite type attributes: %w", err)
+	}
+	var field CompositeField
+	var fieldTypeID pgtype.Uint32
+	if _, err := pgx.ForEachRow(rows, []any{&typeID, &field.Name, &fieldTypeID}, func() error {
+		if typeData, ok := customTypeMap[typeID.Uint32]; ok {
+			field.OID = fieldTypeID.Uint32
+			typeData.Fields = append(typeData.Fields, field)
+			customTypeMap[typeID.Uint32] = typeData
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to scan composite type attributes: %w", err)
+	}
 	return customTypeMap, nil
 }
 
+// NewCompositeTypeMap returns a type map able to decode the composite types among customTypes into map[string]any,
+// attributes of types unknown to pgx are decoded as text
+func NewCompositeTypeMap(customTypes map[uint32]CustomDataType) *pgtype.Map {
+	typeMap := pgtype.NewMap()
+	var fieldType func(oid uint32) *pgtype.Type
+	fieldType = func(oid uint32) *pgtype.Type {
+		if ty, ok := typeMap.TypeForOID(oid); ok {
+			return ty
+		}
+		typeData, ok := customTypes[oid]
+		var ty *pgtype.Type
+		switch {
+		case ok && typeData.Type == 'd' && typeData.Delim == 0 && typeData.BaseOID != 0:
+			ty = &pgtype.Type{Name: typeData.Name, OID: oid, Codec: fieldType(typeData.BaseOID).Codec}
+		case ok && typeData.Type == 'c' && typeData.Delim == 0 && len(typeData.Fields) != 0:
+			// registered before its attributes to stop at types referring to themselves through arrays or domains
+			codec := &pgtype.CompositeCodec{}
+			ty = &pgtype.Type{Name: typeData.Name, OID: oid, Codec: codec}
+			typeMap.RegisterType(ty)
+			codec.Fields = make([]pgtype.CompositeCodecField, 0, len(typeData.Fields))
+			for _, field := range typeData.Fields {
+				codec.Fields = append(codec.Fields, pgtype.CompositeCodecField{Name: field.Name, Type: fieldType(field.OID)})
+			}
+			return ty
+		default:
+			ty = &pgtype.Type{Name: typeData.Name, OID: oid, Codec: &pgtype.TextFormatOnlyCodec{Codec: pgtype.TextCodec{}}}
+		}
+		typeMap.RegisterType(ty)
+		return ty
+	}
+	for oid, typeData := range customTypes {
+		if typeData.Type == 'c' && typeData.Delim == 0 {
+			fieldType(oid)
+		}
+	}
+	return typeMap
+}
+
 func RegisterExtensions(ctx context.Context, conn *pgx.Conn, version uint32) error {
 	var hstoreOID *uint32
 	var vectorOID *uint32
@@ -106,6 +106,9 @@ func PostgresOIDToQValueKind(
 				return types.QValueKindPoint, nil
 			default:
 				if typeData, ok := customTypeMapping[recvOID]; ok {
+					if baseOID := DomainBaseOID(typeData, version); baseOID != 0 {
+						return PostgresOIDToQValueKind(baseOID, customTypeMapping, typeMap, version)
+					}
 					return CustomTypeToQKind(typeData, version), nil
 				}
 				return types.QValueKindString, nil
@@ -127,6 +130,10 @@ func CustomTypeToQKind(typeData shared.CustomDataType, version uint32) types.QVa
 		return types.QValueKindArrayString
 	}
 
+	if typeData.Type == 'c' && version >= shared.InternalVersion_PgCompositeAsJSON {
+		return types.QValueKindJSON
+	}
+
 	switch typeData.Name {
 	case "geometry":
 		return types.QValueKindGeometry
@@ -144,3 +151,11 @@ func CustomTypeToQKind(typeData shared.CustomDataType, version uint32) types.QVa
 		return types.QValueKindString
 	}
 }
+
+// DomainBaseOID returns the type to decode columns of a domain as, 0 for other types
+func DomainBaseOID(typeData shared.CustomDataType, version uint32) uint32 {
+	if typeData.Type == 'd' && typeData.Delim == 0 && version >= shared.InternalVersion_PgCompositeAsJSON {
+		return typeData.BaseOID
+	}
+	return 0
+}
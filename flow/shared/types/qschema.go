@@ -11,6 +11,8 @@ type QField struct {
 	Precision int16
 	Scale     int16
 	Nullable  bool
+	// length of pgvector columns, 0 for other columns or vectors of any length
+	Dimensions int32
 }
 
 type QRecordSchema struct {
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimestampZones normalizes the timestamps of a mirror, a nil TimestampZones leaves them unchanged
+type TimestampZones struct {
+	// timestamptz values are converted to this zone when set
+	Target *time.Location
+	// naive timestamps are taken to be wall times in this zone when set, becoming timestamptz
+	NaiveSource *time.Location
+}
+
+// NewTimestampZones loads the zones named by target and naiveSource, returning nil when both are empty
+func NewTimestampZones(target string, naiveSource string) (*TimestampZones, error) {
+	if target == "" && naiveSource == "" {
+		return nil, nil
+	}
+	var zones TimestampZones
+	if target != "" {
+		loc, err := time.LoadLocation(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamptz zone %s: %w", target, err)
+		}
+		zones.Target = loc
+	}
+	if naiveSource != "" {
+		loc, err := time.LoadLocation(naiveSource)
+		if err != nil {
+			return nil, fmt.Errorf("invalid naive timestamp source zone %s: %w", naiveSource, err)
+		}
+		zones.NaiveSource = loc
+	}
+	return &zones, nil
+}
+
+// Kind is the kind values of kind have after normalization
+func (z *TimestampZones) Kind(kind QValueKind) QValueKind {
+	if z != nil && z.NaiveSource != nil {
+		switch kind {
+		case QValueKindTimestamp:
+			return QValueKindTimestampTZ
+		case QValueKindArrayTimestamp:
+			return QValueKindArrayTimestampTZ
+		}
+	}
+	return kind
+}
+
+func (z *TimestampZones) instant(t time.Time) time.Time {
+	if z.Target != nil {
+		return t.In(z.Target)
+	}
+	return t
+}
+
+func (z *TimestampZones) naive(t time.Time) time.Time {
+	return z.instant(time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), z.NaiveSource))
+}
+
+func convertTimes(times []time.Time, convert func(time.Time) time.Time) []time.Time {
+	converted := make([]time.Time, len(times))
+	for idx, t := range times {
+		converted[idx] = convert(t)
+	}
+	return converted
+}
+
+// Value normalizes timestamp values, other values are returned as is
+func (z *TimestampZones) Value(value QValue) QValue {
+	if z == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case QValueTimestampTZ:
+		if z.Target != nil {
+			return QValueTimestampTZ{Val: z.instant(v.Val)}
+		}
+	case QValueArrayTimestampTZ:
+		if z.Target != nil {
+			return QValueArrayTimestampTZ{Val: convertTimes(v.Val, z.instant)}
+		}
+	case QValueTimestamp:
+		if z.NaiveSource != nil {
+			return QValueTimestampTZ{Val: z.naive(v.Val)}
+		}
+	case QValueArrayTimestamp:
+		if z.NaiveSource != nil {
+			return QValueArrayTimestampTZ{Val: convertTimes(v.Val, z.naive)}
+		}
+	case QValueNull:
+		return QValueNull(z.Kind(QValueKind(v)))
+	}
+	return value
+}
+
+// Fields returns fields with their kinds normalized
+func (z *TimestampZones) Fields(fields []QField) []QField {
+	if z == nil || z.NaiveSource == nil {
+		return fields
+	}
+	converted := make([]QField, len(fields))
+	for idx, field := range fields {
+		field.Type = z.Kind(field.Type)
+		converted[idx] = field
+	}
+	return converted
+}
@@ -47,3 +47,20 @@ func NumericToStringSchemaConversion(val QField) QField {
 func NumericToStringValueConversion(val QValueNumeric) QValueString {
 	return QValueString{Val: val.Val.String()}
 }
+
+// PassthroughConversion leaves values of Kind as they are, for destination types that parse them on insert
+type PassthroughConversion struct {
+	Kind QValueKind
+}
+
+func (tc PassthroughConversion) SchemaConversion(field QField) QField {
+	return field
+}
+
+func (tc PassthroughConversion) ValueConversion(val QValue) QValue {
+	return val
+}
+
+func (tc PassthroughConversion) FromKind() QValueKind {
+	return tc.Kind
+}
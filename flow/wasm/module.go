@@ -0,0 +1,228 @@
+// Package wasm runs user-defined transforms compiled to WebAssembly, as an alternative to Lua scripts.
+//
+// Modules export their linear memory as "memory" along with:
+//   - peerdb_alloc(size i32) i32, returning a buffer of size bytes for input to be written to
+//   - peerdb_transform_row(ptr i32, len i32) i64, optional, receiving a row as a JSON object
+//   - peerdb_route(ptr i32, len i32) i64, optional, receiving a CDC record as a JSON object
+//
+// Results are packed as ptr<<32|len. peerdb_transform_row returns a JSON object whose keys overwrite
+// columns of the row and add new ones, or 0 to drop the row. peerdb_route returns the destination table
+// or topic to route the record to, or 0 to keep its destination. WASI is available without filesystem access.
+package wasm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const (
+	allocExport     = "peerdb_alloc"
+	transformExport = "peerdb_transform_row"
+	routeExport     = "peerdb_route"
+	// pages of WASM memory are 64KiB, so a megabyte is 16 of them
+	pagesPerMB = 16
+	maxPages   = 65536
+)
+
+// Limits bound the resources of a module
+type Limits struct {
+	MemoryLimitMB uint32
+	CallTimeout   time.Duration
+}
+
+// LimitsFromEnv reads the limits of modules from dynamic settings
+func LimitsFromEnv(ctx context.Context, env map[string]string) (Limits, error) {
+	memoryLimitMB, err := internal.PeerDBWasmMemoryLimitMB(ctx, env)
+	if err != nil {
+		return Limits{}, err
+	}
+	callTimeout, err := internal.PeerDBWasmCallTimeout(ctx, env)
+	if err != nil {
+		return Limits{}, err
+	}
+	return Limits{MemoryLimitMB: memoryLimitMB, CallTimeout: callTimeout}, nil
+}
+
+// Module is an instantiated WASM transform, it is not safe for concurrent use
+type Module struct {
+	runtime     wazero.Runtime
+	module      api.Module
+	alloc       api.Function
+	transform   api.Function
+	route       api.Function
+	callTimeout time.Duration
+}
+
+func newRuntime(ctx context.Context, limits Limits) wazero.Runtime {
+	config := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if limits.MemoryLimitMB != 0 {
+		config = config.WithMemoryLimitPages(uint32(min(uint64(limits.MemoryLimitMB)*pagesPerMB, maxPages)))
+	}
+	return wazero.NewRuntimeWithConfig(ctx, config)
+}
+
+// checkSignature errors unless fn takes params and returns results
+func checkSignature(name string, fn api.Function, params []api.ValueType, results []api.ValueType) error {
+	def := fn.Definition()
+	if !slices.Equal(def.ParamTypes(), params) || !slices.Equal(def.ResultTypes(), results) {
+		return fmt.Errorf("export %s has signature %v -> %v, expected %v -> %v",
+			name, def.ParamTypes(), def.ResultTypes(), params, results)
+	}
+	return nil
+}
+
+// New compiles and instantiates source, checking that it exports the transform interface
+func New(ctx context.Context, source []byte, limits Limits) (*Module, error) {
+	runtime := newRuntime(ctx, limits)
+	m, err := instantiate(ctx, runtime, source)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+	m.callTimeout = limits.CallTimeout
+	return m, nil
+}
+
+func instantiate(ctx context.Context, runtime wazero.Runtime, source []byte) (*Module, error) {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	compiled, err := runtime.CompileModule(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile WASM module: %w", err)
+	}
+	// reactor modules initialize with _initialize, calling _start would run and exit a command module
+	module, err := runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate WASM module: %w", err)
+	}
+	if module.Memory() == nil {
+		return nil, errors.New("WASM module does not export memory")
+	}
+
+	m := &Module{
+		runtime:   runtime,
+		module:    module,
+		alloc:     module.ExportedFunction(allocExport),
+		transform: module.ExportedFunction(transformExport),
+		route:     module.ExportedFunction(routeExport),
+	}
+	if m.alloc == nil {
+		return nil, fmt.Errorf("WASM module does not export %s", allocExport)
+	}
+	if err := checkSignature(allocExport, m.alloc,
+		[]api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}); err != nil {
+		return nil, err
+	}
+	if m.transform == nil && m.route == nil {
+		return nil, fmt.Errorf("WASM module exports neither %s nor %s", transformExport, routeExport)
+	}
+	for name, fn := range map[string]api.Function{transformExport: m.transform, routeExport: m.route} {
+		if fn != nil {
+			if err := checkSignature(name, fn,
+				[]api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// Validate checks that source compiles and exports the transform interface
+func Validate(ctx context.Context, source []byte) error {
+	runtime := newRuntime(ctx, Limits{})
+	defer runtime.Close(ctx)
+	_, err := instantiate(ctx, runtime, source)
+	return err
+}
+
+// DecodeSource decodes a module posted as base64, WASM being binary
+func DecodeSource(source string) ([]byte, error) {
+	module, err := base64.StdEncoding.DecodeString(source)
+	if err != nil {
+		return nil, fmt.Errorf("WASM scripts must be base64 encoded: %w", err)
+	}
+	return module, nil
+}
+
+// LoadFromCatalog instantiates the WASM script named name, returning nil when there is none so Lua can be tried
+func LoadFromCatalog(
+	ctx context.Context, pool shared.CatalogPool, env map[string]string, name string,
+) (*Module, error) {
+	var source []byte
+	if err := pool.QueryRow(ctx, "SELECT source FROM scripts WHERE lang = 'wasm' AND name = $1", name).Scan(&source); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load script %s: %w", name, err)
+	}
+	limits, err := LimitsFromEnv(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	m, err := New(ctx, source, limits)
+	if err != nil {
+		return nil, fmt.Errorf("error loading script %s: %w", name, err)
+	}
+	return m, nil
+}
+
+// IsWasmScript reports whether the script named name is a WASM module
+func IsWasmScript(ctx context.Context, pool shared.CatalogPool, name string) (bool, error) {
+	if name == "" {
+		return false, nil
+	}
+	var exists bool
+	if err := pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM scripts WHERE lang = 'wasm' AND name = $1)", name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check language of script %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+// Close releases the module along with its runtime
+func (m *Module) Close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}
+
+// call passes input to fn, returning nil when fn returned 0
+func (m *Module) call(ctx context.Context, fn api.Function, input []byte) ([]byte, error) {
+	if m.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.callTimeout)
+		defer cancel()
+	}
+	res, err := m.alloc.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", allocExport, err)
+	}
+	ptr := api.DecodeU32(res[0])
+	if !m.module.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("%s returned out of range buffer %d of %d bytes", allocExport, ptr, len(input))
+	}
+	if res, err = fn.Call(ctx, uint64(ptr), uint64(len(input))); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", fn.Definition().ExportNames()[0], err)
+	}
+	if res[0] == 0 {
+		return nil, nil
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	output, ok := m.module.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("%s returned out of range buffer %d of %d bytes",
+			fn.Definition().ExportNames()[0], outPtr, outLen)
+	}
+	// output is a view of memory that the next call may overwrite
+	return slices.Clone(output), nil
+}
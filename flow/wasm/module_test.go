@@ -0,0 +1,189 @@
+package wasm
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+// testModule assembles a module exporting peerdb_alloc along with a function per entry of results,
+// which ignores its input and returns its result from a data segment, or 0 when it is empty
+func testModule(results map[string]string) []byte {
+	const allocOffset = 1024
+	types := vec(
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},       // (i32) -> i32
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e}, // (i32, i32) -> i64
+	)
+	functions := [][]byte{{0x00}}
+	exports := [][]byte{
+		append(name("memory"), 0x02, 0x00),
+		append(name(allocExport), 0x00, 0x00),
+	}
+	codes := [][]byte{append(append([]byte{0x00, 0x41}, sleb(allocOffset)...), 0x0b)}
+	var data [][]byte
+	offset := 16
+	for _, export := range slices.Sorted(maps.Keys(results)) {
+		result := results[export]
+		var packed int64
+		if result != "" {
+			packed = int64(offset)<<32 | int64(len(result))
+			data = append(data, append(append(append([]byte{0x00, 0x41}, sleb(int64(offset))...), 0x0b),
+				append(uleb(uint64(len(result))), result...)...))
+			offset += len(result)
+		}
+		exports = append(exports, append(name(export), 0x00, byte(len(functions))))
+		functions = append(functions, []byte{0x01})
+		body := append(append([]byte{0x00, 0x42}, sleb(packed)...), 0x0b)
+		codes = append(codes, append(uleb(uint64(len(body))), body...))
+	}
+	codes[0] = append(uleb(uint64(len(codes[0]))), codes[0]...)
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, types)...)
+	module = append(module, section(3, vec(functions...))...)
+	module = append(module, section(5, vec([]byte{0x00, 0x01}))...)
+	module = append(module, section(7, vec(exports...))...)
+	module = append(module, section(10, vec(codes...))...)
+	return append(module, section(11, vec(data...))...)
+}
+
+func TestTransformRow(t *testing.T) {
+	t.Parallel()
+
+	m, err := New(t.Context(), testModule(map[string]string{
+		transformExport: `{"id":1,"name":"changed","score":null,"added":true}`,
+	}), Limits{MemoryLimitMB: 1})
+	require.NoError(t, err)
+	defer m.Close(t.Context())
+
+	row := model.NewRecordItems(3)
+	row.AddColumn("id", types.QValueInt64{Val: 1})
+	row.AddColumn("name", types.QValueString{Val: "original"})
+	row.AddColumn("score", types.QValueFloat64{Val: 2.5})
+	keep, err := m.TransformRow(t.Context(), row)
+	require.NoError(t, err)
+	require.True(t, keep)
+	require.Equal(t, map[string]types.QValue{
+		"id":    types.QValueInt64{Val: 1},
+		"name":  types.QValueString{Val: "changed"},
+		"score": types.QValueNull(types.QValueKindFloat64),
+		"added": types.QValueBoolean{Val: true},
+	}, row.ColToVal)
+}
+
+func TestTransformRowDrop(t *testing.T) {
+	t.Parallel()
+
+	m, err := New(t.Context(), testModule(map[string]string{transformExport: ""}), Limits{})
+	require.NoError(t, err)
+	defer m.Close(t.Context())
+
+	row := model.NewRecordItems(1)
+	row.AddColumn("id", types.QValueInt64{Val: 1})
+	keep, err := m.TransformRow(t.Context(), row)
+	require.NoError(t, err)
+	require.False(t, keep)
+}
+
+func TestRouteRecord(t *testing.T) {
+	t.Parallel()
+
+	m, err := New(t.Context(), testModule(map[string]string{routeExport: "public.routed"}), Limits{})
+	require.NoError(t, err)
+	defer m.Close(t.Context())
+
+	items := model.NewRecordItems(1)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	record := &model.InsertRecord[model.RecordItems]{
+		Items:                items,
+		SourceTableName:      "public.source",
+		DestinationTableName: "public.destination",
+	}
+	keep, err := m.transformRecord(t.Context(), record)
+	require.NoError(t, err)
+	require.True(t, keep)
+	require.Equal(t, "public.routed", record.DestinationTableName)
+}
+
+func TestAttachToStream(t *testing.T) {
+	t.Parallel()
+
+	m, err := New(t.Context(), testModule(map[string]string{transformExport: `{"added":"value"}`}), Limits{})
+	require.NoError(t, err)
+
+	stream := model.NewQRecordStream(2)
+	stream.SetSchema(types.NewQRecordSchema([]types.QField{{Name: "id", Type: types.QValueKindInt64}}))
+	stream.Records <- []types.QValue{types.QValueInt64{Val: 1}}
+	stream.Records <- []types.QValue{types.QValueInt64{Val: 2}}
+	stream.Close(nil)
+
+	output := AttachToStream(t.Context(), m, stream)
+	schema, err := output.Schema()
+	require.NoError(t, err)
+	require.Equal(t, []types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "added", Type: types.QValueKindString, Nullable: true},
+	}, schema.Fields)
+	var records [][]types.QValue
+	for record := range output.Records {
+		records = append(records, record)
+	}
+	require.NoError(t, output.Err())
+	require.Equal(t, [][]types.QValue{
+		{types.QValueInt64{Val: 1}, types.QValueString{Val: "value"}},
+		{types.QValueInt64{Val: 2}, types.QValueString{Val: "value"}},
+	}, records)
+}
+
+func TestNewMissingExports(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(t.Context(), testModule(nil), Limits{})
+	require.ErrorContains(t, err, "exports neither")
+	require.Error(t, Validate(t.Context(), []byte("not wasm")))
+}
@@ -0,0 +1,103 @@
+package wasm
+
+import (
+	"context"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// AttachToStream transforms every row of stream with m, closing m once stream is consumed.
+// Columns added to the first row are appended to the schema, later rows lacking them get nulls.
+func AttachToStream(ctx context.Context, m *Module, stream *model.QRecordStream) *model.QRecordStream {
+	output := model.NewQRecordStream(0)
+	go func() {
+		defer m.Close(ctx)
+		schema, err := stream.Schema()
+		if err != nil {
+			output.Close(err)
+			return
+		}
+		var fields []types.QField
+		for record := range stream.Records {
+			row := model.NewRecordItems(len(record))
+			for i, qv := range record {
+				row.AddColumn(schema.Fields[i].Name, qv)
+			}
+			keep, err := m.TransformRow(ctx, row)
+			if err != nil {
+				output.Close(err)
+				return
+			}
+			if fields == nil {
+				fields = row.AppendMissingFields(schema.Fields)
+				output.SetSchema(types.NewQRecordSchema(fields))
+			}
+			if !keep {
+				continue
+			}
+			transformed := make([]types.QValue, len(fields))
+			for i, field := range fields {
+				if qv := row.GetColumnValue(field.Name); qv != nil {
+					transformed[i] = qv
+				} else {
+					transformed[i] = types.QValueNull(field.Type)
+				}
+			}
+			output.Records <- transformed
+		}
+		if fields == nil {
+			output.SetSchema(schema)
+		}
+		output.Close(stream.Err())
+	}()
+	return output
+}
+
+// AttachToCdcStream transforms and routes every record of stream with m, closing m once stream is consumed
+func AttachToCdcStream(
+	ctx context.Context,
+	m *Module,
+	stream *model.CDCStream[model.RecordItems],
+	onErr context.CancelCauseFunc,
+) *model.CDCStream[model.RecordItems] {
+	outstream := model.NewCDCStream[model.RecordItems](0)
+
+	handleErr := func(err error) {
+		onErr(err)
+		<-ctx.Done()
+		for range stream.GetRecords() {
+			// still read records to make sure input closes first
+		}
+	}
+
+	go func() {
+		defer m.Close(ctx)
+		if stream.WaitAndCheckEmpty() {
+			outstream.SignalAsEmpty()
+			<-stream.GetRecords() // needed because empty signal comes before Close
+		} else {
+			outstream.SignalAsNotEmpty()
+			for record := range stream.GetRecords() {
+				keep, err := m.transformRecord(ctx, record)
+				if err != nil {
+					handleErr(err)
+					break
+				}
+				if !keep {
+					continue
+				}
+				if err := outstream.AddRecord(ctx, record); err != nil {
+					handleErr(err)
+					break
+				}
+			}
+		}
+		outstream.SchemaDeltas = stream.SchemaDeltas
+		lastCP := stream.GetLastCheckpoint()
+		outstream.UpdateLatestCheckpointID(lastCP.ID)
+		outstream.UpdateLatestCheckpointText(lastCP.Text)
+		outstream.Close()
+	}()
+	return outstream
+}
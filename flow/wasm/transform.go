@@ -0,0 +1,149 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+var rowJSONOptions = model.ToJSONOptions{MaxValueSize: -1, HStoreAsJSON: true}
+
+func decodeJSON(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// jsonKind is the kind of a column added with value doc, numbers being floats like in Lua transforms
+func jsonKind(doc any) types.QValueKind {
+	switch doc.(type) {
+	case string:
+		return types.QValueKindString
+	case bool:
+		return types.QValueKindBoolean
+	case json.Number:
+		return types.QValueKindFloat64
+	case map[string]any, []any:
+		return types.QValueKindJSON
+	default:
+		return types.QValueKindInvalid
+	}
+}
+
+// TransformRow passes row to peerdb_transform_row and applies the columns it returns,
+// columns returned unchanged keep their values. It returns false when the row is to be dropped.
+func (m *Module) TransformRow(ctx context.Context, row model.RecordItems) (bool, error) {
+	if m.transform == nil {
+		return true, nil
+	}
+	input, err := row.MarshalJSONWithOptions(ctx, rowJSONOptions)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode row for %s: %w", transformExport, err)
+	}
+	output, err := m.call(ctx, m.transform, input)
+	if err != nil || output == nil {
+		return false, err
+	}
+	columns, err := decodeJSON(output)
+	if err != nil {
+		return false, fmt.Errorf("%s returned invalid JSON: %w", transformExport, err)
+	}
+	original, err := decodeJSON(input)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode row for %s: %w", transformExport, err)
+	}
+
+	for name, doc := range columns {
+		var kind types.QValueKind
+		if qv := row.GetColumnValue(name); qv != nil {
+			if reflect.DeepEqual(doc, original[name]) {
+				continue
+			}
+			kind = qv.Kind()
+		} else if kind = jsonKind(doc); kind == types.QValueKindInvalid {
+			// null columns can't be added without knowing their type
+			continue
+		}
+		if doc == nil {
+			row.AddColumn(name, types.QValueNull(kind))
+		} else if qv := model.QValueFromJSON(kind, doc); qv != nil {
+			row.AddColumn(name, qv)
+		} else {
+			return false, fmt.Errorf("%s returned %v for column %s, which does not convert to %s",
+				transformExport, doc, name, kind)
+		}
+	}
+	return true, nil
+}
+
+// routeInput is what peerdb_route receives for a record
+type routeInput struct {
+	Kind             string            `json:"kind"`
+	SourceTable      string            `json:"source_table"`
+	DestinationTable string            `json:"destination_table"`
+	Row              model.RecordItems `json:"row"`
+}
+
+// RouteRecord passes record to peerdb_route, changing its destination to the one returned
+func (m *Module) RouteRecord(ctx context.Context, record model.Record[model.RecordItems]) error {
+	if m.route == nil {
+		return nil
+	}
+	input := routeInput{
+		Kind:             record.Kind(),
+		SourceTable:      record.GetSourceTableName(),
+		DestinationTable: record.GetDestinationTableName(),
+		Row:              record.GetItems(),
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode record for %s: %w", routeExport, err)
+	}
+	output, err := m.call(ctx, m.route, data)
+	if err != nil || len(output) == 0 {
+		return err
+	}
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		r.DestinationTableName = string(output)
+	case *model.UpdateRecord[model.RecordItems]:
+		r.DestinationTableName = string(output)
+	case *model.DeleteRecord[model.RecordItems]:
+		r.DestinationTableName = string(output)
+	}
+	return nil
+}
+
+// transformRecord transforms the rows of record then routes it, returning false when it is to be dropped.
+// Records other than inserts, updates and deletes pass through.
+func (m *Module) transformRecord(ctx context.Context, record model.Record[model.RecordItems]) (bool, error) {
+	var rows []model.RecordItems
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		rows = []model.RecordItems{r.Items}
+	case *model.UpdateRecord[model.RecordItems]:
+		rows = []model.RecordItems{r.OldItems, r.NewItems}
+	case *model.DeleteRecord[model.RecordItems]:
+		rows = []model.RecordItems{r.Items}
+	default:
+		return true, nil
+	}
+	for _, row := range rows {
+		if row.ColToVal == nil {
+			continue
+		}
+		if keep, err := m.TransformRow(ctx, row); err != nil || !keep {
+			return false, err
+		}
+	}
+	return true, m.RouteRecord(ctx, record)
+}
@@ -35,6 +35,14 @@ type CDCFlowWorkflowState struct {
 	// Current signalled state of the peer flow.
 	ActiveSignal      model.CDCFlowSignal
 	CurrentFlowStatus protos.FlowStatus
+	// latest end-to-end lag per destination table, reported by SyncFlow
+	TableLag map[string]*protos.TableReplicationLag
+	// tables of paused table groups, removed from SyncFlowOptions.TableMappings
+	PausedTableMappings []*protos.TableMapping
+	// compliance with FlowConnectionConfigs.FreshnessSlo, nil until first evaluated
+	FreshnessSlo *protos.FreshnessSloStatus
+	// change capture pause requested during a snapshot, applied once the snapshot completes
+	PauseAfterSnapshot bool
 }
 
 // returns a new empty PeerFlowState
@@ -43,13 +51,19 @@ func NewCDCFlowWorkflowState(ctx workflow.Context, logger log.Logger, cfg *proto
 	for _, tableMapping := range cfg.TableMappings {
 		tableMappings = append(tableMappings, proto.CloneOf(tableMapping))
 	}
+	pausedTableMappings := make([]*protos.TableMapping, 0, len(cfg.PausedTableMappings))
+	for _, tableMapping := range cfg.PausedTableMappings {
+		pausedTableMappings = append(pausedTableMappings, proto.CloneOf(tableMapping))
+	}
 	state := CDCFlowWorkflowState{
-		ActiveSignal:      model.NoopSignal,
-		CurrentFlowStatus: protos.FlowStatus_STATUS_SETUP,
-		FlowConfigUpdate:  nil,
+		ActiveSignal:        model.NoopSignal,
+		CurrentFlowStatus:   protos.FlowStatus_STATUS_SETUP,
+		FlowConfigUpdate:    nil,
+		PausedTableMappings: pausedTableMappings,
 		SyncFlowOptions: &protos.SyncFlowOptions{
 			BatchSize:          cfg.MaxBatchSize,
 			IdleTimeoutSeconds: cfg.IdleTimeoutSeconds,
+			MaxBatchBytes:      cfg.MaxBatchBytes,
 			TableMappings:      tableMappings,
 			NumberOfSyncs:      0,
 		},
@@ -97,7 +111,9 @@ func updateFlowConfigWithLatestSettings(
 	cloneCfg := proto.CloneOf(cfg)
 	cloneCfg.MaxBatchSize = state.SyncFlowOptions.BatchSize
 	cloneCfg.IdleTimeoutSeconds = state.SyncFlowOptions.IdleTimeoutSeconds
+	cloneCfg.MaxBatchBytes = state.SyncFlowOptions.MaxBatchBytes
 	cloneCfg.TableMappings = state.SyncFlowOptions.TableMappings
+	cloneCfg.PausedTableMappings = state.PausedTableMappings
 	return cloneCfg
 }
 
@@ -145,11 +161,19 @@ func processCDCFlowConfigUpdate(
 	if flowConfigUpdate.IdleTimeout > 0 {
 		state.SyncFlowOptions.IdleTimeoutSeconds = flowConfigUpdate.IdleTimeout
 	}
+	if flowConfigUpdate.MaxBatchBytes != nil {
+		state.SyncFlowOptions.MaxBatchBytes = *flowConfigUpdate.MaxBatchBytes
+	}
 	if flowConfigUpdate.NumberOfSyncs > 0 {
 		state.SyncFlowOptions.NumberOfSyncs = flowConfigUpdate.NumberOfSyncs
 	} else if flowConfigUpdate.NumberOfSyncs < 0 {
 		state.SyncFlowOptions.NumberOfSyncs = 0
 	}
+	if flowConfigUpdate.FreshnessSlo != nil {
+		cfg.FreshnessSlo = flowConfigUpdate.FreshnessSlo
+		// compliance is tracked against the latest SLO
+		state.FreshnessSlo = nil
+	}
 	if flowConfigUpdate.UpdatedEnv != nil {
 		if cfg.Env == nil {
 			cfg.Env = make(map[string]string, len(flowConfigUpdate.UpdatedEnv))
@@ -157,6 +181,8 @@ func processCDCFlowConfigUpdate(
 		maps.Copy(cfg.Env, flowConfigUpdate.UpdatedEnv)
 	}
 
+	pausedTables := expandTableGroupUpdates(flowConfigUpdate, state)
+
 	tablesAreAdded := len(flowConfigUpdate.AdditionalTables) > 0
 	tablesAreRemoved := len(flowConfigUpdate.RemovedTables) > 0
 	if !tablesAreAdded && !tablesAreRemoved {
@@ -166,24 +192,65 @@ func processCDCFlowConfigUpdate(
 
 	logger.Info("processing CDCFlowConfigUpdate", slog.Any("updatedState", flowConfigUpdate))
 
-	if tablesAreAdded {
+	// removals go first, so that resyncing a table group can remove and re-add its tables in one update.
+	// Updates processed before that keep adding first when replayed
+	removeFirst := !tablesAreAdded || !tablesAreRemoved ||
+		workflow.GetVersion(ctx, "cdc-config-update-removals-first", workflow.DefaultVersion, 1) != workflow.DefaultVersion
+	if !removeFirst {
 		if err := processTableAdditions(ctx, logger, cfg, state, mirrorNameSearch); err != nil {
 			logger.Error("failed to process additional tables", slog.Any("error", err))
 			return err
 		}
 	}
-
 	if tablesAreRemoved {
 		if err := processTableRemovals(ctx, logger, cfg, state); err != nil {
 			logger.Error("failed to process removed tables", slog.Any("error", err))
 			return err
 		}
 	}
+	state.PausedTableMappings = append(state.PausedTableMappings, pausedTables...)
+
+	if tablesAreAdded && removeFirst {
+		if err := processTableAdditions(ctx, logger, cfg, state, mirrorNameSearch); err != nil {
+			logger.Error("failed to process additional tables", slog.Any("error", err))
+			return err
+		}
+	}
 
 	syncStateToConfigProtoInCatalog(ctx, cfg, state)
 	return nil
 }
 
+// expandTableGroupUpdates turns table group operations of the pending update into table additions and removals.
+// Resumed groups are taken out of state.PausedTableMappings, the tables of newly paused groups are returned,
+// to be added to state.PausedTableMappings once they have been removed.
+func expandTableGroupUpdates(flowConfigUpdate *protos.CDCFlowConfigUpdate, state *CDCFlowWorkflowState) []*protos.TableMapping {
+	var pausedTables []*protos.TableMapping
+	for _, tm := range state.SyncFlowOptions.TableMappings {
+		if tm.TableGroup == "" {
+			continue
+		}
+		if slices.Contains(flowConfigUpdate.PausedTableGroups, tm.TableGroup) {
+			flowConfigUpdate.RemovedTables = append(flowConfigUpdate.RemovedTables, tm)
+			pausedTables = append(pausedTables, tm)
+		} else if slices.Contains(flowConfigUpdate.ResyncedTableGroups, tm.TableGroup) {
+			flowConfigUpdate.RemovedTables = append(flowConfigUpdate.RemovedTables, tm)
+			flowConfigUpdate.AdditionalTables = append(flowConfigUpdate.AdditionalTables, proto.CloneOf(tm))
+		}
+	}
+
+	if len(flowConfigUpdate.ResumedTableGroups) > 0 {
+		state.PausedTableMappings = slices.DeleteFunc(state.PausedTableMappings, func(tm *protos.TableMapping) bool {
+			if slices.Contains(flowConfigUpdate.ResumedTableGroups, tm.TableGroup) {
+				flowConfigUpdate.AdditionalTables = append(flowConfigUpdate.AdditionalTables, tm)
+				return true
+			}
+			return false
+		})
+	}
+	return pausedTables
+}
+
 func processTableAdditions(
 	ctx workflow.Context,
 	logger log.Logger,
@@ -254,6 +321,8 @@ func processTableAdditions(
 				SkipDestinationDrop:   val.SkipDestinationDrop,
 				Resync:                true,
 			}
+		} else if val.Scope == protos.FlowStateChangeScope_FLOW_STATE_CHANGE_SCOPE_CDC {
+			handleSnapshotCDCStateChange(logger, state, val)
 		} else if val.RequestedFlowState == protos.FlowStatus_STATUS_PAUSED {
 			logger.Info("pause requested during table additions, ignoring")
 		}
@@ -311,6 +380,19 @@ func processTableAdditions(
 	return nil
 }
 
+// handleSnapshotCDCStateChange handles pausing or resuming only change capture while a snapshot is running,
+// the snapshot keeps running and the pause is applied once it completes
+func handleSnapshotCDCStateChange(logger log.Logger, state *CDCFlowWorkflowState, val *protos.FlowStateChangeRequest) {
+	switch val.RequestedFlowState {
+	case protos.FlowStatus_STATUS_PAUSED:
+		logger.Info("change capture pause requested during snapshot, pausing once it completes")
+		state.PauseAfterSnapshot = true
+	case protos.FlowStatus_STATUS_RUNNING:
+		logger.Info("change capture resume requested during snapshot")
+		state.PauseAfterSnapshot = false
+	}
+}
+
 func processTableRemovals(
 	ctx workflow.Context,
 	logger log.Logger,
@@ -369,6 +451,9 @@ func processTableRemovals(
 		_, removed := removedTables[tm.SourceTableIdentifier]
 		return removed
 	})
+	for _, removedTable := range state.FlowConfigUpdate.RemovedTables {
+		delete(state.TableLag, removedTable.DestinationTableIdentifier)
+	}
 
 	return nil
 }
@@ -386,14 +471,102 @@ func addCdcPropertiesSignalListener(
 		logger.Info("CDC Signal received",
 			slog.Int("BatchSize", int(state.SyncFlowOptions.BatchSize)),
 			slog.Int("IdleTimeout", int(state.SyncFlowOptions.IdleTimeoutSeconds)),
+			slog.Uint64("MaxBatchBytes", state.SyncFlowOptions.MaxBatchBytes),
 			slog.Any("AdditionalTables", cdcConfigUpdate.AdditionalTables),
 			slog.Any("RemovedTables", cdcConfigUpdate.RemovedTables),
+			slog.Any("PausedTableGroups", cdcConfigUpdate.PausedTableGroups),
+			slog.Any("ResumedTableGroups", cdcConfigUpdate.ResumedTableGroups),
+			slog.Any("ResyncedTableGroups", cdcConfigUpdate.ResyncedTableGroups),
 			slog.Int("NumberOfSyncs", int(state.SyncFlowOptions.NumberOfSyncs)),
 			slog.Any("UpdatedEnv", cdcConfigUpdate.UpdatedEnv),
 		)
 	})
 }
 
+// addMirrorEnvSignalListener applies dynamic setting overrides to cfg and saves them to the catalog,
+// onUpdated is called so the running sync can pick them up
+func addMirrorEnvSignalListener(
+	ctx workflow.Context,
+	logger log.Logger,
+	selector workflow.Selector,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+	onUpdated func(),
+) {
+	model.MirrorEnvUpdateSignal.GetSignalChannel(ctx).AddToSelector(selector, func(update *protos.MirrorEnvUpdate, _ bool) {
+		logger.Info("mirror env update received", slog.Any("env", update.Env), slog.Any("removedKeys", update.RemovedKeys))
+		if cfg.Env == nil {
+			cfg.Env = make(map[string]string, len(update.Env))
+		}
+		maps.Copy(cfg.Env, update.Env)
+		for _, key := range update.RemovedKeys {
+			delete(cfg.Env, key)
+		}
+		syncStateToConfigProtoInCatalog(ctx, cfg, state)
+		if onUpdated != nil {
+			onUpdated()
+		}
+	})
+}
+
+// addWorkerPoolSignalListener moves the mirror to another worker pool, taking effect when it continues as new,
+// onMoved is called so a running mirror can do so right away
+func addWorkerPoolSignalListener(
+	ctx workflow.Context,
+	logger log.Logger,
+	selector workflow.Selector,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+	onMoved func(),
+) {
+	model.WorkerPoolSignal.GetSignalChannel(ctx).AddToSelector(selector, func(workerPool string, _ bool) {
+		logger.Info("worker pool move received", slog.String("from", cfg.WorkerPool), slog.String("to", workerPool))
+		if workerPool == cfg.WorkerPool {
+			return
+		}
+		cfg.WorkerPool = workerPool
+		syncStateToConfigProtoInCatalog(ctx, cfg, state)
+		if onMoved != nil {
+			onMoved()
+		}
+	})
+}
+
+// continueOnWorkerPool continues the mirror as new on the task queue of its worker pool
+func continueOnWorkerPool(ctx workflow.Context, cfg *protos.FlowConnectionConfigs, state *CDCFlowWorkflowState) error {
+	if taskQueue := internal.WorkerPoolTaskQueueName(cfg.WorkerPool); workflow.GetInfo(ctx).TaskQueueName != taskQueue {
+		ctx = workflow.WithWorkflowTaskQueue(ctx, taskQueue)
+	}
+	return workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
+}
+
+// addTableLagSignalListener also evaluates the freshness SLO of the mirror,
+// onRemediated is called when sync settings were changed to recover from a breach, nil disables remediation
+func addTableLagSignalListener(
+	ctx workflow.Context,
+	logger log.Logger,
+	selector workflow.Selector,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+	onRemediated func(),
+) {
+	tableLagSignalChan := model.TableLagSignal.GetSignalChannel(ctx)
+	tableLagSignalChan.AddToSelector(selector, func(lags []*protos.TableReplicationLag, _ bool) {
+		if state.TableLag == nil {
+			state.TableLag = make(map[string]*protos.TableReplicationLag, len(lags))
+		}
+		for _, lag := range lags {
+			state.TableLag[lag.DestinationTableName] = lag
+		}
+		if onRemediated == nil {
+			return
+		}
+		if evaluateFreshnessSlo(ctx, logger, cfg.FreshnessSlo, state, lags) {
+			onRemediated()
+		}
+	})
+}
+
 func CDCFlowWorkflow(
 	ctx workflow.Context,
 	cfg *protos.FlowConnectionConfigs,
@@ -420,6 +593,16 @@ func CDCFlowWorkflow(
 	}); err != nil {
 		return state, fmt.Errorf("failed to set `%s` query handler: %w", shared.FlowStatusQuery, err)
 	}
+	if err := workflow.SetQueryHandler(ctx, shared.CDCTableLagQuery, func() (map[string]*protos.TableReplicationLag, error) {
+		return state.TableLag, nil
+	}); err != nil {
+		return state, fmt.Errorf("failed to set `%s` query handler: %w", shared.CDCTableLagQuery, err)
+	}
+	if err := workflow.SetQueryHandler(ctx, shared.CDCFreshnessSloQuery, func() (*protos.FreshnessSloStatus, error) {
+		return state.FreshnessSlo, nil
+	}); err != nil {
+		return state, fmt.Errorf("failed to set `%s` query handler: %w", shared.CDCFreshnessSloQuery, err)
+	}
 
 	if state.CurrentFlowStatus == protos.FlowStatus_STATUS_COMPLETED {
 		return state, nil
@@ -455,9 +638,16 @@ func CDCFlowWorkflow(
 					SkipDestinationDrop:   val.SkipDestinationDrop,
 					Resync:                true,
 				}
+			} else if val.Scope == protos.FlowStateChangeScope_FLOW_STATE_CHANGE_SCOPE_CDC &&
+				val.RequestedFlowState == protos.FlowStatus_STATUS_RUNNING {
+				// requested while the snapshot was completing, change capture is what is paused now
+				state.ActiveSignal = model.FlowSignalHandler(state.ActiveSignal, model.NoopSignal, logger)
 			}
 		})
 		addCdcPropertiesSignalListener(ctx, logger, selector, state)
+		addMirrorEnvSignalListener(ctx, logger, selector, cfg, state, nil)
+		addWorkerPoolSignalListener(ctx, logger, selector, cfg, state, nil)
+		addTableLagSignalListener(ctx, logger, selector, cfg, state, nil)
 		startTime := workflow.Now(ctx)
 		state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_PAUSED)
 
@@ -481,13 +671,18 @@ func CDCFlowWorkflow(
 				logger.Info("wiping flow state after state update processing")
 				// finished processing, wipe it
 				state.FlowConfigUpdate = nil
-				state.ActiveSignal = model.NoopSignal
+				if state.PauseAfterSnapshot {
+					logger.Info("table additions completed, keeping change capture paused as requested")
+					state.PauseAfterSnapshot = false
+				} else {
+					state.ActiveSignal = model.NoopSignal
+				}
 			}
 		}
 
 		logger.Info(fmt.Sprintf("mirror has been resumed after %s", time.Since(startTime).Round(time.Second)))
 		state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_RUNNING)
-		return state, workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
+		return state, continueOnWorkerPool(ctx, cfg, state)
 	}
 
 	originalRunID := workflow.GetInfo(ctx).OriginalRunID
@@ -532,7 +727,9 @@ func CDCFlowWorkflow(
 		setupSnapshotSelector := workflow.NewNamedSelector(ctx, "Setup/Snapshot")
 		setupSnapshotSelector.AddReceive(ctx.Done(), func(_ workflow.ReceiveChannel, _ bool) {})
 		flowSignalStateChangeChan.AddToSelector(setupSnapshotSelector, func(val *protos.FlowStateChangeRequest, _ bool) {
-			if val.RequestedFlowState == protos.FlowStatus_STATUS_PAUSED {
+			if val.Scope == protos.FlowStateChangeScope_FLOW_STATE_CHANGE_SCOPE_CDC {
+				handleSnapshotCDCStateChange(logger, state, val)
+			} else if val.RequestedFlowState == protos.FlowStatus_STATUS_PAUSED {
 				logger.Warn("pause requested during setup, ignoring")
 			} else if val.RequestedFlowState == protos.FlowStatus_STATUS_TERMINATING {
 				state.ActiveSignal = model.TerminateSignal
@@ -694,11 +891,15 @@ func CDCFlowWorkflow(
 		if cfg.InitialSnapshotOnly {
 			logger.Info("initial snapshot only, ending flow")
 			state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_COMPLETED)
+		} else if state.PauseAfterSnapshot {
+			logger.Info("executed setup flow and snapshot flow, pausing change capture as requested")
+			state.PauseAfterSnapshot = false
+			state.ActiveSignal = model.PauseSignal
 		} else {
 			logger.Info("executed setup flow and snapshot flow, start running")
 			state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_RUNNING)
 		}
-		return state, workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
+		return state, continueOnWorkerPool(ctx, cfg, state)
 	}
 
 	var finished bool
@@ -793,10 +994,28 @@ func CDCFlowWorkflow(
 				SkipDestinationDrop:   val.SkipDestinationDrop,
 				Resync:                true,
 			}
+		} else if val.Scope == protos.FlowStateChangeScope_FLOW_STATE_CHANGE_SCOPE_CDC &&
+			val.RequestedFlowState == protos.FlowStatus_STATUS_PAUSED {
+			// requested while the snapshot was completing, change capture is what runs now
+			state.ActiveSignal = model.FlowSignalHandler(state.ActiveSignal, model.PauseSignal, logger)
 		}
 	})
 
 	addCdcPropertiesSignalListener(ctx, logger, mainLoopSelector, state)
+	addMirrorEnvSignalListener(ctx, logger, mainLoopSelector, cfg, state, func() {
+		// SyncFlow reads the env it was started with, so restart it
+		finished = true
+	})
+	addWorkerPoolSignalListener(ctx, logger, mainLoopSelector, cfg, state, func() {
+		finished = true
+	})
+	onFreshnessSloRemediated := func() {
+		// SyncFlow picks up the new options after continuing as new
+		syncStateToConfigProtoInCatalog(ctx, cfg, state)
+		finished = true
+	}
+	addTableLagSignalListener(ctx, logger, mainLoopSelector, cfg, state, onFreshnessSloRemediated)
+	addFreshnessSloTimer(ctx, logger, mainLoopSelector, cfg, state, onFreshnessSloRemediated)
 
 	state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_RUNNING)
 	for {
@@ -837,7 +1056,7 @@ func CDCFlowWorkflow(
 			if state.ActiveSignal == model.TerminateSignal || state.ActiveSignal == model.ResyncSignal {
 				return state, workflow.NewContinueAsNewError(ctx, DropFlowWorkflow, state.DropFlowInput)
 			}
-			return state, workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
+			return state, continueOnWorkerPool(ctx, cfg, state)
 		}
 	}
 }
@@ -0,0 +1,36 @@
+package peerflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// CutoverReportWorkflow creates a signed report of the state of a CDC mirror at cutover,
+// best run once the mirror is paused so counts on both sides line up.
+func CutoverReportWorkflow(
+	ctx workflow.Context,
+	input *protos.CutoverReportInput,
+) (*protos.CutoverReportOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("creating cutover report", "flowName", input.FlowJobName)
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 24 * time.Hour,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Minute,
+			MaximumAttempts: 3,
+		},
+	})
+
+	var output *protos.CutoverReportOutput
+	if err := workflow.ExecuteActivity(ctx, flowable.CreateCutoverReport, input).Get(ctx, &output); err != nil {
+		logger.Error("failed to create cutover report", "error", err)
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,37 @@
+package peerflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// DeduplicateDestinationWorkflow removes rows that an interrupted sync applied twice to
+// append-only destination tables of a CDC mirror.
+func DeduplicateDestinationWorkflow(
+	ctx workflow.Context,
+	input *protos.DeduplicateDestinationInput,
+) (*protos.DeduplicateDestinationOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("deduplicating destination", "flowName", input.FlowJobName,
+		"startBatchID", input.StartBatchId, "endBatchID", input.EndBatchId)
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 24 * time.Hour,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Minute,
+			MaximumAttempts: 5,
+		},
+	})
+
+	var output *protos.DeduplicateDestinationOutput
+	if err := workflow.ExecuteActivity(ctx, flowable.DeduplicateDestination, input).Get(ctx, &output); err != nil {
+		logger.Error("failed to deduplicate destination", "error", err)
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,192 @@
+package peerflow
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const (
+	// minimum time between remediation steps, giving the previous step time to show an effect on lag
+	freshnessSloRemediationInterval = 10 * time.Minute
+	// how often the freshness SLO is evaluated from the last sync, to catch mirrors that stopped syncing
+	freshnessSloCheckInterval = time.Minute
+)
+
+// evaluateFreshnessSlo updates compliance of the mirror with its freshness SLO from newly reported table lag,
+// returns true if SyncFlowOptions were changed to remediate a breach, in which case SyncFlow needs a restart
+func evaluateFreshnessSlo(
+	ctx workflow.Context,
+	logger log.Logger,
+	slo *protos.FreshnessSlo,
+	state *CDCFlowWorkflowState,
+	lags []*protos.TableReplicationLag,
+) bool {
+	if slo.GetMaxStalenessSeconds() == 0 || len(lags) == 0 {
+		return false
+	}
+
+	var staleness float64
+	for _, lag := range lags {
+		staleness = max(staleness, lag.LagSeconds)
+	}
+	return updateFreshnessSlo(logger, slo, state, staleness, workflow.Now(ctx))
+}
+
+// evaluateStalledFreshnessSlo updates compliance of the mirror with its freshness SLO from time since its last sync.
+// Syncs complete at least every idle timeout even without records, so only past that does time since the last sync
+// count as staleness. Table lag is only reported for synced records, so this is what catches a stalled mirror
+func evaluateStalledFreshnessSlo(
+	logger log.Logger,
+	slo *protos.FreshnessSlo,
+	state *CDCFlowWorkflowState,
+	lastSyncAt time.Time,
+	now time.Time,
+) bool {
+	// mirrors that never synced are still setting up or snapshotting
+	if slo.GetMaxStalenessSeconds() == 0 || lastSyncAt.IsZero() {
+		return false
+	}
+	sinceSync := now.Sub(lastSyncAt)
+	if sinceSync <= time.Duration(state.SyncFlowOptions.IdleTimeoutSeconds)*time.Second {
+		return false
+	}
+	return updateFreshnessSlo(logger, slo, state, sinceSync.Seconds(), now)
+}
+
+func updateFreshnessSlo(
+	logger log.Logger,
+	slo *protos.FreshnessSlo,
+	state *CDCFlowWorkflowState,
+	staleness float64,
+	now time.Time,
+) bool {
+	if state.FreshnessSlo == nil {
+		state.FreshnessSlo = &protos.FreshnessSloStatus{}
+	}
+	status := state.FreshnessSlo
+	status.StalenessSeconds = staleness
+	if staleness <= float64(slo.MaxStalenessSeconds) {
+		if status.Breached {
+			logger.Info("freshness SLO recovered", slog.Float64("stalenessSeconds", staleness),
+				slog.Duration("breachedFor", now.Sub(status.BreachedSince.AsTime())))
+		}
+		status.Breached = false
+		status.BreachedSince = nil
+		status.CompliantEvaluations += 1
+		return false
+	}
+
+	if !status.Breached {
+		logger.Warn("freshness SLO breached", slog.Float64("stalenessSeconds", staleness),
+			slog.Uint64("maxStalenessSeconds", slo.MaxStalenessSeconds))
+		status.Breached = true
+		status.BreachedSince = timestamppb.New(now)
+	}
+	status.BreachedEvaluations += 1
+
+	if !slo.AutoRemediate ||
+		(status.LastRemediatedAt != nil && now.Sub(status.LastRemediatedAt.AsTime()) < freshnessSloRemediationInterval) {
+		return false
+	}
+	return remediateFreshnessSlo(logger, slo, state, now)
+}
+
+// remediateFreshnessSlo syncs more often and in larger batches, halving the idle timeout and doubling the batch size
+// within the bounds of the SLO
+func remediateFreshnessSlo(
+	logger log.Logger,
+	slo *protos.FreshnessSlo,
+	state *CDCFlowWorkflowState,
+	now time.Time,
+) bool {
+	options := state.SyncFlowOptions
+	idleTimeout := options.IdleTimeoutSeconds
+	if slo.MinIdleTimeoutSeconds > 0 && idleTimeout > slo.MinIdleTimeoutSeconds {
+		idleTimeout = max(idleTimeout/2, slo.MinIdleTimeoutSeconds)
+	}
+	batchSize := options.BatchSize
+	if slo.MaxBatchSize > 0 && batchSize > 0 && batchSize < slo.MaxBatchSize {
+		batchSize = uint32(min(uint64(batchSize)*2, uint64(slo.MaxBatchSize)))
+	}
+	if idleTimeout == options.IdleTimeoutSeconds && batchSize == options.BatchSize {
+		logger.Warn("freshness SLO breached, but sync settings are already at their remediation bounds",
+			slog.Uint64("idleTimeoutSeconds", idleTimeout), slog.Uint64("batchSize", uint64(batchSize)))
+		return false
+	}
+
+	logger.Info("remediating freshness SLO breach",
+		slog.Uint64("previousIdleTimeoutSeconds", options.IdleTimeoutSeconds), slog.Uint64("idleTimeoutSeconds", idleTimeout),
+		slog.Uint64("previousBatchSize", uint64(options.BatchSize)), slog.Uint64("batchSize", uint64(batchSize)))
+	options.IdleTimeoutSeconds = idleTimeout
+	options.BatchSize = batchSize
+	state.FreshnessSlo.Remediations += 1
+	state.FreshnessSlo.LastRemediatedAt = timestamppb.New(now)
+	return true
+}
+
+// addFreshnessSloTimer evaluates the freshness SLO from the last sync of the mirror every freshnessSloCheckInterval,
+// onRemediated is called when sync settings were changed to recover from a breach
+func addFreshnessSloTimer(
+	ctx workflow.Context,
+	logger log.Logger,
+	selector workflow.Selector,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+	onRemediated func(),
+) {
+	if cfg.FreshnessSlo.GetMaxStalenessSeconds() == 0 ||
+		workflow.GetVersion(ctx, "freshness-slo-timer", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return
+	}
+
+	lastSyncCtx := workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+	var addTimer func()
+	addTimer = func() {
+		selector.AddFuture(workflow.NewTimer(ctx, freshnessSloCheckInterval), func(f workflow.Future) {
+			if err := f.Get(ctx, nil); err != nil {
+				return
+			}
+			lastSyncFuture := workflow.ExecuteLocalActivity(lastSyncCtx, lastSyncAtActivity, cfg.FlowJobName)
+			selector.AddFuture(lastSyncFuture, func(f workflow.Future) {
+				var lastSyncAt time.Time
+				if err := f.Get(lastSyncCtx, &lastSyncAt); err != nil {
+					logger.Warn("failed to get last sync of mirror for freshness SLO", slog.Any("error", err))
+				} else if evaluateStalledFreshnessSlo(logger, cfg.FreshnessSlo, state, lastSyncAt, workflow.Now(ctx)) {
+					onRemediated()
+				}
+				addTimer()
+			})
+		})
+	}
+	addTimer()
+}
+
+// lastSyncAtActivity returns when a sync of the mirror last completed, zero if it never did
+func lastSyncAtActivity(ctx context.Context, flowJobName string) (time.Time, error) {
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get catalog connection pool: %w", err)
+	}
+	var lastSyncAt *time.Time
+	if err := pool.QueryRow(ctx, `SELECT greatest(
+			(SELECT last_sync_at FROM peerdb_stats.cdc_flows WHERE flow_name = $1),
+			(SELECT max(end_time) FROM peerdb_stats.cdc_batches WHERE flow_name = $1)
+		)`, flowJobName).Scan(&lastSyncAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read last sync from catalog: %w", err)
+	}
+	if lastSyncAt == nil {
+		return time.Time{}, nil
+	}
+	return *lastSyncAt, nil
+}
@@ -0,0 +1,122 @@
+package peerflow
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+var discardLogger = log.NewStructuredLogger(slog.New(slog.DiscardHandler))
+
+func freshnessSloState(idleTimeoutSeconds uint64, batchSize uint32) *CDCFlowWorkflowState {
+	return &CDCFlowWorkflowState{
+		SyncFlowOptions: &protos.SyncFlowOptions{IdleTimeoutSeconds: idleTimeoutSeconds, BatchSize: batchSize},
+	}
+}
+
+func TestFreshnessSloBreachAndRecovery(t *testing.T) {
+	t.Parallel()
+
+	slo := &protos.FreshnessSlo{MaxStalenessSeconds: 60}
+	state := freshnessSloState(60, 1000)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.False(t, updateFreshnessSlo(discardLogger, slo, state, 30, now))
+	require.False(t, state.FreshnessSlo.Breached)
+	require.EqualValues(t, 1, state.FreshnessSlo.CompliantEvaluations)
+
+	require.False(t, updateFreshnessSlo(discardLogger, slo, state, 90, now))
+	require.True(t, state.FreshnessSlo.Breached)
+	require.Equal(t, now, state.FreshnessSlo.BreachedSince.AsTime())
+	require.False(t, updateFreshnessSlo(discardLogger, slo, state, 120, now.Add(time.Minute)))
+	// breached since the first evaluation above the SLO
+	require.Equal(t, now, state.FreshnessSlo.BreachedSince.AsTime())
+	require.EqualValues(t, 2, state.FreshnessSlo.BreachedEvaluations)
+	require.Zero(t, state.FreshnessSlo.Remediations, "remediation is opt-in")
+	require.EqualValues(t, 60, state.SyncFlowOptions.IdleTimeoutSeconds)
+
+	require.False(t, updateFreshnessSlo(discardLogger, slo, state, 60, now.Add(2*time.Minute)))
+	require.False(t, state.FreshnessSlo.Breached)
+	require.Nil(t, state.FreshnessSlo.BreachedSince)
+	require.InDelta(t, 60, state.FreshnessSlo.StalenessSeconds, 0)
+	require.EqualValues(t, 2, state.FreshnessSlo.CompliantEvaluations)
+}
+
+func TestFreshnessSloRemediationBounds(t *testing.T) {
+	t.Parallel()
+
+	slo := &protos.FreshnessSlo{MaxStalenessSeconds: 60, AutoRemediate: true, MinIdleTimeoutSeconds: 10, MaxBatchSize: 1000}
+	state := freshnessSloState(60, 300)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.True(t, updateFreshnessSlo(discardLogger, slo, state, 90, now))
+	require.EqualValues(t, 30, state.SyncFlowOptions.IdleTimeoutSeconds)
+	require.EqualValues(t, 600, state.SyncFlowOptions.BatchSize)
+
+	// the previous step gets time to show an effect
+	require.False(t, updateFreshnessSlo(discardLogger, slo, state, 90, now.Add(5*time.Minute)))
+	require.EqualValues(t, 30, state.SyncFlowOptions.IdleTimeoutSeconds)
+
+	now = now.Add(freshnessSloRemediationInterval)
+	require.True(t, updateFreshnessSlo(discardLogger, slo, state, 90, now))
+	require.EqualValues(t, 15, state.SyncFlowOptions.IdleTimeoutSeconds)
+	require.EqualValues(t, 1000, state.SyncFlowOptions.BatchSize)
+
+	now = now.Add(freshnessSloRemediationInterval)
+	require.True(t, updateFreshnessSlo(discardLogger, slo, state, 90, now))
+	require.EqualValues(t, 10, state.SyncFlowOptions.IdleTimeoutSeconds)
+	require.EqualValues(t, 1000, state.SyncFlowOptions.BatchSize)
+
+	now = now.Add(freshnessSloRemediationInterval)
+	require.False(t, updateFreshnessSlo(discardLogger, slo, state, 90, now), "already at bounds")
+	require.EqualValues(t, 10, state.SyncFlowOptions.IdleTimeoutSeconds)
+	require.EqualValues(t, 1000, state.SyncFlowOptions.BatchSize)
+	require.EqualValues(t, 3, state.FreshnessSlo.Remediations)
+	require.Equal(t, now.Add(-freshnessSloRemediationInterval), state.FreshnessSlo.LastRemediatedAt.AsTime())
+}
+
+func TestFreshnessSloStalledMirror(t *testing.T) {
+	t.Parallel()
+
+	slo := &protos.FreshnessSlo{MaxStalenessSeconds: 300, AutoRemediate: true, MinIdleTimeoutSeconds: 15}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("never synced", func(t *testing.T) {
+		state := freshnessSloState(60, 1000)
+		require.False(t, evaluateStalledFreshnessSlo(discardLogger, slo, state, time.Time{}, now))
+		require.Nil(t, state.FreshnessSlo)
+	})
+
+	t.Run("idle within idle timeout", func(t *testing.T) {
+		state := freshnessSloState(60, 1000)
+		require.False(t, evaluateStalledFreshnessSlo(discardLogger, slo, state, now.Add(-time.Minute), now))
+		require.Nil(t, state.FreshnessSlo, "left to table lag")
+	})
+
+	t.Run("stalled and recovered", func(t *testing.T) {
+		state := freshnessSloState(60, 1000)
+		require.False(t, evaluateStalledFreshnessSlo(discardLogger, slo, state, now.Add(-2*time.Minute), now))
+		require.False(t, state.FreshnessSlo.Breached)
+		require.InDelta(t, 120, state.FreshnessSlo.StalenessSeconds, 0)
+
+		require.True(t, evaluateStalledFreshnessSlo(discardLogger, slo, state, now.Add(-10*time.Minute), now))
+		require.True(t, state.FreshnessSlo.Breached)
+		require.InDelta(t, 600, state.FreshnessSlo.StalenessSeconds, 0)
+		require.EqualValues(t, 30, state.SyncFlowOptions.IdleTimeoutSeconds)
+
+		// syncs resume and report lag within the SLO
+		require.False(t, updateFreshnessSlo(discardLogger, slo, state, 20, now.Add(time.Minute)))
+		require.False(t, state.FreshnessSlo.Breached)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		state := freshnessSloState(60, 1000)
+		require.False(t, evaluateStalledFreshnessSlo(discardLogger, &protos.FreshnessSlo{}, state, now.Add(-time.Hour), now))
+		require.Nil(t, state.FreshnessSlo)
+	})
+}
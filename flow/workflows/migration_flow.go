@@ -0,0 +1,181 @@
+package peerflow
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const (
+	migrationCatchUpCheckInterval = time.Minute
+	// writes are frozen while draining, so check more often to keep the downtime window short
+	migrationDrainCheckInterval = 5 * time.Second
+)
+
+type migrationExecutor struct {
+	input  *protos.MigrationFlowInput
+	state  *protos.MigrationState
+	logger log.Logger
+}
+
+func (m *migrationExecutor) setPhase(ctx workflow.Context, phase protos.MigrationPhase) {
+	m.logger.Info("migration entering phase", slog.String("phase", phase.String()))
+	m.state.Phase = phase
+	m.state.PhaseStartedAt = timestamppb.New(workflow.Now(ctx))
+}
+
+// waitForLag polls the lag of the mirror until it trails untilLSN by at most maxLagBytes with every batch normalized
+func (m *migrationExecutor) waitForLag(ctx workflow.Context, untilLSN string, maxLagBytes int64, interval time.Duration) error {
+	lagCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 10 * time.Second,
+			MaximumAttempts: 5,
+		},
+	})
+	for {
+		var lag *protos.MigrationLag
+		if err := workflow.ExecuteActivity(lagCtx, flowable.GetMigrationLag,
+			m.input.FlowConnectionConfigs, untilLSN).Get(lagCtx, &lag); err != nil {
+			return fmt.Errorf("failed to get lag: %w", err)
+		}
+		m.state.LagBytes = lag.LagBytes
+		m.state.PendingBatches = lag.PendingBatches
+		if lag.LagBytes <= maxLagBytes && lag.PendingBatches == 0 {
+			return nil
+		}
+		if err := workflow.Sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForWriteFreeze waits for writes to be reported frozen until they stay frozen over the verification window
+func (m *migrationExecutor) waitForWriteFreeze(ctx workflow.Context) error {
+	verifyCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Duration(m.input.WriteFreezeWindowSeconds)*time.Second + 5*time.Minute,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 10 * time.Second,
+			MaximumAttempts: 3,
+		},
+	})
+	freezeChan := model.MigrationWriteFreezeSignal.GetSignalChannel(ctx)
+	for {
+		if _, more := freezeChan.Receive(ctx); !more {
+			return ctx.Err()
+		}
+		var verification *protos.WriteFreezeVerification
+		if err := workflow.ExecuteActivity(verifyCtx, flowable.VerifyWriteFreeze, m.input.FlowConnectionConfigs,
+			time.Duration(m.input.WriteFreezeWindowSeconds)*time.Second).Get(verifyCtx, &verification); err != nil {
+			return fmt.Errorf("failed to verify write freeze: %w", err)
+		}
+		if verification.Frozen {
+			m.state.FreezeLsn = verification.Lsn
+			m.state.Error = ""
+			return nil
+		}
+		m.state.Error = fmt.Sprintf("tables written to during write freeze: %v, freeze writes and confirm again",
+			verification.WrittenTables)
+		m.logger.Warn("write freeze verification failed", slog.Any("tables", verification.WrittenTables))
+	}
+}
+
+func (m *migrationExecutor) run(ctx workflow.Context) error {
+	cfg := m.input.FlowConnectionConfigs
+
+	snapshotCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 365 * 24 * time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Minute,
+		},
+	})
+	var status protos.FlowStatus
+	if err := workflow.ExecuteActivity(snapshotCtx, flowable.WaitForMigrationSnapshot, cfg.FlowJobName).Get(ctx, &status); err != nil {
+		return fmt.Errorf("failed waiting for snapshot: %w", err)
+	}
+
+	m.setPhase(ctx, protos.MigrationPhase_MIGRATION_PHASE_CATCH_UP)
+	if err := m.waitForLag(ctx, "", m.input.MaxCatchUpLagBytes, migrationCatchUpCheckInterval); err != nil {
+		return err
+	}
+
+	m.setPhase(ctx, protos.MigrationPhase_MIGRATION_PHASE_WRITE_FREEZE)
+	if err := m.waitForWriteFreeze(ctx); err != nil {
+		return err
+	}
+	if err := m.waitForLag(ctx, m.state.FreezeLsn, 0, migrationDrainCheckInterval); err != nil {
+		return err
+	}
+
+	m.setPhase(ctx, protos.MigrationPhase_MIGRATION_PHASE_SEQUENCE_SYNC)
+	sequenceCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Hour,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 10 * time.Second,
+			MaximumAttempts: 3,
+		},
+	})
+	if err := workflow.ExecuteActivity(sequenceCtx, flowable.SyncMigrationSequences, cfg).Get(ctx, &m.state.SyncedSequences); err != nil {
+		return fmt.Errorf("failed to sync sequences: %w", err)
+	}
+
+	m.setPhase(ctx, protos.MigrationPhase_MIGRATION_PHASE_CUTOVER_REPORT)
+	reportCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 24 * time.Hour,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Minute,
+			MaximumAttempts: 3,
+		},
+	})
+	var report *protos.CutoverReportOutput
+	if err := workflow.ExecuteActivity(reportCtx, flowable.CreateCutoverReport, &protos.CutoverReportInput{
+		FlowJobName:           cfg.FlowJobName,
+		FlowConnectionConfigs: cfg,
+	}).Get(ctx, &report); err != nil {
+		return fmt.Errorf("failed to create cutover report: %w", err)
+	}
+	m.state.CutoverReportId = report.ReportId
+
+	m.setPhase(ctx, protos.MigrationPhase_MIGRATION_PHASE_COMPLETED)
+	return nil
+}
+
+// MigrationFlowWorkflow takes a CDC mirror through a one-shot migration: it waits for the snapshot,
+// catches up until the mirror trails the source by little, waits for writes to be frozen and verifies it,
+// drains the remaining changes, syncs sequences and finally creates a cutover report.
+// The current phase is exposed through MigrationStateQuery so downtime windows can be orchestrated around it.
+func MigrationFlowWorkflow(ctx workflow.Context, input *protos.MigrationFlowInput) (*protos.MigrationState, error) {
+	m := &migrationExecutor{
+		input: input,
+		state: &protos.MigrationState{
+			Phase:          protos.MigrationPhase_MIGRATION_PHASE_SNAPSHOT,
+			PhaseStartedAt: timestamppb.New(workflow.Now(ctx)),
+		},
+		logger: log.With(workflow.GetLogger(ctx), slog.String(string(shared.FlowNameKey), input.FlowConnectionConfigs.FlowJobName)),
+	}
+	if err := workflow.SetQueryHandler(ctx, shared.MigrationStateQuery, func() (*protos.MigrationState, error) {
+		return m.state, nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set `%s` query handler: %w", shared.MigrationStateQuery, err)
+	}
+
+	if err := m.run(ctx); err != nil {
+		m.logger.Error("migration failed", slog.String("phase", m.state.Phase.String()), slog.Any("error", err))
+		m.setPhase(ctx, protos.MigrationPhase_MIGRATION_PHASE_FAILED)
+		m.state.Error = err.Error()
+		return m.state, err
+	}
+	return m.state, nil
+}
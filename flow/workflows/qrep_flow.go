@@ -12,6 +12,7 @@ import (
 	"go.temporal.io/sdk/workflow"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
@@ -150,6 +151,9 @@ func (q *QRepFlowExecution) setupWatermarkTableOnDestination(ctx workflow.Contex
 				{
 					SourceTableIdentifier:      q.config.WatermarkTable,
 					DestinationTableIdentifier: q.config.DestinationTableIdentifier,
+					TimePartitioningColumn:     q.config.TimePartitioningColumn,
+					PartitionExpirationDays:    q.config.PartitionExpirationDays,
+					ClusteringColumns:          q.config.ClusteringColumns,
 				},
 			},
 			SyncedAtColName:   q.config.SyncedAtColName,
@@ -245,6 +249,48 @@ func (q *QRepFlowExecution) startChildWorkflow(
 	return workflow.ExecuteChildWorkflow(partFlowCtx, QRepPartitionWorkflow, q.config, partitions, q.runUUID)
 }
 
+// waitForPartitionQuota limits partition workflows to the quota of the mirror,
+// waiting while the mirror is over its rows per day quota.
+// Runs started before quotas were checked replay without it
+func (q *QRepFlowExecution) waitForPartitionQuota(ctx workflow.Context, maxParallelWorkers int) (int, error) {
+	if workflow.GetVersion(ctx, "qrep-partition-quota", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return maxParallelWorkers, nil
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+	for {
+		var allowed int
+		if err := workflow.ExecuteActivity(ctx, flowable.QRepPartitionQuota, q.config, maxParallelWorkers).Get(ctx, &allowed); err != nil {
+			return 0, fmt.Errorf("failed to check mirror quota: %w", err)
+		}
+		if allowed > 0 {
+			return allowed, nil
+		}
+		if err := workflow.Sleep(ctx, time.Minute); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// moveToWorkerPool applies worker pool moves signaled during the run,
+// returning the context to continue as new with on the task queue of the pool
+func (q *QRepFlowExecution) moveToWorkerPool(ctx workflow.Context) workflow.Context {
+	workerPoolChan := model.WorkerPoolSignal.GetSignalChannel(ctx)
+	for {
+		workerPool, ok := workerPoolChan.ReceiveAsync()
+		if !ok {
+			break
+		}
+		q.logger.Info("moving to worker pool", slog.String("from", q.config.WorkerPool), slog.String("to", workerPool))
+		q.config.WorkerPool = workerPool
+	}
+	if taskQueue := internal.WorkerPoolTaskQueueName(q.config.WorkerPool); workflow.GetInfo(ctx).TaskQueueName != taskQueue {
+		return workflow.WithWorkflowTaskQueue(ctx, taskQueue)
+	}
+	return ctx
+}
+
 // processPartitions handles the logic for processing the partitions.
 func (q *QRepFlowExecution) processPartitions(
 	ctx workflow.Context,
@@ -463,7 +509,7 @@ func QRepWaitForNewRowsWorkflow(ctx workflow.Context, config *protos.QRepConfig,
 	fullRefresh := optedForOverwrite && getQRepOverwriteFullRefreshMode(ctx, logger, config.Env)
 	// If no new rows are found, continue as new
 	if !hasNewRows || fullRefresh {
-		waitBetweenBatches := 5 * time.Second
+		waitBetweenBatches := time.Duration(shared.DefaultQRepWaitBetweenBatchesSeconds) * time.Second
 		if config.WaitBetweenBatchesSeconds > 0 {
 			waitBetweenBatches = time.Duration(config.WaitBetweenBatchesSeconds) * time.Second
 		}
@@ -542,7 +588,7 @@ func QRepFlowWorkflow(
 		updateStatus(ctx, q.logger, state, protos.FlowStatus_STATUS_RUNNING)
 	}
 
-	maxParallelWorkers := 16
+	maxParallelWorkers := int(shared.DefaultQRepMaxParallelWorkers)
 	if config.MaxParallelWorkers > 0 {
 		maxParallelWorkers = int(config.MaxParallelWorkers)
 	}
@@ -582,6 +628,11 @@ func QRepFlowWorkflow(
 		}
 
 		q.logger.Info(fmt.Sprintf("%d partitions to replicate", len(partitions.Partitions)))
+		if len(partitions.Partitions) > 0 {
+			if maxParallelWorkers, err = q.waitForPartitionQuota(ctx, maxParallelWorkers); err != nil {
+				return state, err
+			}
+		}
 		if err := q.processPartitions(ctx, maxParallelWorkers, partitions.Partitions); err != nil {
 			return state, err
 		}
@@ -617,6 +668,7 @@ func QRepFlowWorkflow(
 		}
 		q.activeSignal = model.FlowSignalHandler(q.activeSignal, val, q.logger)
 	}
+	ctx = q.moveToWorkerPool(ctx)
 
 	q.logger.Info("Continuing as new workflow",
 		slog.Any("Last Partition", state.LastPartition),
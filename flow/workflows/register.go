@@ -12,10 +12,17 @@ func RegisterFlowWorkerWorkflows(w worker.WorkflowRegistry) {
 	w.RegisterWorkflow(QRepWaitForNewRowsWorkflow)
 	w.RegisterWorkflow(QRepPartitionWorkflow)
 	w.RegisterWorkflow(XminFlowWorkflow)
+	w.RegisterWorkflow(ReplayRecordsWorkflow)
+	w.RegisterWorkflow(DeduplicateDestinationWorkflow)
+	w.RegisterWorkflow(CutoverReportWorkflow)
+	w.RegisterWorkflow(MigrationFlowWorkflow)
 
 	w.RegisterWorkflow(GlobalScheduleManagerWorkflow)
 	w.RegisterWorkflow(HeartbeatFlowWorkflow)
 	w.RegisterWorkflow(RecordSlotSizeWorkflow)
+	w.RegisterWorkflow(AlertRulesWorkflow)
+	w.RegisterWorkflow(StaleFlowsWorkflow)
+	w.RegisterWorkflow(StagingJanitorWorkflow)
 
 	w.RegisterWorkflow(StartMaintenanceWorkflow)
 	w.RegisterWorkflow(EndMaintenanceWorkflow)
@@ -0,0 +1,34 @@
+package peerflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// ReplayRecordsWorkflow re-applies already synced batches of a CDC mirror to its destination,
+// so that data lost or corrupted downstream can be backfilled without a full resync.
+func ReplayRecordsWorkflow(ctx workflow.Context, input *protos.ReplayRecordsInput) (*protos.ReplayRecordsOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("replaying records", "flowName", input.FlowJobName,
+		"startBatchID", input.StartBatchId, "endBatchID", input.EndBatchId)
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 24 * time.Hour,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Minute,
+			MaximumAttempts: 5,
+		},
+	})
+
+	var output *protos.ReplayRecordsOutput
+	if err := workflow.ExecuteActivity(ctx, flowable.ReplayRecords, input).Get(ctx, &output); err != nil {
+		logger.Error("failed to replay records", "error", err)
+		return nil, err
+	}
+	return output, nil
+}
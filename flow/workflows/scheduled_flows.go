@@ -19,6 +19,45 @@ func RecordSlotSizeWorkflow(ctx workflow.Context) error {
 	return slotSizeFuture.Get(ctx, nil)
 }
 
+// AlertRulesWorkflow evaluates alert rules against mirror metrics
+func AlertRulesWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Minute,
+		HeartbeatTimeout:    time.Minute,
+	})
+	alertRulesFuture := workflow.ExecuteActivity(ctx, flowable.EvaluateAlertRules)
+	return alertRulesFuture.Get(ctx, nil)
+}
+
+// StaleFlowsWorkflow alerts on running mirrors that stopped syncing
+func StaleFlowsWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Minute,
+		HeartbeatTimeout:    time.Minute,
+	})
+	staleFlowsFuture := workflow.ExecuteActivity(ctx, flowable.CheckStaleFlows)
+	return staleFlowsFuture.Get(ctx, nil)
+}
+
+// StagingJanitorWorkflow drops stages kept past their retention
+func StagingJanitorWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+	})
+	cleanupFuture := workflow.ExecuteActivity(ctx, flowable.CleanupExpiredStages)
+	return cleanupFuture.Get(ctx, nil)
+}
+
 // HeartbeatFlowWorkflow sends WAL heartbeats
 func HeartbeatFlowWorkflow(ctx workflow.Context) error {
 	if ctx.Err() != nil {
@@ -58,6 +97,21 @@ func GlobalScheduleManagerWorkflow(ctx workflow.Context) error {
 		"* * * * *")
 	workflow.ExecuteChildWorkflow(slotSizeCtx, RecordSlotSizeWorkflow)
 
+	alertRulesCtx := withCronOptions(ctx,
+		"alert-rules-"+info.OriginalRunID,
+		"* * * * *")
+	workflow.ExecuteChildWorkflow(alertRulesCtx, AlertRulesWorkflow)
+
+	staleFlowsCtx := withCronOptions(ctx,
+		"stale-flows-"+info.OriginalRunID,
+		"*/5 * * * *")
+	workflow.ExecuteChildWorkflow(staleFlowsCtx, StaleFlowsWorkflow)
+
+	stagingJanitorCtx := withCronOptions(ctx,
+		"staging-janitor-"+info.OriginalRunID,
+		"17 * * * *")
+	workflow.ExecuteChildWorkflow(stagingJanitorCtx, StagingJanitorWorkflow)
+
 	ctx.Done().Receive(ctx, nil)
 	return ctx.Err()
 }
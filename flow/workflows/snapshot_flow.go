@@ -10,6 +10,7 @@ import (
 	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/activities"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -91,11 +92,24 @@ func (s *SnapshotFlowExecution) closeSlotKeepAlive(
 	return nil
 }
 
+// migrateConstraints recreates source constraints on the destination once the tables they depend on are loaded
+func (s *SnapshotFlowExecution) migrateConstraints(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 24 * time.Hour,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 1 * time.Minute,
+		},
+	})
+	s.logger.Info("migrating constraints")
+	return workflow.ExecuteActivity(ctx, snapshot.MigrateConstraints, s.config).Get(ctx, nil)
+}
+
 func (s *SnapshotFlowExecution) cloneTable(
 	ctx workflow.Context,
 	boundSelector *shared.BoundSelector,
 	snapshotName string,
 	mapping *protos.TableMapping,
+	isPartition bool,
 ) error {
 	flowName := s.config.FlowJobName
 	cloneLog := slog.Group("clone-log",
@@ -112,7 +126,7 @@ func (s *SnapshotFlowExecution) cloneTable(
 	s.logger.Info(fmt.Sprintf("Obtained child id %s for source table %s and destination table %s",
 		childWorkflowID, srcName, dstName), cloneLog)
 
-	taskQueue := internal.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
+	taskQueue := internal.WorkerPoolTaskQueueName(s.config.WorkerPool)
 	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
 		WorkflowID:          childWorkflowID,
 		WorkflowTaskTimeout: 5 * time.Minute,
@@ -145,27 +159,46 @@ func (s *SnapshotFlowExecution) cloneTable(
 		s.logger.Error("unable to parse source table", slog.Any("error", err), cloneLog)
 		return fmt.Errorf("unable to parse source table: %w", err)
 	}
-	from := "*"
-	if len(mapping.Exclude) != 0 {
+	var columns []string
+	// columns of a partition may be in a different order than in its partitioned table,
+	// generated and identity columns may be skipped or regenerated at the destination
+	if len(mapping.Exclude) != 0 || isPartition ||
+		mapping.GeneratedColumns != protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REPLICATE ||
+		mapping.IdentityColumns != protos.GeneratedColumnPolicy_GENERATED_COLUMN_POLICY_REPLICATE {
 		if err := initTableSchema(); err != nil {
 			return err
 		}
-		quotedColumns := make([]string, 0, len(tableSchema.Columns))
+		columns = make([]string, 0, len(tableSchema.Columns))
 		for _, col := range tableSchema.Columns {
-			if !slices.Contains(mapping.Exclude, col.Name) {
-				quotedColumns = append(quotedColumns, utils.QuoteIdentifier(col.Name))
+			if !slices.Contains(mapping.Exclude, col.Name) && col.GenerationExpression == "" && !col.Identity &&
+				!slices.ContainsFunc(mapping.JsonPathColumns, func(jsonPathColumn *protos.JsonPathColumn) bool {
+					return jsonPathColumn.DestinationName == col.Name
+				}) {
+				columns = append(columns, col.Name)
 			}
 		}
-		from = strings.Join(quotedColumns, ",")
 	}
 
 	// usually MySQL supports double quotes with ANSI_QUOTES, but Vitess doesn't
 	// Vitess currently only supports initial load so change here is enough
 	srcTableEscaped := parsedSrcTable.String()
+	quoteIdentifier := utils.QuoteIdentifier
 	if dbtype, err := getPeerType(ctx, s.config.SourceName); err != nil {
 		return err
 	} else if dbtype == protos.DBType_MYSQL {
 		srcTableEscaped = parsedSrcTable.MySQL()
+	} else if dbtype == protos.DBType_SQLSERVER {
+		srcTableEscaped = parsedSrcTable.SqlServer()
+		quoteIdentifier = utils.QuoteSqlServerIdentifier
+	}
+
+	from := "*"
+	if columns != nil {
+		quotedColumns := make([]string, 0, len(columns))
+		for _, col := range columns {
+			quotedColumns = append(quotedColumns, quoteIdentifier(col))
+		}
+		from = strings.Join(quotedColumns, ",")
 	}
 
 	var query string
@@ -176,12 +209,12 @@ func (s *SnapshotFlowExecution) cloneTable(
 			from, srcTableEscaped, mapping.PartitionKey)
 	}
 
-	numWorkers := uint32(8)
+	numWorkers := shared.DefaultSnapshotMaxParallelWorkers
 	if s.config.SnapshotMaxParallelWorkers > 0 {
 		numWorkers = s.config.SnapshotMaxParallelWorkers
 	}
 
-	numRowsPerPartition := uint32(250000)
+	numRowsPerPartition := shared.DefaultSnapshotNumRowsPerPartition
 	if s.config.SnapshotNumRowsPerPartition > 0 {
 		numRowsPerPartition = s.config.SnapshotNumRowsPerPartition
 	}
@@ -224,9 +257,13 @@ func (s *SnapshotFlowExecution) cloneTable(
 		Script:                     s.config.Script,
 		Env:                        s.config.Env,
 		ParentMirrorName:           flowName,
+		MaxSourceConnections:       s.config.MaxSourceConnections,
+		S3Partition:                s.config.S3Partition,
 		Exclude:                    mapping.Exclude,
 		Columns:                    mapping.Columns,
+		JsonPathColumns:            mapping.JsonPathColumns,
 		Version:                    s.config.Version,
+		WorkerPool:                 s.config.WorkerPool,
 	}
 
 	boundSelector.SpawnChild(childCtx, QRepFlowWorkflow, nil, config, nil)
@@ -255,6 +292,32 @@ func (s *SnapshotFlowExecution) cloneTables(
 		defaultPartitionCol = ""
 	}
 
+	// ctid is only unique within a partition, so partitioned tables are cloned one partition at a time
+	var leafPartitions map[string][]string
+	if defaultPartitionCol == "ctid" {
+		var partitionedCandidates []string
+		for _, v := range s.config.TableMappings {
+			if v.PartitionKey == "" {
+				partitionedCandidates = append(partitionedCandidates, v.SourceTableIdentifier)
+			}
+		}
+		// snapshots started before partitions were looked up replay without the activity
+		if len(partitionedCandidates) > 0 &&
+			workflow.GetVersion(ctx, "snapshot-leaf-partitions", workflow.DefaultVersion, 1) != workflow.DefaultVersion {
+			partitionsCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+				StartToCloseTimeout: 5 * time.Minute,
+				RetryPolicy: &temporal.RetryPolicy{
+					InitialInterval: 1 * time.Minute,
+				},
+			})
+			if err := workflow.ExecuteActivity(partitionsCtx, snapshot.GetLeafPartitions,
+				s.config.SourceName, s.config.Env, partitionedCandidates,
+			).Get(ctx, &leafPartitions); err != nil {
+				return fmt.Errorf("failed to get partitions of source tables: %w", err)
+			}
+		}
+	}
+
 	for _, v := range s.config.TableMappings {
 		source := v.SourceTableIdentifier
 		destination := v.DestinationTableIdentifier
@@ -263,9 +326,21 @@ func (s *SnapshotFlowExecution) cloneTables(
 			slog.String("snapshotName", snapshotName),
 		)
 		if v.PartitionKey == "" {
+			if partitions, ok := leafPartitions[source]; ok {
+				s.logger.Info(fmt.Sprintf("Cloning %d partitions of partitioned table %s", len(partitions), source))
+				for _, partition := range partitions {
+					partitionMapping := proto.CloneOf(v)
+					partitionMapping.SourceTableIdentifier = partition
+					partitionMapping.PartitionKey = defaultPartitionCol
+					if err := s.cloneTable(ctx, boundSelector, snapshotName, partitionMapping, true); err != nil {
+						s.logger.Error("failed to start clone child workflow", slog.Any("error", err))
+					}
+				}
+				continue
+			}
 			v.PartitionKey = defaultPartitionCol
 		}
-		if err := s.cloneTable(ctx, boundSelector, snapshotName, v); err != nil {
+		if err := s.cloneTable(ctx, boundSelector, snapshotName, v, false); err != nil {
 			s.logger.Error("failed to start clone child workflow", slog.Any("error", err))
 			continue
 		}
@@ -323,7 +398,7 @@ func SnapshotFlowWorkflow(
 			slog.String("sourcePeer", config.SourceName)),
 	}
 
-	numTablesInParallel := int(max(config.SnapshotNumTablesInParallel, 1))
+	numTablesInParallel := int(max(config.SnapshotNumTablesInParallel, shared.DefaultSnapshotNumTablesInParallel))
 
 	sessionOpts := &workflow.SessionOptions{
 		CreationTimeout:  5 * time.Minute,
@@ -406,5 +481,11 @@ func SnapshotFlowWorkflow(
 		return fmt.Errorf("failed to clone slots and create replication slot: %w", err)
 	}
 
+	if config.MigrateConstraints {
+		if err := se.migrateConstraints(ctx); err != nil {
+			return fmt.Errorf("failed to migrate constraints: %w", err)
+		}
+	}
+
 	return nil
 }
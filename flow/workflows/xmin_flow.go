@@ -116,6 +116,7 @@ func XminFlowWorkflow(
 		}
 		q.activeSignal = model.FlowSignalHandler(q.activeSignal, val, q.logger)
 	}
+	ctx = q.moveToWorkerPool(ctx)
 
 	logger.Info("Continuing as new workflow",
 		slog.Any("Last Partition", state.LastPartition),
@@ -8,11 +8,13 @@ use std::{
 use anyhow::Context;
 use pt::peerdb_peers::{MySqlAuthType, PostgresAuthType};
 use pt::{
-    flow_model::{FlowJob, FlowJobTableMapping, QRepFlowJob},
+    flow_model::{FlowJob, FlowJobS3Partition, FlowJobTableMapping, QRepFlowJob},
     peerdb_peers::{
-        BigqueryConfig, ClickhouseConfig, DbType, EventHubConfig, GcpServiceAccount, KafkaConfig,
-        MongoConfig, MySqlFlavor, MySqlReplicationMechanism, Peer, PostgresConfig, PubSubConfig,
-        S3Config, SnowflakeConfig, SqlServerConfig, SshConfig, peer::Config,
+        AzureBlobConfig, BigqueryConfig, ClickhouseConfig, DbType, EventHubConfig,
+        GcpServiceAccount, KafkaConfig, KinesisConfig, MongoConfig, MySqlFlavor,
+        MySqlReplicationMechanism, Peer, PostgresConfig, PubSubConfig, S3Config,
+        SchemaRegistryConfig, SnowflakeConfig, SqlServerConfig, SqlServerReplicationMechanism,
+        SshConfig, TlsConfig, peer::Config,
     },
 };
 use qrep::process_options;
@@ -287,6 +289,13 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                             _ => None,
                         };
 
+                        let max_batch_bytes: Option<u64> = match raw_options
+                            .remove("max_batch_bytes")
+                        {
+                            Some(Expr::Value(ast::Value::Number(n, _))) => Some(n.parse::<u64>()?),
+                            _ => None,
+                        };
+
                         let sync_interval: Option<u64> = match raw_options.remove("sync_interval") {
                             Some(Expr::Value(ast::Value::Number(n, _))) => Some(n.parse::<u64>()?),
                             _ => None,
@@ -327,6 +336,18 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                                 _ => false,
                             };
 
+                        let s3_partition = parse_s3_partition(&mut raw_options)?;
+
+                        let migrate_constraints = match raw_options.remove("migrate_constraints") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let worker_pool = match raw_options.remove("worker_pool") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
                         let flow_job = FlowJob {
                             name: cdc.mirror_name.to_string().to_lowercase(),
                             source_peer: cdc.source_peer.to_string().to_lowercase(),
@@ -341,6 +362,7 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                             cdc_staging_path,
                             replication_slot_name,
                             max_batch_size,
+                            max_batch_bytes,
                             sync_interval,
                             resync,
                             soft_delete_col_name,
@@ -349,6 +371,9 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                             script,
                             system,
                             disable_peerdb_columns,
+                            s3_partition,
+                            migrate_constraints,
+                            worker_pool,
                         };
 
                         if initial_copy_only && !do_initial_copy {
@@ -533,6 +558,81 @@ impl StatementAnalyzer for PeerCursorAnalyzer {
     }
 }
 
+// s3_partition_* options of CDC mirrors, partitioning is only enabled when any of them is set
+fn parse_s3_partition(
+    raw_options: &mut HashMap<&str, &Expr>,
+) -> anyhow::Result<Option<FlowJobS3Partition>> {
+    let mut string_option = |name: &str| match raw_options.remove(name) {
+        Some(Expr::Value(ast::Value::SingleQuotedString(s))) => Some(s.clone()),
+        _ => None,
+    };
+    let column = string_option("s3_partition_column");
+    let granularity = string_option("s3_partition_granularity");
+    let key = string_option("s3_partition_key");
+    let by_table = match raw_options.remove("s3_partition_by_table") {
+        Some(Expr::Value(ast::Value::Boolean(b))) => Some(*b),
+        _ => None,
+    };
+    if column.is_none() && granularity.is_none() && key.is_none() && by_table.is_none() {
+        return Ok(None);
+    }
+    let granularity = granularity.unwrap_or_else(|| "day".to_string());
+    if !["day", "hour", "month"].contains(&granularity.as_str()) {
+        anyhow::bail!("s3_partition_granularity must be one of day, hour or month");
+    }
+    Ok(Some(FlowJobS3Partition {
+        column: column.unwrap_or_default(),
+        granularity,
+        key: key.unwrap_or_default(),
+        by_table: by_table.unwrap_or_default(),
+    }))
+}
+
+// schema_registry_* options of Kafka & S3 peers, the registry is only configured when any of them is set
+fn parse_schema_registry(opts: &HashMap<&str, &str>) -> Option<SchemaRegistryConfig> {
+    if !opts.keys().any(|key| key.starts_with("schema_registry_")) {
+        return None;
+    }
+    let string_option = |name: &str| opts.get(name).map(|s| s.to_string());
+    Some(SchemaRegistryConfig {
+        r#type: opts
+            .get("schema_registry_type")
+            .and_then(|s| pt::peerdb_peers::SchemaRegistryType::from_str_name(s))
+            .map(|registry_type| registry_type.into())
+            .unwrap_or_default(),
+        url: string_option("schema_registry_url").unwrap_or_default(),
+        username: string_option("schema_registry_user").unwrap_or_default(),
+        password: string_option("schema_registry_password").unwrap_or_default(),
+        registry_name: string_option("schema_registry_name").unwrap_or_default(),
+        region: string_option("schema_registry_region").unwrap_or_default(),
+        access_key_id: string_option("schema_registry_access_key_id"),
+        secret_access_key: string_option("schema_registry_secret_access_key"),
+        role_arn: string_option("schema_registry_role_arn"),
+        auto_register: opts
+            .get("schema_registry_auto_register")
+            .and_then(|s| s.parse::<bool>().ok())
+            .unwrap_or_default(),
+    })
+}
+
+fn parse_ssh_config(opts: &HashMap<&str, &str>) -> anyhow::Result<Option<SshConfig>> {
+    match opts.get("ssh_config") {
+        Some(ssh_config) if !ssh_config.is_empty() => Ok(Some(
+            serde_json::from_str(ssh_config).context("failed to deserialize ssh_config")?,
+        )),
+        _ => Ok(None),
+    }
+}
+
+fn parse_tls_config(opts: &HashMap<&str, &str>) -> anyhow::Result<Option<TlsConfig>> {
+    match opts.get("tls") {
+        Some(tls) if !tls.is_empty() => Ok(Some(
+            serde_json::from_str(tls).context("failed to deserialize tls")?,
+        )),
+        _ => Ok(None),
+    }
+}
+
 fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Result<Option<Config>> {
     let mut opts: HashMap<&str, &str> = HashMap::with_capacity(with_options.len());
     for opt in with_options {
@@ -599,6 +699,12 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .get("dataset_id")
                     .ok_or_else(|| anyhow::anyhow!("missing dataset_id in peer options"))?
                     .to_string(),
+                max_load_jobs_per_day: opts
+                    .get("max_load_jobs_per_day")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("max_load_jobs_per_day must be a non-negative integer")?
+                    .unwrap_or_default(),
             };
             Config::BigqueryConfig(bq_config)
         }
@@ -666,19 +772,6 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
             Config::MongoConfig(mongo_config)
         }
         DbType::Postgres => {
-            let ssh_fields: Option<SshConfig> = match opts.get("ssh_config") {
-                Some(ssh_config) => {
-                    let ssh_config_str = ssh_config.to_string();
-                    if ssh_config_str.is_empty() {
-                        None
-                    } else {
-                        serde_json::from_str(&ssh_config_str)
-                            .context("failed to deserialize ssh_config")?
-                    }
-                }
-                None => None,
-            };
-
             let postgres_config = PostgresConfig {
                 host: opts.get("host").context("no host specified")?.to_string(),
                 port: opts
@@ -699,7 +792,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .context("no default database specified")?
                     .to_string(),
                 metadata_schema: opts.get("metadata_schema").map(|s| s.to_string()),
-                ssh_config: ssh_fields,
+                ssh_config: parse_ssh_config(&opts)?,
                 root_ca: opts.get("root_ca").map(|s| s.to_string()),
                 tls_host: opts
                     .get("tls_host")
@@ -711,11 +804,20 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .unwrap_or_default(),
                 auth_type: PostgresAuthType::PostgresPassword.into(),
                 aws_auth: None,
+                tls: parse_tls_config(&opts)?,
             };
 
             Config::PostgresConfig(postgres_config)
         }
         DbType::S3 => {
+            let gcp_service_account: Option<GcpServiceAccount> =
+                match opts.get("gcp_service_account") {
+                    Some(sa) if !sa.is_empty() => Some(
+                        serde_json::from_str(sa)
+                            .context("failed to deserialize gcp_service_account")?,
+                    ),
+                    _ => None,
+                };
             let s3_config = S3Config {
                 url: opts
                     .get("url")
@@ -736,9 +838,64 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .and_then(|s| pt::peerdb_peers::AvroCodec::from_str_name(s))
                     .map(|codec| codec.into())
                     .unwrap_or_default(),
+                file_format: opts
+                    .get("file_format")
+                    .and_then(|s| pt::peerdb_peers::S3FileFormat::from_str_name(s))
+                    .map(|format| format.into())
+                    .unwrap_or_default(),
+                hudi_table_type: opts
+                    .get("hudi_table_type")
+                    .and_then(|s| pt::peerdb_peers::HudiTableType::from_str_name(s))
+                    .map(|table_type| table_type.into())
+                    .unwrap_or_default(),
+                gcp_service_account,
+                csv_delimiter: opts
+                    .get("csv_delimiter")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                csv_header: opts
+                    .get("csv_header")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                csv_null_string: opts
+                    .get("csv_null_string")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                schema_registry: parse_schema_registry(&opts),
+                use_accelerate: opts
+                    .get("use_accelerate")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                sse_kms_key_arn: opts.get("sse_kms_key_arn").map(|s| s.to_string()),
+                sse_customer_key: opts.get("sse_customer_key").map(|s| s.to_string()),
+                bucket_owner_full_control: opts
+                    .get("bucket_owner_full_control")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
             };
             Config::S3Config(s3_config)
         }
+        DbType::AzureBlob => {
+            let azure_blob_config = AzureBlobConfig {
+                url: opts
+                    .get("url")
+                    .context("Azure Blob Storage url not specified")?
+                    .to_string(),
+                sas_token: opts.get("sas_token").map(|s| s.to_string()),
+                client_id: opts.get("client_id").map(|s| s.to_string()),
+                codec: opts
+                    .get("codec")
+                    .and_then(|s| pt::peerdb_peers::AvroCodec::from_str_name(s))
+                    .map(|codec| codec.into())
+                    .unwrap_or_default(),
+                file_format: opts
+                    .get("file_format")
+                    .and_then(|s| pt::peerdb_peers::S3FileFormat::from_str_name(s))
+                    .map(|format| format.into())
+                    .unwrap_or_default(),
+            };
+            Config::AzureBlobConfig(azure_blob_config)
+        }
         DbType::Sqlserver => {
             let port_str = opts.get("port").context("port not specified")?;
             let port: u32 = port_str.parse().context("port is invalid")?;
@@ -757,6 +914,22 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .get("database")
                     .context("database is not specified")?
                     .to_string(),
+                disable_tls: opts
+                    .get("disable_tls")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                trust_server_certificate: opts
+                    .get("trust_server_certificate")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                replication_mechanism: match opts.get("replication_mechanism") {
+                    Some(&"change_tracking") => {
+                        SqlServerReplicationMechanism::SqlserverChangeTracking
+                    }
+                    _ => SqlServerReplicationMechanism::SqlserverCdc,
+                }
+                .into(),
+                ssh_config: parse_ssh_config(&opts)?,
             };
             Config::SqlserverConfig(sqlserver_config)
         }
@@ -809,6 +982,25 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .map(|s| s.to_string())
                     .unwrap_or_default(),
                 s3: None,
+                azure_blob: opts.get("azure_blob_url").map(|url| AzureBlobConfig {
+                    url: url.to_string(),
+                    sas_token: opts.get("azure_sas_token").map(|s| s.to_string()),
+                    ..Default::default()
+                }),
+                native_insert: opts
+                    .get("native_insert")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+                cluster: opts
+                    .get("cluster")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                distributed: opts
+                    .get("distributed")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+                tls: parse_tls_config(&opts)?,
+                ssh_config: parse_ssh_config(&opts)?,
             };
             Config::ClickhouseConfig(clickhouse_config)
         }
@@ -840,6 +1032,13 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .get("disable_tls")
                     .and_then(|s| s.parse::<bool>().ok())
                     .unwrap_or_default(),
+                value_format: opts
+                    .get("value_format")
+                    .and_then(|s| pt::peerdb_peers::KafkaValueFormat::from_str_name(s))
+                    .map(|format| format.into())
+                    .unwrap_or_default(),
+                schema_registry: parse_schema_registry(&opts),
+                tls: parse_tls_config(&opts)?,
             };
             Config::KafkaConfig(kafka_config)
         }
@@ -897,9 +1096,38 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                         })?
                         .to_string(),
                 }),
+                ordering_key_from_primary_key: opts
+                    .get("ordering_key_from_primary_key")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                metadata_attributes: opts
+                    .get("metadata_attributes")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                create_topics: opts
+                    .get("create_topics")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
             };
             Config::PubsubConfig(ps_config)
         }
+        DbType::Kinesis => {
+            let kinesis_config = KinesisConfig {
+                region: opts
+                    .get("region")
+                    .context("no region specified")?
+                    .to_string(),
+                access_key_id: opts.get("access_key_id").map(|s| s.to_string()),
+                secret_access_key: opts.get("secret_access_key").map(|s| s.to_string()),
+                role_arn: opts.get("role_arn").map(|s| s.to_string()),
+                endpoint: opts.get("endpoint").map(|s| s.to_string()),
+                disable_aggregation: opts
+                    .get("disable_aggregation")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+            };
+            Config::KinesisConfig(kinesis_config)
+        }
         DbType::Eventhubs => {
             let unnest_columns = opts
                 .get("unnest_columns")
@@ -946,36 +1174,93 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
             let api_key = opts.get("api_key").map(|s| s.to_string());
             let username = opts.get("username").map(|s| s.to_string());
             let password = opts.get("password").map(|s| s.to_string());
-            if api_key.is_some() {
+            let sigv4 = opts
+                .get("auth_type")
+                .is_some_and(|s| s.eq_ignore_ascii_case("aws_sigv4"));
+            let (auth_type, username, password, api_key) = if sigv4 {
+                if api_key.is_some() || username.is_some() || password.is_some() {
+                    return Err(anyhow::anyhow!(
+                        "SigV4 auth can't be combined with API key or basic auth"
+                    ));
+                }
+                (
+                    pt::peerdb_peers::ElasticsearchAuthType::AwsSigv4,
+                    None,
+                    None,
+                    None,
+                )
+            } else if api_key.is_some() {
                 if username.is_some() || password.is_some() {
                     return Err(anyhow::anyhow!(
                         "both API key auth and basic auth specified"
                     ));
                 }
-                Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
-                    addresses,
-                    auth_type: pt::peerdb_peers::ElasticsearchAuthType::Apikey.into(),
-                    username: None,
-                    password: None,
+                (
+                    pt::peerdb_peers::ElasticsearchAuthType::Apikey,
+                    None,
+                    None,
                     api_key,
-                })
+                )
             } else if username.is_some() && password.is_some() {
-                Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
-                    addresses,
-                    auth_type: pt::peerdb_peers::ElasticsearchAuthType::Basic.into(),
+                (
+                    pt::peerdb_peers::ElasticsearchAuthType::Basic,
                     username,
                     password,
-                    api_key: None,
-                })
+                    None,
+                )
             } else {
-                Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
-                    addresses,
-                    auth_type: pt::peerdb_peers::ElasticsearchAuthType::None.into(),
-                    username: None,
-                    password: None,
-                    api_key: None,
-                })
-            }
+                (
+                    pt::peerdb_peers::ElasticsearchAuthType::None,
+                    None,
+                    None,
+                    None,
+                )
+            };
+            Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
+                addresses,
+                auth_type: auth_type.into(),
+                username,
+                password,
+                api_key,
+                manage_indices: opts
+                    .get("manage_indices")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                rollover: opts
+                    .get("rollover")
+                    .and_then(|s| pt::peerdb_peers::ElasticsearchRollover::from_str_name(s))
+                    .map(|rollover| rollover.into())
+                    .unwrap_or_default(),
+                number_of_shards: opts
+                    .get("number_of_shards")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("number_of_shards must be a non-negative integer")?,
+                number_of_replicas: opts
+                    .get("number_of_replicas")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("number_of_replicas must be a non-negative integer")?,
+                opensearch: opts
+                    .get("opensearch")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                region: opts.get("region").map(|s| s.to_string()),
+                access_key_id: opts.get("access_key_id").map(|s| s.to_string()),
+                secret_access_key: opts.get("secret_access_key").map(|s| s.to_string()),
+                role_arn: opts.get("role_arn").map(|s| s.to_string()),
+                serverless: opts
+                    .get("serverless")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+                tls: parse_tls_config(&opts)?,
+                max_bulk_requests_per_second: opts
+                    .get("max_bulk_requests_per_second")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("max_bulk_requests_per_second must be a non-negative integer")?
+                    .unwrap_or_default(),
+            })
         }
         DbType::Mysql => Config::MysqlConfig(pt::peerdb_peers::MySqlConfig {
             host: opts.get("host").context("no host specified")?.to_string(),
@@ -1031,6 +1316,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
             }
             .into(),
             aws_auth: None,
+            tls: parse_tls_config(&opts)?,
         }),
     }))
 }
@@ -78,6 +78,53 @@ const QREP_OPTIONS: &[QRepOptionType] = &[
         default_value: 50000,
         required: true,
     },
+    QRepOptionType::String {
+        name: "file_format",
+        default_val: None,
+        required: false,
+        accepted_values: Some(&["S3_AVRO", "S3_PARQUET"]),
+    },
+    QRepOptionType::String {
+        name: "codec",
+        default_val: None,
+        required: false,
+        accepted_values: Some(&["Null", "Deflate", "Snappy", "ZStandard"]),
+    },
+    QRepOptionType::Int {
+        name: "parquet_row_group_size",
+        min_value: None,
+        default_value: 0,
+        required: false,
+    },
+    QRepOptionType::String {
+        name: "s3_partition_column",
+        default_val: None,
+        required: false,
+        accepted_values: None,
+    },
+    QRepOptionType::String {
+        name: "s3_partition_granularity",
+        default_val: None,
+        required: false,
+        accepted_values: Some(&["day", "hour", "month"]),
+    },
+    QRepOptionType::String {
+        name: "s3_partition_key",
+        default_val: None,
+        required: false,
+        accepted_values: None,
+    },
+    QRepOptionType::String {
+        name: "worker_pool",
+        default_val: None,
+        required: false,
+        accepted_values: None,
+    },
+    QRepOptionType::Boolean {
+        name: "s3_partition_by_table",
+        default_value: false,
+        required: false,
+    },
     QRepOptionType::Boolean {
         name: "initial_copy_only",
         default_value: false,
@@ -0,0 +1 @@
+ALTER TABLE peerdb_stats.alerting_config ADD COLUMN IF NOT EXISTS alert_for_table_groups TEXT[];
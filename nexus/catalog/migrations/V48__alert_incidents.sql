@@ -0,0 +1,13 @@
+ALTER TABLE peerdb_stats.alerting_config
+DROP CONSTRAINT alerting_config_service_type_check;
+
+ALTER TABLE peerdb_stats.alerting_config
+ADD CONSTRAINT alerting_config_service_type_check
+CHECK (service_type IN ('slack', 'email', 'pagerduty', 'opsgenie'));
+
+CREATE TABLE IF NOT EXISTS peerdb_stats.alert_incidents (
+    alert_config_id BIGINT NOT NULL REFERENCES peerdb_stats.alerting_config(id) ON DELETE CASCADE,
+    dedup_key TEXT NOT NULL,
+    triggered_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (alert_config_id, dedup_key)
+);
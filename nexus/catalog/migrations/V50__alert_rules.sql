@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.alert_rules (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    -- rules apply to mirrors matching every scope set, NULL matches all mirrors
+    mirror_name TEXT,
+    -- source or destination peer of the mirror
+    peer_name TEXT,
+    metric TEXT NOT NULL CHECK (metric IN ('slot_lag_mb', 'lag_seconds', 'error_rate', 'rows_per_second')),
+    threshold DOUBLE PRECISION NOT NULL,
+    window_minutes INTEGER NOT NULL DEFAULT 15,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+ALTER TABLE peerdb_stats.cdc_flows ADD COLUMN IF NOT EXISTS last_sync_at TIMESTAMPTZ;
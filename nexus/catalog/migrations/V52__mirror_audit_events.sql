@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.mirror_audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    operation TEXT NOT NULL CHECK (operation IN ('create', 'pause', 'resume', 'resync', 'edit', 'drop')),
+    actor TEXT NOT NULL DEFAULT '',
+    previous_config JSONB,
+    new_config JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mirror_audit_events_flow_name ON peerdb_stats.mirror_audit_events (flow_name, id);
+
+CREATE OR REPLACE FUNCTION peerdb_stats.reject_mirror_audit_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'peerdb_stats.mirror_audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER mirror_audit_events_append_only
+BEFORE UPDATE OR DELETE ON peerdb_stats.mirror_audit_events
+FOR EACH ROW EXECUTE FUNCTION peerdb_stats.reject_mirror_audit_event_change();
@@ -0,0 +1 @@
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS backfill_paused BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS flow_config_versions (
+    id BIGSERIAL PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    config_proto BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (flow_name, version)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS mirror_templates (
+    name TEXT PRIMARY KEY,
+    config_proto BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+ALTER TABLE peerdb_stats.qrep_partitions ADD COLUMN IF NOT EXISTS partition_proto BYTEA;
+ALTER TABLE peerdb_stats.qrep_partitions ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE peerdb_stats.qrep_partitions ADD COLUMN IF NOT EXISTS last_error_time TIMESTAMP;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.peer_probes (
+    id BIGSERIAL PRIMARY KEY,
+    peer_name TEXT NOT NULL,
+    probed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    connect_ms DOUBLE PRECISION,
+    latency_min_ms DOUBLE PRECISION,
+    latency_avg_ms DOUBLE PRECISION,
+    latency_max_ms DOUBLE PRECISION,
+    read_bytes_per_second DOUBLE PRECISION,
+    write_bytes_per_second DOUBLE PRECISION,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_peer_probes_peer_name_probed_at ON peerdb_stats.peer_probes(peer_name, probed_at);
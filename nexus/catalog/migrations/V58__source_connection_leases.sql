@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS source_connection_leases (
+    flow_name TEXT NOT NULL,
+    lease_id TEXT NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (flow_name, lease_id)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS peer_rate_limits (
+    peer_name TEXT NOT NULL,
+    limit_key TEXT NOT NULL,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (peer_name, limit_key)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS cutover_reports (
+    id BIGSERIAL PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    report_json TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    key_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cutover_reports_flow_name ON cutover_reports (flow_name, created_at DESC);
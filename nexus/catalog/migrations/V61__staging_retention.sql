@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS staging_retention (
+    peer_name TEXT NOT NULL,
+    flow_name TEXT NOT NULL,
+    staging_path TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (peer_name, flow_name, staging_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_staging_retention_expires_at ON staging_retention (expires_at);
@@ -0,0 +1,5 @@
+ALTER TABLE peerdb_stats.cdc_batch_table
+ADD COLUMN IF NOT EXISTS oversized_count INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE peerdb_stats.cdc_batches
+ADD COLUMN IF NOT EXISTS oversized_rows BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE peerdb_stats.cdc_batch_table
+ADD COLUMN IF NOT EXISTS numeric_overflow_count INTEGER NOT NULL DEFAULT 0;
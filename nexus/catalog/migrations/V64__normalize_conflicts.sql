@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.normalize_conflicts (
+    id BIGSERIAL PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    batch_id BIGINT NOT NULL,
+    destination_table_name TEXT NOT NULL,
+    primary_key JSONB NOT NULL,
+    record_type INTEGER NOT NULL,
+    policy TEXT NOT NULL,
+    source_applied BOOLEAN NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_normalize_conflicts_flow_name_detected_at
+ON peerdb_stats.normalize_conflicts (flow_name, detected_at);
@@ -0,0 +1 @@
+ALTER TYPE script_lang ADD VALUE IF NOT EXISTS 'wasm';
@@ -0,0 +1,18 @@
+CREATE OR REPLACE FUNCTION notify_dynamic_settings_change() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'TRUNCATE' THEN
+    PERFORM pg_notify('peerdb_dynamic_settings', '');
+  ELSIF TG_OP = 'DELETE' THEN
+    PERFORM pg_notify('peerdb_dynamic_settings', OLD.config_name);
+  ELSE
+    PERFORM pg_notify('peerdb_dynamic_settings', NEW.config_name);
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER dynamic_settings_notify AFTER INSERT OR UPDATE OR DELETE ON dynamic_settings
+FOR EACH ROW EXECUTE FUNCTION notify_dynamic_settings_change();
+
+CREATE TRIGGER dynamic_settings_notify_truncate AFTER TRUNCATE ON dynamic_settings
+FOR EACH STATEMENT EXECUTE FUNCTION notify_dynamic_settings_change();
@@ -0,0 +1,6 @@
+ALTER TABLE dynamic_settings
+  ADD COLUMN scope TEXT NOT NULL DEFAULT 'global' CHECK (scope IN ('global', 'peer', 'flow')),
+  ADD COLUMN scope_name TEXT NOT NULL DEFAULT '';
+
+DROP INDEX idx_alerting_settings_config_name;
+CREATE UNIQUE INDEX idx_dynamic_settings_scope ON dynamic_settings (config_name, scope, scope_name);
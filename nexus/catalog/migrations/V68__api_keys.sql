@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    key_prefix TEXT NOT NULL,
+    key_hash BYTEA NOT NULL UNIQUE,
+    role TEXT NOT NULL CHECK (role IN ('read-only', 'operator', 'admin')),
+    peers TEXT[] NOT NULL DEFAULT '{}',
+    mirrors TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ
+);
@@ -0,0 +1,8 @@
+-- flow_name '' is the default quota of mirrors without one of their own, 0 means unlimited
+CREATE TABLE IF NOT EXISTS mirror_quotas (
+    flow_name TEXT PRIMARY KEY,
+    max_concurrent_partition_workflows INTEGER NOT NULL DEFAULT 0 CHECK (max_concurrent_partition_workflows >= 0),
+    max_staged_bytes BIGINT NOT NULL DEFAULT 0 CHECK (max_staged_bytes >= 0),
+    max_rows_per_day BIGINT NOT NULL DEFAULT 0 CHECK (max_rows_per_day >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,8 @@
+-- write stream a batch is committed through, for destinations to tell whether an interrupted commit went through
+CREATE TABLE IF NOT EXISTS metadata_write_streams (
+    job_name TEXT NOT NULL,
+    batch_id BIGINT NOT NULL,
+    stream_name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (job_name, batch_id)
+);
@@ -0,0 +1,6 @@
+-- rate limit state goes with its peer
+DELETE FROM peer_rate_limits WHERE peer_name NOT IN (SELECT name FROM peers);
+
+ALTER TABLE peer_rate_limits
+ADD CONSTRAINT fk_peer_rate_limits_peer_name
+FOREIGN KEY (peer_name) REFERENCES peers(name) ON DELETE CASCADE ON UPDATE CASCADE;
@@ -97,6 +97,7 @@ impl CatalogConfig<'_> {
             require_tls: false,
             auth_type: PostgresAuthType::PostgresPassword.into(),
             aws_auth: None,
+            tls: None,
         }
     }
 
@@ -360,6 +361,11 @@ impl Catalog {
                         pt::peerdb_peers::S3Config::decode(&options[..]).with_context(err)?;
                     Config::S3Config(s3_config)
                 }
+                DbType::AzureBlob => {
+                    let azure_blob_config = pt::peerdb_peers::AzureBlobConfig::decode(&options[..])
+                        .with_context(err)?;
+                    Config::AzureBlobConfig(azure_blob_config)
+                }
                 DbType::Sqlserver => {
                     let sqlserver_config = pt::peerdb_peers::SqlServerConfig::decode(&options[..])
                         .with_context(err)?;
@@ -387,6 +393,11 @@ impl Catalog {
                         pt::peerdb_peers::PubSubConfig::decode(&options[..]).with_context(err)?;
                     Config::PubsubConfig(pubsub_config)
                 }
+                DbType::Kinesis => {
+                    let kinesis_config =
+                        pt::peerdb_peers::KinesisConfig::decode(&options[..]).with_context(err)?;
+                    Config::KinesisConfig(kinesis_config)
+                }
                 DbType::Elasticsearch => {
                     let elasticsearch_config =
                         pt::peerdb_peers::ElasticsearchConfig::decode(&options[..])
@@ -1,11 +1,24 @@
 use pt::{
     flow_model::{FlowJob, QRepFlowJob},
-    peerdb_flow::{QRepWriteMode, QRepWriteType, TypeSystem},
+    peerdb_flow::{
+        QRepWriteMode, QRepWriteType, S3PartitionConfig, S3PartitionGranularity, TypeSystem,
+    },
+    peerdb_peers::{AvroCodec, S3FileFormat},
     peerdb_route, tonic,
 };
 use serde_json::Value;
 use tonic_health::pb::health_client;
 
+fn s3_partition_granularity(granularity: &str) -> anyhow::Result<i32> {
+    let granularity = match granularity {
+        "day" => S3PartitionGranularity::S3PartitionDay,
+        "hour" => S3PartitionGranularity::S3PartitionHour,
+        "month" => S3PartitionGranularity::S3PartitionMonth,
+        _ => anyhow::bail!("invalid s3_partition_granularity {}", granularity),
+    };
+    Ok(granularity as i32)
+}
+
 pub enum PeerCreationResult {
     Created,
     Failed(String),
@@ -48,6 +61,7 @@ impl FlowGrpcClient {
         let create_qrep_flow_req = pt::peerdb_route::CreateQRepFlowRequest {
             qrep_config: Some(qrep_config.clone()),
             create_catalog_entry: false,
+            allow_update: false,
         };
         let response = self.client.create_q_rep_flow(create_qrep_flow_req).await?;
         let workflow_id = response.into_inner().workflow_id;
@@ -60,6 +74,8 @@ impl FlowGrpcClient {
     ) -> anyhow::Result<String> {
         let create_peer_flow_req = pt::peerdb_route::CreateCdcFlowRequest {
             connection_configs: Some(peer_flow_config),
+            template_name: String::new(),
+            allow_update: false,
         };
         let response = self.client.create_cdc_flow(create_peer_flow_req).await?;
         let workflow_id = response.into_inner().workflow_id;
@@ -86,6 +102,7 @@ impl FlowGrpcClient {
             flow_config_update,
             drop_mirror_stats: false,
             skip_destination_drop: false,
+            scope: pt::peerdb_route::FlowStateChangeScope::Mirror.into(),
         };
         self.client.flow_state_change(state_change_req).await?;
         Ok(())
@@ -105,8 +122,7 @@ impl FlowGrpcClient {
                 destination_table_identifier: mapping.destination_table_identifier.clone(),
                 partition_key: mapping.partition_key.clone().unwrap_or_default(),
                 exclude: mapping.exclude.clone(),
-                columns: Default::default(),
-                engine: Default::default(),
+                ..Default::default()
             })
             .collect::<Vec<_>>();
 
@@ -146,6 +162,24 @@ impl FlowGrpcClient {
             idle_timeout_seconds: job.sync_interval.unwrap_or_default(),
             env: Default::default(),
             version: 0, // filled in by server
+            max_batch_bytes: job.max_batch_bytes.unwrap_or_default(),
+            paused_table_mappings: vec![],
+            freshness_slo: None,
+            max_source_connections: 0,
+            s3_partition: job
+                .s3_partition
+                .as_ref()
+                .map(|partition| -> anyhow::Result<S3PartitionConfig> {
+                    Ok(S3PartitionConfig {
+                        column: partition.column.clone(),
+                        granularity: s3_partition_granularity(&partition.granularity)?,
+                        key: partition.key.clone(),
+                        by_table: partition.by_table,
+                    })
+                })
+                .transpose()?,
+            migrate_constraints: job.migrate_constraints,
+            worker_pool: job.worker_pool.clone(),
         };
 
         if job.disable_peerdb_columns {
@@ -204,6 +238,29 @@ impl FlowGrpcClient {
                         }
                     }
                     "staging_path" => cfg.staging_path.clone_from(s),
+                    "file_format" => {
+                        let format = S3FileFormat::from_str_name(s)
+                            .ok_or_else(|| anyhow::anyhow!("invalid file_format {}", s))?;
+                        cfg.file_format = Some(format as i32);
+                    }
+                    "codec" => {
+                        let codec = AvroCodec::from_str_name(s)
+                            .ok_or_else(|| anyhow::anyhow!("invalid codec {}", s))?;
+                        cfg.codec = Some(codec as i32);
+                    }
+                    "s3_partition_column" => cfg
+                        .s3_partition
+                        .get_or_insert_default()
+                        .column
+                        .clone_from(s),
+                    "s3_partition_granularity" => {
+                        cfg.s3_partition.get_or_insert_default().granularity =
+                            s3_partition_granularity(s)?
+                    }
+                    "s3_partition_key" => {
+                        cfg.s3_partition.get_or_insert_default().key.clone_from(s)
+                    }
+                    "worker_pool" => cfg.worker_pool.clone_from(s),
                     _ => return anyhow::Result::Err(anyhow::anyhow!("invalid str option {}", key)),
                 },
                 Value::Number(n) => match key.as_str() {
@@ -222,6 +279,11 @@ impl FlowGrpcClient {
                             cfg.num_rows_per_partition = n as u32;
                         }
                     }
+                    "parquet_row_group_size" => {
+                        if let Some(n) = n.as_i64() {
+                            cfg.parquet_row_group_size = n as u32;
+                        }
+                    }
                     _ => return anyhow::Result::Err(anyhow::anyhow!("invalid num option {}", key)),
                 },
                 Value::Bool(v) => {
@@ -231,6 +293,11 @@ impl FlowGrpcClient {
                         cfg.setup_watermark_table_on_destination = *v;
                     } else if key == "dst_table_full_resync" {
                         cfg.dst_table_full_resync = *v;
+                    } else if key == "s3_partition_by_table" {
+                        // always set with its default, so only enables partitioning when true
+                        if *v {
+                            cfg.s3_partition.get_or_insert_default().by_table = true;
+                        }
                     } else {
                         return anyhow::Result::Err(anyhow::anyhow!("invalid bool option {}", key));
                     }
@@ -296,6 +363,7 @@ impl FlowGrpcClient {
             flow_config_update: None,
             drop_mirror_stats: true,
             skip_destination_drop: false,
+            scope: pt::peerdb_route::FlowStateChangeScope::Mirror.into(),
         };
         self.client.flow_state_change(state_change_req).await?;
         Ok(())
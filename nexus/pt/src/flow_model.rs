@@ -26,6 +26,7 @@ pub struct FlowJob {
     pub cdc_staging_path: Option<String>,
     pub replication_slot_name: Option<String>,
     pub max_batch_size: Option<u32>,
+    pub max_batch_bytes: Option<u64>,
     pub sync_interval: Option<u64>,
     pub resync: bool,
     pub soft_delete_col_name: Option<String>,
@@ -34,6 +35,17 @@ pub struct FlowJob {
     pub script: String,
     pub system: String,
     pub disable_peerdb_columns: bool,
+    pub s3_partition: Option<FlowJobS3Partition>,
+    pub migrate_constraints: bool,
+    pub worker_pool: String,
+}
+
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize, Clone)]
+pub struct FlowJobS3Partition {
+    pub column: String,
+    pub granularity: String,
+    pub key: String,
+    pub by_table: bool,
 }
 
 #[derive(Debug, PartialEq, Eq, Serialize, Deserialize, Clone)]
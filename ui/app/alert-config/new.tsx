@@ -1,6 +1,7 @@
 import { PostAlertConfigRequest } from '@/grpc_generated/route';
 import { Button } from '@/lib/Button';
 import { Label } from '@/lib/Label/Label';
+import { Switch } from '@/lib/Switch';
 import { TextField } from '@/lib/TextField';
 import Image from 'next/image';
 import Link from 'next/link';
@@ -13,13 +14,35 @@ import { notifyErr } from '../utils/notify';
 import {
   alertConfigReqSchema,
   alertConfigType,
+  alertTypes,
   emailConfigType,
+  opsgenieConfigType,
+  pagerDutyConfigType,
   serviceConfigType,
   serviceTypeSchemaMap,
+  severities,
+  severityMappingType,
   slackConfigType,
+  webhookConfigType,
 } from './validation';
 
-export type ServiceType = 'slack' | 'email';
+export type ServiceType =
+  | 'slack'
+  | 'email'
+  | 'pagerduty'
+  | 'opsgenie'
+  | 'webhook';
+
+export const serviceTypeLabels: Record<ServiceType, string> = {
+  slack: 'Slack',
+  email: 'Email',
+  pagerduty: 'PagerDuty',
+  opsgenie: 'Opsgenie',
+  webhook: 'Webhook',
+};
+
+// providers without an icon under public/images
+export const serviceTypesWithIcon: string[] = ['slack', 'email'];
 
 export interface AlertConfigProps {
   id?: number;
@@ -27,9 +50,13 @@ export interface AlertConfigProps {
   alertConfig: serviceConfigType;
   forEdit?: boolean;
   alertForMirrors?: string[];
+  alertForTableGroups?: string[];
 }
 
 function ConfigLabel(data: { label: string; value: string }) {
+  if (!serviceTypesWithIcon.includes(data.value)) {
+    return <div>{data.label}</div>;
+  }
   return (
     <div style={{ display: 'flex', alignItems: 'center' }}>
       <Image
@@ -133,6 +160,254 @@ function getEmailProps(
     </>
   );
 }
+function getSeverityMappingProps(
+  severityMapping: severityMappingType,
+  setSeverityMapping: (severityMapping: severityMappingType) => void
+) {
+  return (
+    <div>
+      <p>Severity per alert</p>
+      <Label as='label' style={{ fontSize: 14 }}>
+        Alerts without a severity here use their default severity
+      </Label>
+      {alertTypes.map((alertType) => (
+        <div
+          key={alertType}
+          style={{
+            display: 'flex',
+            alignItems: 'center',
+            columnGap: '1rem',
+            marginTop: '0.5rem',
+          }}
+        >
+          <p style={{ width: '40%' }}>{alertType}</p>
+          <div style={{ width: '60%' }}>
+            <ReactSelect
+              options={severities.map((severity) => ({
+                value: severity,
+                label: severity,
+              }))}
+              placeholder='default'
+              isClearable
+              value={
+                severityMapping?.[alertType]
+                  ? {
+                      value: severityMapping[alertType],
+                      label: severityMapping[alertType],
+                    }
+                  : null
+              }
+              onChange={(val) => {
+                const updated = { ...severityMapping };
+                if (val) {
+                  updated[alertType] = val.value;
+                } else {
+                  delete updated[alertType];
+                }
+                setSeverityMapping(updated);
+              }}
+              theme={SelectTheme}
+            />
+          </div>
+        </div>
+      ))}
+    </div>
+  );
+}
+
+function getPagerDutyProps(
+  config: pagerDutyConfigType,
+  setConfig: Dispatch<SetStateAction<pagerDutyConfigType>>
+) {
+  return (
+    <>
+      <div>
+        <p>Routing Key</p>
+        <Label as='label' style={{ fontSize: 14 }}>
+          Integration key of an Events API v2 integration
+        </Label>
+        <TextField
+          key={'routing_key'}
+          style={{ height: '2.5rem', marginTop: '0.5rem' }}
+          variant='simple'
+          placeholder='Routing Key'
+          value={config.routing_key}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              routing_key: e.target.value,
+            }));
+          }}
+        />
+      </div>
+      {getSeverityMappingProps(config.severity_mapping, (severityMapping) =>
+        setConfig((previous) => ({
+          ...previous,
+          severity_mapping: severityMapping,
+        }))
+      )}
+    </>
+  );
+}
+
+function getOpsgenieProps(
+  config: opsgenieConfigType,
+  setConfig: Dispatch<SetStateAction<opsgenieConfigType>>
+) {
+  return (
+    <>
+      <div>
+        <p>API Key</p>
+        <TextField
+          key={'api_key'}
+          style={{ height: '2.5rem', marginTop: '0.5rem' }}
+          variant='simple'
+          placeholder='API Key'
+          value={config.api_key}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              api_key: e.target.value,
+            }));
+          }}
+        />
+      </div>
+      <div>
+        <p>API URL</p>
+        <Label as='label' style={{ fontSize: 14 }}>
+          Use https://api.eu.opsgenie.com for accounts in the EU region
+        </Label>
+        <TextField
+          key={'api_url'}
+          style={{ height: '2.5rem', marginTop: '0.5rem' }}
+          variant='simple'
+          placeholder='https://api.opsgenie.com'
+          value={config.api_url}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              api_url: e.target.value,
+            }));
+          }}
+        />
+      </div>
+      {getSeverityMappingProps(config.severity_mapping, (severityMapping) =>
+        setConfig((previous) => ({
+          ...previous,
+          severity_mapping: severityMapping,
+        }))
+      )}
+    </>
+  );
+}
+
+// headers are edited as `Name: value` lines
+function parseHeaders(text: string): Record<string, string> {
+  const headers: Record<string, string> = {};
+  for (const line of text.split('\n')) {
+    const separator = line.indexOf(':');
+    if (separator > 0) {
+      headers[line.slice(0, separator).trim()] = line
+        .slice(separator + 1)
+        .trim();
+    }
+  }
+  return headers;
+}
+
+function getWebhookProps(
+  config: webhookConfigType,
+  setConfig: Dispatch<SetStateAction<webhookConfigType>>
+) {
+  return (
+    <>
+      <div>
+        <p>URL</p>
+        <TextField
+          key={'url'}
+          style={{ height: '2.5rem', marginTop: '0.5rem' }}
+          variant='simple'
+          placeholder='https://example.com/alerts'
+          value={config.url}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              url: e.target.value,
+            }));
+          }}
+        />
+      </div>
+      <div>
+        <p>Headers</p>
+        <Label as='label' style={{ fontSize: 14 }}>
+          One header per line, as Name: value
+        </Label>
+        <textarea
+          key={'headers'}
+          style={{ width: '100%', marginTop: '0.5rem' }}
+          rows={3}
+          placeholder='Authorization: Bearer ...'
+          defaultValue={Object.entries(config.headers ?? {})
+            .map(([name, value]) => `${name}: ${value}`)
+            .join('\n')}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              headers: parseHeaders(e.target.value),
+            }));
+          }}
+        />
+      </div>
+      <div>
+        <p>Payload Template</p>
+        <Label as='label' style={{ fontSize: 14 }}>
+          Go template with fields .Event (trigger or resolve), .DedupKey,
+          .Title, .Message, .FlowName, .AlertType, .Severity, .DeploymentUID
+          and .Timestamp. If left empty, all fields are sent as JSON. Use json
+          to quote values, e.g. {'{{ json .Message }}'}
+        </Label>
+        <textarea
+          key={'payload_template'}
+          style={{
+            width: '100%',
+            marginTop: '0.5rem',
+            fontFamily: 'monospace',
+          }}
+          rows={5}
+          placeholder='{"text": {{ json .Message }}}'
+          value={config.payload_template}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              payload_template: e.target.value,
+            }));
+          }}
+        />
+      </div>
+      <div
+        style={{ display: 'flex', alignItems: 'center', columnGap: '1rem' }}
+      >
+        <Switch
+          checked={config.send_flow_errors ?? false}
+          onCheckedChange={(state: boolean) => {
+            setConfig((previous) => ({
+              ...previous,
+              send_flow_errors: state,
+            }));
+          }}
+        />
+        <p>Send mirror errors that need attention</p>
+      </div>
+      {getSeverityMappingProps(config.severity_mapping, (severityMapping) =>
+        setConfig((previous) => ({
+          ...previous,
+          severity_mapping: severityMapping,
+        }))
+      )}
+    </>
+  );
+}
+
 function getServiceFields<T extends serviceConfigType>(
   serviceType: ServiceType,
   config: T,
@@ -150,6 +425,21 @@ function getServiceFields<T extends serviceConfigType>(
         setConfig as Dispatch<SetStateAction<slackConfigType>>
       );
     }
+    case 'pagerduty':
+      return getPagerDutyProps(
+        config as pagerDutyConfigType,
+        setConfig as Dispatch<SetStateAction<pagerDutyConfigType>>
+      );
+    case 'opsgenie':
+      return getOpsgenieProps(
+        config as opsgenieConfigType,
+        setConfig as Dispatch<SetStateAction<opsgenieConfigType>>
+      );
+    case 'webhook':
+      return getWebhookProps(
+        config as webhookConfigType,
+        setConfig as Dispatch<SetStateAction<webhookConfigType>>
+      );
   }
 }
 
@@ -166,6 +456,10 @@ export function NewConfig(alertProps: AlertConfigProps) {
     alertProps.alertForMirrors || []
   );
 
+  const [alertForTableGroups, setAlertForTableGroups] = useState<string[]>(
+    alertProps.alertForTableGroups || []
+  );
+
   const [loading, setLoading] = useState(false);
 
   const handleAdd = async () => {
@@ -191,6 +485,7 @@ export function NewConfig(alertProps: AlertConfigProps) {
       serviceType,
       serviceConfig,
       alertForMirrors,
+      alertForTableGroups,
     };
 
     const alertReqValidity = alertConfigReqSchema.safeParse(alertConfigReq);
@@ -208,6 +503,10 @@ export function NewConfig(alertProps: AlertConfigProps) {
           alertConfigReq.alertForMirrors?.filter(
             (mirror) => mirror && mirror.trim() !== ''
           ) || [],
+        alertForTableGroups:
+          alertConfigReq.alertForTableGroups?.filter(
+            (group) => group && group.trim() !== ''
+          ) || [],
       },
     };
 
@@ -240,20 +539,13 @@ export function NewConfig(alertProps: AlertConfigProps) {
         <p style={{ marginBottom: '0.5rem' }}>Alert Provider</p>
         <ReactSelect
           key={'serviceType'}
-          options={[
-            {
-              value: 'slack',
-              label: 'Slack',
-            },
-            {
-              value: 'email',
-              label: 'Email',
-            },
-          ]}
+          options={Object.entries(serviceTypeLabels).map(
+            ([value, label]) => ({ value, label })
+          )}
           placeholder='Select provider'
           defaultValue={{
             value: serviceType,
-            label: serviceType.charAt(0).toUpperCase() + serviceType.slice(1),
+            label: serviceTypeLabels[serviceType],
           }}
           formatOptionLabel={ConfigLabel}
           onChange={(val, _) => val && setServiceType(val.value as ServiceType)}
@@ -317,6 +609,20 @@ export function NewConfig(alertProps: AlertConfigProps) {
           onChange={(e) => setAlertForMirrors(e.target.value.split(','))}
         />
       </div>
+      <div>
+        <p>
+          Alert only for tables in these table groups (leave empty to alert for
+          all tables)
+        </p>
+        <TextField
+          key={'alert_for_table_groups'}
+          style={{ height: '2.5rem', marginTop: '0.5rem' }}
+          variant='simple'
+          placeholder='Comma separated'
+          value={alertForTableGroups.join(',')}
+          onChange={(e) => setAlertForTableGroups(e.target.value.split(','))}
+        />
+      </div>
       {ServiceFields}
       <Button
         style={{ marginTop: '1rem', width: '20%', height: '2.5rem' }}
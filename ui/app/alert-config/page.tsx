@@ -12,7 +12,13 @@ import { PulseLoader } from 'react-spinners';
 import useSWR from 'swr';
 import { tableStyle } from '../peers/[peerName]/style';
 import { fetcher } from '../utils/swr';
-import { AlertConfigProps, NewConfig, ServiceType } from './new';
+import {
+  AlertConfigProps,
+  NewConfig,
+  ServiceType,
+  serviceTypeLabels,
+  serviceTypesWithIcon,
+} from './new';
 
 function ServiceIcon({
   serviceType,
@@ -21,6 +27,9 @@ function ServiceIcon({
   serviceType: string;
   size: number;
 }) {
+  if (!serviceTypesWithIcon.includes(serviceType)) {
+    return null;
+  }
   return (
     <Image
       src={`/images/${serviceType}.png`}
@@ -47,10 +56,14 @@ export default function AlertConfigPage() {
       email_addresses: [''],
       auth_token: '',
       channel_ids: [''],
+      routing_key: '',
+      api_key: '',
+      url: '',
       open_connections_alert_threshold: 20,
       slot_lag_mb_alert_threshold: 5000,
     },
     alertForMirrors: [],
+    alertForTableGroups: [],
     forEdit: false,
   };
 
@@ -67,13 +80,18 @@ export default function AlertConfigPage() {
       alertConfig: JSON.parse(alertConfig.serviceConfig),
       forEdit: true,
       alertForMirrors: alertConfig.alertForMirrors,
+      alertForTableGroups: alertConfig.alertForTableGroups,
     });
   };
 
   const genConfigJSON = (alertConfig: AlertConfig) => {
     const parsedConfig = JSON.parse(alertConfig.serviceConfig);
     return JSON.stringify(
-      { ...parsedConfig, alertForMirrors: alertConfig.alertForMirrors },
+      {
+        ...parsedConfig,
+        alertForMirrors: alertConfig.alertForMirrors,
+        alertForTableGroups: alertConfig.alertForTableGroups,
+      },
       null,
       2
     );
@@ -123,8 +141,9 @@ export default function AlertConfigPage() {
                             size={30}
                           />
                           <Label>
-                            {alertConfig.serviceType.charAt(0).toUpperCase() +
-                              alertConfig.serviceType.slice(1)}
+                            {serviceTypeLabels[
+                              alertConfig.serviceType as ServiceType
+                            ] ?? alertConfig.serviceType}
                           </Label>
                         </div>
                       </div>
@@ -42,23 +42,89 @@ export const emailServiceConfigSchema = z.intersection(
   })
 );
 
+export const alertTypes = [
+  'slot_lag',
+  'bad_wal_status',
+  'open_connections',
+  'normalize_gap',
+  'table_lag',
+  'freshness_slo',
+  'flow_error',
+  'alert_rule',
+  'stale_flow',
+  'quota',
+] as const;
+export const severities = ['critical', 'error', 'warning', 'info'] as const;
+
+const severityMappingSchema = z
+  .record(z.string(), z.enum(severities))
+  .optional();
+
+export const pagerDutyServiceConfigSchema = z.intersection(
+  baseServiceConfigSchema,
+  z.object({
+    routing_key: z
+      .string({ error: () => 'Routing Key is needed.' })
+      .trim()
+      .min(1, { message: 'Routing Key cannot be empty' }),
+    severity_mapping: severityMappingSchema,
+  })
+);
+
+export const opsgenieServiceConfigSchema = z.intersection(
+  baseServiceConfigSchema,
+  z.object({
+    api_key: z
+      .string({ error: () => 'API Key is needed.' })
+      .trim()
+      .min(1, { message: 'API Key cannot be empty' }),
+    api_url: z.string().trim().optional(),
+    severity_mapping: severityMappingSchema,
+  })
+);
+
+export const webhookServiceConfigSchema = z.intersection(
+  baseServiceConfigSchema,
+  z.object({
+    url: z
+      .string({ error: () => 'URL is needed.' })
+      .trim()
+      .url({ message: 'URL is invalid' }),
+    headers: z.record(z.string(), z.string()).optional(),
+    payload_template: z.string().optional(),
+    send_flow_errors: z.boolean().optional(),
+    severity_mapping: severityMappingSchema,
+  })
+);
+
 export const serviceConfigSchema = z.union([
   slackServiceConfigSchema,
   emailServiceConfigSchema,
+  pagerDutyServiceConfigSchema,
+  opsgenieServiceConfigSchema,
+  webhookServiceConfigSchema,
 ]);
 export const alertConfigReqSchema = z.object({
   id: z.optional(z.number({ error: () => 'ID must be a valid number' })),
-  serviceType: z.enum(['slack', 'email'], {
-    error: () => ({ message: 'Invalid service type' }),
-  }),
+  serviceType: z.enum(
+    ['slack', 'email', 'pagerduty', 'opsgenie', 'webhook'],
+    {
+      error: () => ({ message: 'Invalid service type' }),
+    }
+  ),
   serviceConfig: serviceConfigSchema,
   alertForMirrors: z.array(z.string().trim()).optional(),
+  alertForTableGroups: z.array(z.string().trim()).optional(),
 });
 
 export type baseServiceConfigType = z.infer<typeof baseServiceConfigSchema>;
 
 export type slackConfigType = z.infer<typeof slackServiceConfigSchema>;
 export type emailConfigType = z.infer<typeof emailServiceConfigSchema>;
+export type pagerDutyConfigType = z.infer<typeof pagerDutyServiceConfigSchema>;
+export type opsgenieConfigType = z.infer<typeof opsgenieServiceConfigSchema>;
+export type webhookConfigType = z.infer<typeof webhookServiceConfigSchema>;
+export type severityMappingType = z.infer<typeof severityMappingSchema>;
 
 export type serviceConfigType = z.infer<typeof serviceConfigSchema>;
 
@@ -67,4 +133,7 @@ export type alertConfigType = z.infer<typeof alertConfigReqSchema>;
 export const serviceTypeSchemaMap = {
   slack: slackServiceConfigSchema,
   email: emailServiceConfigSchema,
+  pagerduty: pagerDutyServiceConfigSchema,
+  opsgenie: opsgenieServiceConfigSchema,
+  webhook: webhookServiceConfigSchema,
 };
@@ -8,6 +8,7 @@ export async function GET(request: NextRequest) {
     'SNOWFLAKE',
     'BIGQUERY',
     'S3',
+    'AZURE_BLOB',
     'CLICKHOUSE',
     'ELASTICSEARCH',
   ];
@@ -19,6 +20,7 @@ export async function GET(request: NextRequest) {
     'KAFKA',
     'EVENTHUBS',
     'PUBSUB',
+    'KINESIS',
   ];
   const postgresTypes: [
     string,
@@ -27,4 +27,15 @@ export type TableMapRow = {
   editingDisabled: boolean;
   engine: TableEngine;
   columns: ColumnSetting[];
+  tableGroup: string;
+  orderBy: string;
+  partitionBy: string;
+  ttl: string;
+  clusterBy: string;
+  transient: boolean;
+  dataRetentionDays?: number;
+  timePartitioningColumn: string;
+  partitionExpirationDays?: number;
+  clusteringColumns: string[];
+  precombineField: string;
 };
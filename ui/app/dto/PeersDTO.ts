@@ -1,10 +1,12 @@
 import {
+  AzureBlobConfig,
   BigqueryConfig,
   ClickhouseConfig,
   ElasticsearchConfig,
   EventHubConfig,
   EventHubGroupConfig,
   KafkaConfig,
+  KinesisConfig,
   MySqlConfig,
   PostgresConfig,
   PubSubConfig,
@@ -19,7 +21,9 @@ export type PeerConfig =
   | BigqueryConfig
   | ClickhouseConfig
   | S3Config
+  | AzureBlobConfig
   | KafkaConfig
+  | KinesisConfig
   | PubSubConfig
   | EventHubConfig
   | EventHubGroupConfig
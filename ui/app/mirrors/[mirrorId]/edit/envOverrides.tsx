@@ -0,0 +1,139 @@
+'use client';
+
+import SelectTheme from '@/app/styles/select';
+import { notifyErr } from '@/app/utils/notify';
+import {
+  DynamicSetting,
+  GetDynamicSettingsResponse,
+  UpdateMirrorEnvRequest,
+} from '@/grpc_generated/route';
+import { Button } from '@/lib/Button';
+import { Label } from '@/lib/Label';
+import { TextField } from '@/lib/TextField';
+import { useEffect, useMemo, useState } from 'react';
+import ReactSelect from 'react-select';
+
+type EnvOverridesProps = {
+  mirrorId: string;
+  env: { [key: string]: string };
+  onUpdate: () => void;
+};
+
+// overrides apply to running mirrors without pausing them
+export default function EnvOverrides({
+  mirrorId,
+  env,
+  onUpdate,
+}: EnvOverridesProps) {
+  const [settings, setSettings] = useState<DynamicSetting[]>([]);
+  const [key, setKey] = useState('');
+  const [value, setValue] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    fetch('/api/v1/dynamic_settings', { cache: 'no-store' })
+      .then((res) => res.json())
+      .then((res: GetDynamicSettingsResponse) => setSettings(res.settings));
+  }, []);
+
+  const options = useMemo(
+    () =>
+      settings.map((setting) => ({
+        value: setting.name,
+        label: setting.name,
+      })),
+    [settings]
+  );
+  const selected = settings.find((setting) => setting.name === key);
+
+  const updateEnv = async (req: UpdateMirrorEnvRequest) => {
+    setLoading(true);
+    const res = await fetch('/api/v1/mirrors/env', {
+      method: 'POST',
+      body: JSON.stringify(req),
+      cache: 'no-store',
+    });
+    setLoading(false);
+    if (!res.ok) {
+      notifyErr(`Failed to update env: ${await res.text()}`);
+      return;
+    }
+    setKey('');
+    setValue('');
+    onUpdate();
+  };
+
+  return (
+    <div style={{ marginTop: '1rem' }}>
+      <Label variant='action' as='label'>
+        Setting Overrides
+      </Label>
+      {Object.entries(env).map(([name, current]) => (
+        <div
+          key={name}
+          style={{ display: 'flex', alignItems: 'center', columnGap: '1rem' }}
+        >
+          <Label as='label' style={{ fontSize: 14 }}>
+            {name} = {current}
+          </Label>
+          <Button
+            disabled={loading}
+            onClick={() =>
+              updateEnv({
+                flowJobName: mirrorId,
+                env: {},
+                removedKeys: [name],
+              })
+            }
+          >
+            Remove
+          </Button>
+        </div>
+      ))}
+      <div
+        style={{
+          display: 'flex',
+          alignItems: 'center',
+          columnGap: '1rem',
+          marginTop: '0.5rem',
+        }}
+      >
+        <div style={{ width: '30rem' }}>
+          <ReactSelect
+            options={options}
+            value={options.find((option) => option.value === key) ?? null}
+            onChange={(option) => setKey(option?.value ?? '')}
+            placeholder='Setting'
+            theme={SelectTheme}
+          />
+        </div>
+        <TextField
+          variant='simple'
+          placeholder={selected?.defaultValue ?? 'Value'}
+          value={value}
+          onChange={(e: React.ChangeEvent<HTMLInputElement>) =>
+            setValue(e.target.value)
+          }
+        />
+        <Button
+          variant='normalSolid'
+          disabled={loading || !key}
+          onClick={() =>
+            updateEnv({
+              flowJobName: mirrorId,
+              env: { [key]: value },
+              removedKeys: [],
+            })
+          }
+        >
+          Override
+        </Button>
+      </div>
+      {selected && (
+        <Label as='label' style={{ fontSize: 13 }}>
+          {selected.description}
+        </Label>
+      )}
+    </div>
+  );
+}
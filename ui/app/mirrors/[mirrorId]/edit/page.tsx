@@ -29,6 +29,7 @@ import { blankCDCSetting } from '../../create/helpers/common';
 import { tableMappingSchema } from '../../create/schema';
 import * as styles from '../../create/styles';
 import { getMirrorState } from '../handlers';
+import EnvOverrides from './envOverrides';
 
 type EditMirrorProps = {
   params: { mirrorId: string };
@@ -46,8 +47,12 @@ export default function EditMirror({ params: { mirrorId } }: EditMirrorProps) {
     idleTimeout: defaultIdleTimeout,
     additionalTables: [],
     removedTables: [],
+    pausedTableGroups: [],
+    resumedTableGroups: [],
+    resyncedTableGroups: [],
     numberOfSyncs: 0,
     updatedEnv: {},
+    maxBatchBytes: blankCDCSetting.maxBatchBytes,
   });
   const { push } = useRouter();
 
@@ -64,8 +69,13 @@ export default function EditMirror({ params: { mirrorId } }: EditMirrorProps) {
         defaultIdleTimeout,
       additionalTables: [],
       removedTables: [],
+      pausedTableGroups: [],
+      resumedTableGroups: [],
+      resyncedTableGroups: [],
       numberOfSyncs: 0,
       updatedEnv: {},
+      maxBatchBytes:
+        (res as MirrorStatusResponse).cdcStatus?.config?.maxBatchBytes ?? 0,
     });
   }, [mirrorId, defaultBatchSize, defaultIdleTimeout]);
 
@@ -162,6 +172,33 @@ export default function EditMirror({ params: { mirrorId } }: EditMirrorProps) {
         }
       />
 
+      <RowWithTextField
+        key={3}
+        label={<Label>{'Pull Batch Size (Bytes, 0 for no limit)'} </Label>}
+        action={
+          <div
+            style={{
+              display: 'flex',
+              flexDirection: 'row',
+              alignItems: 'center',
+            }}
+          >
+            <TextField
+              variant='simple'
+              type={'number'}
+              onChange={(e: React.ChangeEvent<HTMLInputElement>) =>
+                setConfig({
+                  ...config,
+                  // empty means no limit
+                  maxBatchBytes: e.target.valueAsNumber || 0,
+                })
+              }
+              defaultValue={config.maxBatchBytes}
+            />
+          </div>
+        }
+      />
+
       <RowWithTextField
         key={2}
         label={<Label>{'Sync Interval (Seconds)'} </Label>}
@@ -188,6 +225,12 @@ export default function EditMirror({ params: { mirrorId } }: EditMirrorProps) {
         }
       />
 
+      <EnvOverrides
+        mirrorId={mirrorId}
+        env={mirrorState.cdcStatus?.config?.env ?? {}}
+        onUpdate={fetchStateAndUpdateDeps}
+      />
+
       <Label variant='action' as='label' style={{ marginTop: '1rem' }}>
         Adding Tables
       </Label>
@@ -140,15 +140,19 @@ export function SyncStatusTable({ mirrorName }: SyncStatusTableProps) {
       }}
       header={
         <TableRow>
-          {['Batch ID', 'Start Time', 'End Time (Duration)', 'Rows Synced'].map(
-            (heading, index) => (
-              <TableCell as='th' key={index}>
-                <Label as='label' style={{ fontWeight: 'bold' }}>
-                  {heading}
-                </Label>
-              </TableCell>
-            )
-          )}
+          {[
+            'Batch ID',
+            'Start Time',
+            'End Time (Duration)',
+            'Rows Synced',
+            'Oversized Rows',
+          ].map((heading, index) => (
+            <TableCell as='th' key={index}>
+              <Label as='label' style={{ fontWeight: 'bold' }}>
+                {heading}
+              </Label>
+            </TableCell>
+          ))}
         </TableRow>
       }
     >
@@ -173,6 +177,7 @@ export function SyncStatusTable({ mirrorName }: SyncStatusTableProps) {
             />
           </TableCell>
           <TableCell>{RowDataFormatter(row.numRows)}</TableCell>
+          <TableCell>{RowDataFormatter(row.oversizedRows)}</TableCell>
         </TableRow>
       ))}
     </Table>
@@ -7,7 +7,7 @@ import { Button } from '@/lib/Button';
 import { Icon } from '@/lib/Icon';
 import { ProgressCircle } from '@/lib/ProgressCircle';
 import { CDCConfig, TableMapRow } from '../../../dto/MirrorsDTO';
-import { IsEventhubsPeer, IsQueuePeer, fetchPublications } from '../handlers';
+import { IsQueuePeer, fetchPublications } from '../handlers';
 import { AdvancedSettingType, MirrorSetting } from '../helpers/common';
 import CDCField from './fields';
 import TablePicker from './tablemapping';
@@ -90,13 +90,9 @@ export default function CDCConfigForm({
           destinationType.toString() === DBType[DBType.BIGQUERY] ||
           destinationType.toString() === DBType[DBType.SNOWFLAKE]
         )) ||
-      (IsEventhubsPeer(destinationType) &&
-        (label.includes('initial copy') ||
-          label.includes('initial load') ||
-          label.includes('snapshot'))) ||
       ((sourceType.toString() !== DBType[DBType.POSTGRES] ||
         destinationType.toString() !== DBType[DBType.POSTGRES]) &&
-        label.includes('type system')) ||
+        (label.includes('type system') || label.includes('constraints'))) ||
       (destinationType.toString() !== DBType[DBType.BIGQUERY] &&
         label.includes('column name')) ||
       (label.includes('soft delete') &&
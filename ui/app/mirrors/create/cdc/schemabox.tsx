@@ -122,6 +122,56 @@ export default function SchemaBox({
     setRows(newRows);
   };
 
+  const updateTableClause = (
+    source: string,
+    clause: 'orderBy' | 'partitionBy' | 'ttl',
+    value: string
+  ) => {
+    const newRows = [...rows];
+    const index = newRows.findIndex((row) => row.source === source);
+    newRows[index] = { ...newRows[index], [clause]: value };
+    setRows(newRows);
+  };
+
+  const updateSnowflakeOptions = (
+    source: string,
+    options: Partial<
+      Pick<TableMapRow, 'clusterBy' | 'transient' | 'dataRetentionDays'>
+    >
+  ) => {
+    const newRows = [...rows];
+    const index = newRows.findIndex((row) => row.source === source);
+    newRows[index] = { ...newRows[index], ...options };
+    setRows(newRows);
+  };
+
+  const updateBigQueryOptions = (
+    source: string,
+    options: Partial<
+      Pick<
+        TableMapRow,
+        | 'timePartitioningColumn'
+        | 'partitionExpirationDays'
+        | 'clusteringColumns'
+      >
+    >
+  ) => {
+    const newRows = [...rows];
+    const index = newRows.findIndex((row) => row.source === source);
+    newRows[index] = { ...newRows[index], ...options };
+    setRows(newRows);
+  };
+
+  const updateS3Options = (
+    source: string,
+    options: Partial<Pick<TableMapRow, 'precombineField'>>
+  ) => {
+    const newRows = [...rows];
+    const index = newRows.findIndex((row) => row.source === source);
+    newRows[index] = { ...newRows[index], ...options };
+    setRows(newRows);
+  };
+
   const addTableColumns = useCallback(
     (table: string) => {
       const [schemaName, tableName] = table.split('.');
@@ -251,6 +301,15 @@ export default function SchemaBox({
     },
     { value: 'CH_ENGINE_REPLICATED_MERGE_TREE', label: 'ReplicatedMergeTree' },
     { value: 'CH_ENGINE_NULL', label: 'Null' },
+    {
+      value: 'CH_ENGINE_COLLAPSING_MERGE_TREE',
+      label: 'CollapsingMergeTree',
+    },
+    { value: 'CH_ENGINE_SHARED_MERGE_TREE', label: 'SharedMergeTree' },
+    {
+      value: 'CH_ENGINE_SHARED_REPLACING_MERGE_TREE',
+      label: 'SharedReplacingMergeTree',
+    },
   ];
 
   useEffect(() => {
@@ -359,6 +418,7 @@ export default function SchemaBox({
                           rowGap: '0.5rem',
                           columnGap: '3rem',
                           display: row.selected ? 'flex' : 'none',
+                          flexWrap: 'wrap',
                         }}
                         key={row.source}
                       >
@@ -415,6 +475,252 @@ export default function SchemaBox({
                             />
                           </div>
                         )}
+                        {peerType?.toString() ===
+                          DBType[DBType.CLICKHOUSE].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            ORDER BY:
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              placeholder='Sorting key (default: primary key)'
+                              value={row.orderBy}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateTableClause(
+                                  row.source,
+                                  'orderBy',
+                                  e.target.value
+                                )
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.CLICKHOUSE].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            PARTITION BY:
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              placeholder='Optional partition expression'
+                              value={row.partitionBy}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateTableClause(
+                                  row.source,
+                                  'partitionBy',
+                                  e.target.value
+                                )
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.CLICKHOUSE].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            TTL:
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              placeholder='Optional TTL expression'
+                              value={row.ttl}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateTableClause(
+                                  row.source,
+                                  'ttl',
+                                  e.target.value
+                                )
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.SNOWFLAKE].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            CLUSTER BY:
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              placeholder='Optional clustering key'
+                              value={row.clusterBy}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateSnowflakeOptions(row.source, {
+                                  clusterBy: e.target.value,
+                                })
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.SNOWFLAKE].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            Data retention (days):
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              type='number'
+                              min={0}
+                              placeholder='Schema default'
+                              value={row.dataRetentionDays ?? ''}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateSnowflakeOptions(row.source, {
+                                  dataRetentionDays:
+                                    e.target.value === ''
+                                      ? undefined
+                                      : Number(e.target.value),
+                                })
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.SNOWFLAKE].toString() && (
+                          <div
+                            style={{
+                              width: '30%',
+                              fontSize: 12,
+                              display: 'flex',
+                              alignItems: 'center',
+                              columnGap: '0.5rem',
+                            }}
+                          >
+                            <Checkbox
+                              disabled={row.editingDisabled}
+                              checked={row.transient}
+                              onCheckedChange={(state: boolean) =>
+                                updateSnowflakeOptions(row.source, {
+                                  transient: state,
+                                })
+                              }
+                            />
+                            Transient table
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.BIGQUERY].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            Partition column:
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              placeholder='DATE, DATETIME or TIMESTAMP column'
+                              value={row.timePartitioningColumn}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateBigQueryOptions(row.source, {
+                                  timePartitioningColumn: e.target.value,
+                                })
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.BIGQUERY].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            Partition expiration (days):
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              type='number'
+                              min={1}
+                              placeholder='Never expire'
+                              value={row.partitionExpirationDays ?? ''}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateBigQueryOptions(row.source, {
+                                  partitionExpirationDays:
+                                    e.target.value === ''
+                                      ? undefined
+                                      : Number(e.target.value),
+                                })
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.BIGQUERY].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            Clustering columns:
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              placeholder='Up to 4, comma separated'
+                              value={row.clusteringColumns.join(',')}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateBigQueryOptions(row.source, {
+                                  clusteringColumns: e.target.value.split(','),
+                                })
+                              }
+                            />
+                          </div>
+                        )}
+                        {peerType?.toString() ===
+                          DBType[DBType.S3].toString() && (
+                          <div style={{ width: '30%', fontSize: 12 }}>
+                            Precombine field (Hudi):
+                            <TextField
+                              disabled={row.editingDisabled}
+                              style={{
+                                marginTop: '0.5rem',
+                                cursor: 'pointer',
+                              }}
+                              variant='simple'
+                              placeholder='Latest change wins'
+                              value={row.precombineField}
+                              onChange={(
+                                e: React.ChangeEvent<HTMLInputElement>
+                              ) =>
+                                updateS3Options(row.source, {
+                                  precombineField: e.target.value,
+                                })
+                              }
+                            />
+                          </div>
+                        )}
                       </div>
                     </div>
 
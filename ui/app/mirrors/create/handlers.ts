@@ -2,7 +2,9 @@ import { notifyErr } from '@/app/utils/notify';
 import QRepQueryTemplate from '@/app/utils/qreptemplate';
 import { DBTypeToGoodText } from '@/components/PeerTypeComponent';
 import {
+  ConflictPolicy,
   FlowConnectionConfigs,
+  GeneratedColumnPolicy,
   QRepConfig,
   QRepWriteType,
   TableEngine,
@@ -36,14 +38,8 @@ export function IsQueuePeer(peerType?: DBType): boolean {
     !!peerType &&
     (peerType === DBType.KAFKA ||
       peerType === DBType.PUBSUB ||
-      peerType === DBType.EVENTHUBS)
-  );
-}
-
-export function IsEventhubsPeer(peerType?: DBType): boolean {
-  return (
-    (!!peerType && peerType === DBType.EVENTHUBS) ||
-    peerType?.toString() === DBType[DBType.EVENTHUBS]
+      peerType === DBType.EVENTHUBS ||
+      peerType === DBType.KINESIS)
   );
 }
 
@@ -95,10 +91,6 @@ function CDCCheck(
   config.tableMappings = tableNameMapping as TableMapping[];
   config.flowJobName = flowJobName;
 
-  if (IsEventhubsPeer(destinationType)) {
-    config.doInitialSnapshot = false;
-  }
-
   if (config.doInitialSnapshot == false && config.initialSnapshotOnly == true) {
     return 'Initial Snapshot Only cannot be true if Initial Snapshot is false.';
   }
@@ -186,6 +178,26 @@ export function reformattedTableMapping(
       exclude: Array.from(row.exclude),
       columns: row.columns,
       engine: row.engine,
+      tableGroup: row.tableGroup,
+      orderBy: row.orderBy,
+      partitionBy: row.partitionBy,
+      ttl: row.ttl,
+      clusterBy: row.clusterBy,
+      transient: row.transient,
+      dataRetentionDays: row.dataRetentionDays,
+      timePartitioningColumn: row.timePartitioningColumn,
+      partitionExpirationDays: row.partitionExpirationDays,
+      clusteringColumns: row.clusteringColumns
+        .map((col) => col.trim())
+        .filter((col) => col !== ''),
+      precombineField: row.precombineField,
+      topicCleanupPolicy: '',
+      topicConfigs: {},
+      jsonPathColumns: [],
+      generatedColumns: GeneratedColumnPolicy.GENERATED_COLUMN_POLICY_REPLICATE,
+      identityColumns: GeneratedColumnPolicy.GENERATED_COLUMN_POLICY_REPLICATE,
+      conflictPolicy: ConflictPolicy.CONFLICT_POLICY_SOURCE_WINS,
+      conflictTimestampColumn: '',
     }));
 }
 
@@ -220,6 +232,19 @@ export function changesToTablesMapping(
           exclude: Array.from(row.exclude),
           columns: row.columns,
           engine: row.engine,
+          tableGroup: row.tableGroup,
+          orderBy: row.orderBy,
+          partitionBy: row.partitionBy,
+          ttl: row.ttl,
+          clusterBy: row.clusterBy,
+          transient: row.transient,
+          dataRetentionDays: row.dataRetentionDays,
+          timePartitioningColumn: row.timePartitioningColumn,
+          partitionExpirationDays: row.partitionExpirationDays,
+          clusteringColumns: row.clusteringColumns
+            .map((col) => col.trim())
+            .filter((col) => col !== ''),
+          precombineField: row.precombineField,
         }) as TableMapping
     );
   return mapping;
@@ -453,6 +478,15 @@ export async function fetchTables(
         editingDisabled: false,
         columns: [],
         engine: TableEngine.CH_ENGINE_REPLACING_MERGE_TREE,
+        tableGroup: '',
+        orderBy: '',
+        partitionBy: '',
+        ttl: '',
+        clusterBy: '',
+        transient: false,
+        timePartitioningColumn: '',
+        clusteringColumns: [],
+        precombineField: '',
       });
     }
   }
@@ -1,6 +1,11 @@
 import { TypeSystem } from '@/grpc_generated/flow';
 import { CDCConfig } from '../../../dto/MirrorsDTO';
-import { AdvancedSettingType, blankCDCSetting, MirrorSetting } from './common';
+import {
+  AdvancedSettingType,
+  blankCDCSetting,
+  blankFreshnessSlo,
+  MirrorSetting,
+} from './common';
 export const cdcSettings: MirrorSetting[] = [
   {
     label: 'Initial Copy',
@@ -30,6 +35,20 @@ export const cdcSettings: MirrorSetting[] = [
     default: '250000',
     advanced: AdvancedSettingType.ALL,
   },
+  {
+    label: 'Pull Batch Size (Bytes)',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          maxBatchBytes: (value as number) || 0,
+        })
+      ),
+    tips: 'Size of source changes after which PeerDB syncs a batch, whichever of rows, bytes or sync interval is reached first. If left empty, batches are not limited by size.',
+    type: 'number',
+    default: '0',
+    advanced: AdvancedSettingType.ALL,
+  },
   {
     label: 'Sync Interval (Seconds)',
     stateHandler: (value, setter) =>
@@ -46,6 +65,78 @@ export const cdcSettings: MirrorSetting[] = [
     required: true,
     advanced: AdvancedSettingType.QUEUE,
   },
+  {
+    label: 'Freshness SLO (Seconds)',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          freshnessSlo: {
+            ...blankFreshnessSlo,
+            ...curr.freshnessSlo,
+            maxStalenessSeconds: (value as number) || 0,
+          },
+        })
+      ),
+    tips: 'Maximum time changes may take from commit at source to reaching the target. Breaches are alerted on. If left empty, the mirror has no freshness SLO.',
+    type: 'number',
+    default: '0',
+    advanced: AdvancedSettingType.ALL,
+  },
+  {
+    label: 'Remediate Freshness SLO Breaches',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          freshnessSlo: {
+            ...blankFreshnessSlo,
+            ...curr.freshnessSlo,
+            autoRemediate: (value as boolean) || false,
+          },
+        })
+      ),
+    tips: 'On a breach of the freshness SLO, halve the sync interval and double the pull batch size every 10 minutes until the mirror recovers, within the bounds below.',
+    type: 'switch',
+    default: false,
+    advanced: AdvancedSettingType.ALL,
+  },
+  {
+    label: 'Minimum Sync Interval for Remediation (Seconds)',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          freshnessSlo: {
+            ...blankFreshnessSlo,
+            ...curr.freshnessSlo,
+            minIdleTimeoutSeconds: (value as number) || 0,
+          },
+        })
+      ),
+    tips: 'Lowest sync interval remediation may go down to. If left empty, remediation leaves the sync interval unchanged.',
+    type: 'number',
+    default: '0',
+    advanced: AdvancedSettingType.ALL,
+  },
+  {
+    label: 'Maximum Pull Batch Size for Remediation',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          freshnessSlo: {
+            ...blankFreshnessSlo,
+            ...curr.freshnessSlo,
+            maxBatchSize: (value as number) || 0,
+          },
+        })
+      ),
+    tips: 'Largest pull batch size remediation may go up to. If left empty, remediation leaves the pull batch size unchanged.',
+    type: 'number',
+    default: '0',
+    advanced: AdvancedSettingType.ALL,
+  },
   {
     label: 'Publication Name',
     stateHandler: (value, setter) =>
@@ -121,7 +212,7 @@ export const cdcSettings: MirrorSetting[] = [
           snapshotStagingPath: value as string | '',
         })
       ),
-    tips: 'You can specify staging path for Snapshot sync mode AVRO. For Snowflake as destination peer, this must be either empty or an S3 bucket URL. For BigQuery, this must be either empty or an existing GCS bucket name. In both cases, if empty, the local filesystem will be used.',
+    tips: 'You can specify staging path for Snapshot sync mode AVRO. For Snowflake as destination peer, this must be either empty or an S3 bucket URL. For BigQuery, this must be either empty or an existing GCS bucket name, initial loads are still staged in GCS unlike CDC batches. In both cases, if empty, the local filesystem will be used.',
     advanced: AdvancedSettingType.ALL,
   },
   {
@@ -133,7 +224,7 @@ export const cdcSettings: MirrorSetting[] = [
           cdcStagingPath: (value as string) || '',
         })
       ),
-    tips: 'You can specify staging path for CDC sync mode AVRO. For Snowflake as destination peer, this must be either empty or an S3 bucket URL. For BigQuery, this must be either empty or an existing GCS bucket name. In both cases, if empty, the local filesystem will be used.',
+    tips: 'You can specify staging path for CDC sync mode AVRO. For Snowflake as destination peer, this must be either empty or an S3 bucket URL. If empty, the local filesystem will be used. BigQuery writes CDC batches through the Storage Write API and does not use this path.',
     advanced: AdvancedSettingType.ALL,
   },
   {
@@ -161,7 +252,20 @@ export const cdcSettings: MirrorSetting[] = [
           initialSnapshotOnly: (value as boolean) ?? false,
         })
       ),
-    tips: 'If set, PeerDB will only perform initial load and will not perform CDC sync.',
+    tips: 'If set, PeerDB will only perform initial load and will not perform CDC sync. For queues, rows are sent as inserts followed by a completion marker per table.',
+    type: 'switch',
+    advanced: AdvancedSettingType.ALL,
+  },
+  {
+    label: 'Migrate Constraints After Snapshot',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          migrateConstraints: (value as boolean) ?? false,
+        })
+      ),
+    tips: 'If set, PeerDB recreates the unique indexes, check constraints and foreign keys of source tables on the destination once initial load completes. Foreign keys to tables outside the mirror are left out.',
     type: 'switch',
     advanced: AdvancedSettingType.ALL,
   },
@@ -174,7 +278,19 @@ export const cdcSettings: MirrorSetting[] = [
           script: (value as string) || '',
         })
       ),
-    tips: 'Associate PeerDB script with this mirror.',
+    tips: 'Associate PeerDB script, Lua or WASM, with this mirror. transformRow can rewrite, drop (by returning false) or add columns to rows. Not supported with the PG type system.',
+    advanced: AdvancedSettingType.ALL,
+  },
+  {
+    label: 'Worker pool',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          workerPool: (value as string) || '',
+        })
+      ),
+    tips: 'Run this mirror on flow workers started with --worker-pool set to this name. Leave empty for the default workers.',
     advanced: AdvancedSettingType.ALL,
   },
   {
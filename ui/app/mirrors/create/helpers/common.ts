@@ -1,5 +1,5 @@
 import { CDCConfig } from '@/app/dto/MirrorsDTO';
-import { QRepConfig, TypeSystem } from '@/grpc_generated/flow';
+import { FreshnessSlo, QRepConfig, TypeSystem } from '@/grpc_generated/flow';
 
 export enum AdvancedSettingType {
   QUEUE = 'queue',
@@ -25,12 +25,15 @@ export const blankCDCSetting: CDCConfig = {
   destinationName: '',
   flowJobName: '',
   tableMappings: [],
+  pausedTableMappings: [],
   maxBatchSize: 250000,
+  maxBatchBytes: 0,
   doInitialSnapshot: true,
   publicationName: '',
   snapshotNumRowsPerPartition: 250000,
   snapshotMaxParallelWorkers: 4,
   snapshotNumTablesInParallel: 1,
+  maxSourceConnections: 0,
   snapshotStagingPath: '',
   cdcStagingPath: '',
   replicationSlotName: '',
@@ -38,15 +41,25 @@ export const blankCDCSetting: CDCConfig = {
   softDeleteColName: '_PEERDB_IS_DELETED',
   syncedAtColName: '_PEERDB_SYNCED_AT',
   initialSnapshotOnly: false,
+  migrateConstraints: false,
   idleTimeoutSeconds: 60,
   script: '',
+  workerPool: '',
   system: TypeSystem.Q,
   disablePeerDBColumns: false,
+  freshnessSlo: undefined,
   env: {},
   envString: '',
   version: 0,
 };
 
+export const blankFreshnessSlo: FreshnessSlo = {
+  maxStalenessSeconds: 0,
+  autoRemediate: false,
+  minIdleTimeoutSeconds: 0,
+  maxBatchSize: 0,
+};
+
 export const blankQRepSetting: QRepConfig = {
   sourceName: '',
   destinationName: '',
@@ -57,20 +70,28 @@ export const blankQRepSetting: QRepConfig = {
   watermarkColumn: '',
   initialCopyOnly: false,
   maxParallelWorkers: 4,
+  maxSourceConnections: 0,
   waitBetweenBatchesSeconds: 30,
   writeMode: undefined,
   stagingPath: '',
+  parquetRowGroupSize: 0,
   numRowsPerPartition: 100000,
   setupWatermarkTableOnDestination: false,
   dstTableFullResync: false,
   snapshotName: '',
+  samplePercent: 0,
+  sampleColumn: '',
   softDeleteColName: '_PEERDB_IS_DELETED',
   syncedAtColName: '',
   script: '',
+  workerPool: '',
   system: TypeSystem.Q,
   env: {},
   version: 0,
   parentMirrorName: '',
   exclude: [],
   columns: [],
+  timePartitioningColumn: '',
+  clusteringColumns: [],
+  jsonPathColumns: [],
 };
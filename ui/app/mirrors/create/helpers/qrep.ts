@@ -137,7 +137,7 @@ export const qrepSettings: MirrorSetting[] = [
     label: 'Script',
     stateHandler: (value, setter) =>
       setter((curr: QRepConfig) => ({ ...curr, script: value as string })),
-    tips: 'Script to use for row transformations. The default is no scripting.',
+    tips: 'Script to use for row transformations, transformRow can rewrite rows, drop them by returning false or add columns. The default is no scripting.',
     advanced: AdvancedSettingType.ALL,
   },
   {
@@ -165,4 +165,38 @@ export const qrepSettings: MirrorSetting[] = [
     tips: 'For Snowflake as destination peer, this must be either empty or an S3 bucket URL. For BigQuery, this must be either empty or an existing GCS bucket name. In both cases, if empty, the local filesystem will be used.',
     advanced: AdvancedSettingType.ALL,
   },
+  {
+    label: 'Partition Column',
+    stateHandler: (value, setter) =>
+      setter((curr: QRepConfig) => ({
+        ...curr,
+        timePartitioningColumn: (value as string) || '',
+      })),
+    tips: 'For BigQuery as destination peer, the DATE, DATETIME or TIMESTAMP column to partition the destination table on by day when PeerDB creates it.',
+    advanced: AdvancedSettingType.ALL,
+  },
+  {
+    label: 'Partition Expiration (Days)',
+    stateHandler: (value, setter) =>
+      setter((curr: QRepConfig) => ({
+        ...curr,
+        partitionExpirationDays: parseInt(value as string, 10) || undefined,
+      })),
+    tips: 'For BigQuery as destination peer, partitions older than this many days are deleted. Partitions never expire if empty.',
+    type: 'number',
+    advanced: AdvancedSettingType.ALL,
+  },
+  {
+    label: 'Clustering Columns',
+    stateHandler: (value, setter) =>
+      setter((curr: QRepConfig) => ({
+        ...curr,
+        clusteringColumns: ((value as string) || '')
+          .split(',')
+          .map((col) => col.trim())
+          .filter((col) => col !== ''),
+      })),
+    tips: 'For BigQuery as destination peer, up to 4 comma separated columns to cluster the destination table on. Defaults to the primary key.',
+    advanced: AdvancedSettingType.ALL,
+  },
 ];
@@ -1,11 +1,13 @@
 import { PeerConfig } from '@/app/dto/PeersDTO';
 import {
+  AzureBlobConfig,
   BigqueryConfig,
   ClickhouseConfig,
   DBType,
   ElasticsearchConfig,
   EventHubGroupConfig,
   KafkaConfig,
+  KinesisConfig,
   MySqlConfig,
   Peer,
   PostgresConfig,
@@ -26,11 +28,13 @@ import {
 import { Dispatch, SetStateAction } from 'react';
 
 import {
+  azSchema,
   bqSchema,
   chSchema,
   ehGroupSchema,
   esSchema,
   kaSchema,
+  kiSchema,
   mySchema,
   peerNameSchema,
   pgSchema,
@@ -81,12 +85,24 @@ function constructPeer(
         type: DBType.S3,
         s3Config: config as S3Config,
       };
+    case 'AZURE_BLOB':
+      return {
+        name,
+        type: DBType.AZURE_BLOB,
+        azureBlobConfig: config as AzureBlobConfig,
+      };
     case 'KAFKA':
       return {
         name,
         type: DBType.KAFKA,
         kafkaConfig: config as KafkaConfig,
       };
+    case 'KINESIS':
+      return {
+        name,
+        type: DBType.KINESIS,
+        kinesisConfig: config as KinesisConfig,
+      };
     case 'PUBSUB':
       return {
         name,
@@ -166,10 +182,18 @@ async function validateFields(
       const s3Config = s3Schema.safeParse(config);
       if (!s3Config.success) validationErr = s3Config.error.issues[0].message;
       break;
+    case 'AZURE_BLOB':
+      const azConfig = azSchema.safeParse(config);
+      if (!azConfig.success) validationErr = azConfig.error.issues[0].message;
+      break;
     case 'KAFKA':
       const kaConfig = kaSchema.safeParse(config);
       if (!kaConfig.success) validationErr = kaConfig.error.issues[0].message;
       break;
+    case 'KINESIS':
+      const kiConfig = kiSchema.safeParse(config);
+      if (!kiConfig.success) validationErr = kiConfig.error.issues[0].message;
+      break;
     case 'PUBSUB':
       const psConfig = psSchema.safeParse(config);
       if (!psConfig.success) validationErr = psConfig.error.issues[0].message;
@@ -223,7 +247,13 @@ export async function handleValidate(
 }
 
 function S3Validation(config: S3Config): string {
-  if (!config.secretAccessKey && !config.accessKeyId && !config.roleArn) {
+  // gs:// buckets without HMAC keys use workload identity
+  if (
+    !config.url.startsWith('gs://') &&
+    !config.secretAccessKey &&
+    !config.accessKeyId &&
+    !config.roleArn
+  ) {
     return 'Either both access key and secret or role ARN is required';
   }
   return '';
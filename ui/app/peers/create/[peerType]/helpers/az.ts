@@ -0,0 +1,76 @@
+import {
+  AvroCodec,
+  AzureBlobConfig,
+  S3FileFormat,
+  avroCodecFromJSON,
+  s3FileFormatFromJSON,
+} from '@/grpc_generated/peers';
+import { PeerSetting } from './common';
+
+export const azureBlobSetting: PeerSetting[] = [
+  {
+    label: 'Container URL',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, url: value as string })),
+    tips: 'URL of your container along with a prefix of your choice. ADLS Gen2 abfss:// URLs are accepted too.',
+    helpfulLink:
+      'https://docs.snowflake.com/en/user-guide/data-load-azure-create-stage',
+    default: 'azure://<account>.blob.core.windows.net/<container>/<prefix>',
+  },
+  {
+    label: 'SAS Token',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, sasToken: value as string })),
+    type: 'password',
+    optional: true,
+    tips: 'Shared access signature with read, write, list & delete permissions on the container. Leave empty to use the managed identity of the PeerDB deployment.',
+    helpfulLink:
+      'https://learn.microsoft.com/en-us/azure/storage/common/storage-sas-overview',
+  },
+  {
+    label: 'Managed Identity Client ID',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, clientId: value as string })),
+    optional: true,
+    tips: 'Client ID of a user-assigned managed identity, the default identity is used when empty.',
+  },
+  {
+    label: 'File Format',
+    field: 'fileFormat',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        fileFormat: s3FileFormatFromJSON(value),
+      })),
+    type: 'select',
+    placeholder: 'Select file format',
+    options: [
+      { value: 'S3_AVRO', label: 'Avro' },
+      { value: 'S3_PARQUET', label: 'Parquet' },
+    ],
+    tips: 'Format of the snapshot and CDC files written to the container.',
+  },
+  {
+    label: 'Codec',
+    field: 'codec',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, codec: avroCodecFromJSON(value) })),
+    type: 'select',
+    placeholder: 'Select codec',
+    options: [
+      { value: 'Null', label: 'Null' },
+      { value: 'Deflate', label: 'Deflate' },
+      { value: 'Snappy', label: 'Snappy' },
+      { value: 'ZStandard', label: 'ZStandard' },
+    ],
+    tips: 'Compression of the written files. Parquet files use gzip for Deflate.',
+  },
+];
+
+export const blankAzureBlobSetting: AzureBlobConfig = {
+  url: 'azure://<account>.blob.core.windows.net/<container>/<prefix>',
+  sasToken: undefined,
+  clientId: undefined,
+  codec: AvroCodec.Null,
+  fileFormat: S3FileFormat.S3_AVRO,
+};
@@ -1,6 +1,7 @@
 import { ClickhouseConfig } from '@/grpc_generated/peers';
 import { PeerSetting } from './common';
 import { blankS3Setting } from './s3';
+import { tlsSettings } from './tls';
 
 export const clickhouseSetting: PeerSetting[] = [
   {
@@ -45,6 +46,29 @@ export const clickhouseSetting: PeerSetting[] = [
     tips: 'If you are using a non-TLS connection for ClickHouse server, check this box.',
     optional: true,
   },
+  {
+    label: 'Native Insert?',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, nativeInsert: value as boolean })),
+    type: 'switch',
+    tips: 'Insert batches directly over the native protocol instead of staging them on S3. Suited to small mirrors or deployments without object storage.',
+    optional: true,
+  },
+  {
+    label: 'Cluster',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, cluster: value as string })),
+    tips: 'Name of the cluster in remote_servers. Tables are created and altered ON CLUSTER, leave empty for single nodes and ClickHouse Cloud.',
+    optional: true,
+  },
+  {
+    label: 'Distributed Tables?',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, distributed: value as boolean })),
+    type: 'switch',
+    tips: 'Store data in <table>_local on every shard of the cluster and create a Distributed table named <table> over them.',
+    optional: true,
+  },
   {
     label: 'Certificate',
     stateHandler: (value, setter) => {
@@ -217,6 +241,7 @@ export const clickhouseSetting: PeerSetting[] = [
     optional: true,
     s3: true,
   },
+  ...tlsSettings,
 ];
 
 export const blankClickHouseSetting: ClickhouseConfig = {
@@ -232,4 +257,7 @@ export const blankClickHouseSetting: ClickhouseConfig = {
   disableTls: false,
   endpoint: undefined,
   tlsHost: '',
+  nativeInsert: false,
+  cluster: '',
+  distributed: false,
 };
@@ -1,9 +1,11 @@
 import { PeerConfig, PeerSetter } from '@/app/dto/PeersDTO';
+import { blankAzureBlobSetting } from './az';
 import { blankBigquerySetting } from './bq';
 import { blankClickHouseSetting } from './ch';
 import { blankEventHubGroupSetting } from './eh';
 import { blankElasticsearchSetting } from './es';
 import { blankKafkaSetting } from './ka';
+import { blankKinesisSetting } from './ki';
 import { blankMySqlSetting } from './my';
 import { blankPostgresSetting } from './pg';
 import { blankPubSubSetting } from './ps';
@@ -40,8 +42,12 @@ export function getBlankSetting(dbType: string): PeerConfig {
       return blankPubSubSetting;
     case 'KAFKA':
       return blankKafkaSetting;
+    case 'KINESIS':
+      return blankKinesisSetting;
     case 'S3':
       return blankS3Setting;
+    case 'AZURE_BLOB':
+      return blankAzureBlobSetting;
     case 'EVENTHUBS':
       return blankEventHubGroupSetting;
     case 'ELASTICSEARCH':
@@ -2,8 +2,11 @@ import {
   ElasticsearchAuthType,
   elasticsearchAuthTypeFromJSON,
   ElasticsearchConfig,
+  ElasticsearchRollover,
+  elasticsearchRolloverFromJSON,
 } from '@/grpc_generated/peers';
 import { PeerSetting } from './common';
+import { tlsSettings } from './tls';
 
 export const esSetting: PeerSetting[] = [
   {
@@ -27,6 +30,7 @@ export const esSetting: PeerSetting[] = [
       { value: 'NONE', label: 'None' },
       { value: 'BASIC', label: 'Basic' },
       { value: 'APIKEY', label: 'API Key' },
+      { value: 'AWS_SIGV4', label: 'AWS SigV4' },
     ],
   },
   // remaining fields are optional but displayed conditionally so not optional style wise
@@ -47,6 +51,84 @@ export const esSetting: PeerSetting[] = [
     stateHandler: (value, setter) =>
       setter((curr) => ({ ...curr, apiKey: value as string })),
   },
+  {
+    label: 'Region',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, region: value as string })),
+    tips: 'Region of the Amazon OpenSearch Service domain or collection.',
+  },
+  {
+    label: 'Access Key ID',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, accessKeyId: value as string })),
+    tips: 'Leave empty to use the credentials of the environment PeerDB runs in.',
+  },
+  {
+    label: 'Secret Access Key',
+    type: 'password',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, secretAccessKey: value as string })),
+  },
+  {
+    label: 'Role ARN',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, roleArn: value as string })),
+    tips: 'Role assumed to sign requests.',
+  },
+  {
+    label: 'Serverless',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, serverless: value as boolean })),
+    type: 'switch',
+    tips: 'Sign requests for an OpenSearch Serverless collection instead of a domain.',
+    optional: true,
+  },
+  {
+    label: 'OpenSearch',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, opensearch: value as boolean })),
+    type: 'switch',
+    tips: 'Connect to OpenSearch rather than Elasticsearch, implied by AWS SigV4 authentication.',
+    optional: true,
+  },
+  {
+    label: 'Manage Indices',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, manageIndices: value as boolean })),
+    type: 'switch',
+    tips: 'Creates index templates with mappings from table schemas and writes every table through an alias named after it.',
+    optional: true,
+  },
+  {
+    label: 'Index Rollover',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        rollover: elasticsearchRolloverFromJSON(value),
+      })),
+    type: 'select',
+    placeholder: 'Select rollover interval',
+    options: [
+      { value: 'ES_ROLLOVER_NONE', label: 'None' },
+      { value: 'ES_ROLLOVER_DAY', label: 'Daily' },
+      { value: 'ES_ROLLOVER_MONTH', label: 'Monthly' },
+    ],
+    tips: 'Only used with managed indices. Starts a new index behind each alias every day or month, suited to append-only tables.',
+    optional: true,
+  },
+  {
+    label: 'Max Bulk Requests per Second',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        maxBulkRequestsPerSecond: parseInt(value as string, 10) || 0,
+      })),
+    type: 'number',
+    default: 0,
+    tips: 'Bulk requests per second shared by all mirrors writing to this peer. 0 for no limit.',
+    optional: true,
+  },
+  ...tlsSettings,
 ];
 
 export const blankElasticsearchSetting: ElasticsearchConfig = {
@@ -55,4 +137,9 @@ export const blankElasticsearchSetting: ElasticsearchConfig = {
   username: '',
   password: '',
   apiKey: '',
+  manageIndices: false,
+  rollover: ElasticsearchRollover.ES_ROLLOVER_NONE,
+  opensearch: false,
+  serverless: false,
+  maxBulkRequestsPerSecond: 0,
 };
@@ -1,5 +1,10 @@
-import { KafkaConfig } from '@/grpc_generated/peers';
+import {
+  KafkaConfig,
+  KafkaValueFormat,
+  kafkaValueFormatFromJSON,
+} from '@/grpc_generated/peers';
 import { PeerSetting } from './common';
+import { tlsSettings } from './tls';
 
 export const kaSetting: PeerSetting[] = [
   {
@@ -53,6 +58,22 @@ export const kaSetting: PeerSetting[] = [
       { value: 'Sticky', label: 'Sticky' },
     ],
   },
+  {
+    label: 'Value Format',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        valueFormat: kafkaValueFormatFromJSON(value),
+      })),
+    type: 'select',
+    placeholder: 'Select a value format',
+    tips: 'Debezium produces json envelopes of before & after images keyed by the primary key, in place of a script.',
+    options: [
+      { value: 'KAFKA_VALUE_SCRIPT', label: 'Script' },
+      { value: 'KAFKA_VALUE_DEBEZIUM', label: 'Debezium' },
+    ],
+    optional: true,
+  },
   {
     label: 'Disable TLS?',
     stateHandler: (value, setter) =>
@@ -61,6 +82,7 @@ export const kaSetting: PeerSetting[] = [
     tips: 'If you are using a non-TLS connection for Kafka server, check this box.',
     optional: true,
   },
+  ...tlsSettings,
 ];
 
 export const blankKafkaSetting: KafkaConfig = {
@@ -70,4 +92,5 @@ export const blankKafkaSetting: KafkaConfig = {
   sasl: 'PLAIN',
   partitioner: '',
   disableTls: false,
+  valueFormat: KafkaValueFormat.KAFKA_VALUE_SCRIPT,
 };
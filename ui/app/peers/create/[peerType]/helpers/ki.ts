@@ -0,0 +1,54 @@
+import { KinesisConfig } from '@/grpc_generated/peers';
+import { PeerSetting } from './common';
+
+export const kinesisSetting: PeerSetting[] = [
+  {
+    label: 'Region',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, region: value as string })),
+    tips: 'The AWS region of your Kinesis data streams.',
+  },
+  {
+    label: 'Access Key ID',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, accessKeyId: value as string })),
+    optional: true,
+    tips: 'Leave empty to use the credentials of the PeerDB deployment.',
+  },
+  {
+    label: 'Secret Access Key',
+    type: 'password',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, secretAccessKey: value as string })),
+    optional: true,
+  },
+  {
+    label: 'Role ARN',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, roleArn: value as string })),
+    optional: true,
+    tips: 'IAM role assumed to put records.',
+  },
+  {
+    label: 'Endpoint',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, endpoint: value as string })),
+    optional: true,
+    tips: 'Endpoint of a Kinesis compatible service, defaults to AWS.',
+  },
+  {
+    label: 'Disable aggregation?',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, disableAggregation: value as boolean })),
+    type: 'switch',
+    optional: true,
+    tips: 'Records are aggregated in the KPL format by default. Check this box if your consumers cannot deaggregate records.',
+    helpfulLink:
+      'https://docs.aws.amazon.com/streams/latest/dev/kinesis-kpl-concepts.html#kinesis-kpl-concepts-aggretation',
+  },
+];
+
+export const blankKinesisSetting: KinesisConfig = {
+  region: '',
+  disableAggregation: false,
+};
@@ -12,6 +12,7 @@ import {
 } from '@/grpc_generated/peers';
 
 import { PeerSetting } from './common';
+import { tlsSettings } from './tls';
 
 export const mysqlSetting: PeerSetting[] = [
   {
@@ -232,6 +233,7 @@ export const mysqlSetting: PeerSetting[] = [
     type: 'password',
     tips: 'AWS Secret Access Key',
   },
+  ...tlsSettings,
 ];
 
 export const blankMySqlSetting: MySqlConfig = {
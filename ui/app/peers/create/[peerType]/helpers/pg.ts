@@ -8,6 +8,7 @@ import {
 } from '@/grpc_generated/peers';
 
 import { PeerSetting } from './common';
+import { tlsSettings } from './tls';
 
 export const postgresSetting: PeerSetting[] = [
   {
@@ -193,6 +194,7 @@ export const postgresSetting: PeerSetting[] = [
     type: 'password',
     tips: 'AWS Secret Access Key',
   },
+  ...tlsSettings,
 ];
 
 export const blankPostgresSetting: PostgresConfig = {
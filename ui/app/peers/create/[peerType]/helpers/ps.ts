@@ -13,4 +13,7 @@ export const blankPubSubSetting: PubSubConfig = {
     authProviderX509CertUrl: '',
     clientX509CertUrl: '',
   },
+  orderingKeyFromPrimaryKey: false,
+  metadataAttributes: false,
+  createTopics: false,
 };
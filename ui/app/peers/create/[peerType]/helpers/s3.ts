@@ -1,4 +1,12 @@
-import { AvroCodec, S3Config, avroCodecFromJSON } from '@/grpc_generated/peers';
+import {
+  AvroCodec,
+  HudiTableType,
+  S3Config,
+  S3FileFormat,
+  avroCodecFromJSON,
+  hudiTableTypeFromJSON,
+  s3FileFormatFromJSON,
+} from '@/grpc_generated/peers';
 import { PeerSetting } from './common';
 
 export const s3Setting: PeerSetting[] = [
@@ -6,7 +14,7 @@ export const s3Setting: PeerSetting[] = [
     label: 'Bucket URL',
     stateHandler: (value, setter) =>
       setter((curr) => ({ ...curr, url: value as string })),
-    tips: 'The URL of your existing S3/GCS bucket along with a prefix of your choice. It begins with s3://',
+    tips: 'The URL of your existing S3/GCS bucket along with a prefix of your choice. It begins with s3://, or gs:// for GCS',
     helpfulLink:
       'https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-bucket-intro.html#accessing-a-bucket-using-S3-format',
     default: 's3://<bucket_name>/<prefix_name>',
@@ -15,7 +23,8 @@ export const s3Setting: PeerSetting[] = [
     label: 'Access Key ID',
     stateHandler: (value, setter) =>
       setter((curr) => ({ ...curr, accessKeyId: value as string })),
-    tips: 'The AWS access key ID associated with your account. In case of GCS, this is the HMAC access key ID.',
+    tips: 'The AWS access key ID associated with your account. In case of GCS, this is the HMAC access key ID, leave empty to use workload identity.',
+    optional: true,
     helpfulLink:
       'https://docs.aws.amazon.com/IAM/latest/UserGuide/id_credentials_access-keys.html',
   },
@@ -23,7 +32,8 @@ export const s3Setting: PeerSetting[] = [
     label: 'Secret Access Key',
     stateHandler: (value, setter) =>
       setter((curr) => ({ ...curr, secretAccessKey: value as string })),
-    tips: 'The AWS secret access key associated with your account. In case of GCS, this is the HMAC secret.',
+    tips: 'The AWS secret access key associated with your account. In case of GCS, this is the HMAC secret, leave empty to use workload identity.',
+    optional: true,
     helpfulLink:
       'https://docs.aws.amazon.com/IAM/latest/UserGuide/id_credentials_access-keys.html',
   },
@@ -74,6 +84,42 @@ export const s3Setting: PeerSetting[] = [
     tips: 'Overrides expected hostname during tls cert verification.',
     optional: true,
   },
+  {
+    label: 'File Format',
+    field: 'fileFormat',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        fileFormat: s3FileFormatFromJSON(value),
+      })),
+    type: 'select',
+    placeholder: 'Select file format',
+    options: [
+      { value: 'S3_AVRO', label: 'Avro' },
+      { value: 'S3_PARQUET', label: 'Parquet' },
+      { value: 'S3_HUDI', label: 'Hudi' },
+      { value: 'S3_CSV', label: 'CSV' },
+      { value: 'S3_JSONL', label: 'JSON Lines' },
+    ],
+    tips: 'Format of the snapshot and CDC files written to the bucket. Hudi keeps a table per destination table that CDC upserts into.',
+  },
+  {
+    label: 'Hudi Table Type',
+    field: 'hudiTableType',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        hudiTableType: hudiTableTypeFromJSON(value),
+      })),
+    type: 'select',
+    placeholder: 'Select table type',
+    options: [
+      { value: 'HUDI_COPY_ON_WRITE', label: 'Copy on write' },
+      { value: 'HUDI_MERGE_ON_READ', label: 'Merge on read' },
+    ],
+    tips: 'Only used with Hudi. Copy on write rewrites files on every change, merge on read appends changes to log files which readers merge.',
+    optional: true,
+  },
   {
     label: 'Avro Codec',
     field: 'codec',
@@ -87,6 +133,71 @@ export const s3Setting: PeerSetting[] = [
       { value: 'Snappy', label: 'Snappy' },
       { value: 'ZStandard', label: 'ZStandard' },
     ],
+    tips: 'Compression of the written files. Parquet, CSV and JSON Lines files use gzip for Deflate, Snappy is not supported for CSV and JSON Lines.',
+  },
+  {
+    label: 'CSV Delimiter',
+    field: 'csvDelimiter',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, csvDelimiter: value as string })),
+    tips: 'Only used with CSV. A single character separating fields, defaults to a comma.',
+    optional: true,
+  },
+  {
+    label: 'CSV Null String',
+    field: 'csvNullString',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, csvNullString: value as string })),
+    tips: 'Only used with CSV. How null values are written, defaults to an empty field.',
+    optional: true,
+  },
+  {
+    label: 'CSV Header',
+    field: 'csvHeader',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, csvHeader: value as boolean })),
+    type: 'switch',
+    tips: 'Only used with CSV. Writes column names as the first line of every file.',
+    optional: true,
+  },
+  {
+    label: 'Transfer Acceleration',
+    field: 'useAccelerate',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, useAccelerate: value as boolean })),
+    type: 'switch',
+    tips: 'Uses S3 Transfer Acceleration, which must be enabled on the bucket. Ignored when an endpoint is set.',
+    optional: true,
+  },
+  {
+    label: 'SSE-KMS Key ARN',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        sseKmsKeyArn: (value as string) || undefined,
+      })),
+    tips: 'Objects are encrypted with this KMS key. The default encryption of the bucket applies when empty.',
+    optional: true,
+  },
+  {
+    label: 'SSE-C Key',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        sseCustomerKey: (value as string) || undefined,
+      })),
+    type: 'password',
+    tips: 'Base64 encoded 256-bit key to encrypt objects with SSE-C. Cannot be combined with a KMS key.',
+    optional: true,
+  },
+  {
+    label: 'Bucket Owner Full Control',
+    field: 'bucketOwnerFullControl',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, bucketOwnerFullControl: value as boolean })),
+    type: 'switch',
+    tips: 'Writes objects with the bucket-owner-full-control ACL, needed when the bucket belongs to another account.',
+    optional: true,
   },
 ];
 
@@ -100,4 +211,13 @@ export const blankS3Setting: S3Config = {
   rootCa: undefined,
   tlsHost: '',
   codec: AvroCodec.Null,
+  fileFormat: S3FileFormat.S3_AVRO,
+  hudiTableType: HudiTableType.HUDI_COPY_ON_WRITE,
+  csvDelimiter: '',
+  csvHeader: false,
+  csvNullString: '',
+  useAccelerate: false,
+  sseKmsKeyArn: undefined,
+  sseCustomerKey: undefined,
+  bucketOwnerFullControl: false,
 };
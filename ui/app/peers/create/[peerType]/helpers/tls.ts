@@ -0,0 +1,70 @@
+import { PeerConfig, PeerSetter } from '@/app/dto/PeersDTO';
+import { TlsConfig, tlsVersionFromJSON } from '@/grpc_generated/peers';
+import { PeerSetting } from './common';
+
+const blankTlsSetting: TlsConfig = {
+  minVersion: 0,
+  serverName: '',
+};
+
+function setTls(setter: PeerSetter, update: Partial<TlsConfig>) {
+  setter(
+    (curr) =>
+      ({
+        ...curr,
+        tls: {
+          ...blankTlsSetting,
+          ...(curr as { tls?: TlsConfig }).tls,
+          ...update,
+        },
+      }) as PeerConfig
+  );
+}
+
+// shared by peers whose connections honor the tls block of their config
+export const tlsSettings: PeerSetting[] = [
+  {
+    label: 'TLS: Client Certificate',
+    stateHandler: (value, setter) =>
+      setTls(setter, { certificate: (value as string) || undefined }),
+    type: 'file',
+    optional: true,
+    tips: 'PEM encoded certificate presented to the server for mutual TLS.',
+  },
+  {
+    label: 'TLS: Client Private Key',
+    stateHandler: (value, setter) =>
+      setTls(setter, { privateKey: (value as string) || undefined }),
+    type: 'file',
+    optional: true,
+    tips: 'PEM encoded private key of the client certificate.',
+  },
+  {
+    label: 'TLS: CA Bundle',
+    stateHandler: (value, setter) =>
+      setTls(setter, { rootCa: (value as string) || undefined }),
+    type: 'file',
+    optional: true,
+    tips: 'PEM encoded CA certificates the server certificate is verified against. If not provided, host CA roots will be used.',
+  },
+  {
+    label: 'TLS: Minimum Version',
+    stateHandler: (value, setter) =>
+      setTls(setter, { minVersion: tlsVersionFromJSON(value) }),
+    type: 'select',
+    placeholder: 'Select a minimum version',
+    options: [
+      { value: 'TLS_VERSION_DEFAULT', label: 'Default' },
+      { value: 'TLS_VERSION_1_2', label: 'TLS 1.2' },
+      { value: 'TLS_VERSION_1_3', label: 'TLS 1.3' },
+    ],
+    optional: true,
+  },
+  {
+    label: 'TLS: Server Name',
+    stateHandler: (value, setter) =>
+      setTls(setter, { serverName: value as string }),
+    optional: true,
+    tips: 'Sent for SNI and expected during certificate verification, overrides the host.',
+  },
+];
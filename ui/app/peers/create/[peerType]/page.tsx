@@ -1,9 +1,11 @@
 'use client';
 import { PeerConfig } from '@/app/dto/PeersDTO';
 import GuideForDestinationSetup from '@/app/mirrors/create/cdc/guide';
+import AzureBlobForm from '@/components/PeerForms/AzureBlobForm';
 import BigqueryForm from '@/components/PeerForms/BigqueryConfig';
 import ClickHouseForm from '@/components/PeerForms/ClickhouseConfig';
 import KafkaForm from '@/components/PeerForms/KafkaConfig';
+import KinesisForm from '@/components/PeerForms/KinesisConfig';
 import MySqlForm from '@/components/PeerForms/MySqlForm';
 import PostgresForm from '@/components/PeerForms/PostgresForm';
 import PubSubForm from '@/components/PeerForms/PubSubConfig';
@@ -101,8 +103,12 @@ export default function CreateConfig({
         );
       case 'S3':
         return <S3Form setter={setConfig} />;
+      case 'AZURE_BLOB':
+        return <AzureBlobForm setter={setConfig} />;
       case 'KAFKA':
         return <KafkaForm setter={setConfig} />;
+      case 'KINESIS':
+        return <KinesisForm setter={setConfig} />;
       case 'PUBSUB':
         return <PubSubForm setter={setConfig} />;
       case 'EVENTHUBS':
@@ -2,8 +2,11 @@ import { ehSchema } from '@/components/PeerForms/Eventhubs/schema';
 import {
   AvroCodec,
   ElasticsearchAuthType,
+  ElasticsearchRollover,
+  HudiTableType,
   MySqlFlavor,
   MySqlReplicationMechanism,
+  S3FileFormat,
 } from '@/grpc_generated/peers';
 import * as z from 'zod/v4';
 
@@ -350,6 +353,10 @@ export const bqSchema = z.object({
     })
     .min(1, { message: 'Dataset ID must be non-empty' })
     .max(1024, 'DatasetID must be less than 1025 characters'),
+  maxLoadJobsPerDay: z
+    .int({ error: () => 'Max load jobs per day must be a number' })
+    .min(0, 'Max load jobs per day cannot be negative')
+    .optional(),
 });
 
 export function chSchema(hostDomains: string[]) {
@@ -416,6 +423,11 @@ export function chSchema(hostDomains: string[]) {
     region: z.string({ error: () => 'Region must be a string' }).optional(),
     endpoint: z.string({ error: () => 'Endpoint must be a string' }).optional(),
     disableTls: z.boolean(),
+    nativeInsert: z.boolean(),
+    cluster: z
+      .string({ error: () => 'Cluster must be a string' })
+      .max(255, 'Cluster must be less than 255 characters'),
+    distributed: z.boolean(),
     certificate: z
       .string({
         error: () => 'Certificate must be a string',
@@ -435,6 +447,7 @@ export function chSchema(hostDomains: string[]) {
       .optional()
       .transform((e) => (e === '' ? undefined : e)),
     tlsHost: z.string(),
+    sshConfig: sshSchema,
   });
 }
 
@@ -486,8 +499,8 @@ const urlSchema = z
       issue.input === undefined ? 'URL is required' : 'URL must be a string',
   })
   .min(1, { message: 'URL must be non-empty' })
-  .refine((url) => url.startsWith('s3://'), {
-    message: 'URL must start with s3://',
+  .refine((url) => url.startsWith('s3://') || url.startsWith('gs://'), {
+    message: 'URL must start with s3:// or gs://',
   });
 
 const accessKeySchema = z
@@ -512,29 +525,136 @@ const regionSchema = z.string({
   error: () => 'Region must be a string',
 });
 
-export const s3Schema = z.object({
-  url: urlSchema,
-  accessKeyId: accessKeySchema,
-  secretAccessKey: secretKeySchema,
-  roleArn: z
+export const s3Schema = z
+  .object({
+    url: urlSchema,
+    accessKeyId: accessKeySchema.optional(),
+    secretAccessKey: secretKeySchema.optional(),
+    roleArn: z
+      .string({
+        error: () => 'Role ARN must be a string',
+      })
+      .optional(),
+    region: regionSchema.optional(),
+    endpoint: z
+      .string({
+        error: () => 'Endpoint must be a string',
+      })
+      .optional(),
+    codec: z.enum(AvroCodec, {
+      error: (issue) =>
+        issue.input === undefined
+          ? 'Avro codec is required'
+          : 'Avro codec must be one of [Null,Deflate,Snappy,ZStandard]',
+    }),
+    fileFormat: z.enum(S3FileFormat, {
+      error: () =>
+        'File format must be one of [S3_AVRO,S3_PARQUET,S3_HUDI,S3_CSV,S3_JSONL]',
+    }),
+    hudiTableType: z.enum(HudiTableType, {
+      error: () =>
+        'Hudi table type must be one of [HUDI_COPY_ON_WRITE,HUDI_MERGE_ON_READ]',
+    }),
+    csvDelimiter: z
+      .string({ error: () => 'CSV delimiter must be a string' })
+      .max(1, { message: 'CSV delimiter must be a single character' })
+      .optional(),
+    csvHeader: z.boolean().optional(),
+    csvNullString: z
+      .string({ error: () => 'CSV null string must be a string' })
+      .optional(),
+    useAccelerate: z.boolean().optional(),
+    sseKmsKeyArn: z
+      .string({ error: () => 'SSE-KMS key ARN must be a string' })
+      .optional(),
+    sseCustomerKey: z
+      .string({ error: () => 'SSE-C key must be a string' })
+      .optional(),
+    bucketOwnerFullControl: z.boolean().optional(),
+  })
+  .refine((config) => !(config.sseKmsKeyArn && config.sseCustomerKey), {
+    message: 'SSE-KMS and SSE-C cannot be used together',
+    path: ['sseCustomerKey'],
+  })
+  // gs:// buckets fall back to workload identity without HMAC keys
+  .refine(
+    (config) =>
+      config.url.startsWith('gs://') ||
+      (!!config.accessKeyId && !!config.secretAccessKey),
+    {
+      message: 'Access Key ID and Secret Access Key are required for s3://',
+      path: ['accessKeyId'],
+    }
+  );
+
+export const azSchema = z.object({
+  url: z
     .string({
-      error: () => 'Role ARN must be a string',
+      error: (issue) =>
+        issue.input === undefined ? 'URL is required' : 'URL must be a string',
+    })
+    .refine(
+      (url) =>
+        url.startsWith('azure://') ||
+        url.startsWith('abfss://') ||
+        url.startsWith('abfs://'),
+      {
+        message: 'URL must start with azure:// or abfss://',
+      }
+    ),
+  sasToken: z
+    .string({
+      error: () => 'SAS token must be a string',
     })
     .optional(),
-  region: regionSchema.optional(),
-  endpoint: z
+  clientId: z
     .string({
-      error: () => 'Endpoint must be a string',
+      error: () => 'Client ID must be a string',
     })
     .optional(),
   codec: z.enum(AvroCodec, {
     error: (issue) =>
       issue.input === undefined
-        ? 'Avro codec is required'
-        : 'Avro codec must be one of [Null,Deflate,Snappy,ZStandard]',
+        ? 'Codec is required'
+        : 'Codec must be one of [Null,Deflate,Snappy,ZStandard]',
+  }),
+  fileFormat: z.enum(S3FileFormat, {
+    error: () => 'File format must be one of [S3_AVRO,S3_PARQUET]',
   }),
 });
 
+export const kiSchema = z.object({
+  region: z
+    .string({
+      error: (issue) =>
+        issue.input === undefined
+          ? 'Region is required'
+          : 'Region must be a string',
+    })
+    .min(1, { message: 'Region must be non-empty' }),
+  accessKeyId: z
+    .string({
+      error: () => 'Access Key ID must be a string',
+    })
+    .optional(),
+  secretAccessKey: z
+    .string({
+      error: () => 'Secret Access Key must be a string',
+    })
+    .optional(),
+  roleArn: z
+    .string({
+      error: () => 'Role ARN must be a string',
+    })
+    .optional(),
+  endpoint: z
+    .string({
+      error: () => 'Endpoint must be a string',
+    })
+    .optional(),
+  disableAggregation: z.boolean().optional(),
+});
+
 export const psSchema = z.object({
   serviceAccount: z.object({
     authType: z
@@ -646,11 +766,27 @@ export const esSchema = z
       error: (issue) =>
         issue.input === undefined
           ? 'Auth type cannot be empty'
-          : 'Auth type must be one of [none,basic,apikey]',
+          : 'Auth type must be one of [none,basic,apikey,aws_sigv4]',
     }),
     username: z.string({ error: () => 'Username must be a string' }).optional(),
     password: z.string({ error: () => 'Password must be a string' }).optional(),
     apiKey: z.string({ error: () => 'API key must be a string' }).optional(),
+    manageIndices: z.boolean().optional(),
+    rollover: z.enum(ElasticsearchRollover).optional(),
+    opensearch: z.boolean().optional(),
+    region: z.string({ error: () => 'Region must be a string' }).optional(),
+    accessKeyId: z
+      .string({ error: () => 'Access Key ID must be a string' })
+      .optional(),
+    secretAccessKey: z
+      .string({ error: () => 'Secret Access Key must be a string' })
+      .optional(),
+    roleArn: z.string({ error: () => 'Role ARN must be a string' }).optional(),
+    serverless: z.boolean().optional(),
+    maxBulkRequestsPerSecond: z
+      .int({ error: () => 'Max bulk requests per second must be a number' })
+      .min(0, 'Max bulk requests per second cannot be negative')
+      .optional(),
   })
   .refine(
     (esSchema) => {
@@ -666,7 +802,10 @@ export const esSchema = z
           !isString(esSchema.password) &&
           isString(esSchema.apiKey)
         );
-      } else if (esSchema.authType === ElasticsearchAuthType.NONE) {
+      } else if (
+        esSchema.authType === ElasticsearchAuthType.NONE ||
+        esSchema.authType === ElasticsearchAuthType.AWS_SIGV4
+      ) {
         return (
           !isString(esSchema.username) &&
           !isString(esSchema.password) &&
@@ -1,8 +1,13 @@
 'use client';
 
-import { DynconfApplyMode, DynconfValueType } from '@/grpc_generated/flow';
+import {
+  DynconfApplyMode,
+  DynconfScope,
+  DynconfValueType,
+} from '@/grpc_generated/flow';
 import {
   DynamicSetting,
+  DynamicSettingOverride,
   GetDynamicSettingsResponse,
 } from '@/grpc_generated/route';
 import { Button } from '@/lib/Button';
@@ -112,6 +117,22 @@ function DynamicSettingItem({
     onSettingUpdate();
   };
 
+  const removeOverride = async (override: DynamicSettingOverride) => {
+    const res = await fetch('/api/v1/dynamic_settings', {
+      method: 'POST',
+      body: JSON.stringify({
+        name: setting.name,
+        scope: override.scope,
+        scopeName: override.scopeName,
+      }),
+    });
+    if (!res.ok) {
+      notifyErr(`Failed to remove override: ${await res.text()}`);
+      return;
+    }
+    onSettingUpdate();
+  };
+
   return (
     <div
       style={{
@@ -163,6 +184,26 @@ function DynamicSettingItem({
               Default:<b> {setting.defaultValue || 'N/A'} </b>
             </Label>
           </div>
+          {setting.overrides.map((override) => (
+            <div
+              key={`${override.scope}/${override.scopeName}`}
+              style={{ display: 'flex', alignItems: 'center' }}
+            >
+              <Label style={{ padding: 0, fontSize: 14 }}>
+                {override.scope.toString() ===
+                DynconfScope[DynconfScope.SCOPE_PEER].toString()
+                  ? 'Peer'
+                  : 'Mirror'}{' '}
+                {override.scopeName}:<b> {override.value} </b>
+              </Label>
+              <Button
+                variant='normalBorderless'
+                onClick={() => removeOverride(override)}
+              >
+                <Icon name='delete' />
+              </Button>
+            </div>
+          ))}
           <div>
             <ApplyModeIconWithTooltip applyMode={setting.applyMode || 0} />
           </div>
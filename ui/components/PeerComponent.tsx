@@ -36,6 +36,9 @@ export function DBTypeToImageMapping(peerType: DBType | string) {
     case DBType.S3:
     case 'S3':
       return '/svgs/aws.svg';
+    case DBType.AZURE_BLOB:
+    case 'AZURE_BLOB':
+      return '/svgs/ms.svg';
     case DBType.CLICKHOUSE:
     case 'CLICKHOUSE':
       return '/svgs/ch.svg';
@@ -49,6 +52,9 @@ export function DBTypeToImageMapping(peerType: DBType | string) {
     case DBType.PUBSUB:
     case 'PUBSUB':
       return '/svgs/pubsub.svg';
+    case DBType.KINESIS:
+    case 'KINESIS':
+      return '/svgs/aws.svg';
     case DBType.EVENTHUBS:
     case 'EVENTHUBS':
       return '/svgs/ms.svg';
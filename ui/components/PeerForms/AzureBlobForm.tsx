@@ -0,0 +1,84 @@
+'use client';
+import { PeerSetter } from '@/app/dto/PeersDTO';
+import { azureBlobSetting } from '@/app/peers/create/[peerType]/helpers/az';
+import SelectTheme from '@/app/styles/select';
+import InfoPopover from '@/components/InfoPopover';
+import { Label } from '@/lib/Label';
+import { RowWithSelect, RowWithTextField } from '@/lib/Layout';
+import { TextField } from '@/lib/TextField';
+import { Tooltip } from '@/lib/Tooltip';
+import ReactSelect from 'react-select';
+import { handleFieldChange } from './common';
+
+interface AzureBlobProps {
+  setter: PeerSetter;
+}
+
+export default function AzureBlobForm({ setter }: AzureBlobProps) {
+  return (
+    <div>
+      <Label>
+        PeerDB writes Avro or Parquet files to Azure Blob Storage and ADLS Gen2
+        containers, authenticating with a SAS token or managed identity.
+      </Label>
+      {azureBlobSetting.map((setting, index) =>
+        setting.type === 'select' ? (
+          <RowWithSelect
+            key={index}
+            label={<Label>{setting.label}</Label>}
+            action={
+              <ReactSelect
+                placeholder={setting.placeholder}
+                onChange={(val) =>
+                  val && setting.stateHandler(val.value, setter)
+                }
+                options={setting.options}
+                theme={SelectTheme}
+              />
+            }
+          />
+        ) : (
+          <RowWithTextField
+            key={index}
+            label={
+              <Label>
+                {setting.label}{' '}
+                {!setting.optional && (
+                  <Tooltip
+                    style={{ width: '100%' }}
+                    content='This is a required field.'
+                  >
+                    <Label colorName='lowContrast' colorSet='destructive'>
+                      *
+                    </Label>
+                  </Tooltip>
+                )}
+              </Label>
+            }
+            action={
+              <div
+                style={{
+                  display: 'flex',
+                  flexDirection: 'row',
+                  alignItems: 'center',
+                }}
+              >
+                <TextField
+                  variant='simple'
+                  type={setting.type}
+                  defaultValue={setting.default}
+                  onChange={(e: React.ChangeEvent<HTMLInputElement>) =>
+                    handleFieldChange(e, setting, setter)
+                  }
+                />
+                {setting.tips && (
+                  <InfoPopover tips={setting.tips} link={setting.helpfulLink} />
+                )}
+              </div>
+            }
+          />
+        )
+      )}
+    </div>
+  );
+}
@@ -15,6 +15,7 @@ interface BQProps {
 }
 export default function BigqueryForm(props: BQProps) {
   const [datasetID, setDatasetID] = useState<string>('');
+  const [maxLoadJobsPerDay, setMaxLoadJobsPerDay] = useState<number>(0);
   const handleJSONFile = (file: File) => {
     if (file) {
       const reader = new FileReader();
@@ -40,6 +41,7 @@ export default function BigqueryForm(props: BQProps) {
           authProviderX509CertUrl: bqJson.auth_provider_x509_cert_url,
           clientX509CertUrl: bqJson.client_x509_cert_url,
           datasetId: datasetID,
+          maxLoadJobsPerDay,
         };
         props.setter(bqConfig);
       };
@@ -127,6 +129,37 @@ export default function BigqueryForm(props: BQProps) {
           </div>
         }
       />
+
+      <RowWithTextField
+        label={<Label>Max Load Jobs per Day</Label>}
+        action={
+          <div
+            style={{
+              display: 'flex',
+              flexDirection: 'row',
+              alignItems: 'center',
+            }}
+          >
+            <TextField
+              variant='simple'
+              type='number'
+              defaultValue={0}
+              onChange={(e: React.ChangeEvent<HTMLInputElement>) => {
+                const value = parseInt(e.target.value, 10) || 0;
+                setMaxLoadJobsPerDay(value);
+                props.setter((curr) => ({
+                  ...curr,
+                  maxLoadJobsPerDay: value,
+                }));
+              }}
+            />
+            <InfoPopover
+              tips='Load jobs per day shared by all mirrors writing to this peer, to stay within the table and project quotas. 0 for no limit.'
+              link='https://cloud.google.com/bigquery/quotas#load_jobs'
+            />
+          </div>
+        }
+      />
     </>
   );
 }
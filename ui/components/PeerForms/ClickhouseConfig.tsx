@@ -1,7 +1,12 @@
 'use client';
 import { PeerSetter } from '@/app/dto/PeersDTO';
 import { PeerSetting } from '@/app/peers/create/[peerType]/helpers/common';
+import {
+  blankSSHConfig,
+  sshSetting,
+} from '@/app/peers/create/[peerType]/helpers/ssh';
 import InfoPopover from '@/components/InfoPopover';
+import { SSHConfig } from '@/grpc_generated/peers';
 import { Button } from '@/lib/Button/Button';
 import { Icon } from '@/lib/Icon/Icon';
 import { Label } from '@/lib/Label';
@@ -10,8 +15,8 @@ import { Switch } from '@/lib/Switch';
 import { TextField } from '@/lib/TextField';
 import { Tooltip } from '@/lib/Tooltip';
 import Link from 'next/link';
-import { useState } from 'react';
-import { handleFieldChange } from './common';
+import { useEffect, useState } from 'react';
+import { handleFieldChange, handleSSHParam } from './common';
 
 interface ConfigProps {
   settings: PeerSetting[];
@@ -20,6 +25,15 @@ interface ConfigProps {
 
 export default function ClickHouseForm({ settings, setter }: ConfigProps) {
   const [show, setShow] = useState(false);
+  const [showSSH, setShowSSH] = useState(false);
+  const [sshConfig, setSSHConfig] = useState(blankSSHConfig);
+
+  useEffect(() => {
+    setter((prev) => ({
+      ...prev,
+      sshConfig: showSSH ? sshConfig : undefined,
+    }));
+  }, [sshConfig, setter, showSSH]);
 
   return (
     <>
@@ -111,6 +125,75 @@ export default function ClickHouseForm({ settings, setter }: ConfigProps) {
           );
         })}
 
+      <Label
+        as='label'
+        style={{ marginTop: '1rem', display: 'block' }}
+        variant='subheadline'
+        colorName='lowContrast'
+      >
+        SSH Configuration
+      </Label>
+      <Label>
+        You may provide SSH configuration to connect to your ClickHouse server
+        through SSH tunnel.
+      </Label>
+      <div style={{ width: '50%', display: 'flex', alignItems: 'center' }}>
+        <Label variant='subheadline'>Configure SSH Tunnel</Label>
+        <Switch onCheckedChange={(state) => setShowSSH(state)} />
+      </div>
+      {showSSH &&
+        sshSetting.map((sshParam, index) => (
+          <RowWithTextField
+            key={index}
+            label={
+              <Label>
+                {sshParam.label}{' '}
+                {!sshParam.optional && (
+                  <Tooltip
+                    style={{ width: '100%' }}
+                    content='This is a required field.'
+                  >
+                    <Label colorName='lowContrast' colorSet='destructive'>
+                      *
+                    </Label>
+                  </Tooltip>
+                )}
+              </Label>
+            }
+            action={
+              <div
+                style={{
+                  display: 'flex',
+                  flexDirection: 'row',
+                  alignItems: 'center',
+                }}
+              >
+                <TextField
+                  variant='simple'
+                  onChange={(e: React.ChangeEvent<HTMLInputElement>) =>
+                    handleSSHParam(e, sshParam, setSSHConfig)
+                  }
+                  style={{
+                    border: sshParam.type === 'file' ? 'none' : 'auto',
+                    height: sshParam.type === 'textarea' ? '15rem' : 'auto',
+                  }}
+                  type={sshParam.type}
+                  defaultValue={
+                    (sshConfig as SSHConfig)[
+                      sshParam.label === 'SSH Private Key'
+                        ? 'privateKey'
+                        : sshParam.label === "Host's Public Key"
+                          ? 'hostKey'
+                          : (sshParam.label.toLowerCase() as keyof SSHConfig)
+                    ] || ''
+                  }
+                />
+                {sshParam.tips && <InfoPopover tips={sshParam.tips} />}
+              </div>
+            }
+          />
+        ))}
+
       <Label variant='subheadline' as='label' style={{ marginTop: '2rem' }}>
         Transient S3 Stage (Optional)
       </Label>
@@ -7,7 +7,8 @@ import {
   ElasticsearchConfig,
 } from '@/grpc_generated/peers';
 import { Label } from '@/lib/Label';
-import { RowWithSelect, RowWithTextField } from '@/lib/Layout';
+import { RowWithSelect, RowWithSwitch, RowWithTextField } from '@/lib/Layout';
+import { Switch } from '@/lib/Switch/Switch';
 import { TextField } from '@/lib/TextField';
 import { Tooltip } from '@/lib/Tooltip';
 import ReactSelect from 'react-select';
@@ -17,6 +18,27 @@ interface ElasticsearchProps {
   setter: PeerSetter;
 }
 
+const awsSettings = [
+  'Region',
+  'Access Key ID',
+  'Secret Access Key',
+  'Role ARN',
+  'Serverless',
+];
+
+function isVisible(label: string, config: ElasticsearchConfig): boolean {
+  if (label === 'Username' || label === 'Password') {
+    return config.authType === ElasticsearchAuthType.BASIC;
+  } else if (label === 'API Key') {
+    return config.authType === ElasticsearchAuthType.APIKEY;
+  } else if (awsSettings.includes(label)) {
+    return config.authType === ElasticsearchAuthType.AWS_SIGV4;
+  } else if (label === 'Index Rollover') {
+    return config.manageIndices;
+  }
+  return true;
+}
+
 export default function ElasticsearchConfigForm({
   config,
   setter,
@@ -24,7 +46,26 @@ export default function ElasticsearchConfigForm({
   return (
     <div style={{ display: 'flex', flexDirection: 'column', rowGap: '0.5rem' }}>
       {esSetting.map((setting, index) => {
-        return setting.type === 'select' ? (
+        return !isVisible(setting.label, config) ? (
+          <></>
+        ) : setting.type === 'switch' ? (
+          <RowWithSwitch
+            key={index}
+            label={<Label>{setting.label}</Label>}
+            action={
+              <div style={{ display: 'flex', alignItems: 'center' }}>
+                <Switch
+                  onCheckedChange={(state: boolean) =>
+                    setting.stateHandler(state, setter)
+                  }
+                />
+                {setting.tips && (
+                  <InfoPopover tips={setting.tips} link={setting.helpfulLink} />
+                )}
+              </div>
+            }
+          />
+        ) : setting.type === 'select' ? (
           <RowWithSelect
             label={<Label>{setting.label}</Label>}
             action={
@@ -39,11 +80,7 @@ export default function ElasticsearchConfigForm({
               />
             }
           />
-        ) : (setting.label === 'API Key' &&
-            config.authType === ElasticsearchAuthType.APIKEY) ||
-          (setting.label !== 'API Key' &&
-            config.authType === ElasticsearchAuthType.BASIC) ||
-          setting.label === 'Addresses' ? (
+        ) : (
           <RowWithTextField
             key={index}
             label={
@@ -88,8 +125,6 @@ export default function ElasticsearchConfigForm({
               </div>
             }
           />
-        ) : (
-          <></>
         );
       })}
     </div>
@@ -0,0 +1,99 @@
+'use client';
+import { PeerSetter } from '@/app/dto/PeersDTO';
+import { kinesisSetting } from '@/app/peers/create/[peerType]/helpers/ki';
+import InfoPopover from '@/components/InfoPopover';
+import { Label } from '@/lib/Label';
+import { RowWithSwitch, RowWithTextField } from '@/lib/Layout';
+import { Switch } from '@/lib/Switch/Switch';
+import { TextField } from '@/lib/TextField';
+import { Tooltip } from '@/lib/Tooltip';
+
+interface KinesisProps {
+  setter: PeerSetter;
+}
+
+export default function KinesisForm({ setter }: KinesisProps) {
+  return (
+    <div style={{ display: 'flex', flexDirection: 'column', rowGap: '0.5rem' }}>
+      {kinesisSetting.map((setting, index) => {
+        return setting.type === 'switch' ? (
+          <RowWithSwitch
+            key={index}
+            label={
+              <Label>
+                {setting.label}{' '}
+                {!setting.optional && (
+                  <Tooltip
+                    style={{ width: '100%' }}
+                    content='This is a required field.'
+                  >
+                    <Label colorName='lowContrast' colorSet='destructive'>
+                      *
+                    </Label>
+                  </Tooltip>
+                )}
+              </Label>
+            }
+            action={
+              <div style={{ display: 'flex', alignItems: 'center' }}>
+                <Switch
+                  onCheckedChange={(state: boolean) =>
+                    setting.stateHandler(state, setter)
+                  }
+                />
+                {setting.tips && (
+                  <InfoPopover tips={setting.tips} link={setting.helpfulLink} />
+                )}
+              </div>
+            }
+          />
+        ) : (
+          <RowWithTextField
+            key={index}
+            label={
+              <Label>
+                {setting.label}{' '}
+                {!setting.optional && (
+                  <Tooltip
+                    style={{ width: '100%' }}
+                    content='This is a required field.'
+                  >
+                    <Label colorName='lowContrast' colorSet='destructive'>
+                      *
+                    </Label>
+                  </Tooltip>
+                )}
+              </Label>
+            }
+            action={
+              <div
+                style={{
+                  display: 'flex',
+                  flexDirection: 'row',
+                  alignItems: 'center',
+                }}
+              >
+                <TextField
+                  variant='simple'
+                  style={
+                    setting.type === 'file'
+                      ? { border: 'none', height: 'auto' }
+                      : { border: 'auto' }
+                  }
+                  type={setting.type}
+                  defaultValue={setting.default}
+                  onChange={(e: React.ChangeEvent<HTMLInputElement>) =>
+                    setting.stateHandler(e.target.value, setter)
+                  }
+                />
+                {setting.tips && (
+                  <InfoPopover tips={setting.tips} link={setting.helpfulLink} />
+                )}
+              </div>
+            }
+          />
+        );
+      })}
+    </div>
+  );
+}
@@ -28,6 +28,7 @@ export default function PubSubForm(props: PSProps) {
           return;
         }
         const psConfig: PubSubConfig = {
+          ...blankPubSubSetting,
           serviceAccount: {
             authType: psJson.type ?? psJson.auth_type,
             projectId: psJson.project_id,
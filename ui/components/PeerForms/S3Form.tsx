@@ -8,9 +8,11 @@ import { Label } from '@/lib/Label';
 import {
   RowWithRadiobutton,
   RowWithSelect,
+  RowWithSwitch,
   RowWithTextField,
 } from '@/lib/Layout';
 import { RadioButton, RadioButtonGroup } from '@/lib/RadioButtonGroup';
+import { Switch } from '@/lib/Switch/Switch';
 import { TextField } from '@/lib/TextField';
 import { Tooltip } from '@/lib/Tooltip';
 import { useEffect, useState } from 'react';
@@ -89,6 +91,26 @@ export default function S3Form({ setter }: S3Props) {
                 />
               }
             />
+          ) : setting.type === 'switch' ? (
+            <RowWithSwitch
+              key={index}
+              label={<Label>{setting.label}</Label>}
+              action={
+                <div style={{ display: 'flex', alignItems: 'center' }}>
+                  <Switch
+                    onCheckedChange={(state: boolean) =>
+                      setting.stateHandler(state, setter)
+                    }
+                  />
+                  {setting.tips && (
+                    <InfoPopover
+                      tips={setting.tips}
+                      link={setting.helpfulLink}
+                    />
+                  )}
+                </div>
+              }
+            />
           ) : (
             <RowWithTextField
               key={index}
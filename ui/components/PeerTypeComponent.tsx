@@ -18,6 +18,8 @@ export function DBTypeToGoodText(ptype?: DBType) {
       return 'BigQuery';
     case DBType.S3:
       return 'AWS S3';
+    case DBType.AZURE_BLOB:
+      return 'Azure Blob Storage';
     case DBType.SQLSERVER:
       return 'SQL Server';
     case DBType.MONGO:
@@ -28,6 +30,8 @@ export function DBTypeToGoodText(ptype?: DBType) {
       return 'Kafka';
     case DBType.PUBSUB:
       return 'PubSub';
+    case DBType.KINESIS:
+      return 'Kinesis';
     case DBType.ELASTICSEARCH:
       return 'Elasticsearch';
     default: